@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// maxBenchmarkWorkers caps the largest worker count tried.
+const maxBenchmarkWorkers = 64
+
+// scanBenchmarkNote explains what the warm passes measure.
+const scanBenchmarkNote = "cleanup scans keep no size cache, so warm passes gain only from the OS metadata cache"
+
+// scanBenchmark times full size scans of one path at several worker counts.
+type scanBenchmark struct {
+	Path  string `json:"path"`
+	Files int64  `json:"files"`
+	Dirs  int64  `json:"dirs"`
+	Bytes int64  `json:"bytes"`
+	// Runs holds the cold pass followed by one warm pass per worker count.
+	Runs []scanRun `json:"runs"`
+	// WarmSavedSeconds is the cold pass time minus the warm pass time at
+	// the same worker count.
+	WarmSavedSeconds float64 `json:"warm_saved_seconds"`
+	Note             string  `json:"note"`
+}
+
+// scanRun is one timed scan.
+type scanRun struct {
+	Workers        int     `json:"workers"`
+	Pass           string  `json:"pass"`
+	WallSeconds    float64 `json:"wall_seconds"`
+	FilesPerSecond float64 `json:"files_per_second"`
+	MBPerSecond    float64 `json:"mb_per_second"`
+}
+
+// scanTotals is what one scan found.
+type scanTotals struct {
+	files int64
+	dirs  int64
+	bytes int64
+}
+
+// benchmarkWorkerCounts returns powers of two up to twice the CPU count,
+// since directory scans wait on the filesystem more than on the CPU.
+func benchmarkWorkerCounts(cpus int) []int {
+	limit := min(max(2*cpus, 2), maxBenchmarkWorkers)
+	var counts []int
+	for n := 1; n <= limit; n *= 2 {
+		counts = append(counts, n)
+	}
+	return counts
+}
+
+// benchmarkScan sizes root once cold with the largest worker count, then once
+// warm per worker count. It only reads the tree.
+func benchmarkScan(ctx context.Context, root string, workers []int) (scanBenchmark, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return scanBenchmark{}, err
+	}
+	if !info.IsDir() {
+		return scanBenchmark{}, fmt.Errorf("%s is not a directory", root)
+	}
+	if len(workers) == 0 {
+		return scanBenchmark{}, fmt.Errorf("no worker counts to benchmark")
+	}
+
+	report := scanBenchmark{Path: root, Note: scanBenchmarkNote}
+	widest := workers[len(workers)-1]
+	cold, totals, err := timeScan(ctx, root, widest, "cold")
+	if err != nil {
+		return report, err
+	}
+	report.Files, report.Dirs, report.Bytes = totals.files, totals.dirs, totals.bytes
+	report.Runs = append(report.Runs, cold)
+	for _, n := range workers {
+		warm, _, err := timeScan(ctx, root, n, "warm")
+		if err != nil {
+			return report, err
+		}
+		report.Runs = append(report.Runs, warm)
+		if n == widest {
+			report.WarmSavedSeconds = cold.WallSeconds - warm.WallSeconds
+		}
+	}
+	return report, nil
+}
+
+func timeScan(ctx context.Context, root string, workers int, pass string) (scanRun, scanTotals, error) {
+	start := time.Now()
+	totals, err := scanTree(ctx, root, workers)
+	elapsed := time.Since(start)
+	if err != nil {
+		return scanRun{}, totals, err
+	}
+	run := scanRun{Workers: workers, Pass: pass, WallSeconds: elapsed.Seconds()}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		run.FilesPerSecond = float64(totals.files) / seconds
+		run.MBPerSecond = float64(totals.bytes) / (1024 * 1024) / seconds
+	}
+	return run, totals, nil
+}
+
+// scanTree sizes root with up to workers directories read concurrently,
+// without following symlinks or crossing onto other filesystems. A directory
+// is handed to a new goroutine when a worker slot is free and read inline
+// otherwise, so the walk cannot deadlock on a full pool.
+func scanTree(ctx context.Context, root string, workers int) (scanTotals, error) {
+	dev, ok := pathDevice(root)
+	if !ok {
+		return scanTotals{}, fmt.Errorf("cannot stat %s", root)
+	}
+	var files, dirs, bytes atomic.Int64
+	slots := make(chan struct{}, max(workers-1, 0))
+	var wg sync.WaitGroup
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		if ctx.Err() != nil {
+			return
+		}
+		dirs.Add(1)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				if onDevice(path, dev) {
+					select {
+					case slots <- struct{}{}:
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							defer func() { <-slots }()
+							walk(path)
+						}()
+					default:
+						walk(path)
+					}
+				}
+				continue
+			}
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files.Add(1)
+			bytes.Add(info.Size())
+		}
+	}
+	walk(root)
+	wg.Wait()
+	return scanTotals{files: files.Load(), dirs: dirs.Load(), bytes: bytes.Load()}, ctx.Err()
+}
+
+func onDevice(path string, dev uint64) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || uint64(stat.Dev) == dev
+}
+
+// runScanBenchmark is the -benchmark-scan entry point.
+func runScanBenchmark(ctx context.Context, w io.Writer, output, path string) error {
+	root, err := filepath.Abs(expandPathHome(path))
+	if err != nil {
+		return err
+	}
+	report, err := benchmarkScan(ctx, root, benchmarkWorkerCounts(runtime.NumCPU()))
+	if err != nil {
+		return err
+	}
+	return writeScanBenchmark(w, output, report)
+}
+
+func writeScanBenchmark(w io.Writer, output string, report scanBenchmark) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if _, err := fmt.Fprintln(w, "tinyland-cleanup scan benchmark"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "path: %s, %d files in %d directories, %s\n\n",
+		report.Path, report.Files, report.Dirs, formatByteCount(report.Bytes)); err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "WORKERS\tPASS\tWALL\tFILES/S\tMB/S")
+	for _, run := range report.Runs {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%.0f\t%.1f\n",
+			run.Workers,
+			run.Pass,
+			time.Duration(run.WallSeconds*float64(time.Second)).Round(time.Millisecond),
+			run.FilesPerSecond,
+			run.MBPerSecond,
+		)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	saved := time.Duration(report.WarmSavedSeconds * float64(time.Second)).Round(time.Millisecond)
+	_, err := fmt.Fprintf(w, "\nwarm second run saved %s (%s)\n", saved, report.Note)
+	return err
+}
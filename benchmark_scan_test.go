@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBenchmarkWorkerCounts(t *testing.T) {
+	if got := benchmarkWorkerCounts(1); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("one CPU: got %v", got)
+	}
+	if got := benchmarkWorkerCounts(6); !reflect.DeepEqual(got, []int{1, 2, 4, 8}) {
+		t.Fatalf("six CPUs: got %v", got)
+	}
+	if got := benchmarkWorkerCounts(256); got[len(got)-1] != maxBenchmarkWorkers {
+		t.Fatalf("expected the count to be capped at %d, got %v", maxBenchmarkWorkers, got)
+	}
+}
+
+func TestBenchmarkScanCountsTreeAtEveryWorkerCount(t *testing.T) {
+	root := t.TempDir()
+	for i, dir := range []string{"a", "a/b", "a/b/c", "d", "e/f"} {
+		writeSizedFile(t, filepath.Join(root, dir, "blob"), 1000*(i+1))
+	}
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := benchmarkScan(context.Background(), root, []int{1, 2, 4})
+	if err != nil {
+		t.Fatalf("benchmarkScan failed: %v", err)
+	}
+	if report.Files != 5 || report.Bytes != 15000 || report.Dirs != 7 {
+		t.Fatalf("unexpected totals files=%d dirs=%d bytes=%d", report.Files, report.Dirs, report.Bytes)
+	}
+	if len(report.Runs) != 4 || report.Runs[0].Pass != "cold" || report.Runs[0].Workers != 4 {
+		t.Fatalf("expected a cold pass at 4 workers then one warm pass each, got %+v", report.Runs)
+	}
+	for _, workers := range []int{1, 2, 4} {
+		totals, err := scanTree(context.Background(), root, workers)
+		if err != nil || totals != (scanTotals{files: 5, dirs: 7, bytes: 15000}) {
+			t.Fatalf("scanTree with %d workers = %+v, %v", workers, totals, err)
+		}
+	}
+
+	if _, err := benchmarkScan(context.Background(), filepath.Join(root, "a", "blob"), []int{1}); err == nil {
+		t.Fatal("expected a file path to be rejected")
+	}
+}
+
+func TestWriteScanBenchmarkText(t *testing.T) {
+	var out bytes.Buffer
+	report := scanBenchmark{
+		Path:  "/work",
+		Files: 10,
+		Dirs:  2,
+		Bytes: 2 << 20,
+		Runs: []scanRun{
+			{Workers: 2, Pass: "cold", WallSeconds: 0.5, FilesPerSecond: 20, MBPerSecond: 4},
+			{Workers: 2, Pass: "warm", WallSeconds: 0.1, FilesPerSecond: 100, MBPerSecond: 20},
+		},
+		WarmSavedSeconds: 0.4,
+		Note:             scanBenchmarkNote,
+	}
+	if err := writeScanBenchmark(&out, "text", report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"path: /work, 10 files in 2 directories, 2.0 MiB",
+		"WORKERS  PASS  WALL   FILES/S  MB/S",
+		"2        cold  500ms  20       4.0",
+		"warm second run saved 400ms",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
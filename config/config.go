@@ -2,30 +2,91 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the cleanup daemon configuration.
 type Config struct {
+	// ConfigVersion is the schema version the file was written for; files
+	// without it are version 1. See CurrentConfigVersion.
+	ConfigVersion int `yaml:"config_version"`
+
+	// Migration lists the keys added since ConfigVersion that took their
+	// defaults. It is nil when the file is current or there is no file.
+	Migration *Migration `yaml:"-"`
+
+	// RemoteWarnings note remote -config URLs served from their cached copy
+	// because the fetch failed or the fetched config was invalid.
+	RemoteWarnings []string `yaml:"-"`
+
+	// ConfigSource controls re-fetching a remote -config
+	ConfigSource ConfigSourceConfig `yaml:"config"`
+
+	// Profile names a built-in preset (laptop, ci-runner, workstation)
+	// applied over the defaults before the rest of this file
+	Profile string `yaml:"profile"`
+
 	// PollInterval in seconds between cleanup checks
 	PollInterval int `yaml:"poll_interval"`
 
 	// Thresholds for disk usage (percentage)
 	Thresholds Thresholds `yaml:"thresholds"`
 
+	// Monitor controls how the poll loop checks disk usage between cycles.
+	Monitor MonitorConfig `yaml:"monitor"`
+
 	// TargetFree is the legacy config key for target maximum used percentage after cleanup.
 	TargetFree int `yaml:"target_free"`
 
+	// TargetFreeGB is the free space to reach after cleanup in GiB. When set
+	// it takes precedence over TargetFree.
+	TargetFreeGB float64 `yaml:"target_free_gb"`
+
 	// Policy controls daemon-level cleanup policy such as cooldown state.
 	Policy PolicyConfig `yaml:"policy"`
 
+	// Cooldowns maps plugin.operation keys, such as podman.disk_compact, to
+	// the minimum hours between runs of that expensive or destructive
+	// operation. History is kept beside policy.state_file.
+	Cooldowns map[string]float64 `yaml:"cooldowns"`
+
+	// Pool bounds how long a cleanup cycle may run.
+	Pool PoolConfig `yaml:"pool"`
+
+	// Safety controls how deletions are accounted.
+	Safety SafetyConfig `yaml:"safety"`
+
 	// LogFile path for cleanup logs
 	LogFile string `yaml:"log_file"`
 
+	// Log controls how logs and reports present paths.
+	Log LogConfig `yaml:"log"`
+
+	// PluginOrder runs the listed plugins first, in this order, instead of by
+	// priority. Unlisted enabled plugins run afterward in registration order.
+	PluginOrder []string `yaml:"plugin_order"`
+
+	// LevelPlugins limits the plugins that run at a cleanup level, keyed by
+	// level name. A level without an entry runs every enabled plugin.
+	LevelPlugins map[string][]string `yaml:"level_plugins"`
+
+	// PluginLevels caps the level a plugin runs at, keyed by plugin name.
+	// "none" keeps the plugin from running. Unlisted plugins run at the
+	// cycle level.
+	PluginLevels map[string]string `yaml:"plugin_levels"`
+
+	// HomeOverride pins the home directory used for per-user cleanup paths
+	HomeOverride string `yaml:"home_override"`
+
+	// RunAsUser resolves the home directory of this user when running as a service
+	RunAsUser string `yaml:"run_as_user"`
+
 	// Enable flags for specific cleanup plugins
 	Enable EnableFlags `yaml:"enable"`
 
@@ -47,23 +108,72 @@ type Config struct {
 	// iCloud-specific settings (Darwin)
 	ICloud ICloudConfig `yaml:"icloud"`
 
+	// Cache plugin settings
+	Cache CacheConfig `yaml:"cache"`
+
+	// Homebrew-specific settings (Darwin)
+	Homebrew HomebrewConfig `yaml:"homebrew"`
+
+	// iOS Simulator settings (Darwin)
+	IOSSimulator IOSSimulatorConfig `yaml:"ios_simulator"`
+
 	// GitHub Actions runner settings (Linux)
 	GitHubRunner GitHubRunnerConfig `yaml:"github_runner"`
 
 	// Monitored mount points (multi-volume support)
 	MonitoredMounts []MountConfig `yaml:"monitored_mounts"`
 
+	// Directories watched for bursty growth in daemon mode
+	WatchDirs []WatchDirConfig `yaml:"watch_dirs"`
+
+	// User-nominated scratch directories emptied of old files
+	ScratchDirs []ScratchDirConfig `yaml:"scratch_dirs"`
+
+	// Settings shared by every scratch_dirs entry
+	Scratch ScratchConfig `yaml:"scratch"`
+
 	// Dev artifact cleanup settings
 	DevArtifacts DevArtifactsConfig `yaml:"dev_artifacts"`
 
+	// Git repository maintenance settings
+	GitMaintenance GitMaintenanceConfig `yaml:"git_maintenance"`
+
+	// .DS_Store and similar OS clutter settings
+	JunkFiles JunkFilesConfig `yaml:"junk_files"`
+
+	// Large sparse and dense file report settings
+	SparseFiles SparseFilesConfig `yaml:"sparse_files"`
+
 	// Darwin developer cache cleanup settings
 	DarwinDevCaches DarwinDevCachesConfig `yaml:"darwin_dev_caches"`
 
 	// APFS snapshot settings (Darwin)
 	APFS APFSConfig `yaml:"apfs"`
 
+	// ZFS and Btrfs snapshot settings (Linux)
+	CoWSnapshots CoWSnapshotsConfig `yaml:"cow_snapshots"`
+
+	// Quick Look and icon services cache settings (Darwin)
+	SystemCaches SystemCachesConfig `yaml:"system_caches"`
+
+	// AppLogs settings for macOS application log cleanup (Darwin)
+	AppLogs AppLogsConfig `yaml:"app_logs"`
+
+	// ExternalTrash settings for .Trashes on external volumes (Darwin)
+	ExternalTrash ExternalTrashConfig `yaml:"external_trash"`
+
+	// ElectronApps settings for Electron app cache cleanup (Darwin and Linux)
+	ElectronApps ElectronAppsConfig `yaml:"electron_apps"`
+
 	// Notification settings
 	Notify NotifyConfig `yaml:"notify"`
+
+	// Recommendations settings for the analysis after a cycle that leaves
+	// the disk over threshold
+	Recommendations RecommendationsConfig `yaml:"recommendations"`
+
+	// Local HTTP endpoint settings for daemon mode
+	Observability ObservabilityConfig `yaml:"observability"`
 }
 
 // GitHubRunnerConfig holds GitHub Actions runner cleanup settings.
@@ -84,6 +194,60 @@ type MountConfig struct {
 	ThresholdWarning int `yaml:"threshold_warning,omitempty"`
 	// ThresholdCritical overrides the global critical threshold
 	ThresholdCritical int `yaml:"threshold_critical,omitempty"`
+	// Priority orders mounts that reach the same cleanup level; the highest
+	// is addressed first. Ties keep config order.
+	Priority int `yaml:"priority,omitempty"`
+}
+
+// WatchDirConfig is a hot directory the daemon watches with inotify (Linux)
+// or kqueue (macOS) so growth past MaxGB triggers a scoped cleanup without
+// waiting for the next poll.
+type WatchDirConfig struct {
+	// Path is the directory to watch.
+	Path string `yaml:"path"`
+	// MaxGB is the directory size that triggers cleanup.
+	MaxGB float64 `yaml:"max_gb"`
+	// Plugins limits the triggered cleanup; empty runs every enabled plugin.
+	Plugins []string `yaml:"plugins,omitempty"`
+	// Level is the cleanup level to run (default: moderate).
+	Level string `yaml:"level,omitempty"`
+	// DebounceSeconds is how long to collect events before sizing the
+	// directory (default: 5).
+	DebounceSeconds int `yaml:"debounce_seconds,omitempty"`
+	// PollSeconds is the size-check interval used when the directory cannot
+	// be watched, e.g. when inotify watches are exhausted (default: 30).
+	PollSeconds int `yaml:"poll_seconds,omitempty"`
+}
+
+// ScratchDirConfig is a user-nominated directory whose old files are deleted
+// at moderate level and above.
+type ScratchDirConfig struct {
+	// Path is the directory to clean; absolute or starting with ~.
+	Path string `yaml:"path"`
+	// MaxAgeDays deletes files not modified for this many days.
+	MaxAgeDays int `yaml:"max_age_days"`
+	// Recursive also cleans subdirectories on the same filesystem.
+	Recursive bool `yaml:"recursive,omitempty"`
+	// RemoveEmptyDirs removes subdirectories left empty, when Recursive.
+	RemoveEmptyDirs bool `yaml:"remove_empty_dirs,omitempty"`
+}
+
+// ScratchConfig holds settings shared by every scratch_dirs entry.
+type ScratchConfig struct {
+	// CompressInsteadOfDelete gzips expired files in place instead of
+	// deleting them
+	CompressInsteadOfDelete bool `yaml:"compress_instead_of_delete"`
+	// MinCompressionRatio keeps a file uncompressed unless gzip makes it at
+	// least this many times smaller (default: 2)
+	MinCompressionRatio float64 `yaml:"min_compression_ratio"`
+}
+
+// MonitorConfig holds poll-loop disk check settings.
+type MonitorConfig struct {
+	// IdleMarginPercent skips a poll tick entirely, including proactive
+	// checks, while every monitored path is at least this many points below
+	// its warning threshold. 0 runs a full cycle on every tick.
+	IdleMarginPercent int `yaml:"idle_margin_percent"`
 }
 
 // Thresholds defines disk usage thresholds for graduated cleanup.
@@ -130,6 +294,36 @@ type EnableFlags struct {
 	Bazel bool `yaml:"bazel"`
 	// APFSSnapshots for APFS snapshot thinning (Darwin)
 	APFSSnapshots bool `yaml:"apfs_snapshots"`
+	// ZFSSnapshots for old ZFS snapshot deletion on monitored mounts (Linux)
+	ZFSSnapshots bool `yaml:"zfs_snapshots"`
+	// BtrfsSnapshots for old Btrfs snapshot deletion on monitored mounts (Linux)
+	BtrfsSnapshots bool `yaml:"btrfs_snapshots"`
+	// Scratch for expired files in scratch_dirs
+	Scratch bool `yaml:"scratch"`
+	// JunkFiles for .DS_Store and similar clutter under scan paths (opt-in)
+	JunkFiles bool `yaml:"junk_files"`
+	// SparseFiles for a report of large sparse and dense files (opt-in)
+	SparseFiles bool `yaml:"sparse_files"`
+	// GitMaintenance for git gc in large repositories under dev_artifacts.scan_paths
+	GitMaintenance bool `yaml:"git_maintenance"`
+	// SystemCaches for Quick Look and icon services cache resets (Darwin)
+	SystemCaches bool `yaml:"system_caches"`
+	// AppLogs for old application logs under ~/Library/Logs (Darwin, opt-in)
+	AppLogs bool `yaml:"app_logs"`
+	// ExternalTrash for old items in .Trashes on external volumes (Darwin, opt-in)
+	ExternalTrash bool `yaml:"external_trash"`
+	// ElectronApps for allowlisted Electron app caches (Darwin and Linux, opt-in)
+	ElectronApps bool `yaml:"electron_apps"`
+	// Containerd for nerdctl image/container/volume/build cache cleanup
+	// (Rancher Desktop in containerd mode)
+	Containerd bool `yaml:"containerd"`
+}
+
+// ConfigSourceConfig holds settings for the config source itself.
+type ConfigSourceConfig struct {
+	// RefreshMinutes re-fetches a remote -config this often in daemon mode,
+	// applying it when valid; 0 disables refresh
+	RefreshMinutes int `yaml:"refresh_minutes"`
 }
 
 // PolicyConfig holds daemon-level cleanup policy settings.
@@ -138,6 +332,57 @@ type PolicyConfig struct {
 	Cooldown string `yaml:"cooldown"`
 	// StateFile stores daemon cleanup state such as per-plugin last-run timestamps.
 	StateFile string `yaml:"state_file"`
+	// OrderByEfficiency runs plugins by their last measured bytes freed per
+	// second, highest first, when a free-space target is set and plugin_order
+	// is not.
+	OrderByEfficiency bool `yaml:"order_by_efficiency"`
+}
+
+// PoolConfig holds cleanup cycle execution limits. Plugins run one at a
+// time in execution order, so target_free can end a cycle between plugins
+// and each plugin's host free-space delta is measured on its own.
+type PoolConfig struct {
+	// MaxCycleMinutes cancels a cleanup cycle after this many minutes; 0 means no limit.
+	MaxCycleMinutes int `yaml:"max_cycle_minutes"`
+}
+
+// LogConfig holds log and report presentation settings.
+type LogConfig struct {
+	// Redact replaces the home directory with ~ and masks usernames in logs and reports
+	Redact bool `yaml:"redact"`
+	// RedactProjectNames also hashes path segments that may name projects or
+	// files when Redact is set (default: true)
+	RedactProjectNames bool `yaml:"redact_project_names"`
+	// ReportGrowth adds bytes_grown to plugin reports when a cleanup target
+	// was larger after cleanup than before. Growth is always logged at debug.
+	ReportGrowth bool `yaml:"report_growth"`
+}
+
+// SafetyConfig holds deletion accounting and cleanup level ceiling settings.
+type SafetyConfig struct {
+	// AccountActualBlocks reports freed bytes from allocated blocks (st_blocks)
+	// instead of logical file size, matching df on compressed APFS volumes.
+	AccountActualBlocks bool `yaml:"account_actual_blocks"`
+	// MaxLevel caps the cleanup level the daemon will run (warning, moderate,
+	// aggressive, critical). Empty means no ceiling.
+	MaxLevel string `yaml:"max_level"`
+	// SkipWhenDisplayAsleep defers heavy plugins, such as VM compaction and
+	// large scans, while the display is asleep or the lid is closed (macOS)
+	SkipWhenDisplayAsleep bool `yaml:"skip_when_display_asleep"`
+	// FollowSymlinkedCaches cleans cache roots that are symlinks to another
+	// filesystem, e.g. ~/Library/Caches moved to an external disk. The link is
+	// kept and only the target's contents are deleted.
+	FollowSymlinkedCaches bool `yaml:"follow_symlinked_caches"`
+	// VerifyFreedBytes snapshots volume free space around each plugin that
+	// deletes files directly and compares the observed delta with the
+	// plugin's reported BytesFreed. Diagnostic; costs two statfs calls per plugin.
+	VerifyFreedBytes bool `yaml:"verify_freed_bytes"`
+	// VerifyToleranceMB is the absolute divergence allowed before a
+	// discrepancy is reported, absorbing concurrent writes and deletes.
+	VerifyToleranceMB int `yaml:"verify_tolerance_mb"`
+	// VerifyTolerancePercent is the divergence allowed as a percentage of the
+	// reported bytes. The larger of the two tolerances applies.
+	VerifyTolerancePercent int `yaml:"verify_tolerance_percent"`
 }
 
 // DockerConfig holds Docker-specific cleanup settings.
@@ -146,26 +391,57 @@ type DockerConfig struct {
 	Socket string `yaml:"socket"`
 	// PruneImagesAge for images older than this duration
 	PruneImagesAge string `yaml:"prune_images_age"`
+	// PruneAges overrides PruneImagesAge per cleanup level ("moderate",
+	// "aggressive")
+	PruneAges map[string]string `yaml:"prune_ages"`
 	// ProtectRunningContainers prevents pruning images used by running containers
 	ProtectRunningContainers bool `yaml:"protect_running_containers"`
+	// Proactive checks docker system df every cycle and prunes when reclaimable
+	// space exceeds ProactiveReclaimGB, regardless of host disk usage
+	Proactive bool `yaml:"proactive"`
+	// ProactiveReclaimGB is the reclaimable size that triggers proactive cleanup (default: 10)
+	ProactiveReclaimGB int `yaml:"proactive_reclaim_gb"`
+	// DeepBuildCacheGC drops every BuildKit cache record in buildx builder
+	// containers at aggressive level and above, so orphaned blobs are collected
+	// (forces a cold build cache)
+	DeepBuildCacheGC bool `yaml:"deep_build_cache_gc"`
+	// KeepRecentlyUsed keeps the N most recently used images, ranked by
+	// container history, when pruning images older than PruneImagesAge
+	// (0 uses the plain age filter)
+	KeepRecentlyUsed int `yaml:"keep_recently_used"`
+	// Hosts are remote DOCKER_HOST values, such as ssh://user@build01, pruned
+	// every cycle when their own `docker system df` reports more than
+	// ProactiveReclaimGB reclaimable. Local disk levels never trigger them.
+	Hosts []string `yaml:"hosts"`
 }
 
 // LimaConfig holds Lima VM cleanup settings.
 type LimaConfig struct {
 	// VMNames to check for Docker cleanup
 	VMNames []string `yaml:"vm_names"`
-	// CompactOffline enables offline qcow2 compaction at Critical level
+	// CompactOffline enables offline qcow2 compaction at Critical level, and at
+	// any level for VMs where fstrim is not supported
 	CompactOffline bool `yaml:"compact_offline"`
+	// VMPolicies maps a VM name to the operations allowed on it: "prune",
+	// "fstrim", "compact", "resize". VMs without an entry allow all of them.
+	VMPolicies map[string][]string `yaml:"vm_policies"`
 }
 
 // PodmanConfig holds Podman-specific cleanup settings.
 type PodmanConfig struct {
 	// PruneImagesAge for images older than this duration
 	PruneImagesAge string `yaml:"prune_images_age"`
+	// PruneAges overrides PruneImagesAge per cleanup level ("moderate",
+	// "aggressive")
+	PruneAges map[string]string `yaml:"prune_ages"`
 	// ProtectRunningContainers prevents pruning images used by running containers
 	ProtectRunningContainers bool `yaml:"protect_running_containers"`
-	// MachineNames to check for cleanup (Darwin)
+	// MachineNames restricts and orders the Podman machines to clean on
+	// Darwin, or on Linux when machines run. Empty cleans every running machine.
 	MachineNames []string `yaml:"machine_names"`
+	// CleanRootful also cleans the rootful system engine on Linux, through
+	// sudo -n, after the rootless user engine
+	CleanRootful bool `yaml:"clean_rootful"`
 	// BuildKitPrune enables targeted BuildKit cache pruning at critical level
 	BuildKitPrune bool `yaml:"buildkit_prune"`
 	// BuildKitPruneKeepDuration preserves BuildKit records newer than this duration
@@ -174,11 +450,14 @@ type PodmanConfig struct {
 	BuildKitPruneKeepStorageMB int `yaml:"buildkit_prune_keep_storage_mb"`
 	// BuildKitPruneMinReclaimGB skips BuildKit pruning below this reclaimable cache size
 	BuildKitPruneMinReclaimGB int `yaml:"buildkit_prune_min_reclaim_gb"`
+	// DeepBuildCacheGC drops every BuildKit cache record at aggressive level
+	// and above, so orphaned blobs are collected (forces a cold build cache)
+	DeepBuildCacheGC bool `yaml:"deep_build_cache_gc"`
 	// CriticalSystemPrune enables broad critical system prune with volumes.
 	CriticalSystemPrune bool `yaml:"critical_system_prune"`
-	// CleanInsideVM enables cleanup inside Podman machine VM (Darwin)
+	// CleanInsideVM enables cleanup inside running Podman machine VMs
 	CleanInsideVM bool `yaml:"clean_inside_vm"`
-	// TrimVMDisk enables fstrim inside VM to reclaim sparse disk space (Darwin)
+	// TrimVMDisk enables fstrim inside running Podman machines to reclaim sparse disk space
 	TrimVMDisk bool `yaml:"trim_vm_disk"`
 	// CompactDiskOffline enables offline raw disk compaction at Critical level
 	CompactDiskOffline bool `yaml:"compact_disk_offline"`
@@ -228,6 +507,8 @@ type NixConfig struct {
 	MinSystemGenerations int `yaml:"min_system_generations"`
 	// HostMeasurePath is the filesystem path used for plugin-isolated host free-space deltas.
 	HostMeasurePath string `yaml:"host_measure_path"`
+	// StoreVolume overrides the mount used to measure Nix store reclaim, such as the macOS /nix APFS volume.
+	StoreVolume string `yaml:"store_volume"`
 	// DeleteGenerationsOlderThan is the normal generation age policy.
 	DeleteGenerationsOlderThan string `yaml:"delete_generations_older_than"`
 	// CriticalDeleteGenerationsOlderThan is the critical-level generation age policy.
@@ -254,6 +535,36 @@ type ICloudConfig struct {
 	MinFileSizeMB int `yaml:"min_file_size_mb"`
 }
 
+// CacheConfig holds cache plugin settings.
+type CacheConfig struct {
+	// ExtraPaths are user cache directories emptied alongside the built-in
+	// caches, such as ~/.cache/huggingface
+	ExtraPaths []CachePathConfig `yaml:"extra_paths"`
+}
+
+// CachePathConfig is one extra cache directory.
+type CachePathConfig struct {
+	// Path is the cache directory; ~ expands to the home directory
+	Path string `yaml:"path"`
+	// MinLevel is the lowest cleanup level that empties Path (default: warning)
+	MinLevel string `yaml:"min_level"`
+}
+
+// HomebrewConfig holds Homebrew cleanup settings (Darwin).
+type HomebrewConfig struct {
+	// IncludeCasks runs brew cleanup for installed casks and removes cached
+	// cask installers at aggressive and critical levels (default: true).
+	// Installed casks are never uninstalled.
+	IncludeCasks bool `yaml:"include_casks"`
+}
+
+// IOSSimulatorConfig holds iOS Simulator cleanup settings (Darwin).
+type IOSSimulatorConfig struct {
+	// EraseUnusedAfterDays erases shut-down simulator devices not booted in
+	// this many days at aggressive and critical levels; 0 disables (default: 30)
+	EraseUnusedAfterDays int `yaml:"erase_unused_after_days"`
+}
+
 // DevArtifactsConfig holds development artifact cleanup settings.
 type DevArtifactsConfig struct {
 	// ScanPaths is the list of directories to scan for dev artifacts
@@ -276,6 +587,9 @@ type DevArtifactsConfig struct {
 	NodeModules bool `yaml:"node_modules"`
 	// PythonVenvs enables .venv cleanup
 	PythonVenvs bool `yaml:"python_venvs"`
+	// PythonBuildCaches enables __pycache__, .pytest_cache, .mypy_cache,
+	// .ruff_cache, and .tox cleanup without a staleness check
+	PythonBuildCaches bool `yaml:"python_build_caches"`
 	// RustTargets enables Rust target/ cleanup
 	RustTargets bool `yaml:"rust_targets"`
 	// ZigArtifacts enables Zig .zig-cache/ and zig-out/ cleanup
@@ -290,10 +604,48 @@ type DevArtifactsConfig struct {
 	LargeLocalArtifacts bool `yaml:"large_local_artifacts"`
 	// LargeLocalArtifactMinMB is the minimum physical size for review-only large local artifact targets
 	LargeLocalArtifactMinMB int `yaml:"large_local_artifact_min_mb"`
+	// Incremental re-walks only project directories whose mtime changed since the
+	// last scan, using a manifest stored next to policy.state_file
+	Incremental bool `yaml:"incremental"`
 	// ProtectPaths are paths that should never be cleaned
 	ProtectPaths []string `yaml:"protect_paths"`
 }
 
+// GitMaintenanceConfig holds git repository maintenance settings.
+// Repositories are discovered under dev_artifacts.scan_paths and honor
+// dev_artifacts.protect_paths.
+type GitMaintenanceConfig struct {
+	// MinGitDirMB is the .git size below which a repository is left alone (default: 1024)
+	MinGitDirMB int `yaml:"min_git_dir_mb"`
+	// ActiveWithin skips repositories whose index changed within this duration (default: 1h)
+	ActiveWithin string `yaml:"active_within"`
+	// MaxDepth limits how deep below each scan path repositories are searched (default: 4)
+	MaxDepth int `yaml:"max_depth"`
+}
+
+// JunkFilesConfig holds settings for removing OS clutter files.
+type JunkFilesConfig struct {
+	// Patterns are file name globs to remove, such as .DS_Store or ._*; a
+	// trailing slash matches directories, such as .AppleDouble/
+	Patterns []string `yaml:"patterns"`
+	// ScanPaths to search; empty uses dev_artifacts.scan_paths
+	ScanPaths []string `yaml:"scan_paths"`
+}
+
+// SparseFilesConfig holds settings for the large sparse file report.
+type SparseFilesConfig struct {
+	// ScanPaths to search, each on its own filesystem (default: ~)
+	ScanPaths []string `yaml:"scan_paths"`
+	// MinSizeGB reports files whose apparent or allocated size reaches this (default: 10)
+	MinSizeGB float64 `yaml:"min_size_gb"`
+	// SparsePercent flags files allocating less than this share of their
+	// apparent size as sparse (default: 50)
+	SparsePercent int `yaml:"sparse_percent"`
+	// DensePercent flags files allocating at least this share of their
+	// apparent size as dense (default: 90)
+	DensePercent int `yaml:"dense_percent"`
+}
+
 // DarwinDevCachesConfig holds macOS developer-cache budget settings.
 type DarwinDevCachesConfig struct {
 	// Enabled controls typed Darwin developer-cache planning.
@@ -345,71 +697,249 @@ type APFSConfig struct {
 	DeleteOSUpdates bool `yaml:"delete_os_updates"`
 }
 
+// CoWSnapshotsConfig holds ZFS and Btrfs snapshot cleanup settings (Linux).
+type CoWSnapshotsConfig struct {
+	// KeepRecent always keeps this many of the newest snapshots per mount;
+	// values below 1 keep one
+	KeepRecent int `yaml:"keep_recent"`
+	// KeepRecentDays keeps snapshots newer than this many days
+	KeepRecentDays int `yaml:"keep_recent_days"`
+}
+
+// SystemCachesConfig holds macOS system cache cleanup settings (Darwin).
+type SystemCachesConfig struct {
+	// ResetIconServices allows removing the icon services store with sudo and
+	// restarting the Dock at Critical level
+	ResetIconServices bool `yaml:"reset_icon_services"`
+}
+
+// AppLogsConfig holds macOS application log cleanup settings (Darwin).
+type AppLogsConfig struct {
+	// MaxAgeDays removes logs not modified for this many days (default: 7)
+	MaxAgeDays int `yaml:"max_age_days"`
+	// ExtraPaths are more log directories to sweep alongside ~/Library/Logs
+	// and the built-in Application Support log directories
+	ExtraPaths []string `yaml:"extra_paths"`
+	// CompressInsteadOfDelete gzips old logs in place instead of removing
+	// them
+	CompressInsteadOfDelete bool `yaml:"compress_instead_of_delete"`
+	// MinCompressionRatio keeps a log uncompressed unless gzip makes it at
+	// least this many times smaller (default: 2)
+	MinCompressionRatio float64 `yaml:"min_compression_ratio"`
+}
+
+// ExternalTrashConfig holds external volume Trash cleanup settings (Darwin).
+type ExternalTrashConfig struct {
+	// MaxAgeDays empties items moved to the Trash this many days ago (default: 30)
+	MaxAgeDays int `yaml:"max_age_days"`
+	// IncludeUserTrash also empties old items from ~/.Trash on the boot volume
+	IncludeUserTrash bool `yaml:"include_user_trash"`
+}
+
+// ElectronAppsConfig holds Electron app cache cleanup settings.
+type ElectronAppsConfig struct {
+	// Apps extends the built-in allowlist. An entry named like a built-in app
+	// replaces it.
+	Apps []ElectronAppConfig `yaml:"apps"`
+}
+
+// ElectronAppConfig is one Electron app's allowlisted cache subpaths.
+type ElectronAppConfig struct {
+	// Name identifies the app in plans and logs
+	Name string `yaml:"name"`
+	// Process marks the app running when a process command contains it
+	// (default: Name)
+	Process string `yaml:"process"`
+	// Dirs are the app's data directories: relative to Application Support
+	// on macOS and to ~/.config on Linux, or absolute or ~-prefixed
+	Dirs []string `yaml:"dirs"`
+	// Moderate are cache subpaths cleared at Moderate and above
+	// (default: Cache, Code Cache, GPUCache)
+	Moderate []string `yaml:"moderate"`
+	// Aggressive are cache subpaths also cleared at Aggressive and above
+	// (default: Service Worker/CacheStorage)
+	Aggressive []string `yaml:"aggressive"`
+}
+
 // NotifyConfig holds notification settings.
 type NotifyConfig struct {
 	// Enabled for notifications
 	Enabled bool `yaml:"enabled"`
 	// WebhookURL for Slack/Discord notifications
 	WebhookURL string `yaml:"webhook_url"`
+	// AlertOnIneffectiveCritical raises an alert when a critical cycle frees
+	// less than IneffectiveCriticalMB (default: true)
+	AlertOnIneffectiveCritical bool `yaml:"alert_on_ineffective_critical"`
+	// IneffectiveCriticalMB is the freed space below which a critical cycle
+	// counts as ineffective (default: 100)
+	IneffectiveCriticalMB int `yaml:"ineffective_critical_mb"`
+	// DiskHogCount is how many of the largest directories under the
+	// monitored path to include in the alert; 0 skips the scan (default: 5)
+	DiskHogCount int `yaml:"disk_hog_count"`
+	// OnCleanup posts a summary after each real cleanup cycle at or above
+	// MinLevel that freed at least MinFreedGB (default: false)
+	OnCleanup bool `yaml:"on_cleanup"`
+	// MinLevel is the lowest cycle level that notifies: warning, moderate,
+	// aggressive, or critical (default: critical)
+	MinLevel string `yaml:"min_level"`
+	// MinFreedGB keeps cleanup summaries silent for cycles that freed less (default: 1)
+	MinFreedGB float64 `yaml:"min_freed_gb"`
+	// NotifyOnError posts an alert naming each plugin that failed in a real
+	// cleanup cycle, regardless of MinLevel (default: true)
+	NotifyOnError bool `yaml:"notify_on_error"`
+	// CoalesceWindow folds repeated critical notifications within this
+	// duration into one "still critical" message; empty uses policy.cooldown
+	CoalesceWindow string `yaml:"coalesce_window"`
+}
+
+// RecommendationsConfig controls the analysis run after a real cleanup cycle
+// that leaves the monitored disk over the warning threshold. It explains why
+// more was not freed and suggests config changes.
+type RecommendationsConfig struct {
+	// Enabled adds a recommendations section to the cycle report (default: false)
+	Enabled bool `yaml:"enabled"`
+	// Notify also posts the recommendations through notify, subject to
+	// notify.min_level (default: false)
+	Notify bool `yaml:"notify"`
+	// MaxItems caps the protected items and uncovered directories listed;
+	// 0 skips the directory scan (default: 5)
+	MaxItems int `yaml:"max_items"`
+}
+
+// ObservabilityConfig holds the daemon's local HTTP server and metrics
+// export settings.
+type ObservabilityConfig struct {
+	// ListenAddr is the loopback host:port to serve on; empty disables the server
+	ListenAddr string `yaml:"listen_addr"`
+	// TriggerToken is the shared bearer token required by POST /cleanup;
+	// empty disables the trigger endpoint
+	TriggerToken string `yaml:"trigger_token"`
+	// MetricsEnabled exports metrics after each real cleanup cycle (default: false)
+	MetricsEnabled bool `yaml:"metrics_enabled"`
+	// OTLPEndpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318;
+	// /v1/metrics is appended when it has no path. Empty writes only to
+	// FallbackPath.
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	// FallbackPath receives one JSON line per cycle that could not be
+	// exported (default: metrics.jsonl beside the state file)
+	FallbackPath string `yaml:"fallback_path"`
+	// HeartbeatEnabled has the daemon rewrite HeartbeatPath after every poll
+	// tick, for -check-heartbeat (default: false)
+	HeartbeatEnabled bool `yaml:"heartbeat_enabled"`
+	// HeartbeatPath is the heartbeat JSON file (default: heartbeat.json
+	// beside the state file)
+	HeartbeatPath string `yaml:"heartbeat_path"`
+	// PluginTimeoutMinutes times the number of enabled plugins is how long a
+	// cycle may run before it is reported stalled; 0 disables the watchdog
+	// (default: 10)
+	PluginTimeoutMinutes int `yaml:"plugin_timeout_minutes"`
 }
 
-// DefaultConfig returns the default configuration.
+// DefaultConfig returns the default configuration. Home-relative defaults
+// follow env.HomeDir; when no home can be resolved they are left empty so
+// plugins skip them instead of acting on system paths.
 func DefaultConfig() *Config {
-	home, _ := os.UserHomeDir()
+	home, homeErr := env.HomeDir()
+	if homeErr != nil {
+		home = ""
+	}
 	logFile := filepath.Join(home, ".local", "log", "disk-cleanup.log")
 	stateFile := filepath.Join(home, ".local", "state", "tinyland-cleanup", "state.json")
+	if home == "" {
+		logFile = filepath.Join(os.TempDir(), "tinyland-cleanup", "disk-cleanup.log")
+		stateFile = filepath.Join(os.TempDir(), "tinyland-cleanup", "state.json")
+	}
 
-	defaultScanPaths := []string{
-		filepath.Join(home, "git"),
-		filepath.Join(home, "src"),
-		filepath.Join(home, "projects"),
+	var defaultScanPaths []string
+	if home != "" {
+		defaultScanPaths = []string{
+			filepath.Join(home, "git"),
+			filepath.Join(home, "src"),
+			filepath.Join(home, "projects"),
+		}
 	}
 	defaultTempScanPaths := []string{"/tmp"}
 	if runtime.GOOS == "darwin" {
 		defaultTempScanPaths = []string{"/private/tmp"}
 	}
-	bazeliskCache := filepath.Join(home, ".cache", "bazelisk")
-	if runtime.GOOS == "darwin" {
-		bazeliskCache = filepath.Join(home, "Library", "Caches", "bazelisk")
+	var bazeliskCache string
+	var bazelProtectWorkspaces []string
+	if home != "" {
+		bazeliskCache = filepath.Join(home, ".cache", "bazelisk")
+		if runtime.GOOS == "darwin" {
+			bazeliskCache = filepath.Join(home, "Library", "Caches", "bazelisk")
+		}
+		bazelProtectWorkspaces = []string{
+			filepath.Join(home, "git", "lab"),
+			filepath.Join(home, "git", "GloriousFlywheel"),
+		}
 	}
 
 	config := &Config{
-		PollInterval: 60,
+		ConfigVersion: CurrentConfigVersion,
+		PollInterval:  60,
 		Thresholds: Thresholds{
 			Warning:    80,
 			Moderate:   85,
 			Aggressive: 90,
 			Critical:   95,
 		},
+		Monitor: MonitorConfig{
+			IdleMarginPercent: 0,
+		},
 		TargetFree: 70,
 		Policy: PolicyConfig{
 			Cooldown:  "30m",
 			StateFile: stateFile,
 		},
+		Pool: PoolConfig{
+			MaxCycleMinutes: 0,
+		},
+		Safety: SafetyConfig{
+			AccountActualBlocks:    runtime.GOOS == "darwin",
+			MaxLevel:               "",
+			VerifyFreedBytes:       false,
+			VerifyToleranceMB:      64,
+			VerifyTolerancePercent: 20,
+		},
 		LogFile: logFile,
+		Log: LogConfig{
+			Redact:             false,
+			RedactProjectNames: true,
+			ReportGrowth:       false,
+		},
 		Enable: EnableFlags{
-			Cache:         true,
-			NixGC:         true,
-			Docker:        true,
-			Podman:        true,
-			Lima:          runtime.GOOS == "darwin",
-			Homebrew:      runtime.GOOS == "darwin",
-			IOSSimulator:  runtime.GOOS == "darwin",
-			GitLabRunner:  true,
-			ICloud:        runtime.GOOS == "darwin",
-			Photos:        runtime.GOOS == "darwin",
-			DevArtifacts:  true,
-			Bazel:         true,
-			APFSSnapshots: runtime.GOOS == "darwin",
+			Cache:          true,
+			NixGC:          true,
+			Docker:         true,
+			Podman:         true,
+			Lima:           runtime.GOOS == "darwin",
+			Homebrew:       runtime.GOOS == "darwin",
+			IOSSimulator:   runtime.GOOS == "darwin",
+			GitLabRunner:   true,
+			ICloud:         runtime.GOOS == "darwin",
+			Photos:         runtime.GOOS == "darwin",
+			DevArtifacts:   true,
+			Scratch:        true,
+			Bazel:          true,
+			APFSSnapshots:  runtime.GOOS == "darwin",
+			GitMaintenance: false,
+			JunkFiles:      false,
+			SparseFiles:    false,
+			SystemCaches:   runtime.GOOS == "darwin",
+			AppLogs:        false,
+			ElectronApps:   false,
+			Containerd:     true,
 		},
 		Docker: DockerConfig{
 			PruneImagesAge:           "24h",
 			ProtectRunningContainers: true,
+			Proactive:                false,
+			ProactiveReclaimGB:       10,
 		},
 		Podman: PodmanConfig{
 			PruneImagesAge:                   "24h",
 			ProtectRunningContainers:         true,
-			MachineNames:                     []string{"podman-machine-default"},
 			BuildKitPrune:                    true,
 			BuildKitPruneKeepDuration:        "24h",
 			BuildKitPruneKeepStorageMB:       8192,
@@ -423,17 +953,14 @@ func DefaultConfig() *Config {
 			CompactProviderAllowlist:         []string{"applehv", "libkrun", "qemu"},
 		},
 		Bazel: BazelConfig{
-			Roots:                 defaultBazelRoots(home),
-			WorkspaceRoots:        defaultScanPaths,
-			BazeliskCache:         bazeliskCache,
-			MaxTotalGB:            20,
-			KeepRecentOutputBases: 5,
-			StaleAfter:            "14d",
-			CriticalStaleAfter:    "3d",
-			ProtectWorkspaces: []string{
-				filepath.Join(home, "git", "lab"),
-				filepath.Join(home, "git", "GloriousFlywheel"),
-			},
+			Roots:                        defaultBazelRoots(home),
+			WorkspaceRoots:               defaultScanPaths,
+			BazeliskCache:                bazeliskCache,
+			MaxTotalGB:                   20,
+			KeepRecentOutputBases:        5,
+			StaleAfter:                   "14d",
+			CriticalStaleAfter:           "3d",
+			ProtectWorkspaces:            bazelProtectWorkspaces,
 			AllowStopIdleServers:         true,
 			AllowDeleteActiveOutputBases: false,
 		},
@@ -457,6 +984,15 @@ func DefaultConfig() *Config {
 			ExcludePaths:   []string{},
 			MinFileSizeMB:  10,
 		},
+		Cache: CacheConfig{
+			ExtraPaths: []CachePathConfig{},
+		},
+		Homebrew: HomebrewConfig{
+			IncludeCasks: true,
+		},
+		IOSSimulator: IOSSimulatorConfig{
+			EraseUnusedAfterDays: 30,
+		},
 		DevArtifacts: DevArtifactsConfig{
 			ScanPaths:               defaultScanPaths,
 			ScanMaxDuration:         "30s",
@@ -468,6 +1004,7 @@ func DefaultConfig() *Config {
 			TempArtifactStaleAfter:  "6h",
 			NodeModules:             true,
 			PythonVenvs:             true,
+			PythonBuildCaches:       true,
 			RustTargets:             true,
 			ZigArtifacts:            true,
 			GoBuildCache:            true,
@@ -475,8 +1012,23 @@ func DefaultConfig() *Config {
 			LMStudioModels:          false,
 			LargeLocalArtifacts:     true,
 			LargeLocalArtifactMinMB: 1024,
+			Incremental:             false,
 			ProtectPaths:            []string{},
 		},
+		GitMaintenance: GitMaintenanceConfig{
+			MinGitDirMB:  1024,
+			ActiveWithin: "1h",
+			MaxDepth:     4,
+		},
+		JunkFiles: JunkFilesConfig{
+			Patterns: []string{".DS_Store"},
+		},
+		SparseFiles: SparseFilesConfig{
+			ScanPaths:     []string{"~"},
+			MinSizeGB:     10,
+			SparsePercent: 50,
+			DensePercent:  90,
+		},
 		DarwinDevCaches: DarwinDevCachesConfig{
 			Enabled:    runtime.GOOS == "darwin",
 			Enforce:    false,
@@ -518,14 +1070,49 @@ func DefaultConfig() *Config {
 			KeepRecentDays:  1,
 			DeleteOSUpdates: true,
 		},
+		CoWSnapshots: CoWSnapshotsConfig{
+			KeepRecent:     3,
+			KeepRecentDays: 7,
+		},
+		SystemCaches: SystemCachesConfig{
+			ResetIconServices: false,
+		},
+		AppLogs: AppLogsConfig{
+			MaxAgeDays:          7,
+			MinCompressionRatio: 2,
+		},
+		ExternalTrash: ExternalTrashConfig{
+			MaxAgeDays: 30,
+		},
+		Scratch: ScratchConfig{
+			MinCompressionRatio: 2,
+		},
 		Notify: NotifyConfig{
-			Enabled: false,
+			Enabled:                    false,
+			AlertOnIneffectiveCritical: true,
+			IneffectiveCriticalMB:      100,
+			DiskHogCount:               5,
+			OnCleanup:                  false,
+			MinLevel:                   "critical",
+			MinFreedGB:                 1,
+			NotifyOnError:              true,
+		},
+		Recommendations: RecommendationsConfig{
+			MaxItems: 5,
+		},
+		Observability: ObservabilityConfig{
+			ListenAddr:           "",
+			FallbackPath:         filepath.Join(filepath.Dir(stateFile), "metrics.jsonl"),
+			HeartbeatPath:        filepath.Join(filepath.Dir(stateFile), "heartbeat.json"),
+			PluginTimeoutMinutes: 10,
 		},
 	}
 
 	// Platform-specific socket defaults
 	if runtime.GOOS == "darwin" {
-		config.Docker.Socket = filepath.Join(home, ".colima", "default", "docker.sock")
+		if home != "" {
+			config.Docker.Socket = filepath.Join(home, ".colima", "default", "docker.sock")
+		}
 	} else {
 		config.Docker.Socket = "/var/run/docker.sock"
 	}
@@ -534,7 +1121,10 @@ func DefaultConfig() *Config {
 }
 
 func defaultBazelRoots(home string) []string {
-	roots := []string{filepath.Join(home, ".cache", "bazel")}
+	var roots []string
+	if home != "" {
+		roots = append(roots, filepath.Join(home, ".cache", "bazel"))
+	}
 	if runtime.GOOS == "darwin" {
 		if user := os.Getenv("USER"); user != "" {
 			roots = append(roots,
@@ -548,28 +1138,90 @@ func defaultBazelRoots(home string) []string {
 }
 
 // LoadConfig loads configuration from a YAML file, merging with defaults.
+//
+// path may list several comma-separated files or globs, and each file may
+// include others; they are deep-merged in order with later files winning.
+// See loadLayeredYAML for the merge rules.
+//
+// home_override and run_as_user are applied to env.Configure before defaults
+// are built, so home-relative defaults resolve against the configured user.
+// They are restored to their previous values when loading fails.
 func LoadConfig(path string) (*Config, error) {
-	config := DefaultConfig()
+	return LoadConfigWithProfile(path, "")
+}
 
-	if path == "" {
-		return config, nil
+// LoadConfigWithProfile is LoadConfig with a built-in profile applied between
+// the defaults and the files. A non-empty profile, such as the -profile flag,
+// takes precedence over a profile key in the files.
+func LoadConfigWithProfile(path, profile string) (config *Config, err error) {
+	data, warnings, err := loadLayeredYAML(path)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return config, nil
-		}
+	var identity struct {
+		Profile      string `yaml:"profile"`
+		HomeOverride string `yaml:"home_override"`
+		RunAsUser    string `yaml:"run_as_user"`
+	}
+	if err := yaml.Unmarshal(data, &identity); err != nil {
 		return nil, err
 	}
+	previousOverride, previousUser := env.Settings()
+	env.Configure(identity.HomeOverride, identity.RunAsUser)
+	defer func() {
+		if err != nil {
+			env.Configure(previousOverride, previousUser)
+		}
+	}()
+	if profile == "" {
+		profile = identity.Profile
+	}
 
+	config = DefaultConfig()
+	if err := applyProfile(config, profile); err != nil {
+		return nil, err
+	}
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
+	config.Profile = profile
+	config.RemoteWarnings = warnings
+	if data != nil {
+		if config.ConfigVersion, err = configVersion(data); err != nil {
+			return nil, err
+		}
+		if config.Migration, err = planMigration(data, config.ConfigVersion); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
 
+// validateConfig applies the checks LoadConfig makes after decoding.
+func validateConfig(config *Config) error {
+	if err := validatePruneAges("docker", config.Docker.PruneAges); err != nil {
+		return err
+	}
+	if err := validatePruneAges("podman", config.Podman.PruneAges); err != nil {
+		return err
+	}
+	if err := validateLimaVMPolicies(config.Lima.VMPolicies); err != nil {
+		return err
+	}
+	if config.ConfigSource.RefreshMinutes < 0 {
+		return fmt.Errorf("config.refresh_minutes must not be negative, got %d", config.ConfigSource.RefreshMinutes)
+	}
+	if config.Observability.PluginTimeoutMinutes < 0 {
+		return fmt.Errorf("observability.plugin_timeout_minutes must not be negative, got %d", config.Observability.PluginTimeoutMinutes)
+	}
+	return nil
+}
+
 // SaveConfig saves configuration to a YAML file.
 func SaveConfig(config *Config, path string) error {
 	dir := filepath.Dir(path)
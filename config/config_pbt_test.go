@@ -199,10 +199,9 @@ func TestPodmanConfigDefaults(t *testing.T) {
 		t.Error("Podman.CriticalSystemPrune should default to false")
 	}
 
-	// MachineNames should have at least one entry on Darwin
-	// (but we can't check runtime.GOOS in test, so just verify it's set)
-	if len(cfg.Podman.MachineNames) == 0 {
-		t.Error("Podman.MachineNames should have defaults")
+	// MachineNames defaults to empty so every running machine is cleaned
+	if len(cfg.Podman.MachineNames) != 0 {
+		t.Errorf("Podman.MachineNames should default to all running machines, got %v", cfg.Podman.MachineNames)
 	}
 }
 
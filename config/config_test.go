@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -159,6 +161,76 @@ poll_interval: "not a number"
 	}
 }
 
+func TestPruneAgeSelection(t *testing.T) {
+	docker := DockerConfig{PruneImagesAge: "24h", PruneAges: map[string]string{"aggressive": "2h"}}
+	if got := docker.PruneAge("moderate"); got != "24h" {
+		t.Errorf("moderate should fall back to prune_images_age, got %q", got)
+	}
+	if got := docker.PruneAge("aggressive"); got != "2h" {
+		t.Errorf("expected the aggressive override, got %q", got)
+	}
+	podman := PodmanConfig{PruneImagesAge: "48h"}
+	if got := podman.PruneAge("aggressive"); got != "48h" {
+		t.Errorf("expected prune_images_age without prune_ages, got %q", got)
+	}
+}
+
+func TestLoadConfigValidatesPruneAges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(content string) (*Config, error) {
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadConfig(configPath)
+	}
+
+	cfg, err := load("docker:\n  prune_ages:\n    moderate: 72h\n    aggressive: 6h\npodman:\n  prune_ages:\n    aggressive: 12h\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Docker.PruneAge("moderate") != "72h" || cfg.Podman.PruneAge("aggressive") != "12h" || cfg.Podman.PruneAge("moderate") != "24h" {
+		t.Errorf("unexpected prune ages: docker=%v podman=%v", cfg.Docker.PruneAges, cfg.Podman.PruneAges)
+	}
+
+	for _, content := range []string{
+		"docker:\n  prune_ages:\n    moderate: 3d\n",
+		"docker:\n  prune_ages:\n    critical: 1h\n",
+		"podman:\n  prune_ages:\n    aggressive: -1h\n",
+	} {
+		if _, err := load(content); err == nil {
+			t.Errorf("expected %q to be rejected", content)
+		}
+	}
+}
+
+func TestLimaVMPolicies(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "lima:\n  vm_policies:\n    unified: [prune]\n    sealed: []\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Lima.Allows("unified", LimaOpPrune) || cfg.Lima.Allows("unified", LimaOpCompact) {
+		t.Errorf("unified should allow only prune, got %v", cfg.Lima.VMPolicies["unified"])
+	}
+	if cfg.Lima.Allows("sealed", LimaOpFSTrim) {
+		t.Error("an empty policy should allow nothing")
+	}
+	if !cfg.Lima.Allows("colima", LimaOpCompact) {
+		t.Error("a VM without a policy should allow everything")
+	}
+
+	if err := os.WriteFile(configPath, []byte("lima:\n  vm_policies:\n    unified: [shrink]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("expected an unknown operation to be rejected")
+	}
+}
+
 func TestDevArtifactsConfigDefaults(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -171,6 +243,18 @@ func TestDevArtifactsConfigDefaults(t *testing.T) {
 	if !cfg.DevArtifacts.PythonVenvs {
 		t.Error("DevArtifacts.PythonVenvs should be true by default")
 	}
+	if !cfg.DevArtifacts.PythonBuildCaches {
+		t.Error("DevArtifacts.PythonBuildCaches should be true by default")
+	}
+	if cfg.Log.Redact || !cfg.Log.RedactProjectNames {
+		t.Errorf("Log = %+v, want redaction off with project names hashed once enabled", cfg.Log)
+	}
+	if cfg.Safety.FollowSymlinkedCaches {
+		t.Error("Safety.FollowSymlinkedCaches should be false by default")
+	}
+	if cfg.Log.ReportGrowth {
+		t.Error("Log.ReportGrowth should be false by default")
+	}
 	if !cfg.DevArtifacts.RustTargets {
 		t.Error("DevArtifacts.RustTargets should be true by default")
 	}
@@ -281,6 +365,14 @@ func TestPodmanCompactDefaults(t *testing.T) {
 	}
 }
 
+func TestSafetyAccountActualBlocksDefaultsOnDarwin(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if want := runtime.GOOS == "darwin"; cfg.Safety.AccountActualBlocks != want {
+		t.Errorf("Safety.AccountActualBlocks should be %v on %s, got %v", want, runtime.GOOS, cfg.Safety.AccountActualBlocks)
+	}
+}
+
 func TestNixPolicyDefaults(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -719,3 +811,73 @@ darwin_dev_caches:
 		t.Errorf("DarwinDevCaches.Cursor.StaleAfterDays should be 5 per config, got %d", cfg.DarwinDevCaches.Cursor.StaleAfterDays)
 	}
 }
+
+func TestLoadConfigHomeOverrideDrivesDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	home := filepath.Join(tmpDir, "service-home")
+	content := "home_override: " + home + "\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Cleanup(func() { env.Configure("", "") })
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.HomeOverride != home {
+		t.Fatalf("expected home_override %q, got %q", home, cfg.HomeOverride)
+	}
+	if want := filepath.Join(home, ".local", "log", "disk-cleanup.log"); cfg.LogFile != want {
+		t.Fatalf("expected log file under override %q, got %q", want, cfg.LogFile)
+	}
+	if len(cfg.DevArtifacts.ScanPaths) == 0 || cfg.DevArtifacts.ScanPaths[0] != filepath.Join(home, "git") {
+		t.Fatalf("expected scan paths under override, got %v", cfg.DevArtifacts.ScanPaths)
+	}
+	if resolved, err := env.HomeDir(); err != nil || resolved != home {
+		t.Fatalf("expected env.HomeDir to return override, got %q err=%v", resolved, err)
+	}
+}
+
+func TestLoadConfigRejectedKeepsPreviousHome(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := "home_override: " + filepath.Join(tmpDir, "other-home") + "\ndocker:\n  prune_ages:\n    moderate: 3d\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	home := filepath.Join(tmpDir, "service-home")
+	env.Configure(home, "")
+	t.Cleanup(func() { env.Configure("", "") })
+
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Fatal("expected the invalid prune age to be rejected")
+	}
+	if resolved, err := env.HomeDir(); err != nil || resolved != home {
+		t.Fatalf("expected the rejected config to keep home %q, got %q err=%v", home, resolved, err)
+	}
+}
+
+func TestLoadConfigUnresolvableHomeLeavesHomePathsEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("home_override: /\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Cleanup(func() { env.Configure("", "") })
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(cfg.DevArtifacts.ScanPaths) != 0 || len(cfg.Bazel.WorkspaceRoots) != 0 || cfg.Bazel.BazeliskCache != "" {
+		t.Fatalf("expected no home-relative defaults, got scan=%v workspaces=%v bazelisk=%q",
+			cfg.DevArtifacts.ScanPaths, cfg.Bazel.WorkspaceRoots, cfg.Bazel.BazeliskCache)
+	}
+	if filepath.Dir(cfg.Policy.StateFile) != filepath.Join(os.TempDir(), "tinyland-cleanup") {
+		t.Fatalf("expected state file under temp dir, got %q", cfg.Policy.StateFile)
+	}
+}
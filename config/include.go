@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layering directives. They are read by the loader and never reach Config:
+//
+//	include:           # files loaded before this one, relative to it; globs allowed
+//	  - base.yaml
+//	  - conf.d/*.yaml
+//	merge:
+//	  append:          # dotted keys whose lists extend earlier files instead of replacing them
+//	    - monitored_mounts
+//	    - bazel.roots
+const (
+	includeKey = "include"
+	mergeKey   = "merge"
+)
+
+// maxIncludeDepth bounds nested include chains.
+const maxIncludeDepth = 8
+
+// mergeDirectives is the merge: block of one config file.
+type mergeDirectives struct {
+	Append []string `yaml:"append"`
+}
+
+// configLayers accumulates config files in load order.
+type configLayers struct {
+	merged   *yaml.Node
+	appendTo map[string]bool
+	loading  map[string]bool
+	// warnings note remote configs served from their cached copy.
+	warnings []string
+}
+
+// loadLayeredYAML reads the comma-separated files, globs, or http(s) URLs in
+// spec, expands their include directives, and deep-merges them in order.
+// Later files win: mappings merge key by key, scalars replace, and lists
+// replace unless their key is listed under merge.append in any file loaded
+// so far. The merged document is re-encoded as YAML; nil means no file
+// existed. Remote configs are fetched by fetchRemoteConfig, and the returned
+// warnings say when a cached copy was used instead.
+func loadLayeredYAML(spec string) ([]byte, []string, error) {
+	layers := &configLayers{appendTo: map[string]bool{}, loading: map[string]bool{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if IsRemoteConfig(entry) {
+			if err := layers.loadRemote(entry); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		paths, err := expandConfigPattern(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, path := range paths {
+			// A missing -config file falls back to defaults, as it always has.
+			if err := layers.load(path, 0, true); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if layers.merged == nil {
+		return nil, layers.warnings, nil
+	}
+	data, err := yaml.Marshal(layers.merged)
+	return data, layers.warnings, err
+}
+
+// expandConfigPattern returns the sorted matches for a glob, or the path
+// itself when it has no glob metacharacters.
+func expandConfigPattern(pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("config pattern %q: %w", pattern, err)
+	}
+	return matches, nil
+}
+
+func (l *configLayers) load(path string, depth int, allowMissing bool) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("config %s: includes nested deeper than %d", path, maxIncludeDepth)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if l.loading[abs] {
+		return fmt.Errorf("config %s: include cycle", path)
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		if allowMissing && os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	root, directives, err := parseConfigLayer(path, data)
+	if err != nil || root == nil {
+		return err
+	}
+
+	l.loading[abs] = true
+	defer delete(l.loading, abs)
+	for _, include := range directives.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(abs), include)
+		}
+		paths, err := expandConfigPattern(include)
+		if err != nil {
+			return err
+		}
+		for _, includePath := range paths {
+			if err := l.load(includePath, depth+1, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	l.merge(root, directives)
+	return nil
+}
+
+// loadRemote fetches a remote config and merges it. Remote configs cannot
+// include other files.
+func (l *configLayers) loadRemote(url string) error {
+	data, warning, err := fetchRemoteConfig(url)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		l.warnings = append(l.warnings, warning)
+	}
+	root, directives, err := parseConfigLayer(url, data)
+	if err != nil || root == nil {
+		return err
+	}
+	if len(directives.Include) > 0 {
+		return fmt.Errorf("config %s: include is not supported in a remote config", url)
+	}
+	l.merge(root, directives)
+	return nil
+}
+
+// configDirectives are the layering directives of one config file.
+type configDirectives struct {
+	Include []string        `yaml:"include"`
+	Merge   mergeDirectives `yaml:"merge"`
+}
+
+// parseConfigLayer parses one config file named name into its top-level
+// mapping and directives. An empty file yields a nil mapping.
+func parseConfigLayer(name string, data []byte) (*yaml.Node, configDirectives, error) {
+	var directives configDirectives
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, directives, fmt.Errorf("config %s: %w", name, err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil, directives, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, directives, fmt.Errorf("config %s: top level must be a mapping", name)
+	}
+	if err := root.Decode(&directives); err != nil {
+		return nil, directives, fmt.Errorf("config %s: %w", name, err)
+	}
+	return root, directives, nil
+}
+
+// merge applies one parsed layer's append directives and merges it onto the
+// layers loaded so far.
+func (l *configLayers) merge(root *yaml.Node, directives configDirectives) {
+	for _, key := range directives.Merge.Append {
+		l.appendTo[key] = true
+	}
+	root = withoutKeys(root, includeKey, mergeKey)
+	if l.merged == nil {
+		l.merged = root
+		return
+	}
+	l.merged = mergeYAMLNodes(l.merged, root, "", l.appendTo)
+}
+
+// mergeYAMLNodes returns overlay merged onto base. key is the dotted path of
+// the nodes, used to look up list append rules.
+func mergeYAMLNodes(base, overlay *yaml.Node, key string, appendTo map[string]bool) *yaml.Node {
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		merged := *base
+		merged.Content = append([]*yaml.Node(nil), base.Content...)
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			name, value := overlay.Content[i], overlay.Content[i+1]
+			childKey := name.Value
+			if key != "" {
+				childKey = key + "." + name.Value
+			}
+			if idx := mappingIndex(&merged, name.Value); idx >= 0 {
+				merged.Content[idx+1] = mergeYAMLNodes(merged.Content[idx+1], value, childKey, appendTo)
+				continue
+			}
+			merged.Content = append(merged.Content, name, value)
+		}
+		return &merged
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && appendTo[key]:
+		merged := *overlay
+		merged.Content = append(append([]*yaml.Node(nil), base.Content...), overlay.Content...)
+		return &merged
+	default:
+		return overlay
+	}
+}
+
+// mappingIndex returns the index of name's key node in a mapping, or -1.
+func mappingIndex(mapping *yaml.Node, name string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// withoutKeys returns a copy of a mapping node without the named keys.
+func withoutKeys(mapping *yaml.Node, names ...string) *yaml.Node {
+	trimmed := *mapping
+	trimmed.Content = nil
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		skip := false
+		for _, name := range names {
+			if mapping.Content[i].Value == name {
+				skip = true
+			}
+		}
+		if !skip {
+			trimmed.Content = append(trimmed.Content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	return &trimmed
+}
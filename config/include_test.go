@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigLaterFilesOverrideEarlier(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "base.yaml", `
+poll_interval: 120
+thresholds:
+  warning: 70
+  moderate: 80
+enable:
+  docker: false
+  nix_gc: false
+bazel:
+  roots: [/org/bazel]
+`)
+	machine := writeConfigFile(t, dir, "machine.yaml", `
+thresholds:
+  warning: 75
+enable:
+  docker: true
+bazel:
+  roots: [/machine/bazel]
+`)
+
+	cfg, err := LoadConfig(base + "," + machine)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.PollInterval != 120 {
+		t.Errorf("PollInterval = %d, want base value 120", cfg.PollInterval)
+	}
+	if cfg.Thresholds.Warning != 75 || cfg.Thresholds.Moderate != 80 {
+		t.Errorf("thresholds = %+v, want warning from machine and moderate from base", cfg.Thresholds)
+	}
+	if !cfg.Enable.Docker || cfg.Enable.NixGC {
+		t.Errorf("enable = docker %v nix_gc %v, want machine docker and base nix_gc", cfg.Enable.Docker, cfg.Enable.NixGC)
+	}
+	if !reflect.DeepEqual(cfg.Bazel.Roots, []string{"/machine/bazel"}) {
+		t.Errorf("Bazel.Roots = %v, want later list to replace earlier", cfg.Bazel.Roots)
+	}
+}
+
+func TestLoadConfigIncludeDirective(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "org/base.yaml", `
+poll_interval: 300
+target_free: 60
+monitored_mounts:
+  - path: /
+    label: root
+`)
+	writeConfigFile(t, dir, "org/conf.d/20-enables.yaml", "poll_interval: 200\n")
+	writeConfigFile(t, dir, "org/conf.d/10-mounts.yaml", `
+monitored_mounts:
+  - path: /data
+    label: data
+`)
+	machine := writeConfigFile(t, dir, "machine.yaml", `
+include:
+  - org/base.yaml
+  - org/conf.d/*.yaml
+merge:
+  append: [monitored_mounts]
+monitored_mounts:
+  - path: /scratch
+    label: scratch
+target_free: 50
+`)
+
+	cfg, err := LoadConfig(machine)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.PollInterval != 200 || cfg.TargetFree != 50 {
+		t.Errorf("PollInterval = %d, TargetFree = %d, want 200 from conf.d and 50 from machine", cfg.PollInterval, cfg.TargetFree)
+	}
+	var paths []string
+	for _, mount := range cfg.MonitoredMounts {
+		paths = append(paths, mount.Path)
+	}
+	// conf.d/10-mounts.yaml replaces base's list; merge.append only applies
+	// once declared, so machine.yaml's mount is appended.
+	if want := []string{"/data", "/scratch"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("monitored mount paths = %v, want %v", paths, want)
+	}
+}
+
+func TestLoadConfigAppendNestedList(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "base.yaml", `
+merge:
+  append: [bazel.roots]
+bazel:
+  roots: [/org/bazel]
+`)
+	machine := writeConfigFile(t, dir, "machine.yaml", `
+bazel:
+  roots: [/machine/bazel]
+`)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if want := []string{"/org/bazel", "/machine/bazel"}; !reflect.DeepEqual(cfg.Bazel.Roots, want) {
+		t.Errorf("Bazel.Roots = %v, want %v", cfg.Bazel.Roots, want)
+	}
+
+	cfg, err = LoadConfig(machine + "," + base)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	// A file's own merge.append applies to its lists too.
+	if want := []string{"/machine/bazel", "/org/bazel"}; !reflect.DeepEqual(cfg.Bazel.Roots, want) {
+		t.Errorf("Bazel.Roots = %v, want %v", cfg.Bazel.Roots, want)
+	}
+}
+
+func TestLoadConfigIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := writeConfigFile(t, dir, "missing.yaml", "include: [nope.yaml]\n")
+	if _, err := LoadConfig(missing); err == nil {
+		t.Error("expected a missing include to fail")
+	}
+
+	writeConfigFile(t, dir, "a.yaml", "include: [b.yaml]\n")
+	b := writeConfigFile(t, dir, "b.yaml", "include: [a.yaml]\n")
+	if _, err := LoadConfig(b); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("expected include cycle error, got %v", err)
+	}
+
+	list := writeConfigFile(t, dir, "list.yaml", "- poll_interval: 10\n")
+	if _, err := LoadConfig(list); err == nil {
+		t.Error("expected a non-mapping config to fail")
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lima VM operations that lima.vm_policies can allow.
+const (
+	LimaOpPrune   = "prune"
+	LimaOpFSTrim  = "fstrim"
+	LimaOpCompact = "compact"
+	LimaOpResize  = "resize"
+)
+
+var limaOperations = []string{LimaOpPrune, LimaOpFSTrim, LimaOpCompact, LimaOpResize}
+
+// Allows reports whether lima.vm_policies permits operation on vm. A VM
+// without a policy allows every operation.
+func (c LimaConfig) Allows(vm, operation string) bool {
+	allowed, ok := c.VMPolicies[vm]
+	if !ok {
+		return true
+	}
+	for _, op := range allowed {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLimaVMPolicies rejects operations the Lima plugin does not know.
+func validateLimaVMPolicies(policies map[string][]string) error {
+	vms := make([]string, 0, len(policies))
+	for vm := range policies {
+		vms = append(vms, vm)
+	}
+	sort.Strings(vms)
+	for _, vm := range vms {
+		for _, op := range policies[vm] {
+			known := false
+			for _, candidate := range limaOperations {
+				known = known || op == candidate
+			}
+			if !known {
+				return fmt.Errorf("lima.vm_policies.%s: unknown operation %q (want %s)", vm, op, strings.Join(limaOperations, ", "))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config schema this binary writes and expects.
+// Files without a config_version key are version 1, the 0.2.0 schema.
+const CurrentConfigVersion = 2
+
+// schemaAdditions lists, per schema version, the dotted keys added in that
+// version. A key under a block added in the same version is covered by the
+// block. Add new keys to the current version until a release bumps
+// CurrentConfigVersion.
+var schemaAdditions = map[int][]string{
+	2: {
+		"app_logs", "cache", "config", "cooldowns", "cow_snapshots",
+		"dev_artifacts.incremental", "dev_artifacts.python_build_caches",
+		"docker.deep_build_cache_gc", "docker.hosts", "docker.keep_recently_used",
+		"docker.proactive", "docker.proactive_reclaim_gb", "docker.prune_ages",
+		"electron_apps", "external_trash",
+		"enable.app_logs", "enable.btrfs_snapshots", "enable.containerd", "enable.electron_apps", "enable.external_trash",
+		"enable.git_maintenance",
+		"enable.junk_files", "enable.scratch", "enable.sparse_files",
+		"enable.system_caches", "enable.zfs_snapshots",
+		"git_maintenance", "home_override", "homebrew", "ios_simulator", "junk_files", "level_plugins",
+		"lima.vm_policies", "log", "monitor", "nix.store_volume",
+		"notify.alert_on_ineffective_critical", "notify.coalesce_window",
+		"notify.disk_hog_count", "notify.ineffective_critical_mb",
+		"notify.min_freed_gb", "notify.min_level", "notify.notify_on_error", "notify.on_cleanup",
+		"observability", "plugin_order",
+		"podman.clean_rootful", "podman.deep_build_cache_gc", "podman.prune_ages",
+		"plugin_levels", "policy.order_by_efficiency", "pool", "profile", "recommendations", "run_as_user", "safety",
+		"scratch", "scratch_dirs", "sparse_files", "system_caches", "target_free_gb", "watch_dirs",
+	},
+}
+
+// Migration describes a config file older than CurrentConfigVersion.
+type Migration struct {
+	// FromVersion is the file's config_version, 1 when it has none.
+	FromVersion int
+	// Defaulted are the keys added since FromVersion that the file does
+	// not set, so they took their defaults.
+	Defaulted []string
+}
+
+// planMigration returns the keys added after version that data does not
+// set, or nil when version is current.
+func planMigration(data []byte, version int) (*Migration, error) {
+	if version >= CurrentConfigVersion {
+		return nil, nil
+	}
+	root, err := yamlMapping(data)
+	if err != nil {
+		return nil, err
+	}
+	migration := &Migration{FromVersion: version}
+	for v := version + 1; v <= CurrentConfigVersion; v++ {
+		for _, key := range schemaAdditions[v] {
+			if mappingLookup(root, key) == nil {
+				migration.Defaulted = append(migration.Defaulted, key)
+			}
+		}
+	}
+	return migration, nil
+}
+
+// configVersion returns the config_version in data, or 1 when unset.
+func configVersion(data []byte) (int, error) {
+	var stamp struct {
+		ConfigVersion int `yaml:"config_version"`
+	}
+	if err := yaml.Unmarshal(data, &stamp); err != nil {
+		return 0, err
+	}
+	if stamp.ConfigVersion == 0 {
+		return 1, nil
+	}
+	return stamp.ConfigVersion, nil
+}
+
+// MigrateConfigFile stamps the config file at path with CurrentConfigVersion
+// and writes the default of every key added since its version that neither
+// it nor its includes set. Existing values and comments are kept, and the
+// original is saved beside it with a .bak suffix. A file already at the
+// current version is left untouched and nil is returned.
+func MigrateConfigFile(path string) (*Migration, error) {
+	if strings.ContainsAny(path, ",*?[") || IsRemoteConfig(path) {
+		return nil, fmt.Errorf("-migrate-config needs a single local config file, got %q", path)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	merged, _, err := loadLayeredYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	version, err := configVersion(merged)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentConfigVersion {
+		return nil, fmt.Errorf("%s has config_version %d, newer than this binary's %d", path, version, CurrentConfigVersion)
+	}
+	migration, err := planMigration(merged, version)
+	if err != nil || migration == nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level is not a mapping", path)
+	}
+	var defaults yaml.Node
+	if err := defaults.Encode(DefaultConfig()); err != nil {
+		return nil, err
+	}
+	commented := map[*yaml.Node]bool{}
+	for _, key := range migration.Defaulted {
+		value := mappingLookup(&defaults, key)
+		if value == nil {
+			continue
+		}
+		setMappingKey(root, key, value, commented)
+	}
+	setConfigVersion(root)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+".bak", original, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, out.Bytes(), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// yamlMapping decodes data and returns its top-level mapping, or an empty
+// mapping when data is empty.
+func yamlMapping(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// mappingLookup returns the value at a dotted key, or nil when any part of
+// it is missing.
+func mappingLookup(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, part := range strings.Split(key, ".") {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// migrationComment marks the first key -migrate-config adds to a mapping.
+const migrationComment = "added by -migrate-config; review the defaults below"
+
+// setMappingKey sets a dotted key in mapping to value, creating parent
+// mappings as needed. The first key added to each mapping carries
+// migrationComment; commented tracks those mappings. A parent that is set
+// to something other than a mapping is left alone.
+func setMappingKey(mapping *yaml.Node, key string, value *yaml.Node, commented map[*yaml.Node]bool) {
+	add := func(name string, value *yaml.Node) {
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+		if !commented[mapping] {
+			keyNode.HeadComment = migrationComment
+			commented[mapping] = true
+		}
+		mapping.Content = append(mapping.Content, keyNode, value)
+	}
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingLookup(mapping, part)
+		if child != nil && child.Kind != yaml.MappingNode {
+			return
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			add(part, child)
+			// Keys inside a new block are covered by the block's comment.
+			commented[child] = true
+		}
+		mapping = child
+	}
+	add(parts[len(parts)-1], value)
+}
+
+// setConfigVersion sets config_version to the current version, adding it
+// first in the file when missing. A comment heading the file stays at the top.
+func setConfigVersion(root *yaml.Node) {
+	version := fmt.Sprint(CurrentConfigVersion)
+	if node := mappingLookup(root, "config_version"); node != nil {
+		node.Value = version
+		return
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "config_version"}
+	if len(root.Content) > 0 {
+		keyNode.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{
+		keyNode,
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: version},
+	}, root.Content...)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// v1Config is a 0.2.0-era config: no config_version, no newer blocks.
+const v1Config = `# my machine
+poll_interval: 120 # every two minutes
+thresholds:
+  warning: 70
+docker:
+  prune_images_age: 48h
+enable:
+  docker: true
+`
+
+func TestLoadConfigReportsDefaultedKeysForOldSchema(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "config.yaml", v1Config)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ConfigVersion != 1 || cfg.Migration == nil || cfg.Migration.FromVersion != 1 {
+		t.Fatalf("expected a version 1 migration, got version %d and %+v", cfg.ConfigVersion, cfg.Migration)
+	}
+	for _, key := range []string{"docker.proactive", "enable.junk_files", "safety", "app_logs"} {
+		if !slices.Contains(cfg.Migration.Defaulted, key) {
+			t.Errorf("expected %s to be reported as defaulted, got %v", key, cfg.Migration.Defaulted)
+		}
+	}
+
+	current := writeConfigFile(t, t.TempDir(), "config.yaml", "config_version: 2\npoll_interval: 30\n")
+	if cfg, err := LoadConfig(current); err != nil || cfg.Migration != nil || cfg.ConfigVersion != CurrentConfigVersion {
+		t.Fatalf("expected a current config to need no migration, got %+v, %v", cfg.Migration, err)
+	}
+}
+
+func TestMigrateConfigFileFromV1(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "config.yaml", v1Config)
+
+	migration, err := MigrateConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migration == nil || migration.FromVersion != 1 || len(migration.Defaulted) == 0 {
+		t.Fatalf("unexpected migration: %+v", migration)
+	}
+	if backup, err := os.ReadFile(path + ".bak"); err != nil || string(backup) != v1Config {
+		t.Fatalf("expected the original saved as a backup, got %q, %v", backup, err)
+	}
+
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(migrated)
+	for _, want := range []string{"config_version: 2", "# my machine", "# every two minutes", "prune_images_age: 48h", migrationComment} {
+		if !strings.Contains(text, want) {
+			t.Errorf("migrated config missing %q:\n%s", want, text)
+		}
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Migration != nil || cfg.ConfigVersion != CurrentConfigVersion {
+		t.Fatalf("expected the migrated config to be current, got version %d and %+v", cfg.ConfigVersion, cfg.Migration)
+	}
+	if cfg.PollInterval != 120 || cfg.Thresholds.Warning != 70 || cfg.Docker.PruneImagesAge != "48h" {
+		t.Fatalf("user values were not preserved: %+v", cfg)
+	}
+	if cfg.AppLogs.MaxAgeDays != DefaultConfig().AppLogs.MaxAgeDays {
+		t.Fatalf("expected new keys to carry their defaults, got %+v", cfg.AppLogs)
+	}
+
+	if again, err := MigrateConfigFile(path); err != nil || again != nil {
+		t.Fatalf("expected a second migration to be a no-op, got %+v, %v", again, err)
+	}
+	if _, err := MigrateConfigFile(filepath.Join(dir, "*.yaml")); err == nil {
+		t.Fatal("expected a glob to be rejected")
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a built-in preset for a common kind of host. LoadConfig applies
+// it over the defaults before the user's files, so any key the user sets
+// still wins.
+type Profile struct {
+	Name        string
+	Description string
+	// Settings is the YAML the profile applies, in config file syntax.
+	Settings string
+}
+
+var profiles = map[string]Profile{
+	"laptop": {
+		Name:        "laptop",
+		Description: "Conservative cleanup for a personal machine: no critical level, heavy work deferred while the display sleeps",
+		Settings: `safety:
+  max_level: aggressive
+  skip_when_display_asleep: true
+docker:
+  proactive: false
+podman:
+  critical_system_prune: false
+`,
+	},
+	"ci-runner": {
+		Name:        "ci-runner",
+		Description: "Aggressive cleanup for a build host: lower thresholds, proactive Docker pruning, no notifications",
+		Settings: `poll_interval: 30
+thresholds:
+  warning: 70
+  moderate: 75
+  aggressive: 80
+  critical: 90
+notify:
+  enabled: false
+docker:
+  proactive: true
+  prune_images_age: "6h"
+enable:
+  lima: false
+  ios_simulator: false
+  icloud: false
+  photos: false
+`,
+	},
+	"workstation": {
+		Name:        "workstation",
+		Description: "Default thresholds with proactive Docker pruning that keeps frequently used images, plus git maintenance",
+		Settings: `docker:
+  proactive: true
+  keep_recently_used: 20
+enable:
+  git_maintenance: true
+`,
+	},
+}
+
+// Profiles returns the built-in profiles sorted by name.
+func Profiles() []Profile {
+	list := make([]Profile, 0, len(profiles))
+	for _, profile := range profiles {
+		list = append(list, profile)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ProfileNames returns the built-in profile names sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range Profiles() {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// ProfileConfig returns the defaults with the named profile applied.
+func ProfileConfig(name string) (*Config, error) {
+	config := DefaultConfig()
+	if err := applyProfile(config, name); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyProfile decodes the named profile's settings over config. Unknown
+// keys are rejected so a typo in a preset fails loudly instead of silently
+// doing nothing.
+func applyProfile(config *Config, name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (want one of: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(profile.Settings)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	config.Profile = name
+	return nil
+}
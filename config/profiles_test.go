@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfilesApplyOverDefaults(t *testing.T) {
+	for _, name := range ProfileNames() {
+		cfg, err := ProfileConfig(name)
+		if err != nil {
+			t.Fatalf("profile %s: %v", name, err)
+		}
+		if cfg.Profile != name {
+			t.Errorf("profile %s: Profile = %q", name, cfg.Profile)
+		}
+	}
+
+	laptop, _ := ProfileConfig("laptop")
+	if laptop.Safety.MaxLevel != "aggressive" || !laptop.Safety.SkipWhenDisplayAsleep || laptop.Docker.Proactive {
+		t.Errorf("unexpected laptop settings: %+v %+v", laptop.Safety, laptop.Docker)
+	}
+	ci, _ := ProfileConfig("ci-runner")
+	if ci.Thresholds.Warning != 70 || ci.Notify.Enabled || !ci.Docker.Proactive || ci.Docker.PruneImagesAge != "6h" {
+		t.Errorf("unexpected ci-runner settings: %+v %+v %+v", ci.Thresholds, ci.Notify, ci.Docker)
+	}
+	// Keys a profile does not set keep their defaults.
+	if ci.Docker.ProactiveReclaimGB != DefaultConfig().Docker.ProactiveReclaimGB {
+		t.Errorf("ci-runner changed proactive_reclaim_gb to %d", ci.Docker.ProactiveReclaimGB)
+	}
+
+	if _, err := ProfileConfig("server"); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+}
+
+func TestLoadConfigWithProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "profile: ci-runner\nthresholds:\n  warning: 65\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "ci-runner" || cfg.Thresholds.Warning != 65 || cfg.Thresholds.Critical != 90 || !cfg.Docker.Proactive {
+		t.Errorf("expected the file's profile under its own keys, got profile=%q thresholds=%+v", cfg.Profile, cfg.Thresholds)
+	}
+
+	cfg, err = LoadConfigWithProfile(configPath, "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "laptop" || cfg.Thresholds.Warning != 65 || cfg.Thresholds.Critical != 95 || cfg.Safety.MaxLevel != "aggressive" {
+		t.Errorf("expected the flag's profile to replace the file's, got profile=%q thresholds=%+v", cfg.Profile, cfg.Thresholds)
+	}
+
+	if _, err := LoadConfigWithProfile(configPath, "nope"); err == nil {
+		t.Error("expected an unknown profile to fail loading")
+	}
+}
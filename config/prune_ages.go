@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// pruneAgeLevels are the cleanup levels whose image prune filters by age.
+// Warning removes only dangling images and critical prunes everything.
+var pruneAgeLevels = map[string]bool{"moderate": true, "aggressive": true}
+
+// PruneAge returns the image prune age for the named cleanup level.
+func (c DockerConfig) PruneAge(level string) string {
+	return pruneAgeFor(c.PruneAges, level, c.PruneImagesAge)
+}
+
+// PruneAge returns the image prune age for the named cleanup level.
+func (c PodmanConfig) PruneAge(level string) string {
+	return pruneAgeFor(c.PruneAges, level, c.PruneImagesAge)
+}
+
+// pruneAgeFor returns the prune_ages entry for level, or fallback when the
+// level has none.
+func pruneAgeFor(ages map[string]string, level, fallback string) string {
+	if age, ok := ages[level]; ok && age != "" {
+		return age
+	}
+	return fallback
+}
+
+// validatePruneAges rejects prune_ages keys that are not age-filtered levels
+// and values that are not positive durations.
+func validatePruneAges(section string, ages map[string]string) error {
+	levels := make([]string, 0, len(ages))
+	for level := range ages {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, level := range levels {
+		if !pruneAgeLevels[level] {
+			return fmt.Errorf("%s.prune_ages: unknown level %q (want moderate or aggressive)", section, level)
+		}
+		age, err := time.ParseDuration(ages[level])
+		if err != nil {
+			return fmt.Errorf("%s.prune_ages.%s: %w", section, level, err)
+		}
+		if age <= 0 {
+			return fmt.Errorf("%s.prune_ages.%s: %q must be positive", section, level, ages[level])
+		}
+	}
+	return nil
+}
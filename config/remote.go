@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+	"gopkg.in/yaml.v3"
+)
+
+// remoteConfigTimeout bounds one fetch of a remote config.
+var remoteConfigTimeout = 10 * time.Second
+
+// remoteConfigMaxBytes caps the size of a fetched config.
+const remoteConfigMaxBytes = 4 << 20
+
+// remoteConfigClient fetches remote configs; tests may replace it. It
+// refuses redirects to a URL checkRemoteConfigURL would reject.
+var remoteConfigClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkRemoteConfigURL(req.URL)
+	},
+}
+
+// IsRemoteConfig reports whether a -config entry is an http(s) URL. Plain
+// http URLs are recognised so that fetchRemoteConfig can reject them rather
+// than reading them as file paths.
+func IsRemoteConfig(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	return strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://")
+}
+
+// HasRemoteConfig reports whether any comma-separated entry of spec is a URL.
+func HasRemoteConfig(spec string) bool {
+	for _, entry := range strings.Split(spec, ",") {
+		if IsRemoteConfig(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteConfigCacheDir returns the directory holding the last good copy of
+// each remote config and its ETag.
+func RemoteConfigCacheDir() string {
+	home, err := env.HomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "tinyland-cleanup", "remote-config")
+	}
+	return filepath.Join(home, ".cache", "tinyland-cleanup", "remote-config")
+}
+
+// remoteConfigCachePaths returns the cached copy and ETag paths for url.
+func remoteConfigCachePaths(url string) (string, string) {
+	sum := sha256.Sum256([]byte(url))
+	base := filepath.Join(RemoteConfigCacheDir(), hex.EncodeToString(sum[:8]))
+	return base + ".yaml", base + ".etag"
+}
+
+// checkRemoteConfigURL requires https, except for loopback hosts, so a
+// config that decides what gets deleted is not fetched in the clear.
+func checkRemoteConfigURL(u *url.URL) error {
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("remote config must use https unless the host is loopback")
+	}
+	return fmt.Errorf("unsupported remote config scheme %q", u.Scheme)
+}
+
+// fetchRemoteConfig returns the config served at url. A cached copy is
+// revalidated with If-None-Match, and is used instead when the fetch fails
+// or the fetched config is invalid; the returned warning says why. Without
+// a cached copy those failures are errors. A valid fetched config replaces
+// the cached copy. A URL checkRemoteConfigURL rejects is always an error.
+func fetchRemoteConfig(url string) ([]byte, string, error) {
+	if err := validateRemoteConfigURL(url); err != nil {
+		return nil, "", fmt.Errorf("config %s: %w", url, err)
+	}
+	cachePath, etagPath := remoteConfigCachePaths(url)
+	cached, cacheErr := os.ReadFile(cachePath)
+	haveCache := cacheErr == nil
+	var etag string
+	if haveCache {
+		if data, err := os.ReadFile(etagPath); err == nil {
+			etag = strings.TrimSpace(string(data))
+		}
+	}
+
+	data, newETag, err := getRemoteConfig(url, etag)
+	switch {
+	case err != nil && haveCache:
+		return cached, fmt.Sprintf("config %s: fetch failed, using cached copy: %v", url, err), nil
+	case err != nil:
+		return nil, "", fmt.Errorf("config %s: %w", url, err)
+	case data == nil:
+		// 304 Not Modified: the cached copy is current.
+		return cached, "", nil
+	}
+	if err := validateRemoteConfig(data); err != nil {
+		if haveCache {
+			return cached, fmt.Sprintf("config %s: refusing invalid config, using cached copy: %v", url, err), nil
+		}
+		return nil, "", fmt.Errorf("config %s: %w", url, err)
+	}
+	if err := writeRemoteConfigCache(cachePath, etagPath, data, newETag); err != nil {
+		return data, fmt.Sprintf("config %s: could not cache fetched copy: %v", url, err), nil
+	}
+	return data, "", nil
+}
+
+// validateRemoteConfigURL parses rawURL and applies checkRemoteConfigURL.
+func validateRemoteConfigURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	return checkRemoteConfigURL(u)
+}
+
+// getRemoteConfig fetches url, sending etag as If-None-Match when set. It
+// returns nil data on 304 Not Modified.
+func getRemoteConfig(url, etag string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := remoteConfigClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, etag, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, remoteConfigMaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > remoteConfigMaxBytes {
+		return nil, "", fmt.Errorf("config larger than %d bytes", remoteConfigMaxBytes)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// validateRemoteConfig rejects a fetched config that is not a YAML mapping,
+// uses include, or fails the checks LoadConfig applies.
+func validateRemoteConfig(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("top level must be a mapping")
+	}
+	if mappingIndex(root, includeKey) >= 0 {
+		return fmt.Errorf("include is not supported in a remote config")
+	}
+	config := DefaultConfig()
+	if err := root.Decode(config); err != nil {
+		return err
+	}
+	return validateConfig(config)
+}
+
+// writeRemoteConfigCache replaces the cached copy and its ETag.
+func writeRemoteConfigCache(cachePath, etagPath string, data []byte, etag string) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err != nil {
+		return err
+	}
+	// Drop the old ETag first so it never outlives the copy it describes.
+	if err := os.Remove(etagPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if etag == "" {
+		return nil
+	}
+	return os.WriteFile(etagPath, []byte(etag+"\n"), 0o600)
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// remoteConfigServer serves body with an ETag derived from it, answering a
+// matching If-None-Match with 304. status overrides the response when set.
+type remoteConfigServer struct {
+	mu          sync.Mutex
+	body        string
+	status      int
+	notModified int
+}
+
+func (s *remoteConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	etag := `"` + strings.TrimSpace(strings.ReplaceAll(s.body, "\n", "|")) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(s.body))
+}
+
+func (s *remoteConfigServer) set(body string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.status = body, status
+}
+
+func TestLoadConfigFetchesAndCachesRemoteConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	remote := &remoteConfigServer{body: "poll_interval: 120\nthresholds:\n  warning: 70\n"}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+	url := server.URL + "/fleet.yaml"
+
+	cfg, err := LoadConfig(url)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.PollInterval != 120 || cfg.Thresholds.Warning != 70 || len(cfg.RemoteWarnings) != 0 {
+		t.Fatalf("unexpected remote config: poll %d, warning %d, warnings %v", cfg.PollInterval, cfg.Thresholds.Warning, cfg.RemoteWarnings)
+	}
+
+	if cfg, err = LoadConfig(url); err != nil || cfg.PollInterval != 120 {
+		t.Fatalf("revalidated load failed: %v", err)
+	}
+	if remote.notModified != 1 {
+		t.Fatalf("expected the unchanged config to be revalidated with If-None-Match, got %d 304s", remote.notModified)
+	}
+
+	remote.set("", http.StatusServiceUnavailable)
+	cfg, err = LoadConfig(url)
+	if err != nil {
+		t.Fatalf("expected the cached copy after a failed fetch: %v", err)
+	}
+	if cfg.PollInterval != 120 || len(cfg.RemoteWarnings) != 1 {
+		t.Fatalf("expected cached config with a warning, got poll %d, warnings %v", cfg.PollInterval, cfg.RemoteWarnings)
+	}
+
+	remote.set("poll_interval: 30\nconfig:\n  refresh_minutes: -1\n", 0)
+	cfg, err = LoadConfig(url)
+	if err != nil {
+		t.Fatalf("expected the cached copy for an invalid config: %v", err)
+	}
+	if cfg.PollInterval != 120 || len(cfg.RemoteWarnings) != 1 || !strings.Contains(cfg.RemoteWarnings[0], "refusing invalid config") {
+		t.Fatalf("expected the invalid config to be refused, got poll %d, warnings %v", cfg.PollInterval, cfg.RemoteWarnings)
+	}
+
+	remote.set("poll_interval: 45\n", 0)
+	if cfg, err = LoadConfig(url); err != nil || cfg.PollInterval != 45 {
+		t.Fatalf("expected the changed config to apply, got %v", err)
+	}
+}
+
+func TestLoadConfigRemoteWithoutCacheFails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	remote := &remoteConfigServer{status: http.StatusNotFound}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	if _, err := LoadConfig(server.URL + "/missing.yaml"); err == nil {
+		t.Fatal("expected a failed fetch without a cached copy to be an error")
+	}
+	remote.set("include: [base.yaml]\n", 0)
+	if _, err := LoadConfig(server.URL + "/include.yaml"); err == nil {
+		t.Fatal("expected include in a remote config to be rejected")
+	}
+}
+
+func TestLoadConfigRejectsPlainHTTPRemoteConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if _, err := LoadConfig("http://config.example.com/fleet.yaml"); err == nil {
+		t.Fatal("expected a plain http config URL to be rejected")
+	}
+
+	for _, rawURL := range []string{"https://config.example.com/fleet.yaml", "http://127.0.0.1:8080/fleet.yaml", "http://localhost/fleet.yaml", "http://[::1]/fleet.yaml"} {
+		if err := validateRemoteConfigURL(rawURL); err != nil {
+			t.Errorf("expected %s to be allowed: %v", rawURL, err)
+		}
+	}
+
+	server := httptest.NewServer(http.RedirectHandler("http://config.example.com/fleet.yaml", http.StatusFound))
+	defer server.Close()
+	if _, err := LoadConfig(server.URL + "/fleet.yaml"); err == nil {
+		t.Fatal("expected a redirect to a plain http URL to be rejected")
+	}
+}
+
+func TestLoadConfigLayersLocalFileOverRemote(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(&remoteConfigServer{body: "poll_interval: 120\nthresholds:\n  warning: 70\n"})
+	defer server.Close()
+	local := writeConfigFile(t, t.TempDir(), "local.yaml", "thresholds:\n  warning: 75\n")
+
+	cfg, err := LoadConfig(server.URL + "/fleet.yaml," + local)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.PollInterval != 120 || cfg.Thresholds.Warning != 75 {
+		t.Fatalf("expected local file to override remote, got poll %d, warning %d", cfg.PollInterval, cfg.Thresholds.Warning)
+	}
+}
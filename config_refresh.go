@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log/slog"
+	"reflect"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// logRemoteConfigWarnings logs each remote -config URL that was served from
+// its cached copy.
+func logRemoteConfigWarnings(logger *slog.Logger, cfg *config.Config) {
+	for _, warning := range cfg.RemoteWarnings {
+		logger.Warn("remote config unavailable; using last good copy", "detail", warning)
+	}
+}
+
+// currentConfig returns the running config. A refresh replaces it rather
+// than editing it, so callers can read the returned snapshot freely.
+func (d *daemon) currentConfig() *config.Config {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+	return d.config
+}
+
+// currentMonitor returns the disk monitor built from the running config.
+func (d *daemon) currentMonitor() *monitor.DiskMonitor {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+	return d.monitor
+}
+
+// keepHomeOnError wraps a config reload so that a config it rejects leaves
+// the running home_override and run_as_user in place.
+func keepHomeOnError(reload func() (*config.Config, error)) func() (*config.Config, error) {
+	return func() (*config.Config, error) {
+		previousOverride, previousUser := env.Settings()
+		cfg, err := reload()
+		if err != nil {
+			env.Configure(previousOverride, previousUser)
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
+
+// refreshConfig re-loads a remote -config every config.refresh_minutes and
+// applies it when it passes the same checks as at startup, keeping the
+// running config otherwise. Thresholds, enables, and plugin settings apply
+// from the next cycle; poll_interval, log, and observability settings apply
+// on restart.
+func (d *daemon) refreshConfig() {
+	next, err := d.reloadConfig()
+	if err != nil {
+		d.logger.Warn("config refresh failed; keeping the running config", "error", err)
+		return
+	}
+	logRemoteConfigWarnings(d.logger, next)
+	next.RemoteWarnings = nil
+
+	// Holding the run lock keeps a cycle from seeing the config change
+	// partway through.
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	current := *d.currentConfig()
+	current.RemoteWarnings = nil
+	if reflect.DeepEqual(&current, next) {
+		d.logger.Debug("remote config unchanged")
+		return
+	}
+	d.configMu.Lock()
+	d.config = next
+	d.monitor = monitor.NewDiskMonitor(
+		next.Thresholds.Warning,
+		next.Thresholds.Moderate,
+		next.Thresholds.Aggressive,
+		next.Thresholds.Critical,
+	)
+	d.configMu.Unlock()
+	plugins.ConfigureAccounting(next.Safety)
+	d.logger.Info("applied refreshed config",
+		"warning", next.Thresholds.Warning,
+		"moderate", next.Thresholds.Moderate,
+		"aggressive", next.Thresholds.Aggressive,
+		"critical", next.Thresholds.Critical,
+	)
+}
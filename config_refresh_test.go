@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+func TestRefreshConfigAppliesValidConfigAndKeepsRunningOnError(t *testing.T) {
+	var output bytes.Buffer
+	daemon := newTestDaemon(t, &reportingPlugin{name: "cache"}, &output)
+	running := daemon.config
+
+	daemon.reloadConfig = func() (*config.Config, error) {
+		return nil, errors.New("invalid remote config")
+	}
+	daemon.refreshConfig()
+	if daemon.config != running {
+		t.Fatal("a failed refresh replaced the running config")
+	}
+
+	next := config.DefaultConfig()
+	next.Thresholds.Warning = 60
+	next.Enable.Docker = false
+	next.RemoteWarnings = []string{"fetch failed, using cached copy"}
+	daemon.reloadConfig = func() (*config.Config, error) { return next, nil }
+	daemon.refreshConfig()
+	if daemon.config != next || daemon.monitor.ThresholdWarning != 60 {
+		t.Fatalf("expected the refreshed config to apply, got warning threshold %g", daemon.monitor.ThresholdWarning)
+	}
+	if daemon.config.Enable.Docker {
+		t.Fatal("expected refreshed enables to apply")
+	}
+}
+
+func TestKeepHomeOnErrorRestoresHome(t *testing.T) {
+	home := t.TempDir()
+	env.Configure(home, "")
+	t.Cleanup(func() { env.Configure("", "") })
+
+	reload := keepHomeOnError(func() (*config.Config, error) {
+		env.Configure(t.TempDir(), "nobody")
+		return nil, errors.New("unknown plugin in plugin_order")
+	})
+	if _, err := reload(); err == nil {
+		t.Fatal("expected the reload error to be returned")
+	}
+	if override, user := env.Settings(); override != home || user != "" {
+		t.Fatalf("expected home %q to be restored, got override=%q user=%q", home, override, user)
+	}
+}
+
+func TestRefreshConfigSwapsUnderReaders(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{name: "cache"}, io.Discard)
+	warning := 60
+	daemon.reloadConfig = func() (*config.Config, error) {
+		next := config.DefaultConfig()
+		next.Thresholds.Warning = warning
+		warning++
+		return next, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			daemon.cycleStallLimit()
+			daemon.notifyCoalesceWindow()
+			daemon.currentMonitor()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		daemon.refreshConfig()
+	}
+	<-done
+
+	if got := daemon.currentConfig().Thresholds.Warning; got != 159 {
+		t.Fatalf("expected the last refresh to apply, got warning threshold %d", got)
+	}
+}
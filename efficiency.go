@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// pluginEfficiency returns bytes freed per wall-clock second, or zero when
+// nothing was freed or no time was measured.
+func pluginEfficiency(bytesFreed int64, elapsed time.Duration) int64 {
+	if bytesFreed <= 0 || elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(bytesFreed) / elapsed.Seconds())
+}
+
+// efficiencyRanking returns the plugins that freed space this cycle, most
+// bytes per second first.
+func efficiencyRanking(reports []pluginCycleReport) []string {
+	ranked := make([]pluginCycleReport, 0, len(reports))
+	for _, report := range reports {
+		if report.BytesPerSecond > 0 {
+			ranked = append(ranked, report)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].BytesPerSecond > ranked[j].BytesPerSecond })
+	names := make([]string, 0, len(ranked))
+	for _, report := range ranked {
+		names = append(names, report.Name)
+	}
+	return names
+}
+
+// orderByEfficiency reorders list by each plugin's last recorded bytes per
+// second, highest first. Plugins without a measurement keep their place
+// ahead of measured ones, so a new or never-productive plugin still runs
+// once to be measured.
+func orderByEfficiency(list []plugins.Plugin, state *cleanupState) []plugins.Plugin {
+	rate := func(p plugins.Plugin) int64 {
+		if state == nil {
+			return 0
+		}
+		return state.Plugins[p.Name()].LastBytesPerSecond
+	}
+	ordered := append([]plugins.Plugin(nil), list...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		left, right := rate(ordered[i]), rate(ordered[j])
+		if left == 0 || right == 0 {
+			return left == 0 && right != 0
+		}
+		return left > right
+	})
+	return ordered
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestPluginEfficiency(t *testing.T) {
+	if got := pluginEfficiency(10<<20, 2*time.Second); got != 5<<20 {
+		t.Fatalf("expected 5 MiB/s, got %d", got)
+	}
+	if pluginEfficiency(0, time.Second) != 0 || pluginEfficiency(1<<20, 0) != 0 {
+		t.Fatal("expected zero without bytes freed or a measured duration")
+	}
+}
+
+func TestEfficiencyRankingAndOrder(t *testing.T) {
+	ranking := efficiencyRanking([]pluginCycleReport{
+		{Name: "lima", BytesPerSecond: 10},
+		{Name: "cache"},
+		{Name: "docker", BytesPerSecond: 500},
+	})
+	if want := []string{"docker", "lima"}; !reflect.DeepEqual(ranking, want) {
+		t.Fatalf("ranking = %v, want %v", ranking, want)
+	}
+
+	state := newCleanupState()
+	for name, rate := range map[string]int64{"lima": 10, "docker": 500} {
+		state.recordPluginRun(name, plugins.LevelModerate, time.Now(), plugins.CleanupResult{})
+		state.recordEfficiency(name, rate)
+	}
+	list := []plugins.Plugin{
+		&reportingPlugin{name: "lima"},
+		&reportingPlugin{name: "new"},
+		&reportingPlugin{name: "docker"},
+	}
+	var got []string
+	for _, p := range orderByEfficiency(list, state) {
+		got = append(got, p.Name())
+	}
+	if want := []string{"new", "docker", "lima"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want unmeasured first then fastest: %v", got, want)
+	}
+}
+
+func TestRunOnceReportsPluginEfficiency(t *testing.T) {
+	var output bytes.Buffer
+	mock := &reportingPlugin{result: plugins.CleanupResult{BytesFreed: 64 << 20, ItemsCleaned: 1}}
+	daemon := newTestDaemonWithPlugins(t, &output, mock)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 100, 90))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	if len(report.Plugins) != 1 || report.Plugins[0].DurationSeconds <= 0 || report.Plugins[0].BytesPerSecond <= 0 {
+		t.Fatalf("expected a measured duration and rate, got %+v", report.Plugins)
+	}
+	if !reflect.DeepEqual(report.EfficiencyRanking, []string{"reporting"}) {
+		t.Fatalf("unexpected efficiency ranking %v", report.EfficiencyRanking)
+	}
+	state, err := loadCleanupState(daemon.config.Policy.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Plugins["reporting"].LastBytesPerSecond != report.Plugins[0].BytesPerSecond {
+		t.Fatalf("expected the rate saved to state, got %+v", state.Plugins["reporting"])
+	}
+}
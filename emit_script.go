@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// scriptedDeleteActions are plan target actions the daemon performs by
+// removing the target path, so `rm -rf` reproduces them exactly. Other
+// actions go through tool-specific commands and are left as comments.
+var scriptedDeleteActions = map[string]bool{
+	"delete":                true,
+	"delete_cache_tier":     true,
+	"delete_device_support": true,
+	"delete_simulator_logs": true,
+}
+
+// writeCleanupScriptFile writes the dry-run report as a reviewable shell
+// script for -emit-script. The file is not executable; run it with sh.
+func writeCleanupScriptFile(path string, report cycleReport) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writeCleanupScript(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeCleanupScript renders the commands and deletions a dry-run cycle
+// planned. Command-oriented plugins contribute their CleanupCommands;
+// file-deleting plugins contribute one `rm -rf` per eligible target with its
+// size. Protected, active, and unscripted targets are listed as comments, and
+// deletions of shallow or home-level paths are commented out behind a GUARD
+// note so they only run after deliberate review.
+func writeCleanupScript(w io.Writer, report cycleReport) error {
+	home, homeErr := env.HomeDir()
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "#!/bin/sh")
+	fmt.Fprintf(out, "# tinyland-cleanup dry-run script, generated %s at level %s.\n", report.Timestamp, report.Level)
+	fmt.Fprintln(out, "# Review every line before running it with sh. Sizes are dry-run estimates.")
+	if homeErr != nil {
+		fmt.Fprintf(out, "# %s: every deletion is guarded.\n", homeErr)
+		home = ""
+	}
+	fmt.Fprintln(out, "set -eu")
+
+	var scriptedBytes int64
+	for _, plugin := range report.Plugins {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "# == %s (%s)\n", plugin.Name, plugin.Level)
+		if plugin.SkipReason != "" && plugin.SkipReason != "dry_run" {
+			fmt.Fprintf(out, "# skipped: %s\n", plugin.SkipReason)
+			continue
+		}
+		plan := plugin.Plan
+		if plan != nil && !plan.WouldRun && plan.SkipReason != "" {
+			fmt.Fprintf(out, "# skipped: %s\n", plan.SkipReason)
+			continue
+		}
+		if len(plugin.Commands) > 0 {
+			if plan != nil && plan.EstimatedBytesFreed > 0 {
+				fmt.Fprintf(out, "# estimated reclaim: %s\n", formatByteCount(plan.EstimatedBytesFreed))
+			}
+			for _, args := range plugin.Commands {
+				fmt.Fprintln(out, shellJoin(args))
+			}
+			continue
+		}
+		if plan == nil {
+			fmt.Fprintln(out, "# no plan available; nothing scripted")
+			continue
+		}
+		scripted := 0
+		for _, target := range plan.Targets {
+			line, bytes := cleanupScriptTarget(target, home)
+			fmt.Fprintln(out, line)
+			if bytes > 0 {
+				scripted++
+				scriptedBytes += bytes
+			}
+		}
+		if scripted == 0 {
+			fmt.Fprintln(out, "# no deletions scripted")
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "# scripted deletions total %s\n", formatByteCount(scriptedBytes))
+	return out.Flush()
+}
+
+// cleanupScriptTarget renders one plan target and returns the bytes it would
+// delete, or 0 when the line is only a comment.
+func cleanupScriptTarget(target plugins.CleanupTarget, home string) (string, int64) {
+	label := fmt.Sprintf("%s %s, %s", target.Type, target.Name, formatByteCount(target.Bytes))
+	switch {
+	case target.Protected || target.Active:
+		return fmt.Sprintf("# keep (%s): %s %s", target.Action, label, target.Path), 0
+	case target.Path == "" || !scriptedDeleteActions[target.Action]:
+		return fmt.Sprintf("# not scripted (%s): %s %s", target.Action, label, target.Path), 0
+	}
+	command := shellJoin([]string{"rm", "-rf", "--", target.Path})
+	if reason := cleanupScriptGuard(target.Path, home); reason != "" {
+		return fmt.Sprintf("# GUARD: %s; uncomment only after checking this path\n# %s  # %s", reason, command, label), 0
+	}
+	return fmt.Sprintf("%s  # %s", command, label), target.Bytes
+}
+
+// cleanupScriptGuard explains why a path is too risky to delete
+// unattended, or returns "" when it looks like an ordinary artifact path.
+func cleanupScriptGuard(path, home string) string {
+	switch {
+	case strings.ContainsAny(path, "\n\r"):
+		return "path contains a line break"
+	case !filepath.IsAbs(path):
+		return "relative path"
+	case filepath.Clean(path) != path:
+		return "path is not canonical"
+	case strings.Count(path, string(filepath.Separator)) < 3:
+		return "shallow path"
+	case home == "":
+		return "home directory unavailable"
+	case path == home || filepath.Dir(path) == home:
+		return "home directory or one of its top-level entries"
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestRunOnceEmitsDryRunScript(t *testing.T) {
+	var output bytes.Buffer
+	explainer := &explainingPlugin{reportingPlugin: reportingPlugin{name: "docker"}}
+	planner := &planningPlugin{
+		reportingPlugin: reportingPlugin{name: "dev-artifacts"},
+		plan: plugins.CleanupPlan{
+			WouldRun: true,
+			Targets: []plugins.CleanupTarget{
+				{Type: "node_modules", Name: "node_modules", Path: "/work/app/node_modules", Bytes: 3 << 20, Action: "delete"},
+				{Type: "node_modules", Name: "node_modules", Path: "/work/keep/node_modules", Bytes: 1 << 20, Action: "protect", Protected: true},
+				{Type: "rust-target", Name: "target", Path: "/work/live/target", Bytes: 1 << 20, Action: "protect", Active: true},
+				{Type: "cache", Name: "everything", Path: "/work", Bytes: 9 << 30, Action: "delete"},
+				{Type: "simulator-runtime", Name: "iOS 17", Path: "/Library/Developer/CoreSimulator/Volumes/iOS_21A", Bytes: 1 << 30, Action: "delete_simulator_runtimes"},
+			},
+		},
+	}
+	daemon := newTestDaemonWithPlugins(t, &output, explainer, planner)
+	daemon.dryRun = true
+	daemon.scriptPath = filepath.Join(t.TempDir(), "cleanup.sh")
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if explainer.called || planner.called {
+		t.Fatal("dry-run script generation should not run plugin cleanup")
+	}
+
+	data, err := os.ReadFile(daemon.scriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := string(data)
+	for _, want := range []string{
+		"#!/bin/sh\n",
+		"set -eu\n",
+		"# == docker (moderate)\ntool prune --level moderate\n",
+		"rm -rf -- /work/app/node_modules  # node_modules node_modules, 3.0 MiB\n",
+		"# keep (protect): node_modules node_modules, 1.0 MiB /work/keep/node_modules\n",
+		"# keep (protect): rust-target target, 1.0 MiB /work/live/target\n",
+		"# GUARD: shallow path; uncomment only after checking this path\n# rm -rf -- /work  #",
+		"# not scripted (delete_simulator_runtimes):",
+		"# scripted deletions total 3.0 MiB\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Fatalf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestCleanupScriptGuard(t *testing.T) {
+	home := "/Users/me"
+	for path, guarded := range map[string]bool{
+		"/Users/me/git/app/node_modules": false,
+		"/Users/me/Library":              true,
+		"/Users/me":                      true,
+		"relative/node_modules":          true,
+		"/tmp/a/../b/c":                  true,
+		"/opt":                           true,
+		"/tmp/build\n/etc/passwd":        true,
+	} {
+		if got := cleanupScriptGuard(path, home) != ""; got != guarded {
+			t.Errorf("cleanupScriptGuard(%q) guarded = %v, want %v", path, got, guarded)
+		}
+	}
+	if reason := cleanupScriptGuard("/Users/me/git/app/node_modules", ""); reason == "" {
+		t.Error("expected every path to be guarded when the home directory is unavailable")
+	}
+}
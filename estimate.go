@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// estimateReport ranks enabled plugins by the bytes their dry-run plans
+// estimate they could reclaim at each cleanup level.
+type estimateReport struct {
+	Timestamp     string           `json:"timestamp"`
+	MonitorPath   string           `json:"monitor_path"`
+	HostFreeBytes uint64           `json:"host_free_bytes"`
+	HostFreeError string           `json:"host_free_error,omitempty"`
+	Plugins       []pluginEstimate `json:"plugins"`
+	// Totals sums the plugin estimates per level.
+	Totals []levelEstimate `json:"totals"`
+}
+
+// pluginEstimate is one plugin's reclaim estimate across levels.
+type pluginEstimate struct {
+	Name string `json:"name"`
+	// Estimated is false for plugins that cannot produce a dry-run plan.
+	Estimated bool            `json:"estimated"`
+	Levels    []levelEstimate `json:"levels,omitempty"`
+	// MaxEstimatedBytes is the largest estimate across levels and sets the rank.
+	MaxEstimatedBytes int64 `json:"max_estimated_bytes"`
+}
+
+// levelEstimate is the estimate at one cleanup level.
+type levelEstimate struct {
+	Level          string `json:"level"`
+	EstimatedBytes int64  `json:"estimated_bytes"`
+	SkipReason     string `json:"skip_reason,omitempty"`
+}
+
+// estimateReclaimable plans every enabled plugin at every action level
+// without cleaning and ranks plugins by their largest estimate. Plugins
+// without a dry-run plan are listed last as not estimated.
+func (d *daemon) estimateReclaimable(ctx context.Context) estimateReport {
+	cfg := d.currentConfig()
+	assessment := d.assessMounts()
+	report := estimateReport{
+		Timestamp:   d.currentTime().UTC().Format(time.RFC3339),
+		MonitorPath: d.primaryMonitorPath(assessment),
+	}
+	if stats, err := d.getDiskStats(report.MonitorPath); err != nil {
+		report.HostFreeError = err.Error()
+	} else {
+		report.HostFreeBytes = stats.Free
+	}
+
+	levels := plugins.ActionLevels()
+	totals := make([]int64, len(levels))
+	for _, p := range executionOrder(filterEnabledPlugins(d.registry.GetEnabled(cfg), d.pluginFilter), cfg.PluginOrder) {
+		estimate := pluginEstimate{Name: p.Name()}
+		planner, ok := p.(plugins.Planner)
+		if ok {
+			estimate.Estimated = true
+			for i, level := range levels {
+				if ctx.Err() != nil {
+					break
+				}
+				plan := planner.PlanCleanup(ctx, level, cfg, d.logger)
+				entry := levelEstimate{Level: level.String(), SkipReason: plan.SkipReason}
+				if plan.WouldRun {
+					entry.EstimatedBytes = plan.EstimatedBytesFreed
+				}
+				totals[i] += entry.EstimatedBytes
+				if entry.EstimatedBytes > estimate.MaxEstimatedBytes {
+					estimate.MaxEstimatedBytes = entry.EstimatedBytes
+				}
+				estimate.Levels = append(estimate.Levels, entry)
+			}
+		}
+		report.Plugins = append(report.Plugins, estimate)
+	}
+
+	sort.SliceStable(report.Plugins, func(i, j int) bool {
+		a, b := report.Plugins[i], report.Plugins[j]
+		if a.Estimated != b.Estimated {
+			return a.Estimated
+		}
+		return a.MaxEstimatedBytes > b.MaxEstimatedBytes
+	})
+	for i, level := range levels {
+		report.Totals = append(report.Totals, levelEstimate{Level: level.String(), EstimatedBytes: totals[i]})
+	}
+	if d.redactor != nil {
+		report.MonitorPath = d.redactor.Path(report.MonitorPath)
+		report.HostFreeError = d.redactor.String(report.HostFreeError)
+		for i := range report.Plugins {
+			for j := range report.Plugins[i].Levels {
+				report.Plugins[i].Levels[j].SkipReason = d.redactor.String(report.Plugins[i].Levels[j].SkipReason)
+			}
+		}
+	}
+	return report
+}
+
+func writeEstimate(w io.Writer, output string, report estimateReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if _, err := fmt.Fprintln(w, "tinyland-cleanup reclaimable estimate"); err != nil {
+		return err
+	}
+	free := formatByteCount(int64(report.HostFreeBytes))
+	if report.HostFreeError != "" {
+		free = "unknown (" + report.HostFreeError + ")"
+	}
+	if _, err := fmt.Fprintf(w, "monitor: %s, free %s\n\n", report.MonitorPath, free); err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"PLUGIN"}
+	for _, total := range report.Totals {
+		header = append(header, strings.ToUpper(total.Level))
+	}
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+	for _, plugin := range report.Plugins {
+		row := []string{plugin.Name}
+		if !plugin.Estimated {
+			row = append(row, "no estimate")
+		}
+		for _, level := range plugin.Levels {
+			cell := formatByteCount(level.EstimatedBytes)
+			if level.SkipReason != "" {
+				cell += " (" + level.SkipReason + ")"
+			}
+			row = append(row, cell)
+		}
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	row := []string{"total"}
+	for _, total := range report.Totals {
+		row = append(row, formatByteCount(total.EstimatedBytes))
+	}
+	fmt.Fprintln(table, strings.Join(row, "\t"))
+	return table.Flush()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+type levelPlanningPlugin struct {
+	reportingPlugin
+	perLevel int64
+}
+
+func (p *levelPlanningPlugin) PlanCleanup(_ context.Context, level plugins.CleanupLevel, _ *config.Config, _ *slog.Logger) plugins.CleanupPlan {
+	if level == plugins.LevelWarning {
+		return plugins.CleanupPlan{WouldRun: false, SkipReason: "report_only", EstimatedBytesFreed: 999}
+	}
+	return plugins.CleanupPlan{WouldRun: true, EstimatedBytesFreed: p.perLevel * int64(level)}
+}
+
+func TestEstimateReclaimableRanksPluginsWithoutCleaning(t *testing.T) {
+	small := &levelPlanningPlugin{reportingPlugin: reportingPlugin{name: "small"}, perLevel: 1 << 20}
+	large := &levelPlanningPlugin{reportingPlugin: reportingPlugin{name: "large"}, perLevel: 1 << 30}
+	unplanned := &reportingPlugin{name: "unplanned"}
+	daemon := newTestDaemonWithPlugins(t, &bytes.Buffer{}, unplanned, small, large)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 250, 75))
+
+	report := daemon.estimateReclaimable(context.Background())
+	if small.called || large.called || unplanned.called {
+		t.Fatal("estimate must not run cleanup")
+	}
+	var names []string
+	for _, plugin := range report.Plugins {
+		names = append(names, plugin.Name)
+	}
+	if got := strings.Join(names, ","); got != "large,small,unplanned" {
+		t.Fatalf("rank = %q, want large,small,unplanned", got)
+	}
+	if report.Plugins[0].MaxEstimatedBytes != 4<<30 || report.Plugins[2].Estimated {
+		t.Fatalf("unexpected estimates %+v", report.Plugins)
+	}
+	if warning := report.Plugins[0].Levels[0]; warning.EstimatedBytes != 0 || warning.SkipReason != "report_only" {
+		t.Fatalf("skipped level should not count toward estimate: %+v", warning)
+	}
+	if report.HostFreeBytes != 250 {
+		t.Fatalf("host free = %d, want 250", report.HostFreeBytes)
+	}
+	if len(report.Totals) != 4 || report.Totals[3].Level != "critical" || report.Totals[3].EstimatedBytes != 4<<30+4<<20 {
+		t.Fatalf("unexpected totals %+v", report.Totals)
+	}
+}
+
+func TestWriteEstimateTextAndJSON(t *testing.T) {
+	report := estimateReport{
+		MonitorPath:   "/",
+		HostFreeBytes: 2 << 30,
+		Plugins: []pluginEstimate{
+			{Name: "docker", Estimated: true, MaxEstimatedBytes: 3 << 30, Levels: []levelEstimate{
+				{Level: "warning", EstimatedBytes: 1 << 30},
+				{Level: "critical", EstimatedBytes: 3 << 30},
+			}},
+			{Name: "yum"},
+		},
+		Totals: []levelEstimate{{Level: "warning", EstimatedBytes: 1 << 30}, {Level: "critical", EstimatedBytes: 3 << 30}},
+	}
+
+	var text bytes.Buffer
+	if err := writeEstimate(&text, "text", report); err != nil {
+		t.Fatalf("writeEstimate text: %v", err)
+	}
+	for _, want := range []string{"monitor: /, free 2.0 GiB", "WARNING", "CRITICAL", "docker", "3.0 GiB", "yum", "no estimate", "total"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("estimate text missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeEstimate(&out, "json", report); err != nil {
+		t.Fatalf("writeEstimate json: %v", err)
+	}
+	var decoded estimateReport
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode estimate JSON: %v", err)
+	}
+	if len(decoded.Plugins) != 2 || decoded.Plugins[0].MaxEstimatedBytes != 3<<30 {
+		t.Fatalf("unexpected decoded estimate %+v", decoded)
+	}
+}
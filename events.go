@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+)
+
+// cycleEvent is one -events-json line. Events are written as they happen;
+// every cycle ends with a cycle_summary event carrying the full report.
+type cycleEvent struct {
+	Time                string       `json:"time"`
+	Type                string       `json:"type"`
+	Level               string       `json:"level,omitempty"`
+	DryRun              bool         `json:"dry_run,omitempty"`
+	MonitorPath         string       `json:"monitor_path,omitempty"`
+	WatchPath           string       `json:"watch_path,omitempty"`
+	Plugin              string       `json:"plugin,omitempty"`
+	BytesFreed          int64        `json:"bytes_freed,omitempty"`
+	EstimatedBytesFreed int64        `json:"estimated_bytes_freed,omitempty"`
+	ItemsCleaned        int          `json:"items_cleaned,omitempty"`
+	DurationSeconds     float64      `json:"duration_seconds,omitempty"`
+	BytesPerSecond      int64        `json:"bytes_per_second,omitempty"`
+	Cancelled           bool         `json:"cancelled,omitempty"`
+	Error               string       `json:"error,omitempty"`
+	Report              *cycleReport `json:"report,omitempty"`
+}
+
+// Event types written by eventStream.
+const (
+	eventCycleStart   = "cycle_start"
+	eventPluginStart  = "plugin_start"
+	eventPluginEnd    = "plugin_end"
+	eventCycleSummary = "cycle_summary"
+)
+
+// eventStream writes newline-delimited JSON events. A nil stream discards
+// events, so call sites need no checks when -events-json is off.
+type eventStream struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	now     func() time.Time
+}
+
+func newEventStream(w io.Writer) *eventStream {
+	return &eventStream{encoder: json.NewEncoder(w), now: time.Now}
+}
+
+// emit stamps and writes event. Write errors are dropped: a closed pipe to
+// jq must not stop cleanup.
+func (s *eventStream) emit(event cycleEvent) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.Time = s.now().UTC().Format(time.RFC3339Nano)
+	_ = s.encoder.Encode(event)
+}
+
+// emit writes event to the -events-json stream with paths redacted when
+// log.redact is set.
+func (d *daemon) emit(event cycleEvent) {
+	if d.events == nil {
+		return
+	}
+	event.MonitorPath = d.redactor.Path(event.MonitorPath)
+	event.WatchPath = d.redactor.Path(event.WatchPath)
+	event.Error = d.redactor.String(event.Error)
+	d.events.emit(event)
+}
+
+// emitPluginEnd writes the plugin_end event for a plugin that ran or planned.
+func (d *daemon) emitPluginEnd(level monitor.CleanupLevel, dryRun bool, report pluginCycleReport) {
+	event := cycleEvent{
+		Type:            eventPluginEnd,
+		Level:           level.String(),
+		DryRun:          dryRun,
+		Plugin:          report.Name,
+		BytesFreed:      report.BytesFreed,
+		ItemsCleaned:    report.ItemsCleaned,
+		Cancelled:       report.Cancelled,
+		Error:           report.Error,
+		DurationSeconds: report.DurationSeconds,
+		BytesPerSecond:  report.BytesPerSecond,
+	}
+	if report.Plan != nil {
+		event.EstimatedBytesFreed = report.Plan.EstimatedBytesFreed
+	} else {
+		event.EstimatedBytesFreed = report.EstimatedBytesFreed
+	}
+	d.emit(event)
+}
+
+// emitSummary writes the cycle_summary event for report.
+func (d *daemon) emitSummary(report cycleReport) {
+	if d.events == nil {
+		return
+	}
+	report = d.redactReport(report)
+	d.events.emit(cycleEvent{
+		Type:       eventCycleSummary,
+		Level:      report.Level,
+		DryRun:     report.DryRun,
+		BytesFreed: report.TotalBytesFreed,
+		Report:     &report,
+	})
+}
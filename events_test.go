@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func decodeEvents(t *testing.T, data []byte) []cycleEvent {
+	t.Helper()
+	var events []cycleEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var event cycleEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRunOnceStreamsEvents(t *testing.T) {
+	var report, stream bytes.Buffer
+	freed := &reportingPlugin{name: "freed", result: plugins.CleanupResult{BytesFreed: 4096, ItemsCleaned: 2}}
+	failed := &reportingPlugin{name: "failed", result: plugins.CleanupResult{
+		Error: &plugins.PluginError{Plugin: "failed", Operation: "vm_stop", Err: errors.New("exit status 1")},
+	}}
+	daemon := newTestDaemonWithPlugins(t, &report, freed, failed)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.events = newEventStream(&stream)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	events := decodeEvents(t, stream.Bytes())
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type+":"+event.Plugin)
+	}
+	want := []string{"cycle_start:", "plugin_start:freed", "plugin_end:freed", "plugin_start:failed", "plugin_end:failed", "cycle_summary:"}
+	if len(types) != len(want) {
+		t.Fatalf("unexpected events %v", types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("unexpected events %v", types)
+		}
+	}
+	if events[2].BytesFreed != 4096 || events[2].ItemsCleaned != 2 {
+		t.Errorf("unexpected plugin_end for freed: %+v", events[2])
+	}
+	if events[4].Error == "" {
+		t.Errorf("expected the failed plugin's error in its plugin_end: %+v", events[4])
+	}
+	summary := events[5]
+	if summary.Report == nil || summary.BytesFreed != 4096 || len(summary.Report.Plugins) != 2 {
+		t.Errorf("unexpected cycle_summary: %+v", summary)
+	}
+	if report.Len() == 0 {
+		t.Error("expected the -output report to be written to its own writer")
+	}
+}
+
+func TestCycleSummaryEmittedWithoutCleanupLevel(t *testing.T) {
+	var stream bytes.Buffer
+	daemon := newTestDaemonWithPlugins(t, &bytes.Buffer{}, &reportingPlugin{})
+	daemon.events = newEventStream(&stream)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 900, 10))
+
+	daemon.runCycle(context.Background(), monitor.LevelNone, false)
+
+	events := decodeEvents(t, stream.Bytes())
+	if len(events) != 2 || events[0].Type != eventCycleStart || events[1].Type != eventCycleSummary || events[1].Report == nil {
+		t.Fatalf("expected cycle_start and cycle_summary, got %+v", events)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// explainProtectionPlugins are the filesystem-scanning plugins
+// -explain-protection covers when -plugins is not given.
+var explainProtectionPlugins = []string{"dev-artifacts", "cache", "scratch", "junk-files", "app-logs", "electron-apps", "external-trash", "bazel"}
+
+// explainProtectionLevel is the level -explain-protection plans at when
+// -level is not given: the first level that deletes.
+const explainProtectionLevel = monitor.LevelModerate
+
+// protectionReport lists, per plugin, every candidate a dry-run plan found
+// with whether it would be deleted or kept and why.
+type protectionReport struct {
+	Level   string                     `json:"level"`
+	Plugins []pluginProtectionDecision `json:"plugins"`
+}
+
+// pluginProtectionDecision is one plugin's candidates and their counts.
+type pluginProtectionDecision struct {
+	Name         string               `json:"name"`
+	Deleted      int                  `json:"deleted"`
+	DeletedBytes int64                `json:"deleted_bytes"`
+	Kept         int                  `json:"kept"`
+	KeptBytes    int64                `json:"kept_bytes"`
+	SkipReason   string               `json:"skip_reason,omitempty"`
+	Candidates   []protectionDecision `json:"candidates,omitempty"`
+}
+
+// protectionDecision is one candidate: delete or keep, and the reason.
+type protectionDecision struct {
+	Decision string `json:"decision"`
+	Type     string `json:"type"`
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// explainProtection plans the enabled filesystem-scanning plugins at level
+// without cleaning and classifies every plan target. Protected targets are
+// kept; the rest would be deleted. Paths are redacted when log.redact is set.
+func (d *daemon) explainProtection(ctx context.Context, level monitor.CleanupLevel) protectionReport {
+	report := d.planProtection(ctx, level)
+	if d.redactor != nil {
+		for i := range report.Plugins {
+			for j := range report.Plugins[i].Candidates {
+				candidate := &report.Plugins[i].Candidates[j]
+				candidate.Path = d.redactor.Path(candidate.Path)
+				candidate.Reason = d.redactor.String(candidate.Reason)
+			}
+		}
+	}
+	return report
+}
+
+// planProtection is explainProtection without redaction.
+func (d *daemon) planProtection(ctx context.Context, level monitor.CleanupLevel) protectionReport {
+	cfg := d.currentConfig()
+	filter := d.pluginFilter
+	if len(filter) == 0 {
+		filter = explainProtectionPlugins
+	}
+	report := protectionReport{Level: level.String()}
+	for _, p := range executionOrder(filterEnabledPlugins(d.registry.GetEnabled(cfg), filter), cfg.PluginOrder) {
+		if ctx.Err() != nil {
+			break
+		}
+		entry := pluginProtectionDecision{Name: p.Name()}
+		planner, ok := p.(plugins.Planner)
+		if !ok {
+			entry.SkipReason = "no_plan"
+			report.Plugins = append(report.Plugins, entry)
+			continue
+		}
+		plan := planner.PlanCleanup(ctx, plugins.CleanupLevel(level), cfg, d.logger)
+		entry.SkipReason = plan.SkipReason
+		for _, target := range plan.Targets {
+			decision := protectionDecision{
+				Decision: "delete",
+				Type:     target.Type,
+				Path:     target.Path,
+				Bytes:    target.Bytes,
+				Action:   target.Action,
+				Reason:   target.Reason,
+			}
+			if target.Protected || !plan.WouldRun {
+				decision.Decision = "keep"
+				entry.Kept++
+				entry.KeptBytes += target.Bytes
+			} else {
+				entry.Deleted++
+				entry.DeletedBytes += target.Bytes
+			}
+			entry.Candidates = append(entry.Candidates, decision)
+		}
+		report.Plugins = append(report.Plugins, entry)
+	}
+	return report
+}
+
+func writeProtectionReport(w io.Writer, output string, report protectionReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if _, err := fmt.Fprintf(w, "tinyland-cleanup protection explanation at %s (dry run, nothing deleted)\n", report.Level); err != nil {
+		return err
+	}
+	for _, plugin := range report.Plugins {
+		header := fmt.Sprintf("\n%s: %d delete (%s), %d keep (%s)",
+			plugin.Name,
+			plugin.Deleted, formatByteCount(plugin.DeletedBytes),
+			plugin.Kept, formatByteCount(plugin.KeptBytes),
+		)
+		if plugin.SkipReason != "" {
+			header += " [" + plugin.SkipReason + "]"
+		}
+		if _, err := fmt.Fprintln(w, header); err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, candidate := range plugin.Candidates {
+			fmt.Fprintf(table, "  %s\t%s\t%s\t%s\n", candidate.Decision, formatByteCount(candidate.Bytes), candidate.Path, candidate.Reason)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+type targetPlanningPlugin struct {
+	reportingPlugin
+	targets []plugins.CleanupTarget
+}
+
+func (p *targetPlanningPlugin) PlanCleanup(_ context.Context, level plugins.CleanupLevel, _ *config.Config, _ *slog.Logger) plugins.CleanupPlan {
+	return plugins.CleanupPlan{Level: level.String(), WouldRun: true, Targets: p.targets}
+}
+
+func TestExplainProtectionClassifiesScanningPluginTargets(t *testing.T) {
+	devArtifacts := &targetPlanningPlugin{reportingPlugin: reportingPlugin{name: "dev-artifacts"}, targets: []plugins.CleanupTarget{
+		{Type: "node_modules", Path: "/src/old/node_modules", Bytes: 300, Action: "delete", Reason: "project marker package.json is stale for 30 days"},
+		{Type: "node_modules", Path: "/src/keep/node_modules", Bytes: 200, Action: "protect", Protected: true, Reason: "path is covered by dev_artifacts.protect_paths entry /src/keep"},
+		{Type: "rust-target", Path: "/src/new/target", Bytes: 100, Action: "protect", Protected: true, Reason: "project marker Cargo.toml is newer than 30 days (modified 2 days ago)"},
+	}}
+	docker := &targetPlanningPlugin{reportingPlugin: reportingPlugin{name: "docker"}, targets: []plugins.CleanupTarget{{Path: "image", Bytes: 1}}}
+	daemon := newTestDaemonWithPlugins(t, &bytes.Buffer{}, devArtifacts, docker)
+
+	report := daemon.explainProtection(context.Background(), monitor.LevelModerate)
+	if devArtifacts.called || docker.called {
+		t.Fatal("explain-protection must not run cleanup")
+	}
+	if len(report.Plugins) != 1 || report.Plugins[0].Name != "dev-artifacts" {
+		t.Fatalf("expected only the scanning plugin, got %+v", report.Plugins)
+	}
+	plugin := report.Plugins[0]
+	if plugin.Deleted != 1 || plugin.DeletedBytes != 300 || plugin.Kept != 2 || plugin.KeptBytes != 300 {
+		t.Fatalf("unexpected counts %+v", plugin)
+	}
+	if plugin.Candidates[1].Decision != "keep" || !strings.Contains(plugin.Candidates[1].Reason, "/src/keep") {
+		t.Fatalf("expected the protected candidate kept with its protect path, got %+v", plugin.Candidates[1])
+	}
+
+	var text bytes.Buffer
+	if err := writeProtectionReport(&text, "text", report); err != nil {
+		t.Fatalf("writeProtectionReport text: %v", err)
+	}
+	for _, want := range []string{"nothing deleted", "dev-artifacts: 1 delete", "2 keep", "delete", "/src/old/node_modules", "modified 2 days ago"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("explanation text missing %q:\n%s", want, text.String())
+		}
+	}
+	var out bytes.Buffer
+	if err := writeProtectionReport(&out, "json", report); err != nil {
+		t.Fatalf("writeProtectionReport json: %v", err)
+	}
+	var decoded protectionReport
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded.Plugins[0].Candidates) != 3 {
+		t.Fatalf("unexpected decoded explanation %+v (%v)", decoded, err)
+	}
+}
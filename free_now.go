@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+)
+
+// freeNowPlugins are the plugins -free-now runs: cache clears with the user
+// journal vacuum, and container image, container, and build cache prunes.
+// Plugins that are disabled or not built for this platform are left out.
+var freeNowPlugins = []string{"cache", "system-caches", "docker", "podman"}
+
+// freeNowLevel is the level -free-now runs at. At moderate, freeNowPlugins
+// prune without removing volumes, stopping VMs, or compacting disks.
+const freeNowLevel = monitor.LevelModerate
+
+// freeNowDeadline bounds a -free-now cycle when -max-runtime is not set.
+const freeNowDeadline = 60 * time.Second
+
+// validateFreeNow rejects flags that -free-now replaces or that do not fit a
+// single immediate run.
+func validateFreeNow(pluginFilter []string, level string, daemonMode bool) error {
+	switch {
+	case len(pluginFilter) > 0:
+		return fmt.Errorf("-free-now picks its own plugins; drop -plugins")
+	case level != "":
+		return fmt.Errorf("-free-now runs at %s; drop -level", freeNowLevel)
+	case daemonMode:
+		return fmt.Errorf("-free-now runs once and exits; drop -daemon")
+	}
+	return nil
+}
+
+// freeNowMaxRuntime returns the -free-now cycle deadline: maxRuntime when
+// set and freeNowDeadline otherwise.
+func freeNowMaxRuntime(maxRuntime time.Duration) time.Duration {
+	if maxRuntime > 0 {
+		return maxRuntime
+	}
+	return freeNowDeadline
+}
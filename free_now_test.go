@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateFreeNow(t *testing.T) {
+	if err := validateFreeNow(nil, "", false); err != nil {
+		t.Fatalf("plain -free-now rejected: %v", err)
+	}
+	if err := validateFreeNow([]string{"docker"}, "", false); err == nil {
+		t.Fatal("expected -plugins to be rejected")
+	}
+	if err := validateFreeNow(nil, "critical", false); err == nil {
+		t.Fatal("expected -level to be rejected")
+	}
+	if err := validateFreeNow(nil, "", true); err == nil {
+		t.Fatal("expected -daemon to be rejected")
+	}
+}
+
+func TestFreeNowMaxRuntime(t *testing.T) {
+	if got := freeNowMaxRuntime(0); got != freeNowDeadline {
+		t.Fatalf("default deadline = %s, want %s", got, freeNowDeadline)
+	}
+	if got := freeNowMaxRuntime(5 * time.Minute); got != 5*time.Minute {
+		t.Fatalf("-max-runtime not honored: %s", got)
+	}
+}
+
+func TestRunOnceFreeNowRunsCuratedPluginsPastTarget(t *testing.T) {
+	var output bytes.Buffer
+	cache := &reportingPlugin{name: "cache"}
+	docker := &reportingPlugin{name: "docker"}
+	podman := &heavyPlugin{reportingPlugin{name: "podman"}}
+	bazel := &reportingPlugin{name: "bazel"}
+	daemon := newTestDaemonWithPlugins(t, &output, cache, docker, podman, bazel)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.TargetFree = 70
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 400, 60))
+	daemon.pluginFilter = freeNowPlugins
+	daemon.freeNow = true
+
+	if err := daemon.runOnce(context.Background(), freeNowLevel); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	if !cache.called || !docker.called {
+		t.Fatal("expected curated plugins to run although target_free is met")
+	}
+	if podman.called {
+		t.Fatal("heavy plugin should not run under -free-now")
+	}
+	if bazel.called {
+		t.Fatal("plugin outside the -free-now set should not run")
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	if !report.FreeNow || report.Level != freeNowLevel.String() {
+		t.Fatalf("expected free-now report at %s, got free_now=%v level=%s", freeNowLevel, report.FreeNow, report.Level)
+	}
+	if len(report.Plugins) != 3 || report.Plugins[2].Name != "podman" || report.Plugins[2].SkipReason != "free_now_heavy" {
+		t.Fatalf("expected podman skipped as free_now_heavy, got %+v", report.Plugins)
+	}
+}
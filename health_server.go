@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+)
+
+const maxTriggerBodyBytes = 4096
+
+// cleanupTriggerRequest is the JSON body accepted by POST /cleanup.
+type cleanupTriggerRequest struct {
+	Level  string `json:"level"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type triggerErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// readyResponse is the /readyz body. LastCycle and Level describe the most
+// recently completed cleanup cycle.
+type readyResponse struct {
+	Ready     bool   `json:"ready"`
+	LastCycle string `json:"last_cycle,omitempty"`
+	Level     string `json:"level,omitempty"`
+}
+
+// cycleHealth records the last completed cycle for /readyz and the
+// heartbeat. Dry-run cycles clean nothing, so they are not recorded.
+type cycleHealth struct {
+	mu    sync.Mutex
+	at    time.Time
+	level string
+	freed int64
+}
+
+func (h *cycleHealth) record(at time.Time, level string, freed int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.at = at
+	h.level = level
+	h.freed = freed
+}
+
+// last returns the last completed cycle's level and freed bytes.
+func (h *cycleHealth) last() (string, int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.level, h.freed
+}
+
+func (h *cycleHealth) ready() readyResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.at.IsZero() {
+		return readyResponse{}
+	}
+	return readyResponse{Ready: true, LastCycle: h.at.UTC().Format(time.RFC3339), Level: h.level}
+}
+
+// newHealthServer builds the daemon's local HTTP server. It returns nil when
+// observability.listen_addr is empty. Triggered cycles run under ctx, the
+// daemon's lifetime, rather than the request's.
+func newHealthServer(ctx context.Context, d *daemon, cfg config.ObservabilityConfig) (*http.Server, error) {
+	if cfg.ListenAddr == "" {
+		return nil, nil
+	}
+	if err := validateLoopbackListenAddr(cfg.ListenAddr); err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           d.healthHandler(ctx, cfg),
+		ReadHeaderTimeout: 5 * time.Second,
+	}, nil
+}
+
+// lookupListenIPs resolves a listen_addr host name; tests replace it.
+var lookupListenIPs = net.LookupIP
+
+// validateLoopbackListenAddr rejects listen addresses that are not bound to
+// localhost, since the trigger endpoint can run cleanup on this host. A host
+// name such as localhost is resolved, and every address it resolves to must
+// be loopback, since /etc/hosts can point it anywhere.
+func validateLoopbackListenAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("observability.listen_addr %q: %w", addr, err)
+	}
+	if host == "" {
+		return fmt.Errorf("observability.listen_addr %q must bind a loopback address", addr)
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = lookupListenIPs(host); err != nil {
+			return fmt.Errorf("observability.listen_addr %q: %w", addr, err)
+		}
+		if len(ips) == 0 {
+			return fmt.Errorf("observability.listen_addr %q: %s resolves to no addresses", addr, host)
+		}
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return fmt.Errorf("observability.listen_addr %q must bind a loopback address, not %s", addr, ip)
+		}
+	}
+	return nil
+}
+
+func (d *daemon) healthHandler(ctx context.Context, cfg config.ObservabilityConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/readyz", d.handleReadyz)
+	if cfg.TriggerToken != "" {
+		mux.HandleFunc("/cleanup", d.handleCleanupTrigger(ctx, cfg.TriggerToken))
+	}
+	return mux
+}
+
+// handleHealthz reports that the process is alive and serving.
+func handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeTriggerJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz returns 503 until the first cleanup cycle completes, then 200
+// with that cycle's time and level. A supervisor can restart a daemon whose
+// last_cycle stops advancing.
+func (d *daemon) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	ready := d.health.ready()
+	status := http.StatusOK
+	if !ready.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeTriggerJSON(w, status, ready)
+}
+
+// handleCleanupTrigger runs one cleanup cycle on request and responds with
+// the same JSON report -output json prints. The cycle shares the daemon's
+// run lock, so a trigger that arrives mid-cycle waits for it to finish. It
+// runs under ctx, so a client that disconnects does not cancel it partway,
+// and gets the same notifications, metrics, and heartbeat as a polled cycle.
+func (d *daemon) handleCleanupTrigger(ctx context.Context, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeTriggerError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !validTriggerToken(r.Header.Get("Authorization"), token) {
+			writeTriggerError(w, http.StatusUnauthorized, "missing or invalid trigger token")
+			return
+		}
+
+		var request cleanupTriggerRequest
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxTriggerBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			writeTriggerError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+
+		level := parseLevel(request.Level)
+		if request.Level != "" && level == monitor.LevelNone {
+			writeTriggerError(w, http.StatusBadRequest, fmt.Sprintf("unknown level %q", request.Level))
+			return
+		}
+
+		dryRun := d.dryRun || request.DryRun
+		d.logger.Info("cleanup triggered over HTTP", "level", request.Level, "dry_run", dryRun)
+		report, err := d.runObservedCycle(ctx, level, dryRun)
+		if err != nil {
+			d.logger.Error("triggered cleanup cycle failed", "error", err)
+		}
+		d.beat(heartbeatOK, false)
+		writeTriggerJSON(w, http.StatusOK, d.redactReport(report))
+	}
+}
+
+func validTriggerToken(header, token string) bool {
+	provided, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+func writeTriggerError(w http.ResponseWriter, status int, message string) {
+	writeTriggerJSON(w, status, triggerErrorResponse{Error: message})
+}
+
+func writeTriggerJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+// serveHealth runs server until ctx is canceled.
+func serveHealth(ctx context.Context, server *http.Server, logger *slog.Logger) {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("serving local HTTP endpoints", "addr", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("local HTTP server failed", "error", err)
+	}
+}
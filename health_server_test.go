@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/observability"
+)
+
+func TestCleanupTriggerRejectsMissingToken(t *testing.T) {
+	mock := &reportingPlugin{}
+	daemon := newTestDaemon(t, mock, io.Discard)
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{TriggerToken: "secret"})
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		request := httptest.NewRequest(http.MethodPost, "/cleanup", strings.NewReader(`{"level":"critical"}`))
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: status = %d, want %d", header, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+	if mock.called {
+		t.Fatal("unauthorized trigger ran a cleanup plugin")
+	}
+}
+
+func TestCleanupTriggerDryRunReturnsReport(t *testing.T) {
+	mock := &reportingPlugin{}
+	daemon := newTestDaemon(t, mock, io.Discard)
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{TriggerToken: "secret"})
+
+	request := httptest.NewRequest(http.MethodPost, "/cleanup", strings.NewReader(`{"level":"moderate","dry_run":true}`))
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	if mock.called {
+		t.Fatal("dry-run trigger called plugin Cleanup")
+	}
+
+	report := decodeCycleReport(t, recorder.Body.Bytes())
+	if !report.DryRun || !report.ForcedLevel || report.Level != "moderate" {
+		t.Fatalf("unexpected report header: %+v", report)
+	}
+	if len(report.Plugins) != 1 || report.Plugins[0].SkipReason != "dry_run" {
+		t.Fatalf("unexpected plugin reports: %+v", report.Plugins)
+	}
+}
+
+func TestCleanupTriggerKeepsDaemonDryRun(t *testing.T) {
+	mock := &reportingPlugin{}
+	daemon := newTestDaemon(t, mock, io.Discard)
+	daemon.dryRun = true
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{TriggerToken: "secret"})
+
+	request := httptest.NewRequest(http.MethodPost, "/cleanup", strings.NewReader(`{"level":"critical","dry_run":false}`))
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if mock.called || !decodeCycleReport(t, recorder.Body.Bytes()).DryRun {
+		t.Fatal("trigger overrode daemon -dry-run")
+	}
+}
+
+func TestCleanupTriggerOutlivesRequestAndIsObserved(t *testing.T) {
+	mock := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemon(t, mock, io.Discard)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 150, 85))
+	fallback := filepath.Join(t.TempDir(), "metrics.jsonl")
+	exporter, err := observability.NewExporter("", fallback)
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	daemon.metrics = exporter
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{TriggerToken: "secret"})
+
+	// The client is already gone when the cycle starts.
+	requestCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest(http.MethodPost, "/cleanup", strings.NewReader(`{"level":"moderate"}`)).WithContext(requestCtx)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	if !mock.called {
+		t.Fatal("a disconnected client canceled the triggered cycle")
+	}
+	if !daemon.health.ready().Ready {
+		t.Fatal("a triggered cycle did not mark the daemon ready")
+	}
+	if _, err := os.Stat(fallback); err != nil {
+		t.Fatalf("a triggered cycle recorded no metrics: %v", err)
+	}
+}
+
+func TestCleanupTriggerRejectsBadRequests(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{TriggerToken: "secret"})
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{name: "get", method: http.MethodGet, want: http.StatusMethodNotAllowed},
+		{name: "unknown level", method: http.MethodPost, body: `{"level":"extreme"}`, want: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPost, body: `{"level":"warning","force":true}`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/cleanup", strings.NewReader(tt.body))
+			request.Header.Set("Authorization", "Bearer secret")
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanupTriggerDisabledWithoutToken(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{ListenAddr: "127.0.0.1:0"})
+
+	request := httptest.NewRequest(http.MethodPost, "/cleanup", strings.NewReader(`{"level":"warning"}`))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestHealthzAndReadyz(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 150, 85))
+	handler := daemon.healthHandler(context.Background(), config.ObservabilityConfig{ListenAddr: "127.0.0.1:0"})
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	if recorder := get("/healthz"); recorder.Code != http.StatusOK {
+		t.Fatalf("/healthz status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if recorder := get("/readyz"); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz before a cycle: status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	daemon.runCycle(context.Background(), monitor.LevelNone, true)
+	if recorder := get("/readyz"); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz after a dry-run cycle: status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	daemon.runCycle(context.Background(), monitor.LevelNone, false)
+	recorder := get("/readyz")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("/readyz after a cycle: status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	var ready readyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &ready); err != nil {
+		t.Fatalf("decode /readyz: %v", err)
+	}
+	if !ready.Ready || ready.Level != "moderate" || ready.LastCycle == "" {
+		t.Fatalf("unexpected /readyz body %+v", ready)
+	}
+}
+
+func TestValidateLoopbackListenAddr(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:9477", "localhost:9477", "[::1]:9477"} {
+		if err := validateLoopbackListenAddr(addr); err != nil {
+			t.Fatalf("validateLoopbackListenAddr(%q) = %v, want nil", addr, err)
+		}
+	}
+	for _, addr := range []string{":9477", "0.0.0.0:9477", "192.168.1.10:9477", "127.0.0.1"} {
+		if err := validateLoopbackListenAddr(addr); err == nil {
+			t.Fatalf("validateLoopbackListenAddr(%q) = nil, want error", addr)
+		}
+	}
+}
+
+func TestValidateLoopbackListenAddrResolvesNames(t *testing.T) {
+	hosts := map[string][]net.IP{
+		"localhost": {net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		"hijacked":  {net.ParseIP("127.0.0.1"), net.ParseIP("192.168.1.10")},
+		"empty":     nil,
+	}
+	old := lookupListenIPs
+	lookupListenIPs = func(host string) ([]net.IP, error) {
+		ips, ok := hosts[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return ips, nil
+	}
+	t.Cleanup(func() { lookupListenIPs = old })
+
+	if err := validateLoopbackListenAddr("localhost:9477"); err != nil {
+		t.Fatalf("expected a name resolving only to loopback to pass, got %v", err)
+	}
+	for addr, want := range map[string]string{
+		"hijacked:9477": "not 192.168.1.10",
+		"empty:9477":    "no addresses",
+		"missing:9477":  "no such host",
+	} {
+		if err := validateLoopbackListenAddr(addr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("validateLoopbackListenAddr(%q) = %v, want error containing %q", addr, err, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+const (
+	heartbeatOK      = "ok"
+	heartbeatRunning = "running"
+	heartbeatStalled = "stalled"
+	// heartbeatGrace is added to the poll interval before -check-heartbeat
+	// calls a heartbeat stale, covering the idle check between a tick and
+	// its heartbeat.
+	heartbeatGrace = time.Minute
+)
+
+// heartbeat is the observability.heartbeat_path file the daemon rewrites
+// after every poll tick and while a cycle runs.
+type heartbeat struct {
+	Timestamp string `json:"timestamp"`
+	// Status is "ok" after a tick, "running" during a cycle, or "stalled"
+	// once a cycle outlasts the watchdog limit.
+	Status string `json:"status"`
+	// Level is the last completed cycle's level, or "none" after a tick
+	// that found the disk comfortably below warning.
+	Level          string `json:"level"`
+	LastFreedBytes int64  `json:"last_freed_bytes"`
+	PID            int    `json:"pid"`
+}
+
+// heartbeatWriter serializes heartbeat writes from the poll loop and the
+// cycle watchdog.
+type heartbeatWriter struct {
+	path string
+	mu   sync.Mutex
+}
+
+// write replaces the heartbeat file through a temporary file so a reader
+// never sees a partial one.
+func (h *heartbeatWriter) write(beat heartbeat) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(beat, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// newHeartbeatWriter returns the observability.heartbeat_enabled writer, or
+// nil when heartbeats are disabled.
+func (d *daemon) newHeartbeatWriter() *heartbeatWriter {
+	cfg := d.currentConfig().Observability
+	if !cfg.HeartbeatEnabled || cfg.HeartbeatPath == "" {
+		return nil
+	}
+	return &heartbeatWriter{path: expandPathHome(cfg.HeartbeatPath)}
+}
+
+// beat writes a heartbeat with status, taking the level and freed bytes from
+// the last completed cycle unless idle reports a skipped tick.
+func (d *daemon) beat(status string, idle bool) {
+	if d.heartbeat == nil {
+		return
+	}
+	beat := heartbeat{
+		Timestamp: d.currentTime().UTC().Format(time.RFC3339),
+		Status:    status,
+		PID:       os.Getpid(),
+	}
+	beat.Level, beat.LastFreedBytes = d.health.last()
+	if idle || beat.Level == "" {
+		beat.Level = "none"
+	}
+	if err := d.heartbeat.write(beat); err != nil {
+		d.logger.Warn("failed to write heartbeat", "path", d.heartbeat.path, "error", err)
+	}
+}
+
+// cycleStallLimit is observability.plugin_timeout_minutes for each enabled
+// plugin; 0 disables the watchdog.
+func (d *daemon) cycleStallLimit() time.Duration {
+	cfg := d.currentConfig()
+	minutes := cfg.Observability.PluginTimeoutMinutes
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes*len(d.registry.GetEnabled(cfg))) * time.Minute
+}
+
+// heartbeatInterval is how often a running cycle refreshes the heartbeat:
+// the poll interval, so -check-heartbeat sees a long cycle as alive.
+func (d *daemon) heartbeatInterval() time.Duration {
+	if interval := time.Duration(d.currentConfig().PollInterval) * time.Second; interval > 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// startWatchdog watches one cleanup cycle. While the cycle runs it refreshes
+// the heartbeat every interval; past limit it logs a stall warning and marks
+// the heartbeat stalled. The returned function ends the watch and waits for
+// its last write.
+func (d *daemon) startWatchdog(limit, interval time.Duration) func() {
+	if limit <= 0 && d.heartbeat == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var stall <-chan time.Time
+		if limit > 0 {
+			timer := time.NewTimer(limit)
+			defer timer.Stop()
+			stall = timer.C
+		}
+		status := heartbeatRunning
+		for {
+			select {
+			case <-done:
+				return
+			case <-stall:
+				status = heartbeatStalled
+				d.logger.Warn("cleanup cycle stalled", "limit", limit.String())
+				d.beat(status, false)
+			case <-ticker.C:
+				d.beat(status, false)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// checkConfiguredHeartbeat runs -check-heartbeat against cfg's heartbeat
+// file, allowing the poll interval plus heartbeatGrace between beats. It
+// fails when heartbeats are disabled, since no file is being written.
+func checkConfiguredHeartbeat(w io.Writer, cfg *config.Config, now time.Time) error {
+	if !cfg.Observability.HeartbeatEnabled {
+		return errors.New("heartbeat disabled (observability.heartbeat_enabled is false)")
+	}
+	maxAge := time.Duration(cfg.PollInterval)*time.Second + heartbeatGrace
+	return checkHeartbeat(w, expandPathHome(cfg.Observability.HeartbeatPath), maxAge, now)
+}
+
+// checkHeartbeat reads the heartbeat at path and reports an error when it is
+// missing, stalled, or older than maxAge.
+func checkHeartbeat(w io.Writer, path string, maxAge time.Duration, now time.Time) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read heartbeat: %w", err)
+	}
+	var beat heartbeat
+	if err := json.Unmarshal(data, &beat); err != nil {
+		return fmt.Errorf("parse heartbeat %s: %w", path, err)
+	}
+	at, err := time.Parse(time.RFC3339, beat.Timestamp)
+	if err != nil {
+		return fmt.Errorf("parse heartbeat timestamp %q: %w", beat.Timestamp, err)
+	}
+	age := now.Sub(at)
+	if beat.Status == heartbeatStalled {
+		return fmt.Errorf("daemon pid %d reports a stalled cleanup cycle as of %s", beat.PID, beat.Timestamp)
+	}
+	if age > maxAge {
+		return fmt.Errorf("heartbeat from pid %d is %s old, more than %s", beat.PID, age.Round(time.Second), maxAge)
+	}
+	_, err = fmt.Fprintf(w, "heartbeat ok: pid %d, %s, level %s, %s old\n", beat.PID, beat.Status, beat.Level, age.Round(time.Second))
+	return err
+}
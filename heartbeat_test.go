@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func readHeartbeat(t *testing.T, path string) heartbeat {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read heartbeat: %v", err)
+	}
+	var beat heartbeat
+	if err := json.Unmarshal(data, &beat); err != nil {
+		t.Fatalf("decode heartbeat: %v", err)
+	}
+	return beat
+}
+
+func TestBeatRecordsLastCycle(t *testing.T) {
+	plugin := &reportingPlugin{name: "cache", result: plugins.CleanupResult{Plugin: "cache", BytesFreed: 200}}
+	daemon := newTestDaemon(t, plugin, io.Discard)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	daemon.heartbeat = &heartbeatWriter{path: path}
+	daemon.diskStats = sequenceDiskStats(t,
+		diskStats(1000, 100, 90),
+		diskStats(1000, 100, 90),
+		diskStats(1000, 300, 70),
+	)
+
+	daemon.beat(heartbeatOK, true)
+	if beat := readHeartbeat(t, path); beat.Level != "none" || beat.PID != os.Getpid() || beat.Status != heartbeatOK {
+		t.Fatalf("unexpected idle heartbeat %+v", beat)
+	}
+
+	daemon.runCycle(context.Background(), monitor.LevelNone, false)
+	daemon.beat(heartbeatOK, false)
+	if beat := readHeartbeat(t, path); beat.Level != "aggressive" || beat.LastFreedBytes != 200 {
+		t.Fatalf("expected the cycle's level and measured freed bytes, got %+v", beat)
+	}
+}
+
+func TestRunBeatsRunningBeforeFirstCycle(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.PollInterval = 3600
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	daemon.heartbeat = &heartbeatWriter{path: path}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var during heartbeat
+	stats := sequenceDiskStats(t, diskStats(1000, 900, 10))
+	daemon.diskStats = func(mount string) (*monitor.DiskStats, error) {
+		if during.Status == "" {
+			during = readHeartbeat(t, path)
+			cancel()
+		}
+		return stats(mount)
+	}
+
+	if err := daemon.run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("run returned %v, want context.Canceled", err)
+	}
+	if during.Status != heartbeatRunning {
+		t.Fatalf("expected a running heartbeat during the first cycle, got %+v", during)
+	}
+	if beat := readHeartbeat(t, path); beat.Status != heartbeatOK {
+		t.Fatalf("expected an ok heartbeat after the first cycle, got %+v", beat)
+	}
+}
+
+func TestWatchdogMarksStalledCycle(t *testing.T) {
+	var logs bytes.Buffer
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	daemon.heartbeat = &heartbeatWriter{path: path}
+
+	stop := daemon.startWatchdog(20*time.Millisecond, time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), heartbeatStalled) {
+			break
+		}
+		if time.Now().After(deadline) {
+			stop()
+			t.Fatal("expected the watchdog to mark the heartbeat stalled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+
+	if !strings.Contains(logs.String(), "cleanup cycle stalled") {
+		t.Fatalf("expected a stall warning, got %q", logs.String())
+	}
+	if err := checkHeartbeat(io.Discard, path, time.Hour, time.Now()); err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Fatalf("expected -check-heartbeat to fail on a stalled cycle, got %v", err)
+	}
+}
+
+func TestCheckHeartbeat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	if err := checkHeartbeat(io.Discard, path, time.Minute, time.Now()); err == nil {
+		t.Fatal("expected a missing heartbeat to fail")
+	}
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	writer := &heartbeatWriter{path: path}
+	if err := writer.write(heartbeat{Timestamp: now.Add(-4 * time.Minute).Format(time.RFC3339), Status: heartbeatOK, Level: "none", PID: 42}); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := checkHeartbeat(&output, path, 6*time.Minute, now); err != nil || !strings.Contains(output.String(), "pid 42") {
+		t.Fatalf("expected a fresh heartbeat to pass, got %v: %q", err, output.String())
+	}
+	if err := checkHeartbeat(io.Discard, path, 3*time.Minute, now); err == nil || !strings.Contains(err.Error(), "4m0s old") {
+		t.Fatalf("expected a stale heartbeat to fail, got %v", err)
+	}
+}
+
+func TestCheckConfiguredHeartbeat(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.PollInterval = 60
+	cfg.Observability.HeartbeatPath = filepath.Join(t.TempDir(), "heartbeat.json")
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	writer := &heartbeatWriter{path: cfg.Observability.HeartbeatPath}
+	if err := writer.write(heartbeat{Timestamp: now.Add(-90 * time.Second).Format(time.RFC3339), Status: heartbeatOK, Level: "none", PID: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Observability.HeartbeatEnabled = false
+	if err := checkConfiguredHeartbeat(io.Discard, cfg, now); err == nil || !strings.Contains(err.Error(), "heartbeat disabled") {
+		t.Fatalf("expected a disabled heartbeat to fail clearly, got %v", err)
+	}
+	cfg.Observability.HeartbeatEnabled = true
+	if err := checkConfiguredHeartbeat(io.Discard, cfg, now); err != nil {
+		t.Fatalf("expected a heartbeat within the poll interval plus grace to pass, got %v", err)
+	}
+}
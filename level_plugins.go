@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// validateLevelPlugins rejects level_plugins keys that are not cleanup
+// levels and plugin names that are not registered.
+func validateLevelPlugins(levelPlugins map[string][]string, registry *plugins.Registry) error {
+	levels := make(map[string]bool)
+	var levelNames []string
+	for _, level := range plugins.ActionLevels() {
+		levels[level.String()] = true
+		levelNames = append(levelNames, level.String())
+	}
+	available := make(map[string]bool)
+	for _, name := range availablePluginNames(registry) {
+		available[name] = true
+	}
+
+	keys := make([]string, 0, len(levelPlugins))
+	for level := range levelPlugins {
+		keys = append(keys, level)
+	}
+	sort.Strings(keys)
+	for _, level := range keys {
+		if !levels[level] {
+			return fmt.Errorf("unknown level %q in level_plugins; expected one of %s", level, strings.Join(levelNames, ", "))
+		}
+		for _, name := range levelPlugins[level] {
+			if !available[name] {
+				return fmt.Errorf("unknown plugin %q in level_plugins.%s; available plugins: %s", name, level, strings.Join(availablePluginNames(registry), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// permittedAtLevel reports whether level_plugins lets the named plugin run
+// at level. A level without an entry permits every enabled plugin.
+func permittedAtLevel(cfg *config.Config, level plugins.CleanupLevel, name string) bool {
+	permitted, ok := cfg.LevelPlugins[level.String()]
+	if !ok {
+		return true
+	}
+	for _, candidate := range permitted {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePluginLevels rejects plugin_levels keys that are not registered
+// plugins and values that are not cleanup levels.
+func validatePluginLevels(pluginLevels map[string]string, registry *plugins.Registry) error {
+	available := make(map[string]bool)
+	for _, name := range availablePluginNames(registry) {
+		available[name] = true
+	}
+	names := make([]string, 0, len(pluginLevels))
+	for name := range pluginLevels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !available[name] {
+			return fmt.Errorf("unknown plugin %q in plugin_levels; available plugins: %s", name, strings.Join(availablePluginNames(registry), ", "))
+		}
+		if level := pluginLevels[name]; level != plugins.LevelNone.String() && parseLevel(level) == monitor.LevelNone {
+			return fmt.Errorf("invalid level %q in plugin_levels.%s; expected none, warning, moderate, aggressive, or critical", level, name)
+		}
+	}
+	return nil
+}
+
+// clampPluginLevel returns level capped at the plugin's plugin_levels entry.
+func clampPluginLevel(cfg *config.Config, name string, level plugins.CleanupLevel) plugins.CleanupLevel {
+	limit, ok := cfg.PluginLevels[name]
+	if !ok {
+		return level
+	}
+	if capped := plugins.CleanupLevel(parseLevel(limit)); capped < level {
+		return capped
+	}
+	return level
+}
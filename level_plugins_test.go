@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestValidateLevelPlugins(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&reportingPlugin{name: "docker"})
+	registry.Register(&reportingPlugin{name: "cache"})
+
+	if err := validateLevelPlugins(nil, registry); err != nil {
+		t.Fatalf("empty map rejected: %v", err)
+	}
+	if err := validateLevelPlugins(map[string][]string{"warning": {"cache"}, "critical": {"docker", "cache"}}, registry); err != nil {
+		t.Fatalf("valid level_plugins rejected: %v", err)
+	}
+	if err := validateLevelPlugins(map[string][]string{"urgent": {"cache"}}, registry); err == nil || !strings.Contains(err.Error(), "urgent") {
+		t.Fatalf("expected unknown level error, got %v", err)
+	}
+	if err := validateLevelPlugins(map[string][]string{"moderate": {"podman"}}, registry); err == nil || !strings.Contains(err.Error(), "podman") {
+		t.Fatalf("expected unknown plugin error, got %v", err)
+	}
+}
+
+func TestRunCycleSkipsPluginsExcludedByLevel(t *testing.T) {
+	docker := &reportingPlugin{name: "docker"}
+	cache := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemonWithPlugins(t, io.Discard, docker, cache)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.LevelPlugins = map[string][]string{"critical": {"cache"}}
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 50, 95))
+
+	report := daemon.runCycle(context.Background(), monitor.LevelNone, false)
+	if docker.called || !cache.called {
+		t.Fatalf("expected only cache to run at critical, docker=%v cache=%v", docker.called, cache.called)
+	}
+	skipped := map[string]string{}
+	for _, plugin := range report.Plugins {
+		skipped[plugin.Name] = plugin.SkipReason
+	}
+	if skipped["docker"] != "level_plugins" || skipped["cache"] != "" {
+		t.Fatalf("unexpected skip reasons: %v", skipped)
+	}
+
+	entries := listLevelEntries(daemon.registry, daemon.config, nil)
+	for _, entry := range entries {
+		for _, level := range entry.Levels {
+			want := entry.Name == "docker" && level.Level == "critical"
+			if level.Excluded != want {
+				t.Errorf("%s at %s: excluded = %v, want %v", entry.Name, level.Level, level.Excluded, want)
+			}
+		}
+	}
+}
+
+// levelRecordingPlugin records the level Cleanup was called at.
+type levelRecordingPlugin struct {
+	reportingPlugin
+	level plugins.CleanupLevel
+}
+
+func (p *levelRecordingPlugin) Cleanup(ctx context.Context, level plugins.CleanupLevel, cfg *config.Config, logger *slog.Logger) plugins.CleanupResult {
+	p.level = level
+	return p.reportingPlugin.Cleanup(ctx, level, cfg, logger)
+}
+
+func TestValidatePluginLevels(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&reportingPlugin{name: "docker"})
+
+	if err := validatePluginLevels(map[string]string{"docker": "moderate"}, registry); err != nil {
+		t.Fatalf("valid plugin_levels rejected: %v", err)
+	}
+	if err := validatePluginLevels(map[string]string{"docker": "none"}, registry); err != nil {
+		t.Fatalf("none rejected: %v", err)
+	}
+	if err := validatePluginLevels(map[string]string{"docker": "urgent"}, registry); err == nil || !strings.Contains(err.Error(), "urgent") {
+		t.Fatalf("expected invalid level error, got %v", err)
+	}
+	if err := validatePluginLevels(map[string]string{"podman": "moderate"}, registry); err == nil || !strings.Contains(err.Error(), "podman") {
+		t.Fatalf("expected unknown plugin error, got %v", err)
+	}
+}
+
+func TestRunCycleClampsPluginLevels(t *testing.T) {
+	docker := &levelRecordingPlugin{reportingPlugin: reportingPlugin{name: "docker"}}
+	cache := &levelRecordingPlugin{reportingPlugin: reportingPlugin{name: "cache"}}
+	lima := &levelRecordingPlugin{reportingPlugin: reportingPlugin{name: "lima"}}
+	daemon := newTestDaemonWithPlugins(t, io.Discard, docker, cache, lima)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.PluginLevels = map[string]string{"docker": "moderate", "lima": "none"}
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 50, 95))
+
+	report := daemon.runCycle(context.Background(), monitor.LevelNone, false)
+	if docker.level != plugins.LevelModerate || cache.level != plugins.LevelCritical {
+		t.Fatalf("expected docker capped at moderate and cache at critical, got %s and %s", docker.level, cache.level)
+	}
+	if lima.called {
+		t.Fatal("expected plugin_levels none to keep lima from running")
+	}
+	reports := map[string]pluginCycleReport{}
+	for _, plugin := range report.Plugins {
+		reports[plugin.Name] = plugin
+	}
+	if got := reports["docker"]; got.Level != "moderate" || got.LevelClampedFrom != "critical" {
+		t.Fatalf("docker report = level %q clamped from %q", got.Level, got.LevelClampedFrom)
+	}
+	if got := reports["lima"]; got.SkipReason != "plugin_levels" {
+		t.Fatalf("lima skip reason = %q, want plugin_levels", got.SkipReason)
+	}
+}
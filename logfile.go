@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// logCriticalBudget caps the bytes written to the log file per cycle while
+// the cycle runs at critical level, so a chatty cleanup cannot eat the space
+// it is freeing. stderr still receives every line.
+const logCriticalBudget = 256 << 10
+
+// logBudgetNotice is written once when the critical-level budget runs out.
+const logBudgetNotice = "level=WARN msg=\"log file writes capped for this critical cycle; see stderr for the rest\"\n"
+
+// logSink is the log file half of the daemon's log output. When its
+// filesystem is full, which is exactly when cleanup matters most, the file
+// is dropped and logs go to stderr only. The file is reopened after each
+// cycle once space has been freed. Write never fails, so a full disk never
+// stops the logger.
+type logSink struct {
+	mu       sync.Mutex
+	path     string
+	open     func(path string) (io.WriteCloser, error)
+	file     io.WriteCloser
+	critical bool
+	written  int64
+	capped   bool
+}
+
+// openLogFile creates the log directory and opens path for appending.
+func openLogFile(path string) (io.WriteCloser, error) {
+	if err := ensureLogDir(path); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// openLogSink opens the log file at path. A full filesystem leaves the sink
+// without a file instead of failing; other errors are returned.
+func openLogSink(path string, open func(path string) (io.WriteCloser, error)) (*logSink, error) {
+	sink := &logSink{path: path, open: open}
+	file, err := open(path)
+	if err != nil && !diskFull(err) {
+		return nil, err
+	}
+	sink.file = file
+	return sink, nil
+}
+
+// diskFull reports whether err means the filesystem or quota is full.
+func diskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
+
+// unavailable reports whether log lines are currently going to stderr only.
+func (s *logSink) unavailable() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file == nil
+}
+
+func (s *logSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return len(p), nil
+	}
+	if s.critical && s.written+int64(len(p)) > logCriticalBudget {
+		if !s.capped {
+			s.capped = true
+			_, _ = io.WriteString(s.file, logBudgetNotice)
+		}
+		return len(p), nil
+	}
+	n, err := s.file.Write(p)
+	s.written += int64(n)
+	if err != nil && diskFull(err) {
+		_ = s.file.Close()
+		s.file = nil
+		fmt.Fprintf(os.Stderr, "log file %s: %v; logging to stderr only until cleanup frees space\n", s.path, err)
+	}
+	return len(p), nil
+}
+
+// setCritical applies the per-cycle log file budget while level is critical.
+func (s *logSink) setCritical(critical bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.critical = critical
+}
+
+// afterCycle resets the per-cycle budget and, when the log file was dropped
+// for lack of space, tries to reopen it now that cleanup has run.
+func (s *logSink) afterCycle(logger *slog.Logger) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.critical = false
+	s.written = 0
+	s.capped = false
+	if s.file != nil {
+		s.mu.Unlock()
+		return
+	}
+	file, err := s.open(s.path)
+	if err == nil {
+		s.file = file
+	}
+	s.mu.Unlock()
+	if err != nil {
+		logger.Debug("log file still unavailable; logging to stderr only", "path", s.path, "error", err)
+		return
+	}
+	logger.Info("log file reopened after cleanup", "path", s.path)
+}
+
+// Close closes the log file, if open.
+func (s *logSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// fullDiskLog opens real files unless full is set, in which case opening
+// fails the way it does on a filesystem with no space left.
+type fullDiskLog struct {
+	full bool
+}
+
+func (f *fullDiskLog) open(path string) (io.WriteCloser, error) {
+	if f.full {
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.ENOSPC}
+	}
+	return openLogFile(path)
+}
+
+func TestLogSinkFallsBackWhenDiskFull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log", "disk-cleanup.log")
+	disk := &fullDiskLog{full: true}
+	sink, err := openLogSink(path, disk.open)
+	if err != nil {
+		t.Fatalf("a full disk must not stop startup: %v", err)
+	}
+	defer sink.Close()
+	if !sink.unavailable() {
+		t.Fatal("expected the sink to report the log file unavailable")
+	}
+
+	var stderr bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(io.MultiWriter(&stderr, sink), nil))
+	logger.Info("cleaning while full")
+	if !strings.Contains(stderr.String(), "cleaning while full") {
+		t.Fatal("expected logs to still reach stderr")
+	}
+
+	// Still full after the cycle: keep logging to stderr only.
+	sink.afterCycle(logger)
+	if !sink.unavailable() {
+		t.Fatal("expected the log file to stay closed while the disk is full")
+	}
+
+	disk.full = false
+	sink.afterCycle(logger)
+	if sink.unavailable() {
+		t.Fatal("expected the log file to reopen once space is freed")
+	}
+	logger.Info("after cleanup")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "after cleanup") || strings.Contains(string(data), "cleaning while full") {
+		t.Fatalf("unexpected log file contents:\n%s", data)
+	}
+}
+
+func TestLogSinkOpenErrorsOtherThanFullDiskFail(t *testing.T) {
+	_, err := openLogSink("/unused", func(path string) (io.WriteCloser, error) {
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.EACCES}
+	})
+	if err == nil {
+		t.Fatal("expected a permission error to be returned")
+	}
+}
+
+func TestLogSinkCapsWritesWhileCritical(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk-cleanup.log")
+	sink, err := openLogSink(path, openLogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.setCritical(true)
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for i := 0; i < 2*logCriticalBudget/len(line); i++ {
+		if n, err := sink.Write(line); err != nil || n != len(line) {
+			t.Fatalf("writes past the budget must still succeed, got %d, %v", n, err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > logCriticalBudget+int64(len(logBudgetNotice)) {
+		t.Fatalf("expected the log file capped near %d bytes, got %d", logCriticalBudget, info.Size())
+	}
+
+	sink.afterCycle(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := sink.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(path); after.Size() != info.Size()+int64(len(line)) {
+		t.Fatal("expected the budget to reset after the cycle")
+	}
+}
@@ -10,18 +10,54 @@
 //
 // Flags:
 //
-//	-config string    Path to configuration file (default: ~/.config/tinyland-cleanup/config.yaml)
+//	-config string    Path to configuration file (default: ~/.config/tinyland-cleanup/config.yaml);
+//	                  comma-separated files, globs, or http(s) URLs are merged in order
 //	-daemon           Run as a daemon (default: false)
 //	-once             Run cleanup once and exit (default: false)
 //	-level string     Force cleanup level: none, warning, moderate, aggressive, critical
 //	-dry-run          Show what would be cleaned without actually cleaning
+//	-free-now         Run the fast, low-risk container prunes and cache clears
+//	                 at moderate now, within 60s unless -max-runtime is set
+//	-emit-script string
+//	                 With -dry-run, write the planned commands and deletions as a shell script
 //	-output string    Output format: text, json (default: text)
-//	-list-plugins     List registered plugin names and exit
+//	-events-json      Stream newline-delimited JSON cycle events to stdout as
+//	                 they happen; the -output report moves to stderr
+//	-profile string   Built-in config profile applied under the config file:
+//	                 laptop, ci-runner, workstation
+//	-list-profiles    List built-in config profiles and their settings and exit
+//	-migrate-config   Stamp -config with the current config_version, write the
+//	                 defaults of keys added since its version, and exit
+//	-list-plugins     List registered plugins in execution priority order and exit
+//	-list-levels      List what each cleanup level does per enabled plugin and exit
+//	-estimate         Rank enabled plugins by estimated reclaimable space at each level and exit
+//	-status           Print disk usage, cleanup level, VM disks, and enabled plugins without cleaning and exit
+//	-check-heartbeat  Exit non-zero when the daemon heartbeat is missing, stalled, or
+//	                 older than the poll interval
+//	-explain-protection
+//	                 With -dry-run, list every candidate the filesystem-scanning plugins
+//	                 found, whether it would be deleted or kept, and why (default level: moderate)
+//	-explain-plugin string
+//	                 Print the external commands a plugin would run at -level and exit
+//	-benchmark-scan string
+//	                 Time read-only size scans of a path at several worker counts and exit
+//	-install-service  Print a launchd (macOS) or systemd --user (Linux) unit for this binary and config
+//	-uninstall-service
+//	                 Print the service unit path that -install-service writes
+//	-confirm          Write or remove the service unit with -install-service or -uninstall-service
 //	-plugins string   Comma-separated plugin names to run or plan
 //	-target-used-percent int
 //	                 Override target maximum used-space percentage after cleanup
+//	-target-free-gb float
+//	                 Override target free space in GiB after cleanup (target_free_gb)
+//	-max-runtime duration
+//	                 Overall deadline for one cleanup cycle (default: pool.max_cycle_minutes)
+//	-watch-min-interval duration
+//	                 With -daemon, space cleanup cycles at least this far apart
 //	-verbose          Enable verbose logging
-//	-version          Print version and exit
+//	-redact           Mask home paths, usernames, and project names in logs and reports
+//	-version          Print version and exit; with -output json, also the Go
+//	                 version, OS, architecture, build tags, and compiled-in plugins
 //	-probe-volume-path string    Darwin-only: probe direct volume access and exit
 //	-probe-result-path string    Path to write the key=value probe result summary
 //	-probe-name string           Probe label used for the temporary write-test file
@@ -29,23 +65,32 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"os/user"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
 	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/observability"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/power"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/redact"
 	"github.com/Jesssullivan/tinyland-cleanup/plugins"
 )
 
@@ -58,17 +103,42 @@ var (
 func main() {
 	// Parse command line flags
 	var (
-		configPath          = flag.String("config", "", "Path to configuration file")
+		configPath          = flag.String("config", "", "Path to configuration file; comma-separated files, globs, or http(s) URLs are merged in order")
 		runDaemon           = flag.Bool("daemon", false, "Run as a daemon")
 		once                = flag.Bool("once", false, "Run cleanup once and exit")
 		level               = flag.String("level", "", "Force cleanup level")
 		dryRun              = flag.Bool("dry-run", false, "Show what would be cleaned")
+		freeNow             = flag.Bool("free-now", false, "Run the fast, low-risk container prunes and cache clears at moderate now, within 60s unless -max-runtime is set")
+		emitScript          = flag.String("emit-script", "", "With -dry-run, write the planned commands and deletions as a shell script to this path")
 		output              = flag.String("output", "text", "Output format: text, json")
-		listPlugins         = flag.Bool("list-plugins", false, "List registered plugin names and exit")
+		eventsJSON          = flag.Bool("events-json", false, "Stream newline-delimited JSON cycle events to stdout as they happen; the -output report moves to stderr")
+		profile             = flag.String("profile", "", "Built-in config profile applied under the config file: "+strings.Join(config.ProfileNames(), ", "))
+		listProfiles        = flag.Bool("list-profiles", false, "List built-in config profiles and their settings and exit")
+		migrateConfig       = flag.Bool("migrate-config", false, "Stamp -config with the current config_version, write defaults for keys added since, and exit")
+		listPlugins         = flag.Bool("list-plugins", false, "List registered plugins in execution priority order and exit")
+		listLevels          = flag.Bool("list-levels", false, "List what each cleanup level does per enabled plugin and exit")
+		estimate            = flag.Bool("estimate", false, "Rank enabled plugins by estimated reclaimable space at each level without cleaning and exit")
+		checkHeartbeatFile  = flag.Bool("check-heartbeat", false, "Exit non-zero when the daemon heartbeat is missing, stalled, or older than the poll interval")
+		showStatus          = flag.Bool("status", false, "Print disk usage, the cleanup level it triggers, VM disks, and enabled plugins without cleaning and exit")
+		explainProtect      = flag.Bool("explain-protection", false, "With -dry-run, explain why each candidate of the filesystem-scanning plugins would be deleted or kept, and exit")
+		explainPlugin       = flag.String("explain-plugin", "", "Print the external commands a plugin would run at -level and exit")
+		benchmarkScanPath   = flag.String("benchmark-scan", "", "Time read-only size scans of this path at several worker counts and exit")
+		installService      = flag.Bool("install-service", false, "Print a launchd or systemd --user unit running this binary with -config; -confirm writes it")
+		uninstallService    = flag.Bool("uninstall-service", false, "Print the service unit path to remove; -confirm removes it")
+		confirm             = flag.Bool("confirm", false, "Write or remove the service unit for -install-service or -uninstall-service")
 		pluginNames         = flag.String("plugins", "", "Comma-separated plugin names to run or plan")
+		skipNames           = flag.String("skip", "", "Comma-separated plugin names to leave out, whatever the config enables")
 		targetUsed          = flag.Int("target-used-percent", 0, "Override target maximum used-space percentage after cleanup")
+		targetFreeGB        = flag.Float64("target-free-gb", 0, "Override target free space in GiB after cleanup (target_free_gb)")
+		maxRuntime          = flag.Duration("max-runtime", 0, "Overall deadline for one cleanup cycle, e.g. 5m (default: pool.max_cycle_minutes)")
+		watchMinInterval    = flag.Duration("watch-min-interval", 0, "With -daemon, space cleanup cycles at least this far apart, e.g. 15m; polling and reports continue between them")
+		overrideMaxLevel    = flag.Bool("override-max-level", false, "Allow a forced -level above safety.max_level")
+		acknowledgeRisks    = flag.Bool("acknowledge-risks", false, "Print what offline VM disk compaction does, record a one-time acknowledgment, and exit")
+		yesIUnderstand      = flag.Bool("yes-i-understand", false, "Allow offline VM disk compaction for this run without the acknowledgment file")
 		verbose             = flag.Bool("verbose", false, "Enable verbose logging")
-		showVersion         = flag.Bool("version", false, "Print version and exit")
+		redactOutput        = flag.Bool("redact", false, "Mask home paths, usernames, and project names in logs and reports (log.redact)")
+		compareBeforeAfter  = flag.Bool("compare-before-after", false, "Check each file-deleting plugin's reported bytes against observed free space (safety.verify_freed_bytes)")
+		showVersion         = flag.Bool("version", false, "Print version and exit; -output json adds build info and compiled-in plugins")
 		probeVolumePath     = flag.String("probe-volume-path", "", "Darwin-only: probe direct volume access and exit")
 		probeResultPath     = flag.String("probe-result-path", "", "Path to write the key=value probe result summary")
 		probeName           = flag.String("probe-name", "tinyland-cleanup-probe", "Probe label used for the temporary write-test file")
@@ -80,10 +150,33 @@ func main() {
 		probeFile      = flag.String("probe-file", "", "internal volume probe file path")
 		probeErrorPath = flag.String("probe-error-path", "", "internal volume probe error path")
 	)
+	flag.StringVar(output, "format", "text", "Alias for -output")
+	flag.StringVar(pluginNames, "only", "", "Alias for -plugins")
 	flag.Parse()
 
 	if *showVersion {
-		fmt.Printf("tinyland-cleanup %s (%s) built %s\n", version, commit, date)
+		if *output != "text" && *output != "json" {
+			fmt.Fprintf(os.Stderr, "invalid output format %q: expected text or json\n", *output)
+			os.Exit(2)
+		}
+		registry := plugins.NewRegistry()
+		registerPlugins(registry)
+		if err := writeVersion(os.Stdout, *output, newVersionReport(registry)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write version: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *listProfiles {
+		if *output != "text" && *output != "json" {
+			fmt.Fprintf(os.Stderr, "invalid output format %q: expected text or json\n", *output)
+			os.Exit(2)
+		}
+		if err := writeProfileList(os.Stdout, *output, config.Profiles()); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list profiles: %v\n", err)
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
@@ -103,25 +196,99 @@ func main() {
 		fmt.Fprintf(os.Stderr, "invalid output format %q: expected text or json\n", *output)
 		os.Exit(2)
 	}
+	if *benchmarkScanPath != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runScanBenchmark(ctx, os.Stdout, *output, *benchmarkScanPath)
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "scan benchmark failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	pluginFilter, err := parsePluginFilter(*pluginNames)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(2)
 	}
+	skipFilter, err := parsePluginFilter(*skipNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if *freeNow {
+		if err := validateFreeNow(pluginFilter, *level, *runDaemon); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+	}
 
 	// Load configuration first to get log file path
 	if *configPath == "" {
-		home, _ := os.UserHomeDir()
+		home, err := env.HomeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot locate default config: %v; pass -config\n", err)
+			os.Exit(2)
+		}
 		*configPath = filepath.Join(home, ".config", "tinyland-cleanup", "config.yaml")
 	}
 
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.LoadConfigWithProfile(*configPath, *profile)
 	if err != nil {
 		// Fall back to stderr logging if config fails
 		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
 		os.Exit(1)
 	}
-	if err := applyTargetUsedPercentOverride(cfg, *targetUsed); err != nil {
+	if *migrateConfig {
+		migration, err := config.MigrateConfigFile(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to migrate config: %v\n", err)
+			os.Exit(1)
+		}
+		writeConfigMigration(os.Stdout, *configPath, migration)
+		return
+	}
+	if *emitScript != "" && !*dryRun {
+		fmt.Fprintln(os.Stderr, "-emit-script requires -dry-run")
+		os.Exit(2)
+	}
+	if *explainProtect && !*dryRun {
+		fmt.Fprintln(os.Stderr, "-explain-protection requires -dry-run")
+		os.Exit(2)
+	}
+	// applyConfigFlags layers flag overrides onto a loaded config and checks
+	// the settings they touch. A refreshed remote config goes through it too.
+	applyConfigFlags := func(cfg *config.Config) (time.Duration, error) {
+		if *redactOutput {
+			cfg.Log.Redact = true
+		}
+		if *compareBeforeAfter {
+			cfg.Safety.VerifyFreedBytes = true
+		}
+		if err := applyTargetOverrides(cfg, *targetUsed, *targetFreeGB); err != nil {
+			return 0, err
+		}
+		if err := validateTargetFree(cfg, defaultMonitorPath(), monitor.GetDiskStats); err != nil {
+			return 0, err
+		}
+		deadline, err := cycleMaxRuntime(cfg, *maxRuntime)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := safetyMaxLevel(cfg); err != nil {
+			return 0, err
+		}
+		return deadline, nil
+	}
+	cycleDeadline, err := applyConfigFlags(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if *freeNow {
+		cycleDeadline = freeNowMaxRuntime(*maxRuntime)
+	}
+	if err := validateWatchMinInterval(*watchMinInterval, *runDaemon && !*once && *level == ""); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(2)
 	}
@@ -129,22 +296,68 @@ func main() {
 	// Create plugin registry and register all plugins.
 	registry := plugins.NewRegistry()
 	registerPlugins(registry)
+	plugins.ConfigureAccounting(cfg.Safety)
+	if *acknowledgeRisks {
+		fmt.Fprintln(os.Stderr, plugins.RiskWarning)
+		path, err := plugins.WriteRiskAcknowledgment(cfg, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to record risk acknowledgment: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "\nAcknowledgment recorded at %s; offline compaction will run when enabled.\n", path)
+		return
+	}
+	if *yesIUnderstand {
+		plugins.AcknowledgeRisksForRun()
+	}
 	if err := validatePluginFilter(pluginFilter, registry); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(2)
 	}
+	if err := validateSkipFilter(skipFilter, pluginFilter, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	registry.Skip(skipFilter...)
+	if *freeNow {
+		pluginFilter = freeNowPlugins
+	}
+	if err := validateRuntimeConfig(cfg, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if *installService || *uninstallService {
+		if err := runServiceCommand(os.Stdout, *output, *installService, *uninstallService, *configPath, *confirm); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if *listPlugins {
-		if err := writePluginList(os.Stdout, *output, listPluginEntries(registry, cfg)); err != nil {
+		if err := writePluginList(os.Stdout, *output, pluginListOrder(cfg), listPluginEntries(registry, cfg)); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to write plugin list: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
-
-	// Setup log file directory
-	if err := ensureLogDir(cfg.LogFile); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to create log directory: %v\n", err)
-		os.Exit(1)
+	if *listLevels {
+		if err := writeLevelList(os.Stdout, *output, listLevelEntries(registry, cfg, pluginFilter)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write level list: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *explainPlugin != "" {
+		report, err := explainPluginCommands(registry, cfg, *explainPlugin, *level)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+		if err := writeCommandExplanation(os.Stdout, *output, report); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write command explanation: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Setup logging - write to both stderr and log file
@@ -153,8 +366,9 @@ func main() {
 		logLevel = slog.LevelDebug
 	}
 
-	// Open log file for writing
-	logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	// Open the log file. If it lives on the full disk being cleaned, log to
+	// stderr only and still run cleanup.
+	logFile, err := openLogSink(cfg.LogFile, openLogFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to open log file: %v\n", err)
 		os.Exit(1)
@@ -163,9 +377,16 @@ func main() {
 
 	// Create multi-writer for both stderr and log file
 	multiWriter := io.MultiWriter(os.Stderr, logFile)
+	redactor := newRedactor(cfg)
 	logger := slog.New(slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
-		Level: logLevel,
+		Level:       logLevel,
+		ReplaceAttr: redactor.ReplaceAttr,
 	}))
+	if logFile.unavailable() {
+		logger.Warn("log file's filesystem is full; logging to stderr only until cleanup frees space", "path", cfg.LogFile)
+	}
+	logConfigVersion(logger, cfg)
+	logRemoteConfigWarnings(logger, cfg)
 
 	// Create disk monitor
 	diskMon := monitor.NewDiskMonitor(
@@ -175,18 +396,52 @@ func main() {
 		cfg.Thresholds.Critical,
 	)
 
+	metrics, err := newMetricsExporter(cfg.Observability)
+	if err != nil {
+		logger.Error("invalid observability config", "error", err)
+		os.Exit(2)
+	}
+
 	// Create cleanup daemon
 	d := &daemon{
-		config:       cfg,
-		registry:     registry,
-		monitor:      diskMon,
-		logger:       logger,
-		dryRun:       *dryRun,
-		output:       *output,
-		pluginFilter: pluginFilter,
-		report:       os.Stdout,
-		diskStats:    monitor.GetDiskStats,
-		now:          time.Now,
+		config:        cfg,
+		registry:      registry,
+		monitor:       diskMon,
+		logger:        logger,
+		dryRun:        *dryRun,
+		output:        *output,
+		pluginFilter:  pluginFilter,
+		freeNow:       *freeNow,
+		maxRuntime:    cycleDeadline,
+		minInterval:   *watchMinInterval,
+		overrideMax:   *overrideMaxLevel && *level != "",
+		report:        os.Stdout,
+		scriptPath:    *emitScript,
+		diskStats:     monitor.GetDiskStats,
+		now:           time.Now,
+		displayAsleep: power.DisplayAsleep,
+		redactor:      redactor,
+		logFile:       logFile,
+		metrics:       metrics,
+	}
+	if *runDaemon && cfg.ConfigSource.RefreshMinutes > 0 && config.HasRemoteConfig(*configPath) {
+		d.configRefresh = time.Duration(cfg.ConfigSource.RefreshMinutes) * time.Minute
+		d.reloadConfig = keepHomeOnError(func() (*config.Config, error) {
+			next, err := config.LoadConfigWithProfile(*configPath, *profile)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := applyConfigFlags(next); err != nil {
+				return nil, err
+			}
+			return next, validateRuntimeConfig(next, registry)
+		})
+	}
+	if *eventsJSON {
+		// stdout carries only events, so jq and log shippers can read it
+		// line by line.
+		d.events = newEventStream(os.Stdout)
+		d.report = os.Stderr
 	}
 
 	// Determine operation mode
@@ -202,6 +457,51 @@ func main() {
 		cancel()
 	}()
 
+	if *checkHeartbeatFile {
+		if err := checkConfiguredHeartbeat(os.Stdout, cfg, time.Now()); err != nil {
+			logger.Error("heartbeat check failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *showStatus {
+		if err := writeStatus(os.Stdout, *output, d.status(ctx)); err != nil {
+			logger.Error("failed to write status", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *estimate {
+		if err := writeEstimate(os.Stdout, *output, d.estimateReclaimable(ctx)); err != nil {
+			logger.Error("failed to write estimate", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *explainProtect {
+		explainLevel := explainProtectionLevel
+		if *level != "" {
+			explainLevel = parseLevel(*level)
+		}
+		plugins.SetExplainProtection(true)
+		if err := writeProtectionReport(os.Stdout, *output, d.explainProtection(ctx, explainLevel)); err != nil {
+			logger.Error("failed to write protection explanation", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *freeNow {
+		if err := d.runOnce(ctx, freeNowLevel); err != nil {
+			logger.Error("free-now cleanup failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// If level is specified, force that level
 	if *level != "" {
 		forcedLevel := parseLevel(*level)
@@ -230,6 +530,16 @@ func main() {
 		"critical", cfg.Thresholds.Critical,
 	)
 
+	d.heartbeat = d.newHeartbeatWriter()
+	server, err := newHealthServer(ctx, d, cfg.Observability)
+	if err != nil {
+		logger.Error("invalid observability config", "error", err)
+		os.Exit(2)
+	}
+	if server != nil {
+		go serveHealth(ctx, server, logger)
+	}
+
 	if err := d.run(ctx); err != nil && err != context.Canceled {
 		logger.Error("daemon error", "error", err)
 		os.Exit(1)
@@ -237,70 +547,270 @@ func main() {
 }
 
 type daemon struct {
-	config       *config.Config
-	registry     *plugins.Registry
-	monitor      *monitor.DiskMonitor
-	logger       *slog.Logger
-	dryRun       bool
-	output       string
-	pluginFilter []string
-	report       io.Writer
-	diskStats    func(path string) (*monitor.DiskStats, error)
-	now          func() time.Time
+	config        *config.Config
+	registry      *plugins.Registry
+	monitor       *monitor.DiskMonitor
+	logger        *slog.Logger
+	dryRun        bool
+	output        string
+	pluginFilter  []string
+	freeNow       bool
+	maxRuntime    time.Duration
+	minInterval   time.Duration
+	lastCleanup   time.Time
+	overrideMax   bool
+	report        io.Writer
+	scriptPath    string
+	diskStats     func(path string) (*monitor.DiskStats, error)
+	now           func() time.Time
+	displayAsleep func() bool
+	notify        func(ctx context.Context, message string) error
+	redactor      *redact.Redactor
+	events        *eventStream
+	logFile       *logSink
+	metrics       *observability.Exporter
+	configRefresh time.Duration
+	reloadConfig  func() (*config.Config, error)
+	runMu         sync.Mutex
+	// configMu guards config and monitor, which refreshConfig replaces.
+	configMu  sync.RWMutex
+	reportMu  sync.Mutex
+	notifyMu  sync.Mutex
+	critical  criticalStreak
+	health    cycleHealth
+	heartbeat *heartbeatWriter
+	// lastRecommendations is when the last recommendations analysis ran.
+	lastRecommendations time.Time
 }
 
 func (d *daemon) run(ctx context.Context) error {
-	ticker := time.NewTicker(time.Duration(d.config.PollInterval) * time.Second)
+	ticker := time.NewTicker(time.Duration(d.currentConfig().PollInterval) * time.Second)
 	defer ticker.Stop()
+	var refresh <-chan time.Time
+	if d.configRefresh > 0 && d.reloadConfig != nil {
+		refreshTicker := time.NewTicker(d.configRefresh)
+		defer refreshTicker.Stop()
+		refresh = refreshTicker.C
+	}
 
-	// Run immediately on start
+	if err := d.startDirWatchers(ctx); err != nil {
+		d.logger.Error("invalid watch_dirs config", "error", err)
+	}
+
+	// Run immediately on start. The first cycle can outlast the heartbeat's
+	// max age before the watchdog's first refresh, so mark it running first.
+	d.beat(heartbeatRunning, false)
 	if err := d.runOnce(ctx, monitor.LevelNone); err != nil {
 		d.logger.Error("initial cleanup failed", "error", err)
 	}
+	d.beat(heartbeatOK, false)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-refresh:
+			d.refreshConfig()
 		case <-ticker.C:
+			if d.idleTick() {
+				d.beat(heartbeatOK, true)
+				continue
+			}
 			if err := d.runOnce(ctx, monitor.LevelNone); err != nil {
 				d.logger.Error("cleanup cycle failed", "error", err)
 			}
+			d.beat(heartbeatOK, false)
 		}
 	}
 }
 
+// idleTick reports whether every monitored path is at least
+// monitor.idle_margin_percent points below its warning threshold, so a poll
+// tick can skip the cycle and its size scans. It only reads disk stats.
+func (d *daemon) idleTick() bool {
+	cfg := d.currentConfig()
+	margin := cfg.Monitor.IdleMarginPercent
+	if margin <= 0 {
+		return false
+	}
+
+	type check struct {
+		path    string
+		warning int
+	}
+	var checks []check
+	for _, mount := range cfg.MonitoredMounts {
+		warning := cfg.Thresholds.Warning
+		if mount.ThresholdWarning > 0 {
+			warning = mount.ThresholdWarning
+		}
+		checks = append(checks, check{path: mount.Path, warning: warning})
+	}
+	if len(checks) == 0 {
+		checks = append(checks, check{path: defaultMonitorPath(), warning: cfg.Thresholds.Warning})
+	}
+
+	for _, c := range checks {
+		stats, err := d.getDiskStats(c.path)
+		if err != nil || stats.UsedPercent >= float64(c.warning-margin) {
+			return false
+		}
+	}
+	d.logger.Debug("disk comfortably below warning; skipping cleanup tick",
+		"idle_margin_percent", margin,
+		"paths", len(checks),
+	)
+	return true
+}
+
 func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel) error {
+	_, err := d.runObservedCycle(ctx, forcedLevel, d.dryRun)
+	return err
+}
+
+// runObservedCycle runs one cycle with its alerts, notifications, metrics,
+// dry-run script, and report, and returns the report. runOnce and the
+// trigger endpoint share it so a triggered cycle is observed like a polled
+// one.
+func (d *daemon) runObservedCycle(ctx context.Context, forcedLevel monitor.CleanupLevel, dryRun bool) (cycleReport, error) {
+	report := d.runCycle(ctx, forcedLevel, dryRun)
+	d.checkCriticalEffectiveness(ctx, &report)
+	d.recommend(ctx, &report)
+	d.alertVMRestartFailures(ctx, &report)
+	d.alertPluginErrors(ctx, &report)
+	d.notifyCycle(ctx, &report)
+	d.recordMetrics(ctx, report)
+	if d.scriptPath != "" && report.DryRun {
+		if err := writeCleanupScriptFile(d.scriptPath, report); err != nil {
+			return report, fmt.Errorf("write cleanup script: %w", err)
+		}
+		d.logger.Info("wrote dry-run cleanup script", "path", d.scriptPath)
+	}
+	return report, d.writeReport(report)
+}
+
+// runCycle performs one cleanup cycle under the run lock and returns its
+// report. Callers that overlap, such as the poll loop and the trigger
+// endpoint, are serialized so only one cycle touches the host at a time.
+func (d *daemon) runCycle(ctx context.Context, forcedLevel monitor.CleanupLevel, dryRun bool) cycleReport {
+	return d.runScopedCycle(ctx, forcedLevel, dryRun, cycleScope{plugins: d.pluginFilter, freeNow: d.freeNow})
+}
+
+// cycleScope narrows a cycle to a plugin filter and records what triggered it.
+type cycleScope struct {
+	// plugins limits the cycle; empty runs every enabled plugin.
+	plugins []string
+	// watchPath is the watch_dirs entry that triggered the cycle. Such cycles
+	// run even when the host target_free is met, since the trigger is the
+	// directory's own size.
+	watchPath  string
+	watchBytes int64
+	// freeNow marks a -free-now cycle, which runs even when the host
+	// target_free is met or a plugin is in cooldown, and skips heavy plugins.
+	freeNow bool
+}
+
+// runScopedCycle is runCycle limited to scope. Every cycle, including one
+// that stops early or whose plugins fail, ends with a cycle_summary event.
+func (d *daemon) runScopedCycle(ctx context.Context, forcedLevel monitor.CleanupLevel, dryRun bool, scope cycleScope) cycleReport {
+	stopWatchdog := d.startWatchdog(d.cycleStallLimit(), d.heartbeatInterval())
+	report := d.cleanupCycle(ctx, forcedLevel, dryRun, scope)
+	stopWatchdog()
+	for i := range report.Plugins {
+		report.Plugins[i].Skipped = report.Plugins[i].SkipReason != ""
+	}
+	d.emitSummary(report)
+	d.logFile.afterCycle(d.logger)
+	if !report.DryRun {
+		d.health.record(d.currentTime(), report.Level, cycleFreedBytes(report))
+	}
+	return report
+}
+
+func (d *daemon) cleanupCycle(ctx context.Context, forcedLevel monitor.CleanupLevel, dryRun bool, scope cycleScope) cycleReport {
+	pluginFilter := scope.plugins
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	cfg := d.currentConfig()
+
+	if d.maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.maxRuntime)
+		defer cancel()
+	}
+
 	assessment := d.assessMounts()
 	level := forcedLevel
 
 	if level == monitor.LevelNone {
 		level = assessment.Level
 	}
+	ceiling, _ := safetyMaxLevel(cfg)
+	requestedLevel := level
+	if level > ceiling && !(forcedLevel != monitor.LevelNone && d.overrideMax) {
+		level = ceiling
+		d.logger.Warn("cleanup level clamped by safety.max_level; intervene manually if more space is needed",
+			"requested_level", requestedLevel.String(),
+			"max_level", ceiling.String(),
+		)
+	}
 
 	now := d.currentTime()
 	report := cycleReport{
 		Timestamp:    now.UTC().Format(time.RFC3339),
-		DryRun:       d.dryRun,
+		DryRun:       dryRun,
 		ForcedLevel:  forcedLevel != monitor.LevelNone,
 		Level:        level.String(),
 		MonitorPath:  d.primaryMonitorPath(assessment),
 		Mounts:       assessment.Mounts,
-		PluginFilter: d.pluginFilter,
+		PluginFilter: pluginFilter,
+		WatchPath:    scope.watchPath,
+		WatchBytes:   scope.watchBytes,
+		FreeNow:      scope.freeNow,
+	}
+	if cfg.Safety.MaxLevel != "" {
+		report.MaxLevel = ceiling.String()
+	}
+	if level != requestedLevel {
+		report.LevelClampedFrom = requestedLevel.String()
+	}
+	d.logFile.setCritical(level >= monitor.LevelCritical)
+
+	if d.maxRuntime > 0 {
+		report.MaxRuntimeSeconds = int64(d.maxRuntime / time.Second)
 	}
+	d.emit(cycleEvent{
+		Type:        eventCycleStart,
+		Level:       report.Level,
+		DryRun:      dryRun,
+		MonitorPath: report.MonitorPath,
+		WatchPath:   report.WatchPath,
+	})
 
 	cooldown := d.cleanupCooldown()
 	if cooldown > 0 {
 		report.CooldownSeconds = int64(cooldown / time.Second)
 	}
-	report.StateFile = expandPathHome(d.config.Policy.StateFile)
-	state, stateErr := d.loadStateForCycle()
+	report.StateFile = expandPathHome(cfg.Policy.StateFile)
+	state, stateErr := d.loadStateForCycle(dryRun)
 	if stateErr != nil {
 		report.StateError = stateErr.Error()
 		d.logger.Warn("failed to load cleanup state", "path", report.StateFile, "error", stateErr)
 	}
 	stateDirty := false
 
+	floor := d.minIntervalRemaining(now, dryRun)
+	if floor > 0 {
+		report.MinIntervalRemainingSeconds = int64(floor.Round(time.Second) / time.Second)
+		if level != monitor.LevelNone {
+			d.logger.Info("cleanup held back by -watch-min-interval",
+				"level", level.String(),
+				"min_interval", d.minInterval,
+				"remaining", floor.Round(time.Second),
+			)
+		}
+	}
+
 	beforeStats, beforeErr := d.getDiskStats(report.MonitorPath)
 	if beforeErr != nil {
 		report.HostFreeError = beforeErr.Error()
@@ -310,39 +820,127 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 		d.updateTargetFreeStatus(&report, beforeStats)
 	}
 
+	if floor == 0 {
+		d.runProactiveCleanup(ctx, &report, dryRun)
+		for _, entry := range report.Proactive {
+			if entry.Triggered && !dryRun {
+				d.lastCleanup = now
+			}
+		}
+	}
+
 	if level == monitor.LevelNone {
-		return d.writeReport(report)
+		return report
 	}
 
 	// Convert monitor level to plugin level
-	pluginLevel := plugins.CleanupLevel(level)
+	cycleLevel := plugins.CleanupLevel(level)
 
-	// Run cleanup plugins
-	enabledPlugins := filterEnabledPlugins(d.registry.GetEnabled(d.config), d.pluginFilter)
+	// Run cleanup plugins cheapest-first so target-driven cycles stop before
+	// reaching expensive or destructive plugins, unless plugin_order is set.
+	enabledPlugins := executionOrder(filterEnabledPlugins(d.registry.GetEnabled(cfg), pluginFilter), cfg.PluginOrder)
+	reordered := len(cfg.PluginOrder) > 0
+	if cfg.Policy.OrderByEfficiency && !reordered && report.TargetFreeBytes > 0 && stateErr == nil {
+		enabledPlugins = orderByEfficiency(enabledPlugins, state)
+		reordered = true
+	}
 	d.logger.Debug("running plugins", "count", len(enabledPlugins))
+	if reordered {
+		for _, p := range enabledPlugins {
+			report.PluginOrder = append(report.PluginOrder, p.Name())
+		}
+	}
+
+	focus, contended := assessment.priorityMount()
+	if contended {
+		report.PriorityMount = focus.Path
+		d.logger.Info("several mounts at cleanup level; addressing highest-priority mount first",
+			"mount", focus.Label,
+			"path", focus.Path,
+			"priority", focus.Priority,
+			"level", level.String(),
+		)
+	}
+
+	report.HeavyDeferred = d.shouldDeferHeavyWork(report)
+	if report.HeavyDeferred {
+		d.logger.Info("display asleep; deferring heavy cleanup plugins", "level", level.String())
+	}
 
 	var totalFreed int64
 	var totalItems int
+	pluginsRun := 0
 	for _, p := range enabledPlugins {
 		pluginReport := pluginCycleReport{
 			Name:        p.Name(),
 			Description: p.Description(),
+			Priority:    plugins.PluginPriority(p),
 			Level:       level.String(),
-			DryRun:      d.dryRun,
+			DryRun:      dryRun,
 			WouldRun:    true,
 		}
 
-		if !d.dryRun && report.TargetFreeMet {
+		if ctx.Err() != nil {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = cycleSkipReason(ctx)
+			if report.StopReason == "" {
+				report.StopReason = pluginReport.SkipReason
+			}
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		if !permittedAtLevel(cfg, cycleLevel, p.Name()) {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "level_plugins"
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		pluginLevel := clampPluginLevel(cfg, p.Name(), cycleLevel)
+		if pluginLevel != cycleLevel {
+			pluginReport.Level = pluginLevel.String()
+			pluginReport.LevelClampedFrom = level.String()
+		}
+		if pluginLevel == plugins.LevelNone {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "plugin_levels"
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		if !dryRun && report.TargetFreeMet && report.WatchPath == "" && !scope.freeNow {
 			pluginReport.WouldRun = false
 			pluginReport.SkipReason = "target_free_met"
 			if report.StopReason == "" {
 				report.StopReason = "target_free_met"
+				d.logger.Info("target free reached, stopping early",
+					"plugins_run", pluginsRun,
+					"target_free_bytes", report.TargetFreeBytes,
+				)
 			}
 			report.Plugins = append(report.Plugins, pluginReport)
 			continue
 		}
 
-		if d.shouldApplyCooldown(report, level) && stateErr == nil {
+		if floor > 0 {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "min_interval"
+			if report.StopReason == "" {
+				report.StopReason = "min_interval"
+			}
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		if contended && !affectsMount(p, cfg, assessment.Mounts, focus.Path) {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "other_mount"
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		if d.shouldApplyCooldown(report, level) && stateErr == nil && !scope.freeNow {
 			if remaining := state.cooldownRemaining(p.Name(), pluginLevel, now, cooldown); remaining > 0 {
 				pluginReport.WouldRun = false
 				pluginReport.SkipReason = "cooldown"
@@ -352,9 +950,24 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 			}
 		}
 
-		if d.dryRun {
+		if report.HeavyDeferred && plugins.IsHeavy(p, pluginLevel, cfg) {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "display_asleep"
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		if scope.freeNow && plugins.IsHeavy(p, pluginLevel, cfg) {
+			pluginReport.WouldRun = false
+			pluginReport.SkipReason = "free_now_heavy"
+			report.Plugins = append(report.Plugins, pluginReport)
+			continue
+		}
+
+		d.emit(cycleEvent{Type: eventPluginStart, Level: level.String(), DryRun: dryRun, Plugin: p.Name()})
+		if dryRun {
 			if planner, ok := p.(plugins.Planner); ok {
-				plan := planner.PlanCleanup(ctx, pluginLevel, d.config, d.logger)
+				plan := planner.PlanCleanup(ctx, pluginLevel, cfg, d.logger)
 				pluginReport.Plan = &plan
 				report.PlannedEstimatedBytesFreed += plan.EstimatedBytesFreed
 				report.PlannedTargets += len(plan.Targets)
@@ -362,6 +975,9 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 					report.PlannedRequiredFreeBytes = plan.RequiredFreeBytes
 				}
 			}
+			if explainer, ok := p.(plugins.CommandExplainer); ok {
+				pluginReport.Commands = explainer.CleanupCommands(pluginLevel, cfg)
+			}
 			pluginReport.SkipReason = "dry_run"
 			d.logger.Info("dry-run plugin plan",
 				"plugin", p.Name(),
@@ -369,29 +985,60 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 				"description", p.Description(),
 			)
 			report.Plugins = append(report.Plugins, pluginReport)
+			d.emitPluginEnd(level, true, pluginReport)
 			continue
 		}
 
-		result := p.Cleanup(ctx, pluginLevel, d.config, d.logger)
+		d.lastCleanup = now
+		pluginsRun++
+		verification := d.startFreedBytesCheck(p, report.MonitorPath)
+		started := time.Now()
+		result := p.Cleanup(ctx, pluginLevel, cfg, d.logger)
+		elapsed := time.Since(started)
+		pluginReport.DurationSeconds = elapsed.Seconds()
+		pluginReport.BytesPerSecond = pluginEfficiency(result.BytesFreed, elapsed)
+		if ctx.Err() != nil {
+			pluginReport.Cancelled = true
+			d.logger.Warn("plugin cancelled by cycle deadline", "plugin", p.Name(), "max_runtime", d.maxRuntime)
+		}
 		pluginReport.BytesFreed = result.BytesFreed
 		pluginReport.EstimatedBytesFreed = result.EstimatedBytesFreed
 		pluginReport.CommandBytesFreed = result.CommandBytesFreed
 		pluginReport.HostBytesFreed = result.HostBytesFreed
+		if cfg.Log.ReportGrowth {
+			pluginReport.BytesGrown = result.BytesGrown
+		}
 		pluginReport.ItemsCleaned = result.ItemsCleaned
+		pluginReport.ProtectedSkippedItems = result.ProtectedSkippedItems
+		pluginReport.ProtectedSkippedBytes = result.ProtectedSkippedBytes
+		pluginReport.WalkPermissionSkips = result.WalkPermissionSkips
+		pluginReport.WalkErrorSkips = result.WalkErrorSkips
+		pluginReport.DeepGCBytesFreed = result.DeepGCBytesFreed
+		pluginReport.CompressedBytesSaved = result.CompressedBytesSaved
+		pluginReport.CleanedPaths = result.CleanedPaths
+		pluginReport.FreedBytesCheck = d.finishFreedBytesCheck(verification, p.Name(), result)
+		if pluginReport.FreedBytesCheck != nil && pluginReport.FreedBytesCheck.Discrepancy != "" {
+			report.FreedBytesDiscrepancies++
+		}
 		if result.Error != nil {
 			pluginReport.Error = result.Error.Error()
+			pluginReport.ErrorDetail = newPluginErrorReport(p.Name(), result.Error)
 			report.Plugins = append(report.Plugins, pluginReport)
-			d.logger.Error("plugin failed", "plugin", p.Name(), "error", result.Error)
+			d.emitPluginEnd(level, false, pluginReport)
+			d.logger.Error("plugin failed", "plugin", p.Name(), "operation", pluginReport.ErrorDetail.Operation, "error", result.Error)
 			if stateErr == nil {
 				state.recordPluginRun(p.Name(), pluginLevel, now, result)
+				state.recordEfficiency(p.Name(), pluginReport.BytesPerSecond)
 				stateDirty = true
 			}
 			continue
 		}
 
 		report.Plugins = append(report.Plugins, pluginReport)
+		d.emitPluginEnd(level, false, pluginReport)
 		if stateErr == nil {
 			state.recordPluginRun(p.Name(), pluginLevel, now, result)
+			state.recordEfficiency(p.Name(), pluginReport.BytesPerSecond)
 			stateDirty = true
 		}
 		if result.BytesFreed > 0 || result.ItemsCleaned > 0 {
@@ -403,12 +1050,14 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 			totalFreed += result.BytesFreed
 			totalItems += result.ItemsCleaned
 		}
+		report.TotalCompressedBytesSaved += result.CompressedBytesSaved
 
 		d.updateHostFreeAfter(&report, beforeStats, beforeErr)
 	}
 
 	report.TotalBytesFreed = totalFreed
 	report.TotalItemsCleaned = totalItems
+	report.EfficiencyRanking = efficiencyRanking(report.Plugins)
 
 	d.updateHostFreeAfter(&report, beforeStats, beforeErr)
 	if stateDirty {
@@ -427,13 +1076,13 @@ func (d *daemon) runOnce(ctx context.Context, forcedLevel monitor.CleanupLevel)
 		"delta_mb", report.HostFreeDeltaBytes/(1024*1024),
 	)
 
-	if !d.dryRun && totalFreed > 0 {
+	if !dryRun && totalFreed > 0 {
 		d.logger.Info("cleanup complete",
 			"total_freed_mb", totalFreed/(1024*1024),
 		)
 	}
 
-	return d.writeReport(report)
+	return report
 }
 
 type cycleReport struct {
@@ -449,27 +1098,167 @@ type cycleReport struct {
 	StateFile           string `json:"state_file,omitempty"`
 	StateError          string `json:"state_error,omitempty"`
 	CooldownSeconds     int64  `json:"cooldown_seconds,omitempty"`
+	// UsedPercent and FreeGB are MonitorPath's usage after the cycle.
+	UsedPercent float64 `json:"used_percent"`
+	FreeGB      float64 `json:"free_gb"`
+	// MaxLevel is the effective safety.max_level ceiling, when one is set.
+	MaxLevel string `json:"max_level,omitempty"`
+	// LevelClampedFrom is the level the cycle would have run without the ceiling.
+	LevelClampedFrom string `json:"level_clamped_from,omitempty"`
+	// HeavyDeferred reports that heavy plugins were skipped because the display
+	// was asleep and safety.skip_when_display_asleep is set.
+	HeavyDeferred bool `json:"heavy_deferred,omitempty"`
+	// MaxRuntimeSeconds is the overall cycle deadline, when one is set.
+	MaxRuntimeSeconds int64 `json:"max_runtime_seconds,omitempty"`
 	// TargetUsedPercent is the legacy target_free config value as a maximum used percentage.
 	TargetUsedPercent int `json:"target_used_percent"`
-	// TargetFreeBytes is the free-space equivalent required to satisfy TargetUsedPercent.
+	// TargetFreeGB is the target_free_gb value when it sets the target.
+	TargetFreeGB float64 `json:"target_free_gb,omitempty"`
+	// TargetFreeBytes is the free space required to satisfy the target.
 	TargetFreeBytes uint64 `json:"target_free_bytes"`
+	// TargetMaxUsedPercent is the target as a maximum used percentage.
+	TargetMaxUsedPercent float64 `json:"target_max_used_percent,omitempty"`
 	// TargetFreeDeficitBytes is the remaining free-space gap to the target.
 	TargetFreeDeficitBytes int64 `json:"target_free_deficit_bytes"`
 	// TargetFreeMet reports whether the host already satisfies the target.
 	TargetFreeMet bool `json:"target_free_met"`
 	// StopReason explains why remaining cleanup plugins were skipped.
 	StopReason string `json:"stop_reason,omitempty"`
+	// MinIntervalRemainingSeconds is how long -watch-min-interval holds back
+	// cleanup after this cycle.
+	MinIntervalRemainingSeconds int64 `json:"min_interval_remaining_seconds,omitempty"`
 	// PlannedEstimatedBytesFreed aggregates dry-run plugin plan estimates.
 	PlannedEstimatedBytesFreed int64 `json:"planned_estimated_bytes_freed,omitempty"`
 	// PlannedRequiredFreeBytes is the largest free-space preflight requirement across plugin plans.
 	PlannedRequiredFreeBytes int64 `json:"planned_required_free_bytes,omitempty"`
 	// PlannedTargets is the total number of dry-run cleanup targets.
-	PlannedTargets    int                 `json:"planned_targets,omitempty"`
-	TotalBytesFreed   int64               `json:"total_bytes_freed"`
-	TotalItemsCleaned int                 `json:"total_items_cleaned"`
-	Mounts            []mountReport       `json:"mounts"`
-	PluginFilter      []string            `json:"plugin_filter,omitempty"`
-	Plugins           []pluginCycleReport `json:"plugins"`
+	PlannedTargets    int   `json:"planned_targets,omitempty"`
+	TotalBytesFreed   int64 `json:"total_bytes_freed"`
+	TotalItemsCleaned int   `json:"total_items_cleaned"`
+	// TotalCompressedBytesSaved is the space saved by compressing files in
+	// place, kept out of TotalBytesFreed.
+	TotalCompressedBytesSaved int64 `json:"total_compressed_bytes_saved,omitempty"`
+	// EfficiencyRanking lists the plugins that freed space, most bytes per
+	// second first.
+	EfficiencyRanking []string      `json:"efficiency_ranking,omitempty"`
+	Mounts            []mountReport `json:"mounts"`
+	PluginFilter      []string      `json:"plugin_filter,omitempty"`
+	// PriorityMount is the mount addressed first when several monitored
+	// mounts reached the cycle level; mount-scoped plugins for other mounts
+	// are skipped with reason "other_mount".
+	PriorityMount string `json:"priority_mount,omitempty"`
+	// WatchPath is the watch_dirs entry whose growth triggered this cycle.
+	WatchPath string `json:"watch_path,omitempty"`
+	// WatchBytes is WatchPath's measured size when the cycle was triggered.
+	WatchBytes int64 `json:"watch_bytes,omitempty"`
+	// FreeNow reports a -free-now cycle.
+	FreeNow bool `json:"free_now,omitempty"`
+	// PluginOrder is the resolved execution order when plugin_order is set.
+	PluginOrder []string            `json:"plugin_order,omitempty"`
+	Plugins     []pluginCycleReport `json:"plugins"`
+	Proactive   []proactiveReport   `json:"proactive,omitempty"`
+	// ProactiveBytesFreed is kept out of TotalBytesFreed so level-driven
+	// and proactive reclaim can be told apart.
+	ProactiveBytesFreed int64 `json:"proactive_bytes_freed,omitempty"`
+	// IneffectiveCritical reports that a critical cycle freed less than
+	// notify.ineffective_critical_mb and an alert was raised.
+	IneffectiveCritical bool `json:"ineffective_critical,omitempty"`
+	// DiskHogs lists the largest directories under MonitorPath when the
+	// critical cycle was ineffective.
+	DiskHogs []diskHog `json:"disk_hogs,omitempty"`
+	// NotificationCoalesced reports that this critical cycle's notification
+	// was folded into the current critical streak instead of being sent.
+	NotificationCoalesced bool `json:"notification_coalesced,omitempty"`
+	// FreedBytesDiscrepancies counts plugins whose reported bytes diverged
+	// from the observed free-space delta under safety.verify_freed_bytes.
+	FreedBytesDiscrepancies int `json:"freed_bytes_discrepancies,omitempty"`
+	// Recommendations explain why a cycle that left the disk over threshold
+	// did not free more, when recommendations.enabled is set.
+	Recommendations []recommendation `json:"recommendations,omitempty"`
+}
+
+// proactiveReport is one plugin's per-cycle proactive check, which runs
+// regardless of host disk level.
+type proactiveReport struct {
+	Plugin           string             `json:"plugin"`
+	Triggered        bool               `json:"triggered"`
+	SkipReason       string             `json:"skip_reason,omitempty"`
+	ReclaimableBytes int64              `json:"reclaimable_bytes"`
+	ThresholdBytes   int64              `json:"threshold_bytes"`
+	BytesFreed       int64              `json:"bytes_freed"`
+	ItemsCleaned     int                `json:"items_cleaned"`
+	Error            string             `json:"error,omitempty"`
+	ErrorDetail      *pluginErrorReport `json:"error_detail,omitempty"`
+	// Hosts are remote daemons checked by the plugin, such as docker.hosts.
+	// Their bytes are freed remotely and not counted in ProactiveBytesFreed.
+	Hosts []remoteHostReport `json:"hosts,omitempty"`
+}
+
+// remoteHostReport is one remote daemon's proactive check.
+type remoteHostReport struct {
+	Host             string `json:"host"`
+	Triggered        bool   `json:"triggered"`
+	SkipReason       string `json:"skip_reason,omitempty"`
+	ReclaimableBytes int64  `json:"reclaimable_bytes"`
+	ThresholdBytes   int64  `json:"threshold_bytes"`
+	BytesFreed       int64  `json:"bytes_freed"`
+	ItemsCleaned     int    `json:"items_cleaned"`
+	Error            string `json:"error,omitempty"`
+}
+
+// runProactiveCleanup runs every enabled ProactiveCleaner. These checks use
+// the plugin's own usage signal, such as Docker's VM disk, so they run even
+// when the host is below the warning threshold.
+func (d *daemon) runProactiveCleanup(ctx context.Context, report *cycleReport, dryRun bool) {
+	cfg := d.currentConfig()
+	for _, p := range executionOrder(filterEnabledPlugins(d.registry.GetEnabled(cfg), report.PluginFilter), cfg.PluginOrder) {
+		cleaner, ok := p.(plugins.ProactiveCleaner)
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+		result := cleaner.ProactiveCleanup(ctx, cfg, dryRun, d.logger)
+		if !result.Checked {
+			continue
+		}
+		entry := proactiveReport{
+			Plugin:           p.Name(),
+			Triggered:        result.Triggered,
+			SkipReason:       result.SkipReason,
+			ReclaimableBytes: result.ReclaimableBytes,
+			ThresholdBytes:   result.ThresholdBytes,
+			BytesFreed:       result.BytesFreed,
+			ItemsCleaned:     result.ItemsCleaned,
+		}
+		if result.Error != nil {
+			entry.Error = result.Error.Error()
+			entry.ErrorDetail = newPluginErrorReport(p.Name(), result.Error)
+			d.logger.Warn("proactive cleanup failed", "plugin", p.Name(), "error", result.Error)
+		}
+		for _, host := range result.Hosts {
+			hostEntry := remoteHostReport{
+				Host:             host.Host,
+				Triggered:        host.Triggered,
+				SkipReason:       host.SkipReason,
+				ReclaimableBytes: host.ReclaimableBytes,
+				ThresholdBytes:   host.ThresholdBytes,
+				BytesFreed:       host.BytesFreed,
+				ItemsCleaned:     host.ItemsCleaned,
+			}
+			if host.Error != nil {
+				hostEntry.Error = host.Error.Error()
+			}
+			entry.Hosts = append(entry.Hosts, hostEntry)
+		}
+		if result.Triggered && !dryRun {
+			d.logger.Info("proactive cleanup complete",
+				"plugin", p.Name(),
+				"freed_mb", result.BytesFreed/(1024*1024),
+				"items", result.ItemsCleaned,
+			)
+		}
+		report.ProactiveBytesFreed += result.BytesFreed
+		report.Proactive = append(report.Proactive, entry)
+	}
 }
 
 type mountReport struct {
@@ -479,27 +1268,89 @@ type mountReport struct {
 	FreeGB      float64 `json:"free_gb"`
 	FreeBytes   uint64  `json:"free_bytes"`
 	Level       string  `json:"level"`
+	Priority    int     `json:"priority,omitempty"`
 	Error       string  `json:"error,omitempty"`
 }
 
 type pluginCycleReport struct {
-	Name                     string               `json:"name"`
-	Description              string               `json:"description"`
-	Level                    string               `json:"level"`
-	DryRun                   bool                 `json:"dry_run"`
-	WouldRun                 bool                 `json:"would_run"`
-	SkipReason               string               `json:"skip_reason,omitempty"`
-	Plan                     *plugins.CleanupPlan `json:"plan,omitempty"`
-	BytesFreed               int64                `json:"bytes_freed"`
-	EstimatedBytesFreed      int64                `json:"estimated_bytes_freed"`
-	CommandBytesFreed        int64                `json:"command_bytes_freed"`
-	HostBytesFreed           int64                `json:"host_bytes_freed"`
-	ItemsCleaned             int                  `json:"items_cleaned"`
-	CooldownRemainingSeconds int64                `json:"cooldown_remaining_seconds,omitempty"`
-	Error                    string               `json:"error,omitempty"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Priority    int                  `json:"priority"`
+	Level       string               `json:"level"`
+	DryRun      bool                 `json:"dry_run"`
+	WouldRun    bool                 `json:"would_run"`
+	SkipReason  string               `json:"skip_reason,omitempty"`
+	Plan        *plugins.CleanupPlan `json:"plan,omitempty"`
+	// Commands lists the external commands a dry run would execute, for
+	// plugins that expose them.
+	Commands              [][]string `json:"commands,omitempty"`
+	BytesFreed            int64      `json:"bytes_freed"`
+	EstimatedBytesFreed   int64      `json:"estimated_bytes_freed"`
+	CommandBytesFreed     int64      `json:"command_bytes_freed"`
+	HostBytesFreed        int64      `json:"host_bytes_freed"`
+	BytesGrown            int64      `json:"bytes_grown,omitempty"`
+	ItemsCleaned          int        `json:"items_cleaned"`
+	ProtectedSkippedItems int        `json:"protected_skipped_items,omitempty"`
+	ProtectedSkippedBytes int64      `json:"protected_skipped_bytes,omitempty"`
+	WalkPermissionSkips   int        `json:"walk_permission_skips,omitempty"`
+	WalkErrorSkips        int        `json:"walk_error_skips,omitempty"`
+	DeepGCBytesFreed      int64      `json:"deep_gc_bytes_freed,omitempty"`
+	// CompressedBytesSaved is the space saved by compressing files in place,
+	// kept out of BytesFreed.
+	CompressedBytesSaved int64 `json:"compressed_bytes_saved,omitempty"`
+	// DurationSeconds is the wall-clock time the plugin's cleanup took.
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// BytesPerSecond is BytesFreed divided by DurationSeconds.
+	BytesPerSecond           int64              `json:"bytes_per_second,omitempty"`
+	CooldownRemainingSeconds int64              `json:"cooldown_remaining_seconds,omitempty"`
+	Cancelled                bool               `json:"cancelled,omitempty"`
+	Error                    string             `json:"error,omitempty"`
+	ErrorDetail              *pluginErrorReport `json:"error_detail,omitempty"`
+	// FreedBytesCheck is set when safety.verify_freed_bytes measured the
+	// plugin's volume around its cleanup.
+	FreedBytesCheck *freedBytesCheck `json:"freed_bytes_check,omitempty"`
+	// Skipped reports that the plugin did not clean, for any SkipReason.
+	Skipped bool `json:"skipped"`
+	// LevelClampedFrom is the cycle level when plugin_levels ran the plugin
+	// at a lower Level.
+	LevelClampedFrom string `json:"level_clamped_from,omitempty"`
+	// CleanedPaths lists configured paths the plugin emptied, such as
+	// cache.extra_paths entries.
+	CleanedPaths []plugins.CleanedPath `json:"cleaned_paths,omitempty"`
+}
+
+// pluginErrorReport is the structured form of a plugin failure. Operation is
+// "unknown" when the plugin returned a plain error.
+type pluginErrorReport struct {
+	Plugin        string `json:"plugin"`
+	Operation     string `json:"operation"`
+	Path          string `json:"path,omitempty"`
+	VM            string `json:"vm,omitempty"`
+	Message       string `json:"message"`
+	CommandOutput string `json:"command_output,omitempty"`
+}
+
+func newPluginErrorReport(plugin string, err error) *pluginErrorReport {
+	report := &pluginErrorReport{Plugin: plugin, Operation: "unknown", Message: err.Error()}
+	pluginErr, ok := plugins.AsPluginError(err)
+	if !ok {
+		return report
+	}
+	if pluginErr.Plugin != "" {
+		report.Plugin = pluginErr.Plugin
+	}
+	report.Operation = pluginErr.Operation
+	report.Path = pluginErr.Path
+	report.VM = pluginErr.VM
+	if pluginErr.Err != nil {
+		report.Message = pluginErr.Err.Error()
+	}
+	report.CommandOutput = pluginErr.CommandOutput
+	return report
 }
 
 type pluginListReport struct {
+	Order   string            `json:"order"`
 	Plugins []pluginListEntry `json:"plugins"`
 }
 
@@ -509,6 +1360,31 @@ type pluginListEntry struct {
 	Enabled            bool     `json:"enabled"`
 	Supported          bool     `json:"supported"`
 	SupportedPlatforms []string `json:"supported_platforms,omitempty"`
+	Priority           int      `json:"priority"`
+}
+
+type levelListReport struct {
+	Plugins []levelListEntry `json:"plugins"`
+}
+
+type levelListEntry struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Levels      []levelDescriptionEntry `json:"levels"`
+}
+
+type levelDescriptionEntry struct {
+	Level       string `json:"level"`
+	Description string `json:"description"`
+	// Excluded is true when level_plugins leaves the plugin out at this level.
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+type commandExplanationReport struct {
+	Plugin   string     `json:"plugin"`
+	Level    string     `json:"level"`
+	Enabled  bool       `json:"enabled"`
+	Commands [][]string `json:"commands"`
 }
 
 type mountAssessment struct {
@@ -520,11 +1396,12 @@ type mountAssessment struct {
 // cleanup level detected across all of them. Falls back to home directory
 // monitoring if no mounts are configured.
 func (d *daemon) assessMounts() mountAssessment {
+	cfg := d.currentConfig()
 	assessment := mountAssessment{Level: monitor.LevelNone}
 
-	if len(d.config.MonitoredMounts) > 0 {
+	if len(cfg.MonitoredMounts) > 0 {
 		// Multi-mount monitoring: check each configured mount point
-		for _, mount := range d.config.MonitoredMounts {
+		for _, mount := range cfg.MonitoredMounts {
 			stats, err := d.getDiskStats(mount.Path)
 			label := mount.Label
 			if label == "" {
@@ -533,21 +1410,22 @@ func (d *daemon) assessMounts() mountAssessment {
 			if err != nil {
 				d.logger.Warn("failed to check mount", "path", mount.Path, "label", mount.Label, "error", err)
 				assessment.Mounts = append(assessment.Mounts, mountReport{
-					Label: label,
-					Path:  mount.Path,
-					Level: monitor.LevelNone.String(),
-					Error: err.Error(),
+					Label:    label,
+					Path:     mount.Path,
+					Level:    monitor.LevelNone.String(),
+					Priority: mount.Priority,
+					Error:    err.Error(),
 				})
 				continue
 			}
 
 			// Use per-mount thresholds if configured, otherwise use global
-			mountMonitor := d.monitor
+			mountMonitor := d.currentMonitor()
 			if mount.ThresholdWarning > 0 || mount.ThresholdCritical > 0 {
-				warning := d.config.Thresholds.Warning
-				moderate := d.config.Thresholds.Moderate
-				aggressive := d.config.Thresholds.Aggressive
-				critical := d.config.Thresholds.Critical
+				warning := cfg.Thresholds.Warning
+				moderate := cfg.Thresholds.Moderate
+				aggressive := cfg.Thresholds.Aggressive
+				critical := cfg.Thresholds.Critical
 				if mount.ThresholdWarning > 0 {
 					warning = mount.ThresholdWarning
 				}
@@ -565,6 +1443,7 @@ func (d *daemon) assessMounts() mountAssessment {
 				FreeGB:      stats.FreeGB,
 				FreeBytes:   stats.Free,
 				Level:       mountLevel.String(),
+				Priority:    mount.Priority,
 			})
 
 			d.logger.Info("disk status",
@@ -583,10 +1462,7 @@ func (d *daemon) assessMounts() mountAssessment {
 		// Fallback: monitor home directory (original behavior)
 		// On macOS, "/" is the sealed system volume, but user data is on /System/Volumes/Data
 		// Using $HOME ensures we monitor the volume where data actually lives
-		monitorPath := "/"
-		if home, err := os.UserHomeDir(); err == nil && home != "" {
-			monitorPath = home
-		}
+		monitorPath := defaultMonitorPath()
 
 		stats, err := d.getDiskStats(monitorPath)
 		if err != nil {
@@ -599,7 +1475,7 @@ func (d *daemon) assessMounts() mountAssessment {
 			})
 			return assessment
 		}
-		detectedLevel := d.monitor.CheckLevel(stats)
+		detectedLevel := d.currentMonitor().CheckLevel(stats)
 
 		assessment.Mounts = append(assessment.Mounts, mountReport{
 			Label:       monitorPath,
@@ -611,6 +1487,7 @@ func (d *daemon) assessMounts() mountAssessment {
 		})
 
 		d.logger.Info("disk status",
+			"mount", monitorPath,
 			"used_percent", fmt.Sprintf("%.1f%%", stats.UsedPercent),
 			"free_gb", fmt.Sprintf("%.1fGB", stats.FreeGB),
 			"level", detectedLevel.String(),
@@ -622,28 +1499,39 @@ func (d *daemon) assessMounts() mountAssessment {
 	return assessment
 }
 
+// defaultMonitorPath is the path checked when no monitored_mounts are
+// configured: the home directory, or "/" when it is unavailable.
+func defaultMonitorPath() string {
+	if home, err := env.HomeDir(); err == nil {
+		return home
+	}
+	return "/"
+}
+
 func (d *daemon) checkMounts() monitor.CleanupLevel {
 	return d.assessMounts().Level
 }
 
 func (d *daemon) primaryMonitorPath(assessment mountAssessment) string {
-	for _, mount := range assessment.Mounts {
-		if mount.Error == "" && mount.Path != "" && mount.Level == assessment.Level.String() {
-			return mount.Path
-		}
+	if mount, _ := assessment.priorityMount(); mount.Path != "" {
+		return mount.Path
 	}
 	for _, mount := range assessment.Mounts {
 		if mount.Error == "" && mount.Path != "" {
 			return mount.Path
 		}
 	}
-	if home, err := os.UserHomeDir(); err == nil && home != "" {
+	if home, err := env.HomeDir(); err == nil {
 		return home
 	}
 	return "/"
 }
 
 func (d *daemon) writeReport(report cycleReport) error {
+	report = d.redactReport(report)
+	// Watch-triggered cycles report from their own goroutines.
+	d.reportMu.Lock()
+	defer d.reportMu.Unlock()
 	if d.output == "json" {
 		encoder := json.NewEncoder(d.report)
 		encoder.SetIndent("", "  ")
@@ -655,6 +1543,53 @@ func (d *daemon) writeReport(report cycleReport) error {
 	return nil
 }
 
+// redactReport masks paths in every string field of report when redaction is
+// enabled. Sizes, counts, and plugin names are unchanged.
+func (d *daemon) redactReport(report cycleReport) cycleReport {
+	if d.redactor == nil {
+		return report
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return report
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return report
+	}
+	data, err = json.Marshal(d.redactor.Value(generic))
+	if err != nil {
+		return report
+	}
+	var redacted cycleReport
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		return report
+	}
+	return redacted
+}
+
+// newRedactor returns a path redactor when log.redact is set, or nil. The
+// invoking user, run_as_user, and the home directory's owner are masked.
+func newRedactor(cfg *config.Config) *redact.Redactor {
+	if !cfg.Log.Redact {
+		return nil
+	}
+	opts := redact.Options{ProjectNames: cfg.Log.RedactProjectNames}
+	if home, err := env.HomeDir(); err == nil {
+		opts.Home = home
+		opts.Usernames = append(opts.Usernames, filepath.Base(home))
+	}
+	if cfg.RunAsUser != "" {
+		opts.Usernames = append(opts.Usernames, cfg.RunAsUser)
+	}
+	if current, err := user.Current(); err == nil {
+		opts.Usernames = append(opts.Usernames, current.Username)
+	}
+	return redact.New(opts)
+}
+
 func (d *daemon) getDiskStats(path string) (*monitor.DiskStats, error) {
 	if d.diskStats != nil {
 		return d.diskStats(path)
@@ -670,30 +1605,66 @@ func (d *daemon) currentTime() time.Time {
 }
 
 func (d *daemon) cleanupCooldown() time.Duration {
-	if d.config == nil || d.config.Policy.Cooldown == "" {
+	cfg := d.currentConfig()
+	if cfg == nil || cfg.Policy.Cooldown == "" {
 		return 0
 	}
-	duration, err := time.ParseDuration(d.config.Policy.Cooldown)
+	duration, err := time.ParseDuration(cfg.Policy.Cooldown)
 	if err != nil || duration < 0 {
 		return 0
 	}
 	return duration
 }
 
-func (d *daemon) loadStateForCycle() (*cleanupState, error) {
-	if d.dryRun || d.config == nil {
+// minIntervalRemaining returns how much of -watch-min-interval is left since
+// the last cycle that cleaned. Dry runs are never held back. Callers hold
+// runMu.
+func (d *daemon) minIntervalRemaining(now time.Time, dryRun bool) time.Duration {
+	if dryRun || d.minInterval <= 0 || d.lastCleanup.IsZero() {
+		return 0
+	}
+	if remaining := d.lastCleanup.Add(d.minInterval).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// validateWatchMinInterval rejects a negative -watch-min-interval and one
+// set outside daemon mode, where there is no next cycle to space out.
+func validateWatchMinInterval(interval time.Duration, daemonMode bool) error {
+	if interval < 0 {
+		return fmt.Errorf("invalid -watch-min-interval %s: must not be negative", interval)
+	}
+	if interval > 0 && !daemonMode {
+		return fmt.Errorf("-watch-min-interval requires -daemon without -once or -level")
+	}
+	return nil
+}
+
+func (d *daemon) loadStateForCycle(dryRun bool) (*cleanupState, error) {
+	cfg := d.currentConfig()
+	if dryRun || cfg == nil {
 		return newCleanupState(), nil
 	}
-	return loadCleanupState(expandPathHome(d.config.Policy.StateFile))
+	return loadCleanupState(expandPathHome(cfg.Policy.StateFile))
 }
 
 func (d *daemon) shouldApplyCooldown(report cycleReport, level monitor.CleanupLevel) bool {
-	return !d.dryRun &&
+	return !report.DryRun &&
 		!report.ForcedLevel &&
 		level != monitor.LevelCritical &&
 		d.cleanupCooldown() > 0
 }
 
+// shouldDeferHeavyWork reports whether heavy plugins should be skipped this
+// cycle. Forced levels are not deferred; the operator asked for the run.
+func (d *daemon) shouldDeferHeavyWork(report cycleReport) bool {
+	return d.currentConfig().Safety.SkipWhenDisplayAsleep &&
+		!report.ForcedLevel &&
+		d.displayAsleep != nil &&
+		d.displayAsleep()
+}
+
 func (d *daemon) updateHostFreeAfter(report *cycleReport, beforeStats *monitor.DiskStats, beforeErr error) {
 	afterStats, afterErr := d.getDiskStats(report.MonitorPath)
 	if afterErr != nil {
@@ -703,6 +1674,8 @@ func (d *daemon) updateHostFreeAfter(report *cycleReport, beforeStats *monitor.D
 	}
 
 	report.HostFreeAfterBytes = afterStats.Free
+	report.UsedPercent = afterStats.UsedPercent
+	report.FreeGB = afterStats.FreeGB
 	if beforeErr == nil && beforeStats != nil {
 		report.HostFreeDeltaBytes = int64(afterStats.Free) - int64(beforeStats.Free)
 	}
@@ -710,13 +1683,19 @@ func (d *daemon) updateHostFreeAfter(report *cycleReport, beforeStats *monitor.D
 }
 
 func (d *daemon) updateTargetFreeStatus(report *cycleReport, stats *monitor.DiskStats) {
-	targetFreeBytes, ok := targetFreeBytes(stats.Total, d.config.TargetFree)
+	cfg := d.currentConfig()
+	targetFreeBytes, ok := effectiveTargetFreeBytes(stats.Total, cfg)
 	if !ok {
 		return
 	}
 
-	report.TargetUsedPercent = d.config.TargetFree
+	if cfg.TargetFreeGB > 0 {
+		report.TargetFreeGB = cfg.TargetFreeGB
+	} else {
+		report.TargetUsedPercent = cfg.TargetFree
+	}
 	report.TargetFreeBytes = targetFreeBytes
+	report.TargetMaxUsedPercent = 100 - float64(targetFreeBytes)*100/float64(stats.Total)
 	if stats.Free >= targetFreeBytes {
 		report.TargetFreeDeficitBytes = 0
 		report.TargetFreeMet = true
@@ -727,6 +1706,20 @@ func (d *daemon) updateTargetFreeStatus(report *cycleReport, stats *monitor.Disk
 	report.TargetFreeMet = false
 }
 
+// effectiveTargetFreeBytes resolves the free-space target for a disk of
+// totalBytes. target_free_gb wins over the target_free percentage. A GiB
+// target that does not fit on the disk yields no target.
+func effectiveTargetFreeBytes(totalBytes uint64, cfg *config.Config) (uint64, bool) {
+	if cfg.TargetFreeGB > 0 {
+		target := uint64(cfg.TargetFreeGB * 1024 * 1024 * 1024)
+		if totalBytes == 0 || target >= totalBytes {
+			return 0, false
+		}
+		return target, true
+	}
+	return targetFreeBytes(totalBytes, cfg.TargetFree)
+}
+
 func targetFreeBytes(totalBytes uint64, targetUsedPercent int) (uint64, bool) {
 	if totalBytes == 0 || targetUsedPercent <= 0 || targetUsedPercent >= 100 {
 		return 0, false
@@ -736,6 +1729,165 @@ func targetFreeBytes(totalBytes uint64, targetUsedPercent int) (uint64, bool) {
 	return totalBytes * uint64(freePercent) / 100, true
 }
 
+// validateTargetFree rejects a negative target_free_gb or one that is not
+// smaller than the disk holding monitorPath. A disk that cannot be measured
+// is not an error here; the cycle reports it.
+func validateTargetFree(cfg *config.Config, monitorPath string, stat func(string) (*monitor.DiskStats, error)) error {
+	if cfg.TargetFreeGB < 0 {
+		return fmt.Errorf("invalid target_free_gb %g: must not be negative", cfg.TargetFreeGB)
+	}
+	if cfg.TargetFreeGB == 0 {
+		return nil
+	}
+	stats, err := stat(monitorPath)
+	if err != nil || stats.Total == 0 {
+		return nil
+	}
+	if _, ok := effectiveTargetFreeBytes(stats.Total, cfg); !ok {
+		return fmt.Errorf("invalid target_free_gb %g: %s has only %s", cfg.TargetFreeGB, monitorPath, formatByteCount(int64(stats.Total)))
+	}
+	return nil
+}
+
+// validateRuntimeConfig checks the config settings that depend on the
+// registered plugins or are only read by plugins and the daemon.
+func validateRuntimeConfig(cfg *config.Config, registry *plugins.Registry) error {
+	if err := validatePluginOrder(cfg.PluginOrder, registry); err != nil {
+		return err
+	}
+	if err := validateLevelPlugins(cfg.LevelPlugins, registry); err != nil {
+		return err
+	}
+	if err := validatePluginLevels(cfg.PluginLevels, registry); err != nil {
+		return err
+	}
+	if _, err := watchDirSpecs(cfg, registry); err != nil {
+		return err
+	}
+	if err := validateNotifyConfig(cfg.Notify); err != nil {
+		return err
+	}
+	if err := validateCooldowns(cfg.Cooldowns); err != nil {
+		return err
+	}
+	if err := plugins.ValidateScratchDirs(cfg.ScratchDirs); err != nil {
+		return err
+	}
+	if err := plugins.ValidateScratchConfig(cfg.Scratch); err != nil {
+		return err
+	}
+	if err := plugins.ValidateDockerHosts(cfg.Docker.Hosts); err != nil {
+		return err
+	}
+	if err := plugins.ValidateJunkFilePatterns(cfg.JunkFiles.Patterns); err != nil {
+		return err
+	}
+	if err := plugins.ValidateCacheConfig(cfg.Cache); err != nil {
+		return err
+	}
+	if _, err := newMetricsExporter(cfg.Observability); err != nil {
+		return err
+	}
+	if cfg.Enable.SparseFiles {
+		if err := plugins.ValidateSparseFilesConfig(cfg.SparseFiles); err != nil {
+			return err
+		}
+	}
+	if cfg.Enable.AppLogs {
+		if err := plugins.ValidateAppLogsConfig(cfg.AppLogs); err != nil {
+			return err
+		}
+	}
+	if cfg.Enable.ExternalTrash {
+		if err := plugins.ValidateExternalTrashConfig(cfg.ExternalTrash); err != nil {
+			return err
+		}
+	}
+	if cfg.Enable.ElectronApps {
+		if err := plugins.ValidateElectronAppsConfig(cfg.ElectronApps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCooldowns rejects cooldowns keys no plugin consults, which are
+// most likely typos, and negative hours.
+func validateCooldowns(cooldowns map[string]float64) error {
+	keys := make([]string, 0, len(cooldowns))
+	for key := range cooldowns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !slices.Contains(plugins.CooldownOperations, key) {
+			return fmt.Errorf("unknown cooldowns key %q: expected one of %s", key, strings.Join(plugins.CooldownOperations, ", "))
+		}
+		if cooldowns[key] < 0 {
+			return fmt.Errorf("invalid cooldowns.%s %g: hours must not be negative", key, cooldowns[key])
+		}
+	}
+	return nil
+}
+
+// cycleMaxRuntime resolves the per-cycle deadline. A -max-runtime flag wins
+// over pool.max_cycle_minutes; zero means no deadline.
+func cycleMaxRuntime(cfg *config.Config, flagValue time.Duration) (time.Duration, error) {
+	if flagValue < 0 {
+		return 0, fmt.Errorf("invalid max-runtime %s: must not be negative", flagValue)
+	}
+	if flagValue > 0 {
+		return flagValue, nil
+	}
+	if cfg.Pool.MaxCycleMinutes < 0 {
+		return 0, fmt.Errorf("invalid pool.max_cycle_minutes %d: must not be negative", cfg.Pool.MaxCycleMinutes)
+	}
+	return time.Duration(cfg.Pool.MaxCycleMinutes) * time.Minute, nil
+}
+
+// safetyMaxLevel returns the safety.max_level ceiling. An empty value means
+// no ceiling, so critical cleanup stays reachable.
+func safetyMaxLevel(cfg *config.Config) (monitor.CleanupLevel, error) {
+	if cfg.Safety.MaxLevel == "" {
+		return monitor.LevelCritical, nil
+	}
+	level := parseLevel(cfg.Safety.MaxLevel)
+	if level == monitor.LevelNone {
+		return monitor.LevelCritical, fmt.Errorf("invalid safety.max_level %q: expected warning, moderate, aggressive, or critical", cfg.Safety.MaxLevel)
+	}
+	return level, nil
+}
+
+// cycleSkipReason names why the cycle context ended.
+func cycleSkipReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "max_runtime_exceeded"
+	}
+	return "cancelled"
+}
+
+// applyTargetOverrides applies -target-used-percent or -target-free-gb. Only
+// one may be given; either replaces both config targets for the run.
+func applyTargetOverrides(cfg *config.Config, targetUsedPercent int, targetFreeGB float64) error {
+	if targetUsedPercent != 0 && targetFreeGB != 0 {
+		return fmt.Errorf("-target-used-percent and -target-free-gb are mutually exclusive")
+	}
+	if targetFreeGB != 0 {
+		if targetFreeGB < 0 {
+			return fmt.Errorf("invalid target-free-gb %g: must be positive", targetFreeGB)
+		}
+		cfg.TargetFreeGB = targetFreeGB
+		return nil
+	}
+	if err := applyTargetUsedPercentOverride(cfg, targetUsedPercent); err != nil {
+		return err
+	}
+	if targetUsedPercent != 0 {
+		cfg.TargetFreeGB = 0
+	}
+	return nil
+}
+
 func applyTargetUsedPercentOverride(cfg *config.Config, targetUsedPercent int) error {
 	if targetUsedPercent == 0 {
 		return nil
@@ -786,6 +1938,48 @@ func validatePluginFilter(filter []string, registry *plugins.Registry) error {
 	return nil
 }
 
+// validateSkipFilter rejects unknown names in -skip and names also given
+// to -only or -plugins, which would leave nothing clear to run.
+func validateSkipFilter(skip, only []string, registry *plugins.Registry) error {
+	if err := validatePluginFilter(skip, registry); err != nil {
+		return err
+	}
+	for _, name := range skip {
+		if slices.Contains(only, name) {
+			return fmt.Errorf("plugin %q is in both -skip and -only", name)
+		}
+	}
+	return nil
+}
+
+// validatePluginOrder rejects unknown or repeated names in plugin_order.
+func validatePluginOrder(order []string, registry *plugins.Registry) error {
+	available := make(map[string]struct{})
+	for _, name := range availablePluginNames(registry) {
+		available[name] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(order))
+	for _, name := range order {
+		if _, ok := available[name]; !ok {
+			return fmt.Errorf("unknown plugin %q in plugin_order; available plugins: %s", name, strings.Join(availablePluginNames(registry), ", "))
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("plugin %q is listed more than once in plugin_order", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
+
+// executionOrder orders plugins by plugin_order when it is set and by
+// priority otherwise.
+func executionOrder(list []plugins.Plugin, order []string) []plugins.Plugin {
+	if len(order) == 0 {
+		return plugins.SortByPriority(list)
+	}
+	return plugins.SortByOrder(list, order)
+}
+
 func availablePluginNames(registry *plugins.Registry) []string {
 	seen := make(map[string]struct{})
 	for _, plugin := range registry.GetAll() {
@@ -817,8 +2011,9 @@ func filterEnabledPlugins(enabled []plugins.Plugin, filter []string) []plugins.P
 	return filtered
 }
 
+// listPluginEntries lists registered plugins in effective execution order.
 func listPluginEntries(registry *plugins.Registry, cfg *config.Config) []pluginListEntry {
-	registered := registry.GetAll()
+	registered := executionOrder(registry.GetAll(), cfg.PluginOrder)
 	entries := make([]pluginListEntry, 0, len(registered))
 	for _, plugin := range registered {
 		supportedPlatforms := plugin.SupportedPlatforms()
@@ -828,6 +2023,7 @@ func listPluginEntries(registry *plugins.Registry, cfg *config.Config) []pluginL
 			Enabled:            plugin.Enabled(cfg),
 			Supported:          pluginSupportedOnCurrentPlatform(supportedPlatforms),
 			SupportedPlatforms: supportedPlatforms,
+			Priority:           plugins.PluginPriority(plugin),
 		})
 	}
 	return entries
@@ -845,14 +2041,27 @@ func pluginSupportedOnCurrentPlatform(supportedPlatforms []string) bool {
 	return false
 }
 
-func writePluginList(w io.Writer, output string, entries []pluginListEntry) error {
+// pluginListOrder names what determines execution order: "plugin_order" when
+// the config lists one and "priority" otherwise.
+func pluginListOrder(cfg *config.Config) string {
+	if len(cfg.PluginOrder) > 0 {
+		return "plugin_order"
+	}
+	return "priority"
+}
+
+func writePluginList(w io.Writer, output string, order string, entries []pluginListEntry) error {
 	if output == "json" {
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
-		return encoder.Encode(pluginListReport{Plugins: entries})
+		return encoder.Encode(pluginListReport{Order: order, Plugins: entries})
 	}
 
-	if _, err := fmt.Fprintln(w, "tinyland-cleanup plugins"); err != nil {
+	header := "tinyland-cleanup plugins"
+	if order == "plugin_order" {
+		header += " (ordered by plugin_order)"
+	}
+	if _, err := fmt.Fprintln(w, header); err != nil {
 		return err
 	}
 	for _, entry := range entries {
@@ -867,31 +2076,184 @@ func writePluginList(w io.Writer, output string, entries []pluginListEntry) erro
 		if len(entry.SupportedPlatforms) > 0 {
 			supported += " on " + strings.Join(entry.SupportedPlatforms, ",")
 		}
-		if _, err := fmt.Fprintf(w, "- %s: %s, %s - %s\n", entry.Name, enabled, supported, entry.Description); err != nil {
+		if _, err := fmt.Fprintf(w, "- %s: %s, %s - %s (priority %d)\n", entry.Name, enabled, supported, entry.Description, entry.Priority); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+const noLevelDescription = "no level description available"
+
+func listLevelEntries(registry *plugins.Registry, cfg *config.Config, pluginFilter []string) []levelListEntry {
+	enabled := executionOrder(filterEnabledPlugins(registry.GetEnabled(cfg), pluginFilter), cfg.PluginOrder)
+	entries := make([]levelListEntry, 0, len(enabled))
+	for _, plugin := range enabled {
+		describer, _ := plugin.(plugins.LevelDescriber)
+		levels := make([]levelDescriptionEntry, 0, len(plugins.ActionLevels()))
+		for _, level := range plugins.ActionLevels() {
+			description := noLevelDescription
+			if describer != nil {
+				description = describer.LevelDescription(level)
+			}
+			levels = append(levels, levelDescriptionEntry{
+				Level:       level.String(),
+				Description: description,
+				Excluded:    !permittedAtLevel(cfg, level, plugin.Name()),
+			})
+		}
+		entries = append(entries, levelListEntry{
+			Name:        plugin.Name(),
+			Description: plugin.Description(),
+			Levels:      levels,
+		})
+	}
+	return entries
+}
+
+func writeLevelList(w io.Writer, output string, entries []levelListEntry) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(levelListReport{Plugins: entries})
+	}
+
+	if _, err := fmt.Fprintln(w, "tinyland-cleanup levels"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "- %s: %s\n", entry.Name, entry.Description); err != nil {
+			return err
+		}
+		for _, level := range entry.Levels {
+			description := level.Description
+			if level.Excluded {
+				description = "not run (excluded by level_plugins)"
+			}
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", level.Level, description); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func explainPluginCommands(registry *plugins.Registry, cfg *config.Config, name, levelName string) (commandExplanationReport, error) {
+	level := parseLevel(levelName)
+	if level == monitor.LevelNone {
+		return commandExplanationReport{}, fmt.Errorf("-explain-plugin requires -level warning, moderate, aggressive, or critical")
+	}
+
+	for _, plugin := range registry.GetAll() {
+		if plugin.Name() != name {
+			continue
+		}
+		explainer, ok := plugin.(plugins.CommandExplainer)
+		if !ok {
+			return commandExplanationReport{}, fmt.Errorf("plugin %q does not expose its cleanup commands", name)
+		}
+		commands := explainer.CleanupCommands(plugins.CleanupLevel(level), cfg)
+		if commands == nil {
+			commands = [][]string{}
+		}
+		return commandExplanationReport{
+			Plugin:   name,
+			Level:    level.String(),
+			Enabled:  plugin.Enabled(cfg),
+			Commands: commands,
+		}, nil
+	}
+	return commandExplanationReport{}, fmt.Errorf("unknown plugin %q", name)
+}
+
+func writeCommandExplanation(w io.Writer, output string, report commandExplanationReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if _, err := fmt.Fprintf(w, "tinyland-cleanup %s commands at %s\n", report.Plugin, report.Level); err != nil {
+		return err
+	}
+	if !report.Enabled {
+		if _, err := fmt.Fprintln(w, "plugin is disabled in config; these commands would run only after enabling it"); err != nil {
+			return err
+		}
+	}
+	if len(report.Commands) == 0 {
+		_, err := fmt.Fprintln(w, "no commands")
+		return err
+	}
+	for _, args := range report.Commands {
+		if _, err := fmt.Fprintf(w, "  %s\n", shellJoin(args)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellJoin renders args as a copy-pasteable shell command line.
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=./:,@%+") == "" {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
 func expandPathHome(path string) string {
 	if path == "" {
 		return ""
 	}
 	if path == "~" {
-		if home, err := os.UserHomeDir(); err == nil {
+		if home, err := env.HomeDir(); err == nil {
 			return home
 		}
 		return path
 	}
 	if strings.HasPrefix(path, "~/") {
-		if home, err := os.UserHomeDir(); err == nil {
+		if home, err := env.HomeDir(); err == nil {
 			return filepath.Join(home, strings.TrimPrefix(path, "~/"))
 		}
 	}
 	return path
 }
 
+// logConfigVersion warns when the config file predates this binary's schema
+// and names the new keys that took their defaults, so they can be reviewed.
+func logConfigVersion(logger *slog.Logger, cfg *config.Config) {
+	if cfg.ConfigVersion > config.CurrentConfigVersion {
+		logger.Warn("config_version is newer than this binary; unknown keys are ignored",
+			"config_version", cfg.ConfigVersion, "supported", config.CurrentConfigVersion)
+		return
+	}
+	if cfg.Migration == nil || len(cfg.Migration.Defaulted) == 0 {
+		return
+	}
+	logger.Warn("config predates this version; new keys use their defaults, review them or run -migrate-config",
+		"config_version", cfg.Migration.FromVersion,
+		"current_version", config.CurrentConfigVersion,
+		"defaulted", strings.Join(cfg.Migration.Defaulted, ","))
+}
+
+// writeConfigMigration reports what -migrate-config changed.
+func writeConfigMigration(w io.Writer, path string, migration *config.Migration) {
+	if migration == nil {
+		fmt.Fprintf(w, "%s is already at config_version %d\n", path, config.CurrentConfigVersion)
+		return
+	}
+	fmt.Fprintf(w, "migrated %s from config_version %d to %d; original saved as %s.bak\n",
+		path, migration.FromVersion, config.CurrentConfigVersion, path)
+	for _, key := range migration.Defaulted {
+		fmt.Fprintf(w, "  added default: %s\n", key)
+	}
+}
+
 func bytesToGB(bytes uint64) string {
 	return fmt.Sprintf("%.1f", float64(bytes)/(1024*1024*1024))
 }
@@ -900,13 +2262,23 @@ func registerPlugins(registry *plugins.Registry) {
 	// Core plugins (all platforms)
 	registry.Register(plugins.NewDockerPlugin())
 	registry.Register(plugins.NewPodmanPlugin())
+	registry.Register(plugins.NewContainerdPlugin())
 	registry.Register(plugins.NewNixPlugin())
 	registry.Register(plugins.NewBazelPlugin())
 	registry.Register(plugins.NewCachePlugin())
 	registry.Register(plugins.NewGitLabRunnerPlugin())
 
-	// Development artifact cleanup (all platforms)
+	// Development artifact, scratch directory, and junk file cleanup (all platforms)
 	registry.Register(plugins.NewDevArtifactsPlugin())
+	registry.Register(plugins.NewScratchPlugin())
+	registry.Register(plugins.NewJunkFilesPlugin())
+	registry.Register(plugins.NewGitMaintenancePlugin())
+
+	// Large sparse and dense file report (all platforms)
+	registry.Register(plugins.NewSparseFilesPlugin())
+
+	// Electron app caches (Darwin and Linux)
+	registry.Register(plugins.NewElectronAppsPlugin())
 
 	// Kubernetes plugins (disabled by default, for future use)
 	registry.Register(plugins.NewEtcdPlugin())
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"path/filepath"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
 	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/redact"
 	"github.com/Jesssullivan/tinyland-cleanup/plugins"
 )
 
@@ -51,8 +54,8 @@ func TestRunOnceDryRunJSONReport(t *testing.T) {
 	if !plugin.WouldRun {
 		t.Fatal("expected dry-run plugin to be marked would_run")
 	}
-	if plugin.SkipReason != "dry_run" {
-		t.Fatalf("expected dry_run skip reason, got %q", plugin.SkipReason)
+	if plugin.SkipReason != "dry_run" || !plugin.Skipped {
+		t.Fatalf("expected skipped with dry_run skip reason, got %q/%v", plugin.SkipReason, plugin.Skipped)
 	}
 }
 
@@ -204,17 +207,64 @@ func TestRunOnceDryRunHonorsPluginFilter(t *testing.T) {
 	}
 }
 
+func TestRunOnceCleanupJSONReportIncludesStructuredError(t *testing.T) {
+	var output bytes.Buffer
+	mock := &reportingPlugin{
+		result: plugins.CleanupResult{
+			Plugin: "reporting",
+			Level:  plugins.LevelCritical,
+			Error: &plugins.PluginError{
+				Plugin:        "reporting",
+				Operation:     "vm_restart",
+				VM:            "colima",
+				Err:           errors.New("exit status 1"),
+				CommandOutput: "fatal: instance not found",
+			},
+		},
+	}
+	daemon := newTestDaemon(t, mock, &output)
+	daemon.diskStats = sequenceDiskStats(t,
+		diskStats(1000, 20, 98),
+		diskStats(1000, 20, 98),
+		diskStats(1000, 20, 98),
+		diskStats(1000, 20, 98),
+	)
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	plugin := decodeCycleReport(t, output.Bytes()).Plugins[0]
+	if plugin.Error != "vm_restart vm=colima: exit status 1 (output: fatal: instance not found)" {
+		t.Fatalf("unexpected error string %q", plugin.Error)
+	}
+	detail := plugin.ErrorDetail
+	if detail == nil {
+		t.Fatal("expected error_detail")
+	}
+	if detail.Plugin != "reporting" || detail.Operation != "vm_restart" || detail.VM != "colima" ||
+		detail.Message != "exit status 1" || detail.CommandOutput != "fatal: instance not found" {
+		t.Fatalf("unexpected error detail %+v", detail)
+	}
+}
+
 func TestRunOnceCleanupJSONReport(t *testing.T) {
 	var output bytes.Buffer
 	mock := &reportingPlugin{
 		result: plugins.CleanupResult{
-			Plugin:              "reporting",
-			Level:               plugins.LevelCritical,
-			BytesFreed:          1234,
-			EstimatedBytesFreed: 1000,
-			CommandBytesFreed:   200,
-			HostBytesFreed:      34,
-			ItemsCleaned:        2,
+			Plugin:                "reporting",
+			Level:                 plugins.LevelCritical,
+			BytesFreed:            1234,
+			EstimatedBytesFreed:   1000,
+			CommandBytesFreed:     200,
+			HostBytesFreed:        34,
+			ItemsCleaned:          2,
+			ProtectedSkippedItems: 3,
+			ProtectedSkippedBytes: 4096,
+			WalkPermissionSkips:   37,
+			WalkErrorSkips:        1,
+			DeepGCBytesFreed:      150,
+			CompressedBytesSaved:  512,
 		},
 	}
 	daemon := newTestDaemon(t, mock, &output)
@@ -243,8 +293,17 @@ func TestRunOnceCleanupJSONReport(t *testing.T) {
 	if report.TotalItemsCleaned != 2 {
 		t.Fatalf("expected total items 2, got %d", report.TotalItemsCleaned)
 	}
+	if report.TotalCompressedBytesSaved != 512 {
+		t.Fatalf("expected compressed bytes 512 kept out of the total, got %d", report.TotalCompressedBytesSaved)
+	}
+	if report.UsedPercent != 98 || report.FreeGB != float64(20)/(1024*1024*1024) {
+		t.Fatalf("expected after-cycle usage 98%% and 20 bytes free, got %v%% and %v GB", report.UsedPercent, report.FreeGB)
+	}
 
 	plugin := report.Plugins[0]
+	if plugin.Skipped {
+		t.Fatal("expected the plugin that ran not to be marked skipped")
+	}
 	if plugin.BytesFreed != 1234 {
 		t.Fatalf("expected plugin bytes 1234, got %d", plugin.BytesFreed)
 	}
@@ -257,6 +316,18 @@ func TestRunOnceCleanupJSONReport(t *testing.T) {
 	if plugin.HostBytesFreed != 34 {
 		t.Fatalf("expected host bytes 34, got %d", plugin.HostBytesFreed)
 	}
+	if plugin.ProtectedSkippedItems != 3 || plugin.ProtectedSkippedBytes != 4096 {
+		t.Fatalf("expected 3 protected skips of 4096 bytes, got %d/%d", plugin.ProtectedSkippedItems, plugin.ProtectedSkippedBytes)
+	}
+	if plugin.WalkPermissionSkips != 37 || plugin.WalkErrorSkips != 1 {
+		t.Fatalf("expected 37 permission and 1 other walk skip, got %d/%d", plugin.WalkPermissionSkips, plugin.WalkErrorSkips)
+	}
+	if plugin.DeepGCBytesFreed != 150 {
+		t.Fatalf("expected deep GC bytes 150, got %d", plugin.DeepGCBytesFreed)
+	}
+	if plugin.CompressedBytesSaved != 512 {
+		t.Fatalf("expected compressed bytes 512, got %d", plugin.CompressedBytesSaved)
+	}
 }
 
 func TestRunOnceStopsAfterTargetFreeMet(t *testing.T) {
@@ -270,7 +341,9 @@ func TestRunOnceStopsAfterTargetFreeMet(t *testing.T) {
 		},
 	}
 	second := &reportingPlugin{name: "second"}
+	var logs bytes.Buffer
 	daemon := newTestDaemonWithPlugins(t, &output, first, second)
+	daemon.logger = slog.New(slog.NewTextHandler(&logs, nil))
 	daemon.config.TargetFree = 70
 	daemon.diskStats = sequenceDiskStats(t,
 		diskStats(1000, 20, 98),
@@ -315,6 +388,29 @@ func TestRunOnceStopsAfterTargetFreeMet(t *testing.T) {
 	if report.Plugins[1].SkipReason != "target_free_met" {
 		t.Fatalf("expected target_free_met skip reason, got %q", report.Plugins[1].SkipReason)
 	}
+	if !strings.Contains(logs.String(), `msg="target free reached, stopping early" plugins_run=1`) {
+		t.Fatalf("expected early stop log with plugin count, got %s", logs.String())
+	}
+}
+
+func TestExecutionOrderRunsCachesBeforeContainerPrunesAndSnapshots(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registerPlugins(registry)
+
+	position := map[string]int{}
+	snapshots := -1
+	for i, plugin := range executionOrder(registry.GetAll(), nil) {
+		position[plugin.Name()] = i
+		if snapshots < 0 && strings.HasSuffix(plugin.Name(), "-snapshots") {
+			snapshots = i
+		}
+	}
+	if position["cache"] > position["docker"] {
+		t.Fatalf("expected cache to run before docker, got positions %d and %d", position["cache"], position["docker"])
+	}
+	if snapshots < 0 || position["docker"] > snapshots {
+		t.Fatalf("expected docker to run before snapshot deletion, got positions %d and %d", position["docker"], snapshots)
+	}
 }
 
 func TestApplyTargetUsedPercentOverride(t *testing.T) {
@@ -342,6 +438,91 @@ func TestApplyTargetUsedPercentOverride(t *testing.T) {
 	}
 }
 
+func TestTargetFreeGBTakesPrecedence(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+	var output bytes.Buffer
+	first := &reportingPlugin{
+		name:   "first",
+		result: plugins.CleanupResult{Plugin: "first", Level: plugins.LevelCritical},
+	}
+	second := &reportingPlugin{name: "second"}
+	daemon := newTestDaemonWithPlugins(t, &output, first, second)
+	daemon.config.TargetFree = 30
+	daemon.config.TargetFreeGB = 100
+	daemon.diskStats = sequenceDiskStats(t,
+		diskStats(4000*gib, 50*gib, 98),
+		diskStats(4000*gib, 50*gib, 98),
+		diskStats(4000*gib, 150*gib, 96),
+		diskStats(4000*gib, 150*gib, 96),
+	)
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if second.called {
+		t.Fatal("second plugin should stop once 100 GiB is free")
+	}
+
+	report := decodeCycleReport(t, output.Bytes())
+	if !report.TargetFreeMet || report.StopReason != "target_free_met" {
+		t.Fatalf("expected target_free_met, got met=%v stop=%q", report.TargetFreeMet, report.StopReason)
+	}
+	if report.TargetFreeGB != 100 || report.TargetUsedPercent != 0 {
+		t.Fatalf("expected the GiB target to replace the percent, got %g GiB / %d%%", report.TargetFreeGB, report.TargetUsedPercent)
+	}
+	if report.TargetFreeBytes != 100*gib {
+		t.Fatalf("expected target free bytes %d, got %d", uint64(100*gib), report.TargetFreeBytes)
+	}
+	if report.TargetMaxUsedPercent != 97.5 {
+		t.Fatalf("expected 97.5%% used equivalent, got %g", report.TargetMaxUsedPercent)
+	}
+}
+
+func TestApplyTargetOverrides(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TargetFreeGB = 100
+	if err := applyTargetOverrides(cfg, 82, 0); err != nil {
+		t.Fatalf("percent override failed: %v", err)
+	}
+	if cfg.TargetFree != 82 || cfg.TargetFreeGB != 0 {
+		t.Fatalf("percent override should clear target_free_gb, got %d%% / %g GiB", cfg.TargetFree, cfg.TargetFreeGB)
+	}
+	if err := applyTargetOverrides(cfg, 0, 50); err != nil {
+		t.Fatalf("GiB override failed: %v", err)
+	}
+	if cfg.TargetFreeGB != 50 {
+		t.Fatalf("expected 50 GiB target, got %g", cfg.TargetFreeGB)
+	}
+	if err := applyTargetOverrides(cfg, 82, 50); err == nil {
+		t.Fatal("expected error when both overrides are set")
+	}
+	if err := applyTargetOverrides(cfg, 0, -1); err == nil {
+		t.Fatal("expected error for a negative GiB override")
+	}
+}
+
+func TestValidateTargetFree(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+	stat := func(string) (*monitor.DiskStats, error) { return diskStats(500*gib, 100*gib, 80), nil }
+	cfg := config.DefaultConfig()
+
+	if err := validateTargetFree(cfg, "/", stat); err != nil {
+		t.Fatalf("percent target should validate: %v", err)
+	}
+	cfg.TargetFreeGB = 100
+	if err := validateTargetFree(cfg, "/", stat); err != nil {
+		t.Fatalf("100 GiB on a 500 GiB disk should validate: %v", err)
+	}
+	cfg.TargetFreeGB = 500
+	if err := validateTargetFree(cfg, "/", stat); err == nil {
+		t.Fatal("expected error for a target as large as the disk")
+	}
+	cfg.TargetFreeGB = -1
+	if err := validateTargetFree(cfg, "/", stat); err == nil {
+		t.Fatal("expected error for a negative target")
+	}
+}
+
 func TestParsePluginFilter(t *testing.T) {
 	filter, err := parsePluginFilter(" bazel, nix,bazel ")
 	if err != nil {
@@ -376,6 +557,41 @@ func TestValidatePluginFilterRejectsUnknownPlugin(t *testing.T) {
 	}
 }
 
+func TestValidateSkipFilter(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&reportingPlugin{name: "docker"})
+	registry.Register(&reportingPlugin{name: "lima"})
+
+	if err := validateSkipFilter([]string{"docker"}, []string{"lima"}, registry); err != nil {
+		t.Fatalf("skipping a known plugin should validate: %v", err)
+	}
+	if err := validateSkipFilter([]string{"missing"}, nil, registry); err == nil {
+		t.Fatal("expected unknown plugin error")
+	}
+	if err := validateSkipFilter([]string{"docker"}, []string{"docker"}, registry); err == nil {
+		t.Fatal("expected a plugin in both -skip and -only to be rejected")
+	}
+}
+
+func TestRunOnceLeavesOutSkippedPlugins(t *testing.T) {
+	var output bytes.Buffer
+	skipped := &reportingPlugin{name: "docker"}
+	kept := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemonWithPlugins(t, &output, skipped, kept)
+	daemon.registry.Skip("docker")
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 100, 90))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if skipped.called || !kept.called {
+		t.Fatalf("expected only cache to run, got docker=%v cache=%v", skipped.called, kept.called)
+	}
+	if report := decodeCycleReport(t, output.Bytes()); len(report.Plugins) != 1 || report.Plugins[0].Name != "cache" {
+		t.Fatalf("expected the report to cover only cache, got %+v", report.Plugins)
+	}
+}
+
 func TestListPluginEntriesReportsEnabledAndPlatformSupport(t *testing.T) {
 	cfg := config.DefaultConfig()
 	registry := plugins.NewRegistry()
@@ -399,15 +615,379 @@ func TestListPluginEntriesReportsEnabledAndPlatformSupport(t *testing.T) {
 	}
 }
 
+func TestRunOnceRunsPluginsInPriorityOrder(t *testing.T) {
+	var output bytes.Buffer
+	daemon := newTestDaemonWithPlugins(t, &output,
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "snapshots"}, priority: 90},
+		&reportingPlugin{name: "unprioritized"},
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "prune"}, priority: 20},
+	)
+	daemon.dryRun = true
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	report := decodeCycleReport(t, output.Bytes())
+	var order []string
+	for _, plugin := range report.Plugins {
+		order = append(order, fmt.Sprintf("%s:%d", plugin.Name, plugin.Priority))
+	}
+	if got := strings.Join(order, ","); got != "prune:20,unprioritized:50,snapshots:90" {
+		t.Fatalf("unexpected plugin order: %s", got)
+	}
+}
+
+func TestListPluginEntriesUsesPriorityOrder(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "late"}, priority: 80})
+	registry.Register(&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "early"}, priority: 10})
+
+	entries := listPluginEntries(registry, config.DefaultConfig())
+	if len(entries) != 2 || entries[0].Name != "early" || entries[0].Priority != 10 || entries[1].Name != "late" {
+		t.Fatalf("unexpected plugin list order: %#v", entries)
+	}
+}
+
+func TestWritePluginListJSONIsStableAcrossRuns(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&reportingPlugin{name: "zeta"})
+	registry.Register(&reportingPlugin{name: "alpha"})
+	registry.Register(&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "early"}, priority: 10})
+	cfg := config.DefaultConfig()
+
+	var first bytes.Buffer
+	if err := writePluginList(&first, "json", pluginListOrder(cfg), listPluginEntries(registry, cfg)); err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 10; run++ {
+		var next bytes.Buffer
+		if err := writePluginList(&next, "json", pluginListOrder(cfg), listPluginEntries(registry, cfg)); err != nil {
+			t.Fatal(err)
+		}
+		if next.String() != first.String() {
+			t.Fatalf("run %d: plugin list changed:\n%s\nvs\n%s", run, next.String(), first.String())
+		}
+	}
+	entries := listPluginEntries(registry, cfg)
+	if entries[0].Name != "early" || entries[1].Name != "zeta" || entries[2].Name != "alpha" {
+		t.Fatalf("expected priority then registration order, got %#v", entries)
+	}
+}
+
+func TestRunOnceMaxRuntimeCancelsAndSkipsRemainingPlugins(t *testing.T) {
+	var output bytes.Buffer
+	first := &slowPlugin{reportingPlugin: reportingPlugin{name: "first"}, delay: 40 * time.Millisecond}
+	second := &slowPlugin{reportingPlugin: reportingPlugin{name: "second"}, delay: 5 * time.Second}
+	third := &slowPlugin{reportingPlugin: reportingPlugin{name: "third"}, delay: 5 * time.Second}
+	daemon := newTestDaemonWithPlugins(t, &output, first, second, third)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.diskStats = func(string) (*monitor.DiskStats, error) { return diskStats(1000, 20, 98), nil }
+	daemon.maxRuntime = 100 * time.Millisecond
+
+	start := time.Now()
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("cycle ran %s, expected it to end near the 100ms deadline", elapsed)
+	}
+	if third.called {
+		t.Fatal("plugin after the deadline should not run")
+	}
+
+	report := decodeCycleReport(t, output.Bytes())
+	if report.StopReason != "max_runtime_exceeded" {
+		t.Fatalf("expected max_runtime_exceeded stop reason, got %q", report.StopReason)
+	}
+	if len(report.Plugins) != 3 {
+		t.Fatalf("expected 3 plugin reports, got %d", len(report.Plugins))
+	}
+	if got := report.Plugins[0]; got.Cancelled || got.Error != "" {
+		t.Fatalf("first plugin should finish inside the deadline: %+v", got)
+	}
+	if got := report.Plugins[1]; !got.Cancelled || got.Error == "" {
+		t.Fatalf("second plugin should be cancelled in flight: %+v", got)
+	}
+	if got := report.Plugins[2]; got.WouldRun || got.SkipReason != "max_runtime_exceeded" {
+		t.Fatalf("third plugin should be skipped: %+v", got)
+	}
+}
+
+func TestCycleMaxRuntimePrefersFlag(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Pool.MaxCycleMinutes = 10
+
+	if got, err := cycleMaxRuntime(cfg, 0); err != nil || got != 10*time.Minute {
+		t.Fatalf("config deadline = %s, %v; want 10m", got, err)
+	}
+	if got, err := cycleMaxRuntime(cfg, 5*time.Minute); err != nil || got != 5*time.Minute {
+		t.Fatalf("flag deadline = %s, %v; want 5m", got, err)
+	}
+	if _, err := cycleMaxRuntime(cfg, -time.Second); err == nil {
+		t.Fatal("expected negative -max-runtime to be rejected")
+	}
+}
+
+func TestRunOnceClampsLevelToSafetyMaxLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		forced      monitor.CleanupLevel
+		override    bool
+		wantLevel   string
+		wantClamped string
+	}{
+		{name: "disk critical", forced: monitor.LevelNone, wantLevel: "moderate", wantClamped: "critical"},
+		{name: "forced critical", forced: monitor.LevelCritical, wantLevel: "moderate", wantClamped: "critical"},
+		{name: "forced critical with override", forced: monitor.LevelCritical, override: true, wantLevel: "critical"},
+		{name: "override ignored without forced level", forced: monitor.LevelNone, override: true, wantLevel: "moderate", wantClamped: "critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			mock := &reportingPlugin{}
+			daemon := newTestDaemon(t, mock, &output)
+			daemon.config.Safety.MaxLevel = "moderate"
+			daemon.overrideMax = tt.override
+			daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+			if err := daemon.runOnce(context.Background(), tt.forced); err != nil {
+				t.Fatalf("runOnce failed: %v", err)
+			}
+
+			report := decodeCycleReport(t, output.Bytes())
+			if report.Level != tt.wantLevel || report.LevelClampedFrom != tt.wantClamped {
+				t.Fatalf("level = %q clamped from %q, want %q from %q", report.Level, report.LevelClampedFrom, tt.wantLevel, tt.wantClamped)
+			}
+			if report.MaxLevel != "moderate" {
+				t.Fatalf("max_level = %q, want moderate", report.MaxLevel)
+			}
+			if len(report.Plugins) != 1 || report.Plugins[0].Level != tt.wantLevel {
+				t.Fatalf("plugin ran at %+v, want %s", report.Plugins, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestRunOnceDefersHeavyPluginsWhileDisplayAsleep(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		asleep    bool
+		forced    monitor.CleanupLevel
+		wantHeavy bool
+	}{
+		{name: "display asleep", enabled: true, asleep: true, forced: monitor.LevelNone},
+		{name: "display awake", enabled: true, forced: monitor.LevelNone, wantHeavy: true},
+		{name: "guard disabled", asleep: true, forced: monitor.LevelNone, wantHeavy: true},
+		{name: "forced level", enabled: true, asleep: true, forced: monitor.LevelCritical, wantHeavy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			light := &reportingPlugin{name: "light"}
+			heavy := &heavyPlugin{reportingPlugin{name: "heavy"}}
+			daemon := newTestDaemonWithPlugins(t, &output, light, heavy)
+			daemon.config.Safety.SkipWhenDisplayAsleep = tt.enabled
+			daemon.displayAsleep = func() bool { return tt.asleep }
+			daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+			if err := daemon.runOnce(context.Background(), tt.forced); err != nil {
+				t.Fatalf("runOnce failed: %v", err)
+			}
+
+			report := decodeCycleReport(t, output.Bytes())
+			if !light.called {
+				t.Fatal("expected light plugin to run")
+			}
+			if heavy.called != tt.wantHeavy {
+				t.Fatalf("heavy plugin called = %v, want %v", heavy.called, tt.wantHeavy)
+			}
+			if report.HeavyDeferred == tt.wantHeavy {
+				t.Fatalf("heavy_deferred = %v, want %v", report.HeavyDeferred, !tt.wantHeavy)
+			}
+			if !tt.wantHeavy {
+				if len(report.Plugins) != 2 || report.Plugins[1].SkipReason != "display_asleep" {
+					t.Fatalf("expected heavy plugin skipped for display_asleep, got %+v", report.Plugins)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOnceRedactsReportPaths(t *testing.T) {
+	var output bytes.Buffer
+	mock := &reportingPlugin{result: plugins.CleanupResult{
+		BytesFreed: 4096,
+		Error:      errors.New("remove /Users/alice/git/secret-app/node_modules: permission denied"),
+	}}
+	daemon := newTestDaemon(t, mock, &output)
+	daemon.redactor = redact.New(redact.Options{Home: "/Users/alice", Usernames: []string{"alice"}, ProjectNames: true})
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	for _, leaked := range []string{"alice", "secret-app"} {
+		if strings.Contains(output.String(), leaked) {
+			t.Fatalf("%q leaked in report %s", leaked, output.String())
+		}
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	if len(report.Plugins) != 1 || report.Plugins[0].Name != "reporting" || report.Plugins[0].BytesFreed != 4096 {
+		t.Fatalf("expected plugin names and sizes kept, got %+v", report.Plugins)
+	}
+	if !strings.Contains(report.Plugins[0].Error, "~/git/h:") {
+		t.Fatalf("expected redacted path in error, got %q", report.Plugins[0].Error)
+	}
+}
+
+func TestRunOnceReportsGrowthWhenEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var output bytes.Buffer
+		mock := &reportingPlugin{result: plugins.CleanupResult{BytesGrown: 2048}}
+		daemon := newTestDaemon(t, mock, &output)
+		daemon.config.Log.ReportGrowth = enabled
+		daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+		if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+			t.Fatalf("runOnce failed: %v", err)
+		}
+
+		report := decodeCycleReport(t, output.Bytes())
+		if len(report.Plugins) != 1 {
+			t.Fatalf("expected one plugin report, got %+v", report.Plugins)
+		}
+		plugin := report.Plugins[0]
+		if plugin.BytesFreed != 0 {
+			t.Fatalf("BytesFreed = %d, want 0 for a grown target", plugin.BytesFreed)
+		}
+		want := int64(0)
+		if enabled {
+			want = 2048
+		}
+		if plugin.BytesGrown != want {
+			t.Fatalf("report_growth=%v: BytesGrown = %d, want %d", enabled, plugin.BytesGrown, want)
+		}
+	}
+}
+
+func TestIdleTickSkipsHealthyDisks(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Thresholds.Warning = 80
+
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 600, 40))
+	if daemon.idleTick() {
+		t.Fatal("expected idle skip to be off without monitor.idle_margin_percent")
+	}
+
+	daemon.config.Monitor.IdleMarginPercent = 10
+	for _, tt := range []struct {
+		used float64
+		want bool
+	}{
+		{40, true},
+		{69.9, true},
+		{70, false},
+		{85, false},
+	} {
+		daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 500, tt.used))
+		if got := daemon.idleTick(); got != tt.want {
+			t.Errorf("idleTick at %.1f%% used = %v, want %v", tt.used, got, tt.want)
+		}
+	}
+
+	daemon.diskStats = func(string) (*monitor.DiskStats, error) { return nil, errors.New("statfs failed") }
+	if daemon.idleTick() {
+		t.Fatal("expected a failed disk check to run the full cycle")
+	}
+}
+
+func TestIdleTickChecksEveryMonitoredMount(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Thresholds.Warning = 80
+	daemon.config.Monitor.IdleMarginPercent = 10
+	daemon.config.MonitoredMounts = []config.MountConfig{
+		{Path: "/", Label: "root"},
+		{Path: "/Volumes/Data", Label: "data", ThresholdWarning: 60},
+	}
+	used := map[string]float64{"/": 40, "/Volumes/Data": 55}
+	daemon.diskStats = func(path string) (*monitor.DiskStats, error) {
+		stats := diskStats(1000, 500, used[path])
+		stats.Path = path
+		return stats, nil
+	}
+
+	if daemon.idleTick() {
+		t.Fatal("expected a mount within the margin of its own warning threshold to run the cycle")
+	}
+	used["/Volumes/Data"] = 45
+	if !daemon.idleTick() {
+		t.Fatal("expected every mount below its warning minus margin to skip the tick")
+	}
+}
+
+func TestRunOnceCleansWhenAnyMonitoredMountTrips(t *testing.T) {
+	plugin := &reportingPlugin{name: "cache"}
+	var logs bytes.Buffer
+	daemon := newTestDaemon(t, plugin, io.Discard)
+	daemon.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.config.MonitoredMounts = []config.MountConfig{
+		{Path: "/", Label: "root"},
+		{Path: "/Volumes/Data", Label: "data", ThresholdWarning: 60},
+	}
+	used := map[string]float64{"/": 40, "/Volumes/Data": 75}
+	daemon.diskStats = func(path string) (*monitor.DiskStats, error) {
+		stats := diskStats(1000, uint64(1000-10*used[path]), used[path])
+		stats.Path = path
+		return stats, nil
+	}
+
+	assessment := daemon.assessMounts()
+	if assessment.Level != monitor.LevelWarning {
+		t.Fatalf("level = %s, want warning from the data mount's own threshold", assessment.Level)
+	}
+	for _, want := range []string{"mount=root", "mount=data"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("disk status log missing %q:\n%s", want, logs.String())
+		}
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if !plugin.called {
+		t.Fatal("expected cleanup to run when only the data mount is over threshold")
+	}
+}
+
+func TestSafetyMaxLevel(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if got, err := safetyMaxLevel(cfg); err != nil || got != monitor.LevelCritical {
+		t.Fatalf("default ceiling = %s, %v; want critical", got, err)
+	}
+	cfg.Safety.MaxLevel = "aggressive"
+	if got, err := safetyMaxLevel(cfg); err != nil || got != monitor.LevelAggressive {
+		t.Fatalf("ceiling = %s, %v; want aggressive", got, err)
+	}
+	cfg.Safety.MaxLevel = "extreme"
+	if _, err := safetyMaxLevel(cfg); err == nil {
+		t.Fatal("expected invalid safety.max_level to be rejected")
+	}
+}
+
 func TestWritePluginListText(t *testing.T) {
 	var output bytes.Buffer
-	err := writePluginList(&output, "text", []pluginListEntry{
+	err := writePluginList(&output, "text", "priority", []pluginListEntry{
 		{
 			Name:               "bazel",
 			Description:        "Bazel cleanup",
 			Enabled:            true,
 			Supported:          true,
 			SupportedPlatforms: nil,
+			Priority:           50,
 		},
 		{
 			Name:               "homebrew",
@@ -415,6 +995,7 @@ func TestWritePluginListText(t *testing.T) {
 			Enabled:            false,
 			Supported:          false,
 			SupportedPlatforms: []string{"darwin"},
+			Priority:           15,
 		},
 	})
 	if err != nil {
@@ -424,8 +1005,8 @@ func TestWritePluginListText(t *testing.T) {
 	text := output.String()
 	for _, want := range []string{
 		"tinyland-cleanup plugins",
-		"- bazel: enabled, supported - Bazel cleanup",
-		"- homebrew: disabled, unsupported on darwin - Homebrew cleanup",
+		"- bazel: enabled, supported - Bazel cleanup (priority 50)",
+		"- homebrew: disabled, unsupported on darwin - Homebrew cleanup (priority 15)",
 	} {
 		if !strings.Contains(text, want) {
 			t.Fatalf("plugin list text missing %q:\n%s", want, text)
@@ -435,7 +1016,7 @@ func TestWritePluginListText(t *testing.T) {
 
 func TestWritePluginListJSON(t *testing.T) {
 	var output bytes.Buffer
-	err := writePluginList(&output, "json", []pluginListEntry{
+	err := writePluginList(&output, "json", "priority", []pluginListEntry{
 		{Name: "nix", Description: "Nix cleanup", Enabled: true, Supported: true},
 	})
 	if err != nil {
@@ -451,6 +1032,183 @@ func TestWritePluginListJSON(t *testing.T) {
 	}
 }
 
+func TestRunOnceFollowsPluginOrder(t *testing.T) {
+	var output bytes.Buffer
+	daemon := newTestDaemonWithPlugins(t, &output,
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "cache"}, priority: 10},
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "lima"}, priority: 80},
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "docker"}, priority: 20},
+		&prioritizedPlugin{reportingPlugin: reportingPlugin{name: "nix"}, priority: 40},
+	)
+	daemon.config.PluginOrder = []string{"docker", "lima"}
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 20, 98))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	report := decodeCycleReport(t, output.Bytes())
+	var ran []string
+	for _, plugin := range report.Plugins {
+		ran = append(ran, plugin.Name)
+	}
+	want := "docker,lima,cache,nix"
+	if got := strings.Join(ran, ","); got != want {
+		t.Fatalf("plugins ran in %q, want %q", got, want)
+	}
+	if got := strings.Join(report.PluginOrder, ","); got != want {
+		t.Fatalf("plugin_order = %q, want %q", got, want)
+	}
+}
+
+func TestValidatePluginOrder(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registry.Register(&reportingPlugin{name: "docker"})
+	registry.Register(&reportingPlugin{name: "lima"})
+
+	if err := validatePluginOrder([]string{"lima", "docker"}, registry); err != nil {
+		t.Fatalf("valid order rejected: %v", err)
+	}
+	if err := validatePluginOrder([]string{"docker", "podman"}, registry); err == nil || !strings.Contains(err.Error(), "podman") {
+		t.Fatalf("expected unknown plugin error, got %v", err)
+	}
+	if err := validatePluginOrder([]string{"docker", "docker"}, registry); err == nil {
+		t.Fatal("expected repeated plugin to be rejected")
+	}
+}
+
+func TestListLevelEntriesDescribesEnabledPlugins(t *testing.T) {
+	cfg := config.DefaultConfig()
+	registry := plugins.NewRegistry()
+	registry.Register(&describingPlugin{reportingPlugin: reportingPlugin{name: "described"}})
+	registry.Register(&reportingPlugin{name: "plain"})
+	registry.Register(&reportingPlugin{name: "disabled", disabled: true})
+
+	entries := listLevelEntries(registry, cfg, nil)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 level entries, got %d: %#v", len(entries), entries)
+	}
+	if entries[0].Name != "described" || len(entries[0].Levels) != 4 {
+		t.Fatalf("unexpected described entry: %#v", entries[0])
+	}
+	if entries[0].Levels[0].Level != "warning" || entries[0].Levels[0].Description != "warning cleanup" {
+		t.Fatalf("unexpected warning level: %#v", entries[0].Levels[0])
+	}
+	if entries[0].Levels[3].Level != "critical" || entries[0].Levels[3].Description != "critical cleanup" {
+		t.Fatalf("unexpected critical level: %#v", entries[0].Levels[3])
+	}
+	if entries[1].Name != "plain" || entries[1].Levels[0].Description != noLevelDescription {
+		t.Fatalf("unexpected plain entry: %#v", entries[1])
+	}
+
+	filtered := listLevelEntries(registry, cfg, []string{"plain"})
+	if len(filtered) != 1 || filtered[0].Name != "plain" {
+		t.Fatalf("expected plugin filter to select plain only, got %#v", filtered)
+	}
+}
+
+func TestWriteLevelListText(t *testing.T) {
+	var output bytes.Buffer
+	err := writeLevelList(&output, "text", []levelListEntry{
+		{
+			Name:        "docker",
+			Description: "Docker cleanup",
+			Levels: []levelDescriptionEntry{
+				{Level: "warning", Description: "prunes dangling images"},
+				{Level: "critical", Description: "runs a full system prune"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("writeLevelList failed: %v", err)
+	}
+
+	text := output.String()
+	for _, want := range []string{
+		"tinyland-cleanup levels",
+		"- docker: Docker cleanup",
+		"  warning: prunes dangling images",
+		"  critical: runs a full system prune",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("level list text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWriteLevelListJSON(t *testing.T) {
+	var output bytes.Buffer
+	err := writeLevelList(&output, "json", []levelListEntry{
+		{Name: "nix", Description: "Nix cleanup", Levels: []levelDescriptionEntry{{Level: "moderate", Description: "runs nix-collect-garbage"}}},
+	})
+	if err != nil {
+		t.Fatalf("writeLevelList failed: %v", err)
+	}
+
+	var report levelListReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode level list JSON: %v\n%s", err, output.String())
+	}
+	if len(report.Plugins) != 1 || report.Plugins[0].Levels[0].Level != "moderate" {
+		t.Fatalf("unexpected level list report: %#v", report)
+	}
+}
+
+func TestExplainPluginCommandsReportsCommands(t *testing.T) {
+	cfg := config.DefaultConfig()
+	registry := plugins.NewRegistry()
+	registry.Register(&explainingPlugin{reportingPlugin: reportingPlugin{name: "explained", disabled: true}})
+	registry.Register(&reportingPlugin{name: "plain"})
+
+	report, err := explainPluginCommands(registry, cfg, "explained", "critical")
+	if err != nil {
+		t.Fatalf("explainPluginCommands failed: %v", err)
+	}
+	if report.Level != "critical" || report.Enabled || len(report.Commands) != 1 {
+		t.Fatalf("unexpected explanation: %#v", report)
+	}
+	if got := strings.Join(report.Commands[0], " "); got != "tool prune --level critical" {
+		t.Fatalf("unexpected command: %q", got)
+	}
+
+	if _, err := explainPluginCommands(registry, cfg, "plain", "critical"); err == nil {
+		t.Fatal("expected error for plugin without command explanation")
+	}
+	if _, err := explainPluginCommands(registry, cfg, "missing", "critical"); err == nil {
+		t.Fatal("expected unknown plugin error")
+	}
+	if _, err := explainPluginCommands(registry, cfg, "explained", ""); err == nil {
+		t.Fatal("expected error when no level is given")
+	}
+}
+
+func TestWriteCommandExplanationText(t *testing.T) {
+	var output bytes.Buffer
+	err := writeCommandExplanation(&output, "text", commandExplanationReport{
+		Plugin:  "docker",
+		Level:   "moderate",
+		Enabled: true,
+		Commands: [][]string{
+			{"docker", "image", "prune", "-af", "--filter", "until=24h"},
+			{"sh", "-c", "echo it's"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("writeCommandExplanation failed: %v", err)
+	}
+
+	text := output.String()
+	for _, want := range []string{
+		"tinyland-cleanup docker commands at moderate",
+		"  docker image prune -af --filter until=24h",
+		`  sh -c 'echo it'\''s'`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("command explanation missing %q:\n%s", want, text)
+		}
+	}
+}
+
 func TestRunOnceSkipsPluginDuringCooldown(t *testing.T) {
 	var output bytes.Buffer
 	mock := &reportingPlugin{}
@@ -496,6 +1254,57 @@ func TestRunOnceSkipsPluginDuringCooldown(t *testing.T) {
 	}
 }
 
+func TestRunCycleHonorsWatchMinInterval(t *testing.T) {
+	mock := &reportingPlugin{}
+	daemon := newTestDaemon(t, mock, io.Discard)
+	now := time.Date(2026, 4, 26, 12, 0, 0, 0, time.UTC)
+	daemon.now = func() time.Time { return now }
+	daemon.minInterval = 15 * time.Minute
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 50, 95))
+
+	if report := daemon.runCycle(context.Background(), monitor.LevelNone, false); !mock.called || report.StopReason != "" {
+		t.Fatalf("expected the first cycle to clean, got %+v", report)
+	}
+
+	mock.called = false
+	now = now.Add(10 * time.Minute)
+	report := daemon.runCycle(context.Background(), monitor.LevelNone, false)
+	if mock.called {
+		t.Fatal("plugin should be held back inside the minimum interval")
+	}
+	if report.StopReason != "min_interval" || report.MinIntervalRemainingSeconds != 300 {
+		t.Fatalf("expected a min_interval stop with 300s remaining, got %q and %d", report.StopReason, report.MinIntervalRemainingSeconds)
+	}
+	if len(report.Plugins) != 1 || report.Plugins[0].SkipReason != "min_interval" || len(report.Mounts) == 0 {
+		t.Fatalf("expected the held-back cycle to still report mounts and plugins, got %+v", report)
+	}
+
+	if report := daemon.runCycle(context.Background(), monitor.LevelNone, true); report.StopReason == "min_interval" {
+		t.Fatal("dry runs should not be held back")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if report := daemon.runCycle(context.Background(), monitor.LevelNone, false); !mock.called || report.StopReason != "" {
+		t.Fatalf("expected cleanup once the interval passed, got %+v", report)
+	}
+}
+
+func TestValidateWatchMinInterval(t *testing.T) {
+	if err := validateWatchMinInterval(15*time.Minute, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateWatchMinInterval(0, false); err != nil {
+		t.Fatalf("unset interval should always be valid: %v", err)
+	}
+	if err := validateWatchMinInterval(-time.Minute, true); err == nil {
+		t.Fatal("expected a negative interval to be rejected")
+	}
+	if err := validateWatchMinInterval(15*time.Minute, false); err == nil {
+		t.Fatal("expected an interval outside daemon mode to be rejected")
+	}
+}
+
 func TestRunOnceCriticalBypassesCooldown(t *testing.T) {
 	var output bytes.Buffer
 	mock := &reportingPlugin{}
@@ -532,6 +1341,66 @@ func TestRunOnceCriticalBypassesCooldown(t *testing.T) {
 	}
 }
 
+func TestRunOnceRunsProactiveCleanupBelowWarningLevel(t *testing.T) {
+	var output bytes.Buffer
+	mock := &proactivePlugin{
+		proactive: plugins.ProactiveResult{
+			Checked:          true,
+			Triggered:        true,
+			ReclaimableBytes: 12 << 30,
+			ThresholdBytes:   10 << 30,
+			BytesFreed:       11 << 30,
+			ItemsCleaned:     4,
+		},
+	}
+	daemon := newTestDaemon(t, mock, &output)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 500, 50))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	if mock.called {
+		t.Fatal("level-driven cleanup should not run below the warning threshold")
+	}
+	if !mock.proactiveCalled || mock.proactiveDryRun {
+		t.Fatalf("expected a non-dry-run proactive check, called=%v dryRun=%v", mock.proactiveCalled, mock.proactiveDryRun)
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	if report.Level != "none" || len(report.Plugins) != 0 {
+		t.Fatalf("expected monitor-only cycle, got level %q plugins %+v", report.Level, report.Plugins)
+	}
+	if len(report.Proactive) != 1 {
+		t.Fatalf("expected one proactive report, got %+v", report.Proactive)
+	}
+	proactive := report.Proactive[0]
+	if proactive.Plugin != "reporting" || !proactive.Triggered || proactive.BytesFreed != 11<<30 || proactive.ItemsCleaned != 4 {
+		t.Fatalf("unexpected proactive report %+v", proactive)
+	}
+	if report.ProactiveBytesFreed != 11<<30 || report.TotalBytesFreed != 0 {
+		t.Fatalf("expected proactive bytes reported separately, got proactive=%d total=%d", report.ProactiveBytesFreed, report.TotalBytesFreed)
+	}
+}
+
+func TestRunOnceOmitsUncheckedProactiveCleanup(t *testing.T) {
+	var output bytes.Buffer
+	mock := &proactivePlugin{}
+	daemon := newTestDaemon(t, mock, &output)
+	daemon.dryRun = true
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 500, 50))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	if !mock.proactiveDryRun {
+		t.Fatal("expected dry-run to reach the proactive check")
+	}
+	if report := decodeCycleReport(t, output.Bytes()); len(report.Proactive) != 0 {
+		t.Fatalf("expected disabled proactive check to be omitted, got %+v", report.Proactive)
+	}
+}
+
 func newTestDaemon(t *testing.T, plugin plugins.Plugin, output io.Writer) *daemon {
 	t.Helper()
 
@@ -542,6 +1411,8 @@ func newTestDaemonWithPlugins(t *testing.T, output io.Writer, registeredPlugins
 	t.Helper()
 
 	cfg := config.DefaultConfig()
+	// Keep ineffective critical cycles from sizing the real home directory.
+	cfg.Notify.DiskHogCount = 0
 	registry := plugins.NewRegistry()
 	for _, plugin := range registeredPlugins {
 		registry.Register(plugin)
@@ -630,6 +1501,67 @@ func (p *reportingPlugin) Cleanup(context.Context, plugins.CleanupLevel, *config
 	return p.result
 }
 
+type heavyPlugin struct {
+	reportingPlugin
+}
+
+func (p *heavyPlugin) HeavyAt(plugins.CleanupLevel, *config.Config) bool {
+	return true
+}
+
+type proactivePlugin struct {
+	reportingPlugin
+	proactive       plugins.ProactiveResult
+	proactiveCalled bool
+	proactiveDryRun bool
+}
+
+func (p *proactivePlugin) ProactiveCleanup(_ context.Context, _ *config.Config, dryRun bool, _ *slog.Logger) plugins.ProactiveResult {
+	p.proactiveCalled = true
+	p.proactiveDryRun = dryRun
+	return p.proactive
+}
+
+type slowPlugin struct {
+	reportingPlugin
+	delay time.Duration
+}
+
+func (p *slowPlugin) Cleanup(ctx context.Context, _ plugins.CleanupLevel, _ *config.Config, _ *slog.Logger) plugins.CleanupResult {
+	p.called = true
+	select {
+	case <-time.After(p.delay):
+		return p.result
+	case <-ctx.Done():
+		return plugins.CleanupResult{Error: ctx.Err()}
+	}
+}
+
+type explainingPlugin struct {
+	reportingPlugin
+}
+
+func (p *explainingPlugin) CleanupCommands(level plugins.CleanupLevel, _ *config.Config) [][]string {
+	return [][]string{{"tool", "prune", "--level", level.String()}}
+}
+
+type prioritizedPlugin struct {
+	reportingPlugin
+	priority int
+}
+
+func (p *prioritizedPlugin) Priority() int {
+	return p.priority
+}
+
+type describingPlugin struct {
+	reportingPlugin
+}
+
+func (p *describingPlugin) LevelDescription(level plugins.CleanupLevel) string {
+	return level.String() + " cleanup"
+}
+
 type planningPlugin struct {
 	reportingPlugin
 	plan plugins.CleanupPlan
@@ -638,3 +1570,15 @@ type planningPlugin struct {
 func (p *planningPlugin) PlanCleanup(context.Context, plugins.CleanupLevel, *config.Config, *slog.Logger) plugins.CleanupPlan {
 	return p.plan
 }
+
+func TestValidateCooldowns(t *testing.T) {
+	if err := validateCooldowns(map[string]float64{"podman.disk_compact": 24, "cache.go_modcache": 0}); err != nil {
+		t.Fatalf("expected known keys to validate: %v", err)
+	}
+	if err := validateCooldowns(map[string]float64{"podman.compact": 24}); err == nil {
+		t.Fatal("expected error for an unknown operation")
+	}
+	if err := validateCooldowns(map[string]float64{"lima.disk_compact": -1}); err == nil {
+		t.Fatal("expected error for negative hours")
+	}
+}
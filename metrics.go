@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/observability"
+)
+
+// newMetricsExporter returns the observability.metrics_enabled exporter, or
+// nil when metrics are disabled.
+func newMetricsExporter(cfg config.ObservabilityConfig) (*observability.Exporter, error) {
+	if !cfg.MetricsEnabled {
+		return nil, nil
+	}
+	exporter, err := observability.NewExporter(cfg.OTLPEndpoint, expandPathHome(cfg.FallbackPath))
+	if err != nil {
+		return nil, fmt.Errorf("observability: %w", err)
+	}
+	return exporter, nil
+}
+
+// recordMetrics exports a real cycle's metrics. Failures are logged and never
+// fail the cycle.
+func (d *daemon) recordMetrics(ctx context.Context, report cycleReport) {
+	if d.metrics == nil || report.DryRun {
+		return
+	}
+	if err := d.metrics.Record(ctx, cycleMetrics(report, d.currentTime())); err != nil {
+		d.logger.Warn("failed to export cleanup metrics", "error", err)
+	}
+}
+
+// cycleMetrics converts report into the exporter's form. Plugins that were
+// skipped did not run, so they contribute nothing.
+func cycleMetrics(report cycleReport, now time.Time) observability.Cycle {
+	cycle := observability.Cycle{Time: now, DiskUsedPercent: report.UsedPercent}
+	for _, plugin := range report.Plugins {
+		if plugin.SkipReason != "" {
+			continue
+		}
+		cycle.Plugins = append(cycle.Plugins, observability.Plugin{
+			Name:            plugin.Name,
+			BytesFreed:      plugin.BytesFreed,
+			ItemsCleaned:    plugin.ItemsCleaned,
+			DurationSeconds: plugin.DurationSeconds,
+		})
+	}
+	return cycle
+}
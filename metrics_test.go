@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/observability"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestNewMetricsExporter(t *testing.T) {
+	if exporter, err := newMetricsExporter(config.DefaultConfig().Observability); err != nil || exporter != nil {
+		t.Fatalf("expected disabled metrics by default, got %v, %v", exporter, err)
+	}
+	cfg := config.ObservabilityConfig{MetricsEnabled: true, OTLPEndpoint: "localhost:4318"}
+	if _, err := newMetricsExporter(cfg); err == nil || !strings.Contains(err.Error(), "observability") {
+		t.Fatalf("expected an invalid endpoint to be rejected, got %v", err)
+	}
+}
+
+func TestRunOnceRecordsMetricsForRealCycles(t *testing.T) {
+	plugin := &reportingPlugin{name: "cache", result: plugins.CleanupResult{
+		Plugin:       "cache",
+		BytesFreed:   4096,
+		ItemsCleaned: 3,
+	}}
+	daemon := newTestDaemon(t, plugin, &bytes.Buffer{})
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	fallback := filepath.Join(t.TempDir(), "metrics.jsonl")
+	exporter, err := observability.NewExporter("", fallback)
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	daemon.metrics = exporter
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 100, 90))
+
+	daemon.dryRun = true
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("dry-run runOnce failed: %v", err)
+	}
+	if _, err := os.Stat(fallback); !os.IsNotExist(err) {
+		t.Fatalf("expected dry runs to record no metrics, got %v", err)
+	}
+
+	daemon.dryRun = false
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	data, err := os.ReadFile(fallback)
+	if err != nil {
+		t.Fatalf("read metrics fallback: %v", err)
+	}
+	var cycle observability.Cycle
+	if err := json.Unmarshal(bytes.TrimSpace(data), &cycle); err != nil {
+		t.Fatalf("decode metrics line: %v", err)
+	}
+	if cycle.DiskUsedPercent != 90 || len(cycle.Plugins) != 1 || cycle.Plugins[0].BytesFreed != 4096 || cycle.Plugins[0].ItemsCleaned != 3 {
+		t.Fatalf("unexpected recorded cycle %+v", cycle)
+	}
+}
@@ -2,43 +2,19 @@
 package monitor
 
 import (
-	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/diskstats"
 )
 
 // DiskStats represents disk usage statistics.
-type DiskStats struct {
-	// Path is the mount point being monitored
-	Path string
-	// Total bytes on the disk
-	Total uint64
-	// Used bytes on the disk
-	Used uint64
-	// Free bytes on the disk
-	Free uint64
-	// UsedPercent is the percentage of disk used
-	UsedPercent float64
-	// FreePercent is the percentage of disk free
-	FreePercent float64
-	// FreeGB is free space in gigabytes
-	FreeGB float64
-}
+type DiskStats = diskstats.DiskStats
 
 // GetDiskStats returns disk statistics for the specified path.
 func GetDiskStats(path string) (*DiskStats, error) {
-	usage, err := disk.Usage(path)
+	stats, err := diskstats.System{}.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-
-	return &DiskStats{
-		Path:        path,
-		Total:       usage.Total,
-		Used:        usage.Used,
-		Free:        usage.Free,
-		UsedPercent: usage.UsedPercent,
-		FreePercent: 100.0 - usage.UsedPercent,
-		FreeGB:      float64(usage.Free) / (1024 * 1024 * 1024),
-	}, nil
+	return &stats, nil
 }
 
 // GetRootDiskStats returns disk statistics for the root filesystem.
@@ -56,6 +32,8 @@ type DiskMonitor struct {
 	ThresholdAggressive float64
 	// ThresholdCritical percentage for critical level
 	ThresholdCritical float64
+	// Stats reads disk usage in Check; nil reads the real filesystem
+	Stats diskstats.Provider
 }
 
 // NewDiskMonitor creates a new disk monitor with the specified thresholds.
@@ -121,9 +99,13 @@ func (m *DiskMonitor) CheckLevel(stats *DiskStats) CleanupLevel {
 
 // Check performs a disk check and returns the current stats and required level.
 func (m *DiskMonitor) Check(path string) (*DiskStats, CleanupLevel, error) {
-	stats, err := GetDiskStats(path)
+	provider := m.Stats
+	if provider == nil {
+		provider = diskstats.System{}
+	}
+	stats, err := provider.Stat(path)
 	if err != nil {
 		return nil, LevelNone, err
 	}
-	return stats, m.CheckLevel(stats), nil
+	return &stats, m.CheckLevel(&stats), nil
 }
@@ -1,7 +1,10 @@
 package monitor
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/diskstats"
 )
 
 func TestDiskMonitorCheckLevel(t *testing.T) {
@@ -131,3 +134,74 @@ func TestNewDiskMonitor(t *testing.T) {
 		t.Errorf("ThresholdCritical = %v, want 95", mon.ThresholdCritical)
 	}
 }
+
+func TestDiskMonitorCheckUsesProvider(t *testing.T) {
+	const gib = 1 << 30
+	fake := diskstats.NewFake()
+	mon := NewDiskMonitor(80, 85, 90, 95)
+	mon.Stats = fake
+
+	tests := []struct {
+		name     string
+		used     uint64
+		free     uint64
+		expected CleanupLevel
+	}{
+		{"healthy", 50 * gib, 50 * gib, LevelNone},
+		{"just below warning", 7999, 2001, LevelNone},
+		{"at warning", 80 * gib, 20 * gib, LevelWarning},
+		{"at moderate", 85 * gib, 15 * gib, LevelModerate},
+		{"at aggressive", 90 * gib, 10 * gib, LevelAggressive},
+		{"at critical", 95 * gib, 5 * gib, LevelCritical},
+		{"full", 100 * gib, 0, LevelCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Set("/", diskstats.New("", tt.used+tt.free, tt.used, tt.free))
+			stats, level, err := mon.Check("/")
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if level != tt.expected || stats.Free != tt.free {
+				t.Errorf("Check() = %v with %d free, want %v with %d free", level, stats.Free, tt.expected, tt.free)
+			}
+		})
+	}
+}
+
+func TestDiskMonitorCheckCountsReservedBlocksAsUnavailable(t *testing.T) {
+	// 100 GiB with 10 GiB reserved for root: 72 GiB used of the 90 GiB
+	// usable is 80%, though only 72% of the whole disk.
+	const gib = 1 << 30
+	fake := diskstats.NewFake()
+	fake.Set("/", diskstats.New("", 100*gib, 72*gib, 18*gib))
+	mon := NewDiskMonitor(80, 85, 90, 95)
+	mon.Stats = fake
+
+	if _, level, err := mon.Check("/"); err != nil || level != LevelWarning {
+		t.Fatalf("expected warning against usable space, got %v, %v", level, err)
+	}
+}
+
+func TestDiskMonitorCheckMountsIndependently(t *testing.T) {
+	fake := diskstats.NewFake()
+	fake.Set("/", diskstats.New("", 1000, 500, 500))
+	fake.Set("/var/lib/containers", diskstats.New("", 1000, 960, 40))
+	fake.SetError("/Volumes/Backup", errors.New("device not configured"))
+	mon := NewDiskMonitor(80, 85, 90, 95)
+	mon.Stats = fake
+
+	if stats, level, err := mon.Check("/"); err != nil || level != LevelNone || stats.Path != "/" {
+		t.Fatalf("root: got %+v, %v, %v", stats, level, err)
+	}
+	if stats, level, err := mon.Check("/var/lib/containers"); err != nil || level != LevelCritical || stats.Path != "/var/lib/containers" {
+		t.Fatalf("containers: got %+v, %v, %v", stats, level, err)
+	}
+	stats, level, err := mon.Check("/Volumes/Backup")
+	if err == nil || stats != nil || level != LevelNone {
+		t.Fatalf("expected an unreadable mount to fail with no level, got %+v, %v, %v", stats, level, err)
+	}
+	if calls := fake.Calls(); len(calls) != 3 {
+		t.Fatalf("expected one stat per check, got %v", calls)
+	}
+}
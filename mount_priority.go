@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// priorityMount returns the mount the cycle addresses first: the
+// highest-priority mount at the assessed level, with ties kept in config
+// order. contended reports whether more than one mount reached that level,
+// which is when mount-scoped plugins are narrowed to the chosen mount.
+func (a mountAssessment) priorityMount() (mount mountReport, contended bool) {
+	found := false
+	atLevel := 0
+	for _, candidate := range a.Mounts {
+		if candidate.Error != "" || candidate.Path == "" || candidate.Level != a.Level.String() {
+			continue
+		}
+		atLevel++
+		if !found || candidate.Priority > mount.Priority {
+			mount = candidate
+			found = true
+		}
+	}
+	return mount, atLevel > 1 && a.Level > monitor.LevelNone
+}
+
+// mountForPath maps path to the monitored mount that contains it, choosing
+// the deepest mount path that is a prefix of path on a component boundary.
+// It returns "" when no monitored mount contains path.
+func mountForPath(path string, mounts []mountReport) string {
+	path = filepath.Clean(path)
+	best := ""
+	for _, mount := range mounts {
+		root := filepath.Clean(expandPathHome(mount.Path))
+		if mount.Path == "" || !pathWithin(path, root) {
+			continue
+		}
+		if len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+func pathWithin(path, root string) bool {
+	if root == string(filepath.Separator) || path == root {
+		return true
+	}
+	return strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// affectsMount reports whether running p can free space on target. Plugins
+// that are not plugins.MountScoped are assumed to help every mount. A
+// mount-scoped plugin affects target when any of its data paths maps to it,
+// or when a data path maps to no monitored mount, since the daemon cannot
+// rule it out.
+func affectsMount(p plugins.Plugin, cfg *config.Config, mounts []mountReport, target string) bool {
+	scoped, ok := p.(plugins.MountScoped)
+	if !ok {
+		return true
+	}
+	target = filepath.Clean(expandPathHome(target))
+	for _, path := range scoped.DataPaths(cfg) {
+		if path = expandPathHome(path); path == "" {
+			continue
+		}
+		mount := mountForPath(path, mounts)
+		if mount == "" || mount == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+)
+
+type mountScopedPlugin struct {
+	reportingPlugin
+	paths []string
+}
+
+func (p *mountScopedPlugin) DataPaths(*config.Config) []string {
+	return p.paths
+}
+
+func TestPriorityMountPrefersHighestPriorityAtLevel(t *testing.T) {
+	assessment := mountAssessment{
+		Level: monitor.LevelCritical,
+		Mounts: []mountReport{
+			{Path: "/data", Level: "critical"},
+			{Path: "/", Level: "critical", Priority: 10},
+			{Path: "/scratch", Level: "warning", Priority: 99},
+		},
+	}
+	mount, contended := assessment.priorityMount()
+	if mount.Path != "/" || !contended {
+		t.Fatalf("priorityMount() = %s, %v; want / contended", mount.Path, contended)
+	}
+
+	assessment.Mounts[1].Level = "aggressive"
+	mount, contended = assessment.priorityMount()
+	if mount.Path != "/data" || contended {
+		t.Fatalf("priorityMount() = %s, %v; want the only critical mount uncontended", mount.Path, contended)
+	}
+}
+
+func TestMountForPathChoosesDeepestMount(t *testing.T) {
+	mounts := []mountReport{{Path: "/"}, {Path: "/var/lib/docker"}, {Path: "/data"}}
+	for path, want := range map[string]string{
+		"/var/lib/docker/overlay2": "/var/lib/docker",
+		"/var/lib/dockerd":         "/",
+		"/data":                    "/data",
+		"/nix/store":               "/",
+	} {
+		if got := mountForPath(path, mounts); got != want {
+			t.Errorf("mountForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := mountForPath("/nix/store", []mountReport{{Path: "/data"}}); got != "" {
+		t.Errorf("expected no mount for an unmonitored path, got %q", got)
+	}
+}
+
+func TestRunOnceSkipsMountScopedPluginsForLowerPriorityMount(t *testing.T) {
+	var output bytes.Buffer
+	rootPlugin := &mountScopedPlugin{reportingPlugin: reportingPlugin{name: "nix"}, paths: []string{"/nix/store"}}
+	dataPlugin := &mountScopedPlugin{reportingPlugin: reportingPlugin{name: "docker"}, paths: []string{"/data/docker"}}
+	homePlugin := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemonWithPlugins(t, &output, rootPlugin, dataPlugin, homePlugin)
+	daemon.config.MonitoredMounts = []config.MountConfig{
+		{Path: "/data", Label: "data"},
+		{Path: "/", Label: "root", Priority: 10},
+	}
+	daemon.diskStats = func(path string) (*monitor.DiskStats, error) {
+		stats := diskStats(1000, 20, 98)
+		stats.Path = path
+		return stats, nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelNone); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+
+	report := decodeCycleReport(t, output.Bytes())
+	if report.PriorityMount != "/" || report.MonitorPath != "/" {
+		t.Fatalf("expected / to be prioritized and monitored, got priority %q monitor %q", report.PriorityMount, report.MonitorPath)
+	}
+	if !rootPlugin.called || !homePlugin.called {
+		t.Fatal("expected plugins affecting the prioritized mount to run")
+	}
+	if dataPlugin.called {
+		t.Fatal("expected the plugin scoped to /data to wait for a later cycle")
+	}
+	for _, plugin := range report.Plugins {
+		if plugin.Name == "docker" && plugin.SkipReason != "other_mount" {
+			t.Fatalf("expected docker skip reason other_mount, got %q", plugin.SkipReason)
+		}
+	}
+}
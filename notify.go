@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+)
+
+const (
+	diskHogScanTimeout = 2 * time.Minute
+	webhookTimeout     = 10 * time.Second
+)
+
+// diskHog is one large directory under the monitored path.
+type diskHog struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// webhookPayload carries the message as Slack "text", Discord "content", or
+// both when the webhook host is neither service.
+type webhookPayload struct {
+	Text    string `json:"text,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// newWebhookPayload shapes message for the service behind webhookURL.
+func newWebhookPayload(webhookURL, message string) webhookPayload {
+	host := ""
+	if parsed, err := url.Parse(webhookURL); err == nil {
+		host = strings.ToLower(parsed.Hostname())
+	}
+	switch {
+	case host == "hooks.slack.com":
+		return webhookPayload{Text: message}
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return webhookPayload{Content: message}
+	default:
+		return webhookPayload{Text: message, Content: message}
+	}
+}
+
+// checkCriticalEffectiveness alerts when a real critical cycle freed less than
+// notify.ineffective_critical_mb, which means the remaining usage is protected
+// or owned by something no plugin cleans. The top disk hogs under the
+// monitored path are attached to the report and the alert.
+func (d *daemon) checkCriticalEffectiveness(ctx context.Context, report *cycleReport) {
+	notifyCfg := d.currentConfig().Notify
+	if !notifyCfg.AlertOnIneffectiveCritical {
+		return
+	}
+	freed, ineffective := ineffectiveCritical(*report, int64(notifyCfg.IneffectiveCriticalMB)*1024*1024)
+	if !ineffective {
+		return
+	}
+	report.IneffectiveCritical = true
+
+	if notifyCfg.DiskHogCount > 0 && ctx.Err() == nil {
+		scanCtx, cancel := context.WithTimeout(ctx, diskHogScanTimeout)
+		report.DiskHogs = topDiskHogs(scanCtx, report.MonitorPath, notifyCfg.DiskHogCount)
+		cancel()
+	}
+
+	message := ineffectiveCriticalMessage(*report, freed)
+	d.logger.Error("critical cleanup was ineffective; manual intervention needed",
+		"path", report.MonitorPath,
+		"freed_mb", freed/(1024*1024),
+		"disk_hogs", len(report.DiskHogs),
+	)
+	if err := d.dispatchNotification(ctx, report, message); err != nil {
+		d.logger.Warn("failed to send ineffective critical alert", "error", err)
+	}
+}
+
+// alertVMRestartFailures alerts when a plugin reports that a VM did not come
+// back healthy after a compaction restart. The alert skips notify.min_level
+// and critical coalescing, since the VM stays down until someone acts.
+func (d *daemon) alertVMRestartFailures(ctx context.Context, report *cycleReport) {
+	if report.DryRun {
+		return
+	}
+	for _, plugin := range report.Plugins {
+		detail := plugin.ErrorDetail
+		if detail == nil || (detail.Operation != "vm_restart" && detail.Operation != "vm_health") {
+			continue
+		}
+		message := vmRestartFailureMessage(*detail)
+		if d.redactor != nil {
+			message = d.redactor.String(message)
+		}
+		if err := d.sendNotification(ctx, message); err != nil {
+			d.logger.Warn("failed to send VM restart alert", "plugin", detail.Plugin, "vm", detail.VM, "error", err)
+		}
+	}
+}
+
+// alertPluginErrors posts one notify.notify_on_error alert listing the
+// plugins that failed in a real cycle. Like VM restart alerts it skips
+// notify.min_level, and VM restart failures are left to that alert.
+func (d *daemon) alertPluginErrors(ctx context.Context, report *cycleReport) {
+	if report.DryRun || !d.currentConfig().Notify.NotifyOnError {
+		return
+	}
+	var failed []pluginCycleReport
+	for _, plugin := range report.Plugins {
+		if plugin.Error == "" {
+			continue
+		}
+		if detail := plugin.ErrorDetail; detail != nil && (detail.Operation == "vm_restart" || detail.Operation == "vm_health") {
+			continue
+		}
+		failed = append(failed, plugin)
+	}
+	if len(failed) == 0 {
+		return
+	}
+	message := pluginErrorsMessage(*report, failed)
+	if d.redactor != nil {
+		message = d.redactor.String(message)
+	}
+	if err := d.sendNotification(ctx, message); err != nil {
+		d.logger.Warn("failed to send plugin error alert", "plugins", len(failed), "error", err)
+	}
+}
+
+func pluginErrorsMessage(report cycleReport, failed []pluginCycleReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cleanup at %s level on %s: %d plugin(s) failed.", report.Level, report.MonitorPath, len(failed))
+	for _, plugin := range failed {
+		fmt.Fprintf(&b, "\n  %s: %s", plugin.Name, plugin.Error)
+	}
+	return b.String()
+}
+
+func vmRestartFailureMessage(detail pluginErrorReport) string {
+	return fmt.Sprintf("%s VM %s did not come back healthy after disk compaction (%s): %s. It will be retried on the next run; inspect it before use.",
+		detail.Plugin, detail.VM, detail.Operation, detail.Message)
+}
+
+// notifyCycle posts the notify.on_cleanup summary for a real cleanup cycle.
+// Cycles that freed less than notify.min_freed_gb stay silent, and an
+// ineffective critical cycle has already raised its own alert. Any real cycle
+// below critical ends the critical streak used for coalescing.
+func (d *daemon) notifyCycle(ctx context.Context, report *cycleReport) {
+	if report.DryRun {
+		return
+	}
+	level := parseLevel(report.Level)
+	if level != monitor.LevelCritical {
+		d.endCriticalStreak()
+	}
+	notifyCfg := d.currentConfig().Notify
+	if !notifyCfg.OnCleanup || level == monitor.LevelNone || report.IneffectiveCritical {
+		return
+	}
+	freed := cycleFreedBytes(*report)
+	if freed < int64(notifyCfg.MinFreedGB*1024*1024*1024) {
+		return
+	}
+	if err := d.dispatchNotification(ctx, report, cleanupSummaryMessage(*report, freed)); err != nil {
+		d.logger.Warn("failed to send cleanup notification", "error", err)
+	}
+}
+
+// dispatchNotification is the single path for cycle notifications. Cycles
+// below notify.min_level are dropped, and critical notifications within the
+// coalesce window of the last one sent are folded into a later "still
+// critical" message.
+func (d *daemon) dispatchNotification(ctx context.Context, report *cycleReport, message string) error {
+	level := parseLevel(report.Level)
+	if level < notifyMinLevel(d.currentConfig().Notify) {
+		return nil
+	}
+	if level == monitor.LevelCritical {
+		var send bool
+		if message, send = d.coalesceCritical(message); !send {
+			report.NotificationCoalesced = true
+			d.logger.Debug("coalesced critical notification", "path", report.MonitorPath)
+			return nil
+		}
+	}
+	if d.redactor != nil {
+		message = d.redactor.String(message)
+	}
+	return d.sendNotification(ctx, message)
+}
+
+// criticalStreak tracks notifications across consecutive critical cycles.
+type criticalStreak struct {
+	since     time.Time
+	sentAt    time.Time
+	coalesced int
+}
+
+// coalesceCritical reports whether a critical notification should be sent
+// now. Within the window of the last one sent it is counted instead; the first
+// one after the window is prefixed with how long the streak has lasted.
+func (d *daemon) coalesceCritical(message string) (string, bool) {
+	now := d.currentTime()
+	window := d.notifyCoalesceWindow()
+
+	d.notifyMu.Lock()
+	defer d.notifyMu.Unlock()
+	streak := &d.critical
+	if streak.since.IsZero() {
+		streak.since = now
+	} else if window > 0 && now.Sub(streak.sentAt) < window {
+		streak.coalesced++
+		return "", false
+	} else {
+		message = fmt.Sprintf("Still critical since %s (%d notifications coalesced).\n%s",
+			streak.since.Format(time.RFC3339), streak.coalesced, message)
+	}
+	streak.sentAt = now
+	streak.coalesced = 0
+	return message, true
+}
+
+func (d *daemon) endCriticalStreak() {
+	d.notifyMu.Lock()
+	defer d.notifyMu.Unlock()
+	d.critical = criticalStreak{}
+}
+
+// notifyCoalesceWindow returns notify.coalesce_window, falling back to
+// policy.cooldown, the window that already paces non-critical cleanup.
+func (d *daemon) notifyCoalesceWindow() time.Duration {
+	cfg := d.currentConfig()
+	if cfg.Notify.CoalesceWindow == "" {
+		return d.cleanupCooldown()
+	}
+	window, err := time.ParseDuration(cfg.Notify.CoalesceWindow)
+	if err != nil || window < 0 {
+		return 0
+	}
+	return window
+}
+
+// notifyMinLevel returns notify.min_level, treating an empty value as
+// critical so notifications stay quiet unless configured otherwise.
+func notifyMinLevel(cfg config.NotifyConfig) monitor.CleanupLevel {
+	if cfg.MinLevel == "" {
+		return monitor.LevelCritical
+	}
+	return parseLevel(cfg.MinLevel)
+}
+
+// validateNotifyConfig rejects notify settings that would silently disable
+// or mis-pace notifications.
+func validateNotifyConfig(cfg config.NotifyConfig) error {
+	if cfg.MinLevel != "" && parseLevel(cfg.MinLevel) == monitor.LevelNone {
+		return fmt.Errorf("notify.min_level %q must be warning, moderate, aggressive, or critical", cfg.MinLevel)
+	}
+	if cfg.MinFreedGB < 0 {
+		return fmt.Errorf("notify.min_freed_gb must not be negative")
+	}
+	if cfg.CoalesceWindow != "" {
+		if window, err := time.ParseDuration(cfg.CoalesceWindow); err != nil || window < 0 {
+			return fmt.Errorf("notify.coalesce_window %q must be a non-negative duration", cfg.CoalesceWindow)
+		}
+	}
+	return nil
+}
+
+// ineffectiveCritical reports the bytes a completed critical cycle freed and
+// whether that is below threshold. Host free-space deltas are preferred over
+// plugin-reported bytes when they were measured.
+func ineffectiveCritical(report cycleReport, threshold int64) (int64, bool) {
+	if report.DryRun || report.Level != monitor.LevelCritical.String() || report.TargetFreeMet {
+		return 0, false
+	}
+	if report.StopReason != "" {
+		return 0, false
+	}
+	freed := cycleFreedBytes(report)
+	return freed, freed < threshold
+}
+
+// cycleFreedBytes is the space a cycle freed, preferring the measured host
+// free-space delta over plugin-reported bytes.
+func cycleFreedBytes(report cycleReport) int64 {
+	freed := report.TotalBytesFreed
+	if report.HostFreeError == "" && report.HostFreeBeforeBytes > 0 {
+		freed = report.HostFreeDeltaBytes
+	}
+	if freed < 0 {
+		freed = 0
+	}
+	return freed
+}
+
+func cleanupSummaryMessage(report cycleReport, freed int64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cleanup at %s level freed %s on %s.", report.Level, formatByteCount(freed), report.MonitorPath)
+	if report.HostFreeAfterBytes > 0 {
+		fmt.Fprintf(&b, " %s free.", formatByteCount(int64(report.HostFreeAfterBytes)))
+	}
+	var freedBy []pluginCycleReport
+	for _, plugin := range report.Plugins {
+		if plugin.BytesFreed > 0 {
+			freedBy = append(freedBy, plugin)
+		}
+	}
+	sort.SliceStable(freedBy, func(i, j int) bool {
+		return freedBy[i].BytesFreed > freedBy[j].BytesFreed
+	})
+	for _, plugin := range freedBy {
+		fmt.Fprintf(&b, "\n  %10s  %s", formatByteCount(plugin.BytesFreed), plugin.Name)
+	}
+	return b.String()
+}
+
+func ineffectiveCriticalMessage(report cycleReport, freed int64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Critical cleanup freed only %s on %s; manual intervention needed.", formatByteCount(freed), report.MonitorPath)
+	if report.HostFreeAfterBytes > 0 {
+		fmt.Fprintf(&b, " %s free.", formatByteCount(int64(report.HostFreeAfterBytes)))
+	}
+	if len(report.DiskHogs) > 0 {
+		b.WriteString("\nLargest directories:")
+		for _, hog := range report.DiskHogs {
+			fmt.Fprintf(&b, "\n  %10s  %s", formatByteCount(hog.Bytes), hog.Path)
+		}
+	}
+	return b.String()
+}
+
+// topDiskHogs sizes each entry directly under root without crossing onto
+// other filesystems and returns the largest limit entries. Sizes are partial
+// when ctx expires during the scan.
+func topDiskHogs(ctx context.Context, root string, limit int) []diskHog {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	rootDev, ok := pathDevice(root)
+	if !ok {
+		return nil
+	}
+
+	var hogs []diskHog
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		path := filepath.Join(root, entry.Name())
+		size := sameDeviceSize(ctx, path, rootDev)
+		if size > 0 {
+			hogs = append(hogs, diskHog{Path: path, Bytes: size})
+		}
+	}
+	sort.SliceStable(hogs, func(i, j int) bool {
+		return hogs[i].Bytes > hogs[j].Bytes
+	})
+	if len(hogs) > limit {
+		hogs = hogs[:limit]
+	}
+	return hogs
+}
+
+func sameDeviceSize(ctx context.Context, root string, dev uint64) int64 {
+	var size int64
+	_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && uint64(stat.Dev) != dev {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func pathDevice(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// sendNotification posts message to notify.webhook_url when notifications are
+// enabled. It is a no-op otherwise.
+func (d *daemon) sendNotification(ctx context.Context, message string) error {
+	cfg := d.currentConfig()
+	if d.notify != nil {
+		return d.notify(ctx, message)
+	}
+	if !cfg.Notify.Enabled || cfg.Notify.WebhookURL == "" {
+		return nil
+	}
+	return postWebhook(ctx, cfg.Notify.WebhookURL, message)
+}
+
+func postWebhook(ctx context.Context, webhookURL, message string) error {
+	body, err := json.Marshal(newWebhookPayload(webhookURL, message))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
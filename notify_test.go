@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestIneffectiveCritical(t *testing.T) {
+	const threshold = 100 * 1024 * 1024
+	base := cycleReport{
+		Level:               monitor.LevelCritical.String(),
+		HostFreeBeforeBytes: 1 << 30,
+	}
+
+	for _, tt := range []struct {
+		name      string
+		mutate    func(*cycleReport)
+		wantFreed int64
+		want      bool
+	}{
+		{"nothing freed", func(*cycleReport) {}, 0, true},
+		{"host delta below threshold", func(r *cycleReport) { r.HostFreeDeltaBytes = 10 * 1024 * 1024 }, 10 * 1024 * 1024, true},
+		{"host delta above threshold", func(r *cycleReport) { r.HostFreeDeltaBytes = 2 * threshold }, 2 * threshold, false},
+		{"host delta preferred over plugin bytes", func(r *cycleReport) { r.TotalBytesFreed = 2 * threshold }, 0, true},
+		{"plugin bytes without host stats", func(r *cycleReport) {
+			r.HostFreeError = "statfs failed"
+			r.TotalBytesFreed = 2 * threshold
+		}, 2 * threshold, false},
+		{"negative delta clamps to zero", func(r *cycleReport) { r.HostFreeDeltaBytes = -threshold }, 0, true},
+		{"dry run", func(r *cycleReport) { r.DryRun = true }, 0, false},
+		{"aggressive level", func(r *cycleReport) { r.Level = monitor.LevelAggressive.String() }, 0, false},
+		{"target met", func(r *cycleReport) { r.TargetFreeMet = true }, 0, false},
+		{"stopped by deadline", func(r *cycleReport) { r.StopReason = "max_runtime_exceeded" }, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			report := base
+			tt.mutate(&report)
+			freed, got := ineffectiveCritical(report, threshold)
+			if got != tt.want || freed != tt.wantFreed {
+				t.Fatalf("ineffectiveCritical = (%d, %v), want (%d, %v)", freed, got, tt.wantFreed, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunOnceAlertsOnIneffectiveCritical(t *testing.T) {
+	root := t.TempDir()
+	writeSizedFile(t, filepath.Join(root, "big", "blob"), 4096)
+	writeSizedFile(t, filepath.Join(root, "medium", "nested", "blob"), 2048)
+	writeSizedFile(t, filepath.Join(root, "small", "blob"), 16)
+
+	var output bytes.Buffer
+	daemon := newTestDaemon(t, &reportingPlugin{}, &output)
+	daemon.config.MonitoredMounts = []config.MountConfig{{Path: root, Label: "data"}}
+	daemon.config.Notify.DiskHogCount = 2
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 40, 96))
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+
+	var report cycleReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if !report.IneffectiveCritical {
+		t.Fatal("expected critical cycle that freed nothing to be reported as ineffective")
+	}
+	if len(report.DiskHogs) != 2 {
+		t.Fatalf("DiskHogs = %+v, want the 2 largest entries", report.DiskHogs)
+	}
+	if report.DiskHogs[0].Path != filepath.Join(root, "big") || report.DiskHogs[1].Path != filepath.Join(root, "medium") {
+		t.Fatalf("DiskHogs = %+v, want big then medium", report.DiskHogs)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected one alert, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], "Critical cleanup freed only 0 B") || !strings.Contains(messages[0], filepath.Join(root, "big")) {
+		t.Fatalf("alert = %q, want freed bytes and the largest directory", messages[0])
+	}
+}
+
+func TestRunOnceSkipsAlertWhenCriticalFreesSpace(t *testing.T) {
+	var output bytes.Buffer
+	plugin := &reportingPlugin{result: plugins.CleanupResult{BytesFreed: 500 * 1024 * 1024}}
+	daemon := newTestDaemon(t, plugin, &output)
+	daemon.diskStats = sequenceDiskStats(t,
+		diskStats(10<<30, 1<<30, 90),
+		diskStats(10<<30, 1<<30, 90),
+		diskStats(10<<30, 2<<30, 80),
+	)
+	daemon.notify = func(context.Context, string) error {
+		t.Fatal("unexpected alert for an effective critical cycle")
+		return nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if strings.Contains(output.String(), "ineffective_critical") {
+		t.Fatalf("report should not flag an effective critical cycle: %s", output.String())
+	}
+
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	plugin.result = plugins.CleanupResult{}
+	daemon.diskStats = sequenceDiskStats(t, diskStats(10<<30, 1<<30, 90))
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+}
+
+func TestRunOnceAlertsOnUnhealthyVMRestart(t *testing.T) {
+	var output bytes.Buffer
+	plugin := &reportingPlugin{result: plugins.CleanupResult{
+		Error: &plugins.PluginError{Plugin: "podman", Operation: "vm_health", VM: "podman-machine-default", Err: errors.New("not listed as running")},
+	}}
+	daemon := newTestDaemon(t, plugin, &output)
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	daemon.diskStats = sequenceDiskStats(t, diskStats(10<<30, 1<<30, 90))
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "podman-machine-default") || !strings.Contains(messages[0], "not listed as running") {
+		t.Fatalf("expected one VM restart alert, got %q", messages)
+	}
+}
+
+func TestRunOnceAlertsOnPluginErrors(t *testing.T) {
+	var output bytes.Buffer
+	failing := &reportingPlugin{name: "docker", result: plugins.CleanupResult{
+		Plugin: "docker",
+		Error:  errors.New("docker system prune: exit status 1"),
+	}}
+	healthy := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemonWithPlugins(t, &output, failing, healthy)
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	daemon.config.Notify.MinLevel = "critical"
+	daemon.diskStats = sequenceDiskStats(t, diskStats(10<<30, 1<<30, 90))
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelWarning); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "docker: docker system prune: exit status 1") || strings.Contains(messages[0], "cache") {
+		t.Fatalf("expected one alert naming the failed plugin below min_level, got %q", messages)
+	}
+
+	messages = nil
+	daemon.config.Notify.NotifyOnError = false
+	if err := daemon.runOnce(context.Background(), monitor.LevelWarning); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("expected notify_on_error=false to stay silent, got %q", messages)
+	}
+}
+
+func TestNotifyCycleHonorsLevelAndFreedThresholds(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Notify.OnCleanup = true
+	daemon.config.Notify.MinLevel = "aggressive"
+	daemon.config.Notify.MinFreedGB = 1
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	for _, report := range []cycleReport{
+		{Level: "moderate", MonitorPath: "/", TotalBytesFreed: 5 << 30},
+		{Level: "aggressive", MonitorPath: "/", TotalBytesFreed: 512 << 20},
+		{Level: "aggressive", MonitorPath: "/", TotalBytesFreed: 5 << 30, DryRun: true},
+		{Level: "aggressive", MonitorPath: "/", HostFreeBeforeBytes: 1 << 30, HostFreeDeltaBytes: 256 << 20, TotalBytesFreed: 5 << 30},
+	} {
+		daemon.notifyCycle(context.Background(), &report)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("expected silent cycles below min_level or min_freed_gb, got %q", messages)
+	}
+
+	report := cycleReport{Level: "aggressive", MonitorPath: "/", TotalBytesFreed: 3 << 30, HostFreeAfterBytes: 8 << 30}
+	daemon.notifyCycle(context.Background(), &report)
+	if len(messages) != 1 || messages[0] != "Cleanup at aggressive level freed 3.0 GiB on /. 8.0 GiB free." {
+		t.Fatalf("unexpected notifications %q", messages)
+	}
+
+	report = cycleReport{
+		Level:           "aggressive",
+		MonitorPath:     "/",
+		TotalBytesFreed: 3 << 30,
+		Plugins: []pluginCycleReport{
+			{Name: "cache", BytesFreed: 1 << 30},
+			{Name: "homebrew"},
+			{Name: "docker", BytesFreed: 2 << 30},
+		},
+	}
+	daemon.notifyCycle(context.Background(), &report)
+	want := "Cleanup at aggressive level freed 3.0 GiB on /.\n     2.0 GiB  docker\n     1.0 GiB  cache"
+	if len(messages) != 2 || messages[1] != want {
+		t.Fatalf("expected a per-plugin breakdown, got %q", messages)
+	}
+}
+
+func TestCriticalNotificationsCoalesceWithinWindow(t *testing.T) {
+	daemon := newTestDaemon(t, &reportingPlugin{}, io.Discard)
+	daemon.config.Notify.OnCleanup = true
+	daemon.config.Notify.MinFreedGB = 0
+	daemon.config.Notify.CoalesceWindow = "1h"
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	daemon.now = func() time.Time { return now }
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+	critical := func() cycleReport {
+		report := cycleReport{Level: "critical", MonitorPath: "/", TotalBytesFreed: 2 << 30}
+		daemon.notifyCycle(context.Background(), &report)
+		return report
+	}
+
+	critical()
+	for i := 0; i < 3; i++ {
+		now = now.Add(10 * time.Minute)
+		if report := critical(); !report.NotificationCoalesced {
+			t.Fatal("expected a critical notification within the window to be coalesced")
+		}
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected one notification for the burst, got %q", messages)
+	}
+
+	now = start.Add(time.Hour)
+	critical()
+	if len(messages) != 2 || !strings.HasPrefix(messages[1], "Still critical since 2026-03-01T12:00:00Z (3 notifications coalesced).") {
+		t.Fatalf("expected a still-critical notification after the window, got %q", messages)
+	}
+
+	// A cycle below critical ends the streak; the next critical is fresh.
+	daemon.notifyCycle(context.Background(), &cycleReport{Level: "moderate", MonitorPath: "/"})
+	now = now.Add(time.Minute)
+	critical()
+	if len(messages) != 3 || strings.HasPrefix(messages[2], "Still critical") {
+		t.Fatalf("expected a fresh critical notification after the streak ended, got %q", messages)
+	}
+}
+
+func TestValidateNotifyConfig(t *testing.T) {
+	if err := validateNotifyConfig(config.DefaultConfig().Notify); err != nil {
+		t.Fatalf("default notify config rejected: %v", err)
+	}
+	for _, bad := range []config.NotifyConfig{
+		{MinLevel: "loud"},
+		{MinFreedGB: -1},
+		{CoalesceWindow: "soon"},
+		{CoalesceWindow: "-5m"},
+	} {
+		if err := validateNotifyConfig(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	if err := postWebhook(context.Background(), server.URL, "disk full"); err != nil {
+		t.Fatalf("postWebhook returned error: %v", err)
+	}
+	if payload.Text != "disk full" || payload.Content != "disk full" {
+		t.Fatalf("payload = %+v, want message in text and content", payload)
+	}
+	for _, tc := range []struct {
+		url  string
+		want webhookPayload
+	}{
+		{"https://hooks.slack.com/services/T0/B0/x", webhookPayload{Text: "disk full"}},
+		{"https://discord.com/api/webhooks/1/x", webhookPayload{Content: "disk full"}},
+		{"https://ptb.discord.com/api/webhooks/1/x", webhookPayload{Content: "disk full"}},
+		{"https://chat.example.com/hooks/x", webhookPayload{Text: "disk full", Content: "disk full"}},
+	} {
+		if got := newWebhookPayload(tc.url, "disk full"); got != tc.want {
+			t.Errorf("newWebhookPayload(%q) = %+v, want %+v", tc.url, got, tc.want)
+		}
+	}
+	if err := postWebhook(context.Background(), server.URL+"/fail", "disk full"); err == nil {
+		t.Fatal("expected non-2xx webhook status to fail")
+	}
+}
+
+func writeSizedFile(t *testing.T, path string, size int) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package cooldown records when expensive or destructive operations last ran,
+// so a cleanup that keeps finding the disk full does not repeat them every
+// cycle. History is a small JSON file keyed by plugin and operation.
+package cooldown
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the history file written beside the daemon state file.
+const FileName = "cooldowns.json"
+
+const historyVersion = 1
+
+// mu serializes read-modify-write cycles on history files in this process.
+var mu sync.Mutex
+
+type history struct {
+	Version int `json:"version"`
+	// Operations maps Key(plugin, operation) to the last run in RFC 3339.
+	Operations map[string]string `json:"operations"`
+}
+
+// Store reads and writes one history file. A Store with an empty path keeps
+// no history, so every operation is allowed.
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+// New returns a Store backed by path.
+func New(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+// Key names an operation of a plugin, e.g. "podman.disk_compact".
+func Key(plugin, operation string) string {
+	return plugin + "." + operation
+}
+
+// LastRun returns when the operation last ran.
+func (s *Store) LastRun(plugin, operation string) (time.Time, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	h, err := s.load()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	raw, ok := h.Operations[Key(plugin, operation)]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	last, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+	return last, true, nil
+}
+
+// Remaining returns how much of window is left since the operation last ran.
+// It is zero when the operation never ran, the window has passed, or window
+// is not positive.
+func (s *Store) Remaining(plugin, operation string, window time.Duration) (time.Duration, error) {
+	if window <= 0 {
+		return 0, nil
+	}
+	last, ok, err := s.LastRun(plugin, operation)
+	if err != nil || !ok {
+		return 0, err
+	}
+	elapsed := max(s.now().Sub(last), 0)
+	if elapsed >= window {
+		return 0, nil
+	}
+	return window - elapsed, nil
+}
+
+// Record marks the operation as run now.
+func (s *Store) Record(plugin, operation string) error {
+	if s.path == "" {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	h, err := s.load()
+	if err != nil {
+		return err
+	}
+	h.Operations[Key(plugin, operation)] = s.now().UTC().Format(time.RFC3339)
+	return s.save(h)
+}
+
+func (s *Store) load() (*history, error) {
+	h := &history{Version: historyVersion, Operations: map[string]string{}}
+	if s.path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(data) == 0) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.Operations == nil {
+		h.Operations = map[string]string{}
+	}
+	return h, nil
+}
+
+// save writes through a temporary file so a crash never leaves a truncated
+// history that would reset every cooldown.
+func (s *Store) save(h *history) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,80 @@
+package cooldown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemainingEnforcesAndExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := New(path)
+	store.now = func() time.Time { return now }
+
+	if remaining, err := store.Remaining("podman", "disk_compact", 24*time.Hour); err != nil || remaining != 0 {
+		t.Fatalf("never-run operation should be allowed, got %s, %v", remaining, err)
+	}
+	if err := store.Record("podman", "disk_compact"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	now = now.Add(6 * time.Hour)
+	remaining, err := store.Remaining("podman", "disk_compact", 24*time.Hour)
+	if err != nil || remaining != 18*time.Hour {
+		t.Fatalf("expected 18h remaining, got %s, %v", remaining, err)
+	}
+	if remaining, _ := store.Remaining("lima", "disk_compact", 24*time.Hour); remaining != 0 {
+		t.Fatalf("other plugins' operations should be independent, got %s", remaining)
+	}
+	if remaining, _ := store.Remaining("podman", "disk_compact", 0); remaining != 0 {
+		t.Fatalf("a zero window should never block, got %s", remaining)
+	}
+
+	now = now.Add(18 * time.Hour)
+	if remaining, _ := store.Remaining("podman", "disk_compact", 24*time.Hour); remaining != 0 {
+		t.Fatalf("expected cooldown to expire after 24h, got %s", remaining)
+	}
+}
+
+func TestRecordPersistsAcrossStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	first := New(path)
+	if err := first.Record("apfs-snapshots", "delete_snapshots"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := first.Record("cache", "go_modcache"); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	second := New(path)
+	for _, key := range [][2]string{{"apfs-snapshots", "delete_snapshots"}, {"cache", "go_modcache"}} {
+		if _, ok, err := second.LastRun(key[0], key[1]); err != nil || !ok {
+			t.Fatalf("expected %s to be recorded, got ok=%v err=%v", Key(key[0], key[1]), ok, err)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file should be renamed away, got %v", err)
+	}
+}
+
+func TestEmptyPathKeepsNoHistory(t *testing.T) {
+	store := New("")
+	if err := store.Record("podman", "disk_compact"); err != nil {
+		t.Fatalf("Record without a path should be a no-op, got %v", err)
+	}
+	if remaining, err := store.Remaining("podman", "disk_compact", time.Hour); err != nil || remaining != 0 {
+		t.Fatalf("expected no cooldown without history, got %s, %v", remaining, err)
+	}
+}
+
+func TestCorruptHistoryIsAnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path).Remaining("podman", "disk_compact", time.Hour); err == nil {
+		t.Fatal("expected an error for unreadable history")
+	}
+}
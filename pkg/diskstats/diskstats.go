@@ -0,0 +1,123 @@
+// Package diskstats reads filesystem usage behind an interface, so code that
+// decides on disk usage can be tested against fixed numbers instead of the
+// real disk.
+package diskstats
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// DiskStats represents disk usage statistics.
+type DiskStats struct {
+	// Path is the mount point being monitored
+	Path string
+	// Total bytes on the disk
+	Total uint64
+	// Used bytes on the disk
+	Used uint64
+	// Free bytes on the disk
+	Free uint64
+	// UsedPercent is the percentage of disk used
+	UsedPercent float64
+	// FreePercent is the percentage of disk free
+	FreePercent float64
+	// FreeGB is free space in gigabytes
+	FreeGB float64
+}
+
+// Provider reads the usage of the filesystem holding path.
+type Provider interface {
+	Stat(path string) (DiskStats, error)
+}
+
+// System is the Provider backed by statfs.
+type System struct{}
+
+// Stat returns the usage of the filesystem holding path.
+func (System) Stat(path string) (DiskStats, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return DiskStats{}, err
+	}
+	return fromUsage(path, usage.Total, usage.Used, usage.Free, usage.UsedPercent), nil
+}
+
+// New builds the stats a filesystem of total bytes with used and free bytes
+// would report. Like statfs, UsedPercent is used over used plus free, so
+// blocks reserved for root count as neither.
+func New(path string, total, used, free uint64) DiskStats {
+	var usedPercent float64
+	if used+free > 0 {
+		usedPercent = float64(used) / float64(used+free) * 100
+	}
+	return fromUsage(path, total, used, free, usedPercent)
+}
+
+func fromUsage(path string, total, used, free uint64, usedPercent float64) DiskStats {
+	return DiskStats{
+		Path:        path,
+		Total:       total,
+		Used:        used,
+		Free:        free,
+		UsedPercent: usedPercent,
+		FreePercent: 100.0 - usedPercent,
+		FreeGB:      float64(free) / (1024 * 1024 * 1024),
+	}
+}
+
+// Fake is a Provider that serves fixed stats per path, for tests. A path
+// with neither stats nor an error set fails like a missing mount.
+type Fake struct {
+	mu     sync.Mutex
+	stats  map[string]DiskStats
+	errors map[string]error
+	calls  []string
+}
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{stats: map[string]DiskStats{}, errors: map[string]error{}}
+}
+
+// Set serves stats for path, replacing any stats or error set before. The
+// stats' Path is set to path.
+func (f *Fake) Set(path string, stats DiskStats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats.Path = path
+	f.stats[path] = stats
+	delete(f.errors, path)
+}
+
+// SetError makes Stat of path fail with err.
+func (f *Fake) SetError(path string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[path] = err
+	delete(f.stats, path)
+}
+
+// Stat returns the stats or error set for path.
+func (f *Fake) Stat(path string) (DiskStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, path)
+	if err, ok := f.errors[path]; ok {
+		return DiskStats{}, err
+	}
+	stats, ok := f.stats[path]
+	if !ok {
+		return DiskStats{}, fmt.Errorf("no such mount: %s", path)
+	}
+	return stats, nil
+}
+
+// Calls returns the paths passed to Stat, in order.
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
@@ -0,0 +1,52 @@
+package diskstats
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewExcludesReservedBlocksFromUsedPercent(t *testing.T) {
+	// 100 GiB filesystem with 5 GiB reserved for root: 76 used, 19 free.
+	const gib = 1 << 30
+	stats := New("/data", 100*gib, 76*gib, 19*gib)
+	if stats.UsedPercent != 80 || stats.FreePercent != 20 || stats.FreeGB != 19 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if empty := New("/empty", 0, 0, 0); empty.UsedPercent != 0 || empty.FreePercent != 100 {
+		t.Fatalf("expected an empty filesystem to be 0%% used, got %+v", empty)
+	}
+}
+
+func TestFakeServesStatsAndErrors(t *testing.T) {
+	fake := NewFake()
+	fake.Set("/", New("", 1000, 900, 100))
+	fake.SetError("/Volumes/External", errors.New("device not configured"))
+
+	stats, err := fake.Stat("/")
+	if err != nil || stats.Path != "/" || stats.UsedPercent != 90 {
+		t.Fatalf("unexpected stats: %+v, %v", stats, err)
+	}
+	if _, err := fake.Stat("/Volumes/External"); err == nil {
+		t.Fatal("expected the configured error")
+	}
+	if _, err := fake.Stat("/missing"); err == nil {
+		t.Fatal("expected an unknown path to fail")
+	}
+	fake.Set("/Volumes/External", New("", 1000, 100, 900))
+	if _, err := fake.Stat("/Volumes/External"); err != nil {
+		t.Fatalf("expected Set to clear the error: %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 4 || calls[0] != "/" || calls[2] != "/missing" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestSystemStatRoot(t *testing.T) {
+	stats, err := System{}.Stat("/")
+	if err != nil {
+		t.Fatalf("Stat(/) failed: %v", err)
+	}
+	if stats.Path != "/" || stats.Total == 0 {
+		t.Fatalf("unexpected root stats: %+v", stats)
+	}
+}
@@ -0,0 +1,84 @@
+// Package env resolves the user environment that cleanup acts on.
+//
+// Cleanup targets are mostly per-user paths. When the daemon runs as a system
+// service, $HOME may be unset or point at "/", so every home-relative path is
+// resolved through HomeDir, which refuses to guess.
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+)
+
+// ErrHomeUnavailable is returned when no trustworthy home directory is known.
+var ErrHomeUnavailable = errors.New("home directory unavailable")
+
+var (
+	mu           sync.RWMutex
+	homeOverride string
+	runAsUser    string
+	lookupUser   = user.Lookup
+	userHomeDir  = os.UserHomeDir
+)
+
+// Configure sets the config-provided home override and run-as user. An empty
+// value clears the corresponding setting.
+func Configure(override, username string) {
+	mu.Lock()
+	defer mu.Unlock()
+	homeOverride = override
+	runAsUser = username
+}
+
+// Settings returns the home override and run-as user set by Configure, so a
+// caller can restore them.
+func Settings() (override, username string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return homeOverride, runAsUser
+}
+
+// HomeDir returns the home directory cleanup should act on. It prefers the
+// configured home_override, then the home of run_as_user, then the invoking
+// user's home. It returns an error rather than "/" or a relative path.
+func HomeDir() (string, error) {
+	mu.RLock()
+	override, username := homeOverride, runAsUser
+	mu.RUnlock()
+
+	if override != "" {
+		return validateHome(override, "home_override")
+	}
+
+	if username != "" {
+		u, err := lookupUser(username)
+		if err != nil {
+			return "", fmt.Errorf("%w: run_as_user %q: %v", ErrHomeUnavailable, username, err)
+		}
+		return validateHome(u.HomeDir, fmt.Sprintf("run_as_user %q", username))
+	}
+
+	home, err := userHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrHomeUnavailable, err)
+	}
+	return validateHome(home, "$HOME")
+}
+
+func validateHome(home, source string) (string, error) {
+	if home == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrHomeUnavailable, source)
+	}
+	if !filepath.IsAbs(home) {
+		return "", fmt.Errorf("%w: %s %q is not absolute", ErrHomeUnavailable, source, home)
+	}
+	cleaned := filepath.Clean(home)
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("%w: %s resolves to the filesystem root", ErrHomeUnavailable, source)
+	}
+	return cleaned, nil
+}
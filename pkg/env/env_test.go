@@ -0,0 +1,77 @@
+package env
+
+import (
+	"errors"
+	"os/user"
+	"testing"
+)
+
+func withResolvers(t *testing.T, home string, homeErr error, users map[string]string) {
+	t.Helper()
+	origHome, origLookup := userHomeDir, lookupUser
+	userHomeDir = func() (string, error) { return home, homeErr }
+	lookupUser = func(name string) (*user.User, error) {
+		if dir, ok := users[name]; ok {
+			return &user.User{Username: name, HomeDir: dir}, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+	t.Cleanup(func() {
+		userHomeDir, lookupUser = origHome, origLookup
+		Configure("", "")
+	})
+}
+
+func TestHomeDirUsesInvokingUser(t *testing.T) {
+	withResolvers(t, "/home/jess/", nil, nil)
+	Configure("", "")
+
+	home, err := HomeDir()
+	if err != nil {
+		t.Fatalf("HomeDir failed: %v", err)
+	}
+	if home != "/home/jess" {
+		t.Fatalf("expected /home/jess, got %q", home)
+	}
+}
+
+func TestHomeDirRejectsRootAndUnset(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		home string
+		err  error
+	}{
+		{name: "root", home: "/"},
+		{name: "empty", home: ""},
+		{name: "relative", home: "home/jess"},
+		{name: "lookup error", err: errors.New("$HOME is not defined")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			withResolvers(t, tc.home, tc.err, nil)
+			Configure("", "")
+
+			if home, err := HomeDir(); !errors.Is(err, ErrHomeUnavailable) {
+				t.Fatalf("expected ErrHomeUnavailable, got home=%q err=%v", home, err)
+			}
+		})
+	}
+}
+
+func TestHomeDirPrefersOverrideThenRunAsUser(t *testing.T) {
+	withResolvers(t, "/", nil, map[string]string{"jess": "/Users/jess"})
+
+	Configure("", "jess")
+	if home, err := HomeDir(); err != nil || home != "/Users/jess" {
+		t.Fatalf("expected run_as_user home, got %q err=%v", home, err)
+	}
+
+	Configure("/srv/cleanup-home", "jess")
+	if home, err := HomeDir(); err != nil || home != "/srv/cleanup-home" {
+		t.Fatalf("expected home_override, got %q err=%v", home, err)
+	}
+
+	Configure("", "missing")
+	if _, err := HomeDir(); !errors.Is(err, ErrHomeUnavailable) {
+		t.Fatalf("expected unknown run_as_user to fail, got %v", err)
+	}
+}
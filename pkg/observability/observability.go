@@ -0,0 +1,277 @@
+// Package observability exports per-cycle cleanup metrics to an OpenTelemetry
+// collector over OTLP/HTTP with JSON encoding, so no OpenTelemetry SDK is
+// linked into the daemon. When the collector cannot be reached, each cycle is
+// appended to a JSON lines fallback file instead.
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsPath is the OTLP/HTTP metrics path appended to endpoints that have
+// no path of their own.
+const MetricsPath = "/v1/metrics"
+
+const (
+	serviceName   = "tinyland-cleanup"
+	exportTimeout = 10 * time.Second
+	// cumulativeTemporality is AGGREGATION_TEMPORALITY_CUMULATIVE.
+	cumulativeTemporality = 2
+)
+
+// Cycle is the metrics one cleanup cycle contributes.
+type Cycle struct {
+	Time time.Time `json:"timestamp"`
+	// DiskUsedPercent is the monitored disk's usage after the cycle.
+	DiskUsedPercent float64  `json:"disk_used_percent"`
+	Plugins         []Plugin `json:"plugins,omitempty"`
+}
+
+// Plugin is one plugin's contribution to a cycle.
+type Plugin struct {
+	Name            string  `json:"plugin"`
+	BytesFreed      int64   `json:"bytes_freed"`
+	ItemsCleaned    int     `json:"items_cleaned"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// Exporter keeps cumulative per-plugin totals since it was created and sends
+// them, with the cycle's gauges, after each Record.
+type Exporter struct {
+	endpoint     string
+	fallbackPath string
+	client       *http.Client
+	start        time.Time
+
+	mu         sync.Mutex
+	bytesFreed map[string]int64
+	items      map[string]int64
+}
+
+// NewExporter returns an exporter for endpoint, an http or https collector
+// URL such as http://localhost:4318. Cycles that cannot be exported are
+// appended to fallbackPath; empty drops them. An empty endpoint writes every
+// cycle to fallbackPath.
+func NewExporter(endpoint, fallbackPath string) (*Exporter, error) {
+	if endpoint == "" && fallbackPath == "" {
+		return nil, errors.New("an OTLP endpoint or fallback path is required")
+	}
+	if endpoint != "" {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("parse OTLP endpoint: %w", err)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("OTLP endpoint %q must be an http or https URL", endpoint)
+		}
+		if parsed.Path == "" || parsed.Path == "/" {
+			parsed.Path = MetricsPath
+		}
+		endpoint = parsed.String()
+	}
+	return &Exporter{
+		endpoint:     endpoint,
+		fallbackPath: fallbackPath,
+		client:       &http.Client{Timeout: exportTimeout},
+		start:        time.Now(),
+		bytesFreed:   make(map[string]int64),
+		items:        make(map[string]int64),
+	}, nil
+}
+
+// Record adds cycle to the cumulative totals and exports them. An export
+// failure is returned after the cycle has been written to the fallback file.
+func (e *Exporter) Record(ctx context.Context, cycle Cycle) error {
+	e.mu.Lock()
+	for _, plugin := range cycle.Plugins {
+		e.bytesFreed[plugin.Name] += plugin.BytesFreed
+		e.items[plugin.Name] += int64(plugin.ItemsCleaned)
+	}
+	body, err := json.Marshal(e.request(cycle))
+	e.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var exportErr error
+	if e.endpoint != "" {
+		if exportErr = e.post(ctx, body); exportErr == nil {
+			return nil
+		}
+	}
+	if e.fallbackPath == "" {
+		return exportErr
+	}
+	if err := appendJSONLine(e.fallbackPath, cycle); err != nil {
+		return errors.Join(exportErr, err)
+	}
+	return exportErr
+}
+
+func (e *Exporter) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+func appendJSONLine(path string, cycle Cycle) error {
+	line, err := json.Marshal(cycle)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// The types below are the subset of the OTLP ExportMetricsServiceRequest
+// JSON encoding this exporter writes. 64-bit integers are strings, as the
+// protobuf JSON mapping requires.
+
+type exportRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type metric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Sum   *sum   `json:"sum,omitempty"`
+	Gauge *gauge `json:"gauge,omitempty"`
+}
+
+type sum struct {
+	AggregationTemporality int         `json:"aggregationTemporality"`
+	IsMonotonic            bool        `json:"isMonotonic"`
+	DataPoints             []dataPoint `json:"dataPoints"`
+}
+
+type gauge struct {
+	DataPoints []dataPoint `json:"dataPoints"`
+}
+
+type dataPoint struct {
+	Attributes        []attribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string      `json:"timeUnixNano"`
+	AsInt             string      `json:"asInt,omitempty"`
+	AsDouble          *float64    `json:"asDouble,omitempty"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func stringAttribute(key, value string) attribute {
+	return attribute{Key: key, Value: attributeValue{StringValue: value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// request builds the export request for cycle. Callers hold e.mu.
+func (e *Exporter) request(cycle Cycle) exportRequest {
+	now := unixNano(cycle.Time)
+	start := unixNano(e.start)
+
+	counter := func(name, unit string, totals map[string]int64) metric {
+		names := make([]string, 0, len(totals))
+		for plugin := range totals {
+			names = append(names, plugin)
+		}
+		sort.Strings(names)
+		points := make([]dataPoint, 0, len(names))
+		for _, plugin := range names {
+			points = append(points, dataPoint{
+				Attributes:        []attribute{stringAttribute("plugin", plugin)},
+				StartTimeUnixNano: start,
+				TimeUnixNano:      now,
+				AsInt:             strconv.FormatInt(totals[plugin], 10),
+			})
+		}
+		return metric{Name: name, Unit: unit, Sum: &sum{
+			AggregationTemporality: cumulativeTemporality,
+			IsMonotonic:            true,
+			DataPoints:             points,
+		}}
+	}
+
+	used := cycle.DiskUsedPercent
+	metrics := []metric{
+		counter("cleanup_bytes_freed_total", "By", e.bytesFreed),
+		counter("cleanup_items_cleaned_total", "{item}", e.items),
+		{Name: "disk_used_percent", Unit: "%", Gauge: &gauge{DataPoints: []dataPoint{{TimeUnixNano: now, AsDouble: &used}}}},
+	}
+	if len(cycle.Plugins) > 0 {
+		durations := make([]dataPoint, 0, len(cycle.Plugins))
+		for _, plugin := range cycle.Plugins {
+			seconds := plugin.DurationSeconds
+			durations = append(durations, dataPoint{
+				Attributes:   []attribute{stringAttribute("plugin", plugin.Name)},
+				TimeUnixNano: now,
+				AsDouble:     &seconds,
+			})
+		}
+		metrics = append(metrics, metric{Name: "cleanup_duration_seconds", Unit: "s", Gauge: &gauge{DataPoints: durations}})
+	}
+
+	return exportRequest{ResourceMetrics: []resourceMetrics{{
+		Resource:     resource{Attributes: []attribute{stringAttribute("service.name", serviceName)}},
+		ScopeMetrics: []scopeMetrics{{Scope: scope{Name: serviceName}, Metrics: metrics}},
+	}}}
+}
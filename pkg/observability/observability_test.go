@@ -0,0 +1,127 @@
+package observability
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewExporterValidatesEndpoint(t *testing.T) {
+	exporter, err := NewExporter("http://localhost:4318", "")
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	if exporter.endpoint != "http://localhost:4318"+MetricsPath {
+		t.Fatalf("endpoint = %q, want the metrics path appended", exporter.endpoint)
+	}
+	if exporter, err := NewExporter("https://otel.example.com/custom/metrics", ""); err != nil || exporter.endpoint != "https://otel.example.com/custom/metrics" {
+		t.Fatalf("expected an explicit path to be kept, got %v", err)
+	}
+	for _, bad := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+		if _, err := NewExporter(bad, ""); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+	if _, err := NewExporter("", ""); err == nil {
+		t.Fatal("expected an exporter with nowhere to write to be rejected")
+	}
+}
+
+func TestRecordExportsCumulativeCountersAndGauges(t *testing.T) {
+	var requests []exportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != MetricsPath || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var request exportRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("decode export request: %v", err)
+		}
+		requests = append(requests, request)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(server.URL, "")
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	cycle := Cycle{
+		Time:            time.Now(),
+		DiskUsedPercent: 87.5,
+		Plugins:         []Plugin{{Name: "docker", BytesFreed: 3 << 30, ItemsCleaned: 4, DurationSeconds: 12}},
+	}
+	for i := 0; i < 2; i++ {
+		if err := exporter.Record(context.Background(), cycle); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected two exports, got %d", len(requests))
+	}
+	metrics := map[string]metric{}
+	for _, m := range requests[1].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	freed := metrics["cleanup_bytes_freed_total"]
+	if freed.Sum == nil || !freed.Sum.IsMonotonic || len(freed.Sum.DataPoints) != 1 {
+		t.Fatalf("unexpected bytes freed counter %+v", freed)
+	}
+	if point := freed.Sum.DataPoints[0]; point.AsInt != "6442450944" || point.Attributes[0].Value.StringValue != "docker" {
+		t.Fatalf("expected cumulative docker bytes, got %+v", point)
+	}
+	if items := metrics["cleanup_items_cleaned_total"]; items.Sum == nil || items.Sum.DataPoints[0].AsInt != "8" {
+		t.Fatalf("unexpected items counter %+v", items)
+	}
+	if used := metrics["disk_used_percent"]; used.Gauge == nil || *used.Gauge.DataPoints[0].AsDouble != 87.5 {
+		t.Fatalf("unexpected disk gauge %+v", used)
+	}
+	if duration := metrics["cleanup_duration_seconds"]; duration.Gauge == nil || *duration.Gauge.DataPoints[0].AsDouble != 12 {
+		t.Fatalf("unexpected duration gauge %+v", duration)
+	}
+}
+
+func TestRecordFallsBackToJSONLines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	fallback := filepath.Join(t.TempDir(), "state", "metrics.jsonl")
+
+	exporter, err := NewExporter(server.URL, fallback)
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	cycle := Cycle{Time: time.Now(), DiskUsedPercent: 91, Plugins: []Plugin{{Name: "cache", BytesFreed: 1024}}}
+	for i := 0; i < 2; i++ {
+		if err := exporter.Record(context.Background(), cycle); err == nil {
+			t.Fatal("expected the export failure to be returned")
+		}
+	}
+
+	file, err := os.Open(fallback)
+	if err != nil {
+		t.Fatalf("open fallback: %v", err)
+	}
+	defer file.Close()
+	var lines []Cycle
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line Cycle
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode fallback line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[1].DiskUsedPercent != 91 || lines[1].Plugins[0].Name != "cache" {
+		t.Fatalf("unexpected fallback lines %+v", lines)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build darwin
+
+package power
+
+import "context"
+
+// displayAsleep treats a closed lid as asleep even when an external display
+// is driving the session, since clamshell mode usually means a presentation
+// or docked work that heavy cleanup would disturb.
+func displayAsleep(ctx context.Context) bool {
+	if output, err := runCommand(ctx, "ioreg", "-r", "-k", "AppleClamshellState", "-d", "1"); err == nil && parseClamshellClosed(string(output)) {
+		return true
+	}
+	output, err := runCommand(ctx, "pmset", "-g", "powerstate", "IODisplayWrangler")
+	if err != nil {
+		return false
+	}
+	return parseDisplayWranglerAsleep(string(output))
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package power
+
+import "context"
+
+// displayAsleep has no probe outside macOS and reports the display as awake.
+func displayAsleep(ctx context.Context) bool {
+	return false
+}
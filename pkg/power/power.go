@@ -0,0 +1,62 @@
+// Package power probes host power and display state so the daemon can defer
+// heavy cleanup while the machine is in use in ways that should not be
+// disturbed, such as clamshell mode during a presentation.
+package power
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds each external probe command.
+const probeTimeout = 5 * time.Second
+
+// runCommand runs a probe command and returns its stdout. Tests replace it.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// DisplayAsleep reports whether the built-in display is asleep or the lid is
+// closed. It returns false when the state cannot be determined, including on
+// platforms without a probe.
+func DisplayAsleep() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	return displayAsleep(ctx)
+}
+
+// parseClamshellClosed reads `ioreg -r -k AppleClamshellState` output and
+// reports whether the lid is closed.
+func parseClamshellClosed(output string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, `"AppleClamshellState"`) {
+			continue
+		}
+		_, value, ok := strings.Cut(line, "=")
+		return ok && strings.TrimSpace(value) == "Yes"
+	}
+	return false
+}
+
+// parseDisplayWranglerAsleep reads `pmset -g powerstate IODisplayWrangler`
+// output and reports whether the display is off or asleep. The wrangler's
+// current state is 4 when on, 2-3 when dimmed, and 0-1 when off or asleep;
+// dimmed displays count as awake.
+func parseDisplayWranglerAsleep(output string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "IODisplayWrangler" {
+			continue
+		}
+		current, err := strconv.Atoi(fields[2])
+		return err == nil && current <= 1
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package power
+
+import "testing"
+
+func TestParseClamshellClosed(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"+-o AppleARMPE  <class AppleARMPE>\n    {\n      \"AppleClamshellState\" = Yes\n      \"AppleClamshellCausesSleep\" = No\n    }\n", true},
+		{"    {\n      \"AppleClamshellState\" = No\n    }\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := parseClamshellClosed(tt.output); got != tt.want {
+			t.Errorf("parseClamshellClosed(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestParseDisplayWranglerAsleep(t *testing.T) {
+	header := "Driver                      Max   Current   Current Description\n"
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{header + "IODisplayWrangler             4         4   USEABLE\n", false},
+		{header + "IODisplayWrangler             4         3   USEABLE\n", false},
+		{header + "IODisplayWrangler             4         1   SLEEP\n", true},
+		{header + "IODisplayWrangler             4         0   OFF\n", true},
+		{header, false},
+		{"IODisplayWrangler not found\n", false},
+	}
+	for _, tt := range tests {
+		if got := parseDisplayWranglerAsleep(tt.output); got != tt.want {
+			t.Errorf("parseDisplayWranglerAsleep(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
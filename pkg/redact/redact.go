@@ -0,0 +1,194 @@
+// Package redact masks file paths in logs and reports so diagnostics can be
+// shared without leaking usernames or project names.
+//
+// The home directory becomes "~" and user directories under /Users and /home
+// become "<user>". With project names enabled, path segments below the first
+// level under "~" (or the second level elsewhere) are replaced by short
+// stable hashes, so the same project maps to the same token across lines.
+// Well-known tool directory names such as node_modules are kept, since they
+// explain what was cleaned without identifying the project.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// UserToken replaces usernames in paths.
+const UserToken = "<user>"
+
+// pathPattern matches absolute and home-relative paths embedded in free text.
+// The first group is the boundary before the path.
+var pathPattern = regexp.MustCompile(`(^|[\s"'=(\[,])((?:~|/)[^\s"'<>()\[\],;]*)`)
+
+// keptSegments are tool directory names that describe a cleanup target
+// without identifying the project that contains it.
+var keptSegments = map[string]bool{
+	".cache":              true,
+	".git":                true,
+	".lima":               true,
+	".mypy_cache":         true,
+	".pytest_cache":       true,
+	".ruff_cache":         true,
+	".tox":                true,
+	".venv":               true,
+	".zig-cache":          true,
+	"Application Support": true,
+	"Caches":              true,
+	"Containers":          true,
+	"DerivedData":         true,
+	"Library":             true,
+	"Logs":                true,
+	"__pycache__":         true,
+	"go-build":            true,
+	"node_modules":        true,
+	"target":              true,
+	"zig-out":             true,
+}
+
+// Options configures a Redactor.
+type Options struct {
+	// Home is replaced by "~".
+	Home string
+	// Usernames are masked wherever they appear as a path segment.
+	Usernames []string
+	// ProjectNames hashes path segments that may name projects or files.
+	ProjectNames bool
+}
+
+// Redactor masks paths in strings. A nil Redactor leaves input unchanged.
+type Redactor struct {
+	home         string
+	usernames    map[string]bool
+	projectNames bool
+}
+
+// New returns a Redactor for opts.
+func New(opts Options) *Redactor {
+	r := &Redactor{
+		home:         strings.TrimSuffix(opts.Home, "/"),
+		usernames:    map[string]bool{},
+		projectNames: opts.ProjectNames,
+	}
+	for _, name := range opts.Usernames {
+		if name != "" {
+			r.usernames[name] = true
+		}
+	}
+	return r
+}
+
+// Path redacts a single path.
+func (r *Redactor) Path(path string) string {
+	if r == nil || path == "" {
+		return path
+	}
+
+	underHome := false
+	rest := path
+	switch {
+	case r.home != "" && r.home != "/" && (path == r.home || strings.HasPrefix(path, r.home+"/")):
+		underHome = true
+		rest = strings.TrimPrefix(path[len(r.home):], "/")
+	case path == "~" || strings.HasPrefix(path, "~/"):
+		underHome = true
+		rest = strings.TrimPrefix(strings.TrimPrefix(path, "~"), "/")
+	case strings.HasPrefix(path, "/"):
+		rest = path[1:]
+	default:
+		return path
+	}
+
+	var segments []string
+	if rest != "" {
+		segments = strings.Split(rest, "/")
+	}
+
+	// Keep the top of the tree readable, e.g. ~/git or /var/lib.
+	keep := 2
+	if underHome {
+		keep = 1
+	} else if len(segments) > 1 && (segments[0] == "Users" || segments[0] == "home") && segments[1] != "Shared" {
+		segments[1] = UserToken
+		keep = 2
+	}
+
+	for i, segment := range segments {
+		switch {
+		case segment == "" || segment == UserToken:
+		case r.usernames[segment]:
+			segments[i] = UserToken
+		case r.projectNames && i >= keep && !keptSegments[segment]:
+			segments[i] = hashSegment(segment)
+		}
+	}
+
+	prefix := "/"
+	if underHome {
+		prefix = "~"
+		if len(segments) > 0 {
+			prefix = "~/"
+		}
+	}
+	return prefix + strings.Join(segments, "/")
+}
+
+// String redacts every path embedded in s.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	return pathPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := pathPattern.FindStringSubmatch(match)
+		return groups[1] + r.Path(groups[2])
+	})
+}
+
+// Value redacts strings inside a decoded JSON value, leaving numbers, bools,
+// and map keys unchanged.
+func (r *Redactor) Value(v any) any {
+	if r == nil {
+		return v
+	}
+	switch value := v.(type) {
+	case string:
+		return r.String(value)
+	case []any:
+		for i := range value {
+			value[i] = r.Value(value[i])
+		}
+		return value
+	case map[string]any:
+		for key := range value {
+			value[key] = r.Value(value[key])
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// ReplaceAttr is a slog.HandlerOptions.ReplaceAttr hook that redacts string
+// and error attribute values, including the log message.
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if r == nil {
+		return a
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(r.String(err.Error()))
+		}
+	}
+	return a
+}
+
+func hashSegment(segment string) string {
+	sum := sha256.Sum256([]byte(segment))
+	return "h:" + hex.EncodeToString(sum[:4])
+}
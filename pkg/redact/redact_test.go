@@ -0,0 +1,89 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestPathMasksHomeAndUsernames(t *testing.T) {
+	r := New(Options{Home: "/Users/alice", Usernames: []string{"alice"}})
+	tests := map[string]string{
+		"/Users/alice": "~",
+		"/Users/alice/git/secret-app/node_modules": "~/git/secret-app/node_modules",
+		"/Users/bob/Library/Caches":                "/Users/<user>/Library/Caches",
+		"/home/carol/.cache/pip":                   "/home/<user>/.cache/pip",
+		"/var/lib/alice/data":                      "/var/lib/<user>/data",
+		"/Users/Shared/data":                       "/Users/Shared/data",
+		"/":                                        "/",
+		"relative/path":                            "relative/path",
+	}
+	for input, want := range tests {
+		if got := r.Path(input); got != want {
+			t.Errorf("Path(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestPathHashesProjectNames(t *testing.T) {
+	r := New(Options{Home: "/Users/alice", ProjectNames: true})
+	got := r.Path("/Users/alice/git/secret-app/packages/billing/node_modules")
+	if strings.Contains(got, "secret-app") || strings.Contains(got, "billing") {
+		t.Fatalf("project names leaked: %q", got)
+	}
+	if !strings.HasPrefix(got, "~/git/h:") || !strings.HasSuffix(got, "/node_modules") {
+		t.Fatalf("expected top-level and tool names kept, got %q", got)
+	}
+	if again := r.Path("/Users/alice/git/secret-app/packages/billing/node_modules"); again != got {
+		t.Fatalf("hashes are not stable: %q vs %q", again, got)
+	}
+	if file := r.Path("/var/tmp/customer-export.tar"); strings.Contains(file, "customer-export") {
+		t.Fatalf("file name leaked: %q", file)
+	}
+}
+
+func TestStringRedactsEmbeddedPaths(t *testing.T) {
+	r := New(Options{Home: "/Users/alice", Usernames: []string{"alice"}, ProjectNames: true})
+	got := r.String(`remove /Users/alice/git/secret-app/.venv: permission denied (path="/Users/alice/src/acme")`)
+	for _, leaked := range []string{"alice", "secret-app", "acme"} {
+		if strings.Contains(got, leaked) {
+			t.Fatalf("%q leaked in %q", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "permission denied") || !strings.Contains(got, "~/git/") {
+		t.Fatalf("unexpected redaction %q", got)
+	}
+	if plain := r.String("dev-artifacts freed 12 MB"); plain != "dev-artifacts freed 12 MB" {
+		t.Fatalf("text without paths changed: %q", plain)
+	}
+}
+
+func TestReplaceAttrRedactsLogOutput(t *testing.T) {
+	r := New(Options{Home: "/Users/alice", Usernames: []string{"alice"}, ProjectNames: true})
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{ReplaceAttr: r.ReplaceAttr}))
+	logger.Info("removing /Users/alice/git/secret-app/target",
+		"path", "/Users/alice/git/secret-app/target",
+		"error", errors.New("open /Users/alice/git/secret-app/target/x: busy"),
+		"size_mb", 512,
+		"plugin", "dev-artifacts")
+
+	line := out.String()
+	for _, leaked := range []string{"alice", "secret-app"} {
+		if strings.Contains(line, leaked) {
+			t.Fatalf("%q leaked in log line %q", leaked, line)
+		}
+	}
+	if !strings.Contains(line, "size_mb=512") || !strings.Contains(line, "plugin=dev-artifacts") {
+		t.Fatalf("sizes and plugin names should be kept: %q", line)
+	}
+}
+
+func TestNilRedactorIsNoop(t *testing.T) {
+	var r *Redactor
+	if got := r.String("/Users/alice/git/app"); got != "/Users/alice/git/app" {
+		t.Fatalf("nil redactor changed input: %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// errFullDiskAccess means tmutil was refused by macOS privacy protection.
+// Without Full Disk Access, listlocalsnapshots fails or prints nothing, which
+// otherwise reads as "no snapshots".
+var errFullDiskAccess = errors.New("tinyland-cleanup needs Full Disk Access to manage APFS snapshots; grant it in System Settings > Privacy & Security > Full Disk Access")
+
+// classifyTmutilList turns the result of tmutil listlocalsnapshots into
+// errFullDiskAccess when the failure is a privacy refusal (EPERM, "Operation
+// not permitted"), including the case where tmutil exits 0 with empty output
+// and the refusal only on stderr. Other failures are wrapped as is; a clean
+// run with empty output means there are genuinely no snapshots.
+func classifyTmutilList(stdout, stderr []byte, err error) error {
+	denied := errors.Is(err, syscall.EPERM) ||
+		strings.Contains(strings.ToLower(string(stderr)), "operation not permitted")
+	switch {
+	case denied && strings.TrimSpace(string(stdout)) == "":
+		return errFullDiskAccess
+	case err != nil:
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return fmt.Errorf("tmutil listlocalsnapshots failed: %w (output: %s)", err, msg)
+		}
+		return fmt.Errorf("tmutil listlocalsnapshots failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyTmutilList(t *testing.T) {
+	exitErr := &exec.ExitError{}
+	denied := []byte("Unable to list snapshots: Operation not permitted\n")
+
+	if err := classifyTmutilList(nil, denied, exitErr); !errors.Is(err, errFullDiskAccess) {
+		t.Fatalf("expected a Full Disk Access error for a refused run, got %v", err)
+	}
+	if err := classifyTmutilList(nil, denied, nil); !errors.Is(err, errFullDiskAccess) {
+		t.Fatalf("expected a Full Disk Access error for empty output with a refusal, got %v", err)
+	}
+	if err := classifyTmutilList(nil, nil, syscall.EPERM); !errors.Is(err, errFullDiskAccess) {
+		t.Fatalf("expected EPERM to mean Full Disk Access, got %v", err)
+	}
+	if err := classifyTmutilList(nil, nil, nil); err != nil {
+		t.Fatalf("empty output without an error means no snapshots, got %v", err)
+	}
+	if err := classifyTmutilList(nil, []byte("disk not found"), exitErr); err == nil || errors.Is(err, errFullDiskAccess) {
+		t.Fatalf("expected an ordinary failure, got %v", err)
+	}
+	listed := []byte("com.apple.TimeMachine.2026-01-15-123456.local\n")
+	if err := classifyTmutilList(listed, denied, nil); err != nil {
+		t.Fatalf("listed snapshots should not be treated as a refusal, got %v", err)
+	}
+}
@@ -6,7 +6,9 @@
 package plugins
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os/exec"
@@ -24,6 +26,8 @@ const apfsGiB = int64(1024 * 1024 * 1024)
 // APFSPlugin handles APFS snapshot thinning and Time Machine cleanup.
 type APFSPlugin struct {
 	sudoCap *SudoCapability
+	// fdaWarned keeps the Full Disk Access warning to once per process.
+	fdaWarned bool
 }
 
 // NewAPFSPlugin creates a new APFS snapshot cleanup plugin.
@@ -41,6 +45,11 @@ func (p *APFSPlugin) Description() string {
 	return "Thins APFS local snapshots and Time Machine caches to reclaim disk space"
 }
 
+// Priority runs APFS snapshot deletion last; snapshots are not recoverable.
+func (p *APFSPlugin) Priority() int {
+	return 100
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *APFSPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -51,6 +60,22 @@ func (p *APFSPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.APFSSnapshots
 }
 
+// LevelDescription summarizes APFS snapshot cleanup at each level.
+func (p *APFSPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports local APFS snapshots only"
+	case LevelModerate:
+		return "requests 5 GiB of snapshot thinning at urgency 1 with passwordless sudo"
+	case LevelAggressive:
+		return "requests 20 GiB of snapshot thinning at urgency 3 with passwordless sudo"
+	case LevelCritical:
+		return "requests max_thin_gb of snapshot thinning at urgency 4 and deletes snapshots older than keep_recent_days when delete_os_updates is true"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup returns a non-mutating APFS snapshot cleanup plan.
 func (p *APFSPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	apfsCfg := cfg.APFS
@@ -87,6 +112,14 @@ func (p *APFSPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *c
 	plan.Metadata["sudo_passwordless"] = strconv.FormatBool(p.sudoCap.Passwordless)
 
 	snapshots, err := p.listSnapshots(ctx)
+	if errors.Is(err, errFullDiskAccess) {
+		plan.Summary = "APFS snapshots cannot be inspected without Full Disk Access"
+		plan.WouldRun = false
+		plan.SkipReason = "full_disk_access_required"
+		plan.Metadata["full_disk_access"] = "false"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 	if err != nil {
 		plan.Summary = "APFS snapshots could not be inspected"
 		plan.WouldRun = false
@@ -161,6 +194,15 @@ func (p *APFSPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 
 	// List current snapshots
 	snapshots, err := p.listSnapshots(ctx)
+	if errors.Is(err, errFullDiskAccess) {
+		if !p.fdaWarned {
+			p.fdaWarned = true
+			logger.Warn(err.Error())
+		} else {
+			logger.Debug("APFS snapshots skipped without Full Disk Access")
+		}
+		return result
+	}
 	if err != nil {
 		logger.Debug("failed to list snapshots", "error", err)
 		return result
@@ -220,12 +262,15 @@ func (p *APFSPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 		result = p.thinSnapshots(ctx, maxThinGB, 4, logger)
 
 		// Delete old pre-update snapshots if configured
-		if apfsCfg.DeleteOSUpdates {
+		if apfsCfg.DeleteOSUpdates && cooldownAllows(cfg, p.Name(), "delete_snapshots", logger) {
 			keepDays := apfsCfg.KeepRecentDays
 			if keepDays <= 0 {
 				keepDays = 1
 			}
 			deleteResult := p.deleteOldSnapshots(ctx, snapshots, keepDays, logger)
+			if deleteResult.ItemsCleaned > 0 {
+				recordCooldown(cfg, p.Name(), "delete_snapshots", logger)
+			}
 			result.BytesFreed += deleteResult.BytesFreed
 			result.ItemsCleaned += deleteResult.ItemsCleaned
 		}
@@ -248,12 +293,15 @@ func (p *APFSPlugin) listSnapshots(ctx context.Context) ([]snapshotInfo, error)
 	defer cancel()
 
 	cmd := exec.CommandContext(listCtx, "tmutil", "listlocalsnapshots", "/")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("tmutil listlocalsnapshots failed: %w", err)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if err := classifyTmutilList(stdout.Bytes(), stderr.Bytes(), runErr); err != nil {
+		return nil, err
 	}
 
-	return parseSnapshotList(string(output)), nil
+	return parseSnapshotList(stdout.String()), nil
 }
 
 // parseSnapshotList parses tmutil listlocalsnapshots output.
@@ -37,6 +37,13 @@ func TestAPFSPluginInterface(t *testing.T) {
 	if p.Enabled(cfg) {
 		t.Error("expected APFSSnapshots to be disabled when flag is false")
 	}
+
+	if got := PluginPriority(p); got != 100 {
+		t.Errorf("expected snapshot deletion at priority 100, got %d", got)
+	}
+	if got := PluginPriority(NewLimaPlugin()); got != 100 {
+		t.Errorf("expected Lima VM work at priority 100, got %d", got)
+	}
 }
 
 func TestParseSnapshotList(t *testing.T) {
@@ -0,0 +1,409 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// AppLogsPlugin removes old application logs from ~/Library/Logs and a
+// curated set of Application Support log directories. Only files named
+// *.log or *.log.N are touched, so databases and settings beside them are
+// never removed. Xcode's own logs are left to the xcode plugin.
+type AppLogsPlugin struct{}
+
+// appLogName matches foo.log and rotated foo.log.1, foo.log.12.
+var appLogName = regexp.MustCompile(`\.log(\.[0-9]+)?$`)
+
+// appSupportLogDirs are Application Support log directories of apps known
+// to write unbounded logs, with the process name that marks the app running.
+var appSupportLogDirs = []struct {
+	Dir string
+	App string
+}{
+	{Dir: "Code/logs", App: "Visual Studio Code"},
+	{Dir: "Code - Insiders/logs", App: "Visual Studio Code - Insiders"},
+	{Dir: "Cursor/logs", App: "Cursor"},
+	{Dir: "Slack/logs", App: "Slack"},
+}
+
+// appLogLocation is one directory swept for logs. App names the process
+// whose logs these are, when known; the location is skipped while it runs.
+type appLogLocation struct {
+	Path string
+	App  string
+	// Recursive sweeps subdirectories too. ~/Library/Logs itself is swept
+	// flat, since each subdirectory is its own location.
+	Recursive bool
+}
+
+// appLogSweep totals the old logs found in one location.
+type appLogSweep struct {
+	Files int
+	Bytes int64
+	// Compressed logs were gzipped in place, saving SavedBytes.
+	Compressed int
+	SavedBytes int64
+}
+
+// NewAppLogsPlugin creates a new application log cleanup plugin.
+func NewAppLogsPlugin() *AppLogsPlugin {
+	return &AppLogsPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *AppLogsPlugin) Name() string {
+	return "app-logs"
+}
+
+// Description returns the plugin description.
+func (p *AppLogsPlugin) Description() string {
+	return "Removes old application logs from ~/Library/Logs and Application Support"
+}
+
+// Priority runs log cleanup early; logs are never needed to rebuild anything.
+func (p *AppLogsPlugin) Priority() int {
+	return 14
+}
+
+// SupportedPlatforms returns supported platforms (Darwin only).
+func (p *AppLogsPlugin) SupportedPlatforms() []string {
+	return []string{PlatformDarwin}
+}
+
+// Enabled checks if application log cleanup is enabled.
+func (p *AppLogsPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.AppLogs
+}
+
+// LevelDescription summarizes application log cleanup at each level.
+func (p *AppLogsPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports *.log and *.log.N files older than app_logs.max_age_days only"
+	case LevelModerate, LevelAggressive, LevelCritical:
+		return "removes *.log and *.log.N files older than app_logs.max_age_days under ~/Library/Logs and app log directories, or gzips them in place with app_logs.compress_instead_of_delete, skipping running apps and protect paths"
+	default:
+		return "no cleanup"
+	}
+}
+
+// ValidateAppLogsConfig rejects a non-positive max_age_days, and a
+// min_compression_ratio below 1 when compress_instead_of_delete is set.
+func ValidateAppLogsConfig(cfg config.AppLogsConfig) error {
+	if cfg.MaxAgeDays <= 0 {
+		return fmt.Errorf("app_logs.max_age_days must be positive, got %d", cfg.MaxAgeDays)
+	}
+	if cfg.CompressInsteadOfDelete {
+		return validateCompressionRatio("app_logs.min_compression_ratio", cfg.MinCompressionRatio)
+	}
+	return nil
+}
+
+// appLogLocations returns ~/Library/Logs, each of its subdirectories, the
+// curated Application Support log directories, and app_logs.extra_paths.
+func appLogLocations(cfg *config.Config, home string) []appLogLocation {
+	logsDir := filepath.Join(home, "Library", "Logs")
+	locations := []appLogLocation{{Path: logsDir}}
+	if entries, err := os.ReadDir(logsDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				locations = append(locations, appLogLocation{
+					Path:      filepath.Join(logsDir, entry.Name()),
+					App:       entry.Name(),
+					Recursive: true,
+				})
+			}
+		}
+	}
+	support := filepath.Join(home, "Library", "Application Support")
+	for _, dir := range appSupportLogDirs {
+		locations = append(locations, appLogLocation{Path: filepath.Join(support, dir.Dir), App: dir.App, Recursive: true})
+	}
+	for _, path := range cfg.AppLogs.ExtraPaths {
+		path = expandHome(path, home)
+		locations = append(locations, appLogLocation{Path: path, App: appLogDirApp(path), Recursive: true})
+	}
+	return locations
+}
+
+// appLogDirApp names the app owning a log directory: the directory's own
+// name, or its parent's when it is just called logs.
+func appLogDirApp(path string) string {
+	name := filepath.Base(path)
+	if strings.EqualFold(name, "logs") || strings.EqualFold(name, "log") {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	return name
+}
+
+// runningProcesses returns the lowercased command of every process, or nil
+// when ps is unavailable.
+func runningProcesses(ctx context.Context) []string {
+	psCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	output, err := runner.Output(psCtx, nil, "ps", "-axo", "comm=")
+	if err != nil {
+		return nil
+	}
+	var commands []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			commands = append(commands, strings.ToLower(line))
+		}
+	}
+	return commands
+}
+
+// appRunning reports whether any process command mentions app.
+func appRunning(processes []string, app string) bool {
+	if app == "" {
+		return false
+	}
+	app = strings.ToLower(app)
+	for _, command := range processes {
+		if strings.Contains(command, app) {
+			return true
+		}
+	}
+	return false
+}
+
+// sweepAppLogs finds *.log and *.log.N regular files in location modified
+// before cutoff, on the location's filesystem and outside protect paths,
+// removing them when remove is set, or gzipping them in place with
+// compress. Symlinks are never followed or removed.
+func sweepAppLogs(ctx context.Context, location appLogLocation, cutoff time.Time, protect []string, remove bool, compress *fileCompressor) (appLogSweep, error) {
+	var sweep appLogSweep
+	rootDev, err := deviceID(location.Path)
+	if err != nil {
+		return sweep, err
+	}
+	err = filepath.WalkDir(location.Path, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if path != location.Path && isProtectedPath(path, protect) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if path == location.Path {
+				return nil
+			}
+			if !location.Recursive {
+				return filepath.SkipDir
+			}
+			info, err := entry.Info()
+			if err != nil {
+				walkErrors.note(err)
+				return filepath.SkipDir
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); !ok || uint64(stat.Dev) != rootDev {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !appLogName.MatchString(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if remove && compress != nil {
+			saved, err := compress.compress(path, info)
+			if err != nil {
+				if !errors.Is(err, errCompressSkipped) {
+					walkErrors.note(err)
+				}
+				return nil
+			}
+			sweep.Compressed++
+			sweep.SavedBytes += saved
+			return nil
+		}
+		if remove {
+			if err := os.Remove(path); err != nil {
+				walkErrors.note(err)
+				return nil
+			}
+		}
+		sweep.Files++
+		sweep.Bytes += accountedFileBytes(info)
+		return nil
+	})
+	return sweep, err
+}
+
+// appLogProtectPaths returns dev_artifacts.protect_paths expanded against home.
+func appLogProtectPaths(cfg *config.Config, home string) []string {
+	protect := make([]string, 0, len(cfg.DevArtifacts.ProtectPaths))
+	for _, path := range cfg.DevArtifacts.ProtectPaths {
+		protect = append(protect, expandHome(path, home))
+	}
+	return protect
+}
+
+// PlanCleanup reports old logs per location.
+func (p *AppLogsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Application log cleanup plan",
+		WouldRun: level >= LevelModerate,
+		Steps: []string{
+			fmt.Sprintf("Find *.log and *.log.N files not modified for %d days under ~/Library/Logs, app log directories, and app_logs.extra_paths", cfg.AppLogs.MaxAgeDays),
+			"Skip the logs of running apps, dev_artifacts.protect_paths, symlinks, and other mounts",
+		},
+		Metadata: map[string]string{
+			"cleanup_level": level.String(),
+			"max_age_days":  strconv.Itoa(cfg.AppLogs.MaxAgeDays),
+		},
+	}
+	compressMode := cfg.AppLogs.CompressInsteadOfDelete
+	if compressMode {
+		plan.Metadata["compress_instead_of_delete"] = "true"
+	}
+	if level >= LevelModerate && compressMode {
+		plan.Steps = append(plan.Steps, fmt.Sprintf("Gzip the old logs in place where that makes them at least %gx smaller, skipping logs open for writing, and report bytes saved per location", cfg.AppLogs.MinCompressionRatio))
+	} else if level >= LevelModerate {
+		plan.Steps = append(plan.Steps, "Remove the old logs and report bytes freed per location")
+	} else {
+		plan.SkipReason = "below_moderate_level"
+	}
+	if err := ValidateAppLogsConfig(cfg.AppLogs); err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "invalid_config"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -cfg.AppLogs.MaxAgeDays)
+	protect := appLogProtectPaths(cfg, home)
+	processes := runningProcesses(ctx)
+	var files int
+	for _, location := range appLogLocations(cfg, home) {
+		if !pathExistsAndIsDir(location.Path) {
+			continue
+		}
+		sweep, err := sweepAppLogs(ctx, location, cutoff, protect, false, nil)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", location.Path, err))
+			continue
+		}
+		if sweep.Files == 0 {
+			continue
+		}
+		target := CleanupTarget{
+			Type:      "app-logs",
+			Name:      filepath.Base(location.Path),
+			Path:      location.Path,
+			Bytes:     sweep.Bytes,
+			Action:    "delete_old_logs",
+			Protected: level < LevelModerate,
+			Reason:    fmt.Sprintf("%d log files older than %d days", sweep.Files, cfg.AppLogs.MaxAgeDays),
+		}
+		if compressMode {
+			target.Action = "compress_old_logs"
+		}
+		if appRunning(processes, location.App) {
+			target.Protected = true
+			target.Reason += fmt.Sprintf("; %s is running", location.App)
+		}
+		if target.Protected {
+			target.Action = "report"
+		}
+		annotateCleanupTargetPolicy(&target, CleanupTierSafe, hostReclaimForAction(target.Action))
+		plan.Targets = append(plan.Targets, target)
+		files += sweep.Files
+		if !target.Protected && !compressMode {
+			plan.EstimatedBytesFreed += sweep.Bytes
+		}
+	}
+	plan.Metadata["log_file_count"] = strconv.Itoa(files)
+	return plan
+}
+
+// Cleanup removes old logs at Moderate and above, logging bytes freed per
+// location.
+func (p *AppLogsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	if level < LevelModerate {
+		return result
+	}
+	if err := ValidateAppLogsConfig(cfg.AppLogs); err != nil {
+		logger.Warn("skipping application log cleanup", "error", err)
+		return result
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping application log cleanup: home directory unavailable", "error", err)
+		return result
+	}
+
+	skipped := walkErrors.snapshot()
+	cutoff := time.Now().AddDate(0, 0, -cfg.AppLogs.MaxAgeDays)
+	protect := appLogProtectPaths(cfg, home)
+	processes := runningProcesses(ctx)
+	var compress *fileCompressor
+	if cfg.AppLogs.CompressInsteadOfDelete {
+		compress = newFileCompressor(ctx, cfg.AppLogs.MinCompressionRatio)
+	}
+	for _, location := range appLogLocations(cfg, home) {
+		if ctx.Err() != nil {
+			break
+		}
+		if !pathExistsAndIsDir(location.Path) {
+			continue
+		}
+		if appRunning(processes, location.App) {
+			logger.Debug("skipping logs of running app", "path", location.Path, "app", location.App)
+			continue
+		}
+		sweep, err := sweepAppLogs(ctx, location, cutoff, protect, true, compress)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("application log cleanup failed", "path", location.Path, "error", err)
+		}
+		result.BytesFreed += sweep.Bytes
+		result.CompressedBytesSaved += sweep.SavedBytes
+		result.ItemsCleaned += sweep.Files + sweep.Compressed
+		if sweep.Compressed > 0 {
+			logger.Info("compressed old application logs", "path", location.Path, "files", sweep.Compressed, "saved_mb", sweep.SavedBytes/(1024*1024))
+		}
+		if sweep.Files > 0 {
+			logger.Info("removed old application logs", "path", location.Path, "files", sweep.Files, "freed_mb", sweep.Bytes/(1024*1024))
+		}
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestAppLogsCleanupRemovesOnlyOldLogs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	old := time.Now().AddDate(0, 0, -30)
+	write := func(rel string, size int, modTime time.Time) string {
+		path := filepath.Join(home, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	loose := write("Library/Logs/fsck_apfs.log", 100, old)
+	rotated := write("Library/Logs/Homebrew/node/01.brew.log.3", 200, old)
+	code := write("Library/Application Support/Code/logs/20260101/main.log", 300, old)
+	recent := write("Library/Logs/Homebrew/update.log", 400, time.Now())
+	database := write("Library/Logs/Homebrew/state.db", 500, old)
+	running := write("Library/Application Support/Slack/logs/default/browser.log", 600, old)
+	protected := write("Library/Logs/Keep/keep.log", 700, old)
+	nested := write("Library/Logs/Archive/deep.log", 800, old)
+
+	useFakeRunner(t, map[string]fakeResponse{
+		"ps -axo comm=": {Output: "/Applications/Slack.app/Contents/MacOS/Slack\n/usr/sbin/cfprefsd\n"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Enable.AppLogs = true
+	cfg.DevArtifacts.ProtectPaths = []string{filepath.Join(home, "Library", "Logs", "Keep")}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewAppLogsPlugin()
+
+	if result := plugin.Cleanup(context.Background(), LevelWarning, cfg, logger); result.ItemsCleaned != 0 || !pathExists(loose) {
+		t.Fatalf("warning level should not remove anything, got %+v", result)
+	}
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.EstimatedBytesFreed != 1400 || plan.Metadata["log_file_count"] != "5" {
+		t.Fatalf("unexpected plan: estimate %d, metadata %v", plan.EstimatedBytesFreed, plan.Metadata)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.ItemsCleaned != 4 || result.BytesFreed != 1400 {
+		t.Fatalf("expected four logs and 1400 bytes, got %+v", result)
+	}
+	for _, removed := range []string{loose, rotated, code, nested} {
+		if pathExists(removed) {
+			t.Errorf("expected %s to be removed", removed)
+		}
+	}
+	for _, kept := range []string{recent, database, running, protected} {
+		if !pathExists(kept) {
+			t.Errorf("expected %s to be kept", kept)
+		}
+	}
+}
+
+func TestValidateAppLogsConfig(t *testing.T) {
+	if err := ValidateAppLogsConfig(config.AppLogsConfig{MaxAgeDays: 7}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateAppLogsConfig(config.AppLogsConfig{MaxAgeDays: 0}); err == nil {
+		t.Fatal("expected max_age_days 0 to be rejected")
+	}
+	if err := ValidateAppLogsConfig(config.AppLogsConfig{MaxAgeDays: 7, CompressInsteadOfDelete: true, MinCompressionRatio: 0.9}); err == nil {
+		t.Fatal("expected min_compression_ratio below 1 to be rejected")
+	}
+}
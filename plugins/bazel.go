@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 const bazelGiB = int64(1024 * 1024 * 1024)
@@ -63,6 +64,21 @@ func (p *BazelPlugin) Description() string {
 	return "Cleans stale Bazel output bases and reports repository, disk, and Bazelisk cache policy"
 }
 
+// Priority runs Bazel cleanup after other rebuildable caches; cold builds are expensive.
+func (p *BazelPlugin) Priority() int {
+	return 50
+}
+
+// HeavyAt reports Bazel cleanup as heavy; sizing output bases walks large trees.
+func (p *BazelPlugin) HeavyAt(level CleanupLevel, cfg *config.Config) bool {
+	return true
+}
+
+// FreedBytesVolume reports that output-base deletions land on the monitored volume.
+func (p *BazelPlugin) FreedBytesVolume(cfg *config.Config) string {
+	return ""
+}
+
 // SupportedPlatforms returns supported platforms (all).
 func (p *BazelPlugin) SupportedPlatforms() []string {
 	return nil
@@ -73,6 +89,22 @@ func (p *BazelPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Bazel
 }
 
+// LevelDescription summarizes Bazel cleanup at each level.
+func (p *BazelPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports Bazel output base and cache footprint only"
+	case LevelModerate:
+		return "deletes inactive output bases older than stale_after outside keep_recent_output_bases and protected workspaces, plus stale cache tiers when over max_total_gb"
+	case LevelAggressive:
+		return "runs moderate cleanup and may stop idle Bazel servers before deleting their stale output bases when allow_stop_idle_servers is true"
+	case LevelCritical:
+		return "runs aggressive cleanup using the shorter critical_stale_after threshold"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup returns a dry-run plan without mutating Bazel state.
 func (p *BazelPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	plan, _ := p.buildCleanupPlan(ctx, level, cfg, logger)
@@ -104,7 +136,15 @@ func (p *BazelPlugin) buildCleanupPlan(ctx context.Context, level CleanupLevel,
 		},
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Bazel cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan, nil
+	}
+
 	activeInfo, activeErr := p.activeBazelProcessInfo(ctx)
 	if activeErr != nil {
 		plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not inspect active Bazel processes: %v", activeErr))
@@ -155,7 +195,11 @@ func (p *BazelPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 		return result
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping Bazel cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	result = applyBazelCleanupTargets(ctx, p.Name(), level, plan.Targets, cfg.Bazel.WorkspaceRoots, home, logger)
 	if result.ItemsCleaned == 0 {
 		logger.Info("Bazel cleanup found no eligible stale inactive output bases", "level", level.String())
@@ -407,6 +451,9 @@ func newBazelCandidate(candidateType, name, path string, modTime time.Time) baze
 	if resolved, err := filepath.EvalSymlinks(path); err == nil {
 		path = resolved
 	}
+	if candidateType == "output_base" {
+		modTime = bazelOutputBaseLastUsed(path, modTime)
+	}
 	logical, physical := estimateBazelCandidateBytes(path)
 	return bazelCandidate{
 		Type:     candidateType,
@@ -418,6 +465,19 @@ func newBazelCandidate(candidateType, name, path string, modTime time.Time) baze
 	}
 }
 
+// bazelOutputBaseLastUsed returns the newest of modTime, the output base's
+// lock file, and its DO_NOT_BUILD_HERE workspace marker. Rebuilds in an
+// existing output base rarely add top-level entries, so the directory's own
+// mtime understates recent use.
+func bazelOutputBaseLastUsed(path string, modTime time.Time) time.Time {
+	for _, name := range []string{"lock", "DO_NOT_BUILD_HERE"} {
+		if info, err := os.Stat(filepath.Join(path, name)); err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime
+}
+
 func estimateBazelCandidateBytes(path string) (int64, int64) {
 	var logical int64
 	var physical int64
@@ -583,6 +643,9 @@ func newestBazelOutputBases(candidates []bazelCandidate, keep int) map[string]bo
 	}
 
 	sort.Slice(outputBases, func(i, j int) bool {
+		if outputBases[i].ModTime.Equal(outputBases[j].ModTime) {
+			return outputBases[i].Path < outputBases[j].Path
+		}
 		return outputBases[i].ModTime.After(outputBases[j].ModTime)
 	})
 	for idx, candidate := range outputBases {
@@ -44,6 +44,32 @@ func TestDiscoverBazelRootCandidates(t *testing.T) {
 	}
 }
 
+func TestDiscoverBazelOutputBasesUsesWorkspaceMarkerForLastUse(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "_bazel_jess")
+	outputBase := filepath.Join(root, "abc123")
+	makeBazelOutputBase(t, outputBase)
+	marker := filepath.Join(outputBase, "DO_NOT_BUILD_HERE")
+	if err := os.WriteFile(marker, []byte("/home/jess/git/app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(marker, recent, recent); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(outputBase, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates := discoverBazelOutputBases(root)
+	if len(candidates) != 1 {
+		t.Fatalf("expected one output base, got %#v", candidates)
+	}
+	if !candidates[0].ModTime.Equal(recent) {
+		t.Fatalf("expected last use from workspace marker %s, got %s", recent, candidates[0].ModTime)
+	}
+}
+
 func TestDiscoverBazelCandidatesIncludesProcessOutputBases(t *testing.T) {
 	root := t.TempDir()
 	outputBase := filepath.Join(root, "process-ob")
@@ -379,6 +405,21 @@ func TestOutputBasesProtectedByWorkspaces(t *testing.T) {
 	}
 }
 
+func TestNewestBazelOutputBasesBreaksModTimeTiesByPath(t *testing.T) {
+	same := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	candidates := []bazelCandidate{
+		{Type: "output_base", Path: "/cache/_bazel_jess/ccc", ModTime: same},
+		{Type: "output_base", Path: "/cache/_bazel_jess/aaa", ModTime: same},
+		{Type: "output_base", Path: "/cache/_bazel_jess/bbb", ModTime: same},
+	}
+	for run := 0; run < 20; run++ {
+		protected := newestBazelOutputBases(candidates, 1)
+		if len(protected) != 1 || !protected["/cache/_bazel_jess/aaa"] {
+			t.Fatalf("run %d: expected only aaa protected, got %#v", run, protected)
+		}
+	}
+}
+
 func TestBazelBusyProcessReasons(t *testing.T) {
 	ps := `
 /nix/store/abc/bin/bazel bazel build //...
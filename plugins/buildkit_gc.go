@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// buildKitStateDir is the BuildKit state root inside a buildx
+// docker-container builder. Its content store holds the layer blobs.
+const buildKitStateDir = "/var/lib/buildkit"
+
+// buildKitContainer is a running buildx BuildKit builder container.
+type buildKitContainer struct {
+	ID   string
+	Name string
+}
+
+// containerCommand runs the docker or podman CLI with args.
+type containerCommand func(ctx context.Context, args ...string) (string, error)
+
+var buildKitListArgs = []string{"ps", "--filter", "name=buildx_buildkit", "--format", "{{.ID}}\t{{.Names}}"}
+
+// listBuildKitContainers returns the running buildx BuildKit containers.
+func listBuildKitContainers(ctx context.Context, run containerCommand) ([]buildKitContainer, error) {
+	output, err := run(ctx, buildKitListArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBuildKitContainers(output), nil
+}
+
+func parseBuildKitContainers(output string) []buildKitContainer {
+	var containers []buildKitContainer
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		container := buildKitContainer{ID: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			container.Name = strings.TrimSpace(parts[1])
+		}
+		if container.ID == "" {
+			continue
+		}
+		if container.Name == "" {
+			container.Name = container.ID
+		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// buildKitDeepGCArgs drops every cache record in a BuildKit container. With
+// no records left, BuildKit's content-store GC also releases blobs orphaned
+// by interrupted builds, which no filtered prune reaches.
+func buildKitDeepGCArgs(containerID string) []string {
+	return []string{"exec", containerID, "buildctl", "prune", "--all"}
+}
+
+func buildKitStateSizeArgs(containerID string) []string {
+	return []string{"exec", containerID, "du", "-sk", buildKitStateDir}
+}
+
+// buildKitDeepGCResult is the outcome of a deep GC across builders.
+type buildKitDeepGCResult struct {
+	// Bytes is the measured state-directory shrink, or the prune summary
+	// when the state directory could not be sized.
+	Bytes int64
+	// Containers is how many builders were collected.
+	Containers int
+}
+
+// deepBuildKitGC runs the deep prune in each container. The normal builder
+// prune has already run, so the state directory shrink measured around the
+// deep prune is what it reclaimed beyond that prune.
+func deepBuildKitGC(ctx context.Context, run containerCommand, containers []buildKitContainer, logger *slog.Logger) buildKitDeepGCResult {
+	var gc buildKitDeepGCResult
+	for _, container := range containers {
+		before, beforeErr := buildKitStateSize(ctx, run, container.ID)
+		output, err := run(ctx, buildKitDeepGCArgs(container.ID)...)
+		if err != nil {
+			logger.Warn("deep BuildKit cache GC failed", "container", container.Name, "error", err, "output", output)
+			continue
+		}
+		gc.Containers++
+
+		freed := parseBuildKitPruneSummary(output)
+		after, afterErr := buildKitStateSize(ctx, run, container.ID)
+		if beforeErr == nil && afterErr == nil {
+			freed = max(before-after, 0)
+		} else {
+			logger.Debug("could not size BuildKit state; using prune summary",
+				"container", container.Name,
+				"before_error", beforeErr,
+				"after_error", afterErr)
+		}
+		gc.Bytes += freed
+		logger.Info("deep BuildKit cache GC completed", "container", container.Name, "freed_mb", freed/(1024*1024))
+	}
+	return gc
+}
+
+func buildKitStateSize(ctx context.Context, run containerCommand, containerID string) (int64, error) {
+	output, err := run(ctx, buildKitStateSizeArgs(containerID)...)
+	if err != nil {
+		return 0, err
+	}
+	size := parseDuKilobytes(output)
+	if size == 0 {
+		return 0, fmt.Errorf("unexpected du output %q", strings.TrimSpace(output))
+	}
+	return size, nil
+}
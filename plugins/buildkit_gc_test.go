@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDeepBuildKitGCMeasuresStateShrink(t *testing.T) {
+	sizes := map[string][]string{
+		"abc": {"62914560\t/var/lib/buildkit\n", "1048576\t/var/lib/buildkit\n"},
+	}
+	var calls []string
+	run := func(_ context.Context, args ...string) (string, error) {
+		line := strings.Join(args, " ")
+		calls = append(calls, line)
+		switch line {
+		case "exec abc du -sk /var/lib/buildkit":
+			output := sizes["abc"][0]
+			sizes["abc"] = sizes["abc"][1:]
+			return output, nil
+		case "exec abc buildctl prune --all":
+			return "Total:\t1.5GB\n", nil
+		case "exec bad buildctl prune --all":
+			return "buildctl: command not found", errors.New("exit status 127")
+		}
+		return "", errors.New("unexpected command " + line)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	gc := deepBuildKitGC(context.Background(), run, []buildKitContainer{{ID: "abc", Name: "buildx_buildkit_default"}, {ID: "bad", Name: "bad"}}, logger)
+	if gc.Containers != 1 {
+		t.Fatalf("expected one collected builder, got %d", gc.Containers)
+	}
+	// 60 GiB shrank to 1 GiB; the measured shrink wins over the prune summary.
+	if want := int64(59 << 30); gc.Bytes != want {
+		t.Fatalf("Bytes = %d, want %d", gc.Bytes, want)
+	}
+	if calls[1] != "exec abc buildctl prune --all" {
+		t.Fatalf("expected the state to be sized before the prune, got %v", calls)
+	}
+}
+
+func TestDeepBuildKitGCFallsBackToPruneSummary(t *testing.T) {
+	run := func(_ context.Context, args ...string) (string, error) {
+		if strings.Join(args, " ") == "exec abc buildctl prune --all" {
+			return "Total:\t2GB\n", nil
+		}
+		return "du: not found", errors.New("exit status 127")
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	gc := deepBuildKitGC(context.Background(), run, []buildKitContainer{{ID: "abc", Name: "abc"}}, logger)
+	if gc.Bytes != 2<<30 || gc.Containers != 1 {
+		t.Fatalf("expected the prune summary when du fails, got %+v", gc)
+	}
+}
+
+func TestParseBuildKitContainers(t *testing.T) {
+	got := parseBuildKitContainers("abc\tbuildx_buildkit_default\n\ndef\n")
+	if len(got) != 2 || got[0] != (buildKitContainer{ID: "abc", Name: "buildx_buildkit_default"}) || got[1] != (buildKitContainer{ID: "def", Name: "def"}) {
+		t.Fatalf("unexpected containers %+v", got)
+	}
+}
@@ -8,12 +8,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
+// cacheTempDirs are the temp roots the cache plugin ages out, replaced in tests.
+var cacheTempDirs = []string{"/tmp", "/var/tmp"}
+
 // CachePlugin handles cache cleanup operations.
 type CachePlugin struct{}
 
@@ -29,7 +34,17 @@ func (p *CachePlugin) Name() string {
 
 // Description returns the plugin description.
 func (p *CachePlugin) Description() string {
-	return "Cleans various application caches (pip, npm, go, etc.)"
+	return "Cleans various application caches (pip, npm, pnpm, yarn, go, etc.)"
+}
+
+// Priority runs cache clears first; they are cheap and rebuild on demand.
+func (p *CachePlugin) Priority() int {
+	return 10
+}
+
+// FreedBytesVolume reports that cache deletions land on the monitored volume.
+func (p *CachePlugin) FreedBytesVolume(cfg *config.Config) string {
+	return ""
 }
 
 // SupportedPlatforms returns supported platforms (all).
@@ -42,20 +57,51 @@ func (p *CachePlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Cache
 }
 
-// Cleanup performs cache cleanup at the specified level.
+// LevelDescription summarizes cache cleanup at each level.
+func (p *CachePlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "deletes pip, npm, pnpm, and yarn caches and user-owned temp files older than 7 days"
+	case LevelModerate:
+		return "adds go clean -testcache, cargo, maven, and gradle files older than 30 days, temp files older than 3 days, and user journal vacuum"
+	case LevelAggressive:
+		return "adds go clean -cache, go clean -modcache, temp files older than 1 day, and system journal vacuum with passwordless sudo"
+	case LevelCritical:
+		return "adds uninstalling non-default rustup toolchains"
+	default:
+		return "no cleanup"
+	}
+}
+
+// Cleanup performs cache cleanup at the specified level and reports how
+// many paths were skipped as unreadable.
 func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	skipped := walkErrors.snapshot()
+	result := p.cleanup(ctx, level, cfg, logger)
+	if home, err := env.HomeDir(); err == nil && level >= LevelWarning {
+		cleanExtraCachePaths(cfg.Cache, level, home, &result, logger)
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
+
+func (p *CachePlugin) cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
 		Plugin: p.Name(),
 		Level:  level,
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping cache cleanup: home directory unavailable", "error", err)
+		return result
+	}
 
 	// pip cache
 	pipCache := filepath.Join(home, ".cache", "pip")
 	if size := getDirSize(pipCache); size > 0 {
 		if level >= LevelWarning {
-			os.RemoveAll(pipCache)
+			removeCacheRoot(pipCache)
 			result.BytesFreed += size
 			logger.Debug("cleaned pip cache", "freed_mb", size/(1024*1024))
 		}
@@ -65,12 +111,17 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	npmCache := filepath.Join(home, ".npm", "_cacache")
 	if size := getDirSize(npmCache); size > 0 {
 		if level >= LevelWarning {
-			os.RemoveAll(npmCache)
+			removeCacheRoot(npmCache)
 			result.BytesFreed += size
 			logger.Debug("cleaned npm cache", "freed_mb", size/(1024*1024))
 		}
 	}
 
+	// pnpm store and yarn global cache
+	if level >= LevelWarning {
+		cleanJSPackageCaches(ctx, home, &result, logger)
+	}
+
 	// Go build cache (moderate+, separate from module cache)
 	if level >= LevelModerate {
 		if _, err := exec.LookPath("go"); err == nil {
@@ -85,7 +136,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 							exec.CommandContext(ctx, "go", "clean", "-testcache").Run()
 						}
 						sizeAfter := getDirSize(goCacheDir)
-						freed := safeBytesDiff(sizeBefore, sizeAfter)
+						freed := measuredBytesDiff(&result, logger, goCacheDir, sizeBefore, sizeAfter)
 						result.BytesFreed += freed
 						if freed > 0 {
 							logger.Debug("cleaned go build cache", "freed_mb", freed/(1024*1024))
@@ -99,8 +150,9 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	// go module cache (only at aggressive or higher)
 	if level >= LevelAggressive {
 		goModCache := filepath.Join(home, "go", "pkg", "mod", "cache")
-		if size := getDirSize(goModCache); size > 0 {
+		if size := getDirSize(goModCache); size > 0 && cooldownAllows(cfg, p.Name(), "go_modcache", logger) {
 			exec.CommandContext(ctx, "go", "clean", "-modcache").Run()
+			recordCooldown(cfg, p.Name(), "go_modcache", logger)
 			result.BytesFreed += size
 			logger.Debug("cleaned go mod cache", "freed_mb", size/(1024*1024))
 		}
@@ -113,7 +165,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 			sizeBefore := getDirSize(cargoCache)
 			deleteOldFiles(cargoCache, 30*24*time.Hour)
 			sizeAfter := getDirSize(cargoCache)
-			result.BytesFreed += safeBytesDiff(sizeBefore, sizeAfter)
+			result.BytesFreed += measuredBytesDiff(&result, logger, cargoCache, sizeBefore, sizeAfter)
 		}
 
 		// cargo clean gc (Rust 1.82+ automatic garbage collection)
@@ -149,7 +201,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 			sizeBefore := size
 			deleteOldFiles(mavenCache, 30*24*time.Hour)
 			sizeAfter := getDirSize(mavenCache)
-			freed := safeBytesDiff(sizeBefore, sizeAfter)
+			freed := measuredBytesDiff(&result, logger, mavenCache, sizeBefore, sizeAfter)
 			result.BytesFreed += freed
 			logger.Debug("cleaned maven cache", "freed_mb", freed/(1024*1024))
 		}
@@ -162,7 +214,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 			sizeBefore := size
 			deleteOldFiles(gradleCache, 30*24*time.Hour)
 			sizeAfter := getDirSize(gradleCache)
-			freed := safeBytesDiff(sizeBefore, sizeAfter)
+			freed := measuredBytesDiff(&result, logger, gradleCache, sizeBefore, sizeAfter)
 			result.BytesFreed += freed
 			logger.Debug("cleaned gradle cache", "freed_mb", freed/(1024*1024))
 		}
@@ -170,22 +222,12 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 
 	// Temp files - more aggressive cleanup based on level
 	// Uses mount-boundary-safe deletion and tracks actual bytes freed
-	tmpFiles := []string{"/tmp", "/var/tmp"}
-	for _, tmpDir := range tmpFiles {
+	for _, tmpDir := range cacheTempDirs {
 		if !pathExistsAndIsDir(tmpDir) {
 			continue
 		}
-		var maxAge time.Duration
-		switch {
-		case level >= LevelAggressive:
-			maxAge = 1 * 24 * time.Hour // 1 day at aggressive
-		case level >= LevelModerate:
-			maxAge = 3 * 24 * time.Hour // 3 days at moderate
-		default:
-			maxAge = 7 * 24 * time.Hour // 7 days at warning
-		}
 		// Use mount-safe version that returns actual freed bytes
-		freed := deleteOldFilesOwnedByUserSameDevice(tmpDir, maxAge)
+		freed := deleteOldFilesOwnedByUserSameDevice(tmpDir, cacheTempMaxAge(level))
 		result.BytesFreed += freed
 	}
 
@@ -210,6 +252,151 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	return result
 }
 
+// cacheTempMaxAge is the age past which user-owned temp files are deleted.
+func cacheTempMaxAge(level CleanupLevel) time.Duration {
+	switch {
+	case level >= LevelAggressive:
+		return 1 * 24 * time.Hour // 1 day at aggressive
+	case level >= LevelModerate:
+		return 3 * 24 * time.Hour // 3 days at moderate
+	default:
+		return 7 * 24 * time.Hour // 7 days at warning
+	}
+}
+
+// PlanCleanup sizes what Cleanup would delete at level without deleting
+// anything. Steps whose effect cannot be measured beforehand, such as go
+// clean -testcache, rustup toolchain removal, and journal vacuums, are
+// listed with a warning and left out of the estimate.
+func (p *CachePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Application cache cleanup plan",
+		WouldRun: level >= LevelWarning,
+		Metadata: map[string]string{},
+	}
+	if !plan.WouldRun {
+		plan.SkipReason = "below_threshold"
+		return plan
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+
+	skipped := walkErrors.snapshot()
+	add := func(name, path, tier, action string, bytes int64) {
+		if bytes <= 0 {
+			return
+		}
+		target := CleanupTarget{Type: "cache", Name: name, Path: path, Bytes: bytes, Action: action}
+		annotateCleanupTargetPolicy(&target, tier, hostReclaimForAction(action))
+		plan.Targets = append(plan.Targets, target)
+		plan.EstimatedBytesFreed += bytes
+	}
+	sized := func(path string) int64 {
+		size, err := getDirSizeContext(ctx, path)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("could not size cache; left out of the estimate", "path", path, "error", err)
+			plan.Warnings = append(plan.Warnings, "could not size "+path+": "+err.Error())
+		}
+		return size
+	}
+
+	add("pip", filepath.Join(home, ".cache", "pip"), CleanupTierSafe, "delete_cache_root", sized(filepath.Join(home, ".cache", "pip")))
+	add("npm", filepath.Join(home, ".npm", "_cacache"), CleanupTierSafe, "delete_cache_root", sized(filepath.Join(home, ".npm", "_cacache")))
+	for _, cache := range jsPackageCaches(home) {
+		if cache.Name == "pnpm" {
+			if _, err := runner.LookPath("pnpm"); err == nil && len(cache.existingPaths()) > 0 {
+				plan.Warnings = append(plan.Warnings, "pnpm store prune frees only unreferenced packages; not estimated")
+				continue
+			}
+		}
+		for _, path := range cache.existingPaths() {
+			add(cache.Name, path, CleanupTierSafe, "delete_cache_root", sized(path))
+		}
+	}
+
+	if level >= LevelModerate {
+		if _, err := runner.LookPath("go"); err == nil {
+			if output, err := runner.Output(ctx, nil, "go", "env", "GOCACHE"); err != nil {
+				plan.Warnings = append(plan.Warnings, "go env GOCACHE failed; go build cache not estimated: "+err.Error())
+			} else if goCacheDir := strings.TrimSpace(string(output)); goCacheDir != "" && goCacheDir != "off" {
+				if level >= LevelAggressive {
+					add("go-build", goCacheDir, CleanupTierWarm, "delete_go_build_cache", sized(goCacheDir))
+				} else {
+					plan.Warnings = append(plan.Warnings, "go clean -testcache frees an unknown share of "+goCacheDir+"; not estimated")
+				}
+			}
+		}
+		for _, dir := range []struct{ name, path string }{
+			{"cargo-registry", filepath.Join(home, ".cargo", "registry", "cache")},
+			{"maven", filepath.Join(home, ".m2", "repository")},
+			{"gradle", filepath.Join(home, ".gradle", "caches")},
+		} {
+			add(dir.name, dir.path, CleanupTierWarm, "delete_files_older_than_30d", oldFilesSize(ctx, dir.path, 30*24*time.Hour))
+		}
+		plan.Warnings = append(plan.Warnings, "cargo cache --autoclean and journal vacuums are not estimated")
+	}
+	if level >= LevelAggressive {
+		modCache := filepath.Join(home, "go", "pkg", "mod", "cache")
+		if cooldownAllows(cfg, p.Name(), "go_modcache", logger) {
+			add("go-modcache", modCache, CleanupTierWarm, "delete_go_module_cache", sized(modCache))
+		}
+	}
+	if level >= LevelCritical {
+		plan.Warnings = append(plan.Warnings, "non-default rustup toolchains are uninstalled but not estimated")
+	}
+
+	for _, target := range extraCachePlanTargets(ctx, cfg.Cache, level, home) {
+		plan.Targets = append(plan.Targets, target)
+		plan.EstimatedBytesFreed += target.Bytes
+	}
+
+	maxAge := cacheTempMaxAge(level)
+	for _, tmpDir := range cacheTempDirs {
+		if pathExistsAndIsDir(tmpDir) {
+			add("temp", tmpDir, CleanupTierSafe, "delete_user_files_older_than_"+maxAge.String(), oldFilesOwnedByUserSameDevice(tmpDir, maxAge, false))
+		}
+	}
+
+	now := walkErrors.snapshot()
+	if unreadable := now.permission + now.other - skipped.permission - skipped.other; unreadable > 0 {
+		plan.Warnings = append(plan.Warnings, strconv.Itoa(unreadable)+" unreadable paths were left out of the estimate")
+	}
+	plan.Metadata["target_count"] = strconv.Itoa(len(plan.Targets))
+	return plan
+}
+
+// oldFilesSize totals the files under dir not modified within maxAge, the
+// files deleteOldFiles would remove.
+func oldFilesSize(ctx context.Context, dir string, maxAge time.Duration) int64 {
+	root, ok := resolveCacheRoot(dir)
+	if !ok {
+		return 0
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var size int64
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if !info.IsDir() && info.ModTime().Before(cutoff) {
+			size += accountedFileBytes(info)
+		}
+		return nil
+	})
+	return size
+}
+
 // Helper functions
 
 func getDirSize(path string) int64 {
@@ -218,16 +405,21 @@ func getDirSize(path string) int64 {
 }
 
 func getDirSizeContext(ctx context.Context, path string) (int64, error) {
+	root, ok := resolveCacheRoot(path)
+	if !ok {
+		return 0, nil
+	}
 	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.IsDir() {
-			size += info.Size()
+			size += accountedFileBytes(info)
 		}
 		return nil
 	})
@@ -238,9 +430,14 @@ func getDirSizeContext(ctx context.Context, path string) (int64, error) {
 }
 
 func deleteOldFiles(dir string, maxAge time.Duration) {
+	root, ok := resolveCacheRoot(dir)
+	if !ok {
+		return
+	}
 	cutoff := time.Now().Add(-maxAge)
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.IsDir() && info.ModTime().Before(cutoff) {
@@ -251,10 +448,15 @@ func deleteOldFiles(dir string, maxAge time.Duration) {
 }
 
 func deleteOldFilesOwnedByUser(dir string, maxAge time.Duration) {
+	root, ok := resolveCacheRoot(dir)
+	if !ok {
+		return
+	}
 	cutoff := time.Now().Add(-maxAge)
 	uid := os.Getuid()
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.IsDir() && info.ModTime().Before(cutoff) {
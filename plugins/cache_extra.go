@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// ValidateCacheConfig rejects cache.extra_paths entries without a path, with
+// an unknown min_level, or naming a directory too broad to empty.
+func ValidateCacheConfig(cfg config.CacheConfig) error {
+	if len(cfg.ExtraPaths) == 0 {
+		return nil
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		return fmt.Errorf("cache.extra_paths: %w", err)
+	}
+	for i, extra := range cfg.ExtraPaths {
+		if extra.Path == "" {
+			return fmt.Errorf("cache.extra_paths[%d].path is required", i)
+		}
+		if _, ok := extraCacheMinLevel(extra); !ok {
+			return fmt.Errorf("cache.extra_paths[%d].min_level %q must be warning, moderate, aggressive, or critical", i, extra.MinLevel)
+		}
+		if reason := scratchRootRefusal(expandHome(extra.Path, home), home); reason != "" {
+			return fmt.Errorf("cache.extra_paths[%d].path %q refused: %s", i, extra.Path, reason)
+		}
+	}
+	return nil
+}
+
+// extraCacheMinLevel parses an entry's min_level, defaulting to warning.
+func extraCacheMinLevel(extra config.CachePathConfig) (CleanupLevel, bool) {
+	if extra.MinLevel == "" {
+		return LevelWarning, true
+	}
+	for _, level := range ActionLevels() {
+		if level.String() == extra.MinLevel {
+			return level, true
+		}
+	}
+	return LevelNone, false
+}
+
+// extraCachePaths returns the expanded cache.extra_paths entries due at level.
+func extraCachePaths(cfg config.CacheConfig, level CleanupLevel, home string) []string {
+	var paths []string
+	for _, extra := range cfg.ExtraPaths {
+		minLevel, ok := extraCacheMinLevel(extra)
+		if !ok || level < minLevel {
+			continue
+		}
+		path := expandHome(extra.Path, home)
+		if scratchRootRefusal(path, home) != "" {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// cleanExtraCachePaths empties the cache.extra_paths entries due at level,
+// keeping each directory, and records the bytes freed from each in result.
+func cleanExtraCachePaths(cfg config.CacheConfig, level CleanupLevel, home string, result *CleanupResult, logger *slog.Logger) {
+	for _, path := range extraCachePaths(cfg, level, home) {
+		if !pathExistsAndIsDir(path) {
+			continue
+		}
+		root, ok := resolveCacheRoot(path)
+		if !ok {
+			logger.Warn("skipping extra cache path with unsafe symlink target", "path", path)
+			continue
+		}
+		sizeBefore := getDirSize(root)
+		if sizeBefore == 0 {
+			continue
+		}
+		if err := removeDirContents(root); err != nil {
+			logger.Warn("could not fully empty extra cache path", "path", path, "error", err)
+		}
+		freed := measuredBytesDiff(result, logger, path, sizeBefore, getDirSize(root))
+		result.BytesFreed += freed
+		result.CleanedPaths = append(result.CleanedPaths, CleanedPath{Path: path, BytesFreed: freed})
+		logger.Info("cleaned extra cache path", "path", path, "freed_mb", freed/(1024*1024))
+	}
+}
+
+// extraCachePlanTargets sizes the cache.extra_paths entries due at level.
+func extraCachePlanTargets(ctx context.Context, cfg config.CacheConfig, level CleanupLevel, home string) []CleanupTarget {
+	var targets []CleanupTarget
+	for _, path := range extraCachePaths(cfg, level, home) {
+		root, ok := resolveCacheRoot(path)
+		if !ok || !pathExistsAndIsDir(root) {
+			continue
+		}
+		size, _ := getDirSizeContext(ctx, root)
+		if size <= 0 {
+			continue
+		}
+		target := CleanupTarget{Type: "cache", Name: "extra", Path: path, Bytes: size, Action: "delete_cache_contents"}
+		annotateCleanupTargetPolicy(&target, CleanupTierSafe, hostReclaimForAction(target.Action))
+		targets = append(targets, target)
+	}
+	return targets
+}
@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+func TestValidateCacheConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	valid := config.CacheConfig{ExtraPaths: []config.CachePathConfig{
+		{Path: "~/.cache/huggingface"},
+		{Path: "~/.deno", MinLevel: "critical"},
+	}}
+	if err := ValidateCacheConfig(valid); err != nil {
+		t.Fatalf("expected valid extra paths, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		extra config.CachePathConfig
+		want  string
+	}{
+		{config.CachePathConfig{MinLevel: "moderate"}, "path is required"},
+		{config.CachePathConfig{Path: "~/.cache/huggingface", MinLevel: "severe"}, "min_level"},
+		{config.CachePathConfig{Path: "~"}, "home directory"},
+		{config.CachePathConfig{Path: "relative/cache"}, "absolute"},
+	} {
+		err := ValidateCacheConfig(config.CacheConfig{ExtraPaths: []config.CachePathConfig{tc.extra}})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("ValidateCacheConfig(%+v) = %v, want error containing %q", tc.extra, err, tc.want)
+		}
+	}
+}
+
+func TestExtraCachePathsRefusedWithoutHome(t *testing.T) {
+	userHome := t.TempDir()
+	cache := filepath.Join(userHome, "cache")
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cache, "model.bin"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env.Configure("", "")
+	t.Setenv("HOME", "")
+
+	cfg := config.CacheConfig{ExtraPaths: []config.CachePathConfig{{Path: userHome}}}
+	if err := ValidateCacheConfig(cfg); err == nil || !strings.Contains(err.Error(), "home directory unavailable") {
+		t.Fatalf("expected extra paths to be rejected without a home, got %v", err)
+	}
+	if err := ValidateCacheConfig(config.CacheConfig{}); err != nil {
+		t.Fatalf("expected no extra paths to validate without a home, got %v", err)
+	}
+
+	full := config.DefaultConfig()
+	full.Cache = cfg
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if result := NewCachePlugin().Cleanup(context.Background(), LevelCritical, full, logger); len(result.CleanedPaths) != 0 || !pathExists(filepath.Join(cache, "model.bin")) {
+		t.Fatalf("expected the home directory to be left alone, got %+v", result)
+	}
+}
+
+func TestCleanExtraCachePathsHonorsMinLevel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	huggingface := filepath.Join(home, ".cache", "huggingface")
+	deno := filepath.Join(home, ".deno")
+	writeAgedFile(t, filepath.Join(huggingface, "hub", "model.bin"), 4096, 0)
+	writeAgedFile(t, filepath.Join(deno, "deps", "mod.ts"), 2048, 0)
+
+	cfg := config.CacheConfig{ExtraPaths: []config.CachePathConfig{
+		{Path: "~/.cache/huggingface", MinLevel: "moderate"},
+		{Path: "~/.deno", MinLevel: "critical"},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if targets := extraCachePlanTargets(context.Background(), cfg, LevelModerate, home); len(targets) != 1 || targets[0].Path != huggingface {
+		t.Fatalf("expected only the huggingface target at moderate, got %#v", targets)
+	}
+
+	result := CleanupResult{Plugin: "cache"}
+	cleanExtraCachePaths(cfg, LevelModerate, home, &result, logger)
+
+	if len(result.CleanedPaths) != 1 || result.CleanedPaths[0].Path != huggingface || result.CleanedPaths[0].BytesFreed < 4096 {
+		t.Fatalf("expected huggingface to be reported cleaned, got %#v", result.CleanedPaths)
+	}
+	if result.BytesFreed != result.CleanedPaths[0].BytesFreed {
+		t.Fatalf("expected bytes freed %d to match the cleaned path, got %d", result.CleanedPaths[0].BytesFreed, result.BytesFreed)
+	}
+	if entries, err := os.ReadDir(huggingface); err != nil || len(entries) != 0 {
+		t.Fatalf("expected huggingface to be kept and emptied, got %d entries, error %v", len(entries), err)
+	}
+	if _, err := os.Stat(filepath.Join(deno, "deps", "mod.ts")); err != nil {
+		t.Fatalf("expected deno cache to be kept below critical: %v", err)
+	}
+}
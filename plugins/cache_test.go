@@ -0,0 +1,58 @@
+//go:build !darwin
+
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestCachePluginPlanEstimatesWithoutDeleting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tmp := t.TempDir()
+	previous := cacheTempDirs
+	cacheTempDirs = []string{tmp}
+	t.Cleanup(func() { cacheTempDirs = previous })
+	fake := useFakeRunner(t, nil)
+	fake.missing["go"] = true
+
+	const old = 60 * 24 * time.Hour
+	pip := filepath.Join(home, ".cache", "pip", "wheel")
+	writeAgedFile(t, pip, 1000, 0)
+	oldMaven := filepath.Join(home, ".m2", "repository", "old.jar")
+	writeAgedFile(t, oldMaven, 300, old)
+	writeAgedFile(t, filepath.Join(home, ".m2", "repository", "new.jar"), 200, 0)
+	oldTemp := filepath.Join(tmp, "stale")
+	writeAgedFile(t, oldTemp, 50, old)
+
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewCachePlugin()
+
+	plan := plugin.PlanCleanup(context.Background(), LevelWarning, cfg, logger)
+	if !plan.WouldRun || plan.EstimatedBytesFreed != 1050 {
+		t.Fatalf("warning plan = %+v, want pip and stale temp files (1050 bytes)", plan)
+	}
+
+	plan = plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.EstimatedBytesFreed != 1350 {
+		t.Fatalf("moderate estimate = %d, want 1350 with the old maven file and without the new one", plan.EstimatedBytesFreed)
+	}
+	for _, path := range []string{pip, oldMaven, oldTemp} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("planning must not delete %s: %v", path, err)
+		}
+	}
+
+	if plan := plugin.PlanCleanup(context.Background(), LevelNone, cfg, logger); plan.WouldRun || plan.EstimatedBytesFreed != 0 {
+		t.Fatalf("expected no plan below warning, got %+v", plan)
+	}
+}
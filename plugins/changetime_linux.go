@@ -0,0 +1,18 @@
+//go:build linux
+
+package plugins
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the inode change time of info, which moving a file
+// into a Trash folder updates, or its modification time when unavailable.
+func changeTime(info fs.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec)
+	}
+	return info.ModTime()
+}
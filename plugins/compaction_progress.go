@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"syscall"
+	"time"
+)
+
+// compactionProgressInterval is how often a running disk image conversion
+// reports how much of the compacted copy has been written.
+var compactionProgressInterval = 30 * time.Second
+
+// watchCompactionProgress reports the bytes allocated to destPath every
+// interval while a conversion writes it, until ctx ends or the returned stop
+// function is called. stop waits for the watcher, so no report follows it.
+// The conversion itself runs under ctx: cancelling it kills qemu-img and the
+// caller discards the partial copy, leaving the original image untouched.
+func watchCompactionProgress(ctx context.Context, destPath string, interval time.Duration, report func(written int64)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if written, ok := compactionBytesWritten(destPath); ok {
+					report(written)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// compactionBytesWritten returns the blocks allocated to a copy in progress.
+// Unlike allocatedBytes it never falls back to the apparent size, which a
+// sparse destination reaches as soon as it is created.
+func compactionBytesWritten(path string) (int64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(stat.Blocks) * 512, true
+}
+
+// logCompactionProgress returns a watchCompactionProgress report function
+// that logs the bytes written against the source image's allocated size.
+// The compacted copy is smaller than the source, so the two never meet.
+func logCompactionProgress(logger *slog.Logger, sourceBytes int64, attrs ...any) func(int64) {
+	return func(written int64) {
+		logger.Info("disk compaction in progress", append(attrs,
+			"written_gb", fmt.Sprintf("%.1f", float64(written)/(1024*1024*1024)),
+			"source_gb", fmt.Sprintf("%.1f", float64(sourceBytes)/(1024*1024*1024)))...)
+	}
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchCompactionProgressStopsOnCancel(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "disk.compact")
+	file, err := os.Create(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// A large sparse destination, as qemu-img creates for raw output.
+	if err := file.Truncate(8 << 30); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var reports []int64
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := watchCompactionProgress(ctx, dest, 5*time.Millisecond, func(written int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, written)
+	})
+	defer stop()
+
+	chunk := make([]byte, 1<<20)
+	for i := range chunk {
+		chunk[i] = 1
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for offset := int64(0); ; offset += int64(len(chunk)) {
+		if _, err := file.WriteAt(chunk, offset); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		written := int64(0)
+		if len(reports) > 0 {
+			written = reports[len(reports)-1]
+		}
+		mu.Unlock()
+		if written >= int64(len(chunk)) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected progress reports for the blocks written so far")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	for _, written := range reports {
+		if written >= 8<<30 {
+			mu.Unlock()
+			t.Fatalf("progress reported the sparse apparent size: %v", reports)
+		}
+	}
+	mu.Unlock()
+
+	// Cancelling mid-copy stops the reports; the partial copy is left for
+	// the caller to discard.
+	cancel()
+	stop()
+	mu.Lock()
+	count := len(reports)
+	mu.Unlock()
+	if _, err := file.WriteAt(chunk, 1<<30); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) != count {
+		t.Fatalf("expected no progress reports after cancellation, got %d more", len(reports)-count)
+	}
+}
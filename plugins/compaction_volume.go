@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+)
+
+// compactionVolume tracks free space on the filesystem holding a VM disk
+// image across an offline compaction. A smaller image file only means host
+// space was reclaimed if that filesystem gained free space too: hole punching
+// can be a no-op, and snapshots or clones can keep the old blocks referenced.
+type compactionVolume struct {
+	dir       string
+	before    uint64
+	after     uint64
+	err       error
+	freeSpace func(string) (uint64, error)
+}
+
+// measureCompactionVolume records the free space beside diskPath before
+// compaction.
+func measureCompactionVolume(diskPath string, freeSpace func(string) (uint64, error)) *compactionVolume {
+	volume := &compactionVolume{dir: filepath.Dir(diskPath), freeSpace: freeSpace}
+	volume.before, volume.err = freeSpace(volume.dir)
+	return volume
+}
+
+// measureAfter records the free space once the compacted image has replaced
+// the original, before the VM restarts and writes to it again.
+func (v *compactionVolume) measureAfter() {
+	if v.err != nil {
+		return
+	}
+	v.after, v.err = v.freeSpace(v.dir)
+}
+
+// verifiedCompactionFreed reconciles the image file's shrink with the
+// filesystem's free-space gain and returns the bytes to report. A file that
+// shrank on a filesystem that gained nothing reports zero, with a warning
+// that compaction is ineffective there. A smaller gain than the shrink is
+// reported as the gain. When the filesystem could not be measured the file
+// shrink is reported unverified.
+func (v *compactionVolume) verifiedCompactionFreed(fileFreed int64, logger *slog.Logger, attrs ...any) int64 {
+	if fileFreed <= 0 {
+		return 0
+	}
+	if v.err != nil {
+		logger.Debug("could not measure disk image filesystem; reporting the file shrink unverified",
+			append(attrs, "dir", v.dir, "error", v.err)...)
+		return fileFreed
+	}
+	gained := int64(v.after) - int64(v.before)
+	attrs = append(attrs,
+		"dir", v.dir,
+		"file_freed_gb", fmt.Sprintf("%.1f", float64(fileFreed)/(1024*1024*1024)),
+		"volume_gained_gb", fmt.Sprintf("%.1f", float64(gained)/(1024*1024*1024)))
+	if gained <= 0 {
+		logger.Warn("COMPACTION INEFFECTIVE: the disk image shrank but its filesystem gained no free space; hole punching or snapshots on this filesystem keep the old blocks", attrs...)
+		return 0
+	}
+	if gained < fileFreed {
+		logger.Info("disk image filesystem gained less than the image shrank; reporting the filesystem gain", attrs...)
+		return gained
+	}
+	return fileFreed
+}
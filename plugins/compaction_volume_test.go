@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCompactionVolumeVerifiesFreedBytes(t *testing.T) {
+	const gib = int64(1 << 30)
+	cases := []struct {
+		name      string
+		free      []uint64
+		fileFreed int64
+		want      int64
+		warn      bool
+	}{
+		{name: "volume gained the shrink", free: []uint64{10 << 30, 14 << 30}, fileFreed: 4 * gib, want: 4 * gib},
+		{name: "other writers cap at the shrink", free: []uint64{10 << 30, 20 << 30}, fileFreed: 4 * gib, want: 4 * gib},
+		{name: "partial gain", free: []uint64{10 << 30, 11 << 30}, fileFreed: 4 * gib, want: gib},
+		{name: "no gain", free: []uint64{10 << 30, 10 << 30}, fileFreed: 4 * gib, want: 0, warn: true},
+		{name: "volume lost space", free: []uint64{10 << 30, 9 << 30}, fileFreed: 4 * gib, want: 0, warn: true},
+		{name: "file did not shrink", free: []uint64{10 << 30, 12 << 30}, fileFreed: 0, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			freeSpace := func(dir string) (uint64, error) {
+				if dir != "/vm" {
+					t.Fatalf("measured %q, want the disk image directory", dir)
+				}
+				calls++
+				return tc.free[calls-1], nil
+			}
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+			volume := measureCompactionVolume("/vm/disk.raw", freeSpace)
+			volume.measureAfter()
+			if got := volume.verifiedCompactionFreed(tc.fileFreed, logger, "vm", "default"); got != tc.want {
+				t.Fatalf("freed = %d, want %d", got, tc.want)
+			}
+			if warned := strings.Contains(logs.String(), "COMPACTION INEFFECTIVE"); warned != tc.warn {
+				t.Fatalf("ineffective warning = %v, want %v; logs: %s", warned, tc.warn, logs.String())
+			}
+		})
+	}
+}
+
+func TestCompactionVolumeUnmeasurableReportsFileShrink(t *testing.T) {
+	volume := measureCompactionVolume("/vm/disk.raw", func(string) (uint64, error) {
+		return 0, errors.New("statfs failed")
+	})
+	volume.measureAfter()
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if got := volume.verifiedCompactionFreed(1<<30, logger); got != 1<<30 {
+		t.Fatalf("expected the unverified file shrink, got %d", got)
+	}
+}
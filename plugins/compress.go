@@ -0,0 +1,298 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// compressedSuffix is appended to a file compressed in place.
+const compressedSuffix = ".gz"
+
+// compressedExtensions are file extensions whose contents are already
+// compressed, so compressing them again would save nothing.
+var compressedExtensions = []string{
+	".7z", ".br", ".bz2", ".gz", ".lz4", ".lzma", ".rar", ".tbz2", ".tgz", ".txz",
+	".xz", ".zip", ".zst", ".jar", ".whl", ".jpg", ".jpeg", ".png", ".gif", ".webp",
+	".heic", ".mp3", ".mp4", ".m4a", ".mkv", ".mov", ".webm", ".pdf", ".dmg",
+}
+
+// compressedMagic are leading bytes of compressed formats, for files whose
+// name does not say they are compressed.
+var compressedMagic = [][]byte{
+	{0x1f, 0x8b},             // gzip
+	{0x28, 0xb5, 0x2f, 0xfd}, // zstd
+	{0xfd, '7', 'z', 'X', 'Z', 0x00},
+	{'B', 'Z', 'h'},
+	{'P', 'K', 0x03, 0x04},
+	{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c},
+}
+
+// compressCandidate reports whether name may be worth compressing: it does
+// not already carry a compressed extension.
+func compressCandidate(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range compressedExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCompressionRatio rejects a min_compression_ratio below 1, which
+// would let compression grow a file.
+func validateCompressionRatio(field string, ratio float64) error {
+	if ratio < 1 {
+		return fmt.Errorf("%s must be at least 1, got %g", field, ratio)
+	}
+	return nil
+}
+
+// fileCompressor gzips old files in place instead of deleting them. A file
+// is replaced only when its compressed copy is at least minRatio times
+// smaller, and never while any process holds it open for writing.
+type fileCompressor struct {
+	ctx      context.Context
+	minRatio float64
+
+	writers       map[string]bool
+	writersLoaded bool
+	writersErr    error
+}
+
+// newFileCompressor returns a compressor that requires minRatio.
+func newFileCompressor(ctx context.Context, minRatio float64) *fileCompressor {
+	return &fileCompressor{ctx: ctx, minRatio: minRatio}
+}
+
+// errCompressSkipped marks a file left as it was on purpose.
+var errCompressSkipped = errors.New("compression skipped")
+
+// openForWriting reports whether some process holds path open for writing.
+// The open files are listed once per compressor. When they cannot be listed
+// every file is treated as open, so nothing is compressed.
+func (c *fileCompressor) openForWriting(path string) (bool, error) {
+	if !c.writersLoaded {
+		c.writers, c.writersErr = writeOpenFiles(c.ctx)
+		c.writersLoaded = true
+	}
+	if c.writersErr != nil {
+		return true, c.writersErr
+	}
+	return c.writers[path], nil
+}
+
+// compress replaces path with path.gz and returns the bytes saved on disk.
+// It returns errCompressSkipped when the file is already compressed, is
+// open for writing, would not reach the ratio, or changed while compressing.
+// The replacement is atomic: the compressed copy is written to a temporary
+// file beside the original, synced, renamed into place, and only then is the
+// original removed.
+func (c *fileCompressor) compress(path string, info fs.FileInfo) (int64, error) {
+	if !info.Mode().IsRegular() || info.Size() == 0 || !compressCandidate(info.Name()) {
+		return 0, errCompressSkipped
+	}
+	dest := path + compressedSuffix
+	if _, err := os.Lstat(dest); err == nil {
+		return 0, errCompressSkipped
+	}
+	if open, err := c.openForWriting(path); err != nil {
+		return 0, fmt.Errorf("listing files open for writing: %w", err)
+	} else if open {
+		return 0, errCompressSkipped
+	}
+
+	src, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	if current, err := src.Stat(); err != nil {
+		return 0, err
+	} else if !os.SameFile(current, info) || fileChanged(current, info) {
+		return 0, errCompressSkipped
+	}
+	reader := bufio.NewReader(src)
+	head, _ := reader.Peek(6)
+	for _, magic := range compressedMagic {
+		if bytes.HasPrefix(head, magic) {
+			return 0, errCompressSkipped
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+info.Name()+".*"+compressedSuffix+".tmp")
+	if err != nil {
+		return 0, err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	gz := gzip.NewWriter(tmp)
+	gz.Name = info.Name()
+	gz.ModTime = info.ModTime()
+	if _, err := io.Copy(gz, reader); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		// Best effort: only root can give the copy to another owner, and
+		// the sweeps only compress files owned by the running user.
+		_ = tmp.Chown(int(stat.Uid), int(stat.Gid))
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	compressed, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if float64(info.Size()) < c.minRatio*float64(compressed.Size()) {
+		return 0, errCompressSkipped
+	}
+	saved := accountedFileBytes(info) - accountedFileBytes(compressed)
+	if saved <= 0 {
+		return 0, errCompressSkipped
+	}
+	if err := os.Chtimes(tmp.Name(), time.Now(), info.ModTime()); err != nil {
+		return 0, err
+	}
+
+	// A writer that opened the file after the open-file scan shows up as a
+	// changed size or modification time.
+	if current, err := os.Lstat(path); err != nil || !os.SameFile(current, info) || fileChanged(current, info) {
+		return 0, errCompressSkipped
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		return 0, errCompressSkipped
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return 0, err
+	}
+	keep = true
+	if err := os.Remove(path); err != nil {
+		// Leave the original in place rather than two copies.
+		os.Remove(dest)
+		return 0, err
+	}
+	return saved, nil
+}
+
+// fileChanged reports whether current differs in size or modification time
+// from info.
+func fileChanged(current, info fs.FileInfo) bool {
+	return current.Size() != info.Size() || !current.ModTime().Equal(info.ModTime())
+}
+
+// procRoot is the Linux process filesystem, replaced in tests.
+var procRoot = "/proc"
+
+// writeOpenFiles returns the paths of regular files any visible process
+// holds open for writing. Linux reads /proc; other platforms ask lsof.
+func writeOpenFiles(ctx context.Context) (map[string]bool, error) {
+	if goosValue == PlatformLinux {
+		return procWriteOpenFiles(procRoot)
+	}
+	lsofCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	output, err := runner.Output(lsofCtx, nil, "lsof", "-w", "-n", "-P", "-F", "an")
+	if err != nil && len(output) == 0 {
+		return nil, err
+	}
+	return parseLsofWriters(string(output)), nil
+}
+
+// procWriteOpenFiles scans every readable /proc/<pid>/fd for descriptors
+// whose fdinfo flags open them for writing. Processes the running user
+// cannot inspect are skipped.
+func procWriteOpenFiles(root string) (map[string]bool, error) {
+	pids, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	writers := map[string]bool{}
+	for _, pid := range pids {
+		if _, err := strconv.Atoi(pid.Name()); err != nil {
+			continue
+		}
+		fdDir := filepath.Join(root, pid.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !filepath.IsAbs(target) {
+				continue
+			}
+			info, err := os.ReadFile(filepath.Join(root, pid.Name(), "fdinfo", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if fdinfoWritable(string(info)) {
+				writers[target] = true
+			}
+		}
+	}
+	return writers, nil
+}
+
+// fdinfoWritable reports whether an fdinfo flags line has write access.
+func fdinfoWritable(fdinfo string) bool {
+	for _, line := range strings.Split(fdinfo, "\n") {
+		value, ok := strings.CutPrefix(line, "flags:")
+		if !ok {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+		return err == nil && flags&syscall.O_ACCMODE != syscall.O_RDONLY
+	}
+	return false
+}
+
+// parseLsofWriters reads lsof -F an output, where an "a" access mode line
+// precedes each file's "n" name line, and returns the names opened with
+// write ("w") or read-write ("u") access.
+func parseLsofWriters(output string) map[string]bool {
+	writers := map[string]bool{}
+	writable := false
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'f':
+			writable = false
+		case 'a':
+			writable = strings.ContainsAny(line[1:], "wu")
+		case 'n':
+			if writable {
+				writers[line[1:]] = true
+			}
+		}
+	}
+	return writers
+}
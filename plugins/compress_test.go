@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLsofWriters(t *testing.T) {
+	output := "p101\nf3\nar\nn/var/log/read.log\nf4\naw\nn/var/log/write.log\np202\nf5\nau\nn/tmp/both.csv\nfcwd\na \nn/tmp\n"
+	writers := parseLsofWriters(output)
+	if len(writers) != 2 || !writers["/var/log/write.log"] || !writers["/tmp/both.csv"] {
+		t.Fatalf("unexpected writers: %v", writers)
+	}
+}
+
+func TestProcWriteOpenFiles(t *testing.T) {
+	root := t.TempDir()
+	fd := func(pid, n, target, flags string) {
+		if err := os.MkdirAll(filepath.Join(root, pid, "fd"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(root, pid, "fdinfo"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(root, pid, "fd", n)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, pid, "fdinfo", n), []byte("pos:\t0\nflags:\t"+flags+"\nmnt_id:\t26\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fd("10", "3", "/srv/scratch/read.csv", "0100000")
+	fd("10", "4", "/srv/scratch/append.log", "02102001")
+	fd("11", "5", "/srv/scratch/rw.db", "0100002")
+	fd("11", "6", "socket:[1234]", "02")
+
+	writers, err := procWriteOpenFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(writers) != 2 || !writers["/srv/scratch/append.log"] || !writers["/srv/scratch/rw.db"] {
+		t.Fatalf("unexpected writers: %v", writers)
+	}
+}
@@ -0,0 +1,244 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// ContainerdPlugin prunes containerd images, containers, volumes, and build
+// cache through nerdctl, as used by Rancher Desktop in containerd mode. It
+// works in nerdctl's default namespace, so Kubernetes images in k8s.io are
+// left to the kubelet.
+type ContainerdPlugin struct {
+	// nerdctl is the binary to run, set by detectNerdctl.
+	nerdctl string
+}
+
+// NewContainerdPlugin creates a new containerd cleanup plugin.
+func NewContainerdPlugin() *ContainerdPlugin {
+	return &ContainerdPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *ContainerdPlugin) Name() string {
+	return "containerd"
+}
+
+// Description returns the plugin description.
+func (p *ContainerdPlugin) Description() string {
+	return "Cleans containerd images, containers, volumes, and build cache through nerdctl (Rancher Desktop)"
+}
+
+// Priority runs nerdctl prune alongside Docker and Podman.
+func (p *ContainerdPlugin) Priority() int {
+	return 50
+}
+
+// DataPaths returns the Rancher Desktop VM directory that holds containerd
+// storage.
+func (p *ContainerdPlugin) DataPaths(cfg *config.Config) []string {
+	home, err := env.HomeDir()
+	if err != nil {
+		return nil
+	}
+	if runtime.GOOS == "darwin" {
+		return []string{filepath.Join(home, "Library/Application Support/rancher-desktop/lima")}
+	}
+	return []string{filepath.Join(home, ".local/share/rancher-desktop/lima")}
+}
+
+// SupportedPlatforms returns supported platforms (Darwin and Linux).
+func (p *ContainerdPlugin) SupportedPlatforms() []string {
+	return []string{PlatformDarwin, PlatformLinux}
+}
+
+// Enabled checks if containerd cleanup is enabled.
+func (p *ContainerdPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.Containerd
+}
+
+// LevelDescription summarizes containerd cleanup at each level.
+func (p *ContainerdPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "prunes dangling images"
+	case LevelModerate:
+		return "prunes dangling images, stopped containers, and unused build cache"
+	case LevelAggressive:
+		return "runs moderate cleanup, then prunes all unused images, unused volumes, unused networks, and all build cache"
+	case LevelCritical:
+		return "runs a full nerdctl system prune of all unused images, containers, networks, build cache, and volumes"
+	default:
+		return "no cleanup"
+	}
+}
+
+// nerdctlLevelCommands returns the nerdctl arguments run at each cleanup
+// level, in execution order.
+func nerdctlLevelCommands(level CleanupLevel) [][]string {
+	moderate := [][]string{
+		{"image", "prune", "-f"},
+		{"container", "prune", "-f"},
+		{"builder", "prune", "-f"},
+	}
+	switch level {
+	case LevelWarning:
+		return [][]string{{"image", "prune", "-f"}}
+	case LevelModerate:
+		return moderate
+	case LevelAggressive:
+		return append(moderate,
+			[]string{"image", "prune", "-af"},
+			[]string{"volume", "prune", "-f"},
+			[]string{"network", "prune", "-f"},
+			[]string{"builder", "prune", "-af"},
+		)
+	case LevelCritical:
+		return [][]string{{"system", "prune", "-af", "--volumes"}}
+	default:
+		return nil
+	}
+}
+
+// CleanupCommands returns the nerdctl commands Cleanup would run at level.
+// Commands run only when nerdctl is found, Rancher Desktop is not in dockerd
+// mode, and `nerdctl info` succeeds.
+func (p *ContainerdPlugin) CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	levelCommands := nerdctlLevelCommands(level)
+	commands := make([][]string, 0, len(levelCommands))
+	for _, args := range levelCommands {
+		commands = append(commands, append([]string{"nerdctl"}, args...))
+	}
+	return commands
+}
+
+// PlanCleanup returns a non-mutating containerd cleanup plan. nerdctl has no
+// disk usage summary, so the plan carries steps but no reclaim estimate.
+func (p *ContainerdPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "containerd cleanup plan",
+		WouldRun: true,
+		Steps:    containerdPlanSteps(level),
+		Metadata: map[string]string{"cleanup_level": level.String()},
+	}
+	if reason := p.unavailableReason(ctx, logger); reason != "" {
+		plan.Summary = "containerd is not available through nerdctl"
+		plan.WouldRun = false
+		plan.SkipReason = reason
+		return plan
+	}
+	plan.Metadata["nerdctl"] = p.nerdctl
+	plan.Warnings = append(plan.Warnings, "nerdctl reports no disk usage summary, so reclaim is only known after cleanup")
+	return plan
+}
+
+func containerdPlanSteps(level CleanupLevel) []string {
+	switch level {
+	case LevelWarning:
+		return []string{"Prune dangling containerd images"}
+	case LevelModerate:
+		return []string{
+			"Prune dangling containerd images",
+			"Prune stopped containerd containers",
+			"Prune unused nerdctl build cache",
+		}
+	case LevelAggressive:
+		return []string{
+			"Run moderate containerd cleanup",
+			"Prune all unused containerd images",
+			"Prune unused containerd volumes and networks",
+			"Prune all nerdctl build cache",
+		}
+	case LevelCritical:
+		return []string{"Run full nerdctl system prune with volumes"}
+	default:
+		return []string{"Report containerd cleanup state"}
+	}
+}
+
+// Cleanup performs containerd cleanup at the specified level. Prune failures
+// are logged and skipped so one unsupported subcommand does not block the
+// rest; a failed critical system prune is reported.
+func (p *ContainerdPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+
+	if reason := p.unavailableReason(ctx, logger); reason != "" {
+		logger.Debug("containerd not available, skipping", "reason", reason)
+		return result
+	}
+	if level == LevelCritical {
+		logger.Warn("CRITICAL: running full nerdctl system prune with volumes")
+	}
+
+	for _, args := range nerdctlLevelCommands(level) {
+		command := strings.Join(args, " ")
+		logger.Debug("running nerdctl prune", "command", command)
+		output, err := p.runNerdctl(ctx, args...)
+		if err != nil {
+			if level == LevelCritical {
+				result.Error = newCommandError(p.Name(), commandOperation(args), err, output)
+				return result
+			}
+			logger.Warn("nerdctl prune failed", "command", command, "error", err, "output", output)
+			continue
+		}
+		result.BytesFreed += parseReclaimedSpace(output)
+	}
+	return result
+}
+
+// unavailableReason detects nerdctl and returns why cleanup cannot run, or ""
+// when containerd is reachable.
+func (p *ContainerdPlugin) unavailableReason(ctx context.Context, logger *slog.Logger) string {
+	p.nerdctl = detectNerdctl()
+	if p.nerdctl == "" {
+		return "nerdctl_not_found"
+	}
+	// Rancher Desktop exposes ~/.rd/docker.sock only in dockerd (moby)
+	// mode, where the docker plugin with docker.socket pointed at it applies.
+	if home, err := env.HomeDir(); err == nil && pathExists(filepath.Join(home, ".rd", "docker.sock")) {
+		return "rancher_desktop_dockerd_mode"
+	}
+	if output, err := p.runNerdctlWithTimeout(ctx, 30*time.Second, "info"); err != nil {
+		logger.Debug("nerdctl info failed", "error", err, "output", output)
+		return "containerd_unreachable"
+	}
+	return ""
+}
+
+// detectNerdctl returns nerdctl from PATH, falling back to Rancher
+// Desktop's ~/.rd/bin, which a service's PATH usually lacks.
+func detectNerdctl() string {
+	if _, err := runner.LookPath("nerdctl"); err == nil {
+		return "nerdctl"
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		return ""
+	}
+	if path := filepath.Join(home, ".rd", "bin", "nerdctl"); pathExists(path) {
+		return path
+	}
+	return ""
+}
+
+func (p *ContainerdPlugin) runNerdctl(ctx context.Context, args ...string) (string, error) {
+	return p.runNerdctlWithTimeout(ctx, 5*time.Minute, args...)
+}
+
+func (p *ContainerdPlugin) runNerdctlWithTimeout(ctx context.Context, timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := runner.CombinedOutput(ctx, nil, p.nerdctl, args...)
+	return string(output), err
+}
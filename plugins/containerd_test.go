@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestContainerdCleanupModerateRunsPrunesInOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"nerdctl image prune -f":     {Output: "Total reclaimed space: 1MB\n"},
+		"nerdctl container prune -f": {Err: errors.New("exit status 1"), Output: "namespace busy"},
+		"nerdctl builder prune -f":   {Output: "Total reclaimed space: 1GB\n"},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewContainerdPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("moderate prune failures should not fail the plugin, got %v", result.Error)
+	}
+	if want := int64(1024*1024 + 1024*1024*1024); result.BytesFreed != want {
+		t.Fatalf("BytesFreed = %d, want %d", result.BytesFreed, want)
+	}
+	want := []string{
+		"nerdctl info",
+		"nerdctl image prune -f",
+		"nerdctl container prune -f",
+		"nerdctl builder prune -f",
+	}
+	if got := fake.commandLines("nerdctl"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nerdctl commands = %v, want %v", got, want)
+	}
+}
+
+func TestContainerdCleanupCriticalReportsPruneFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useFakeRunner(t, map[string]fakeResponse{
+		"nerdctl system prune -af --volumes": {Err: errors.New("exit status 1"), Output: "permission denied\n"},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewContainerdPlugin().Cleanup(context.Background(), LevelCritical, config.DefaultConfig(), logger)
+	pluginErr, ok := AsPluginError(result.Error)
+	if !ok || pluginErr.Operation != "system_prune" || pluginErr.CommandOutput != "permission denied" {
+		t.Fatalf("expected a system_prune PluginError, got %v", result.Error)
+	}
+}
+
+func TestContainerdDetectsRancherDesktop(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.DefaultConfig()
+
+	fake := useFakeRunner(t, nil)
+	fake.missing["nerdctl"] = true
+	if plan := NewContainerdPlugin().PlanCleanup(context.Background(), LevelModerate, cfg, logger); plan.SkipReason != "nerdctl_not_found" {
+		t.Fatalf("SkipReason = %q, want nerdctl_not_found", plan.SkipReason)
+	}
+
+	// Rancher Desktop's nerdctl is found off PATH.
+	nerdctl := filepath.Join(home, ".rd", "bin", "nerdctl")
+	writeAgedFile(t, nerdctl, 1, 0)
+	NewContainerdPlugin().Cleanup(context.Background(), LevelWarning, cfg, logger)
+	if got, want := fake.commandLines(nerdctl), []string{nerdctl + " info", nerdctl + " image prune -f"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %v, want %v", got, want)
+	}
+
+	// dockerd mode is left to the docker plugin.
+	if err := os.WriteFile(filepath.Join(home, ".rd", "docker.sock"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fake.calls = nil
+	plan := NewContainerdPlugin().PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.WouldRun || plan.SkipReason != "rancher_desktop_dockerd_mode" {
+		t.Fatalf("expected dockerd mode to skip, got %+v", plan)
+	}
+	if len(fake.calls) != 0 {
+		t.Fatalf("expected no nerdctl commands in dockerd mode, got %v", fake.calls)
+	}
+
+	// An unreachable containerd skips without pruning.
+	if err := os.Remove(filepath.Join(home, ".rd", "docker.sock")); err != nil {
+		t.Fatal(err)
+	}
+	fake.responses = map[string]fakeResponse{nerdctl + " info": {Err: errors.New("exit status 1")}}
+	fake.calls = nil
+	NewContainerdPlugin().Cleanup(context.Background(), LevelWarning, cfg, logger)
+	if got := fake.commandLines(nerdctl); !reflect.DeepEqual(got, []string{nerdctl + " info"}) {
+		t.Fatalf("expected only nerdctl info, got %v", got)
+	}
+}
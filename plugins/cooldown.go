@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/cooldown"
+)
+
+// CooldownOperations lists the cooldowns config keys plugins consult before
+// an expensive or destructive operation.
+var CooldownOperations = []string{
+	cooldown.Key("apfs-snapshots", "delete_snapshots"),
+	cooldown.Key("btrfs-snapshots", "delete_snapshots"),
+	cooldown.Key("cache", "go_modcache"),
+	cooldown.Key("lima", "disk_compact"),
+	cooldown.Key("podman", "disk_compact"),
+	cooldown.Key("zfs-snapshots", "delete_snapshots"),
+}
+
+// CooldownPath returns the operation history path beside the daemon state
+// file, or "" when no state file is configured.
+func CooldownPath(cfg *config.Config) string {
+	if cfg.Policy.StateFile == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.Policy.StateFile), cooldown.FileName)
+}
+
+func operationCooldown(cfg *config.Config, plugin, operation string) time.Duration {
+	hours := cfg.Cooldowns[cooldown.Key(plugin, operation)]
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// cooldownAllows gates an operation on its configured cooldown. Operations
+// without a cooldown are always allowed. Unreadable history refuses the
+// operation, since the last run cannot be ruled out.
+func cooldownAllows(cfg *config.Config, plugin, operation string, logger *slog.Logger) bool {
+	window := operationCooldown(cfg, plugin, operation)
+	if window <= 0 {
+		return true
+	}
+	remaining, err := cooldown.New(CooldownPath(cfg)).Remaining(plugin, operation, window)
+	if err != nil {
+		logger.Warn("skipping operation: cooldown history unreadable",
+			"plugin", plugin,
+			"operation", operation,
+			"path", CooldownPath(cfg),
+			"error", err)
+		return false
+	}
+	if remaining > 0 {
+		logger.Info("skipping operation during cooldown",
+			"plugin", plugin,
+			"operation", operation,
+			"remaining", remaining.Round(time.Minute).String())
+		return false
+	}
+	return true
+}
+
+// recordCooldown marks an operation as run. History is kept for every
+// operation, so a cooldown added later applies from the last real run.
+func recordCooldown(cfg *config.Config, plugin, operation string, logger *slog.Logger) {
+	if err := cooldown.New(CooldownPath(cfg)).Record(plugin, operation); err != nil {
+		logger.Warn("failed to record operation for cooldown",
+			"plugin", plugin,
+			"operation", operation,
+			"error", err)
+	}
+}
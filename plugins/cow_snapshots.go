@@ -0,0 +1,625 @@
+//go:build !darwin
+
+// Package plugins provides cleanup plugin implementations.
+// cow_snapshots.go thins old ZFS and Btrfs snapshots on monitored Linux
+// mounts. On copy-on-write filesystems, snapshots pin deleted blocks, so
+// removing old snapshots is often the only way to reclaim space.
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+const (
+	fsTypeZFS   = "zfs"
+	fsTypeBtrfs = "btrfs"
+)
+
+// procMountsPath lists the mounts visible to this process.
+const procMountsPath = "/proc/self/mounts"
+
+// CoWSnapshotsPlugin deletes old snapshots on ZFS or Btrfs mounts, keeping
+// the newest snapshots like the APFS plugin keeps the newest local snapshot.
+type CoWSnapshotsPlugin struct {
+	fsType        string
+	mountsFile    string
+	sudoCap       *SudoCapability
+	geteuid       func() int
+	freeDiskSpace func(string) (uint64, error)
+}
+
+// NewZFSSnapshotsPlugin creates a ZFS snapshot thinning plugin.
+func NewZFSSnapshotsPlugin() *CoWSnapshotsPlugin {
+	return newCoWSnapshotsPlugin(fsTypeZFS)
+}
+
+// NewBtrfsSnapshotsPlugin creates a Btrfs snapshot thinning plugin.
+func NewBtrfsSnapshotsPlugin() *CoWSnapshotsPlugin {
+	return newCoWSnapshotsPlugin(fsTypeBtrfs)
+}
+
+func newCoWSnapshotsPlugin(fsType string) *CoWSnapshotsPlugin {
+	return &CoWSnapshotsPlugin{
+		fsType:        fsType,
+		mountsFile:    procMountsPath,
+		geteuid:       os.Geteuid,
+		freeDiskSpace: getFreeDiskSpace,
+	}
+}
+
+// Name returns the plugin identifier.
+func (p *CoWSnapshotsPlugin) Name() string {
+	return p.fsType + "-snapshots"
+}
+
+// Description returns the plugin description.
+func (p *CoWSnapshotsPlugin) Description() string {
+	if p.fsType == fsTypeZFS {
+		return "Destroys old ZFS snapshots on monitored mounts to release pinned blocks"
+	}
+	return "Deletes old Btrfs snapshots on monitored mounts to release pinned extents"
+}
+
+// Priority runs snapshot deletion last with APFS; snapshots are not recoverable.
+func (p *CoWSnapshotsPlugin) Priority() int {
+	return 100
+}
+
+// SupportedPlatforms returns supported platforms (Linux only).
+func (p *CoWSnapshotsPlugin) SupportedPlatforms() []string {
+	return []string{PlatformLinux}
+}
+
+// Enabled checks if snapshot thinning is enabled for this filesystem.
+func (p *CoWSnapshotsPlugin) Enabled(cfg *config.Config) bool {
+	if p.fsType == fsTypeZFS {
+		return cfg.Enable.ZFSSnapshots
+	}
+	return cfg.Enable.BtrfsSnapshots
+}
+
+// DataPaths returns the monitored paths whose snapshots this plugin thins.
+func (p *CoWSnapshotsPlugin) DataPaths(cfg *config.Config) []string {
+	return cowSnapshotRoots(cfg)
+}
+
+// LevelDescription summarizes snapshot thinning at each level.
+func (p *CoWSnapshotsPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning, LevelModerate:
+		return "reports " + p.fsType + " snapshots on monitored mounts only"
+	case LevelAggressive, LevelCritical:
+		return "deletes " + p.fsType + " snapshots older than cow_snapshots.keep_recent_days, keeping the newest keep_recent, with root or passwordless sudo"
+	default:
+		return "no cleanup"
+	}
+}
+
+// CleanupCommands lists the snapshot commands with placeholders for the
+// mount and snapshot names, which are only known at run time.
+func (p *CoWSnapshotsPlugin) CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	if level < LevelAggressive {
+		return nil
+	}
+	if p.fsType == fsTypeZFS {
+		return [][]string{
+			zfsSnapshotListArgs("<dataset>"),
+			zfsSnapshotDestroyArgs("<dataset>@<snapshot>"),
+		}
+	}
+	return [][]string{
+		btrfsSubvolumeShowArgs("<mount>"),
+		btrfsSnapshotListArgs("<mount>"),
+		btrfsSnapshotDeleteArgs("<mount>/<snapshot>"),
+	}
+}
+
+// cowMount is one entry of /proc/self/mounts.
+type cowMount struct {
+	Device string
+	Path   string
+	FSType string
+}
+
+// cowSnapshot is one ZFS or Btrfs snapshot.
+type cowSnapshot struct {
+	// Name is the ZFS snapshot name or the Btrfs subvolume path as listed.
+	Name string
+	// Path is the Btrfs snapshot path under the mount, empty for ZFS.
+	Path    string
+	Created time.Time
+	// Bytes is the ZFS used property; Btrfs does not report snapshot size
+	// without quotas.
+	Bytes int64
+}
+
+// cowSnapshotRoots returns the configured monitored mount paths, or the home
+// directory when none are configured, matching the daemon's default check.
+func cowSnapshotRoots(cfg *config.Config) []string {
+	home, homeErr := env.HomeDir()
+	var roots []string
+	for _, mount := range cfg.MonitoredMounts {
+		if mount.Path != "" {
+			roots = append(roots, expandHome(mount.Path, home))
+		}
+	}
+	if len(roots) > 0 {
+		return roots
+	}
+	if homeErr == nil {
+		return []string{home}
+	}
+	return []string{"/"}
+}
+
+// parseProcMounts parses /proc/self/mounts, unescaping octal sequences such
+// as \040 in mount paths.
+func parseProcMounts(content string) []cowMount {
+	var mounts []cowMount
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		mounts = append(mounts, cowMount{
+			Device: unescapeMountField(fields[0]),
+			Path:   unescapeMountField(fields[1]),
+			FSType: fields[2],
+		})
+	}
+	return mounts
+}
+
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
+
+// mountContaining returns the most specific mount holding path. Later
+// entries win ties, since they shadow earlier mounts on the same point.
+func mountContaining(mounts []cowMount, path string) (cowMount, bool) {
+	path = filepath.Clean(path)
+	var best cowMount
+	found := false
+	for _, mount := range mounts {
+		if !pathWithin(path, mount.Path) {
+			continue
+		}
+		if !found || len(mount.Path) >= len(best.Path) {
+			best = mount
+			found = true
+		}
+	}
+	return best, found
+}
+
+func pathWithin(path, root string) bool {
+	if root == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+	return path == root || strings.HasPrefix(path, root+"/")
+}
+
+// snapshotMounts returns the distinct mounts of this plugin's filesystem type
+// that hold a monitored path.
+func (p *CoWSnapshotsPlugin) snapshotMounts(cfg *config.Config) ([]cowMount, error) {
+	content, err := os.ReadFile(p.mountsFile)
+	if err != nil {
+		return nil, err
+	}
+	mounts := parseProcMounts(string(content))
+	seen := map[string]bool{}
+	var matched []cowMount
+	for _, root := range cowSnapshotRoots(cfg) {
+		mount, ok := mountContaining(mounts, root)
+		if !ok || mount.FSType != p.fsType || seen[mount.Path] {
+			continue
+		}
+		seen[mount.Path] = true
+		matched = append(matched, mount)
+	}
+	return matched, nil
+}
+
+// cowDeleteCandidates returns snapshots that may be deleted. snapshots must
+// be sorted newest first. The newest keepRecent (at least one) and any
+// snapshot newer than keepDays are always kept.
+func cowDeleteCandidates(snapshots []cowSnapshot, keepRecent int, keepDays int, now time.Time) []cowSnapshot {
+	keepRecent = max(keepRecent, 1)
+	cutoff := now.Add(-time.Duration(max(keepDays, 0)) * 24 * time.Hour)
+	var candidates []cowSnapshot
+	for i, snapshot := range snapshots {
+		if i < keepRecent || snapshot.Created.After(cutoff) {
+			continue
+		}
+		candidates = append(candidates, snapshot)
+	}
+	return candidates
+}
+
+func sortSnapshotsNewestFirst(snapshots []cowSnapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+}
+
+func zfsSnapshotListArgs(dataset string) []string {
+	return []string{"zfs", "list", "-H", "-p", "-t", "snapshot", "-o", "name,creation,used", "-d", "1", dataset}
+}
+
+func zfsSnapshotDestroyArgs(snapshot string) []string {
+	return []string{"zfs", "destroy", snapshot}
+}
+
+// parseZFSSnapshots parses zfs list -H -p output: name, creation as Unix
+// seconds, and used bytes, tab separated.
+func parseZFSSnapshots(output string) []cowSnapshot {
+	var snapshots []cowSnapshot
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 3 || !strings.Contains(fields[0], "@") {
+			continue
+		}
+		created, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		used, _ := strconv.ParseInt(fields[2], 10, 64)
+		snapshots = append(snapshots, cowSnapshot{
+			Name:    fields[0],
+			Created: time.Unix(created, 0),
+			Bytes:   used,
+		})
+	}
+	sortSnapshotsNewestFirst(snapshots)
+	return snapshots
+}
+
+func btrfsSubvolumeShowArgs(mount string) []string {
+	return []string{"btrfs", "subvolume", "show", mount}
+}
+
+func btrfsSnapshotListArgs(mount string) []string {
+	return []string{"btrfs", "subvolume", "list", "-s", mount}
+}
+
+func btrfsSnapshotDeleteArgs(path string) []string {
+	return []string{"btrfs", "subvolume", "delete", "--commit-after", path}
+}
+
+// parseBtrfsSubvolumePath returns the mounted subvolume's path relative to
+// the top-level subvolume, from the first line of btrfs subvolume show.
+// The top level itself is reported as "/" and returned as "".
+func parseBtrfsSubvolumePath(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "/" || line == "<FS_TREE>" {
+			return ""
+		}
+		return strings.Trim(line, "/")
+	}
+	return ""
+}
+
+// parseBtrfsSnapshots parses btrfs subvolume list -s output, e.g.
+// "ID 261 gen 30 cgen 30 top level 5 otime 2026-01-10 10:00:00 path @snapshots/home".
+// Paths are relative to the top-level subvolume; only snapshots inside the
+// mounted subvolume, whose path prefix is subvolume, are reachable through
+// mount. Unreachable snapshots are returned with an empty Path.
+func parseBtrfsSnapshots(output string, mount string, subvolume string) []cowSnapshot {
+	var snapshots []cowSnapshot
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		otime := strings.Index(line, " otime ")
+		pathIndex := strings.Index(line, " path ")
+		if otime < 0 || pathIndex < otime {
+			continue
+		}
+		created, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(line[otime+len(" otime "):pathIndex]), time.Local)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(line[pathIndex+len(" path "):]), "<FS_TREE>/")
+		snapshot := cowSnapshot{Name: name, Created: created}
+		switch {
+		case subvolume == "":
+			snapshot.Path = filepath.Join(mount, name)
+		case strings.HasPrefix(name, subvolume+"/"):
+			snapshot.Path = filepath.Join(mount, strings.TrimPrefix(name, subvolume+"/"))
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sortSnapshotsNewestFirst(snapshots)
+	return snapshots
+}
+
+// privilege reports whether snapshot commands can run, and whether they need
+// sudo -n to do so.
+func (p *CoWSnapshotsPlugin) privilege(ctx context.Context) (useSudo bool, ok bool) {
+	if p.geteuid() == 0 {
+		return false, true
+	}
+	if p.sudoCap == nil {
+		cap := DetectSudo(ctx)
+		p.sudoCap = &cap
+	}
+	return p.sudoCap.Passwordless, p.sudoCap.Passwordless
+}
+
+func (p *CoWSnapshotsPlugin) run(ctx context.Context, useSudo bool, args []string) (string, error) {
+	name, rest := args[0], args[1:]
+	if useSudo {
+		name, rest = "sudo", append([]string{"-n"}, args...)
+	}
+	output, err := runner.CombinedOutput(ctx, nil, name, rest...)
+	return string(output), err
+}
+
+// listSnapshots lists the snapshots of one mount, newest first.
+func (p *CoWSnapshotsPlugin) listSnapshots(ctx context.Context, mount cowMount, useSudo bool) ([]cowSnapshot, error) {
+	if p.fsType == fsTypeZFS {
+		output, err := p.run(ctx, useSudo, zfsSnapshotListArgs(mount.Device))
+		if err != nil {
+			return nil, newCommandError(p.Name(), "zfs list", err, output)
+		}
+		return parseZFSSnapshots(output), nil
+	}
+
+	show, err := p.run(ctx, useSudo, btrfsSubvolumeShowArgs(mount.Path))
+	if err != nil {
+		return nil, newCommandError(p.Name(), "btrfs subvolume show", err, show)
+	}
+	output, err := p.run(ctx, useSudo, btrfsSnapshotListArgs(mount.Path))
+	if err != nil {
+		return nil, newCommandError(p.Name(), "btrfs subvolume list", err, output)
+	}
+	return parseBtrfsSnapshots(output, mount.Path, parseBtrfsSubvolumePath(show)), nil
+}
+
+func (p *CoWSnapshotsPlugin) deleteArgs(mount cowMount, snapshot cowSnapshot) []string {
+	if p.fsType == fsTypeZFS {
+		return zfsSnapshotDestroyArgs(snapshot.Name)
+	}
+	return btrfsSnapshotDeleteArgs(snapshot.Path)
+}
+
+// deletable reports whether snapshot belongs to mount and can be addressed
+// without touching anything else: a ZFS snapshot of exactly this dataset,
+// or a Btrfs snapshot reachable under the mount.
+func (p *CoWSnapshotsPlugin) deletable(mount cowMount, snapshot cowSnapshot) bool {
+	if p.fsType == fsTypeZFS {
+		return strings.HasPrefix(snapshot.Name, mount.Device+"@")
+	}
+	return snapshot.Path != "" && pathWithin(snapshot.Path, mount.Path) && snapshot.Path != mount.Path
+}
+
+// PlanCleanup returns a non-mutating snapshot thinning plan.
+func (p *CoWSnapshotsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	snapCfg := cfg.CoWSnapshots
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  p.fsType + " snapshot review plan",
+		WouldRun: true,
+		Steps: []string{
+			"Find monitored mounts on " + p.fsType,
+			"List snapshots on each mount",
+			fmt.Sprintf("Delete snapshots older than %d day(s) at aggressive or critical level, keeping the newest %d", snapCfg.KeepRecentDays, max(snapCfg.KeepRecent, 1)),
+			"Measure the mount's free-space change",
+		},
+		Metadata: map[string]string{
+			"cleanup_level":    level.String(),
+			"filesystem":       p.fsType,
+			"keep_recent":      strconv.Itoa(max(snapCfg.KeepRecent, 1)),
+			"keep_recent_days": strconv.Itoa(snapCfg.KeepRecentDays),
+		},
+	}
+
+	mounts, err := p.snapshotMounts(cfg)
+	if err != nil {
+		plan.Summary = "mounts could not be read"
+		plan.WouldRun = false
+		plan.SkipReason = "mounts_unavailable"
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not read %s: %v", p.mountsFile, err))
+		return plan
+	}
+	if len(mounts) == 0 {
+		plan.Summary = "No monitored mount is on " + p.fsType
+		plan.WouldRun = false
+		plan.SkipReason = "no_" + p.fsType + "_mounts"
+		return plan
+	}
+	if _, err := runner.LookPath(p.fsType); err != nil {
+		plan.Summary = p.fsType + " tooling is not available"
+		plan.WouldRun = false
+		plan.SkipReason = p.fsType + "_unavailable"
+		return plan
+	}
+
+	useSudo, privileged := p.privilege(ctx)
+	plan.Metadata["sudo"] = strconv.FormatBool(useSudo)
+	now := time.Now()
+	var snapshotCount int
+	for _, mount := range mounts {
+		snapshots, err := p.listSnapshots(ctx, mount, useSudo)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not list snapshots on %s: %v", mount.Path, err))
+			continue
+		}
+		snapshotCount += len(snapshots)
+		candidates := map[string]bool{}
+		for _, snapshot := range cowDeleteCandidates(snapshots, snapCfg.KeepRecent, snapCfg.KeepRecentDays, now) {
+			candidates[snapshot.Name] = true
+		}
+		for _, snapshot := range snapshots {
+			target := CleanupTarget{
+				Type:      p.fsType + "-snapshot",
+				Name:      snapshot.Name,
+				Path:      snapshot.Path,
+				Bytes:     snapshot.Bytes,
+				Protected: true,
+				Action:    "keep",
+				Reason:    "snapshot is newer than keep_recent_days or among the newest keep_recent",
+			}
+			switch {
+			case !candidates[snapshot.Name]:
+			case !p.deletable(mount, snapshot):
+				target.Reason = "snapshot is outside the mounted subvolume and cannot be addressed"
+			case level < LevelAggressive:
+				target.Action = "review"
+				target.Reason = "old snapshot is deleted at aggressive or critical level"
+			case !privileged:
+				target.Reason = "snapshot deletion requires root or passwordless sudo"
+			default:
+				target.Protected = false
+				target.Action = "delete_snapshot"
+				target.Reason = "snapshot is older than keep_recent_days"
+			}
+			annotateCleanupTargetPolicy(&target, CleanupTierPrivileged, hostReclaimForAction(target.Action))
+			plan.Targets = append(plan.Targets, target)
+			if !target.Protected {
+				plan.EstimatedBytesFreed += target.Bytes
+			}
+		}
+	}
+	plan.Metadata["snapshot_count"] = strconv.Itoa(snapshotCount)
+
+	switch {
+	case level < LevelAggressive:
+		plan.Summary = p.fsType + " snapshots are report-only below aggressive level"
+		plan.WouldRun = false
+		plan.SkipReason = "report_only"
+	case !privileged:
+		plan.Summary = p.fsType + " snapshot deletion is deferred because root or passwordless sudo is unavailable"
+		plan.WouldRun = false
+		plan.SkipReason = "sudo_required"
+	}
+	if p.fsType == fsTypeBtrfs {
+		plan.Warnings = append(plan.Warnings, "Btrfs does not report snapshot size without quotas; reclaim is measured from free space after deletion")
+	}
+	return plan
+}
+
+// Cleanup deletes old snapshots at aggressive and critical levels and
+// reports the measured free-space change of each mount.
+func (p *CoWSnapshotsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{
+		Plugin: p.Name(),
+		Level:  level,
+	}
+
+	mounts, err := p.snapshotMounts(cfg)
+	if err != nil {
+		logger.Debug("could not read mounts", "plugin", p.Name(), "error", err)
+		return result
+	}
+	if len(mounts) == 0 {
+		logger.Debug("no monitored mount on filesystem", "plugin", p.Name(), "filesystem", p.fsType)
+		return result
+	}
+	if _, err := runner.LookPath(p.fsType); err != nil {
+		logger.Debug("snapshot tooling not available", "plugin", p.Name(), "tool", p.fsType)
+		return result
+	}
+	useSudo, privileged := p.privilege(ctx)
+	if !privileged {
+		logger.Warn("root or passwordless sudo required for snapshot cleanup, skipping", "plugin", p.Name())
+		return result
+	}
+
+	snapCfg := cfg.CoWSnapshots
+	now := time.Now()
+	for _, mount := range mounts {
+		snapshots, err := p.listSnapshots(ctx, mount, useSudo)
+		if err != nil {
+			logger.Warn("failed to list snapshots", "plugin", p.Name(), "mount", mount.Path, "error", err)
+			result.Error = err
+			continue
+		}
+		candidates := cowDeleteCandidates(snapshots, snapCfg.KeepRecent, snapCfg.KeepRecentDays, now)
+		if level < LevelAggressive {
+			logger.Info("snapshots on monitored mount",
+				"plugin", p.Name(),
+				"mount", mount.Path,
+				"count", len(snapshots),
+				"deletable_at_aggressive", len(candidates))
+			continue
+		}
+		if len(candidates) == 0 || !cooldownAllows(cfg, p.Name(), "delete_snapshots", logger) {
+			continue
+		}
+
+		before, measured := p.measureFree(mount.Path, logger)
+		// Delete oldest first, so an interrupted run keeps the newer history.
+		for i := len(candidates) - 1; i >= 0; i-- {
+			snapshot := candidates[i]
+			if ctx.Err() != nil {
+				break
+			}
+			if !p.deletable(mount, snapshot) {
+				logger.Debug("skipping snapshot outside the mounted subvolume", "plugin", p.Name(), "snapshot", snapshot.Name)
+				continue
+			}
+			logger.Warn("deleting old snapshot", "plugin", p.Name(), "snapshot", snapshot.Name, "created", snapshot.Created.Format(time.RFC3339))
+			if output, err := p.run(ctx, useSudo, p.deleteArgs(mount, snapshot)); err != nil {
+				logger.Warn("failed to delete snapshot", "plugin", p.Name(), "snapshot", snapshot.Name, "error", err, "output", strings.TrimSpace(output))
+				continue
+			}
+			result.ItemsCleaned++
+			result.EstimatedBytesFreed += snapshot.Bytes
+		}
+		if measured {
+			if after, ok := p.measureFree(mount.Path, logger); ok && after > before {
+				result.HostBytesFreed += after - before
+			}
+		}
+	}
+	if result.ItemsCleaned > 0 {
+		recordCooldown(cfg, p.Name(), "delete_snapshots", logger)
+	}
+	// Snapshot sizes are estimates that overlap; the free-space change is
+	// what the deletions actually released.
+	result.BytesFreed = result.HostBytesFreed
+	if result.ItemsCleaned > 0 {
+		logger.Info("snapshot cleanup complete",
+			"plugin", p.Name(),
+			"deleted", result.ItemsCleaned,
+			"freed_mb", result.HostBytesFreed/(1024*1024))
+	}
+	return result
+}
+
+func (p *CoWSnapshotsPlugin) measureFree(path string, logger *slog.Logger) (int64, bool) {
+	free, err := p.freeDiskSpace(path)
+	if err != nil {
+		logger.Debug("could not measure free space", "plugin", p.Name(), "path", path, "error", err)
+		return 0, false
+	}
+	return int64(free), true
+}
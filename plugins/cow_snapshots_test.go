@@ -0,0 +1,248 @@
+//go:build !darwin
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// newTestCoWPlugin returns a plugin reading mounts from a temp file, running
+// as root, and measuring free space from the given sequence.
+func newTestCoWPlugin(t *testing.T, fsType string, mounts string, free ...uint64) *CoWSnapshotsPlugin {
+	t.Helper()
+
+	mountsFile := filepath.Join(t.TempDir(), "mounts")
+	if err := os.WriteFile(mountsFile, []byte(mounts), 0o644); err != nil {
+		t.Fatalf("write mounts: %v", err)
+	}
+	p := newCoWSnapshotsPlugin(fsType)
+	p.mountsFile = mountsFile
+	p.geteuid = func() int { return 0 }
+	p.freeDiskSpace = func(string) (uint64, error) {
+		if len(free) == 0 {
+			return 0, fmt.Errorf("no free-space reading left")
+		}
+		value := free[0]
+		free = free[1:]
+		return value, nil
+	}
+	return p
+}
+
+func cowTestConfig(t *testing.T, mountPath string) *config.Config {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.MonitoredMounts = []config.MountConfig{{Path: mountPath}}
+	cfg.CoWSnapshots = config.CoWSnapshotsConfig{KeepRecent: 1, KeepRecentDays: 7}
+	return cfg
+}
+
+func TestCoWSnapshotsRunWithVMWork(t *testing.T) {
+	for _, p := range []*CoWSnapshotsPlugin{NewZFSSnapshotsPlugin(), NewBtrfsSnapshotsPlugin()} {
+		if got := PluginPriority(p); got != 100 {
+			t.Errorf("%s priority = %d, want 100", p.Name(), got)
+		}
+	}
+}
+
+func TestParseProcMounts(t *testing.T) {
+	mounts := parseProcMounts(`rpool/ROOT/ubuntu / zfs rw,relatime 0 0
+/dev/nvme0n1p2 /home btrfs rw,subvol=/@home 0 0
+/dev/sdb1 /mnt/my\040disk ext4 rw 0 0
+`)
+	if len(mounts) != 3 {
+		t.Fatalf("expected 3 mounts, got %d", len(mounts))
+	}
+	if mounts[2].Path != "/mnt/my disk" {
+		t.Fatalf("expected escaped space decoded, got %q", mounts[2].Path)
+	}
+
+	mount, ok := mountContaining(mounts, "/home/user/src")
+	if !ok || mount.FSType != "btrfs" || mount.Path != "/home" {
+		t.Fatalf("expected /home btrfs mount, got %+v", mount)
+	}
+	mount, ok = mountContaining(mounts, "/var/lib")
+	if !ok || mount.Device != "rpool/ROOT/ubuntu" {
+		t.Fatalf("expected root zfs mount, got %+v", mount)
+	}
+	if mount, _ := mountContaining(mounts, "/homework"); mount.Path != "/" {
+		t.Fatalf("/homework should not match /home, got %+v", mount)
+	}
+}
+
+func TestCoWDeleteCandidatesKeepRecent(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []cowSnapshot{
+		{Name: "a", Created: now.Add(-30 * 24 * time.Hour)},
+		{Name: "b", Created: now.Add(-2 * 24 * time.Hour)},
+		{Name: "c", Created: now.Add(-20 * 24 * time.Hour)},
+		{Name: "d", Created: now.Add(-10 * 24 * time.Hour)},
+	}
+	sortSnapshotsNewestFirst(snapshots)
+
+	var names []string
+	for _, snapshot := range cowDeleteCandidates(snapshots, 2, 7, now) {
+		names = append(names, snapshot.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"c", "a"}) {
+		t.Fatalf("expected the two oldest beyond keep_recent=2, got %v", names)
+	}
+
+	if got := cowDeleteCandidates(snapshots[:1], 0, 0, now); len(got) != 0 {
+		t.Fatalf("the only snapshot must always be kept, got %+v", got)
+	}
+}
+
+func TestParseBtrfsSnapshots(t *testing.T) {
+	output := `ID 260 gen 20 cgen 20 top level 5 otime 2026-01-05 10:00:00 path @home/.snapshots/1/snapshot
+ID 261 gen 30 cgen 30 top level 5 otime 2026-02-10 10:00:00 path @home/.snapshots/2/snapshot
+ID 262 gen 31 cgen 31 top level 5 otime 2026-01-01 09:00:00 path @snapshots/root-old
+`
+	snapshots := parseBtrfsSnapshots(output, "/home", parseBtrfsSubvolumePath("@home\n\tName: \t@home\n"))
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(snapshots))
+	}
+	if snapshots[0].Path != "/home/.snapshots/2/snapshot" {
+		t.Fatalf("expected newest snapshot first under the mount, got %+v", snapshots[0])
+	}
+	if snapshots[2].Name != "@snapshots/root-old" || snapshots[2].Path != "" {
+		t.Fatalf("snapshot outside the mounted subvolume should be unreachable, got %+v", snapshots[2])
+	}
+
+	top := parseBtrfsSnapshots(output, "/mnt/pool", parseBtrfsSubvolumePath("/\n"))
+	if top[2].Path != "/mnt/pool/@snapshots/root-old" {
+		t.Fatalf("top-level mount should reach every snapshot, got %+v", top[2])
+	}
+}
+
+func TestZFSSnapshotsCleanupDeletesOldSnapshots(t *testing.T) {
+	now := time.Now()
+	list := strings.Join([]string{
+		fmt.Sprintf("tank/home@old\t%d\t%d", now.Add(-40*24*time.Hour).Unix(), 3<<30),
+		fmt.Sprintf("tank/home@mid\t%d\t%d", now.Add(-20*24*time.Hour).Unix(), 1<<30),
+		fmt.Sprintf("tank/home@new\t%d\t%d", now.Add(-1*time.Hour).Unix(), 1<<20),
+	}, "\n")
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"zfs list -H -p -t snapshot -o name,creation,used -d 1 tank/home": {Output: list},
+		"zfs destroy tank/home@mid":                                       {Err: fmt.Errorf("exit status 1"), Output: "snapshot is held"},
+	})
+	p := newTestCoWPlugin(t, fsTypeZFS, "tank/home /home zfs rw 0 0\n", 10<<30, 13<<30)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := p.Cleanup(context.Background(), LevelAggressive, cowTestConfig(t, "/home"), logger)
+
+	destroyed := fake.commandLines("zfs destroy")
+	if !reflect.DeepEqual(destroyed, []string{"zfs destroy tank/home@old", "zfs destroy tank/home@mid"}) {
+		t.Fatalf("expected old then mid destroyed, newest kept, got %v", destroyed)
+	}
+	if result.ItemsCleaned != 1 || result.EstimatedBytesFreed != 3<<30 {
+		t.Fatalf("expected one deleted 3 GiB snapshot, got %+v", result)
+	}
+	if result.HostBytesFreed != 3<<30 || result.BytesFreed != result.HostBytesFreed {
+		t.Fatalf("expected the measured 3 GiB free-space delta, got %+v", result)
+	}
+}
+
+func TestCoWSnapshotsReportOnlyBelowAggressive(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"btrfs subvolume show /data": {Output: "/\n"},
+		"btrfs subvolume list -s /data": {Output: "ID 300 gen 1 cgen 1 top level 5 otime 2020-01-01 00:00:00 path snaps/a\n" +
+			"ID 301 gen 2 cgen 2 top level 5 otime 2020-02-01 00:00:00 path snaps/b\n"},
+	})
+	p := newTestCoWPlugin(t, fsTypeBtrfs, "/dev/sda1 /data btrfs rw 0 0\n")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := cowTestConfig(t, "/data")
+
+	result := p.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.ItemsCleaned != 0 || len(fake.commandLines("btrfs subvolume delete")) != 0 {
+		t.Fatalf("moderate level must not delete snapshots, got %+v", result)
+	}
+
+	plan := p.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.WouldRun || plan.SkipReason != "report_only" {
+		t.Fatalf("expected report-only plan, got %+v", plan)
+	}
+	if len(plan.Targets) != 2 || plan.Targets[0].Action != "keep" || plan.Targets[1].Action != "review" {
+		t.Fatalf("expected newest kept and oldest under review, got %+v", plan.Targets)
+	}
+
+	plan = p.PlanCleanup(context.Background(), LevelCritical, cfg, logger)
+	if !plan.WouldRun || plan.Targets[1].Action != "delete_snapshot" || plan.Targets[1].Path != "/data/snaps/a" {
+		t.Fatalf("expected the old snapshot planned for deletion at critical, got %+v", plan.Targets)
+	}
+}
+
+func TestCoWSnapshotsSkipOtherFilesystems(t *testing.T) {
+	fake := useFakeRunner(t, nil)
+	p := newTestCoWPlugin(t, fsTypeZFS, "/dev/sda1 / ext4 rw 0 0\n")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := p.Cleanup(context.Background(), LevelCritical, cowTestConfig(t, "/"), logger)
+	if result.ItemsCleaned != 0 || len(fake.calls) != 0 {
+		t.Fatalf("expected no commands on ext4, got %v", fake.calls)
+	}
+	plan := p.PlanCleanup(context.Background(), LevelCritical, cowTestConfig(t, "/"), logger)
+	if plan.SkipReason != "no_zfs_mounts" {
+		t.Fatalf("expected no_zfs_mounts, got %q", plan.SkipReason)
+	}
+}
+
+func TestCoWSnapshotsUseSudoWhenNotRoot(t *testing.T) {
+	fake := useFakeRunner(t, nil)
+	p := newTestCoWPlugin(t, fsTypeZFS, "tank/data /data zfs rw 0 0\n")
+	p.geteuid = func() int { return 1000 }
+	p.sudoCap = &SudoCapability{Available: true, Passwordless: true}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	p.Cleanup(context.Background(), LevelAggressive, cowTestConfig(t, "/data"), logger)
+	if lines := fake.commandLines("sudo -n zfs list"); len(lines) != 1 {
+		t.Fatalf("expected zfs list through sudo -n, got %v", fake.calls)
+	}
+
+	p.sudoCap = &SudoCapability{Available: true}
+	fake.calls = nil
+	p.Cleanup(context.Background(), LevelAggressive, cowTestConfig(t, "/data"), logger)
+	if len(fake.calls) != 0 {
+		t.Fatalf("expected no commands without passwordless sudo, got %v", fake.calls)
+	}
+}
+
+func TestCoWSnapshotsHonorCooldown(t *testing.T) {
+	old := fmt.Sprintf("tank/data@old\t%d\t%d", time.Now().Add(-40*24*time.Hour).Unix(), 1<<30)
+	newest := fmt.Sprintf("tank/data@new\t%d\t%d", time.Now().Unix(), 1<<20)
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"zfs list -H -p -t snapshot -o name,creation,used -d 1 tank/data": {Output: old + "\n" + newest},
+	})
+	p := newTestCoWPlugin(t, fsTypeZFS, "tank/data /data zfs rw 0 0\n")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := cowTestConfig(t, "/data")
+	cfg.Cooldowns = map[string]float64{"zfs-snapshots.delete_snapshots": 24}
+
+	if result := p.Cleanup(context.Background(), LevelAggressive, cfg, logger); result.ItemsCleaned != 1 {
+		t.Fatalf("expected the first run to delete, got %+v", result)
+	}
+	if result := p.Cleanup(context.Background(), LevelAggressive, cfg, logger); result.ItemsCleaned != 0 {
+		t.Fatalf("expected the second run to wait out the cooldown, got %+v", result)
+	}
+	if destroyed := fake.commandLines("zfs destroy"); len(destroyed) != 1 {
+		t.Fatalf("expected one destroy across both runs, got %v", destroyed)
+	}
+
+	cfg.Cooldowns = nil
+	if result := p.Cleanup(context.Background(), LevelAggressive, cfg, logger); result.ItemsCleaned != 1 {
+		t.Fatalf("expected deletion once no cooldown is configured, got %+v", result)
+	}
+}
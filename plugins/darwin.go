@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 const darwinDevCacheGiB = int64(1024 * 1024 * 1024)
@@ -38,6 +39,11 @@ func (p *HomebrewPlugin) Description() string {
 	return "Cleans Homebrew caches and old formula versions"
 }
 
+// Priority runs Homebrew cleanup early; old bottles and downloads are re-fetchable.
+func (p *HomebrewPlugin) Priority() int {
+	return 15
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *HomebrewPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -48,9 +54,24 @@ func (p *HomebrewPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Homebrew
 }
 
+// LevelDescription summarizes Homebrew cleanup at each level.
+func (p *HomebrewPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "runs brew cleanup -s to remove the downloads cache"
+	case LevelModerate:
+		return "runs brew cleanup --prune=0 to remove old formula and cask versions"
+	case LevelAggressive:
+		return "runs brew cleanup --prune=0 to remove old formula and cask versions; with homebrew.include_casks, also cleans installed casks and removes cached cask installers"
+	case LevelCritical:
+		return "runs brew autoremove for unused dependencies, then brew cleanup --prune=0; with homebrew.include_casks, also cleans installed casks and removes cached cask installers"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup reports Homebrew cleanup candidates without mutating Homebrew state.
 func (p *HomebrewPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
-	_ = cfg
 	_ = logger
 
 	plan := CleanupPlan{
@@ -58,20 +79,27 @@ func (p *HomebrewPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cf
 		Level:    level.String(),
 		Summary:  "Homebrew cleanup plan",
 		WouldRun: true,
-		Steps:    homebrewPlanSteps(level),
+		Steps:    homebrewPlanSteps(level, cfg.Homebrew),
 		Metadata: map[string]string{
 			"cleanup_level": level.String(),
 		},
 	}
 
-	if _, err := exec.LookPath("brew"); err != nil {
+	if _, err := runner.LookPath("brew"); err != nil {
 		plan.Summary = "Homebrew is not available"
 		plan.WouldRun = false
 		plan.SkipReason = "brew_unavailable"
 		return plan
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Homebrew cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 	cachePath := filepath.Join(home, "Library", "Caches", "Homebrew")
 	cacheBytes := getDirSize(cachePath)
 	dryRunBytes, dryRunErr := p.cleanupDryRunEstimate(ctx)
@@ -104,7 +132,7 @@ func (p *HomebrewPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *c
 	}
 
 	// Check if brew is available
-	if _, err := exec.LookPath("brew"); err != nil {
+	if _, err := runner.LookPath("brew"); err != nil {
 		logger.Debug("brew not available, skipping")
 		return result
 	}
@@ -121,23 +149,25 @@ func (p *HomebrewPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *c
 		result = p.cleanupCritical(ctx, logger)
 	}
 
+	if level >= LevelAggressive && cfg.Homebrew.IncludeCasks {
+		freed, removed := p.cleanCasks(ctx, logger)
+		result.BytesFreed += freed
+		result.ItemsCleaned += removed
+	}
+
 	return result
 }
 
 func (p *HomebrewPlugin) cleanCache(ctx context.Context, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: LevelWarning}
 
-	// Get cache size before
-	home, _ := os.UserHomeDir()
-	cachePath := filepath.Join(home, "Library", "Caches", "Homebrew")
-	sizeBefore := getDirSize(cachePath)
+	if _, err := env.HomeDir(); err != nil {
+		logger.Warn("skipping Homebrew cache cleanup: home directory unavailable", "error", err)
+		return result
+	}
 
 	logger.Debug("cleaning Homebrew cache")
-	cmd := exec.CommandContext(ctx, "brew", "cleanup", "-s")
-	cmd.Run() // Ignore errors
-
-	sizeAfter := getDirSize(cachePath)
-	result.BytesFreed = sizeBefore - sizeAfter
+	result.BytesFreed = p.brewCleanup(ctx, logger, "-s")
 	return result
 }
 
@@ -148,11 +178,7 @@ func (p *HomebrewPlugin) cleanupPrune(ctx context.Context, logger *slog.Logger)
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "brew", "cleanup", "--prune=0")
-	output, _ := cmd.CombinedOutput()
-
-	// Parse "Removing: /path/to/file... (X.X MB)"
-	result.BytesFreed = parseBrewCleanupOutput(string(output))
+	result.BytesFreed = p.brewCleanup(ctx, logger, "--prune=0")
 	return result
 }
 
@@ -161,24 +187,125 @@ func (p *HomebrewPlugin) cleanupCritical(ctx context.Context, logger *slog.Logge
 
 	// First autoremove unused dependencies
 	logger.Warn("CRITICAL: running brew autoremove")
-	autoremoveCmd := exec.CommandContext(ctx, "brew", "autoremove")
-	autoremoveCmd.Run()
+	if output, err := runner.CombinedOutput(ctx, nil, "brew", "autoremove"); err != nil {
+		logger.Warn("brew autoremove failed", "error", err, "output", strings.TrimSpace(string(output)))
+	}
 
 	// Then full cleanup
 	logger.Warn("CRITICAL: running brew cleanup --prune=0")
-	cleanupCmd := exec.CommandContext(ctx, "brew", "cleanup", "--prune=0")
-	output, _ := cleanupCmd.CombinedOutput()
-
-	result.BytesFreed = parseBrewCleanupOutput(string(output))
+	result.BytesFreed = p.brewCleanup(ctx, logger, "--prune=0")
 	return result
 }
 
+// cleanCasks runs brew cleanup for each installed cask, then removes the
+// cached installers left under the download cache's Cask directory. It only
+// touches download artifacts; installed casks are never uninstalled. It
+// returns the bytes freed and the installers removed.
+func (p *HomebrewPlugin) cleanCasks(ctx context.Context, logger *slog.Logger) (int64, int) {
+	output, err := runner.Output(ctx, nil, "brew", "list", "--cask")
+	if err != nil {
+		logger.Warn("skipping Homebrew cask cleanup: brew list --cask failed", "error", err)
+		return 0, 0
+	}
+	casks := strings.Fields(string(output))
+	if len(casks) == 0 {
+		logger.Debug("no Homebrew casks installed, skipping cask cleanup")
+		return 0, 0
+	}
+
+	logger.Debug("running brew cleanup for installed casks", "casks", len(casks))
+	freed := p.brewCleanup(ctx, logger, append([]string{"--prune=0"}, casks...)...)
+
+	home, err := env.HomeDir()
+	if err != nil {
+		return freed, 0
+	}
+	cacheDir := filepath.Join(home, "Library", "Caches", "Homebrew")
+	installers, removed := removeCaskInstallers(cacheDir, logger)
+	logger.Info("cleaned Homebrew cask downloads", "casks", len(casks), "installers_removed", removed, "freed_mb", (freed+installers)/(1024*1024))
+	return freed + installers, removed
+}
+
+// removeCaskInstallers removes each entry in cacheDir/Cask. Entries are
+// usually symlinks into the download cache, so a link's target is removed
+// too when it lies inside cacheDir.
+func removeCaskInstallers(cacheDir string, logger *slog.Logger) (int64, int) {
+	caskDir := filepath.Join(cacheDir, "Cask")
+	entries, err := os.ReadDir(caskDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("could not read Homebrew cask download cache", "path", caskDir, "error", err)
+		}
+		return 0, 0
+	}
+
+	realCacheDir, err := filepath.EvalSymlinks(cacheDir)
+	if err != nil {
+		realCacheDir = cacheDir
+	}
+
+	var freed int64
+	removed := 0
+	for _, entry := range entries {
+		path := filepath.Join(caskDir, entry.Name())
+		var size int64
+		paths := []string{path}
+		if entry.Type()&os.ModeSymlink != 0 {
+			if target, err := filepath.EvalSymlinks(path); err == nil && strings.HasPrefix(target, realCacheDir+string(filepath.Separator)) {
+				size = getDirSize(target)
+				paths = append(paths, target)
+			}
+		} else {
+			size = getDirSize(path)
+		}
+		failed := false
+		for _, remove := range paths {
+			if err := os.RemoveAll(remove); err != nil {
+				logger.Warn("could not remove cask installer", "path", remove, "error", err)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+		freed += size
+		removed++
+	}
+	return freed, removed
+}
+
+// brewCleanup runs `brew cleanup` with args and returns the bytes brew
+// reports removing, which covers old versions in the Cellar as well as the
+// download cache. When brew names no sizes, it falls back to the shrink of
+// the download cache.
+func (p *HomebrewPlugin) brewCleanup(ctx context.Context, logger *slog.Logger, args ...string) int64 {
+	var cachePath string
+	var sizeBefore int64
+	if home, err := env.HomeDir(); err == nil {
+		cachePath = filepath.Join(home, "Library", "Caches", "Homebrew")
+		sizeBefore = getDirSize(cachePath)
+	}
+
+	output, err := runner.CombinedOutput(ctx, nil, "brew", append([]string{"cleanup"}, args...)...)
+	if err != nil {
+		logger.Warn("brew cleanup failed", "args", strings.Join(args, " "), "error", err, "output", strings.TrimSpace(string(output)))
+	}
+	if freed := parseBrewCleanupOutput(string(output)); freed > 0 {
+		return freed
+	}
+	if cachePath == "" {
+		return 0
+	}
+	freed := max(sizeBefore-getDirSize(cachePath), 0)
+	logger.Debug("brew cleanup reported no sizes; using the download cache shrink", "freed_bytes", freed)
+	return freed
+}
+
 func (p *HomebrewPlugin) cleanupDryRunEstimate(ctx context.Context) (int64, error) {
 	dryRunCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(dryRunCtx, "brew", "cleanup", "--dry-run", "--prune=0")
-	output, err := cmd.CombinedOutput()
+	output, err := runner.CombinedOutput(dryRunCtx, nil, "brew", "cleanup", "--dry-run", "--prune=0")
 	if err != nil {
 		return 0, fmt.Errorf("brew cleanup --dry-run --prune=0 failed: %w", err)
 	}
@@ -203,6 +330,11 @@ func (p *IOSSimulatorPlugin) Description() string {
 	return "Cleans iOS Simulator devices and runtimes"
 }
 
+// Priority runs simulator cleanup late; runtimes are large re-downloads.
+func (p *IOSSimulatorPlugin) Priority() int {
+	return 60
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *IOSSimulatorPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -215,15 +347,12 @@ func (p *IOSSimulatorPlugin) Enabled(cfg *config.Config) bool {
 
 // PlanCleanup reports iOS Simulator cleanup candidates without deleting devices or runtimes.
 func (p *IOSSimulatorPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
-	_ = cfg
-	_ = logger
-
 	plan := CleanupPlan{
 		Plugin:   p.Name(),
 		Level:    level.String(),
 		Summary:  "iOS Simulator cleanup plan",
 		WouldRun: true,
-		Steps:    iosSimulatorPlanSteps(level),
+		Steps:    iosSimulatorPlanSteps(level, cfg.IOSSimulator),
 		Metadata: map[string]string{
 			"cleanup_level": level.String(),
 		},
@@ -239,7 +368,14 @@ func (p *IOSSimulatorPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 	activeProcesses := darwinActiveProcessNames(ctx)
 	active := darwinAnyProcessActive(activeProcesses, "simulator", "coresimulator", "xcodebuild")
 	sudoCap := DetectSudo(ctx)
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "iOS Simulator cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 	devicePath := filepath.Join(home, "Library", "Developer", "CoreSimulator", "Devices")
 	runtimesPath := "/Library/Developer/CoreSimulator/Volumes"
 
@@ -249,6 +385,15 @@ func (p *IOSSimulatorPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 	plan.Metadata["device_path"] = devicePath
 	plan.Metadata["runtimes_path"] = runtimesPath
 	plan.Targets = iosSimulatorPlanTargets(level, devicePath, runtimesPath, active, sudoCap.Passwordless)
+	if days := cfg.IOSSimulator.EraseUnusedAfterDays; level >= LevelAggressive && days > 0 {
+		devices, err := listSimDevices(ctx)
+		if err != nil {
+			logger.Debug("could not list iOS Simulator devices", "error", err)
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not list simulator devices: %v", err))
+		} else {
+			plan.Targets = append(plan.Targets, iosSimulatorUnusedDeviceTargets(unusedSimDevices(devices, simctlNow().AddDate(0, 0, -days)), days, active)...)
+		}
+	}
 	plan.EstimatedBytesFreed = cleanupTargetEstimatedBytes(plan.Targets)
 	plan.Metadata["target_count"] = strconv.Itoa(len(plan.Targets))
 
@@ -287,11 +432,11 @@ func (p *IOSSimulatorPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 		// Light/moderate: delete unavailable devices
 		result = p.deleteUnavailable(ctx, logger)
 	case LevelAggressive:
-		// Aggressive: + delete device data
-		result = p.cleanAggressive(ctx, logger)
+		// Aggressive: + delete device logs and erase unused devices
+		result = p.cleanAggressive(ctx, cfg, logger)
 	case LevelCritical:
 		// Critical: + delete runtimes
-		result = p.cleanCritical(ctx, logger)
+		result = p.cleanCritical(ctx, cfg, logger)
 	}
 
 	return result
@@ -310,12 +455,16 @@ func (p *IOSSimulatorPlugin) deleteUnavailable(ctx context.Context, logger *slog
 	return result
 }
 
-func (p *IOSSimulatorPlugin) cleanAggressive(ctx context.Context, logger *slog.Logger) CleanupResult {
+func (p *IOSSimulatorPlugin) cleanAggressive(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := p.deleteUnavailable(ctx, logger)
 	result.Level = LevelAggressive
 
 	// Clean up simulator device data directory
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping iOS Simulator device data cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	devicePath := filepath.Join(home, "Library", "Developer", "CoreSimulator", "Devices")
 
 	if info, err := os.Stat(devicePath); err == nil && info.IsDir() {
@@ -324,6 +473,7 @@ func (p *IOSSimulatorPlugin) cleanAggressive(ctx context.Context, logger *slog.L
 		// Delete old log files
 		filepath.Walk(devicePath, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
+				walkErrors.note(err)
 				return nil
 			}
 			if !info.IsDir() && strings.HasSuffix(path, ".log") {
@@ -336,11 +486,15 @@ func (p *IOSSimulatorPlugin) cleanAggressive(ctx context.Context, logger *slog.L
 		result.BytesFreed = sizeBefore - sizeAfter
 	}
 
+	freed, erased := p.eraseUnusedDevices(ctx, cfg.IOSSimulator.EraseUnusedAfterDays, logger)
+	result.BytesFreed += freed
+	result.ItemsCleaned += erased
+
 	return result
 }
 
-func (p *IOSSimulatorPlugin) cleanCritical(ctx context.Context, logger *slog.Logger) CleanupResult {
-	result := p.cleanAggressive(ctx, logger)
+func (p *IOSSimulatorPlugin) cleanCritical(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := p.cleanAggressive(ctx, cfg, logger)
 	result.Level = LevelCritical
 
 	// Check runtime size
@@ -384,7 +538,18 @@ func (p *XcodePlugin) Name() string {
 
 // Description returns the plugin description.
 func (p *XcodePlugin) Description() string {
-	return "Cleans Xcode DerivedData, archives, and device support"
+	return "Cleans Xcode DerivedData, archives, device support, and SwiftPM and CocoaPods caches"
+}
+
+// Priority runs Xcode cleanup after the general build caches.
+func (p *XcodePlugin) Priority() int {
+	return 55
+}
+
+// FreedBytesVolume reports that DerivedData and archive deletions land on the
+// monitored volume.
+func (p *XcodePlugin) FreedBytesVolume(cfg *config.Config) string {
+	return ""
 }
 
 // SupportedPlatforms returns supported platforms (Darwin only).
@@ -413,7 +578,14 @@ func (p *XcodePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *
 		},
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Xcode cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 	xcodeDevDir := filepath.Join(home, "Library", "Developer", "Xcode")
 	if !pathExistsAndIsDir(xcodeDevDir) {
 		plan.Summary = "No Xcode developer directory found"
@@ -427,6 +599,7 @@ func (p *XcodePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *
 	plan.Metadata["xcode_dev_dir"] = xcodeDevDir
 	plan.Metadata["active_xcode_processes"] = strconv.FormatBool(active)
 	plan.Targets = xcodePlanTargets(level, xcodeDevDir, active, time.Now())
+	plan.Targets = append(plan.Targets, xcodePackageCachePlanTargets(level, home, xcodeDevDir, active)...)
 	plan.EstimatedBytesFreed = cleanupTargetEstimatedBytes(plan.Targets)
 	plan.Metadata["target_count"] = strconv.Itoa(len(plan.Targets))
 
@@ -445,7 +618,11 @@ func (p *XcodePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 		Level:  level,
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping Xcode cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	xcodeDevDir := filepath.Join(home, "Library", "Developer", "Xcode")
 
 	if _, err := os.Stat(xcodeDevDir); os.IsNotExist(err) {
@@ -459,15 +636,18 @@ func (p *XcodePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	}
 
 	switch level {
-	case LevelWarning, LevelModerate:
+	case LevelWarning:
 		// Light: clean old logs
 		result.BytesFreed = p.cleanLogs(xcodeDevDir, logger)
+	case LevelModerate:
+		// Moderate: + clean SwiftPM and CocoaPods caches
+		result.BytesFreed = p.cleanModerate(home, xcodeDevDir, logger)
 	case LevelAggressive:
 		// Aggressive: + clean old DerivedData
-		result.BytesFreed = p.cleanDerivedData(xcodeDevDir, logger)
+		result.BytesFreed = p.cleanDerivedData(home, xcodeDevDir, logger)
 	case LevelCritical:
 		// Critical: + clean archives and device support
-		result.BytesFreed = p.cleanCritical(xcodeDevDir, logger)
+		result.BytesFreed = p.cleanCritical(home, xcodeDevDir, logger)
 	}
 
 	return result
@@ -489,15 +669,47 @@ func (p *XcodePlugin) cleanLogs(xcodeDir string, logger *slog.Logger) int64 {
 	return freed
 }
 
-func (p *XcodePlugin) cleanDerivedData(xcodeDir string, logger *slog.Logger) int64 {
+func (p *XcodePlugin) cleanModerate(home, xcodeDir string, logger *slog.Logger) int64 {
 	freed := p.cleanLogs(xcodeDir, logger)
 
+	// SwiftPM and CocoaPods re-download packages on demand.
+	for _, dir := range xcodePackageCacheDirs(home, xcodeDir) {
+		if !pathExistsAndIsDir(dir) {
+			continue
+		}
+		sizeBefore := getDirSize(dir)
+		if err := removeCacheRoot(dir); err != nil {
+			logger.Warn("could not fully remove Xcode package cache", "path", dir, "error", err)
+		}
+		cacheFreed := max(sizeBefore-getDirSize(dir), 0)
+		logger.Debug("cleaned Xcode package cache", "path", dir, "freed_mb", cacheFreed/(1024*1024))
+		freed += cacheFreed
+	}
+
+	return freed
+}
+
+// xcodePackageCacheDirs returns the SwiftPM and CocoaPods download caches and
+// each DerivedData project's SourcePackages checkouts.
+func xcodePackageCacheDirs(home, xcodeDir string) []string {
+	dirs := []string{
+		filepath.Join(home, "Library", "Caches", "org.swift.swiftpm"),
+		filepath.Join(home, "Library", "Caches", "CocoaPods"),
+	}
+	sourcePackages, _ := filepath.Glob(filepath.Join(xcodeDir, "DerivedData", "*", "SourcePackages"))
+	sort.Strings(sourcePackages)
+	return append(dirs, sourcePackages...)
+}
+
+func (p *XcodePlugin) cleanDerivedData(home, xcodeDir string, logger *slog.Logger) int64 {
+	freed := p.cleanModerate(home, xcodeDir, logger)
+
 	derivedData := filepath.Join(xcodeDir, "DerivedData")
 	if info, err := os.Stat(derivedData); err == nil && info.IsDir() {
 		sizeBefore := getDirSize(derivedData)
 		if sizeBefore > 500*1024*1024 { // Only if > 500MB
 			logger.Debug("cleaning Xcode DerivedData", "size_mb", sizeBefore/(1024*1024))
-			os.RemoveAll(derivedData)
+			removeCacheRoot(derivedData)
 			freed += sizeBefore
 		}
 	}
@@ -505,8 +717,8 @@ func (p *XcodePlugin) cleanDerivedData(xcodeDir string, logger *slog.Logger) int
 	return freed
 }
 
-func (p *XcodePlugin) cleanCritical(xcodeDir string, logger *slog.Logger) int64 {
-	freed := p.cleanDerivedData(xcodeDir, logger)
+func (p *XcodePlugin) cleanCritical(home, xcodeDir string, logger *slog.Logger) int64 {
+	freed := p.cleanDerivedData(home, xcodeDir, logger)
 
 	// Clean archives > 500MB
 	archivesDir := filepath.Join(xcodeDir, "Archives")
@@ -514,7 +726,7 @@ func (p *XcodePlugin) cleanCritical(xcodeDir string, logger *slog.Logger) int64
 		size := getDirSize(archivesDir)
 		if size > 500*1024*1024 {
 			logger.Warn("CRITICAL: cleaning Xcode Archives", "size_mb", size/(1024*1024))
-			os.RemoveAll(archivesDir)
+			removeCacheRoot(archivesDir)
 			freed += size
 		}
 	}
@@ -557,6 +769,9 @@ func (p *XcodePlugin) cleanDeviceSupport(dir string, keepCount int, logger *slog
 	}
 
 	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].modTime.Equal(dirs[j].modTime) {
+			return dirs[i].name < dirs[j].name
+		}
 		return dirs[i].modTime.After(dirs[j].modTime)
 	})
 
@@ -573,17 +788,22 @@ func (p *XcodePlugin) cleanDeviceSupport(dir string, keepCount int, logger *slog
 	return freed
 }
 
-func homebrewPlanSteps(level CleanupLevel) []string {
+func homebrewPlanSteps(level CleanupLevel, cfg config.HomebrewConfig) []string {
+	var steps []string
 	switch level {
 	case LevelWarning:
 		return []string{"Run brew cleanup -s to remove Homebrew downloads cache"}
 	case LevelModerate, LevelAggressive:
-		return []string{"Run brew cleanup --prune=0 to remove old formula and cask versions"}
+		steps = []string{"Run brew cleanup --prune=0 to remove old formula and cask versions"}
 	case LevelCritical:
-		return []string{"Run brew autoremove", "Run brew cleanup --prune=0"}
+		steps = []string{"Run brew autoremove", "Run brew cleanup --prune=0"}
 	default:
 		return []string{"Report Homebrew cleanup state"}
 	}
+	if level >= LevelAggressive && cfg.IncludeCasks {
+		steps = append(steps, "Run brew cleanup for installed casks and remove cached cask installers, without uninstalling casks")
+	}
+	return steps
 }
 
 func homebrewPlanTarget(level CleanupLevel, cachePath string, cacheBytes int64, dryRunBytes int64, dryRunAvailable bool) CleanupTarget {
@@ -624,17 +844,50 @@ func homebrewPlanTarget(level CleanupLevel, cachePath string, cacheBytes int64,
 	}
 }
 
-func iosSimulatorPlanSteps(level CleanupLevel) []string {
+func iosSimulatorPlanSteps(level CleanupLevel, cfg config.IOSSimulatorConfig) []string {
+	var steps []string
 	switch level {
 	case LevelWarning, LevelModerate:
 		return []string{"Delete unavailable iOS Simulator devices"}
-	case LevelAggressive:
-		return []string{"Delete unavailable iOS Simulator devices", "Delete simulator device log files"}
-	case LevelCritical:
-		return []string{"Delete unavailable iOS Simulator devices", "Delete simulator device log files", "Delete simulator runtimes when passwordless sudo is available"}
+	case LevelAggressive, LevelCritical:
+		steps = []string{"Delete unavailable iOS Simulator devices", "Delete simulator device log files"}
 	default:
 		return []string{"Report iOS Simulator cleanup state"}
 	}
+	if cfg.EraseUnusedAfterDays > 0 {
+		steps = append(steps, fmt.Sprintf("Erase shut-down simulator devices not booted in %d days", cfg.EraseUnusedAfterDays))
+	}
+	if level == LevelCritical {
+		steps = append(steps, "Delete simulator runtimes when passwordless sudo is available")
+	}
+	return steps
+}
+
+// iosSimulatorUnusedDeviceTargets lists each unused device's data directory
+// as an erase candidate.
+func iosSimulatorUnusedDeviceTargets(devices []simDevice, days int, active bool) []CleanupTarget {
+	targets := make([]CleanupTarget, 0, len(devices))
+	for _, device := range devices {
+		target := CleanupTarget{
+			Type:      "ios-simulator-device-data",
+			Tier:      CleanupTierWarm,
+			Name:      device.Name,
+			Version:   strings.TrimPrefix(device.Runtime, "com.apple.CoreSimulator.SimRuntime."),
+			Path:      device.DataPath,
+			Bytes:     getDirSize(device.DataPath),
+			Active:    active,
+			Protected: active,
+			Action:    "delete_device_data",
+			Reason:    fmt.Sprintf("xcrun simctl erase resets shut-down devices not booted in %d days", days),
+		}
+		if active {
+			target.Action = "protect"
+			target.Reason = "active simulator or Xcode process detected"
+		}
+		annotateCleanupTargetPolicy(&target, target.Tier, hostReclaimForAction(target.Action))
+		targets = append(targets, target)
+	}
+	return targets
 }
 
 func iosSimulatorPlanTargets(level CleanupLevel, devicePath string, runtimesPath string, active bool, sudoPasswordless bool) []CleanupTarget {
@@ -700,12 +953,14 @@ func iosSimulatorPlanTargets(level CleanupLevel, devicePath string, runtimesPath
 
 func xcodePlanSteps(level CleanupLevel) []string {
 	switch level {
-	case LevelWarning, LevelModerate:
+	case LevelWarning:
 		return []string{"Delete Xcode logs older than 7 days"}
+	case LevelModerate:
+		return []string{"Delete Xcode logs older than 7 days", "Delete SwiftPM, CocoaPods, and DerivedData SourcePackages caches"}
 	case LevelAggressive:
-		return []string{"Delete Xcode logs older than 7 days", "Delete Xcode DerivedData when larger than 500 MiB"}
+		return []string{"Delete Xcode logs older than 7 days", "Delete SwiftPM, CocoaPods, and DerivedData SourcePackages caches", "Delete Xcode DerivedData when larger than 500 MiB"}
 	case LevelCritical:
-		return []string{"Delete Xcode logs older than 7 days", "Delete Xcode DerivedData when larger than 500 MiB", "Delete Xcode Archives when larger than 500 MiB", "Delete old iOS DeviceSupport directories while preserving the newest two"}
+		return []string{"Delete Xcode logs older than 7 days", "Delete SwiftPM, CocoaPods, and DerivedData SourcePackages caches", "Delete Xcode DerivedData when larger than 500 MiB", "Delete Xcode Archives when larger than 500 MiB", "Delete old iOS DeviceSupport directories while preserving the newest two"}
 	default:
 		return []string{"Report Xcode cleanup state"}
 	}
@@ -733,6 +988,24 @@ func xcodePlanTargets(level CleanupLevel, xcodeDevDir string, active bool, now t
 	return targets
 }
 
+// xcodePackageCachePlanTargets lists the package caches cleaned at moderate
+// level and above.
+func xcodePackageCachePlanTargets(level CleanupLevel, home, xcodeDevDir string, active bool) []CleanupTarget {
+	var targets []CleanupTarget
+	for _, dir := range xcodePackageCacheDirs(home, xcodeDevDir) {
+		if !pathExistsAndIsDir(dir) {
+			continue
+		}
+		bytes := getDirSize(dir)
+		name := filepath.Base(dir)
+		if name == "SourcePackages" {
+			name = filepath.Base(filepath.Dir(dir)) + " SourcePackages"
+		}
+		targets = append(targets, xcodePlanTarget("xcode-package-cache", name, dir, bytes, CleanupTierSafe, active || level < LevelModerate || bytes == 0, "delete_package_cache", "SwiftPM and CocoaPods re-download packages on demand"))
+	}
+	return targets
+}
+
 func xcodePlanTarget(targetType string, name string, path string, bytes int64, tier string, protected bool, action string, reason string) CleanupTarget {
 	target := CleanupTarget{
 		Type:      targetType,
@@ -782,6 +1055,9 @@ func xcodeDeviceSupportPlanTargets(dir string, keepCount int, active bool, level
 		})
 	}
 	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].modTime.Equal(dirs[j].modTime) {
+			return dirs[i].name < dirs[j].name
+		}
 		return dirs[i].modTime.After(dirs[j].modTime)
 	})
 
@@ -867,7 +1143,17 @@ func (p *CachePlugin) Name() string {
 
 // Description returns the plugin description.
 func (p *CachePlugin) Description() string {
-	return "Cleans various application caches (pip, npm, go, etc.)"
+	return "Cleans various application caches (pip, npm, pnpm, yarn, go, etc.)"
+}
+
+// Priority runs cache clears first; they are cheap and rebuild on demand.
+func (p *CachePlugin) Priority() int {
+	return 10
+}
+
+// FreedBytesVolume reports that cache deletions land on the monitored volume.
+func (p *CachePlugin) FreedBytesVolume(cfg *config.Config) string {
+	return ""
 }
 
 // SupportedPlatforms returns supported platforms (all).
@@ -880,6 +1166,22 @@ func (p *CachePlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Cache
 }
 
+// LevelDescription summarizes macOS cache cleanup at each level.
+func (p *CachePlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports typed developer caches; deletes pip, npm, pnpm, and yarn caches only when darwin_dev_caches is disabled"
+	case LevelModerate:
+		return "deletes eligible typed developer-cache targets when darwin_dev_caches.enforce is true; legacy mode adds go clean -testcache and old cargo files"
+	case LevelAggressive:
+		return "deletes eligible typed developer-cache targets when enforced; legacy mode adds go clean -cache and go clean -modcache"
+	case LevelCritical:
+		return "deletes eligible typed developer-cache targets when enforced; legacy mode adds non-default rustup toolchains and ~/Library/Caches files older than 30 days"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup reports typed Darwin developer-cache candidates without deleting them.
 func (p *CachePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	_ = logger
@@ -907,15 +1209,16 @@ func (p *CachePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *
 		return plan
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Darwin cache cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 	activeProcesses := darwinActiveProcessNames(ctx)
 	targets := p.darwinDeveloperCacheTargets(home, cfg.DarwinDevCaches, activeProcesses, level)
-	sort.Slice(targets, func(i, j int) bool {
-		if targets[i].Bytes == targets[j].Bytes {
-			return targets[i].Path < targets[j].Path
-		}
-		return targets[i].Bytes > targets[j].Bytes
-	})
 
 	var total int64
 	var estimated int64
@@ -925,6 +1228,18 @@ func (p *CachePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *
 			estimated += target.Bytes
 		}
 	}
+	// cache.extra_paths are emptied at their min_level regardless of
+	// darwin_dev_caches enforcement, and stay out of its review budget.
+	for _, target := range extraCachePlanTargets(ctx, cfg.Cache, level, home) {
+		targets = append(targets, target)
+		estimated += target.Bytes
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Bytes == targets[j].Bytes {
+			return targets[i].Path < targets[j].Path
+		}
+		return targets[i].Bytes > targets[j].Bytes
+	})
 
 	plan.Targets = targets
 	plan.EstimatedBytesFreed = estimated
@@ -943,14 +1258,29 @@ func (p *CachePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *
 	return plan
 }
 
-// Cleanup performs cache cleanup at the specified level.
+// Cleanup performs cache cleanup at the specified level and reports how
+// many paths were skipped as unreadable.
 func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	skipped := walkErrors.snapshot()
+	result := p.cleanup(ctx, level, cfg, logger)
+	if home, err := env.HomeDir(); err == nil && level >= LevelWarning {
+		cleanExtraCachePaths(cfg.Cache, level, home, &result, logger)
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
+
+func (p *CachePlugin) cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
 		Plugin: p.Name(),
 		Level:  level,
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping Darwin cache cleanup: home directory unavailable", "error", err)
+		return result
+	}
 
 	if cfg.DarwinDevCaches.Enabled {
 		if !cfg.DarwinDevCaches.Enforce {
@@ -968,7 +1298,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	pipCache := filepath.Join(home, ".cache", "pip")
 	if size := getDirSize(pipCache); size > 0 {
 		if level >= LevelWarning {
-			os.RemoveAll(pipCache)
+			removeCacheRoot(pipCache)
 			result.BytesFreed += size
 			logger.Debug("cleaned pip cache", "freed_mb", size/(1024*1024))
 		}
@@ -978,12 +1308,17 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	npmCache := filepath.Join(home, ".npm", "_cacache")
 	if size := getDirSize(npmCache); size > 0 {
 		if level >= LevelWarning {
-			os.RemoveAll(npmCache)
+			removeCacheRoot(npmCache)
 			result.BytesFreed += size
 			logger.Debug("cleaned npm cache", "freed_mb", size/(1024*1024))
 		}
 	}
 
+	// pnpm store and yarn global cache
+	if level >= LevelWarning {
+		cleanJSPackageCaches(ctx, home, &result, logger)
+	}
+
 	// Go build cache (moderate+, separate from module cache)
 	if level >= LevelModerate {
 		if _, err := exec.LookPath("go"); err == nil {
@@ -998,7 +1333,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 							exec.CommandContext(ctx, "go", "clean", "-testcache").Run()
 						}
 						sizeAfter := getDirSize(goCacheDir)
-						freed := safeBytesDiff(sizeBefore, sizeAfter)
+						freed := measuredBytesDiff(&result, logger, goCacheDir, sizeBefore, sizeAfter)
 						result.BytesFreed += freed
 						if freed > 0 {
 							logger.Debug("cleaned go build cache", "freed_mb", freed/(1024*1024))
@@ -1012,8 +1347,9 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 	// go module cache (only at aggressive or higher)
 	if level >= LevelAggressive {
 		goModCache := filepath.Join(home, "go", "pkg", "mod", "cache")
-		if size := getDirSize(goModCache); size > 0 {
+		if size := getDirSize(goModCache); size > 0 && cooldownAllows(cfg, p.Name(), "go_modcache", logger) {
 			exec.CommandContext(ctx, "go", "clean", "-modcache").Run()
+			recordCooldown(cfg, p.Name(), "go_modcache", logger)
 			result.BytesFreed += size
 			logger.Debug("cleaned go mod cache", "freed_mb", size/(1024*1024))
 		}
@@ -1026,7 +1362,7 @@ func (p *CachePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *conf
 			sizeBefore := getDirSize(cargoCache)
 			deleteOldFiles(cargoCache, 30*24*time.Hour)
 			sizeAfter := getDirSize(cargoCache)
-			result.BytesFreed += safeBytesDiff(sizeBefore, sizeAfter)
+			result.BytesFreed += measuredBytesDiff(&result, logger, cargoCache, sizeBefore, sizeAfter)
 		}
 
 		// cargo clean gc (Rust 1.82+ automatic garbage collection)
@@ -1095,6 +1431,9 @@ func darwinCacheEntriesOverBudget(entries []darwinCacheEntry, maxGB int, keepNew
 
 	sorted := append([]darwinCacheEntry(nil), entries...)
 	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].modTime.Equal(sorted[j].modTime) {
+			return sorted[i].path < sorted[j].path
+		}
 		return sorted[i].modTime.After(sorted[j].modTime)
 	})
 
@@ -1107,6 +1446,9 @@ func darwinCacheEntriesOverBudget(entries []darwinCacheEntry, maxGB int, keepNew
 	}
 
 	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].modTime.Equal(sorted[j].modTime) {
+			return sorted[i].path < sorted[j].path
+		}
 		return sorted[i].modTime.Before(sorted[j].modTime)
 	})
 	for _, entry := range sorted {
@@ -1187,6 +1529,9 @@ func (p *CachePlugin) darwinDeveloperCacheTargets(home string, cfg config.Darwin
 		bazeliskRoot := filepath.Join(home, "Library", "Caches", "bazelisk")
 		entries := listDarwinCacheEntries(bazeliskRoot)
 		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].modTime.Equal(entries[j].modTime) {
+				return entries[i].path < entries[j].path
+			}
 			return entries[i].modTime.After(entries[j].modTime)
 		})
 		keepLatest := cfg.Bazelisk.KeepLatest
@@ -1334,7 +1679,7 @@ func (p *CachePlugin) cleanupDarwinDeveloperCacheTargets(ctx context.Context, le
 		}
 		result.EstimatedBytesFreed += sizeBefore
 		if err := os.RemoveAll(target.Path); err != nil {
-			result.Error = err
+			result.Error = newPluginError(p.Name(), "cache_delete", err).withPath(target.Path)
 			logger.Warn("failed to delete Darwin developer cache target", "path", target.Path, "type", target.Type, "error", err)
 			continue
 		}
@@ -1342,7 +1687,7 @@ func (p *CachePlugin) cleanupDarwinDeveloperCacheTargets(ctx context.Context, le
 		if pathExistsAndIsDir(target.Path) {
 			sizeAfter = getDirAllocatedBytes(target.Path)
 		}
-		freed := safeBytesDiff(sizeBefore, sizeAfter)
+		freed := measuredBytesDiff(&result, logger, target.Path, sizeBefore, sizeAfter)
 		result.BytesFreed += freed
 		result.ItemsCleaned++
 		logger.Info("deleted Darwin developer cache target",
@@ -1494,16 +1839,21 @@ func getDirSize(path string) int64 {
 }
 
 func getDirSizeContext(ctx context.Context, path string) (int64, error) {
+	root, ok := resolveCacheRoot(path)
+	if !ok {
+		return 0, nil
+	}
 	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.IsDir() {
-			size += info.Size()
+			size += accountedFileBytes(info)
 		}
 		return nil
 	})
@@ -1514,9 +1864,14 @@ func getDirSizeContext(ctx context.Context, path string) (int64, error) {
 }
 
 func deleteOldFiles(dir string, maxAge time.Duration) {
+	root, ok := resolveCacheRoot(dir)
+	if !ok {
+		return
+	}
 	cutoff := time.Now().Add(-maxAge)
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.IsDir() && info.ModTime().Before(cutoff) {
@@ -1527,8 +1882,10 @@ func deleteOldFiles(dir string, maxAge time.Duration) {
 }
 
 func parseBrewCleanupOutput(output string) int64 {
-	// Parse lines like "Removing: /path/to/file... (1.2 MB)"
-	re := regexp.MustCompile(`\((\d+\.?\d*)\s*([KMGT]?B)\)`)
+	// Parse lines like "Removing: /path/to/file... (1.2MB)" and, for old
+	// versions in the Cellar, "Removing: /opt/homebrew/Cellar/node/20.1.0...
+	// (2,310 files, 64.3MB)".
+	re := regexp.MustCompile(`[(,]\s*(\d+\.?\d*)\s*([KMGT]?B)\)`)
 	var total int64
 
 	for _, match := range re.FindAllStringSubmatch(output, -1) {
@@ -1544,6 +1901,8 @@ func parseBrewCleanupOutput(output string) int64 {
 				total += int64(value * 1024 * 1024)
 			case "GB":
 				total += int64(value * 1024 * 1024 * 1024)
+			case "TB":
+				total += int64(value * 1024 * 1024 * 1024 * 1024)
 			case "B":
 				total += int64(value)
 			}
@@ -1575,6 +1934,11 @@ func (p *ICloudPlugin) Description() string {
 	return "Evicts downloaded iCloud Drive files to free local storage"
 }
 
+// Priority runs iCloud eviction late; evicted files must be downloaded again.
+func (p *ICloudPlugin) Priority() int {
+	return 70
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *ICloudPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -1599,7 +1963,11 @@ func (p *ICloudPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 	}
 
 	// Find iCloud Drive directory
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping iCloud cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	iCloudPath := filepath.Join(home, "Library", "Mobile Documents", "com~apple~CloudDocs")
 	if _, err := os.Stat(iCloudPath); os.IsNotExist(err) {
 		logger.Debug("iCloud Drive not found", "path", iCloudPath)
@@ -1784,6 +2152,11 @@ func (p *PhotosPlugin) Description() string {
 	return "Cleans Photos library analysis caches (never touches originals)"
 }
 
+// Priority runs Photos cache cleanup late; analysis caches rebuild slowly in the background.
+func (p *PhotosPlugin) Priority() int {
+	return 75
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *PhotosPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -1802,7 +2175,11 @@ func (p *PhotosPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 	}
 
 	// Find Photos library
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping Photos cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	photosLibPath := filepath.Join(home, "Pictures", "Photos Library.photoslibrary")
 
 	if _, err := os.Stat(photosLibPath); os.IsNotExist(err) {
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -276,6 +277,71 @@ func TestHomebrewPlanTargetTrustsZeroDryRunEstimate(t *testing.T) {
 	}
 }
 
+func TestHomebrewCleanupCountsCellarVersions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	output := strings.Join([]string{
+		"Removing: /Users/me/Library/Caches/Homebrew/node--20.1.0.bottle.tar.gz... (12MB)",
+		"Removing: /opt/homebrew/Cellar/node/20.1.0... (2,310 files, 64MB)",
+		"Removing: /opt/homebrew/Cellar/llvm/16.0.6... (7,000 files, 1.5GB)",
+		"==> This operation has freed approximately 1.6GB of disk space.",
+	}, "\n")
+	fake := useFakeRunner(t, map[string]fakeResponse{"brew cleanup --prune=0": {Output: output}})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewHomebrewPlugin().Cleanup(context.Background(), LevelModerate, config.DefaultConfig(), logger)
+	if want := int64(76<<20 + 1536<<20); result.BytesFreed != want {
+		t.Fatalf("BytesFreed = %d, want %d from the cache and Cellar lines", result.BytesFreed, want)
+	}
+	if got := fake.commandLines("brew"); len(got) != 1 || got[0] != "brew cleanup --prune=0" {
+		t.Fatalf("brew commands = %v", got)
+	}
+}
+
+func TestHomebrewCleanCasksRemovesOnlyCachedInstallers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cache := filepath.Join(home, "Library", "Caches", "Homebrew")
+	download := filepath.Join(cache, "downloads", "abc--firefox.dmg")
+	writeAgedFile(t, download, 100, 0)
+	writeAgedFile(t, filepath.Join(cache, "Cask", "slack.zip"), 50, 0)
+	app := filepath.Join(t.TempDir(), "Firefox.app")
+	writeAgedFile(t, filepath.Join(app, "binary"), 10, 0)
+	if err := os.Symlink(download, filepath.Join(cache, "Cask", "firefox.dmg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(app, filepath.Join(cache, "Cask", "installed.app")); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// With no casks installed, nothing is attempted.
+	fake := useFakeRunner(t, nil)
+	if freed, removed := NewHomebrewPlugin().cleanCasks(context.Background(), logger); freed != 0 || removed != 0 {
+		t.Fatalf("expected no cask cleanup without casks, got %d bytes, %d removed", freed, removed)
+	}
+	if got := fake.commandLines("brew cleanup"); len(got) != 0 {
+		t.Fatalf("expected no brew cleanup without casks, got %v", got)
+	}
+
+	fake = useFakeRunner(t, map[string]fakeResponse{"brew list --cask": {Output: "firefox\nslack\n"}})
+	freed, removed := NewHomebrewPlugin().cleanCasks(context.Background(), logger)
+	if got := fake.commandLines("brew cleanup"); len(got) != 1 || got[0] != "brew cleanup --prune=0 firefox slack" {
+		t.Fatalf("brew cleanup = %v", got)
+	}
+	if freed != 150 || removed != 3 {
+		t.Fatalf("expected 150 bytes from 3 installers, got %d bytes, %d removed", freed, removed)
+	}
+	if _, err := os.Stat(download); !os.IsNotExist(err) {
+		t.Fatalf("expected the linked download to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(app, "binary")); err != nil {
+		t.Fatalf("a link out of the cache must not remove its target: %v", err)
+	}
+	if plan := homebrewPlanSteps(LevelAggressive, config.HomebrewConfig{}); len(plan) != 1 {
+		t.Fatalf("expected no cask step with include_casks off, got %v", plan)
+	}
+}
+
 func TestIOSSimulatorPlanTargetsProtectsActiveWork(t *testing.T) {
 	root := t.TempDir()
 	devicePath := filepath.Join(root, "Devices")
@@ -343,6 +409,47 @@ func TestXcodePlanTargetsCriticalKeepsNewestDeviceSupport(t *testing.T) {
 	}
 }
 
+func TestXcodeCleanModerateRemovesPackageCaches(t *testing.T) {
+	home := t.TempDir()
+	xcodeDir := filepath.Join(home, "Library", "Developer", "Xcode")
+	writeCacheFile(t, home, "Library/Caches/org.swift.swiftpm/repositories/swift-nio/pack.bin", "swiftpm")
+	writeCacheFile(t, home, "Library/Caches/CocoaPods/Pods/Release/Alamofire/pod.bin", "cocoapods")
+	writeFileAt(t, filepath.Join(xcodeDir, "DerivedData", "App-abc", "SourcePackages", "checkouts", "nio.bin"), "checkout")
+	writeFileAt(t, filepath.Join(xcodeDir, "DerivedData", "App-abc", "Build", "app.o"), "build")
+
+	targets := xcodePackageCachePlanTargets(LevelModerate, home, xcodeDir, false)
+	if len(targets) != 3 {
+		t.Fatalf("expected three package cache targets, got %#v", targets)
+	}
+	sourcePackages := findCleanupTarget(t, targets, "xcode-package-cache", "App-abc SourcePackages")
+	if sourcePackages.Action != "delete_package_cache" || sourcePackages.Protected {
+		t.Fatalf("expected SourcePackages to be eligible: %#v", sourcePackages)
+	}
+	for _, target := range xcodePackageCachePlanTargets(LevelWarning, home, xcodeDir, false) {
+		if !target.Protected {
+			t.Fatalf("expected package caches to be protected at warning: %#v", target)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	freed := NewXcodePlugin().cleanModerate(home, xcodeDir, logger)
+	if freed <= 0 {
+		t.Fatalf("expected package cache cleanup to free bytes, got %d", freed)
+	}
+	for _, path := range []string{
+		filepath.Join(home, "Library", "Caches", "org.swift.swiftpm", "repositories"),
+		filepath.Join(home, "Library", "Caches", "CocoaPods", "Pods"),
+		filepath.Join(xcodeDir, "DerivedData", "App-abc", "SourcePackages", "checkouts"),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, stat error %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(xcodeDir, "DerivedData", "App-abc", "Build", "app.o")); err != nil {
+		t.Fatalf("expected DerivedData build products to be kept at moderate: %v", err)
+	}
+}
+
 func writeCacheFile(t *testing.T, home, relPath, content string) {
 	t.Helper()
 
@@ -18,9 +18,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 const devArtifactRecentOutputGrace = 2 * time.Hour
@@ -38,6 +40,11 @@ type devArtifactScanBudget struct {
 	tempRoots     int
 	tempRootSeen  map[string]struct{}
 	truncatedPath map[string]string
+
+	// manifest is set in incremental mode to reuse results for unchanged projects.
+	manifest       *devArtifactManifest
+	reusedProjects int
+	walkedProjects int
 }
 
 func newDevArtifactScanBudget(cfg config.DevArtifactsConfig) *devArtifactScanBudget {
@@ -146,6 +153,10 @@ func (b *devArtifactScanBudget) annotatePlan(plan *CleanupPlan) {
 	plan.Metadata["scan_entries_visited"] = strconv.Itoa(b.entries)
 	plan.Metadata["temp_roots_visited"] = strconv.Itoa(b.tempRoots)
 	plan.Metadata["scan_budget_exhausted"] = strconv.FormatBool(b.exhausted())
+	if b.manifest != nil {
+		plan.Metadata["incremental_projects_reused"] = strconv.Itoa(b.reusedProjects)
+		plan.Metadata["incremental_projects_walked"] = strconv.Itoa(b.walkedProjects)
+	}
 	if !b.exhausted() {
 		return
 	}
@@ -160,6 +171,14 @@ func (b *devArtifactScanBudget) annotatePlan(plan *CleanupPlan) {
 // DevArtifactsPlugin handles stale development artifact cleanup.
 type DevArtifactsPlugin struct {
 	activeProcesses func(context.Context) (map[string]string, error)
+	// protected tallies candidates skipped by protect_paths during Cleanup.
+	protected protectedSkips
+}
+
+// protectedSkips counts cleanup candidates skipped because they are protected.
+type protectedSkips struct {
+	items int
+	bytes int64
 }
 
 // NewDevArtifactsPlugin creates a new development artifact cleanup plugin.
@@ -177,6 +196,21 @@ func (p *DevArtifactsPlugin) Description() string {
 	return "Cleans stale development artifacts (node_modules, .venv, target/, zig, go cache, haskell, lmstudio) and reports large local artifacts"
 }
 
+// Priority runs dev-artifact cleanup mid-order; rebuilding outputs is slow.
+func (p *DevArtifactsPlugin) Priority() int {
+	return 45
+}
+
+// HeavyAt reports dev-artifact cleanup as heavy; it walks every scan path.
+func (p *DevArtifactsPlugin) HeavyAt(level CleanupLevel, cfg *config.Config) bool {
+	return true
+}
+
+// FreedBytesVolume reports that artifact deletions land on the monitored volume.
+func (p *DevArtifactsPlugin) FreedBytesVolume(cfg *config.Config) string {
+	return ""
+}
+
 // SupportedPlatforms returns supported platforms (all).
 func (p *DevArtifactsPlugin) SupportedPlatforms() []string {
 	return nil // All platforms
@@ -187,16 +221,33 @@ func (p *DevArtifactsPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.DevArtifacts
 }
 
+// LevelDescription summarizes development artifact cleanup at each level.
+func (p *DevArtifactsPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports development artifacts without deleting them"
+	case LevelModerate:
+		return "deletes inactive node_modules, Rust target/, and Zig outputs older than 30 days, virtualenvs older than 60 days, Python tool caches, .ghcup/cache, and runs go clean -testcache"
+	case LevelAggressive:
+		return "deletes inactive artifacts older than 7 days (virtualenvs 14 days), runs go clean -cache, and deletes old .cabal/store files"
+	case LevelCritical:
+		return "deletes all inactive artifacts regardless of age and, when lmstudio_models is enabled, LM Studio models older than 30 days"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup reports stale development artifact candidates without deleting them.
 func (p *DevArtifactsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	_ = logger
 
-	home, _ := os.UserHomeDir()
 	daCfg := cfg.DevArtifacts
 	nodeAge, venvAge, rustAge, zigAge, mutates := devArtifactThresholds(level)
 	scanBudget := newDevArtifactScanBudget(daCfg)
 	scanCtx, cancelScan := scanBudget.context(ctx)
 	defer cancelScan()
+	manifest, manifestPath, manifestErr := devArtifactIncrementalScan(cfg)
+	scanBudget.manifest = manifest
 	plan := CleanupPlan{
 		Plugin:   p.Name(),
 		Level:    level.String(),
@@ -206,6 +257,7 @@ func (p *DevArtifactsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 			"Scan configured development workspaces for rebuildable artifact directories",
 			"Surface large top-level temporary proof/output directories for manual review without deleting them",
 			"Use project marker mtimes to classify stale node_modules, .venv, Rust target, and Zig artifact directories",
+			"Treat Python tool caches such as __pycache__ and .pytest_cache as regenerable without a staleness check",
 			"Protect artifact families when matching package manager, compiler, language server, or runtime processes are active",
 			"Report large disk images and VM bundles for manual review without deleting them",
 			"Honor configured protected paths before any deletion candidate is eligible",
@@ -214,11 +266,23 @@ func (p *DevArtifactsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 			"scan_path_count":      strconv.Itoa(len(daCfg.ScanPaths)),
 			"temp_scan_path_count": strconv.Itoa(len(daCfg.TempScanPaths)),
 			"mutates":              strconv.FormatBool(mutates),
+			"incremental":          strconv.FormatBool(manifest != nil),
 		},
 	}
+	if manifestErr != nil {
+		plan.Warnings = append(plan.Warnings, manifestErr.Error()+"; running a full scan")
+	}
 	if !mutates {
 		plan.Warnings = append(plan.Warnings, "warning level reports development artifacts only; moderate or higher is required for deletion")
 	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Development artifact cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
 
 	active, activeErr := p.activeDevArtifactProcesses(ctx)
 	if activeErr != nil {
@@ -258,6 +322,9 @@ func (p *DevArtifactsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 		if daCfg.PythonVenvs {
 			p.planPythonVenvs(scanCtx, expanded, venvAge, mutates, daCfg.ProtectPaths, active, tracker, &targets, scanBudget)
 		}
+		if daCfg.PythonBuildCaches {
+			p.planPythonBuildCaches(scanCtx, expanded, mutates, daCfg.ProtectPaths, active, tracker, &targets, scanBudget)
+		}
 		if daCfg.RustTargets {
 			p.planRustTargets(scanCtx, expanded, rustAge, mutates, daCfg.ProtectPaths, active, tracker, &targets, scanBudget)
 		}
@@ -298,22 +365,60 @@ func (p *DevArtifactsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel
 	plan.Metadata["target_count"] = strconv.Itoa(len(targets))
 	plan.Metadata["total_physical_bytes"] = strconv.FormatInt(total, 10)
 	scanBudget.annotatePlan(&plan)
+	if err := manifest.save(manifestPath); err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not save dev-artifacts manifest: %v", err))
+	}
 
 	return plan
 }
 
-// Cleanup performs dev artifact cleanup at the specified level.
+// Cleanup performs dev artifact cleanup at the specified level and reports
+// how much protect_paths kept in place and how many paths were unreadable.
 func (p *DevArtifactsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	p.protected = protectedSkips{}
+	skipped := walkErrors.snapshot()
+	result := p.cleanup(ctx, level, cfg, logger)
+	recordWalkSkips(&result, skipped, logger)
+	result.ProtectedSkippedItems = p.protected.items
+	result.ProtectedSkippedBytes = p.protected.bytes
+	if p.protected.items > 0 {
+		logger.Info("dev artifacts skipped by protect_paths",
+			"items", p.protected.items,
+			"size_mb", p.protected.bytes/(1024*1024))
+	}
+	return result
+}
+
+func (p *DevArtifactsPlugin) cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
 		Plugin: p.Name(),
 		Level:  level,
 	}
 
-	home, _ := os.UserHomeDir()
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping dev-artifacts cleanup: home directory unavailable", "error", err)
+		return result
+	}
 	daCfg := cfg.DevArtifacts
 	scanBudget := newDevArtifactScanBudget(daCfg)
 	scanCtx, cancelScan := scanBudget.context(ctx)
 	defer cancelScan()
+	manifest, manifestPath, manifestErr := devArtifactIncrementalScan(cfg)
+	if manifestErr != nil {
+		logger.Warn("running a full dev artifact scan", "error", manifestErr)
+	}
+	scanBudget.manifest = manifest
+	defer func() {
+		if err := manifest.save(manifestPath); err != nil {
+			logger.Warn("failed to save dev-artifacts manifest", "path", manifestPath, "error", err)
+		}
+		if manifest != nil {
+			logger.Debug("incremental dev artifact scan",
+				"projects_reused", scanBudget.reusedProjects,
+				"projects_walked", scanBudget.walkedProjects)
+		}
+	}()
 
 	// Determine staleness thresholds based on level
 	nodeAge, venvAge, rustAge, zigAge, mutates := devArtifactThresholds(level)
@@ -365,6 +470,16 @@ func (p *DevArtifactsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 			return result
 		}
 
+		if daCfg.PythonBuildCaches {
+			freed, dirs := p.cleanPythonBuildCaches(scanCtx, expanded, daCfg.ProtectPaths, tracker, logger, scanBudget)
+			result.BytesFreed += freed
+			result.ItemsCleaned += dirs
+		}
+		if scanBudget.exhausted() {
+			logger.Warn("stopping dev artifact cleanup because scan budget was exhausted", "truncated_paths", strings.Join(scanBudget.truncatedDetails(), "; "))
+			return result
+		}
+
 		if daCfg.RustTargets && !devArtifactFamilyActive(active, "rust-target") {
 			freed := p.cleanRustTargets(scanCtx, expanded, rustAge, daCfg.ProtectPaths, tracker, logger, scanBudget)
 			result.BytesFreed += freed
@@ -413,7 +528,7 @@ func (p *DevArtifactsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 
 	// Go build cache (not path-dependent - it's a global cache)
 	if daCfg.GoBuildCache && !devArtifactFamilyActive(active, "go-build-cache") {
-		freed := p.cleanGoBuildCache(ctx, level, logger)
+		freed := p.cleanGoBuildCache(ctx, level, &result, logger)
 		result.BytesFreed += freed
 		if freed > 0 {
 			result.ItemsCleaned++
@@ -422,7 +537,7 @@ func (p *DevArtifactsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 
 	// Haskell cache cleanup
 	if daCfg.HaskellCache && !devArtifactFamilyActive(active, "haskell-cache") {
-		freed := p.cleanHaskellCache(ctx, level, home, logger)
+		freed := p.cleanHaskellCache(ctx, level, home, &result, logger)
 		result.BytesFreed += freed
 		if freed > 0 {
 			result.ItemsCleaned++
@@ -431,7 +546,7 @@ func (p *DevArtifactsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 
 	// LM Studio models (opt-in only)
 	if daCfg.LMStudioModels && !devArtifactFamilyActive(active, "lmstudio-models") {
-		freed := p.cleanLMStudioModels(ctx, level, home, logger)
+		freed := p.cleanLMStudioModels(ctx, level, home, &result, logger)
 		result.BytesFreed += freed
 		if freed > 0 {
 			result.ItemsCleaned++
@@ -459,7 +574,8 @@ func (p *DevArtifactsPlugin) planNodeModules(ctx context.Context, scanPath strin
 	p.findArtifactDirs(ctx, scanPath, "node_modules", "package.json", func(dir string, size int64) {
 		marker := filepath.Join(filepath.Dir(dir), "package.json")
 		stale := maxAge == 0 || p.isFileStale(marker, maxAge)
-		*targets = append(*targets, p.devArtifactTarget("node_modules", "node_modules", dir, size, stale, mutates, p.isProtected(dir, protectPaths), "", tracker.ContainsTrackedFiles(dir), "package.json", maxAge, active))
+		protectedBy := p.protectedBy(dir, protectPaths)
+		*targets = append(*targets, p.devArtifactTarget("node_modules", "node_modules", dir, size, stale, mutates, protectedBy != "", protectPathReason(protectedBy), tracker.ContainsTrackedFiles(dir), "package.json", maxAge, active))
 	}, budget)
 }
 
@@ -468,16 +584,42 @@ func (p *DevArtifactsPlugin) planPythonVenvs(ctx context.Context, scanPath strin
 	markers := []string{"pyproject.toml", "setup.py", "requirements.txt"}
 	p.findArtifactDirs(ctx, scanPath, ".venv", "", func(dir string, size int64) {
 		stale := maxAge == 0 || p.pythonProjectStale(filepath.Dir(dir), markers, maxAge)
-		*targets = append(*targets, p.devArtifactTarget("python-venv", ".venv", dir, size, stale, mutates, p.isProtected(dir, protectPaths), "", tracker.ContainsTrackedFiles(dir), strings.Join(markers, ", "), maxAge, active))
+		protectedBy := p.protectedBy(dir, protectPaths)
+		*targets = append(*targets, p.devArtifactTarget("python-venv", ".venv", dir, size, stale, mutates, protectedBy != "", protectPathReason(protectedBy), tracker.ContainsTrackedFiles(dir), strings.Join(markers, ", "), maxAge, active))
 	}, budget)
 }
 
+// pythonBuildCacheNames lists Python tool cache directories that regenerate on
+// demand.
+func pythonBuildCacheNames() []string {
+	return []string{"__pycache__", ".pytest_cache", ".mypy_cache", ".ruff_cache", ".tox"}
+}
+
+func (p *DevArtifactsPlugin) planPythonBuildCaches(ctx context.Context, scanPath string, mutates bool, protectPaths []string, active map[string]string, tracker *devArtifactGitTracker, targets *[]CleanupTarget, budgets ...*devArtifactScanBudget) {
+	budget := optionalDevArtifactScanBudget(budgets)
+	for _, cacheName := range pythonBuildCacheNames() {
+		p.findArtifactDirs(ctx, scanPath, cacheName, "", func(dir string, size int64) {
+			protectReason := protectPathReason(p.protectedBy(dir, protectPaths))
+			tracked := tracker.ContainsTrackedFiles(dir)
+			if protectReason == "" && !tracked {
+				protectReason = devArtifactRecentOutputProtectReasonContext(ctx, dir)
+			}
+			target := p.devArtifactTarget("python-build-cache", cacheName, dir, size, true, mutates, protectReason != "", protectReason, tracked, "", 0, active)
+			if target.Action == "delete" {
+				target.Reason = "Python tool cache regenerates on demand"
+			}
+			*targets = append(*targets, target)
+		}, budget)
+	}
+}
+
 func (p *DevArtifactsPlugin) planRustTargets(ctx context.Context, scanPath string, maxAge time.Duration, mutates bool, protectPaths []string, active map[string]string, tracker *devArtifactGitTracker, targets *[]CleanupTarget, budgets ...*devArtifactScanBudget) {
 	budget := optionalDevArtifactScanBudget(budgets)
 	p.findArtifactDirs(ctx, scanPath, "target", "Cargo.toml", func(dir string, size int64) {
 		marker := filepath.Join(filepath.Dir(dir), "Cargo.toml")
 		stale := maxAge == 0 || p.isFileStale(marker, maxAge)
-		*targets = append(*targets, p.devArtifactTarget("rust-target", "target", dir, size, stale, mutates, p.isProtected(dir, protectPaths), "", tracker.ContainsTrackedFiles(dir), "Cargo.toml", maxAge, active))
+		protectedBy := p.protectedBy(dir, protectPaths)
+		*targets = append(*targets, p.devArtifactTarget("rust-target", "target", dir, size, stale, mutates, protectedBy != "", protectPathReason(protectedBy), tracker.ContainsTrackedFiles(dir), "Cargo.toml", maxAge, active))
 	}, budget)
 }
 
@@ -487,13 +629,12 @@ func (p *DevArtifactsPlugin) planZigArtifacts(ctx context.Context, scanPath stri
 		p.findArtifactDirs(ctx, scanPath, artifactName, "build.zig", func(dir string, size int64) {
 			marker := filepath.Join(filepath.Dir(dir), "build.zig")
 			stale := maxAge == 0 || p.isFileStale(marker, maxAge)
-			protected := p.isProtected(dir, protectPaths)
+			protectReason := protectPathReason(p.protectedBy(dir, protectPaths))
 			tracked := tracker.ContainsTrackedFiles(dir)
-			recentReason := ""
-			if !protected && !tracked {
-				recentReason = devArtifactRecentOutputProtectReasonContext(ctx, dir)
+			if protectReason == "" && !tracked {
+				protectReason = devArtifactRecentOutputProtectReasonContext(ctx, dir)
 			}
-			*targets = append(*targets, p.devArtifactTarget("zig-artifact", artifactName, dir, size, stale, mutates, protected || recentReason != "", recentReason, tracked, "build.zig", maxAge, active))
+			*targets = append(*targets, p.devArtifactTarget("zig-artifact", artifactName, dir, size, stale, mutates, protectReason != "", protectReason, tracked, "build.zig", maxAge, active))
 		}, budget)
 	}
 }
@@ -529,7 +670,7 @@ func (p *DevArtifactsPlugin) planTemporaryArtifacts(ctx context.Context, scanPat
 		}
 		activeReason := activeRoots[canonicalTempArtifactPath(path)]
 		if activeReason != "" {
-			*targets = append(*targets, p.temporaryArtifactTarget(path, 0, info.ModTime(), staleAfter, now, p.isProtected(path, protectPaths), activeReason))
+			*targets = append(*targets, p.temporaryArtifactTarget(path, 0, info.ModTime(), staleAfter, now, p.protectedBy(path, protectPaths), activeReason))
 			continue
 		}
 		size, err := getDirAllocatedBytesContext(ctx, path)
@@ -538,9 +679,12 @@ func (p *DevArtifactsPlugin) planTemporaryArtifacts(ctx context.Context, scanPat
 			return
 		}
 		if size < minBytes {
+			if explainBelowMinSize.Load() {
+				*targets = append(*targets, belowMinSizeTarget("temporary-dev-artifact", filepath.Base(path), path, size, "dev_artifacts.temp_artifact_min_mb", minBytes))
+			}
 			continue
 		}
-		*targets = append(*targets, p.temporaryArtifactTarget(path, size, info.ModTime(), staleAfter, now, p.isProtected(path, protectPaths), ""))
+		*targets = append(*targets, p.temporaryArtifactTarget(path, size, info.ModTime(), staleAfter, now, p.protectedBy(path, protectPaths), ""))
 	}
 }
 
@@ -605,7 +749,7 @@ func (p *DevArtifactsPlugin) forEachStaleTemporaryRoot(ctx context.Context, scan
 		if err != nil {
 			continue
 		}
-		if p.isProtected(root, protectPaths) {
+		if p.skipProtected(root, 0, protectPaths) {
 			continue
 		}
 		if activeRoots[canonicalTempArtifactPath(root)] != "" {
@@ -628,7 +772,7 @@ func (p *DevArtifactsPlugin) forEachStaleTemporaryRoot(ctx context.Context, scan
 	}
 }
 
-func (p *DevArtifactsPlugin) temporaryArtifactTarget(path string, physicalBytes int64, modTime time.Time, staleAfter time.Duration, now time.Time, protected bool, activeReason string) CleanupTarget {
+func (p *DevArtifactsPlugin) temporaryArtifactTarget(path string, physicalBytes int64, modTime time.Time, staleAfter time.Duration, now time.Time, protectedBy string, activeReason string) CleanupTarget {
 	action := "review_temp_artifact"
 	reason := fmt.Sprintf("large top-level temporary artifact is older than %s; manual review required before deletion", formatDevArtifactAge(staleAfter))
 	active := activeReason != ""
@@ -637,12 +781,12 @@ func (p *DevArtifactsPlugin) temporaryArtifactTarget(path string, physicalBytes
 	case active:
 		action = "protect"
 		reason = "active process references this temporary path: " + activeReason
-	case protected:
+	case protectedBy != "":
 		action = "protect"
-		reason = "path is covered by dev_artifacts.protect_paths"
+		reason = protectPathReason(protectedBy)
 	case staleAfter > 0 && modTime.After(now.Add(-staleAfter)):
 		action = "protect"
-		reason = fmt.Sprintf("temporary artifact is newer than %s", formatDevArtifactAge(staleAfter))
+		reason = fmt.Sprintf("temporary artifact is newer than %s (modified %s ago)", formatDevArtifactAge(staleAfter), formatElapsedAge(now.Sub(modTime)))
 	}
 	target := CleanupTarget{
 		Type:      "temporary-dev-artifact",
@@ -676,6 +820,7 @@ func (p *DevArtifactsPlugin) findLargeLocalArtifacts(ctx context.Context, scanPa
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 
@@ -701,7 +846,9 @@ func (p *DevArtifactsPlugin) findLargeLocalArtifacts(ctx context.Context, scanPa
 					return err
 				}
 				if size >= minBytes {
-					callback(p.largeLocalArtifactTarget(kind, path, size, largeLocalArtifactDirLogicalBytes(ext, path), p.isProtected(path, protectPaths), mountedImages[filepath.Clean(path)]))
+					callback(p.largeLocalArtifactTarget(kind, path, size, largeLocalArtifactDirLogicalBytes(ext, path), p.protectedBy(path, protectPaths), mountedImages[filepath.Clean(path)]))
+				} else if explainBelowMinSize.Load() {
+					callback(belowMinSizeTarget("large-local-artifact", kind, path, size, "dev_artifacts.large_local_artifact_min_mb", minBytes))
 				}
 				return filepath.SkipDir
 			}
@@ -720,23 +867,26 @@ func (p *DevArtifactsPlugin) findLargeLocalArtifacts(ctx context.Context, scanPa
 			physicalBytes = info.Size()
 		}
 		if physicalBytes < minBytes {
+			if explainBelowMinSize.Load() {
+				callback(belowMinSizeTarget("large-local-artifact", kind, path, physicalBytes, "dev_artifacts.large_local_artifact_min_mb", minBytes))
+			}
 			return nil
 		}
-		callback(p.largeLocalArtifactTarget(kind, path, physicalBytes, info.Size(), p.isProtected(path, protectPaths), mountedImages[filepath.Clean(path)]))
+		callback(p.largeLocalArtifactTarget(kind, path, physicalBytes, info.Size(), p.protectedBy(path, protectPaths), mountedImages[filepath.Clean(path)]))
 		return nil
 	})
 }
 
-func (p *DevArtifactsPlugin) largeLocalArtifactTarget(kind, path string, physicalBytes, logicalBytes int64, protected bool, mountPoint string) CleanupTarget {
+func (p *DevArtifactsPlugin) largeLocalArtifactTarget(kind, path string, physicalBytes, logicalBytes int64, protectedBy string, mountPoint string) CleanupTarget {
 	action := "review"
 	reason := largeLocalArtifactReviewReason(kind)
 	active := mountPoint != ""
 	if active {
 		action = "protect"
 		reason = "disk/image artifact is mounted at " + mountPoint + "; detach before manual cleanup"
-	} else if protected {
+	} else if protectedBy != "" {
 		action = "protect"
-		reason = "path is covered by dev_artifacts.protect_paths"
+		reason = protectPathReason(protectedBy)
 	}
 	target := CleanupTarget{
 		Type:         "large-local-artifact",
@@ -940,6 +1090,9 @@ func (p *DevArtifactsPlugin) devArtifactTarget(targetType, name, path string, by
 	default:
 		target.Action = "protect"
 		target.Reason = fmt.Sprintf("project marker %s is newer than %s", marker, formatDevArtifactAge(maxAge))
+		if age, ok := devArtifactMarkerAge(filepath.Dir(path), marker); ok {
+			target.Reason += fmt.Sprintf(" (modified %s ago)", formatElapsedAge(age))
+		}
 	}
 	annotateCleanupTargetPolicy(&target, devArtifactTier(targetType), hostReclaimForAction(target.Action))
 	return target
@@ -947,7 +1100,7 @@ func (p *DevArtifactsPlugin) devArtifactTarget(targetType, name, path string, by
 
 func devArtifactTier(targetType string) string {
 	switch targetType {
-	case "go-build-cache", "haskell-ghcup-cache":
+	case "go-build-cache", "haskell-ghcup-cache", "python-build-cache":
 		return CleanupTierSafe
 	case "lmstudio-models", "large-local-artifact":
 		return CleanupTierDestructive
@@ -1277,6 +1430,15 @@ func formatDevArtifactAge(maxAge time.Duration) string {
 	return maxAge.String()
 }
 
+// formatElapsedAge formats how long ago something changed: in whole days
+// from one day, and to the minute below that.
+func formatElapsedAge(age time.Duration) string {
+	if age >= 24*time.Hour {
+		return formatDevArtifactAge(age.Truncate(24 * time.Hour))
+	}
+	return age.Truncate(time.Minute).String()
+}
+
 type devArtifactGitTracker struct {
 	gitPath            string
 	repoRootByDir      map[string]string
@@ -1416,6 +1578,7 @@ func devArtifactHasRecentContent(ctx context.Context, path string, grace time.Du
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if !info.Mode().IsRegular() {
@@ -1452,6 +1615,15 @@ func (p *DevArtifactsPlugin) reportArtifacts(ctx context.Context, daCfg config.D
 			}, budget)
 		}
 
+		// Find and report Python tool caches
+		if daCfg.PythonBuildCaches {
+			for _, cacheName := range pythonBuildCacheNames() {
+				p.findArtifactDirs(ctx, expanded, cacheName, "", func(dir string, size int64) {
+					logger.Info("found Python tool cache", "path", dir, "size_mb", size/(1024*1024))
+				}, budget)
+			}
+		}
+
 		// Find and report target/
 		if daCfg.RustTargets {
 			p.findArtifactDirs(ctx, expanded, "target", "Cargo.toml", func(dir string, size int64) {
@@ -1516,7 +1688,7 @@ func (p *DevArtifactsPlugin) cleanNodeModules(ctx context.Context, scanPath stri
 	budget := optionalDevArtifactScanBudget(budgets)
 
 	p.findArtifactDirs(ctx, scanPath, "node_modules", "package.json", func(dir string, size int64) {
-		if p.isProtected(dir, protectPaths) {
+		if p.skipProtected(dir, size, protectPaths) {
 			return
 		}
 		if tracker.ContainsTrackedFiles(dir) {
@@ -1554,7 +1726,7 @@ func (p *DevArtifactsPlugin) cleanPythonVenvs(ctx context.Context, scanPath stri
 	budget := optionalDevArtifactScanBudget(budgets)
 
 	p.findArtifactDirs(ctx, scanPath, ".venv", "", func(dir string, size int64) {
-		if p.isProtected(dir, protectPaths) {
+		if p.skipProtected(dir, size, protectPaths) {
 			return
 		}
 		if tracker.ContainsTrackedFiles(dir) {
@@ -1608,6 +1780,46 @@ func (p *DevArtifactsPlugin) cleanPythonVenvs(ctx context.Context, scanPath stri
 	return totalFreed
 }
 
+// cleanPythonBuildCaches removes Python tool cache directories. They are pure
+// caches, so no project staleness check applies, but protected paths, tracked
+// files, and recently written caches are preserved. It returns the bytes freed
+// and the number of directories removed.
+func (p *DevArtifactsPlugin) cleanPythonBuildCaches(ctx context.Context, scanPath string, protectPaths []string, tracker *devArtifactGitTracker, logger *slog.Logger, budgets ...*devArtifactScanBudget) (int64, int) {
+	var totalFreed int64
+	var removed int
+	budget := optionalDevArtifactScanBudget(budgets)
+
+	for _, cacheName := range pythonBuildCacheNames() {
+		p.findArtifactDirs(ctx, scanPath, cacheName, "", func(dir string, size int64) {
+			if p.skipProtected(dir, size, protectPaths) {
+				return
+			}
+			if tracker.ContainsTrackedFiles(dir) {
+				logger.Debug("preserving Python tool cache containing tracked files", "path", dir)
+				return
+			}
+			if reason := devArtifactRecentOutputProtectReasonContext(ctx, dir); reason != "" {
+				logger.Debug("preserving recently written Python tool cache", "path", dir, "reason", reason)
+				return
+			}
+
+			logger.Debug("removing Python tool cache", "path", dir, "size_mb", size/(1024*1024))
+			if err := os.RemoveAll(dir); err != nil {
+				logger.Debug("failed to remove Python tool cache", "path", dir, "error", err)
+				return
+			}
+			totalFreed += size
+			removed++
+		}, budget)
+	}
+
+	if removed > 0 {
+		logger.Info("cleaned Python tool caches", "freed_mb", totalFreed/(1024*1024), "directories", removed)
+	}
+
+	return totalFreed, removed
+}
+
 // cleanRustTargets removes stale Rust target/ directories.
 // A target/ is stale if sibling Cargo.toml hasn't been modified within maxAge.
 func (p *DevArtifactsPlugin) cleanRustTargets(ctx context.Context, scanPath string, maxAge time.Duration, protectPaths []string, tracker *devArtifactGitTracker, logger *slog.Logger, budgets ...*devArtifactScanBudget) int64 {
@@ -1615,7 +1827,7 @@ func (p *DevArtifactsPlugin) cleanRustTargets(ctx context.Context, scanPath stri
 	budget := optionalDevArtifactScanBudget(budgets)
 
 	p.findArtifactDirs(ctx, scanPath, "target", "Cargo.toml", func(dir string, size int64) {
-		if p.isProtected(dir, protectPaths) {
+		if p.skipProtected(dir, size, protectPaths) {
 			return
 		}
 		if tracker.ContainsTrackedFiles(dir) {
@@ -1651,7 +1863,7 @@ func (p *DevArtifactsPlugin) cleanZigArtifacts(ctx context.Context, scanPath str
 
 	for _, artifactName := range []string{".zig-cache", "zig-out"} {
 		p.findArtifactDirs(ctx, scanPath, artifactName, "build.zig", func(dir string, size int64) {
-			if p.isProtected(dir, protectPaths) {
+			if p.skipProtected(dir, size, protectPaths) {
 				return
 			}
 			if tracker.ContainsTrackedFiles(dir) {
@@ -1685,7 +1897,7 @@ func (p *DevArtifactsPlugin) cleanZigArtifacts(ctx context.Context, scanPath str
 }
 
 // cleanGoBuildCache cleans the Go build cache using go clean.
-func (p *DevArtifactsPlugin) cleanGoBuildCache(ctx context.Context, level CleanupLevel, logger *slog.Logger) int64 {
+func (p *DevArtifactsPlugin) cleanGoBuildCache(ctx context.Context, level CleanupLevel, result *CleanupResult, logger *slog.Logger) int64 {
 	if _, err := exec.LookPath("go"); err != nil {
 		return 0
 	}
@@ -1716,7 +1928,7 @@ func (p *DevArtifactsPlugin) cleanGoBuildCache(ctx context.Context, level Cleanu
 	}
 
 	sizeAfter := getDirSize(goCacheDir)
-	freed := safeBytesDiff(sizeBefore, sizeAfter)
+	freed := measuredBytesDiff(result, logger, goCacheDir, sizeBefore, sizeAfter)
 	if freed > 0 {
 		logger.Info("cleaned Go build cache", "freed_mb", freed/(1024*1024))
 	}
@@ -1724,7 +1936,7 @@ func (p *DevArtifactsPlugin) cleanGoBuildCache(ctx context.Context, level Cleanu
 }
 
 // cleanHaskellCache cleans Haskell-related caches.
-func (p *DevArtifactsPlugin) cleanHaskellCache(ctx context.Context, level CleanupLevel, home string, logger *slog.Logger) int64 {
+func (p *DevArtifactsPlugin) cleanHaskellCache(ctx context.Context, level CleanupLevel, home string, result *CleanupResult, logger *slog.Logger) int64 {
 	var totalFreed int64
 
 	// .ghcup/cache - always safe to clean (downloaded tarballs)
@@ -1744,7 +1956,7 @@ func (p *DevArtifactsPlugin) cleanHaskellCache(ctx context.Context, level Cleanu
 			sizeBefore := getDirSize(cabalStore)
 			deleteOldFiles(cabalStore, 30*24*time.Hour)
 			sizeAfter := getDirSize(cabalStore)
-			freed := safeBytesDiff(sizeBefore, sizeAfter)
+			freed := measuredBytesDiff(result, logger, cabalStore, sizeBefore, sizeAfter)
 			if freed > 0 {
 				totalFreed += freed
 				logger.Debug("cleaned old .cabal/store entries", "freed_mb", freed/(1024*1024))
@@ -1768,7 +1980,7 @@ func (p *DevArtifactsPlugin) cleanHaskellCache(ctx context.Context, level Cleanu
 				sizeBefore := size
 				deleteOldFiles(pantryCachePath, 14*24*time.Hour)
 				sizeAfter := getDirSize(pantryCachePath)
-				freed := safeBytesDiff(sizeBefore, sizeAfter)
+				freed := measuredBytesDiff(result, logger, pantryCachePath, sizeBefore, sizeAfter)
 				totalFreed += freed
 			}
 		}
@@ -1782,7 +1994,7 @@ func (p *DevArtifactsPlugin) cleanHaskellCache(ctx context.Context, level Cleanu
 }
 
 // cleanLMStudioModels cleans LM Studio model files.
-func (p *DevArtifactsPlugin) cleanLMStudioModels(ctx context.Context, level CleanupLevel, home string, logger *slog.Logger) int64 {
+func (p *DevArtifactsPlugin) cleanLMStudioModels(ctx context.Context, level CleanupLevel, home string, result *CleanupResult, logger *slog.Logger) int64 {
 	lmStudioDir := filepath.Join(home, ".lmstudio", "models")
 	if !pathExistsAndIsDir(lmStudioDir) {
 		return 0
@@ -1809,7 +2021,7 @@ func (p *DevArtifactsPlugin) cleanLMStudioModels(ctx context.Context, level Clea
 		sizeBefore := getDirSize(lmStudioDir)
 		deleteOldFiles(lmStudioDir, 30*24*time.Hour)
 		sizeAfter := getDirSize(lmStudioDir)
-		freed := safeBytesDiff(sizeBefore, sizeAfter)
+		freed := measuredBytesDiff(result, logger, lmStudioDir, sizeBefore, sizeAfter)
 		if freed > 0 {
 			logger.Warn("CRITICAL: cleaned old LM Studio models", "freed_mb", freed/(1024*1024))
 		}
@@ -1823,15 +2035,63 @@ func (p *DevArtifactsPlugin) cleanLMStudioModels(ctx context.Context, level Clea
 // If markerFile is set, only reports dirs that have a sibling marker file.
 // Callback receives the artifact dir path and its size.
 // Limits directory depth to 4 levels to avoid excessive scanning.
+// In incremental mode each top-level project directory is walked only when
+// its mtime differs from the manifest.
 func (p *DevArtifactsPlugin) findArtifactDirs(ctx context.Context, scanPath string, targetName string, markerFile string, callback func(dir string, size int64), budgets ...*devArtifactScanBudget) {
 	budget := optionalDevArtifactScanBudget(budgets)
+	scanBase := filepath.Base(scanPath)
+	if budget == nil || budget.manifest == nil || scanBase == targetName || (strings.HasPrefix(scanBase, ".") && scanBase != ".venv") {
+		p.walkArtifactDirs(ctx, scanPath, scanPath, targetName, markerFile, callback, budget)
+		return
+	}
+
+	projects, err := os.ReadDir(scanPath)
+	if err != nil {
+		return
+	}
+	kind := targetName + "|" + markerFile
+	for _, entry := range projects {
+		if !entry.IsDir() || ctx.Err() != nil || budget.exhausted() {
+			continue
+		}
+		project := filepath.Join(scanPath, entry.Name())
+		info, err := os.Stat(project)
+		if err != nil {
+			continue
+		}
+		if cached, ok := budget.manifest.lookup(project, kind, info.ModTime()); ok {
+			budget.reusedProjects++
+			for _, artifact := range cached {
+				if pathExistsAndIsDir(artifact.Path) {
+					callback(artifact.Path, artifact.Bytes)
+				}
+			}
+			continue
+		}
+
+		budget.walkedProjects++
+		var found []devArtifactManifestEntry
+		p.walkArtifactDirs(ctx, scanPath, project, targetName, markerFile, func(dir string, size int64) {
+			found = append(found, devArtifactManifestEntry{Path: dir, Bytes: size})
+			callback(dir, size)
+		}, budget)
+		if ctx.Err() == nil && !budget.exhausted() {
+			budget.manifest.store(project, kind, info.ModTime(), found)
+		}
+	}
+}
+
+// walkArtifactDirs walks root, measuring depth from scanPath, and reports
+// artifact directories to callback.
+func (p *DevArtifactsPlugin) walkArtifactDirs(ctx context.Context, scanPath string, root string, targetName string, markerFile string, callback func(dir string, size int64), budget *devArtifactScanBudget) {
 	scanDepth := strings.Count(scanPath, string(os.PathSeparator))
 
-	filepath.Walk(scanPath, func(path string, info os.FileInfo, err error) error {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err := budget.checkPath(ctx, path); err != nil {
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 
@@ -1903,16 +2163,91 @@ func (p *DevArtifactsPlugin) isFileStale(path string, maxAge time.Duration) bool
 
 // isProtected checks if a path is in the protect list.
 func (p *DevArtifactsPlugin) isProtected(path string, protectPaths []string) bool {
+	return p.protectedBy(path, protectPaths) != ""
+}
+
+// protectedBy returns the protect list entry covering path, or "".
+func (p *DevArtifactsPlugin) protectedBy(path string, protectPaths []string) string {
 	for _, protect := range protectPaths {
 		if strings.HasPrefix(path, protect) {
-			return true
+			return protect
+		}
+	}
+	return ""
+}
+
+// protectPathReason explains a plan target kept by the protect list entry
+// protect, or returns "" when protect is empty.
+func protectPathReason(protect string) string {
+	if protect == "" {
+		return ""
+	}
+	return "path is covered by dev_artifacts.protect_paths entry " + protect
+}
+
+// devArtifactMarkerAge returns how long ago the newest of the comma-separated
+// project markers in dir was modified.
+func devArtifactMarkerAge(dir, markers string) (time.Duration, bool) {
+	var newest time.Time
+	for _, marker := range strings.Split(markers, ", ") {
+		if marker == "" {
+			continue
 		}
+		if info, err := os.Stat(filepath.Join(dir, marker)); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	if newest.IsZero() {
+		return 0, false
 	}
-	return false
+	return time.Since(newest), true
+}
+
+// explainBelowMinSize makes plans also list candidates skipped for being
+// under a minimum size, as kept targets. -explain-protection sets it.
+var explainBelowMinSize atomic.Bool
+
+// SetExplainProtection makes dry-run plans list candidates that a minimum
+// size would otherwise leave out, so every decision can be explained.
+func SetExplainProtection(enabled bool) {
+	explainBelowMinSize.Store(enabled)
+}
+
+// belowMinSizeTarget is a kept target for a candidate under the minimum size
+// set by setting.
+func belowMinSizeTarget(targetType, name, path string, bytes int64, setting string, minBytes int64) CleanupTarget {
+	target := CleanupTarget{
+		Type:      targetType,
+		Name:      name,
+		Path:      path,
+		Bytes:     bytes,
+		Protected: true,
+		Action:    "keep",
+		Reason:    fmt.Sprintf("below %s (%d MiB)", setting, minBytes/(1024*1024)),
+	}
+	annotateCleanupTargetPolicy(&target, devArtifactTier(targetType), hostReclaimForAction(target.Action))
+	return target
+}
+
+// skipProtected is isProtected for cleanup paths: a protected candidate is
+// tallied with its size, or 0 when it has not been sized, so Cleanup can
+// report how much the protect list kept.
+func (p *DevArtifactsPlugin) skipProtected(path string, size int64, protectPaths []string) bool {
+	if !p.isProtected(path, protectPaths) {
+		return false
+	}
+	p.protected.items++
+	p.protected.bytes += size
+	return true
 }
 
 // expandHome expands ~ to the home directory in a path.
 func expandHome(path string, home string) string {
+	if home == "" {
+		// Leave "~" unexpanded rather than resolving it against the working
+		// directory or filesystem root.
+		return path
+	}
 	if strings.HasPrefix(path, "~/") {
 		return filepath.Join(home, path[2:])
 	}
@@ -0,0 +1,160 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+const (
+	devArtifactManifestVersion  = 1
+	devArtifactManifestFileName = "dev-artifacts-manifest.json"
+)
+
+// devArtifactManifest caches artifact directories found under each top-level
+// project directory of a scan path. In incremental mode a project is walked
+// again only when its own mtime changes, which happens when entries are
+// created or removed directly inside it. Changes deeper in an unchanged
+// project are missed until the next full scan.
+type devArtifactManifest struct {
+	Version    int                                   `json:"version"`
+	ConfigHash string                                `json:"config_hash"`
+	Projects   map[string]devArtifactManifestProject `json:"projects"`
+}
+
+// devArtifactManifestProject records one project directory and the artifact
+// directories found under it per scan kind.
+type devArtifactManifestProject struct {
+	ModTime time.Time                             `json:"mod_time"`
+	Scans   map[string][]devArtifactManifestEntry `json:"scans"`
+}
+
+// devArtifactManifestEntry is one discovered artifact directory.
+type devArtifactManifestEntry struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+func newDevArtifactManifest(configHash string) *devArtifactManifest {
+	return &devArtifactManifest{
+		Version:    devArtifactManifestVersion,
+		ConfigHash: configHash,
+		Projects:   map[string]devArtifactManifestProject{},
+	}
+}
+
+// devArtifactManifestPath places the manifest next to the daemon state file.
+func devArtifactManifestPath(cfg *config.Config) string {
+	if cfg.Policy.StateFile == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.Policy.StateFile), devArtifactManifestFileName)
+}
+
+// devArtifactConfigHash fingerprints the dev-artifacts settings so a config
+// change discards the manifest.
+func devArtifactConfigHash(cfg config.DevArtifactsConfig) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadDevArtifactManifest reads the manifest at path. A missing, unreadable,
+// outdated, or differently configured manifest yields an empty one, so the
+// next scan is a full scan.
+func loadDevArtifactManifest(path string, configHash string) *devArtifactManifest {
+	manifest := newDevArtifactManifest(configHash)
+	if path == "" {
+		return manifest
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest
+	}
+	var loaded devArtifactManifest
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return manifest
+	}
+	if loaded.Version != devArtifactManifestVersion || loaded.ConfigHash != configHash || loaded.Projects == nil {
+		return manifest
+	}
+	return &loaded
+}
+
+// save prunes projects that no longer exist and writes the manifest.
+func (m *devArtifactManifest) save(path string) error {
+	if m == nil || path == "" {
+		return nil
+	}
+	for project := range m.Projects {
+		if !pathExistsAndIsDir(project) {
+			delete(m.Projects, project)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// lookup returns cached artifacts for project and scan kind when the project
+// mtime is unchanged since they were recorded.
+func (m *devArtifactManifest) lookup(project string, kind string, modTime time.Time) ([]devArtifactManifestEntry, bool) {
+	if m == nil {
+		return nil, false
+	}
+	record, ok := m.Projects[project]
+	if !ok || !record.ModTime.Equal(modTime) {
+		return nil, false
+	}
+	entries, ok := record.Scans[kind]
+	return entries, ok
+}
+
+// store records the artifacts found for project and scan kind. A changed
+// project mtime drops results cached for other kinds.
+func (m *devArtifactManifest) store(project string, kind string, modTime time.Time, entries []devArtifactManifestEntry) {
+	if m == nil {
+		return
+	}
+	record, ok := m.Projects[project]
+	if !ok || !record.ModTime.Equal(modTime) {
+		record = devArtifactManifestProject{ModTime: modTime, Scans: map[string][]devArtifactManifestEntry{}}
+	}
+	if entries == nil {
+		entries = []devArtifactManifestEntry{}
+	}
+	record.Scans[kind] = entries
+	m.Projects[project] = record
+}
+
+// devArtifactIncrementalScan loads the manifest when incremental mode is
+// enabled and returns it with the path it should be saved to.
+func devArtifactIncrementalScan(cfg *config.Config) (*devArtifactManifest, string, error) {
+	if !cfg.DevArtifacts.Incremental {
+		return nil, "", nil
+	}
+	path := devArtifactManifestPath(cfg)
+	if path == "" {
+		return nil, "", errors.New("incremental dev-artifact scans need policy.state_file for the manifest")
+	}
+	return loadDevArtifactManifest(path, devArtifactConfigHash(cfg.DevArtifacts)), path, nil
+}
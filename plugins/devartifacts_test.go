@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -295,6 +296,9 @@ func TestCleanNodeModulesProtected(t *testing.T) {
 	if freed != 0 {
 		t.Error("expected protected node_modules to be preserved")
 	}
+	if p.protected.items != 1 || p.protected.bytes <= 0 {
+		t.Errorf("expected protected node_modules to be tallied with its size, got %+v", p.protected)
+	}
 }
 
 func TestCleanZigArtifactsStale(t *testing.T) {
@@ -836,6 +840,41 @@ func TestPlanTemporaryArtifactsReportsReviewOnlyTargets(t *testing.T) {
 	}
 }
 
+func TestPlanTemporaryArtifactsExplainsProtection(t *testing.T) {
+	p := NewDevArtifactsPlugin()
+	tmpDir := t.TempDir()
+	keptPath := filepath.Join(tmpDir, "kept-output")
+	smallPath := filepath.Join(tmpDir, "small-output")
+	for path, size := range map[string]int{keptPath: 8192, smallPath: 1} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "artifact"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	minBytes := getDirAllocatedBytes(keptPath)
+
+	var targets []CleanupTarget
+	p.planTemporaryArtifacts(context.Background(), tmpDir, minBytes, 6*time.Hour, []string{keptPath}, nil, &targets)
+	if len(targets) != 1 {
+		t.Fatalf("expected the small artifact left out without -explain-protection, got %#v", targets)
+	}
+	kept := findDevArtifactTarget(t, targets, "temporary-dev-artifact", keptPath)
+	if !strings.Contains(kept.Reason, "protect_paths entry "+keptPath) {
+		t.Fatalf("expected the matching protect path in the reason, got %q", kept.Reason)
+	}
+
+	SetExplainProtection(true)
+	t.Cleanup(func() { SetExplainProtection(false) })
+	targets = nil
+	p.planTemporaryArtifacts(context.Background(), tmpDir, minBytes, 6*time.Hour, []string{keptPath}, nil, &targets)
+	small := findDevArtifactTarget(t, targets, "temporary-dev-artifact", smallPath)
+	if small.Action != "keep" || !small.Protected || !strings.Contains(small.Reason, "temp_artifact_min_mb") {
+		t.Fatalf("expected the small artifact kept as below min size, got %#v", small)
+	}
+}
+
 func TestPlanCleanupReportsGeneratedArtifactsInsideStaleTemporaryRoots(t *testing.T) {
 	p := newDevArtifactsPluginWithActive(nil)
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
@@ -912,6 +951,48 @@ func TestCleanupPrunesGeneratedArtifactsInsideStaleTemporaryRoots(t *testing.T)
 	}
 }
 
+func TestCleanupReportsProtectedSkips(t *testing.T) {
+	p := newDevArtifactsPluginWithActive(nil)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "temp-rust-worktree")
+	targetDir := filepath.Join(root, "target", "debug")
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cargoToml := filepath.Join(root, "Cargo.toml")
+	if err := os.WriteFile(cargoToml, []byte("[package]\nname = \"temp-rust-worktree\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, "artifact"), make([]byte, 2*1024*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(root, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(cargoToml, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := tempGeneratedArtifactConfig(tmpDir)
+	cfg.DevArtifacts.ProtectPaths = []string{filepath.Join(root, "target")}
+	result := p.Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if !pathExists(targetDir) {
+		t.Fatal("protected Rust target should be preserved")
+	}
+	if result.ProtectedSkippedItems != 1 || result.ProtectedSkippedBytes < 2*1024*1024 {
+		t.Fatalf("expected one protected skip of at least 2 MiB, got items=%d bytes=%d", result.ProtectedSkippedItems, result.ProtectedSkippedBytes)
+	}
+
+	// The tally is per run.
+	cfg.DevArtifacts.ProtectPaths = nil
+	result = p.Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.ProtectedSkippedItems != 0 || result.ProtectedSkippedBytes != 0 {
+		t.Fatalf("expected no protected skips without protect_paths, got %#v", result)
+	}
+}
+
 func tempGeneratedArtifactConfig(tmpDir string) *config.Config {
 	cfg := config.DefaultConfig()
 	cfg.DevArtifacts.ScanPaths = nil
@@ -1128,3 +1209,171 @@ func newDevArtifactsPluginWithActive(active map[string]string) *DevArtifactsPlug
 		},
 	}
 }
+
+func writeNodeProject(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "node_modules", "pkg"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("write package.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "node_modules", "pkg", "index.js"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write index.js: %v", err)
+	}
+}
+
+func TestFindArtifactDirsIncrementalReusesUnchangedProjects(t *testing.T) {
+	p := NewDevArtifactsPlugin()
+	scanPath := t.TempDir()
+	projectA := filepath.Join(scanPath, "a")
+	writeNodeProject(t, projectA)
+	if err := os.MkdirAll(filepath.Join(projectA, "packages", "ui"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	manifest := newDevArtifactManifest("hash")
+	scan := func() ([]string, *devArtifactScanBudget) {
+		budget := newDevArtifactScanBudget(config.DevArtifactsConfig{})
+		budget.manifest = manifest
+		var found []string
+		p.findArtifactDirs(context.Background(), scanPath, "node_modules", "package.json", func(dir string, size int64) {
+			found = append(found, dir)
+		}, budget)
+		sort.Strings(found)
+		return found, budget
+	}
+
+	found, budget := scan()
+	if len(found) != 1 || budget.walkedProjects != 1 || budget.reusedProjects != 0 {
+		t.Fatalf("first scan found %v walked=%d reused=%d", found, budget.walkedProjects, budget.reusedProjects)
+	}
+
+	// A deep change inside an unchanged project is not seen, while a new
+	// project changes the scan path and is walked.
+	writeNodeProject(t, filepath.Join(projectA, "packages", "ui"))
+	writeNodeProject(t, filepath.Join(scanPath, "b"))
+
+	found, budget = scan()
+	want := []string{filepath.Join(projectA, "node_modules"), filepath.Join(scanPath, "b", "node_modules")}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Fatalf("second scan found %v, want %v", found, want)
+	}
+	if budget.walkedProjects != 1 || budget.reusedProjects != 1 {
+		t.Fatalf("second scan walked=%d reused=%d, want 1 and 1", budget.walkedProjects, budget.reusedProjects)
+	}
+
+	// Removing a cached artifact drops it without a rescan.
+	if err := os.RemoveAll(filepath.Join(scanPath, "b", "node_modules")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	found, _ = scan()
+	if len(found) != 1 || found[0] != filepath.Join(projectA, "node_modules") {
+		t.Fatalf("third scan found %v", found)
+	}
+}
+
+func TestDevArtifactManifestInvalidatedOnConfigChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), devArtifactManifestFileName)
+	project := t.TempDir()
+	cfg := config.DefaultConfig().DevArtifacts
+	hash := devArtifactConfigHash(cfg)
+
+	manifest := newDevArtifactManifest(hash)
+	manifest.store(project, "node_modules|package.json", time.Unix(100, 0), []devArtifactManifestEntry{{Path: filepath.Join(project, "node_modules"), Bytes: 42}})
+	if err := manifest.save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded := loadDevArtifactManifest(path, hash)
+	if entries, ok := loaded.lookup(project, "node_modules|package.json", time.Unix(100, 0)); !ok || len(entries) != 1 || entries[0].Bytes != 42 {
+		t.Fatalf("expected cached entry, got %v %v", entries, ok)
+	}
+	if _, ok := loaded.lookup(project, "node_modules|package.json", time.Unix(200, 0)); ok {
+		t.Fatal("expected changed project mtime to miss")
+	}
+
+	cfg.ProtectPaths = []string{"/keep"}
+	if changed := loadDevArtifactManifest(path, devArtifactConfigHash(cfg)); len(changed.Projects) != 0 {
+		t.Fatalf("expected config change to discard manifest, got %+v", changed.Projects)
+	}
+}
+
+func writePythonCache(t *testing.T, dir string, old bool) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	file := filepath.Join(dir, "cached.pyc")
+	if err := os.WriteFile(file, []byte("bytecode"), 0644); err != nil {
+		t.Fatalf("write cache file: %v", err)
+	}
+	if old {
+		oldTime := time.Now().Add(-24 * time.Hour)
+		if err := os.Chtimes(file, oldTime, oldTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+}
+
+func TestCleanPythonBuildCaches(t *testing.T) {
+	p := NewDevArtifactsPlugin()
+	scanPath := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	project := filepath.Join(scanPath, "app")
+	removed := []string{
+		filepath.Join(project, "__pycache__"),
+		filepath.Join(project, "pkg", "__pycache__"),
+		filepath.Join(project, ".pytest_cache"),
+		filepath.Join(project, ".mypy_cache"),
+		filepath.Join(project, ".ruff_cache"),
+		filepath.Join(project, ".tox"),
+	}
+	for _, dir := range removed {
+		writePythonCache(t, dir, true)
+	}
+	recent := filepath.Join(scanPath, "busy", ".pytest_cache")
+	writePythonCache(t, recent, false)
+	protected := filepath.Join(scanPath, "keep", "__pycache__")
+	writePythonCache(t, protected, true)
+
+	freed, dirs := p.cleanPythonBuildCaches(context.Background(), scanPath, []string{filepath.Join(scanPath, "keep")}, newDevArtifactGitTracker(), logger)
+	if dirs != len(removed) || freed == 0 {
+		t.Fatalf("cleaned %d dirs freeing %d bytes, want %d dirs", dirs, freed, len(removed))
+	}
+	for _, dir := range removed {
+		if pathExists(dir) {
+			t.Errorf("%s should have been removed", dir)
+		}
+	}
+	if !pathExists(recent) {
+		t.Error("recently written cache should be preserved")
+	}
+	if !pathExists(protected) {
+		t.Error("protected cache should be preserved")
+	}
+}
+
+func TestPlanPythonBuildCachesIgnoresProjectAge(t *testing.T) {
+	p := NewDevArtifactsPlugin()
+	scanPath := t.TempDir()
+	cache := filepath.Join(scanPath, "fresh", ".mypy_cache")
+	writePythonCache(t, cache, true)
+	if err := os.WriteFile(filepath.Join(scanPath, "fresh", "pyproject.toml"), []byte("[project]\n"), 0644); err != nil {
+		t.Fatalf("write pyproject.toml: %v", err)
+	}
+
+	var targets []CleanupTarget
+	p.planPythonBuildCaches(context.Background(), scanPath, true, nil, nil, newDevArtifactGitTracker(), &targets)
+	target := findDevArtifactTarget(t, targets, "python-build-cache", cache)
+	if target.Action != "delete" || target.Protected || target.Tier != CleanupTierSafe {
+		t.Fatalf("expected fresh project's tool cache to be a safe delete, got %#v", target)
+	}
+
+	targets = nil
+	p.planPythonBuildCaches(context.Background(), scanPath, false, nil, nil, newDevArtifactGitTracker(), &targets)
+	if target := findDevArtifactTarget(t, targets, "python-build-cache", cache); target.Action != "report" {
+		t.Fatalf("expected warning level to report only, got %#v", target)
+	}
+}
@@ -5,8 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
@@ -15,6 +14,7 @@ import (
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 // DockerPlugin handles Docker cleanup operations.
@@ -46,6 +46,33 @@ func (p *DockerPlugin) Description() string {
 	return "Cleans Docker images, containers, volumes, networks, and build cache"
 }
 
+// Priority runs Docker prune with the other container prunes, after the
+// cache clears; it is reversible and usually high-yield.
+func (p *DockerPlugin) Priority() int {
+	return 50
+}
+
+// DataPaths returns where Docker keeps images and volumes: the Colima and
+// Docker Desktop VM directories on macOS, and the rootful and rootless data
+// roots elsewhere. Paths under the home directory are left out when it is
+// unavailable.
+func (p *DockerPlugin) DataPaths(cfg *config.Config) []string {
+	home, err := env.HomeDir()
+	if runtime.GOOS == "darwin" {
+		if err != nil {
+			return nil
+		}
+		return []string{
+			filepath.Join(home, ".colima"),
+			filepath.Join(home, "Library/Containers/com.docker.docker"),
+		}
+	}
+	if err != nil {
+		return []string{"/var/lib/docker"}
+	}
+	return []string{"/var/lib/docker", filepath.Join(home, ".local/share/docker")}
+}
+
 // SupportedPlatforms returns supported platforms (all).
 func (p *DockerPlugin) SupportedPlatforms() []string {
 	return nil // All platforms
@@ -56,11 +83,25 @@ func (p *DockerPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Docker
 }
 
+// LevelDescription summarizes Docker cleanup at each level.
+func (p *DockerPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "prunes dangling images"
+	case LevelModerate:
+		return "prunes dangling images, images older than prune_ages.moderate (default prune_images_age; sparing the keep_recently_used most recently used images, when set), stopped containers older than 1h, and buildx cache older than 24h"
+	case LevelAggressive:
+		return "runs moderate cleanup with the prune_ages.aggressive image age (default prune_images_age), then prunes unused volumes including named volumes, unused networks, and all builder cache; with deep_build_cache_gc, also drops every BuildKit cache record in buildx builders"
+	case LevelCritical:
+		return "runs a full system prune of all unused images, containers, networks, build cache, and volumes, keeping images and volumes used by running containers when protect_running_containers is true; with deep_build_cache_gc, also drops every BuildKit cache record in buildx builders"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup returns a non-mutating Docker cleanup plan.
 func (p *DockerPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
-	if cfg.Docker.Socket != "" {
-		p.socketPath = cfg.Docker.Socket
-	}
+	p.useSocket(cfg, logger)
 
 	plan := CleanupPlan{
 		Plugin:   p.Name(),
@@ -71,8 +112,11 @@ func (p *DockerPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg
 		Metadata: map[string]string{
 			"cleanup_level":              level.String(),
 			"prune_images_age":           cfg.Docker.PruneImagesAge,
+			"prune_age":                  cfg.Docker.PruneAge(level.String()),
+			"keep_recently_used":         strconv.Itoa(cfg.Docker.KeepRecentlyUsed),
 			"protect_running_containers": strconv.FormatBool(cfg.Docker.ProtectRunningContainers),
 			"socket_configured":          strconv.FormatBool(p.socketPath != ""),
+			"deep_build_cache_gc":        strconv.FormatBool(cfg.Docker.DeepBuildCacheGC),
 		},
 	}
 
@@ -125,10 +169,7 @@ func (p *DockerPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 		Level:  level,
 	}
 
-	// Store socket path from config for use in commands
-	if cfg.Docker.Socket != "" {
-		p.socketPath = cfg.Docker.Socket
-	}
+	p.useSocket(cfg, logger)
 
 	// Check if docker is available
 	if !p.isDockerAvailable() {
@@ -151,7 +192,7 @@ func (p *DockerPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 	switch level {
 	case LevelWarning:
 		// Light cleanup: just dangling images
-		result = p.cleanDangling(ctx, logger)
+		result = p.cleanDangling(ctx, cfg, logger)
 	case LevelModerate:
 		// Moderate: dangling + old images + old containers
 		result = p.cleanModerate(ctx, cfg, logger)
@@ -160,12 +201,41 @@ func (p *DockerPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 		result = p.cleanAggressive(ctx, cfg, logger)
 	case LevelCritical:
 		// Emergency: full system prune with volumes
-		result = p.cleanCritical(ctx, logger)
+		result = p.cleanCritical(ctx, cfg, logger)
+	}
+
+	if level >= LevelAggressive && cfg.Docker.DeepBuildCacheGC && result.Error == nil {
+		p.deepBuildCacheGC(ctx, &result, logger)
 	}
 
 	return result
 }
 
+// deepBuildCacheGC collects orphaned blobs in every buildx builder container
+// after the normal prune. It forces a cold build cache, so it never runs while
+// Docker work is active, whether or not protect_running_containers is set.
+func (p *DockerPlugin) deepBuildCacheGC(ctx context.Context, result *CleanupResult, logger *slog.Logger) {
+	activeReasons, err := p.activeDockerProcesses(ctx)
+	if err != nil {
+		logger.Warn("skipping deep BuildKit cache GC because active process inspection failed", "error", err)
+		return
+	}
+	if len(activeReasons) > 0 {
+		logger.Info("skipping deep BuildKit cache GC because active Docker work was detected", "active", strings.Join(activeReasons, ", "))
+		return
+	}
+
+	containers, err := listBuildKitContainers(ctx, p.runDockerCommand)
+	if err != nil {
+		logger.Warn("could not list buildx builder containers", "error", err)
+		return
+	}
+	gc := deepBuildKitGC(ctx, p.runDockerCommand, containers, logger)
+	result.BytesFreed += gc.Bytes
+	result.DeepGCBytesFreed += gc.Bytes
+	result.ItemsCleaned += gc.Containers
+}
+
 func (p *DockerPlugin) isDockerAvailable() bool {
 	return p.isDockerAvailableContext(context.Background())
 }
@@ -174,110 +244,152 @@ func (p *DockerPlugin) isDockerAvailableContext(ctx context.Context) bool {
 	availableCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(availableCtx, "docker", "info")
-	if p.socketPath != "" {
-		cmd.Env = append(os.Environ(), "DOCKER_HOST=unix://"+p.socketPath)
-	}
-	return cmd.Run() == nil
+	return runner.Run(availableCtx, p.dockerEnv(), "docker", "info") == nil
 }
 
-func (p *DockerPlugin) cleanDangling(ctx context.Context, logger *slog.Logger) CleanupResult {
+func (p *DockerPlugin) cleanDangling(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: LevelWarning}
 
 	logger.Debug("cleaning dangling images")
-	output, err := p.runDockerCommand(ctx, "image", "prune", "-f")
-	if err != nil {
-		result.Error = err
-		return result
+	for _, args := range dockerLevelCommands(LevelWarning, cfg.Docker) {
+		output, err := p.runDockerCommand(ctx, args...)
+		if err != nil {
+			result.Error = newCommandError(p.Name(), commandOperation(args), err, output)
+			return result
+		}
+		result.BytesFreed += p.parseReclaimedSpace(output)
 	}
 
-	result.BytesFreed = p.parseReclaimedSpace(output)
 	return result
 }
 
 func (p *DockerPlugin) cleanModerate(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: LevelModerate}
+	p.pruneRecentlyUsedRanked(ctx, LevelModerate, cfg.Docker, &result, logger)
+	p.runPruneCommands(ctx, dockerLevelCommands(LevelModerate, cfg.Docker), &result, logger)
+	return result
+}
 
-	// Clean dangling images
-	logger.Debug("cleaning dangling images")
-	if output, err := p.runDockerCommand(ctx, "image", "prune", "-f"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("dangling image prune failed", "error", err, "output", output)
-	}
+func (p *DockerPlugin) cleanAggressive(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: LevelAggressive}
+	p.pruneRecentlyUsedRanked(ctx, LevelAggressive, cfg.Docker, &result, logger)
+	p.runPruneCommands(ctx, dockerLevelCommands(LevelAggressive, cfg.Docker), &result, logger)
+	return result
+}
 
-	// Clean old images
-	logger.Debug("cleaning old images", "age", cfg.Docker.PruneImagesAge)
-	args := []string{"image", "prune", "-af", "--filter", fmt.Sprintf("until=%s", cfg.Docker.PruneImagesAge)}
-	if output, err := p.runDockerCommand(ctx, args...); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("old image prune failed", "error", err, "output", output)
-	}
+func (p *DockerPlugin) cleanCritical(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: LevelCritical}
 
-	// Clean old stopped containers
-	logger.Debug("cleaning old containers")
-	if output, err := p.runDockerCommand(ctx, "container", "prune", "-f", "--filter", "until=1h"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("container prune failed", "error", err, "output", output)
+	if cfg.Docker.ProtectRunningContainers {
+		use, err := p.dockerRunningContainerUse(ctx)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		if use.Containers > 0 {
+			p.cleanCriticalProtected(ctx, use, &result, logger)
+			return result
+		}
 	}
 
-	// Clean old buildx cache
-	logger.Debug("cleaning buildx cache")
-	if output, err := p.runDockerCommand(ctx, "buildx", "prune", "-f", "--filter", "until=24h"); err == nil {
+	// Full system prune with volumes
+	logger.Warn("CRITICAL: running full Docker system prune with volumes")
+	for _, args := range dockerLevelCommands(LevelCritical, cfg.Docker) {
+		output, err := p.runDockerCommand(ctx, args...)
+		if err != nil {
+			result.Error = newCommandError(p.Name(), commandOperation(args), err, output)
+			return result
+		}
 		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("buildx cache prune failed", "error", err, "output", output)
 	}
 
 	return result
 }
 
-func (p *DockerPlugin) cleanAggressive(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
-	result := p.cleanModerate(ctx, cfg, logger)
-	result.Level = LevelAggressive
-
-	// Clean unused volumes (including named volumes)
-	logger.Debug("cleaning unused volumes")
-	if output, err := p.runDockerCommand(ctx, "volume", "prune", "-af"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("volume prune failed", "error", err, "output", output)
+// pruneRecentlyUsedRanked runs the usage-ranked image prune in place of the
+// age-filtered one when keep_recently_used is set.
+func (p *DockerPlugin) pruneRecentlyUsedRanked(ctx context.Context, level CleanupLevel, cfg config.DockerConfig, result *CleanupResult, logger *slog.Logger) {
+	if !dockerRecentImagesUsesRanking(cfg) {
+		return
 	}
+	freed, removed := p.pruneLeastRecentlyUsedImages(ctx, level, cfg, logger)
+	result.BytesFreed += freed
+	result.ItemsCleaned += removed
+}
 
-	// Clean unused networks
-	logger.Debug("cleaning unused networks")
-	if output, err := p.runDockerCommand(ctx, "network", "prune", "-f"); err == nil {
+// runPruneCommands runs each docker prune command in order, logging failures
+// and continuing so one unsupported subcommand does not block the rest.
+func (p *DockerPlugin) runPruneCommands(ctx context.Context, commands [][]string, result *CleanupResult, logger *slog.Logger) {
+	for _, args := range commands {
+		command := strings.Join(args, " ")
+		logger.Debug("running docker prune", "command", command)
+		output, err := p.runDockerCommand(ctx, args...)
+		if err != nil {
+			logger.Warn("docker prune failed", "command", command, "error", err, "output", output)
+			continue
+		}
 		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("network prune failed", "error", err, "output", output)
 	}
+}
 
-	// Clean all build cache
-	logger.Debug("cleaning all build cache")
-	if output, err := p.runDockerCommand(ctx, "builder", "prune", "-af"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-	} else {
-		logger.Warn("builder cache prune failed", "error", err, "output", output)
-	}
+// dockerLevelCommands returns the docker CLI arguments run at each cleanup
+// level, in execution order.
+// Aggressive repeats the moderate commands with its own prune_ages entry.
+// With keep_recently_used set, the age-filtered image prune is replaced by the
+// usage-ranked pass in docker_recent.go, which runs before these commands.
+func dockerLevelCommands(level CleanupLevel, cfg config.DockerConfig) [][]string {
+	moderate := [][]string{{"image", "prune", "-f"}}
+	if !dockerRecentImagesUsesRanking(cfg) {
+		moderate = append(moderate, []string{"image", "prune", "-af", "--filter", fmt.Sprintf("until=%s", cfg.PruneAge(level.String()))})
+	}
+	moderate = append(moderate,
+		[]string{"container", "prune", "-f", "--filter", "until=1h"},
+		[]string{"buildx", "prune", "-f", "--filter", "until=24h"},
+	)
 
-	return result
+	switch level {
+	case LevelWarning:
+		return [][]string{{"image", "prune", "-f"}}
+	case LevelModerate:
+		return moderate
+	case LevelAggressive:
+		return append(moderate,
+			[]string{"volume", "prune", "-af"},
+			[]string{"network", "prune", "-f"},
+			[]string{"builder", "prune", "-af"},
+		)
+	case LevelCritical:
+		return [][]string{{"system", "prune", "-af", "--volumes"}}
+	default:
+		return nil
+	}
 }
 
-func (p *DockerPlugin) cleanCritical(ctx context.Context, logger *slog.Logger) CleanupResult {
-	result := CleanupResult{Plugin: p.Name(), Level: LevelCritical}
-
-	// Full system prune with volumes
-	logger.Warn("CRITICAL: running full Docker system prune with volumes")
-	output, err := p.runDockerCommand(ctx, "system", "prune", "-af", "--volumes")
-	if err != nil {
-		result.Error = err
-		return result
+// CleanupCommands returns the docker commands Cleanup would run at level.
+// Commands run only when Docker is reachable and no active Docker work is
+// detected while protect_running_containers is enabled; with it enabled, the
+// critical system prune is replaced by docker_protect.go's pass whenever
+// containers are running. The deep BuildKit GC
+// runs once per buildx builder container, shown with a placeholder ID, and the
+// usage-ranked image prune removes each image with its own command.
+func (p *DockerPlugin) CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	prefix := []string{"docker"}
+	if cfg.Docker.Socket != "" {
+		prefix = []string{"env", "DOCKER_HOST=unix://" + cfg.Docker.Socket, "docker"}
 	}
 
-	result.BytesFreed = p.parseReclaimedSpace(output)
-	return result
+	levelCommands := dockerLevelCommands(level, cfg.Docker)
+	if (level == LevelModerate || level == LevelAggressive) && dockerRecentImagesUsesRanking(cfg.Docker) {
+		levelCommands = append(dockerRecentImageCommands(), levelCommands...)
+	}
+	commands := make([][]string, 0, len(levelCommands))
+	for _, args := range levelCommands {
+		commands = append(commands, append(append([]string{}, prefix...), args...))
+	}
+	if level >= LevelAggressive && cfg.Docker.DeepBuildCacheGC {
+		commands = append(commands, append(append([]string{}, prefix...), buildKitDeepGCArgs("<buildkit-container>")...))
+	}
+	return commands
 }
 
 func (p *DockerPlugin) runDockerCommand(ctx context.Context, args ...string) (string, error) {
@@ -288,20 +400,34 @@ func (p *DockerPlugin) runDockerCommandWithTimeout(ctx context.Context, timeout
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	if p.socketPath != "" {
-		cmd.Env = append(os.Environ(), "DOCKER_HOST=unix://"+p.socketPath)
-	}
-	output, err := cmd.CombinedOutput()
+	output, err := runner.CombinedOutput(ctx, p.dockerEnv(), "docker", args...)
 	return string(output), err
 }
 
+// useSocket stores the configured socket for use in commands. An empty
+// socket leaves the docker CLI on the ambient DOCKER_HOST.
+func (p *DockerPlugin) useSocket(cfg *config.Config, logger *slog.Logger) {
+	p.socketPath = cfg.Docker.Socket
+	if p.socketPath == "" {
+		logger.Debug("docker commands use the ambient DOCKER_HOST")
+		return
+	}
+	logger.Debug("docker commands target configured socket", "socket", p.socketPath)
+}
+
+// dockerEnv points the docker CLI at the configured socket, if any.
+func (p *DockerPlugin) dockerEnv() []string {
+	if p.socketPath == "" {
+		return nil
+	}
+	return []string{"DOCKER_HOST=unix://" + p.socketPath}
+}
+
 func (p *DockerPlugin) activeDockerProcesses(ctx context.Context) ([]string, error) {
 	psCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(psCtx, "ps", "-axo", "comm=,args=")
-	output, err := cmd.Output()
+	output, err := runner.Output(psCtx, nil, "ps", "-axo", "comm=,args=")
 	if err != nil {
 		return nil, err
 	}
@@ -344,6 +470,12 @@ func dockerBusyProcessReasons(output string) []string {
 }
 
 func (p *DockerPlugin) parseReclaimedSpace(output string) int64 {
+	return parseReclaimedSpace(output)
+}
+
+// parseReclaimedSpace extracts bytes freed from docker-compatible prune
+// output, which nerdctl shares.
+func parseReclaimedSpace(output string) int64 {
 	// Parse "Total reclaimed space: X.XXY" or similar patterns
 	// Examples:
 	//   "Total reclaimed space: 1.234GB"
@@ -384,24 +516,39 @@ func (p *DockerPlugin) parseReclaimedSpace(output string) int64 {
 }
 
 func dockerPlanSteps(level CleanupLevel, cfg config.DockerConfig) []string {
+	steps := dockerLevelPlanSteps(level, cfg)
+	if level >= LevelAggressive && cfg.DeepBuildCacheGC {
+		steps = append(steps, "Drop every BuildKit cache record in buildx builder containers when no build is active")
+	}
+	return steps
+}
+
+func dockerLevelPlanSteps(level CleanupLevel, cfg config.DockerConfig) []string {
 	switch level {
 	case LevelWarning:
 		return []string{"Prune dangling Docker images"}
 	case LevelModerate:
+		imageStep := fmt.Sprintf("Prune Docker images older than %s", cfg.PruneAge(level.String()))
+		if dockerRecentImagesUsesRanking(cfg) {
+			imageStep = fmt.Sprintf("Remove Docker images last used more than %s ago, keeping the %d most recently used", cfg.PruneAge(level.String()), cfg.KeepRecentlyUsed)
+		}
 		return []string{
 			"Prune dangling Docker images",
-			fmt.Sprintf("Prune Docker images older than %s", cfg.PruneImagesAge),
+			imageStep,
 			"Prune stopped Docker containers older than 1h",
 			"Prune Docker buildx cache older than 24h",
 		}
 	case LevelAggressive:
 		return []string{
-			"Run moderate Docker cleanup",
+			fmt.Sprintf("Run moderate Docker cleanup with images older than %s", cfg.PruneAge(level.String())),
 			"Prune unused Docker volumes",
 			"Prune unused Docker networks",
 			"Prune all Docker builder cache",
 		}
 	case LevelCritical:
+		if cfg.ProtectRunningContainers {
+			return []string{"Run full Docker system prune with volumes, or, while containers run, remove every image and volume they do not use"}
+		}
 		return []string{"Run full Docker system prune with volumes"}
 	default:
 		return []string{"Report Docker cleanup state"}
@@ -602,85 +749,109 @@ func dockerTotalReclaimableBytes(rows []dockerDFSummaryRow) int64 {
 	return total
 }
 
-// ProactiveCleanup checks Docker reclaimable space and cleans if needed.
-// This is useful for Docker Desktop VMs that have separate disk from host.
-func (p *DockerPlugin) ProactiveCleanup(ctx context.Context, logger *slog.Logger) CleanupResult {
-	result := CleanupResult{Plugin: p.Name() + "-proactive"}
+// ProactiveCleanup prunes Docker when `docker system df` reports more
+// reclaimable space than docker.proactive_reclaim_gb. On Docker Desktop and
+// Colima the engine disk lives in a VM that can fill while the host still has
+// room, so this runs every cycle regardless of host disk usage. It runs the
+// moderate-level prunes and never removes volumes. Each docker.hosts daemon
+// gets the same check against its own disk usage.
+func (p *DockerPlugin) ProactiveCleanup(ctx context.Context, cfg *config.Config, dryRun bool, logger *slog.Logger) ProactiveResult {
+	result := ProactiveResult{Plugin: p.Name()}
+	if cfg.Docker.Proactive {
+		result = p.proactiveLocal(ctx, cfg, dryRun, logger)
+	} else if len(cfg.Docker.Hosts) > 0 {
+		result.Checked = true
+		result.SkipReason = "local_proactive_disabled"
+	}
+	if len(cfg.Docker.Hosts) > 0 {
+		result.Hosts = p.cleanRemoteHosts(ctx, cfg, dryRun, logger)
+	}
+	return result
+}
+
+// proactiveLocal is the proactive check of the local Docker daemon.
+func (p *DockerPlugin) proactiveLocal(ctx context.Context, cfg *config.Config, dryRun bool, logger *slog.Logger) ProactiveResult {
+	result := ProactiveResult{Plugin: p.Name(), Checked: true}
+	result.ThresholdBytes = dockerProactiveThresholdBytes(cfg.Docker)
+	p.useSocket(cfg, logger)
+
+	if !p.isDockerAvailableContext(ctx) {
+		result.SkipReason = "docker_unavailable"
+		return result
+	}
 
-	// Get Docker system df info
-	output, err := p.runDockerCommand(ctx, "system", "df", "--format", "{{.Reclaimable}}")
+	output, err := p.runDockerCommandWithTimeout(ctx, 30*time.Second, "system", "df")
 	if err != nil {
 		logger.Warn("docker system df failed", "error", err, "output", output)
+		result.SkipReason = "system_df_failed"
+		result.Error = newCommandError(p.Name(), "system_df", err, output)
 		return result
 	}
-
-	// Parse reclaimable space
-	reclaimableGB := p.parseReclaimableGB(output)
-	if reclaimableGB < 10 {
-		return result // Less than 10GB reclaimable, skip
+	result.ReclaimableBytes = dockerProactiveReclaimableBytes(parseDockerDFSummaryRows(output))
+	if result.ReclaimableBytes < result.ThresholdBytes {
+		result.SkipReason = "below_threshold"
+		return result
 	}
+	result.Triggered = true
 
-	logger.Info("proactive Docker cleanup", "reclaimable_gb", reclaimableGB)
-
-	// Clean dangling images
-	if output, err := p.runDockerCommand(ctx, "image", "prune", "-f"); err == nil {
-		result.ItemsCleaned++
-	} else {
-		logger.Warn("proactive image prune failed", "error", err, "output", output)
+	if cfg.Docker.ProtectRunningContainers {
+		activeReasons, err := p.activeDockerProcesses(ctx)
+		if err != nil {
+			result.SkipReason = "docker_process_inspection_failed"
+			return result
+		}
+		if len(activeReasons) > 0 {
+			logger.Info("deferring proactive Docker cleanup because active Docker work was detected", "processes", strings.Join(activeReasons, ", "))
+			result.SkipReason = "docker_active_work"
+			return result
+		}
 	}
-
-	// Clean old containers
-	if output, err := p.runDockerCommand(ctx, "container", "prune", "-f", "--filter", "until=1h"); err == nil {
-		result.ItemsCleaned++
-	} else {
-		logger.Warn("proactive container prune failed", "error", err, "output", output)
+	if dryRun {
+		result.SkipReason = "dry_run"
+		return result
 	}
 
-	// Clean old build cache
-	if output, err := p.runDockerCommand(ctx, "builder", "prune", "-f", "--filter", "until=24h"); err == nil {
-		result.ItemsCleaned++
-	} else {
-		logger.Warn("proactive builder prune failed", "error", err, "output", output)
+	logger.Info("proactive Docker cleanup",
+		"reclaimable_gb", fmt.Sprintf("%.1f", float64(result.ReclaimableBytes)/(1024*1024*1024)),
+		"threshold_gb", cfg.Docker.ProactiveReclaimGB)
+	if dockerRecentImagesUsesRanking(cfg.Docker) {
+		freed, removed := p.pruneLeastRecentlyUsedImages(ctx, LevelModerate, cfg.Docker, logger)
+		result.BytesFreed += freed
+		result.ItemsCleaned += removed
 	}
-
-	// Clean unused volumes (including named volumes)
-	if output, err := p.runDockerCommand(ctx, "volume", "prune", "-af"); err == nil {
+	for _, args := range dockerLevelCommands(LevelModerate, cfg.Docker) {
+		output, err := p.runDockerCommand(ctx, args...)
+		if err != nil {
+			logger.Warn("proactive docker prune failed", "command", strings.Join(args, " "), "error", err, "output", output)
+			if result.Error == nil {
+				result.Error = newCommandError(p.Name(), commandOperation(args), err, output)
+			}
+			continue
+		}
+		result.BytesFreed += p.parseReclaimedSpace(output)
 		result.ItemsCleaned++
-	} else {
-		logger.Warn("proactive volume prune failed", "error", err, "output", output)
 	}
 
 	return result
 }
 
-func (p *DockerPlugin) parseReclaimableGB(output string) int {
-	// Parse first line which should be something like "10.5GB (50%)"
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	if len(lines) == 0 {
-		return 0
-	}
-
-	line := lines[0]
-	// Extract number and unit
-	re := regexp.MustCompile(`([\d.]+)\s*([KMGT]?B)`)
-	matches := re.FindStringSubmatch(line)
-	if len(matches) < 3 {
-		return 0
-	}
-
-	value, err := strconv.ParseFloat(matches[1], 64)
-	if err != nil {
-		return 0
+// dockerProactiveReclaimableBytes sums reclaimable space except volumes,
+// which proactive cleanup never prunes.
+func dockerProactiveReclaimableBytes(rows []dockerDFSummaryRow) int64 {
+	var total int64
+	for _, row := range rows {
+		if row.Type == "Local Volumes" {
+			continue
+		}
+		total += row.ReclaimableBytes
 	}
+	return total
+}
 
-	switch strings.ToUpper(matches[2]) {
-	case "GB":
-		return int(value)
-	case "TB":
-		return int(value * 1000)
-	case "MB":
-		return 0 // Less than 1GB
-	default:
-		return 0
+func dockerProactiveThresholdBytes(cfg config.DockerConfig) int64 {
+	gb := cfg.ProactiveReclaimGB
+	if gb <= 0 {
+		gb = 10
 	}
+	return int64(gb) * 1024 * 1024 * 1024
 }
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// dockerHostSchemes are the DOCKER_HOST schemes docker.hosts accepts.
+var dockerHostSchemes = map[string]bool{"ssh": true, "tcp": true, "unix": true}
+
+// ValidateDockerHosts rejects docker.hosts entries that are not DOCKER_HOST
+// URLs with an ssh, tcp, or unix scheme, and repeated entries.
+func ValidateDockerHosts(hosts []string) error {
+	seen := make(map[string]bool, len(hosts))
+	for i, host := range hosts {
+		parsed, err := url.Parse(host)
+		if err != nil || !dockerHostSchemes[parsed.Scheme] || (parsed.Host == "" && parsed.Path == "") {
+			return fmt.Errorf("docker.hosts[%d] %q must be a DOCKER_HOST such as ssh://user@host or tcp://host:2376", i, host)
+		}
+		if seen[host] {
+			return fmt.Errorf("docker.hosts[%d] %q is repeated", i, host)
+		}
+		seen[host] = true
+	}
+	return nil
+}
+
+// runDockerHostCommand runs a docker command against host with DOCKER_HOST
+// set for that invocation only.
+func (p *DockerPlugin) runDockerHostCommand(ctx context.Context, host string, timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := runner.CombinedOutput(ctx, []string{"DOCKER_HOST=" + host}, "docker", args...)
+	return string(output), err
+}
+
+// cleanRemoteHosts checks each docker.hosts daemon every cycle. The decision
+// comes from that host's own `docker system df`, never from local disk
+// levels: when its reclaimable space, volumes excluded, reaches
+// docker.proactive_reclaim_gb, the moderate-level prunes run there. Remote
+// bytes free space on the remote host only, so they are reported per host
+// and kept out of the local totals.
+func (p *DockerPlugin) cleanRemoteHosts(ctx context.Context, cfg *config.Config, dryRun bool, logger *slog.Logger) []RemoteHostResult {
+	threshold := dockerProactiveThresholdBytes(cfg.Docker)
+	// The usage-ranked image prune reads local container history, so remote
+	// hosts use the plain age filter.
+	remoteCfg := cfg.Docker
+	remoteCfg.KeepRecentlyUsed = 0
+
+	results := make([]RemoteHostResult, 0, len(cfg.Docker.Hosts))
+	for _, host := range cfg.Docker.Hosts {
+		if ctx.Err() != nil {
+			break
+		}
+		result := RemoteHostResult{Host: host, ThresholdBytes: threshold}
+		hostLogger := logger.With("docker_host", host)
+		if _, err := p.runDockerHostCommand(ctx, host, 30*time.Second, "info"); err != nil {
+			hostLogger.Warn("remote Docker host unreachable", "error", err)
+			result.SkipReason = "docker_unavailable"
+			results = append(results, result)
+			continue
+		}
+		output, err := p.runDockerHostCommand(ctx, host, time.Minute, "system", "df")
+		if err != nil {
+			hostLogger.Warn("docker system df failed on remote host", "error", err, "output", output)
+			result.SkipReason = "system_df_failed"
+			result.Error = newCommandError(p.Name(), "system_df", err, output)
+			results = append(results, result)
+			continue
+		}
+		result.ReclaimableBytes = dockerProactiveReclaimableBytes(parseDockerDFSummaryRows(output))
+		switch {
+		case result.ReclaimableBytes < threshold:
+			result.SkipReason = "below_threshold"
+		case dryRun:
+			result.Triggered = true
+			result.SkipReason = "dry_run"
+		default:
+			result.Triggered = true
+			hostLogger.Info("remote Docker cleanup",
+				"reclaimable_gb", fmt.Sprintf("%.1f", float64(result.ReclaimableBytes)/(1024*1024*1024)),
+				"threshold_gb", threshold/(1024*1024*1024))
+			for _, args := range dockerLevelCommands(LevelModerate, remoteCfg) {
+				output, err := p.runDockerHostCommand(ctx, host, 5*time.Minute, args...)
+				if err != nil {
+					hostLogger.Warn("remote docker prune failed", "command", strings.Join(args, " "), "error", err, "output", output)
+					if result.Error == nil {
+						result.Error = newCommandError(p.Name(), commandOperation(args), err, output)
+					}
+					continue
+				}
+				result.BytesFreed += p.parseReclaimedSpace(output)
+				result.ItemsCleaned++
+			}
+			hostLogger.Info("remote Docker cleanup complete", "freed_mb", result.BytesFreed/(1024*1024), "items", result.ItemsCleaned)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestValidateDockerHosts(t *testing.T) {
+	if err := ValidateDockerHosts([]string{"ssh://builder@build01", "tcp://10.0.0.5:2376", "unix:///run/docker.sock"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, hosts := range [][]string{{"build01"}, {"http://build01"}, {"ssh://"}, {"ssh://a", "ssh://a"}} {
+		if err := ValidateDockerHosts(hosts); err == nil {
+			t.Errorf("expected %v to be rejected", hosts)
+		}
+	}
+}
+
+func TestDockerProactiveCleansRemoteHostsByTheirOwnUsage(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker system df": {Output: `TYPE            TOTAL     ACTIVE    SIZE      RECLAIMABLE
+Images          12        3         30GB      12GB (40%)
+Local Volumes   4         1         10GB      6GB (60%)
+`},
+		"docker image prune -f": {Output: "Total reclaimed space: 1GB"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.Hosts = []string{"ssh://builder@build01"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().ProactiveCleanup(context.Background(), cfg, false, logger)
+	if !result.Checked || result.SkipReason != "local_proactive_disabled" || result.BytesFreed != 0 {
+		t.Fatalf("expected only the remote host to be cleaned, got %+v", result)
+	}
+	if len(result.Hosts) != 1 {
+		t.Fatalf("expected one host result, got %+v", result.Hosts)
+	}
+	host := result.Hosts[0]
+	if !host.Triggered || host.ReclaimableBytes != 12<<30 || host.BytesFreed != 1<<30 || host.Error != nil {
+		t.Fatalf("unexpected host result: %+v", host)
+	}
+	for _, call := range fake.calls {
+		if !slices.Equal(call.Env, []string{"DOCKER_HOST=ssh://builder@build01"}) {
+			t.Fatalf("expected every command to target the remote host, got %q with env %v", call, call.Env)
+		}
+	}
+	if len(fake.commandLines("docker volume")) != 0 || len(fake.commandLines("docker system prune")) != 0 {
+		t.Fatalf("remote cleanup should never prune volumes: %v", fake.calls)
+	}
+
+	cfg.Docker.ProactiveReclaimGB = 20
+	if result := NewDockerPlugin().ProactiveCleanup(context.Background(), cfg, false, logger); result.Hosts[0].SkipReason != "below_threshold" {
+		t.Fatalf("expected the host below threshold to be skipped, got %+v", result.Hosts[0])
+	}
+}
@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// With protect_running_containers set, critical cleanup replaces
+// `docker system prune -af --volumes` with a pass that keeps every image and
+// named volume a running container uses. Stopped containers are still pruned
+// first, so their images and volumes become candidates. With no running
+// containers there is nothing to protect and the system prune runs as usual.
+
+var (
+	dockerRunningIDArgs  = []string{"ps", "-q", "--no-trunc"}
+	dockerRunningUseArgs = []string{"container", "inspect", "--format", "{{.Image}}\t{{.Config.Image}}\t{{range .Mounts}}{{if .Name}}{{.Name}} {{end}}{{end}}"}
+	dockerVolumeListArgs = []string{"volume", "ls", "-q"}
+)
+
+// dockerRunningUse is what running containers reference.
+type dockerRunningUse struct {
+	Containers int
+	// Images holds full image IDs, as reported by `docker container inspect`.
+	Images map[string]bool
+	// Refs holds the image references the containers were started from, with
+	// an implied :latest made explicit.
+	Refs map[string]bool
+	// Volumes holds named and anonymous volume names; bind mounts are omitted.
+	Volumes map[string]bool
+}
+
+// dockerRunningContainerUse lists running containers and the images and
+// volumes they use.
+func (p *DockerPlugin) dockerRunningContainerUse(ctx context.Context) (dockerRunningUse, error) {
+	use := dockerRunningUse{Images: map[string]bool{}, Refs: map[string]bool{}, Volumes: map[string]bool{}}
+	output, err := p.runDockerCommand(ctx, dockerRunningIDArgs...)
+	if err != nil {
+		return use, newCommandError(p.Name(), "running_containers", err, output)
+	}
+	ids := strings.Fields(output)
+	use.Containers = len(ids)
+	if len(ids) == 0 {
+		return use, nil
+	}
+	output, err = p.runDockerCommand(ctx, append(append([]string{}, dockerRunningUseArgs...), ids...)...)
+	if err != nil {
+		return use, newCommandError(p.Name(), "running_containers", err, output)
+	}
+	parseDockerRunningUse(output, &use)
+	return use, nil
+}
+
+// parseDockerRunningUse parses the container inspect format in
+// dockerRunningUseArgs into use.
+func parseDockerRunningUse(output string, use *dockerRunningUse) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		use.Images[fields[0]] = true
+		if ref := dockerExplicitRef(fields[1]); ref != "" {
+			use.Refs[ref] = true
+		}
+		var volumes string
+		if len(fields) == 3 {
+			volumes = fields[2]
+		}
+		for _, volume := range strings.Fields(volumes) {
+			use.Volumes[volume] = true
+		}
+	}
+}
+
+// dockerExplicitRef adds the :latest tag Docker implies to a reference
+// without a tag or digest.
+func dockerExplicitRef(ref string) string {
+	if ref == "" || strings.Contains(ref, "@") {
+		return ref
+	}
+	if strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		return ref
+	}
+	return ref + ":latest"
+}
+
+// dockerImageInUse reports whether a running container uses image, by ID or
+// by any of its tags.
+func dockerImageInUse(image dockerImageUsage, use dockerRunningUse) bool {
+	if use.Images[image.ID] {
+		return true
+	}
+	for _, ref := range image.Refs {
+		if use.Refs[ref] {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanCriticalProtected prunes stopped containers, then removes images and
+// volumes outside use, then prunes dangling images, networks, and builder
+// cache. Images are removed by their tags, so an image tagged in several
+// repositories goes in one call; untagged images are removed by ID, since a
+// bare repository name would resolve to repo:latest. Removal failures are
+// logged and skipped.
+func (p *DockerPlugin) cleanCriticalProtected(ctx context.Context, use dockerRunningUse, result *CleanupResult, logger *slog.Logger) {
+	logger.Warn("CRITICAL: pruning Docker while keeping images and volumes used by running containers",
+		"running_containers", use.Containers, "protected_images", len(use.Images), "protected_volumes", len(use.Volumes))
+	before, beforeErr := p.dockerSystemSizeBytes(ctx)
+
+	var reported CleanupResult
+	p.runPruneCommands(ctx, [][]string{{"container", "prune", "-f"}}, &reported, logger)
+
+	output, err := p.runDockerCommand(ctx, dockerImageListArgs...)
+	if err != nil {
+		logger.Warn("skipping Docker image removal: could not list images", "error", err, "output", output)
+	} else {
+		for _, image := range parseDockerImageList(output) {
+			if dockerImageInUse(image, use) {
+				logger.Debug("keeping image used by a running container", "refs", strings.Join(image.Refs, ","))
+				continue
+			}
+			args := append([]string{"image", "rm"}, image.Refs...)
+			if output, err := p.runDockerCommand(ctx, args...); err != nil {
+				logger.Warn("docker image rm failed", "image", image.ID, "error", err, "output", output)
+				continue
+			}
+			reported.BytesFreed += image.SizeBytes
+			result.ItemsCleaned++
+		}
+	}
+	p.runPruneCommands(ctx, [][]string{{"image", "prune", "-f"}}, &reported, logger)
+
+	output, err = p.runDockerCommand(ctx, dockerVolumeListArgs...)
+	if err != nil {
+		logger.Warn("skipping Docker volume removal: could not list volumes", "error", err, "output", output)
+	} else {
+		for _, volume := range strings.Fields(output) {
+			if use.Volumes[volume] {
+				logger.Debug("keeping volume used by a running container", "volume", volume)
+				continue
+			}
+			if output, err := p.runDockerCommand(ctx, "volume", "rm", volume); err != nil {
+				logger.Warn("docker volume rm failed", "volume", volume, "error", err, "output", output)
+				continue
+			}
+			result.ItemsCleaned++
+		}
+	}
+
+	p.runPruneCommands(ctx, [][]string{{"network", "prune", "-f"}, {"builder", "prune", "-af"}}, &reported, logger)
+
+	// Removed volumes report no size, and listed image sizes count layers
+	// shared with kept images, so prefer the measured shrink of Docker's
+	// storage.
+	result.BytesFreed = reported.BytesFreed
+	after, afterErr := p.dockerSystemSizeBytes(ctx)
+	if beforeErr == nil && afterErr == nil {
+		result.BytesFreed = max(before-after, 0)
+	} else {
+		logger.Debug("could not measure Docker storage; using reported prune sizes", "before_error", beforeErr, "after_error", afterErr)
+	}
+}
+
+// dockerSystemSizeBytes is the total size in `docker system df`.
+func (p *DockerPlugin) dockerSystemSizeBytes(ctx context.Context) (int64, error) {
+	output, err := p.runDockerCommandWithTimeout(ctx, 30*time.Second, "system", "df")
+	if err != nil {
+		return 0, err
+	}
+	rows := parseDockerDFSummaryRows(output)
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("no rows in docker system df output")
+	}
+	return dockerTotalSizeBytes(rows), nil
+}
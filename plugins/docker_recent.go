@@ -0,0 +1,256 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// Docker does not record when an image was last used. Usage is approximated
+// from container history instead: an image's last use is the newest created,
+// started, or finished time of any container still referencing it, and an
+// image with no containers falls back to its own creation time. Containers
+// removed by an earlier prune take their history with them, so this pass runs
+// before the container prune.
+
+// dockerImageCreatedAtLayout is the CreatedAt format of `docker image ls`.
+const dockerImageCreatedAtLayout = "2006-01-02 15:04:05 -0700 MST"
+
+var (
+	dockerContainerIDArgs  = []string{"container", "ls", "-aq", "--no-trunc"}
+	dockerContainerUseArgs = []string{"container", "inspect", "--format", "{{.Image}}\t{{.Created}}\t{{.State.StartedAt}}\t{{.State.FinishedAt}}"}
+	dockerImageListArgs    = []string{"image", "ls", "--no-trunc", "--format", "{{.ID}}\t{{.Repository}}\t{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}"}
+)
+
+// dockerImageUsage is a tagged image with its approximate last use.
+type dockerImageUsage struct {
+	ID        string
+	Refs      []string
+	Created   time.Time
+	LastUsed  time.Time
+	SizeBytes int64
+	// Containers is how many containers, running or stopped, use the image.
+	// Docker refuses to remove those without -f, so they are never candidates.
+	Containers int
+}
+
+// dockerContainerUse is one container's reference to an image.
+type dockerContainerUse struct {
+	ImageID  string
+	LastUsed time.Time
+}
+
+// dockerRecentImagesUsesRanking reports whether the moderate image prune is
+// replaced by the usage-ranked pass.
+func dockerRecentImagesUsesRanking(cfg config.DockerConfig) bool {
+	return cfg.KeepRecentlyUsed > 0
+}
+
+// dockerRecentImageCommands returns the commands the usage-ranked pass runs,
+// with placeholders for the container and image arguments.
+func dockerRecentImageCommands() [][]string {
+	inspect := append(append([]string{}, dockerContainerUseArgs...), "<container-ids>")
+	return [][]string{
+		append([]string{}, dockerContainerIDArgs...),
+		inspect,
+		append([]string{}, dockerImageListArgs...),
+		{"image", "rm", "<least-recently-used-image>"},
+	}
+}
+
+// pruneLeastRecentlyUsedImages removes tagged images outside the
+// keep_recently_used most recently used ones whose last use is older than the
+// level's prune age. Removal failures are logged and skipped.
+func (p *DockerPlugin) pruneLeastRecentlyUsedImages(ctx context.Context, level CleanupLevel, cfg config.DockerConfig, logger *slog.Logger) (int64, int) {
+	pruneAge := cfg.PruneAge(level.String())
+	maxAge, err := time.ParseDuration(pruneAge)
+	if err != nil {
+		logger.Warn("skipping usage-ranked image prune: prune age is not a duration", "prune_age", pruneAge, "error", err)
+		return 0, 0
+	}
+
+	images, err := p.dockerImageUsage(ctx)
+	if err != nil {
+		logger.Warn("skipping usage-ranked image prune", "error", err)
+		return 0, 0
+	}
+	candidates := dockerLeastRecentlyUsed(images, cfg.KeepRecentlyUsed, time.Now().Add(-maxAge))
+	if len(candidates) == 0 {
+		logger.Debug("no least-recently-used images to remove", "images", len(images), "keep", cfg.KeepRecentlyUsed)
+		return 0, 0
+	}
+
+	before, beforeErr := p.dockerImagesSizeBytes(ctx)
+	var listed int64
+	removed := 0
+	for _, image := range candidates {
+		args := append([]string{"image", "rm"}, image.Refs...)
+		output, err := p.runDockerCommand(ctx, args...)
+		if err != nil {
+			logger.Warn("docker image rm failed", "image", image.ID, "error", err, "output", output)
+			continue
+		}
+		logger.Debug("removed least-recently-used image", "refs", strings.Join(image.Refs, ","), "last_used", image.LastUsed.Format(time.RFC3339))
+		listed += image.SizeBytes
+		removed++
+	}
+	if removed == 0 {
+		return 0, 0
+	}
+
+	// Listed sizes count layers shared with kept images, so prefer the
+	// measured shrink of Docker's image storage.
+	freed := listed
+	after, afterErr := p.dockerImagesSizeBytes(ctx)
+	if beforeErr == nil && afterErr == nil {
+		freed = max(before-after, 0)
+	} else {
+		logger.Debug("could not measure image storage; using listed image sizes", "before_error", beforeErr, "after_error", afterErr)
+	}
+	logger.Info("pruned least-recently-used Docker images", "removed", removed, "kept", cfg.KeepRecentlyUsed, "freed_mb", freed/(1024*1024))
+	return freed, removed
+}
+
+func (p *DockerPlugin) dockerImageUsage(ctx context.Context) ([]dockerImageUsage, error) {
+	output, err := p.runDockerCommand(ctx, dockerImageListArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("list images: %w", err)
+	}
+	images := parseDockerImageList(output)
+
+	output, err = p.runDockerCommand(ctx, dockerContainerIDArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+	ids := strings.Fields(output)
+	if len(ids) == 0 {
+		return applyDockerContainerUse(images, nil), nil
+	}
+	output, err = p.runDockerCommand(ctx, append(append([]string{}, dockerContainerUseArgs...), ids...)...)
+	if err != nil {
+		return nil, fmt.Errorf("inspect containers: %w", err)
+	}
+	return applyDockerContainerUse(images, parseDockerContainerUse(output)), nil
+}
+
+func (p *DockerPlugin) dockerImagesSizeBytes(ctx context.Context) (int64, error) {
+	output, err := p.runDockerCommandWithTimeout(ctx, 30*time.Second, "system", "df")
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range parseDockerDFSummaryRows(output) {
+		if row.Type == "Images" {
+			return row.SizeBytes, nil
+		}
+	}
+	return 0, fmt.Errorf("no Images row in docker system df output")
+}
+
+// parseDockerImageList groups `docker image ls` rows by image ID. Dangling
+// images are left to `docker image prune`. A repository row without a tag
+// is referenced by image ID, listed after the image's tags: a bare
+// repository name means repo:latest to `docker image rm`, which may be a
+// different image.
+func parseDockerImageList(output string) []dockerImageUsage {
+	byID := map[string]*dockerImageUsage{}
+	untagged := map[string]bool{}
+	var order []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 5 || fields[0] == "" {
+			continue
+		}
+		repo, tag := fields[1], fields[2]
+		if repo == "<none>" {
+			continue
+		}
+		image, ok := byID[fields[0]]
+		if !ok {
+			created, _ := time.Parse(dockerImageCreatedAtLayout, fields[3])
+			image = &dockerImageUsage{ID: fields[0], Created: created, SizeBytes: parseDockerSizeBytes(fields[4])}
+			byID[fields[0]] = image
+			order = append(order, fields[0])
+		}
+		if tag == "<none>" || tag == "" {
+			untagged[fields[0]] = true
+			continue
+		}
+		image.Refs = append(image.Refs, repo+":"+tag)
+	}
+
+	images := make([]dockerImageUsage, 0, len(order))
+	for _, id := range order {
+		image := byID[id]
+		if untagged[id] {
+			image.Refs = append(image.Refs, id)
+		}
+		images = append(images, *image)
+	}
+	return images
+}
+
+// parseDockerContainerUse parses the container inspect format in
+// dockerContainerUseArgs.
+func parseDockerContainerUse(output string) []dockerContainerUse {
+	var uses []dockerContainerUse
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 4 || fields[0] == "" {
+			continue
+		}
+		use := dockerContainerUse{ImageID: fields[0]}
+		for _, value := range fields[1:4] {
+			if ts, err := time.Parse(time.RFC3339Nano, value); err == nil && ts.After(use.LastUsed) {
+				use.LastUsed = ts
+			}
+		}
+		uses = append(uses, use)
+	}
+	return uses
+}
+
+// applyDockerContainerUse sets each image's last use from its containers,
+// falling back to the image creation time.
+func applyDockerContainerUse(images []dockerImageUsage, uses []dockerContainerUse) []dockerImageUsage {
+	for i := range images {
+		images[i].LastUsed = images[i].Created
+		for _, use := range uses {
+			if use.ImageID != images[i].ID {
+				continue
+			}
+			images[i].Containers++
+			if use.LastUsed.After(images[i].LastUsed) {
+				images[i].LastUsed = use.LastUsed
+			}
+		}
+	}
+	return images
+}
+
+// dockerLeastRecentlyUsed ranks images by last use, newest first, and returns
+// those outside the first keep that were last used before cutoff and have no
+// containers, oldest first.
+func dockerLeastRecentlyUsed(images []dockerImageUsage, keep int, cutoff time.Time) []dockerImageUsage {
+	ranked := append([]dockerImageUsage{}, images...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if !ranked[i].LastUsed.Equal(ranked[j].LastUsed) {
+			return ranked[i].LastUsed.After(ranked[j].LastUsed)
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	var candidates []dockerImageUsage
+	for i := len(ranked) - 1; i >= keep; i-- {
+		image := ranked[i]
+		if image.Containers > 0 || !image.LastUsed.Before(cutoff) {
+			continue
+		}
+		candidates = append(candidates, image)
+	}
+	return candidates
+}
@@ -0,0 +1,384 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestDockerCleanupSkipsWhenActiveWorkIsProtected(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"ps -axo comm=,args=": {Output: "docker docker build -t app .\nzsh -zsh\n"},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 0 || result.Error != nil {
+		t.Fatalf("expected no cleanup while docker build runs, got %+v", result)
+	}
+	if pruned := fake.commandLines("docker image"); len(pruned) != 0 {
+		t.Fatalf("expected no prune commands, got %v", pruned)
+	}
+
+	cfg.Docker.ProtectRunningContainers = false
+	NewDockerPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if pruned := fake.commandLines("docker image"); len(pruned) == 0 {
+		t.Fatal("expected prune commands once protect_running_containers is off")
+	}
+}
+
+func TestDockerCleanupModerateRunsPrunesInOrder(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker image prune -f":                       {Output: "Total reclaimed space: 1MB\n"},
+		"docker image prune -af --filter until=24h":   {Output: "Total reclaimed space: 2MB\n"},
+		"docker container prune -f --filter until=1h": {Err: errors.New("exit status 1"), Output: "daemon busy"},
+		"docker buildx prune -f --filter until=24h":   {Output: "Total reclaimed space: 1GB\n"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.Socket = "/run/user/1000/docker.sock"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("moderate prune failures should not fail the plugin, got %v", result.Error)
+	}
+	if want := int64(3*1024*1024 + 1024*1024*1024); result.BytesFreed != want {
+		t.Fatalf("BytesFreed = %d, want %d", result.BytesFreed, want)
+	}
+
+	want := []string{
+		"docker info",
+		"docker image prune -f",
+		"docker image prune -af --filter until=24h",
+		"docker container prune -f --filter until=1h",
+		"docker buildx prune -f --filter until=24h",
+	}
+	if got := fake.commandLines("docker"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("docker commands = %v, want %v", got, want)
+	}
+	for _, call := range fake.calls {
+		if call.Name == "docker" && !reflect.DeepEqual(call.Env, []string{"DOCKER_HOST=unix:///run/user/1000/docker.sock"}) {
+			t.Fatalf("expected configured socket in env for %q, got %v", call, call.Env)
+		}
+	}
+}
+
+func TestDockerCleanupCriticalKeepsRunningContainerImagesAndVolumes(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Format(dockerImageCreatedAtLayout)
+	imageList := strings.Join([]string{
+		"sha256:aaa\tpostgres\t16\t" + created + "\t400MB",
+		"sha256:bbb\told\tv1\t" + created + "\t1GB",
+	}, "\n")
+	inspect := strings.Join(dockerRunningUseArgs, " ")
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker " + strings.Join(dockerRunningIDArgs, " "):  {Output: "c1\n"},
+		"docker " + inspect + " c1":                         {Output: "sha256:aaa\tpostgres:16\tpgdata \n"},
+		"docker " + strings.Join(dockerImageListArgs, " "):  {Output: imageList},
+		"docker " + strings.Join(dockerVolumeListArgs, " "): {Output: "pgdata\nscratch\n"},
+		"docker builder prune -af":                          {Output: "Total reclaimed space: 2GB\n"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.Socket = ""
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if got := fake.commandLines("docker system prune"); len(got) != 0 {
+		t.Fatalf("expected no system prune while a container runs, got %v", got)
+	}
+	if got, want := fake.commandLines("docker image rm"), []string{"docker image rm old:v1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("image rm = %v, want %v; postgres:16 is used by the running container", got, want)
+	}
+	if got, want := fake.commandLines("docker volume rm"), []string{"docker volume rm scratch"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("volume rm = %v, want %v; pgdata is mounted by the running container", got, want)
+	}
+	// Without system df the reported sizes are summed.
+	if result.BytesFreed != 3<<30 || result.ItemsCleaned != 2 {
+		t.Fatalf("expected 3GB from an image and a volume, got %+v", result)
+	}
+
+	// With protection off, critical cleanup is the full system prune.
+	fake = useFakeRunner(t, map[string]fakeResponse{})
+	cfg.Docker.ProtectRunningContainers = false
+	NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if got := fake.commandLines("docker system prune"); len(got) != 1 || len(fake.commandLines("docker ps")) != 0 {
+		t.Fatalf("expected only the system prune, got %v", fake.commandLines("docker"))
+	}
+}
+
+func TestDockerCleanupCriticalRemovesUntaggedImagesByID(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Format(dockerImageCreatedAtLayout)
+	// The container was started from app when that was sha256:run; a newer
+	// pull moved app:latest to sha256:new, leaving sha256:run and an older
+	// sha256:old as untagged app rows.
+	imageList := strings.Join([]string{
+		"sha256:new\tapp\tlatest\t" + created + "\t1GB",
+		"sha256:run\tapp\t<none>\t" + created + "\t1GB",
+		"sha256:old\tapp\t<none>\t" + created + "\t1GB",
+	}, "\n")
+	inspect := strings.Join(dockerRunningUseArgs, " ")
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker " + strings.Join(dockerRunningIDArgs, " "): {Output: "c1\n"},
+		"docker " + inspect + " c1":                        {Output: "sha256:run\tapp\t\n"},
+		"docker " + strings.Join(dockerImageListArgs, " "): {Output: imageList},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	// sha256:run is in use by ID and sha256:new by the container's app:latest
+	// reference; a bare "app" would have removed app:latest.
+	if got, want := fake.commandLines("docker image rm"), []string{"docker image rm sha256:old"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("image rm = %v, want %v", got, want)
+	}
+}
+
+func TestDockerCleanupCriticalRemovesMultiTagImageByTags(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Format(dockerImageCreatedAtLayout)
+	imageList := strings.Join([]string{
+		"sha256:run\tpostgres\t16\t" + created + "\t400MB",
+		"sha256:big\tbuild\tv1\t" + created + "\t3GB",
+		"sha256:big\tregistry.example.com/build\tv1\t" + created + "\t3GB",
+	}, "\n")
+	inspect := strings.Join(dockerRunningUseArgs, " ")
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker " + strings.Join(dockerRunningIDArgs, " "): {Output: "c1\n"},
+		"docker " + inspect + " c1":                        {Output: "sha256:run\tpostgres:16\t\n"},
+		"docker " + strings.Join(dockerImageListArgs, " "): {Output: imageList},
+		// Docker refuses to remove an image referenced in several
+		// repositories by ID without -f.
+		"docker image rm sha256:big": {Err: errors.New("exit status 1"), Output: "conflict: image is referenced in multiple repositories"},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if got, want := fake.commandLines("docker image rm"), []string{"docker image rm build:v1 registry.example.com/build:v1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("image rm = %v, want %v", got, want)
+	}
+	if result.ItemsCleaned != 1 {
+		t.Fatalf("expected the multi-tag image to be removed, got %+v", result)
+	}
+}
+
+func TestDockerExplicitRef(t *testing.T) {
+	for ref, want := range map[string]string{
+		"app":                   "app:latest",
+		"app:1.2":               "app:1.2",
+		"localhost:5000/app":    "localhost:5000/app:latest",
+		"localhost:5000/app:v1": "localhost:5000/app:v1",
+		"app@sha256:0123":       "app@sha256:0123",
+		"":                      "",
+	} {
+		if got := dockerExplicitRef(ref); got != want {
+			t.Errorf("dockerExplicitRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestDockerCleanupUsesAmbientHostWithoutSocket(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{})
+	cfg := config.DefaultConfig()
+	cfg.Docker.Socket = "/run/user/1000/docker.sock"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	plugin := NewDockerPlugin()
+	plugin.Cleanup(context.Background(), LevelWarning, cfg, logger)
+	cfg.Docker.Socket = ""
+	fake.calls = nil
+	plugin.Cleanup(context.Background(), LevelWarning, cfg, logger)
+
+	if len(fake.commandLines("docker")) == 0 {
+		t.Fatal("expected docker commands")
+	}
+	for _, call := range fake.calls {
+		if call.Name == "docker" && call.Env != nil {
+			t.Fatalf("expected ambient DOCKER_HOST once the socket is cleared, got %v for %q", call.Env, call)
+		}
+	}
+}
+
+func TestDockerCleanupCriticalReportsPruneFailure(t *testing.T) {
+	useFakeRunner(t, map[string]fakeResponse{
+		"docker system prune -af --volumes": {Err: errors.New("exit status 1"), Output: "permission denied\n"},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	pluginErr, ok := AsPluginError(result.Error)
+	if !ok {
+		t.Fatalf("expected PluginError, got %v", result.Error)
+	}
+	if pluginErr.Operation != "system_prune" || pluginErr.CommandOutput != "permission denied" {
+		t.Fatalf("unexpected error detail: %+v", pluginErr)
+	}
+}
+
+func TestDockerCleanupSkipsWhenDockerUnavailable(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker info": {Err: errors.New("cannot connect to the Docker daemon")},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.Error != nil || result.BytesFreed != 0 {
+		t.Fatalf("expected a quiet skip, got %+v", result)
+	}
+	if got := fake.commandLines(""); !reflect.DeepEqual(got, []string{"docker info"}) {
+		t.Fatalf("expected only the availability check, got %v", got)
+	}
+}
+
+func TestDockerCleanupDeepBuildCacheGC(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker ps --filter name=buildx_buildkit --format {{.ID}}\t{{.Names}}": {Output: "abc\tbuildx_buildkit_ci0\n"},
+		"docker builder prune -af":                 {Output: "Total reclaimed space: 1GB\n"},
+		"docker exec abc buildctl prune --all":     {Output: "Total:\t3GB\n"},
+		"docker exec abc du -sk /var/lib/buildkit": {Err: errors.New("exit status 1")},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.DeepBuildCacheGC = true
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelAggressive, cfg, logger)
+	if result.DeepGCBytesFreed != 3<<30 || result.BytesFreed != 4<<30 {
+		t.Fatalf("expected 3GB beyond the 1GB builder prune, got %+v", result)
+	}
+	calls := fake.commandLines("docker")
+	if calls[len(calls)-1] != "docker exec abc du -sk /var/lib/buildkit" {
+		t.Fatalf("expected the deep GC after the normal prunes, got %v", calls)
+	}
+
+	// An active build defers the deep GC even without protect_running_containers.
+	fake = useFakeRunner(t, map[string]fakeResponse{
+		"ps -axo comm=,args=": {Output: "docker-buildx docker-buildx build -t app .\n"},
+	})
+	cfg.Docker.ProtectRunningContainers = false
+	result = NewDockerPlugin().Cleanup(context.Background(), LevelAggressive, cfg, logger)
+	if result.DeepGCBytesFreed != 0 || len(fake.commandLines("docker exec")) != 0 {
+		t.Fatalf("expected no deep GC during a build, got %+v %v", result, fake.commandLines("docker exec"))
+	}
+}
+
+func TestDockerCleanupKeepsRecentlyUsedImages(t *testing.T) {
+	now := time.Now().UTC()
+	created := func(age time.Duration) string { return now.Add(-age).Format(dockerImageCreatedAtLayout) }
+	imageList := strings.Join([]string{
+		"sha256:aaa\tapp\tlatest\t" + created(2*time.Hour) + "\t1GB",
+		"sha256:bbb\tweb\tv1\t" + created(72*time.Hour) + "\t500MB",
+		"sha256:ccc\told\tv1\t" + created(240*time.Hour) + "\t2GB",
+		"sha256:ccc\told\tv2\t" + created(240*time.Hour) + "\t2GB",
+		"sha256:ddd\tmid\tv1\t" + created(48*time.Hour) + "\t300MB",
+		"sha256:eee\t<none>\t<none>\t" + created(480*time.Hour) + "\t100MB",
+	}, "\n")
+	stoppedAt := now.Add(-time.Hour).Format(time.RFC3339Nano)
+	inspect := strings.Join(dockerContainerUseArgs, " ")
+
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker " + strings.Join(dockerImageListArgs, " "):   {Output: imageList},
+		"docker " + strings.Join(dockerContainerIDArgs, " "): {Output: "c1\n"},
+		"docker " + inspect + " c1":                          {Output: "sha256:bbb\t" + stoppedAt + "\t" + stoppedAt + "\t" + stoppedAt + "\n"},
+		"docker image rm mid:v1":                             {Err: errors.New("exit status 1"), Output: "conflict"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.KeepRecentlyUsed = 2
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewDockerPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	// web:v1 and app:latest are the two most recently used; old and mid fall
+	// outside, oldest first. mid fails to remove, and with no system df the
+	// listed size of old is reported.
+	if got, want := fake.commandLines("docker image rm"), []string{"docker image rm old:v1 old:v2", "docker image rm mid:v1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("image rm = %v, want %v", got, want)
+	}
+	if result.BytesFreed != 2<<30 || result.ItemsCleaned != 1 {
+		t.Fatalf("expected 2GB from one image, got %+v", result)
+	}
+	if pruned := fake.commandLines("docker image prune -af"); len(pruned) != 0 {
+		t.Fatalf("expected no age-filtered prune with keep_recently_used, got %v", pruned)
+	}
+	if calls := fake.commandLines("docker container"); calls[len(calls)-1] != "docker container prune -f --filter until=1h" {
+		t.Fatalf("expected container history to be read before the container prune, got %v", calls)
+	}
+}
+
+func TestParseDockerImageListReferencesUntaggedRowsByID(t *testing.T) {
+	created := time.Now().UTC().Format(dockerImageCreatedAtLayout)
+	images := parseDockerImageList(strings.Join([]string{
+		"sha256:new\tapp\tlatest\t" + created + "\t1GB",
+		"sha256:old\tapp\t<none>\t" + created + "\t900MB",
+		"sha256:both\tweb\t<none>\t" + created + "\t100MB",
+		"sha256:both\tweb\tv2\t" + created + "\t100MB",
+		"sha256:dangling\t<none>\t<none>\t" + created + "\t10MB",
+	}, "\n"))
+
+	refs := map[string][]string{}
+	for _, image := range images {
+		refs[image.ID] = image.Refs
+	}
+	want := map[string][]string{
+		"sha256:new":  {"app:latest"},
+		"sha256:old":  {"sha256:old"},
+		"sha256:both": {"web:v2", "sha256:both"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("refs = %v, want %v", refs, want)
+	}
+}
+
+func TestDockerCleanupRemovesUntaggedImageByID(t *testing.T) {
+	now := time.Now().UTC()
+	imageList := strings.Join([]string{
+		"sha256:new\tapp\tlatest\t" + now.Add(-time.Hour).Format(dockerImageCreatedAtLayout) + "\t1GB",
+		"sha256:old\tapp\t<none>\t" + now.Add(-240*time.Hour).Format(dockerImageCreatedAtLayout) + "\t900MB",
+	}, "\n")
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker " + strings.Join(dockerImageListArgs, " "): {Output: imageList},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Docker.KeepRecentlyUsed = 1
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	NewDockerPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if got, want := fake.commandLines("docker image rm"), []string{"docker image rm sha256:old"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("image rm = %v, want %v; a bare app would remove app:latest", got, want)
+	}
+}
+
+func TestDockerLeastRecentlyUsedRanking(t *testing.T) {
+	now := time.Now()
+	images := []dockerImageUsage{
+		{ID: "a", LastUsed: now.Add(-100 * time.Hour)},
+		{ID: "b", LastUsed: now.Add(-10 * time.Hour)},
+		{ID: "c", LastUsed: now.Add(-200 * time.Hour), Containers: 1},
+		{ID: "d", LastUsed: now.Add(-300 * time.Hour)},
+		{ID: "e", LastUsed: now.Add(-time.Hour)},
+	}
+
+	var ids []string
+	for _, image := range dockerLeastRecentlyUsed(images, 1, now.Add(-24*time.Hour)) {
+		ids = append(ids, image.ID)
+	}
+	// e is kept by rank, b by age, and c because a container uses it.
+	if want := []string{"d", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("candidates = %v, want %v", ids, want)
+	}
+	if got := dockerLeastRecentlyUsed(images, 5, now); len(got) != 0 {
+		t.Fatalf("expected nothing outside the top 5, got %v", got)
+	}
+}
@@ -0,0 +1,416 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// ElectronAppsPlugin clears the caches of Electron apps such as Slack,
+// Discord, and Notion. Only allowlisted cache subpaths of each app's data
+// directory are cleared; Local Storage, IndexedDB, cookies, and settings,
+// which hold login state, are refused by validation.
+type ElectronAppsPlugin struct{}
+
+// electronModerateCaches are the cache subpaths cleared at Moderate when an
+// app does not list its own.
+var electronModerateCaches = []string{"Cache", "Code Cache", "GPUCache"}
+
+// electronAggressiveCaches are the cache subpaths also cleared at Aggressive
+// when an app does not list its own.
+var electronAggressiveCaches = []string{"Service Worker/CacheStorage", "Service Worker/ScriptCache"}
+
+// electronStateNames are data directory entries that hold login state or
+// settings. No allowlisted subpath may name or pass through one.
+var electronStateNames = []string{
+	"Cookies", "Cookies-journal", "databases", "IndexedDB", "Local State",
+	"Local Storage", "Network", "Preferences", "Session Storage", "Sessions",
+	"shared_proto_db", "storage", "WebStorage",
+}
+
+// electronBuiltinApps is the built-in allowlist. Relative dirs are under
+// Application Support on macOS and ~/.config on Linux.
+var electronBuiltinApps = []config.ElectronAppConfig{
+	{Name: "Slack", Dirs: []string{"Slack", "~/Library/Containers/com.tinyspeck.slackmacgap/Data/Library/Application Support/Slack"}},
+	{Name: "Discord", Dirs: []string{"discord"}},
+	{Name: "Notion", Dirs: []string{"Notion"}},
+	{Name: "Spotify", Dirs: []string{"Spotify"}},
+	{Name: "Signal", Dirs: []string{"Signal"}},
+	{Name: "Microsoft Teams", Process: "Teams", Dirs: []string{"Microsoft/Teams", "Microsoft Teams"}},
+}
+
+// electronCacheSweep totals the allowlisted caches found under one app data
+// directory.
+type electronCacheSweep struct {
+	Paths []string
+	Bytes int64
+}
+
+// NewElectronAppsPlugin creates a new Electron app cache cleanup plugin.
+func NewElectronAppsPlugin() *ElectronAppsPlugin {
+	return &ElectronAppsPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *ElectronAppsPlugin) Name() string {
+	return "electron-apps"
+}
+
+// Description returns the plugin description.
+func (p *ElectronAppsPlugin) Description() string {
+	return "Clears allowlisted cache directories of Electron apps such as Slack, Discord, and Notion"
+}
+
+// Priority runs Electron cache cleanup after log cleanup; the caches are
+// rebuilt on the app's next launch.
+func (p *ElectronAppsPlugin) Priority() int {
+	return 16
+}
+
+// SupportedPlatforms returns supported platforms.
+func (p *ElectronAppsPlugin) SupportedPlatforms() []string {
+	return []string{PlatformDarwin, PlatformLinux}
+}
+
+// Enabled checks if Electron app cache cleanup is enabled.
+func (p *ElectronAppsPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.ElectronApps
+}
+
+// LevelDescription summarizes Electron app cache cleanup at each level.
+func (p *ElectronAppsPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports allowlisted Electron app cache sizes only"
+	case LevelModerate:
+		return "clears Cache, Code Cache, and GPUCache of allowlisted Electron apps that are not running"
+	case LevelAggressive:
+		return "also clears Service Worker CacheStorage and ScriptCache of allowlisted Electron apps that are not running"
+	case LevelCritical:
+		return "clears moderate and aggressive allowlisted Electron app caches, including those of running apps"
+	default:
+		return "no cleanup"
+	}
+}
+
+// ValidateElectronAppsConfig rejects unnamed apps, new apps without data
+// directories, and cache subpaths that are absolute, leave the data
+// directory, or touch login state or settings.
+func ValidateElectronAppsConfig(cfg config.ElectronAppsConfig) error {
+	for i, app := range cfg.Apps {
+		if strings.TrimSpace(app.Name) == "" {
+			return fmt.Errorf("electron_apps.apps[%d].name is required", i)
+		}
+		if len(app.Dirs) == 0 && electronBuiltinIndex(app.Name) < 0 {
+			return fmt.Errorf("electron_apps.apps[%d] (%s) needs dirs", i, app.Name)
+		}
+		for _, dir := range app.Dirs {
+			if strings.TrimSpace(dir) == "" {
+				return fmt.Errorf("electron_apps.apps[%d] (%s) has an empty dir", i, app.Name)
+			}
+		}
+		for _, sub := range append(slices.Clone(app.Moderate), app.Aggressive...) {
+			if err := validateElectronCachePath(sub); err != nil {
+				return fmt.Errorf("electron_apps.apps[%d] (%s): %w", i, app.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateElectronCachePath rejects a cache subpath that is empty, absolute,
+// leaves the data directory, or names a login state or settings entry.
+func validateElectronCachePath(sub string) error {
+	if strings.TrimSpace(sub) == "" || filepath.IsAbs(sub) {
+		return fmt.Errorf("cache path %q must be relative to the app data directory", sub)
+	}
+	clean := filepath.Clean(sub)
+	if clean == "." {
+		return fmt.Errorf("cache path %q must name a subdirectory", sub)
+	}
+	for _, part := range strings.Split(clean, string(filepath.Separator)) {
+		if part == ".." {
+			return fmt.Errorf("cache path %q leaves the app data directory", sub)
+		}
+		for _, state := range electronStateNames {
+			if strings.EqualFold(part, state) {
+				return fmt.Errorf("cache path %q touches %s, which holds login state or settings", sub, state)
+			}
+		}
+	}
+	return nil
+}
+
+// electronBuiltinIndex returns the index of the built-in app named name, or -1.
+func electronBuiltinIndex(name string) int {
+	return slices.IndexFunc(electronBuiltinApps, func(app config.ElectronAppConfig) bool {
+		return strings.EqualFold(app.Name, name)
+	})
+}
+
+// electronApps returns the built-in allowlist merged with electron_apps.apps.
+// An entry named like a built-in app replaces it, taking the built-in's
+// process and dirs when it leaves them empty.
+func electronApps(cfg *config.Config) []config.ElectronAppConfig {
+	apps := slices.Clone(electronBuiltinApps)
+	for _, app := range cfg.ElectronApps.Apps {
+		i := electronBuiltinIndex(app.Name)
+		if i < 0 {
+			apps = append(apps, app)
+			continue
+		}
+		if app.Process == "" {
+			app.Process = apps[i].Process
+		}
+		if len(app.Dirs) == 0 {
+			app.Dirs = apps[i].Dirs
+		}
+		apps[i] = app
+	}
+	return apps
+}
+
+// electronAppProcess returns the process name that marks app running.
+func electronAppProcess(app config.ElectronAppConfig) string {
+	if app.Process != "" {
+		return app.Process
+	}
+	return app.Name
+}
+
+// electronAppCaches returns the cache subpaths of app cleared at level.
+func electronAppCaches(app config.ElectronAppConfig, level CleanupLevel) []string {
+	if level < LevelModerate {
+		level = LevelModerate
+	}
+	caches := app.Moderate
+	if caches == nil {
+		caches = electronModerateCaches
+	}
+	caches = slices.Clone(caches)
+	if level >= LevelAggressive {
+		if app.Aggressive == nil {
+			caches = append(caches, electronAggressiveCaches...)
+		} else {
+			caches = append(caches, app.Aggressive...)
+		}
+	}
+	return caches
+}
+
+// electronDataRoot returns the directory relative app dirs are under:
+// Application Support on macOS and $XDG_CONFIG_HOME or ~/.config elsewhere.
+func electronDataRoot(home string) string {
+	if goos() == PlatformDarwin {
+		return filepath.Join(home, "Library", "Application Support")
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return xdg
+	}
+	return filepath.Join(home, ".config")
+}
+
+// electronAppDir resolves one configured app data directory.
+func electronAppDir(dir, home string) string {
+	if strings.HasPrefix(dir, "~") {
+		return expandHome(dir, home)
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(electronDataRoot(home), dir)
+}
+
+// electronCachePath joins sub onto appDir, refusing it when any directory
+// between them is a symlink, so a link cannot lead the sweep elsewhere. A
+// symlinked final component is handled by resolveCacheRoot.
+func electronCachePath(appDir, sub string) (string, bool) {
+	path := appDir
+	parts := strings.Split(filepath.Clean(sub), string(filepath.Separator))
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", false
+		}
+		if i < len(parts)-1 && (info.Mode()&os.ModeSymlink != 0 || !info.IsDir()) {
+			return "", false
+		}
+	}
+	return path, true
+}
+
+// sweepElectronCaches sizes the caches of one app data directory cleared at
+// level, skipping protect paths, and removes them when remove is set.
+func sweepElectronCaches(ctx context.Context, appDir string, caches []string, protect []string, remove bool) (electronCacheSweep, error) {
+	var sweep electronCacheSweep
+	var firstErr error
+	for _, sub := range caches {
+		if ctx.Err() != nil {
+			return sweep, ctx.Err()
+		}
+		path, ok := electronCachePath(appDir, sub)
+		if !ok || isProtectedPath(path, protect) {
+			continue
+		}
+		before := getDirSizeSameDevice(path)
+		if before == 0 {
+			continue
+		}
+		bytes := before
+		if remove {
+			if err := removeCacheRoot(path); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			bytes = safeBytesDiff(before, getDirSizeSameDevice(path))
+		}
+		sweep.Paths = append(sweep.Paths, sub)
+		sweep.Bytes += bytes
+	}
+	return sweep, firstErr
+}
+
+// PlanCleanup reports allowlisted cache sizes per app data directory.
+func (p *ElectronAppsPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Electron app cache cleanup plan",
+		WouldRun: level >= LevelModerate,
+		Steps: []string{
+			"Size the allowlisted cache subpaths of each Electron app data directory",
+			"Never touch Local Storage, IndexedDB, cookies, databases, or settings",
+			"Skip running apps below critical, dev_artifacts.protect_paths, and symlinked parents",
+		},
+		Metadata: map[string]string{
+			"cleanup_level": level.String(),
+		},
+	}
+	switch {
+	case level >= LevelAggressive:
+		plan.Steps = append(plan.Steps, "Clear Cache, Code Cache, GPUCache, and Service Worker caches and report bytes freed per app")
+	case level == LevelModerate:
+		plan.Steps = append(plan.Steps, "Clear Cache, Code Cache, and GPUCache and report bytes freed per app")
+	default:
+		plan.SkipReason = "below_moderate_level"
+	}
+	if err := ValidateElectronAppsConfig(cfg.ElectronApps); err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "invalid_config"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+
+	protect := appLogProtectPaths(cfg, home)
+	processes := runningProcesses(ctx)
+	var apps int
+	for _, app := range electronApps(cfg) {
+		caches := electronAppCaches(app, level)
+		for _, dir := range app.Dirs {
+			appDir := electronAppDir(dir, home)
+			if !pathExistsAndIsDir(appDir) {
+				continue
+			}
+			sweep, err := sweepElectronCaches(ctx, appDir, caches, protect, false)
+			if err != nil {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", appDir, err))
+				continue
+			}
+			if sweep.Bytes == 0 {
+				continue
+			}
+			target := CleanupTarget{
+				Type:      "electron-app-cache",
+				Name:      app.Name,
+				Path:      appDir,
+				Bytes:     sweep.Bytes,
+				Action:    "delete_cache_dirs",
+				Protected: level < LevelModerate,
+				Reason:    "caches: " + strings.Join(sweep.Paths, ", "),
+			}
+			if level < LevelCritical && appRunning(processes, electronAppProcess(app)) {
+				target.Protected = true
+				target.Reason += fmt.Sprintf("; %s is running", app.Name)
+			}
+			if target.Protected {
+				target.Action = "report"
+			}
+			annotateCleanupTargetPolicy(&target, CleanupTierSafe, hostReclaimForAction(target.Action))
+			plan.Targets = append(plan.Targets, target)
+			apps++
+			if !target.Protected {
+				plan.EstimatedBytesFreed += sweep.Bytes
+			}
+		}
+	}
+	plan.Metadata["app_dir_count"] = strconv.Itoa(apps)
+	return plan
+}
+
+// Cleanup clears allowlisted Electron app caches at Moderate and above,
+// logging bytes freed per app.
+func (p *ElectronAppsPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	if level < LevelModerate {
+		return result
+	}
+	if err := ValidateElectronAppsConfig(cfg.ElectronApps); err != nil {
+		logger.Warn("skipping Electron app cache cleanup", "error", err)
+		return result
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping Electron app cache cleanup: home directory unavailable", "error", err)
+		return result
+	}
+
+	skipped := walkErrors.snapshot()
+	protect := appLogProtectPaths(cfg, home)
+	processes := runningProcesses(ctx)
+	for _, app := range electronApps(cfg) {
+		if ctx.Err() != nil {
+			break
+		}
+		if level < LevelCritical && appRunning(processes, electronAppProcess(app)) {
+			logger.Debug("skipping caches of running Electron app", "app", app.Name)
+			continue
+		}
+		caches := electronAppCaches(app, level)
+		var freed int64
+		var cleared int
+		for _, dir := range app.Dirs {
+			appDir := electronAppDir(dir, home)
+			if !pathExistsAndIsDir(appDir) {
+				continue
+			}
+			sweep, err := sweepElectronCaches(ctx, appDir, caches, protect, true)
+			if err != nil && ctx.Err() == nil {
+				logger.Warn("Electron app cache cleanup failed", "app", app.Name, "path", appDir, "error", err)
+			}
+			freed += sweep.Bytes
+			cleared += len(sweep.Paths)
+		}
+		result.BytesFreed += freed
+		result.ItemsCleaned += cleared
+		if cleared > 0 {
+			logger.Info("cleared Electron app caches", "app", app.Name, "caches", cleared, "freed_mb", freed/(1024*1024))
+		}
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestElectronAppsCleanupClearsAllowlistedCachesByLevel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	previous := goosValue
+	goosValue = PlatformDarwin
+	t.Cleanup(func() { goosValue = previous })
+	write := func(rel string, size int) string {
+		path := filepath.Join(home, "Library", "Application Support", rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	discordCache := write("discord/Cache/Cache_Data/data_0", 100)
+	discordGPU := write("discord/GPUCache/data_1", 200)
+	discordWorker := write("discord/Service Worker/CacheStorage/abc/index", 400)
+	discordLogin := write("discord/Local Storage/leveldb/000003.log", 800)
+	discordIndexed := write("discord/IndexedDB/https_discord.com_0.indexeddb.leveldb/LOG", 1600)
+	slackCache := write("Slack/Cache/data_0", 3200)
+	obsidianCache := write("obsidian/Code Cache/js/index", 6400)
+
+	useFakeRunner(t, map[string]fakeResponse{
+		"ps -axo comm=": {Output: "/Applications/Slack.app/Contents/MacOS/Slack\n/usr/sbin/cfprefsd\n"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Enable.ElectronApps = true
+	cfg.ElectronApps.Apps = []config.ElectronAppConfig{{Name: "Obsidian", Dirs: []string{"obsidian"}}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewElectronAppsPlugin()
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.EstimatedBytesFreed != 6700 || plan.Metadata["app_dir_count"] != "3" {
+		t.Fatalf("unexpected plan: estimate %d, metadata %v", plan.EstimatedBytesFreed, plan.Metadata)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 6700 || result.ItemsCleaned != 3 {
+		t.Fatalf("expected 6700 bytes from three caches, got %+v", result)
+	}
+	for _, removed := range []string{discordCache, discordGPU, obsidianCache} {
+		if pathExists(removed) {
+			t.Errorf("expected %s to be removed", removed)
+		}
+	}
+	for _, kept := range []string{discordWorker, discordLogin, discordIndexed, slackCache} {
+		if !pathExists(kept) {
+			t.Errorf("expected %s to be kept at moderate", kept)
+		}
+	}
+
+	if result := plugin.Cleanup(context.Background(), LevelAggressive, cfg, logger); result.BytesFreed != 400 || pathExists(discordWorker) {
+		t.Fatalf("expected aggressive to clear the service worker cache only, got %+v", result)
+	}
+	if result := plugin.Cleanup(context.Background(), LevelCritical, cfg, logger); result.BytesFreed != 3200 || pathExists(slackCache) {
+		t.Fatalf("expected critical to clear the running app's cache, got %+v", result)
+	}
+	for _, kept := range []string{discordLogin, discordIndexed} {
+		if !pathExists(kept) {
+			t.Errorf("expected login state %s to survive every level", kept)
+		}
+	}
+}
+
+func TestElectronCachePathRefusesSymlinkedParents(t *testing.T) {
+	appDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outside, "CacheStorage"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(appDir, "Service Worker")); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := electronCachePath(appDir, "Service Worker/CacheStorage"); ok {
+		t.Fatalf("expected symlinked parent to be refused, got %s", path)
+	}
+}
+
+func TestValidateElectronAppsConfig(t *testing.T) {
+	valid := config.ElectronAppsConfig{Apps: []config.ElectronAppConfig{
+		{Name: "Obsidian", Dirs: []string{"obsidian"}, Moderate: []string{"Cache"}},
+		{Name: "slack", Aggressive: []string{"Partitions/work/Cache"}},
+	}}
+	if err := ValidateElectronAppsConfig(valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, app := range map[string]config.ElectronAppConfig{
+		"unnamed":       {Dirs: []string{"x"}},
+		"no dirs":       {Name: "Unknown"},
+		"absolute":      {Name: "Slack", Moderate: []string{"/tmp"}},
+		"parent":        {Name: "Slack", Moderate: []string{"../Cache"}},
+		"local storage": {Name: "Slack", Moderate: []string{"Local Storage"}},
+		"nested state":  {Name: "Slack", Aggressive: []string{"Partitions/work/indexeddb"}},
+	} {
+		if err := ValidateElectronAppsConfig(config.ElectronAppsConfig{Apps: []config.ElectronAppConfig{app}}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
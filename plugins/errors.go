@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"errors"
+	"strings"
+)
+
+// PluginError is a cleanup failure annotated with the plugin, operation, and
+// target that produced it, so reports can group and filter failures by
+// operation instead of parsing error strings.
+type PluginError struct {
+	// Plugin is the plugin that failed.
+	Plugin string
+	// Operation is a stable snake_case name such as "image_prune" or "vm_restart".
+	Operation string
+	// Path is the file or directory being operated on, if any.
+	Path string
+	// VM is the virtual machine being operated on, if any.
+	VM string
+	// Err is the underlying error.
+	Err error
+	// CommandOutput is the trimmed combined output of a failed command.
+	CommandOutput string
+}
+
+// Error formats the failure with its operation and target.
+func (e *PluginError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Operation)
+	if e.VM != "" {
+		b.WriteString(" vm=")
+		b.WriteString(e.VM)
+	}
+	if e.Path != "" {
+		b.WriteString(" path=")
+		b.WriteString(e.Path)
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	if e.CommandOutput != "" {
+		b.WriteString(" (output: ")
+		b.WriteString(e.CommandOutput)
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// AsPluginError returns the first PluginError in err's chain.
+func AsPluginError(err error) (*PluginError, bool) {
+	var pluginErr *PluginError
+	if errors.As(err, &pluginErr) {
+		return pluginErr, true
+	}
+	return nil, false
+}
+
+// newPluginError wraps err with plugin and operation context. It returns nil
+// when err is nil.
+func newPluginError(plugin string, operation string, err error) *PluginError {
+	if err == nil {
+		return nil
+	}
+	return &PluginError{Plugin: plugin, Operation: operation, Err: err}
+}
+
+// newCommandError wraps a failed external command with its output.
+func newCommandError(plugin string, operation string, err error, output string) *PluginError {
+	pluginErr := newPluginError(plugin, operation, err)
+	if pluginErr != nil {
+		pluginErr.CommandOutput = strings.TrimSpace(output)
+	}
+	return pluginErr
+}
+
+// withVM returns e with VM set.
+func (e *PluginError) withVM(vm string) *PluginError {
+	if e != nil {
+		e.VM = vm
+	}
+	return e
+}
+
+// withPath returns e with Path set.
+func (e *PluginError) withPath(path string) *PluginError {
+	if e != nil {
+		e.Path = path
+	}
+	return e
+}
+
+// commandOperation names an operation after the leading subcommand words of
+// args, e.g. ["image", "prune", "-af"] becomes "image_prune".
+func commandOperation(args []string) string {
+	var words []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || len(words) == 2 {
+			break
+		}
+		words = append(words, arg)
+	}
+	if len(words) == 0 {
+		return "command"
+	}
+	return strings.Join(words, "_")
+}
+
+// pluginErrorForVM attaches vm to err, wrapping plain errors in a PluginError
+// with operation so per-VM failures stay attributable.
+func pluginErrorForVM(plugin string, operation string, vm string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if pluginErr, ok := AsPluginError(err); ok {
+		if pluginErr.VM == "" {
+			pluginErr.VM = vm
+		}
+		return err
+	}
+	return newPluginError(plugin, operation, err).withVM(vm)
+}
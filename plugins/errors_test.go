@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCommandErrorPopulatesStructuredFields(t *testing.T) {
+	output, runErr := exec.Command("sh", "-c", "echo 'disk busy' >&2; exit 3").CombinedOutput()
+	if runErr == nil {
+		t.Fatal("expected simulated command to fail")
+	}
+
+	err := fmt.Errorf("cleanup: %w", newCommandError("lima", "vm_stop", runErr, string(output)).withVM("colima"))
+	pluginErr, ok := AsPluginError(err)
+	if !ok {
+		t.Fatalf("expected PluginError in chain, got %T", err)
+	}
+	if pluginErr.Plugin != "lima" || pluginErr.Operation != "vm_stop" || pluginErr.VM != "colima" {
+		t.Fatalf("unexpected structured fields: %+v", pluginErr)
+	}
+	if pluginErr.CommandOutput != "disk busy" {
+		t.Fatalf("command output = %q, want trimmed stderr", pluginErr.CommandOutput)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected wrapped exit status 3, got %v", err)
+	}
+	if got := pluginErr.Error(); got != "vm_stop vm=colima: exit status 3 (output: disk busy)" {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestPluginErrorForVMKeepsExistingOperation(t *testing.T) {
+	inner := newCommandError("podman", "image_prune", errors.New("exit status 1"), "")
+	err := pluginErrorForVM("podman", "machine_cleanup", "podman-machine-default", inner)
+	pluginErr, ok := AsPluginError(err)
+	if !ok || pluginErr.Operation != "image_prune" || pluginErr.VM != "podman-machine-default" {
+		t.Fatalf("unexpected error %+v", pluginErr)
+	}
+
+	err = pluginErrorForVM("podman", "machine_cleanup", "other", errors.New("boom"))
+	pluginErr, ok = AsPluginError(err)
+	if !ok || pluginErr.Operation != "machine_cleanup" || pluginErr.VM != "other" {
+		t.Fatalf("unexpected wrapped plain error %+v", pluginErr)
+	}
+	if pluginErrorForVM("podman", "machine_cleanup", "other", nil) != nil {
+		t.Fatal("expected nil for nil error")
+	}
+}
+
+func TestCommandOperation(t *testing.T) {
+	tests := map[string]string{
+		"image prune -af --filter until=24h": "image_prune",
+		"system prune -af --volumes":         "system_prune",
+		"gc --auto --quiet":                  "gc",
+		"reflog expire --all":                "reflog_expire",
+		"-f":                                 "command",
+	}
+	for args, want := range tests {
+		if got := commandOperation(strings.Fields(args)); got != want {
+			t.Fatalf("commandOperation(%q) = %q, want %q", args, got, want)
+		}
+	}
+}
+
+func TestGitMaintenanceCommandFailureIsStructured(t *testing.T) {
+	git := requireGit(t)
+	dir := t.TempDir()
+
+	err := newTestGitMaintenancePlugin(git, time.Time{}).maintainRepo(context.Background(), dir, LevelModerate)
+	pluginErr, ok := AsPluginError(err)
+	if !ok {
+		t.Fatalf("expected PluginError, got %v", err)
+	}
+	if pluginErr.Plugin != "git-maintenance" || pluginErr.Operation != "gc" || pluginErr.Path != dir {
+		t.Fatalf("unexpected structured fields: %+v", pluginErr)
+	}
+	if !strings.Contains(strings.ToLower(pluginErr.CommandOutput), "not a git repository") {
+		t.Fatalf("expected git output, got %q", pluginErr.CommandOutput)
+	}
+}
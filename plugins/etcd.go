@@ -33,6 +33,11 @@ func (p *EtcdPlugin) Description() string {
 	return "Cleans old etcd snapshots, WAL files, and runs defrag when needed"
 }
 
+// Priority runs etcd maintenance late because it touches live cluster state.
+func (p *EtcdPlugin) Priority() int {
+	return 65
+}
+
 // SupportedPlatforms returns supported platforms (Linux only).
 func (p *EtcdPlugin) SupportedPlatforms() []string {
 	return []string{PlatformLinux}
@@ -138,7 +143,7 @@ func (p *EtcdPlugin) cleanOldWAL(ctx context.Context, cfg *config.Config, logger
 	})
 
 	if err != nil {
-		result.Error = err
+		result.Error = newPluginError(p.Name(), "wal_cleanup", err).withPath(walDir)
 	}
 
 	return result
@@ -174,7 +179,7 @@ func (p *EtcdPlugin) cleanModerate(ctx context.Context, cfg *config.Config, logg
 	})
 
 	if err != nil {
-		result.Error = err
+		result.Error = newPluginError(p.Name(), "snapshot_cleanup", err).withPath(snapDir)
 		return result
 	}
 
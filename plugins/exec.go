@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// commandRunner runs external commands on behalf of plugins. env entries are
+// appended to the daemon's environment; nil keeps it unchanged.
+type commandRunner interface {
+	// LookPath reports where an executable is found in PATH.
+	LookPath(file string) (string, error)
+	// Run runs the command and waits for it to exit.
+	Run(ctx context.Context, env []string, name string, args ...string) error
+	// Output runs the command and returns its standard output.
+	Output(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+	// CombinedOutput runs the command and returns standard output and error.
+	CombinedOutput(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+}
+
+// runner is the commandRunner plugins use to reach external tools. Tests
+// replace it with a fake that returns canned output and records invocations.
+var runner commandRunner = execRunner{}
+
+// commandWaitDelay is how long a command's output pipes stay open after it
+// exits. A tool that forks a background helper, such as brew's auto-update,
+// leaves the helper holding the pipes, and without the delay the read would
+// wait for the helper too.
+var commandWaitDelay = 10 * time.Second
+
+// execRunner runs commands with os/exec.
+type execRunner struct{}
+
+func (execRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+func (r execRunner) Run(ctx context.Context, env []string, name string, args ...string) error {
+	return ignoreWaitDelay(r.command(ctx, env, name, args).Run())
+}
+
+func (r execRunner) Output(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	output, err := r.command(ctx, env, name, args).Output()
+	return output, ignoreWaitDelay(err)
+}
+
+func (r execRunner) CombinedOutput(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	output, err := r.command(ctx, env, name, args).CombinedOutput()
+	return output, ignoreWaitDelay(err)
+}
+
+func (execRunner) command(ctx context.Context, env []string, name string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// ignoreWaitDelay treats a command that succeeded but left its pipes to a
+// background child as successful; its output up to exit is kept.
+func ignoreWaitDelay(err error) error {
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCall is one command recorded by fakeRunner.
+type fakeCall struct {
+	Env  []string
+	Name string
+	Args []string
+}
+
+// String returns the command line, e.g. "docker image prune -f".
+func (c fakeCall) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// fakeResponse is the canned result for one command line.
+type fakeResponse struct {
+	Output string
+	Err    error
+}
+
+// fakeRunner records invocations and answers them from responses, keyed by
+// the full command line. Unlisted commands succeed with no output.
+type fakeRunner struct {
+	responses map[string]fakeResponse
+	missing   map[string]bool
+	calls     []fakeCall
+}
+
+// useFakeRunner installs a fakeRunner for the duration of the test.
+func useFakeRunner(t *testing.T, responses map[string]fakeResponse) *fakeRunner {
+	t.Helper()
+
+	fake := &fakeRunner{responses: responses, missing: map[string]bool{}}
+	previous := runner
+	runner = fake
+	t.Cleanup(func() { runner = previous })
+	return fake
+}
+
+func (f *fakeRunner) LookPath(file string) (string, error) {
+	if f.missing[file] {
+		return "", errors.New("executable file not found in $PATH")
+	}
+	return "/usr/bin/" + file, nil
+}
+
+func (f *fakeRunner) Run(ctx context.Context, env []string, name string, args ...string) error {
+	_, err := f.CombinedOutput(ctx, env, name, args...)
+	return err
+}
+
+func (f *fakeRunner) Output(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	return f.CombinedOutput(ctx, env, name, args...)
+}
+
+func (f *fakeRunner) CombinedOutput(_ context.Context, env []string, name string, args ...string) ([]byte, error) {
+	call := fakeCall{Env: env, Name: name, Args: append([]string(nil), args...)}
+	f.calls = append(f.calls, call)
+	response := f.responses[call.String()]
+	return []byte(response.Output), response.Err
+}
+
+// commandLines returns the recorded calls whose command line starts with prefix.
+func (f *fakeRunner) commandLines(prefix string) []string {
+	var lines []string
+	for _, call := range f.calls {
+		if line := call.String(); strings.HasPrefix(line, prefix) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestExecRunnerRunsCommands(t *testing.T) {
+	output, err := execRunner{}.CombinedOutput(context.Background(), []string{"TINYLAND_EXEC_TEST=ok"}, "sh", "-c", "echo $TINYLAND_EXEC_TEST")
+	if err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		t.Fatalf("expected extra env to reach the command, got %q", output)
+	}
+
+	if err := (execRunner{}).Run(context.Background(), nil, "sh", "-c", "exit 3"); err == nil {
+		t.Fatal("expected a failing command to return an error")
+	}
+}
+
+func TestExecRunnerDoesNotWaitForBackgroundChildren(t *testing.T) {
+	previous := commandWaitDelay
+	commandWaitDelay = 100 * time.Millisecond
+	t.Cleanup(func() { commandWaitDelay = previous })
+
+	start := time.Now()
+	output, err := execRunner{}.CombinedOutput(context.Background(), nil, "sh", "-c", "sleep 30 & echo done")
+	if err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "done" {
+		t.Fatalf("expected output up to exit, got %q", output)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("waited %s for the background child holding the pipe", elapsed)
+	}
+}
+
+func TestFakeRunnerRecordsCalls(t *testing.T) {
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"docker info": {Err: errors.New("daemon down")},
+	})
+
+	if err := runner.Run(context.Background(), nil, "docker", "info"); err == nil {
+		t.Fatal("expected canned error")
+	}
+	if _, err := runner.Output(context.Background(), []string{"A=1"}, "ps", "-ax"); err != nil {
+		t.Fatalf("unlisted command should succeed, got %v", err)
+	}
+	want := []fakeCall{
+		{Name: "docker", Args: []string{"info"}},
+		{Env: []string{"A=1"}, Name: "ps", Args: []string{"-ax"}},
+	}
+	if !reflect.DeepEqual(fake.calls, want) {
+		t.Fatalf("calls = %+v, want %+v", fake.calls, want)
+	}
+}
@@ -0,0 +1,403 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// ExternalTrashPlugin empties old items from the .Trashes/<uid> folders that
+// macOS creates on external volumes. Finder never empties them on its own
+// while the volume is not the boot volume, so they silently fill the disk.
+// The boot volume's ~/.Trash is only touched when explicitly requested.
+type ExternalTrashPlugin struct{}
+
+// externalVolumesRoot is where macOS mounts external volumes; only its
+// direct children are considered.
+var externalVolumesRoot = "/Volumes"
+
+// bootVolumePaths are the boot volume's mount points. A volume under
+// externalVolumesRoot on the same device, such as /Volumes/Macintosh HD, is
+// the boot volume and skipped.
+var bootVolumePaths = []string{"/", "/System/Volumes/Data"}
+
+// externalTrashFSTypes are the local filesystem types whose .Trashes are
+// emptied. Network, virtual, and read-only formats are excluded.
+var externalTrashFSTypes = []string{"apfs", "hfs", "exfat", "msdos"}
+
+// accessWrite is access(2)'s W_OK.
+const accessWrite = 0x2
+
+// externalTrashNow is the clock item ages are measured against.
+var externalTrashNow = time.Now
+
+// externalMount is one line of mount(8) output.
+type externalMount struct {
+	Device  string
+	Path    string
+	FSType  string
+	Options []string
+}
+
+// trashSweep totals the expired items found in one Trash folder.
+type trashSweep struct {
+	Items int
+	Bytes int64
+}
+
+// NewExternalTrashPlugin creates a new external volume Trash cleanup plugin.
+func NewExternalTrashPlugin() *ExternalTrashPlugin {
+	return &ExternalTrashPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *ExternalTrashPlugin) Name() string {
+	return "external-trash"
+}
+
+// Description returns the plugin description.
+func (p *ExternalTrashPlugin) Description() string {
+	return "Empties old items from .Trashes on external volumes"
+}
+
+// Priority runs external Trash cleanup with the other user file sweeps.
+func (p *ExternalTrashPlugin) Priority() int {
+	return 17
+}
+
+// SupportedPlatforms returns supported platforms (Darwin only).
+func (p *ExternalTrashPlugin) SupportedPlatforms() []string {
+	return []string{PlatformDarwin}
+}
+
+// Enabled checks if external Trash cleanup is enabled.
+func (p *ExternalTrashPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.ExternalTrash
+}
+
+// LevelDescription summarizes external Trash cleanup at each level.
+func (p *ExternalTrashPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports items older than external_trash.max_age_days in .Trashes on external volumes only"
+	case LevelModerate, LevelAggressive, LevelCritical:
+		return "empties items older than external_trash.max_age_days from .Trashes on writable local external volumes, and ~/.Trash when include_user_trash is set, unless a Time Machine backup is running"
+	default:
+		return "no cleanup"
+	}
+}
+
+// ValidateExternalTrashConfig rejects a non-positive max_age_days.
+func ValidateExternalTrashConfig(cfg config.ExternalTrashConfig) error {
+	if cfg.MaxAgeDays <= 0 {
+		return fmt.Errorf("external_trash.max_age_days must be positive, got %d", cfg.MaxAgeDays)
+	}
+	return nil
+}
+
+// parseMountOutput parses macOS mount(8) lines such as
+// "/dev/disk4s1 on /Volumes/My Disk (exfat, local, nodev, nosuid, noowners)".
+func parseMountOutput(output string) []externalMount {
+	var mounts []externalMount
+	for _, line := range strings.Split(output, "\n") {
+		on := strings.Index(line, " on ")
+		open := strings.LastIndex(line, " (")
+		if on < 0 || open < on || !strings.HasSuffix(line, ")") {
+			continue
+		}
+		fields := strings.Split(line[open+2:len(line)-1], ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		mounts = append(mounts, externalMount{
+			Device:  line[:on],
+			Path:    line[on+4 : open],
+			FSType:  fields[0],
+			Options: fields[1:],
+		})
+	}
+	return mounts
+}
+
+// externalMounts lists the mounted volumes from mount(8).
+func externalMounts(ctx context.Context) ([]externalMount, error) {
+	mountCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	output, err := runner.Output(mountCtx, nil, "mount")
+	if err != nil {
+		return nil, fmt.Errorf("mount: %w", err)
+	}
+	return parseMountOutput(string(output)), nil
+}
+
+// externalTrashRefusal explains why a mount's Trash may not be emptied, or
+// returns "" when it may. The mount must be a direct child of /Volumes, a
+// local writable filesystem of a known type, not the boot volume, and
+// writable by the running user.
+func externalTrashRefusal(mount externalMount) string {
+	if filepath.Dir(filepath.Clean(mount.Path)) != externalVolumesRoot {
+		return "not under " + externalVolumesRoot
+	}
+	if !slices.Contains(externalTrashFSTypes, mount.FSType) {
+		return "filesystem type " + mount.FSType
+	}
+	if !slices.Contains(mount.Options, "local") {
+		return "not a local volume"
+	}
+	if slices.Contains(mount.Options, "read-only") || slices.Contains(mount.Options, "rdonly") {
+		return "read-only volume"
+	}
+	dev, err := deviceID(mount.Path)
+	if err != nil {
+		return err.Error()
+	}
+	for _, boot := range bootVolumePaths {
+		if bootDev, err := deviceID(boot); err == nil && bootDev == dev {
+			return "boot volume"
+		}
+	}
+	if err := syscall.Access(mount.Path, accessWrite); err != nil {
+		return "volume not writable by this user"
+	}
+	return ""
+}
+
+// userTrashDir returns the running user's Trash folder on mount, refusing a
+// symlink or a folder owned by someone else.
+func userTrashDir(volume string) (string, error) {
+	uid := os.Getuid()
+	path := filepath.Join(volume, ".Trashes", strconv.Itoa(uid))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != uid {
+		return "", fmt.Errorf("%s is not owned by uid %d", path, uid)
+	}
+	return path, nil
+}
+
+// sweepTrash finds the top-level items of trash that were moved there before
+// cutoff, removing them when remove is set. An item's age is its inode
+// change time, which moving it into the Trash updates.
+func sweepTrash(trash string, cutoff time.Time, remove bool) (trashSweep, error) {
+	var sweep trashSweep
+	entries, err := os.ReadDir(trash)
+	if err != nil {
+		return sweep, err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(trash, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			walkErrors.note(err)
+			continue
+		}
+		if !changeTime(info).Before(cutoff) {
+			continue
+		}
+		size := accountedFileBytes(info)
+		if entry.IsDir() {
+			size = getDirSizeSameDevice(path)
+		}
+		if remove {
+			if err := os.RemoveAll(path); err != nil {
+				walkErrors.note(err)
+				continue
+			}
+		}
+		sweep.Items++
+		sweep.Bytes += size
+	}
+	return sweep, nil
+}
+
+// trashLocation is one Trash folder to sweep and the volume it is on.
+type trashLocation struct {
+	Volume string
+	Trash  string
+	// Refusal explains why the folder is skipped, when it is.
+	Refusal string
+}
+
+// externalTrashLocations returns the user's Trash folder on each mounted
+// external volume, and ~/.Trash when include_user_trash is set. Volumes
+// without a Trash folder for the user are omitted.
+func externalTrashLocations(ctx context.Context, cfg *config.Config) ([]trashLocation, error) {
+	mounts, err := externalMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locations []trashLocation
+	for _, mount := range mounts {
+		if filepath.Dir(filepath.Clean(mount.Path)) != externalVolumesRoot {
+			continue
+		}
+		location := trashLocation{Volume: mount.Path}
+		if location.Refusal = externalTrashRefusal(mount); location.Refusal == "" {
+			trash, err := userTrashDir(mount.Path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				location.Refusal = err.Error()
+			}
+			location.Trash = trash
+		}
+		locations = append(locations, location)
+	}
+	if cfg.ExternalTrash.IncludeUserTrash {
+		home, err := env.HomeDir()
+		if err != nil {
+			return locations, err
+		}
+		trash := filepath.Join(home, ".Trash")
+		if pathExistsAndIsDir(trash) {
+			locations = append(locations, trashLocation{Volume: "boot volume", Trash: trash})
+		}
+	}
+	return locations, nil
+}
+
+// timeMachineRunning reports whether tmutil says a backup is in progress.
+func timeMachineRunning(ctx context.Context) bool {
+	statusCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	output, err := runner.Output(statusCtx, nil, "tmutil", "status")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "Running = 1")
+}
+
+// PlanCleanup reports expired Trash items per volume.
+func (p *ExternalTrashPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "External volume Trash cleanup plan",
+		WouldRun: level >= LevelModerate,
+		Steps: []string{
+			fmt.Sprintf("Find items moved to .Trashes/%d more than %d days ago on local, writable external volumes under %s", os.Getuid(), cfg.ExternalTrash.MaxAgeDays, externalVolumesRoot),
+			"Skip the boot volume, network and read-only volumes, and every volume while a Time Machine backup is running",
+		},
+		Metadata: map[string]string{
+			"cleanup_level":      level.String(),
+			"max_age_days":       strconv.Itoa(cfg.ExternalTrash.MaxAgeDays),
+			"include_user_trash": strconv.FormatBool(cfg.ExternalTrash.IncludeUserTrash),
+		},
+	}
+	if level >= LevelModerate {
+		plan.Steps = append(plan.Steps, "Remove those items and report bytes freed per volume")
+	} else {
+		plan.SkipReason = "below_moderate_level"
+	}
+	if err := ValidateExternalTrashConfig(cfg.ExternalTrash); err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "invalid_config"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	backup := timeMachineRunning(ctx)
+	plan.Metadata["backup_active"] = strconv.FormatBool(backup)
+	if backup && level >= LevelModerate {
+		plan.WouldRun = false
+		plan.SkipReason = "time_machine_backup_active"
+	}
+
+	locations, err := externalTrashLocations(ctx, cfg)
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+	}
+	cutoff := externalTrashNow().AddDate(0, 0, -cfg.ExternalTrash.MaxAgeDays)
+	for _, location := range locations {
+		target := CleanupTarget{Type: "external-trash", Name: location.Volume, Path: location.Trash}
+		if location.Refusal != "" {
+			target.Path = location.Volume
+			target.Protected = true
+			target.Action = "skip"
+			target.Reason = location.Refusal
+			plan.Targets = append(plan.Targets, target)
+			continue
+		}
+		sweep, err := sweepTrash(location.Trash, cutoff, false)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", location.Trash, err))
+			continue
+		}
+		if sweep.Items == 0 {
+			continue
+		}
+		target.Bytes = sweep.Bytes
+		target.Action = "delete_trash_items"
+		target.Protected = !plan.WouldRun
+		target.Reason = fmt.Sprintf("%d items in the Trash for more than %d days", sweep.Items, cfg.ExternalTrash.MaxAgeDays)
+		if target.Protected {
+			target.Action = "report"
+		}
+		annotateCleanupTargetPolicy(&target, CleanupTierDestructive, hostReclaimForAction(target.Action))
+		plan.Targets = append(plan.Targets, target)
+		if !target.Protected {
+			plan.EstimatedBytesFreed += sweep.Bytes
+		}
+	}
+	return plan
+}
+
+// Cleanup empties expired Trash items at Moderate and above, logging bytes
+// freed per volume.
+func (p *ExternalTrashPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	if level < LevelModerate {
+		return result
+	}
+	if err := ValidateExternalTrashConfig(cfg.ExternalTrash); err != nil {
+		logger.Warn("skipping external Trash cleanup", "error", err)
+		return result
+	}
+	if timeMachineRunning(ctx) {
+		logger.Info("Time Machine backup in progress, skipping external Trash cleanup")
+		return result
+	}
+	locations, err := externalTrashLocations(ctx, cfg)
+	if err != nil {
+		logger.Warn("could not list external volumes", "error", err)
+	}
+
+	skipped := walkErrors.snapshot()
+	cutoff := externalTrashNow().AddDate(0, 0, -cfg.ExternalTrash.MaxAgeDays)
+	for _, location := range locations {
+		if ctx.Err() != nil {
+			break
+		}
+		if location.Refusal != "" {
+			logger.Debug("skipping volume Trash", "volume", location.Volume, "reason", location.Refusal)
+			continue
+		}
+		sweep, err := sweepTrash(location.Trash, cutoff, true)
+		if err != nil {
+			logger.Warn("external Trash cleanup failed", "path", location.Trash, "error", err)
+			continue
+		}
+		result.BytesFreed += sweep.Bytes
+		result.ItemsCleaned += sweep.Items
+		if sweep.Items > 0 {
+			logger.Info("emptied old Trash items", "volume", location.Volume, "items", sweep.Items, "freed_mb", sweep.Bytes/(1024*1024))
+		}
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
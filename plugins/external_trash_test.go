@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestParseMountOutput(t *testing.T) {
+	output := "/dev/disk3s1s1 on / (apfs, sealed, local, read-only, journaled)\n" +
+		"/dev/disk4s1 on /Volumes/My Disk (exfat, local, nodev, nosuid, noowners)\n" +
+		"//alice@nas/share on /Volumes/share (smbfs, nodev, nosuid, mounted by alice)\n" +
+		"map auto_home on /System/Volumes/Data/home (autofs, automounted, nobrowse)\n"
+	mounts := parseMountOutput(output)
+	if len(mounts) != 4 {
+		t.Fatalf("expected 4 mounts, got %+v", mounts)
+	}
+	disk := mounts[1]
+	if disk.Device != "/dev/disk4s1" || disk.Path != "/Volumes/My Disk" || disk.FSType != "exfat" || len(disk.Options) != 4 || disk.Options[0] != "local" {
+		t.Fatalf("unexpected mount: %+v", disk)
+	}
+	if mounts[3].Device != "map auto_home" || mounts[3].FSType != "autofs" {
+		t.Fatalf("unexpected autofs mount: %+v", mounts[3])
+	}
+}
+
+func TestExternalTrashCleanupEmptiesOnlyEligibleVolumes(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("HOME", filepath.Join(root, "home"))
+	uid := strconv.Itoa(os.Getuid())
+	write := func(rel string, size int) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	movie := write("Volumes/Ext/.Trashes/"+uid+"/Footage/clip.mov", 4096)
+	note := write("Volumes/Ext/.Trashes/"+uid+"/notes.txt", 100)
+	share := write("Volumes/share/.Trashes/"+uid+"/report.pdf", 200)
+	readOnly := write("Volumes/Archive/.Trashes/"+uid+"/old.zip", 300)
+	userTrash := write("home/.Trash/draft.key", 400)
+	if err := os.MkdirAll(filepath.Join(root, "Volumes", "Empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	previousRoot, previousBoot, previousNow := externalVolumesRoot, bootVolumePaths, externalTrashNow
+	externalVolumesRoot = filepath.Join(root, "Volumes")
+	bootVolumePaths = nil
+	externalTrashNow = func() time.Time { return time.Now().AddDate(0, 0, 40) }
+	t.Cleanup(func() {
+		externalVolumesRoot, bootVolumePaths, externalTrashNow = previousRoot, previousBoot, previousNow
+	})
+
+	volumes := externalVolumesRoot
+	mounts := "/dev/disk4s1 on " + volumes + "/Ext (exfat, local, nodev, nosuid, noowners)\n" +
+		"//alice@nas/share on " + volumes + "/share (smbfs, nodev, nosuid, mounted by alice)\n" +
+		"/dev/disk5s1 on " + volumes + "/Archive (apfs, local, read-only, journaled)\n" +
+		"/dev/disk6s1 on " + volumes + "/Empty (apfs, local, journaled)\n"
+	responses := map[string]fakeResponse{
+		"mount":         {Output: mounts},
+		"tmutil status": {Output: "Backup session status:\n{\n    Running = 0;\n}\n"},
+	}
+	useFakeRunner(t, responses)
+
+	cfg := config.DefaultConfig()
+	cfg.Enable.ExternalTrash = true
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewExternalTrashPlugin()
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if plan.EstimatedBytesFreed != 4196 || len(plan.Targets) != 3 {
+		t.Fatalf("unexpected plan: estimate %d, targets %+v", plan.EstimatedBytesFreed, plan.Targets)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 4196 || result.ItemsCleaned != 2 {
+		t.Fatalf("expected the Ext Trash emptied, got %+v", result)
+	}
+	if pathExists(movie) || pathExists(note) || !pathExistsAndIsDir(filepath.Join(volumes, "Ext", ".Trashes", uid)) {
+		t.Fatal("expected the Ext Trash items removed and the Trash folder kept")
+	}
+	for _, kept := range []string{share, readOnly, userTrash} {
+		if !pathExists(kept) {
+			t.Errorf("expected %s to be kept", kept)
+		}
+	}
+
+	cfg.ExternalTrash.IncludeUserTrash = true
+	responses["tmutil status"] = fakeResponse{Output: "Backup session status:\n{\n    BackupPhase = Copying;\n    Running = 1;\n}\n"}
+	if result := plugin.Cleanup(context.Background(), LevelCritical, cfg, logger); result.ItemsCleaned != 0 || !pathExists(userTrash) {
+		t.Fatalf("expected nothing removed during a backup, got %+v", result)
+	}
+	responses["tmutil status"] = fakeResponse{Output: "Running = 0;\n"}
+	if result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger); result.BytesFreed != 400 || pathExists(userTrash) {
+		t.Fatalf("expected ~/.Trash emptied when include_user_trash is set, got %+v", result)
+	}
+}
+
+func TestExternalTrashRefusesBootVolume(t *testing.T) {
+	root := t.TempDir()
+	volume := filepath.Join(root, "Macintosh HD")
+	if err := os.Mkdir(volume, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previousRoot, previousBoot := externalVolumesRoot, bootVolumePaths
+	externalVolumesRoot, bootVolumePaths = root, []string{root}
+	t.Cleanup(func() { externalVolumesRoot, bootVolumePaths = previousRoot, previousBoot })
+
+	if reason := externalTrashRefusal(externalMount{Path: volume, FSType: "apfs", Options: []string{"local"}}); reason != "boot volume" {
+		t.Fatalf("expected the boot volume to be refused, got %q", reason)
+	}
+	if reason := externalTrashRefusal(externalMount{Path: filepath.Join(root, "a", "b"), FSType: "apfs", Options: []string{"local"}}); reason == "" {
+		t.Fatal("expected a nested mount point to be refused")
+	}
+}
+
+func TestSweepTrashKeepsRecentItems(t *testing.T) {
+	trash := t.TempDir()
+	if err := os.WriteFile(filepath.Join(trash, "recent.txt"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Aging the modification time does not age the move into the Trash.
+	stamp := time.Now().AddDate(-1, 0, 0)
+	if err := os.Chtimes(filepath.Join(trash, "recent.txt"), stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	sweep, err := sweepTrash(trash, time.Now().Add(-time.Hour), true)
+	if err != nil || sweep.Items != 0 || !pathExists(filepath.Join(trash, "recent.txt")) {
+		t.Fatalf("expected a recently trashed item to be kept, got %+v, %v", sweep, err)
+	}
+}
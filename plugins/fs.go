@@ -4,12 +4,178 @@ package plugins
 
 import (
 	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
+// accountActualBlocks selects allocated-block accounting for deleted and
+// measured files. It is process-wide because the size helpers are shared
+// by every file-based plugin.
+var accountActualBlocks atomic.Bool
+
+// followSymlinkedCaches lets cache roots that are symlinks to another
+// filesystem be measured and cleaned through their target.
+var followSymlinkedCaches atomic.Bool
+
+// ConfigureAccounting applies the safety accounting settings to the shared
+// filesystem size helpers.
+func ConfigureAccounting(cfg config.SafetyConfig) {
+	accountActualBlocks.Store(cfg.AccountActualBlocks)
+	followSymlinkedCaches.Store(cfg.FollowSymlinkedCaches)
+}
+
+// walkErrors counts paths the shared walkers skipped because they could not
+// be read. It is process-wide like the walkers; plugins take a snapshot
+// before cleanup and report the difference.
+var walkErrors walkErrorCounts
+
+type walkErrorCounts struct {
+	permission atomic.Int64
+	other      atomic.Int64
+}
+
+// note records a path a walk skipped. Paths that vanished mid-walk are not
+// counted, since another process deleting them is expected.
+func (c *walkErrorCounts) note(err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case errors.Is(err, fs.ErrPermission):
+		c.permission.Add(1)
+	default:
+		c.other.Add(1)
+	}
+}
+
+func (c *walkErrorCounts) snapshot() walkSkips {
+	return walkSkips{permission: int(c.permission.Load()), other: int(c.other.Load())}
+}
+
+// walkSkips is a point-in-time count of skipped walk paths.
+type walkSkips struct {
+	permission int
+	other      int
+}
+
+// recordWalkSkips adds the paths skipped since before to result and logs a
+// summary, so unreadable directories do not silently shrink measured sizes.
+func recordWalkSkips(result *CleanupResult, before walkSkips, logger *slog.Logger) {
+	now := walkErrors.snapshot()
+	permission := now.permission - before.permission
+	other := now.other - before.other
+	result.WalkPermissionSkips += permission
+	result.WalkErrorSkips += other
+	if permission > 0 {
+		logger.Info("skipped paths due to permission errors", "plugin", result.Plugin, "paths", permission)
+	}
+	if other > 0 {
+		logger.Debug("skipped paths due to read errors", "plugin", result.Plugin, "paths", other)
+	}
+}
+
+// resolveCacheRoot returns the directory to walk for a cache root. A root
+// that is not a symlink is returned unchanged. A symlinked root resolves to
+// its target when the target is a directory on the link's own filesystem,
+// such as macOS /tmp, or on another filesystem when follow_symlinked_caches
+// is set. Targets that are a mount root or contain the home directory are
+// always refused, so a relocated cache never widens into a whole volume.
+func resolveCacheRoot(path string) (string, bool) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return path, true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+	if target, err := os.Stat(resolved); err != nil || !target.IsDir() {
+		return "", false
+	}
+	if unsafeCacheTarget(resolved) {
+		return "", false
+	}
+	if !followSymlinkedCaches.Load() {
+		linkDev, err := deviceID(filepath.Dir(path))
+		if err != nil {
+			return "", false
+		}
+		if targetDev, err := deviceID(resolved); err != nil || targetDev != linkDev {
+			return "", false
+		}
+	}
+	return resolved, true
+}
+
+// unsafeCacheTarget reports whether a symlink target is a filesystem root,
+// a top-level directory, a mount point, or the home directory or one of its
+// ancestors.
+func unsafeCacheTarget(path string) bool {
+	clean := filepath.Clean(path)
+	if clean == string(filepath.Separator) || filepath.Dir(clean) == string(filepath.Separator) {
+		return true
+	}
+	if dev, err := deviceID(clean); err != nil {
+		return true
+	} else if parentDev, err := deviceID(filepath.Dir(clean)); err != nil || parentDev != dev {
+		return true
+	}
+	if home, err := env.HomeDir(); err == nil {
+		if rel, err := filepath.Rel(clean, home); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCacheRoot deletes a cache directory. A symlinked root is emptied
+// through its target instead, so the link and the target directory survive;
+// a symlink refused by resolveCacheRoot is left untouched.
+func removeCacheRoot(path string) error {
+	root, ok := resolveCacheRoot(path)
+	if !ok {
+		return nil
+	}
+	if root == path {
+		return os.RemoveAll(path)
+	}
+	return removeDirContents(root)
+}
+
+// removeDirContents deletes everything inside dir, keeping dir itself.
+func removeDirContents(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// accountedFileBytes returns the bytes a file contributes to size and freed
+// accounting: allocated blocks when actual-block accounting is on, otherwise
+// the logical size.
+func accountedFileBytes(info os.FileInfo) int64 {
+	if !accountActualBlocks.Load() {
+		return info.Size()
+	}
+	return allocatedBytes(info)
+}
+
 // deviceID returns the device ID for a given path.
 // Used to detect mount point boundaries during traversal.
 func deviceID(path string) (uint64, error) {
@@ -21,13 +187,12 @@ func deviceID(path string) (uint64, error) {
 }
 
 // getDirSizeSameDevice calculates directory size without crossing mount boundaries.
-// It resolves the path first (following symlinks) and only counts files on
-// the same device as the root directory.
+// It resolves a symlinked root first (see resolveCacheRoot) and only counts
+// files on the same device as the resolved root directory.
 func getDirSizeSameDevice(path string) int64 {
-	// Resolve symlinks to get real path
-	resolved, err := filepath.EvalSymlinks(path)
-	if err != nil {
-		return getDirSize(path) // fallback to basic version
+	resolved, ok := resolveCacheRoot(path)
+	if !ok {
+		return 0
 	}
 
 	rootDev, err := deviceID(resolved)
@@ -38,6 +203,7 @@ func getDirSizeSameDevice(path string) int64 {
 	var size int64
 	filepath.Walk(resolved, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		// Check if this entry is on a different device (mount point)
@@ -48,7 +214,7 @@ func getDirSizeSameDevice(path string) int64 {
 			return nil
 		}
 		if !info.IsDir() {
-			size += info.Size()
+			size += accountedFileBytes(info)
 		}
 		return nil
 	})
@@ -61,10 +227,8 @@ func deleteOldFilesSameDevice(dir string, maxAge time.Duration) int64 {
 	cutoff := time.Now().Add(-maxAge)
 	var freed int64
 
-	resolved, err := filepath.EvalSymlinks(dir)
-	if err != nil {
-		// Fallback: use basic version
-		deleteOldFiles(dir, maxAge)
+	resolved, ok := resolveCacheRoot(dir)
+	if !ok {
 		return 0
 	}
 
@@ -76,6 +240,7 @@ func deleteOldFilesSameDevice(dir string, maxAge time.Duration) int64 {
 
 	filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		// Don't cross mount boundaries
@@ -86,7 +251,7 @@ func deleteOldFilesSameDevice(dir string, maxAge time.Duration) int64 {
 			return nil
 		}
 		if !info.IsDir() && info.ModTime().Before(cutoff) {
-			size := info.Size()
+			size := accountedFileBytes(info)
 			if os.Remove(path) == nil {
 				freed += size
 			}
@@ -99,12 +264,19 @@ func deleteOldFilesSameDevice(dir string, maxAge time.Duration) int64 {
 // deleteOldFilesOwnedByUserSameDevice deletes user-owned files older than
 // maxAge without crossing mount boundaries. Returns bytes freed.
 func deleteOldFilesOwnedByUserSameDevice(dir string, maxAge time.Duration) int64 {
+	return oldFilesOwnedByUserSameDevice(dir, maxAge, true)
+}
+
+// oldFilesOwnedByUserSameDevice totals the user-owned files older than maxAge
+// under dir on dir's filesystem, removing them when remove is set. Only
+// removed files count when removing.
+func oldFilesOwnedByUserSameDevice(dir string, maxAge time.Duration, remove bool) int64 {
 	cutoff := time.Now().Add(-maxAge)
 	uid := uint32(os.Getuid())
 	var freed int64
 
-	resolved, err := filepath.EvalSymlinks(dir)
-	if err != nil {
+	resolved, ok := resolveCacheRoot(dir)
+	if !ok {
 		return 0
 	}
 
@@ -115,6 +287,7 @@ func deleteOldFilesOwnedByUserSameDevice(dir string, maxAge time.Duration) int64
 
 	filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		// Don't cross mount boundaries
@@ -128,8 +301,8 @@ func deleteOldFilesOwnedByUserSameDevice(dir string, maxAge time.Duration) int64
 			// Check file ownership via syscall
 			var stat syscall.Stat_t
 			if syscall.Stat(path, &stat) == nil && stat.Uid == uid {
-				size := info.Size()
-				if os.Remove(path) == nil {
+				size := accountedFileBytes(info)
+				if !remove || os.Remove(path) == nil {
 					freed += size
 				}
 			}
@@ -156,13 +329,17 @@ func getFileAllocatedBytes(path string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	return allocatedBytes(info), nil
+}
 
+// allocatedBytes returns st_blocks in bytes, or the apparent size when the
+// filesystem does not report blocks.
+func allocatedBytes(info os.FileInfo) int64 {
 	stat, ok := info.Sys().(*syscall.Stat_t)
 	if !ok || stat.Blocks <= 0 {
-		return info.Size(), nil
+		return info.Size()
 	}
-
-	return stat.Blocks * 512, nil
+	return int64(stat.Blocks) * 512
 }
 
 func getDirAllocatedBytes(path string) int64 {
@@ -177,6 +354,7 @@ func getDirAllocatedBytesContext(ctx context.Context, path string) (int64, error
 			return err
 		}
 		if err != nil {
+			walkErrors.note(err)
 			return nil
 		}
 		if info.IsDir() {
@@ -206,6 +384,22 @@ func safeBytesDiff(before, after int64) int64 {
 	return diff
 }
 
+// measuredBytesDiff is safeBytesDiff for a target measured around a cleanup
+// step. Growth is logged at debug level and added to result.BytesGrown
+// instead of being dropped, so plugins that enlarge a cache are visible.
+func measuredBytesDiff(result *CleanupResult, logger *slog.Logger, path string, before, after int64) int64 {
+	if after > before {
+		grown := after - before
+		result.BytesGrown += grown
+		logger.Debug("cleanup target grew during cleanup",
+			"plugin", result.Plugin,
+			"path", path,
+			"grew_bytes", grown,
+		)
+	}
+	return safeBytesDiff(before, after)
+}
+
 // pathExists returns true if a path exists and is accessible.
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
@@ -217,3 +411,16 @@ func pathExistsAndIsDir(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && info.IsDir()
 }
+
+// parseDuKilobytes parses the size column of `du -sk` output into bytes.
+func parseDuKilobytes(output string) int64 {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0
+	}
+	kb, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || kb < 0 {
+		return 0
+	}
+	return kb * 1024
+}
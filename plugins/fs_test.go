@@ -0,0 +1,311 @@
+package plugins
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// writeSparseFile creates a file whose logical size far exceeds its allocated
+// blocks, standing in for an APFS-compressed file.
+func writeSparseFile(t *testing.T, path string, logical int64) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(make([]byte, 4096)); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := file.Truncate(logical); err != nil {
+		t.Fatalf("truncate %s: %v", path, err)
+	}
+}
+
+func withActualBlockAccounting(t *testing.T, enabled bool) {
+	t.Helper()
+
+	previous := accountActualBlocks.Load()
+	ConfigureAccounting(config.SafetyConfig{AccountActualBlocks: enabled})
+	t.Cleanup(func() { accountActualBlocks.Store(previous) })
+}
+
+func TestAccountedFileBytesUsesAllocatedBlocks(t *testing.T) {
+	const logical = 64 << 20
+	path := filepath.Join(t.TempDir(), "compressible.bin")
+	writeSparseFile(t, path, logical)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if allocatedBytes(info) >= logical {
+		t.Skip("filesystem does not report sparse allocation")
+	}
+
+	withActualBlockAccounting(t, false)
+	if got := accountedFileBytes(info); got != logical {
+		t.Fatalf("logical accounting = %d, want %d", got, logical)
+	}
+
+	withActualBlockAccounting(t, true)
+	if got := accountedFileBytes(info); got >= logical || got <= 0 {
+		t.Fatalf("actual-block accounting = %d, want 0 < bytes < %d", got, logical)
+	}
+}
+
+func TestDeleteOldFilesSameDeviceReportsActualBlocks(t *testing.T) {
+	const logical = 64 << 20
+	dir := t.TempDir()
+	path := filepath.Join(dir, "old.bin")
+	writeSparseFile(t, path, logical)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	allocated := allocatedBytes(info)
+	if allocated >= logical {
+		t.Skip("filesystem does not report sparse allocation")
+	}
+
+	withActualBlockAccounting(t, true)
+	if freed := deleteOldFilesSameDevice(dir, 24*time.Hour); freed != allocated {
+		t.Fatalf("freed = %d, want allocated %d (logical %d)", freed, allocated, logical)
+	}
+	if pathExists(path) {
+		t.Fatal("expected old file to be deleted")
+	}
+}
+
+func TestMeasuredBytesDiffRecordsGrowth(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	result := CleanupResult{Plugin: "cache"}
+
+	if freed := measuredBytesDiff(&result, logger, "/cache/cargo", 1000, 400); freed != 600 {
+		t.Fatalf("freed = %d, want 600", freed)
+	}
+	if result.BytesGrown != 0 || logs.Len() != 0 {
+		t.Fatalf("expected no growth for a shrinking target, got %d and logs %q", result.BytesGrown, logs.String())
+	}
+
+	// cargo cache --autoclean can rebuild its index and leave the cache larger.
+	if freed := measuredBytesDiff(&result, logger, "/cache/cargo", 1000, 1500); freed != 0 {
+		t.Fatalf("freed = %d, want 0 for a grown target", freed)
+	}
+	if freed := measuredBytesDiff(&result, logger, "/cache/brew", 200, 300); freed != 0 {
+		t.Fatalf("freed = %d, want 0 for a grown target", freed)
+	}
+	if result.BytesGrown != 600 {
+		t.Fatalf("BytesGrown = %d, want 600", result.BytesGrown)
+	}
+	if !strings.Contains(logs.String(), "grew during cleanup") || !strings.Contains(logs.String(), "path=/cache/brew") {
+		t.Fatalf("expected debug growth log, got %q", logs.String())
+	}
+}
+
+func withFollowSymlinkedCaches(t *testing.T, enabled bool) {
+	t.Helper()
+
+	previous := followSymlinkedCaches.Load()
+	followSymlinkedCaches.Store(enabled)
+	t.Cleanup(func() { followSymlinkedCaches.Store(previous) })
+}
+
+// writeAgedCacheFile writes an old and a fresh file into dir.
+func writeAgedCacheFile(t *testing.T, dir string) (oldPath string, freshPath string) {
+	t.Helper()
+
+	oldPath = filepath.Join(dir, "old.bin")
+	freshPath = filepath.Join(dir, "fresh.bin")
+	for _, path := range []string{oldPath, freshPath} {
+		if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(oldPath, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	return oldPath, freshPath
+}
+
+func TestSymlinkedCacheRootOnSameFilesystem(t *testing.T) {
+	withFollowSymlinkedCaches(t, false)
+	root := t.TempDir()
+	target := filepath.Join(root, "relocated", "pip")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	oldPath, freshPath := writeAgedCacheFile(t, target)
+	link := filepath.Join(root, "pip")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if got := getDirSize(link); got != 8192 {
+		t.Fatalf("getDirSize(link) = %d, want target size 8192", got)
+	}
+	deleteOldFiles(link, 24*time.Hour)
+	if pathExists(oldPath) || !pathExists(freshPath) {
+		t.Fatal("expected only the old file in the target to be deleted")
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected symlink to survive, got %v, %v", info, err)
+	}
+}
+
+func TestSymlinkedCacheRootOnOtherFilesystem(t *testing.T) {
+	otherFS, err := os.MkdirTemp("/dev/shm", "tinyland-cleanup-cache-")
+	if err != nil {
+		t.Skipf("no second filesystem available: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(otherFS) })
+	home := t.TempDir()
+	homeDev, _ := deviceID(home)
+	if otherDev, _ := deviceID(otherFS); otherDev == homeDev {
+		t.Skip("/dev/shm is on the same filesystem as the temp dir")
+	}
+
+	target := filepath.Join(otherFS, "Caches")
+	if err := os.MkdirAll(filepath.Join(target, "com.example"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	oldPath, freshPath := writeAgedCacheFile(t, target)
+	link := filepath.Join(home, "Caches")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	withFollowSymlinkedCaches(t, false)
+	if got := getDirSize(link); got != 0 {
+		t.Fatalf("getDirSize(link) = %d, want 0 without follow_symlinked_caches", got)
+	}
+	if got := deleteOldFilesSameDevice(link, 24*time.Hour); got != 0 || !pathExists(oldPath) {
+		t.Fatalf("expected cross-filesystem target to be left alone, freed %d", got)
+	}
+	if err := removeCacheRoot(link); err != nil || !pathExists(oldPath) {
+		t.Fatalf("expected removeCacheRoot to skip the target, got %v", err)
+	}
+
+	withFollowSymlinkedCaches(t, true)
+	if got := getDirSizeSameDevice(link); got != 8192 {
+		t.Fatalf("getDirSizeSameDevice(link) = %d, want 8192", got)
+	}
+	if got := deleteOldFilesSameDevice(link, 24*time.Hour); got != 4096 || pathExists(oldPath) || !pathExists(freshPath) {
+		t.Fatalf("expected only the old file to be deleted, freed %d", got)
+	}
+	if err := removeCacheRoot(link); err != nil {
+		t.Fatalf("removeCacheRoot: %v", err)
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected target emptied but kept, got %v, %v", entries, err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected symlink to survive, got %v, %v", info, err)
+	}
+}
+
+func TestResolveCacheRootRefusesBroadTargets(t *testing.T) {
+	withFollowSymlinkedCaches(t, true)
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolve temp dir: %v", err)
+	}
+	home := filepath.Join(root, "home", "jess")
+	if err := os.MkdirAll(home, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	env.Configure(home, "")
+	t.Cleanup(func() { env.Configure("", "") })
+
+	targets := map[string]string{
+		"filesystem root": "/",
+		"top-level dir":   "/usr",
+		"home":            home,
+		"home ancestor":   filepath.Dir(home),
+	}
+	if pathExistsAndIsDir("/dev/shm") {
+		targets["mount root"] = "/dev/shm"
+	}
+	for name, target := range targets {
+		link := filepath.Join(root, "link-"+strings.ReplaceAll(name, " ", "-"))
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatalf("symlink: %v", err)
+		}
+		if resolved, ok := resolveCacheRoot(link); ok {
+			t.Errorf("%s: expected symlink to %s to be refused, resolved %s", name, target, resolved)
+		}
+	}
+
+	cache := filepath.Join(home, "cache-target")
+	if err := os.MkdirAll(cache, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	link := filepath.Join(root, "link-cache")
+	if err := os.Symlink(cache, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if resolved, ok := resolveCacheRoot(link); !ok || resolved != cache {
+		t.Fatalf("resolveCacheRoot(link) = %s, %v; want %s", resolved, ok, cache)
+	}
+}
+
+func TestWalkSkipsCountUnreadableSubdir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root reads directories regardless of permissions")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.MkdirAll(filepath.Join(locked, "inner"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "readable"), make([]byte, 1024), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	before := walkErrors.snapshot()
+	if size := getDirSize(root); size < 1024 {
+		t.Fatalf("expected the readable file to be sized, got %d", size)
+	}
+	result := CleanupResult{Plugin: "cache"}
+	recordWalkSkips(&result, before, logger)
+
+	if result.WalkPermissionSkips != 1 || result.WalkErrorSkips != 0 {
+		t.Fatalf("expected one permission skip, got %d/%d", result.WalkPermissionSkips, result.WalkErrorSkips)
+	}
+	if !strings.Contains(logs.String(), "skipped paths due to permission errors") {
+		t.Fatalf("expected skipped summary in logs, got %q", logs.String())
+	}
+}
+
+func TestWalkErrorCountsClassifyErrors(t *testing.T) {
+	var counts walkErrorCounts
+	counts.note(&os.PathError{Op: "open", Path: "/x", Err: os.ErrPermission})
+	counts.note(&os.PathError{Op: "lstat", Path: "/y", Err: os.ErrNotExist})
+	counts.note(&os.PathError{Op: "readdirent", Path: "/z", Err: os.ErrInvalid})
+
+	got := counts.snapshot()
+	if got.permission != 1 || got.other != 1 {
+		t.Fatalf("expected one permission and one other error, got %+v", got)
+	}
+}
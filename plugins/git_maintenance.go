@@ -0,0 +1,383 @@
+package plugins
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+const (
+	gitMaintenanceDefaultMinGitDirMB  = 1024
+	gitMaintenanceDefaultActiveWithin = time.Hour
+	gitMaintenanceDefaultMaxDepth     = 4
+)
+
+// gitInProgressMarkers are .git entries that mean an operation such as a
+// rebase or merge is unfinished; maintenance must not run underneath it.
+var gitInProgressMarkers = []string{
+	"rebase-merge",
+	"rebase-apply",
+	"MERGE_HEAD",
+	"CHERRY_PICK_HEAD",
+	"REVERT_HEAD",
+	"BISECT_LOG",
+	"index.lock",
+	"gc.pid",
+}
+
+// gitMaintenanceSkipDirs are directory names never descended into while
+// looking for repositories.
+var gitMaintenanceSkipDirs = map[string]bool{
+	"node_modules": true,
+	".venv":        true,
+	"target":       true,
+	".zig-cache":   true,
+	".cache":       true,
+}
+
+// GitMaintenancePlugin runs git garbage collection in large repositories
+// under the dev-artifacts scan paths.
+type GitMaintenancePlugin struct {
+	gitPath string
+	now     func() time.Time
+}
+
+// gitRepoCandidate is one repository considered for maintenance.
+type gitRepoCandidate struct {
+	Path       string
+	GitDir     string
+	GitBytes   int64
+	SkipReason string
+}
+
+// NewGitMaintenancePlugin creates a new git repository maintenance plugin.
+func NewGitMaintenancePlugin() *GitMaintenancePlugin {
+	gitPath, _ := exec.LookPath("git")
+	return &GitMaintenancePlugin{gitPath: gitPath, now: time.Now}
+}
+
+// Name returns the plugin identifier.
+func (p *GitMaintenancePlugin) Name() string {
+	return "git-maintenance"
+}
+
+// Description returns the plugin description.
+func (p *GitMaintenancePlugin) Description() string {
+	return "Runs git gc, reflog expiry, and repacking in large idle repositories"
+}
+
+// Priority runs git maintenance after container prunes; gc keeps committed data.
+func (p *GitMaintenancePlugin) Priority() int {
+	return 35
+}
+
+// HeavyAt reports git maintenance as heavy; gc and repack are CPU-bound.
+func (p *GitMaintenancePlugin) HeavyAt(level CleanupLevel, cfg *config.Config) bool {
+	return true
+}
+
+// SupportedPlatforms returns supported platforms (all).
+func (p *GitMaintenancePlugin) SupportedPlatforms() []string {
+	return nil
+}
+
+// Enabled checks if git maintenance is enabled.
+func (p *GitMaintenancePlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.GitMaintenance
+}
+
+// LevelDescription summarizes git maintenance at each level.
+func (p *GitMaintenancePlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports large repositories without running git"
+	case LevelModerate:
+		return "runs git gc --auto in clean, idle repositories whose .git exceeds min_git_dir_mb"
+	case LevelAggressive, LevelCritical:
+		return "expires reflogs per git's expiry config, runs git gc --prune=now, and repacks with git repack -ad in clean, idle repositories whose .git exceeds min_git_dir_mb"
+	default:
+		return "no cleanup"
+	}
+}
+
+// PlanCleanup reports repository candidates and why any are skipped.
+func (p *GitMaintenancePlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	_ = logger
+
+	gmCfg := cfg.GitMaintenance
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Git repository maintenance plan",
+		WouldRun: level >= LevelModerate,
+		Steps:    gitMaintenanceSteps(level),
+		Metadata: map[string]string{
+			"min_git_dir_mb": strconv.Itoa(gitMaintenanceMinGitDirMB(gmCfg)),
+			"active_within":  gitMaintenanceActiveWithin(gmCfg).String(),
+			"max_depth":      strconv.Itoa(gitMaintenanceMaxDepth(gmCfg)),
+		},
+	}
+	if p.gitPath == "" {
+		plan.WouldRun = false
+		plan.SkipReason = "git_not_available"
+		plan.Summary = "git is not available"
+		return plan
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "Git maintenance skipped because the home directory is unavailable"
+		return plan
+	}
+
+	candidates := p.findCandidates(ctx, cfg, home)
+	action := "git_gc"
+	if level < LevelModerate {
+		action = "report"
+	}
+	for _, candidate := range candidates {
+		target := CleanupTarget{
+			Type:   "git_repository",
+			Name:   filepath.Base(candidate.Path),
+			Path:   candidate.GitDir,
+			Bytes:  candidate.GitBytes,
+			Action: action,
+		}
+		if candidate.SkipReason != "" {
+			target.Protected = true
+			target.Active = candidate.SkipReason == "recently_used"
+			target.Action = "protect"
+			target.Reason = candidate.SkipReason
+		}
+		annotateCleanupTargetPolicy(&target, CleanupTierSafe, CleanupReclaimNone)
+		if target.Action == "git_gc" {
+			target.Reclaim = CleanupReclaimHost
+			target.HostReclaimsSpace = hostReclaimExpectation(CleanupReclaimHost)
+		}
+		plan.Targets = append(plan.Targets, target)
+	}
+	plan.Metadata["repository_count"] = strconv.Itoa(len(candidates))
+	return plan
+}
+
+// Cleanup runs git maintenance in eligible repositories at Moderate and above.
+func (p *GitMaintenancePlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+
+	if level < LevelModerate {
+		return result
+	}
+	if p.gitPath == "" {
+		logger.Debug("git not available, skipping")
+		return result
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping git maintenance: home directory unavailable", "error", err)
+		return result
+	}
+
+	for _, candidate := range p.findCandidates(ctx, cfg, home) {
+		if ctx.Err() != nil {
+			break
+		}
+		if candidate.SkipReason != "" {
+			logger.Debug("skipping git repository", "repo", candidate.Path, "reason", candidate.SkipReason)
+			continue
+		}
+
+		if err := p.maintainRepo(ctx, candidate.Path, level); err != nil {
+			logger.Warn("git maintenance failed", "repo", candidate.Path, "error", err)
+			continue
+		}
+
+		after := getDirAllocatedBytes(candidate.GitDir)
+		freed := measuredBytesDiff(&result, logger, candidate.GitDir, candidate.GitBytes, after)
+		result.BytesFreed += freed
+		result.ItemsCleaned++
+		logger.Info("git repository maintained",
+			"repo", candidate.Path,
+			"git_dir_before_mb", candidate.GitBytes/(1024*1024),
+			"git_dir_after_mb", after/(1024*1024),
+			"freed_mb", freed/(1024*1024),
+		)
+	}
+
+	return result
+}
+
+func (p *GitMaintenancePlugin) maintainRepo(ctx context.Context, repo string, level CleanupLevel) error {
+	for _, args := range gitMaintenanceCommands(level) {
+		cmd := exec.CommandContext(ctx, p.gitPath, append([]string{"-C", repo}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return newCommandError(p.Name(), commandOperation(args), err, string(output)).withPath(repo)
+		}
+	}
+	return nil
+}
+
+// gitMaintenanceCommands returns the git arguments run in each repository.
+func gitMaintenanceCommands(level CleanupLevel) [][]string {
+	switch {
+	case level >= LevelAggressive:
+		return [][]string{
+			{"reflog", "expire", "--all"},
+			{"gc", "--prune=now", "--quiet"},
+			{"repack", "-a", "-d", "--quiet"},
+		}
+	case level == LevelModerate:
+		return [][]string{{"gc", "--auto", "--quiet"}}
+	default:
+		return nil
+	}
+}
+
+func gitMaintenanceSteps(level CleanupLevel) []string {
+	steps := []string{
+		"Find git repositories under dev_artifacts.scan_paths whose .git exceeds min_git_dir_mb",
+		"Skip repositories with a dirty working tree, an in-progress rebase/merge/cherry-pick/bisect, or a recently modified index",
+	}
+	for _, args := range gitMaintenanceCommands(level) {
+		steps = append(steps, "Run git "+strings.Join(args, " "))
+	}
+	return append(steps, "Report reclaimed .git bytes per repository")
+}
+
+// findCandidates walks the dev-artifacts scan paths for repositories whose
+// .git directory is over the size threshold and classifies each one.
+func (p *GitMaintenancePlugin) findCandidates(ctx context.Context, cfg *config.Config, home string) []gitRepoCandidate {
+	gmCfg := cfg.GitMaintenance
+	minBytes := int64(gitMaintenanceMinGitDirMB(gmCfg)) * 1024 * 1024
+	maxDepth := gitMaintenanceMaxDepth(gmCfg)
+	seen := make(map[string]bool)
+
+	var candidates []gitRepoCandidate
+	for _, scanPath := range cfg.DevArtifacts.ScanPaths {
+		root := expandHome(scanPath, home)
+		if !pathExistsAndIsDir(root) {
+			continue
+		}
+		for _, repo := range findGitRepos(ctx, root, maxDepth, cfg.DevArtifacts.ProtectPaths) {
+			if seen[repo] {
+				continue
+			}
+			seen[repo] = true
+
+			gitDir := filepath.Join(repo, ".git")
+			gitBytes := getDirAllocatedBytes(gitDir)
+			if gitBytes < minBytes {
+				continue
+			}
+			candidates = append(candidates, gitRepoCandidate{
+				Path:       repo,
+				GitDir:     gitDir,
+				GitBytes:   gitBytes,
+				SkipReason: p.skipReason(ctx, repo, gmCfg),
+			})
+		}
+	}
+	return candidates
+}
+
+// findGitRepos returns directories under root, up to maxDepth levels deep,
+// that contain a .git directory. It does not descend into repositories,
+// so nested checkouts and submodules are left alone.
+func findGitRepos(ctx context.Context, root string, maxDepth int, protectPaths []string) []string {
+	var repos []string
+	rootDepth := strings.Count(filepath.Clean(root), string(filepath.Separator))
+	_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != root && (gitMaintenanceSkipDirs[entry.Name()] || isProtectedPath(path, protectPaths)) {
+			return filepath.SkipDir
+		}
+		if pathExistsAndIsDir(filepath.Join(path, ".git")) {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+		if strings.Count(filepath.Clean(path), string(filepath.Separator))-rootDepth >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos
+}
+
+// skipReason returns why maintenance must not run in repo, or "".
+func (p *GitMaintenancePlugin) skipReason(ctx context.Context, repo string, cfg config.GitMaintenanceConfig) string {
+	gitDir := filepath.Join(repo, ".git")
+	for _, marker := range gitInProgressMarkers {
+		if pathExists(filepath.Join(gitDir, marker)) {
+			return "operation_in_progress"
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+		if p.currentTime().Sub(info.ModTime()) < gitMaintenanceActiveWithin(cfg) {
+			return "recently_used"
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, p.gitPath, "-C", repo, "status", "--porcelain", "--untracked-files=no")
+	output, err := cmd.Output()
+	if err != nil {
+		return "status_failed"
+	}
+	if strings.TrimSpace(string(output)) != "" {
+		return "dirty_worktree"
+	}
+	return ""
+}
+
+func (p *GitMaintenancePlugin) currentTime() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// isProtectedPath reports whether path falls under one of protectPaths.
+func isProtectedPath(path string, protectPaths []string) bool {
+	for _, protect := range protectPaths {
+		if protect != "" && strings.HasPrefix(path, protect) {
+			return true
+		}
+	}
+	return false
+}
+
+func gitMaintenanceMinGitDirMB(cfg config.GitMaintenanceConfig) int {
+	if cfg.MinGitDirMB <= 0 {
+		return gitMaintenanceDefaultMinGitDirMB
+	}
+	return cfg.MinGitDirMB
+}
+
+func gitMaintenanceActiveWithin(cfg config.GitMaintenanceConfig) time.Duration {
+	duration, err := time.ParseDuration(cfg.ActiveWithin)
+	if err != nil || duration < 0 {
+		return gitMaintenanceDefaultActiveWithin
+	}
+	return duration
+}
+
+func gitMaintenanceMaxDepth(cfg config.GitMaintenanceConfig) int {
+	if cfg.MaxDepth <= 0 {
+		return gitMaintenanceDefaultMaxDepth
+	}
+	return cfg.MaxDepth
+}
@@ -0,0 +1,150 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func initTestRepo(t *testing.T, git string, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	runGit(t, git, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("write README: %v", err)
+	}
+	runGit(t, git, dir, "add", "README")
+	runGit(t, git, dir, "-c", "user.email=test@example.com", "-c", "user.name=test", "commit", "-q", "-m", "init")
+}
+
+func newTestGitMaintenancePlugin(git string, now time.Time) *GitMaintenancePlugin {
+	return &GitMaintenancePlugin{gitPath: git, now: func() time.Time { return now }}
+}
+
+func TestGitMaintenancePluginInterface(t *testing.T) {
+	p := NewGitMaintenancePlugin()
+	if p.Name() != "git-maintenance" {
+		t.Errorf("expected name 'git-maintenance', got %q", p.Name())
+	}
+	if p.SupportedPlatforms() != nil {
+		t.Error("expected git maintenance to support all platforms")
+	}
+	cfg := config.DefaultConfig()
+	if p.Enabled(cfg) {
+		t.Error("expected git maintenance to be opt-in")
+	}
+	cfg.Enable.GitMaintenance = true
+	if !p.Enabled(cfg) {
+		t.Error("expected git maintenance to be enabled by flag")
+	}
+}
+
+func TestGitMaintenanceCommandsByLevel(t *testing.T) {
+	if got := gitMaintenanceCommands(LevelWarning); len(got) != 0 {
+		t.Fatalf("warning commands = %v, want none", got)
+	}
+	if got := gitMaintenanceCommands(LevelModerate); len(got) != 1 || strings.Join(got[0], " ") != "gc --auto --quiet" {
+		t.Fatalf("moderate commands = %v", got)
+	}
+	var aggressive []string
+	for _, args := range gitMaintenanceCommands(LevelAggressive) {
+		aggressive = append(aggressive, strings.Join(args, " "))
+	}
+	want := "reflog expire --all|gc --prune=now --quiet|repack -a -d --quiet"
+	if got := strings.Join(aggressive, "|"); got != want {
+		t.Fatalf("aggressive commands = %q, want %q", got, want)
+	}
+}
+
+func TestFindGitReposStopsAtRepositoriesAndProtectedPaths(t *testing.T) {
+	git := requireGit(t)
+	root := t.TempDir()
+	initTestRepo(t, git, filepath.Join(root, "app"))
+	initTestRepo(t, git, filepath.Join(root, "app", "vendor", "nested"))
+	initTestRepo(t, git, filepath.Join(root, "group", "lib"))
+	initTestRepo(t, git, filepath.Join(root, "keep", "pinned"))
+	initTestRepo(t, git, filepath.Join(root, "a", "b", "c", "d", "deep"))
+
+	repos := findGitRepos(context.Background(), root, 4, []string{filepath.Join(root, "keep")})
+	var names []string
+	for _, repo := range repos {
+		rel, _ := filepath.Rel(root, repo)
+		names = append(names, rel)
+	}
+	if got := strings.Join(names, ","); got != "app,group/lib" {
+		t.Fatalf("repos = %q, want app,group/lib", got)
+	}
+}
+
+func TestGitMaintenanceSkipReasons(t *testing.T) {
+	git := requireGit(t)
+	later := time.Now().Add(2 * time.Hour)
+	cfg := config.GitMaintenanceConfig{ActiveWithin: "1h"}
+
+	repo := filepath.Join(t.TempDir(), "repo")
+	initTestRepo(t, git, repo)
+
+	if got := newTestGitMaintenancePlugin(git, time.Now()).skipReason(context.Background(), repo, cfg); got != "recently_used" {
+		t.Fatalf("fresh index skip reason = %q, want recently_used", got)
+	}
+
+	p := newTestGitMaintenancePlugin(git, later)
+	if got := p.skipReason(context.Background(), repo, cfg); got != "" {
+		t.Fatalf("idle clean repo skip reason = %q, want none", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("changed\n"), 0644); err != nil {
+		t.Fatalf("modify README: %v", err)
+	}
+	if got := p.skipReason(context.Background(), repo, cfg); got != "dirty_worktree" {
+		t.Fatalf("dirty repo skip reason = %q, want dirty_worktree", got)
+	}
+	runGit(t, git, repo, "checkout", "--", "README")
+
+	if err := os.WriteFile(filepath.Join(repo, ".git", "MERGE_HEAD"), []byte("0000000000000000000000000000000000000000\n"), 0644); err != nil {
+		t.Fatalf("write MERGE_HEAD: %v", err)
+	}
+	if got := p.skipReason(context.Background(), repo, cfg); got != "operation_in_progress" {
+		t.Fatalf("merging repo skip reason = %q, want operation_in_progress", got)
+	}
+}
+
+func TestGitMaintenancePlanHonorsSizeThreshold(t *testing.T) {
+	git := requireGit(t)
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	initTestRepo(t, git, filepath.Join(root, "small"))
+	large := filepath.Join(root, "large")
+	initTestRepo(t, git, large)
+	padding := make([]byte, 2<<20)
+	for i := range padding {
+		padding[i] = byte(i * 31)
+	}
+	if err := os.WriteFile(filepath.Join(large, ".git", "padding.pack"), padding, 0644); err != nil {
+		t.Fatalf("write padding: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.DevArtifacts.ScanPaths = []string{root}
+	cfg.GitMaintenance.MinGitDirMB = 1
+
+	plan := newTestGitMaintenancePlugin(git, time.Now().Add(2*time.Hour)).PlanCleanup(context.Background(), LevelAggressive, cfg, slog.Default())
+	if len(plan.Targets) != 1 {
+		t.Fatalf("expected only the large repository, got %+v", plan.Targets)
+	}
+	target := plan.Targets[0]
+	if target.Path != filepath.Join(large, ".git") || target.Action != "git_gc" || target.Protected {
+		t.Fatalf("unexpected target %+v", target)
+	}
+	if plan.Metadata["repository_count"] != "1" {
+		t.Fatalf("repository_count = %q", plan.Metadata["repository_count"])
+	}
+}
@@ -31,6 +31,11 @@ func (p *GitHubRunnerPlugin) Description() string {
 	return "Cleans GitHub Actions runner work directories, cache, and temporary files"
 }
 
+// Priority runs runner work-directory cleanup after container prunes.
+func (p *GitHubRunnerPlugin) Priority() int {
+	return 30
+}
+
 // SupportedPlatforms returns supported platforms (Linux only).
 func (p *GitHubRunnerPlugin) SupportedPlatforms() []string {
 	return []string{"linux"}
@@ -41,6 +46,22 @@ func (p *GitHubRunnerPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.GitHubRunner
 }
 
+// LevelDescription summarizes GitHub runner cleanup at each level.
+func (p *GitHubRunnerPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "removes runner temp files and /tmp action artifacts older than 24h"
+	case LevelModerate:
+		return "adds runner cache files older than 3 days and work directories older than 1 day"
+	case LevelAggressive:
+		return "adds all work directories and runner-labelled Docker containers and volumes"
+	case LevelCritical:
+		return "adds removal of the entire runner cache directory"
+	default:
+		return "no cleanup"
+	}
+}
+
 // githubRunnerPaths returns the set of directories to clean.
 // Uses config if available, falls back to well-known defaults.
 func (p *GitHubRunnerPlugin) githubRunnerPaths(cfg *config.Config) (runnerHome, workDir, cacheDir, tempDir string) {
@@ -107,7 +128,7 @@ func (p *GitHubRunnerPlugin) Cleanup(ctx context.Context, level CleanupLevel, cf
 			sizeBefore := getDirSizeSameDevice(cacheDir)
 			deleteOldFilesSameDevice(cacheDir, 3*24*time.Hour)
 			sizeAfter := getDirSizeSameDevice(cacheDir)
-			freed := safeBytesDiff(sizeBefore, sizeAfter)
+			freed := measuredBytesDiff(&result, logger, cacheDir, sizeBefore, sizeAfter)
 			result.BytesFreed += freed
 			if freed > 0 {
 				logger.Debug("cleaned github runner cache", "freed_mb", freed/(1024*1024))
@@ -3,7 +3,6 @@ package plugins
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -13,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 // GitLabRunnerPlugin cleans up GitLab runner caches and build artifacts.
@@ -33,6 +33,11 @@ func (p *GitLabRunnerPlugin) Description() string {
 	return "Cleans GitLab runner caches, build directories, and stale artifacts"
 }
 
+// Priority runs runner cache cleanup after container prunes.
+func (p *GitLabRunnerPlugin) Priority() int {
+	return 30
+}
+
 // SupportedPlatforms returns platforms this plugin supports (all platforms).
 func (p *GitLabRunnerPlugin) SupportedPlatforms() []string {
 	return []string{} // Empty means all platforms
@@ -43,13 +48,29 @@ func (p *GitLabRunnerPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.GitLabRunner
 }
 
+// LevelDescription summarizes GitLab runner cleanup at each level.
+func (p *GitLabRunnerPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "clears runner download caches"
+	case LevelModerate:
+		return "clears download caches and build directories older than 7 days"
+	case LevelAggressive:
+		return "clears download caches, build directories older than 1 day, and runner Docker caches"
+	case LevelCritical:
+		return "clears download caches, all build directories, runner Docker caches, and all runner caches"
+	default:
+		return "no cleanup"
+	}
+}
+
 // Cleanup performs GitLab runner cleanup at the specified level.
 func (p *GitLabRunnerPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name()}
 
-	home, err := os.UserHomeDir()
+	home, err := env.HomeDir()
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get home directory: %w", err)
+		result.Error = newPluginError(p.Name(), "home_lookup", err)
 		return result
 	}
 
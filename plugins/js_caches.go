@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jsPackageCache is a JavaScript package manager's global cache. When the
+// package manager is installed its own clean command runs, since it knows
+// which entries are still referenced; otherwise the cache directories are
+// deleted.
+type jsPackageCache struct {
+	Name string
+	// Command is the package manager's clean command.
+	Command []string
+	// Paths are the cache locations across platforms and releases.
+	Paths []string
+}
+
+// jsPackageCaches returns the pnpm store and the yarn global cache.
+func jsPackageCaches(home string) []jsPackageCache {
+	return []jsPackageCache{
+		{
+			Name:    "pnpm",
+			Command: []string{"pnpm", "store", "prune"},
+			Paths: []string{
+				filepath.Join(home, ".local", "share", "pnpm", "store"),
+				filepath.Join(home, "Library", "pnpm", "store"),
+			},
+		},
+		{
+			Name:    "yarn",
+			Command: []string{"yarn", "cache", "clean"},
+			Paths: []string{
+				filepath.Join(home, ".yarn", "cache"),
+				filepath.Join(home, ".cache", "yarn"),
+			},
+		},
+	}
+}
+
+// existingPaths returns the cache's paths that exist.
+func (c jsPackageCache) existingPaths() []string {
+	var paths []string
+	for _, path := range c.Paths {
+		if pathExistsAndIsDir(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// cleanJSPackageCaches cleans the pnpm and yarn caches, measuring bytes freed
+// as the shrink of their directories.
+func cleanJSPackageCaches(ctx context.Context, home string, result *CleanupResult, logger *slog.Logger) {
+	for _, cache := range jsPackageCaches(home) {
+		paths := cache.existingPaths()
+		if len(paths) == 0 {
+			continue
+		}
+		sizeBefore := int64(0)
+		for _, path := range paths {
+			sizeBefore += getDirSize(path)
+		}
+		if sizeBefore == 0 {
+			continue
+		}
+
+		if !runJSPackageCacheCommand(ctx, cache, logger) {
+			for _, path := range paths {
+				if err := removeCacheRoot(path); err != nil {
+					logger.Warn("could not fully remove package cache", "cache", cache.Name, "path", path, "error", err)
+				}
+			}
+		}
+
+		sizeAfter := int64(0)
+		for _, path := range paths {
+			sizeAfter += getDirSize(path)
+		}
+		freed := measuredBytesDiff(result, logger, strings.Join(paths, ","), sizeBefore, sizeAfter)
+		result.BytesFreed += freed
+		logger.Debug("cleaned "+cache.Name+" cache", "freed_mb", freed/(1024*1024))
+	}
+}
+
+// runJSPackageCacheCommand runs the cache's clean command and reports whether
+// it ran successfully. A missing binary or a failed command returns false so
+// the caller deletes the directories instead.
+func runJSPackageCacheCommand(ctx context.Context, cache jsPackageCache, logger *slog.Logger) bool {
+	if _, err := runner.LookPath(cache.Command[0]); err != nil {
+		return false
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	output, err := runner.CombinedOutput(cmdCtx, nil, cache.Command[0], cache.Command[1:]...)
+	if err != nil {
+		logger.Warn("package cache clean command failed; deleting the cache instead",
+			"command", strings.Join(cache.Command, " "), "error", err, "output", strings.TrimSpace(string(output)))
+		return false
+	}
+	return true
+}
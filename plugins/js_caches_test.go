@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanJSPackageCachesDeletesWithoutPackageManagers(t *testing.T) {
+	home := t.TempDir()
+	fake := useFakeRunner(t, nil)
+	fake.missing["pnpm"] = true
+	fake.missing["yarn"] = true
+
+	pnpmStore := filepath.Join(home, ".local", "share", "pnpm", "store")
+	yarnCache := filepath.Join(home, ".cache", "yarn")
+	writeAgedFile(t, filepath.Join(pnpmStore, "v3", "files", "00", "blob"), 4096, 0)
+	writeAgedFile(t, filepath.Join(yarnCache, "v6", "npm-left-pad.tgz"), 4096, 0)
+
+	result := CleanupResult{Plugin: "cache"}
+	cleanJSPackageCaches(context.Background(), home, &result, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if result.BytesFreed < 8192 {
+		t.Fatalf("expected both caches to be counted, got %d bytes", result.BytesFreed)
+	}
+	for _, path := range []string{pnpmStore, yarnCache} {
+		if entries, _ := os.ReadDir(path); len(entries) != 0 {
+			t.Fatalf("expected %s to be emptied, got %d entries", path, len(entries))
+		}
+	}
+	if calls := fake.commandLines("pnpm"); len(calls) != 0 {
+		t.Fatalf("expected no pnpm command without the binary, got %v", calls)
+	}
+}
+
+func TestCleanJSPackageCachesPrefersPackageManagerCommands(t *testing.T) {
+	home := t.TempDir()
+	fake := useFakeRunner(t, nil)
+
+	pnpmStore := filepath.Join(home, "Library", "pnpm", "store")
+	yarnCache := filepath.Join(home, ".yarn", "cache")
+	pnpmBlob := filepath.Join(pnpmStore, "v3", "files", "00", "blob")
+	yarnArchive := filepath.Join(yarnCache, "left-pad.zip")
+	writeAgedFile(t, pnpmBlob, 4096, 0)
+	writeAgedFile(t, yarnArchive, 4096, 0)
+
+	result := CleanupResult{Plugin: "cache"}
+	cleanJSPackageCaches(context.Background(), home, &result, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if got := fake.commandLines("pnpm"); len(got) != 1 || got[0] != "pnpm store prune" {
+		t.Fatalf("expected pnpm store prune, got %v", got)
+	}
+	if got := fake.commandLines("yarn"); len(got) != 1 || got[0] != "yarn cache clean" {
+		t.Fatalf("expected yarn cache clean, got %v", got)
+	}
+	// The fake commands remove nothing, so nothing is deleted or counted.
+	for _, path := range []string{pnpmBlob, yarnArchive} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to be left to the package manager: %v", path, err)
+		}
+	}
+	if result.BytesFreed != 0 {
+		t.Fatalf("expected no bytes freed by no-op commands, got %d", result.BytesFreed)
+	}
+}
+
+func TestCleanJSPackageCachesFallsBackWhenCommandFails(t *testing.T) {
+	home := t.TempDir()
+	useFakeRunner(t, map[string]fakeResponse{
+		"yarn cache clean": {Err: errors.New("exit status 1"), Output: "error Couldn't find a cache folder"},
+	})
+
+	yarnCache := filepath.Join(home, ".cache", "yarn")
+	writeAgedFile(t, filepath.Join(yarnCache, "v6", "npm-left-pad.tgz"), 4096, 0)
+
+	result := CleanupResult{Plugin: "cache"}
+	cleanJSPackageCaches(context.Background(), home, &result, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if result.BytesFreed < 4096 {
+		t.Fatalf("expected the yarn cache to be deleted after the command failed, got %d bytes", result.BytesFreed)
+	}
+}
@@ -0,0 +1,314 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// JunkFilesPlugin removes OS clutter such as .DS_Store and Thumbs.db under
+// the scan paths. The files are regenerated on demand, but .DS_Store also
+// holds Finder view settings, so the plugin is opt-in.
+type JunkFilesPlugin struct{}
+
+// junkPattern is one junk_files.patterns entry. A trailing slash makes it
+// match directories, which are removed with their contents.
+type junkPattern struct {
+	glob string
+	dir  bool
+}
+
+// junkSweep is what one pass over a scan path found or removed.
+type junkSweep struct {
+	Files int
+	Dirs  int
+	Bytes int64
+}
+
+// NewJunkFilesPlugin creates a new junk file cleanup plugin.
+func NewJunkFilesPlugin() *JunkFilesPlugin {
+	return &JunkFilesPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *JunkFilesPlugin) Name() string {
+	return "junk-files"
+}
+
+// Description returns the plugin description.
+func (p *JunkFilesPlugin) Description() string {
+	return "Removes .DS_Store, Thumbs.db, and similar OS clutter under scan paths"
+}
+
+// Priority runs junk removal with the other cheap cache clears.
+func (p *JunkFilesPlugin) Priority() int {
+	return 10
+}
+
+// SupportedPlatforms returns supported platforms (all).
+func (p *JunkFilesPlugin) SupportedPlatforms() []string {
+	return nil
+}
+
+// Enabled checks if junk file cleanup is enabled.
+func (p *JunkFilesPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.JunkFiles
+}
+
+// LevelDescription summarizes junk file cleanup at each level.
+func (p *JunkFilesPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelModerate, LevelAggressive, LevelCritical:
+		return "removes files matching junk_files.patterns under the scan paths, outside dev_artifacts.protect_paths"
+	default:
+		return "no cleanup"
+	}
+}
+
+// ValidateJunkFilePatterns rejects junk_files.patterns entries that are not
+// a single name glob, or that would match every name.
+func ValidateJunkFilePatterns(patterns []string) error {
+	_, err := parseJunkPatterns(patterns)
+	return err
+}
+
+func parseJunkPatterns(patterns []string) ([]junkPattern, error) {
+	parsed := make([]junkPattern, 0, len(patterns))
+	for i, raw := range patterns {
+		pattern := junkPattern{glob: strings.TrimSuffix(raw, "/"), dir: strings.HasSuffix(raw, "/")}
+		if pattern.glob == "" || strings.ContainsRune(pattern.glob, '/') {
+			return nil, fmt.Errorf("junk_files.patterns[%d] %q must be a single file name or glob", i, raw)
+		}
+		if _, err := filepath.Match(pattern.glob, ""); err != nil {
+			return nil, fmt.Errorf("junk_files.patterns[%d] %q: %w", i, raw, err)
+		}
+		if strings.Trim(pattern.glob, "*?") == "" {
+			return nil, fmt.Errorf("junk_files.patterns[%d] %q matches every name", i, raw)
+		}
+		parsed = append(parsed, pattern)
+	}
+	return parsed, nil
+}
+
+func junkPatternMatch(patterns []junkPattern, name string, dir bool) bool {
+	for _, pattern := range patterns {
+		if pattern.dir != dir {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern.glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// junkScanPaths returns junk_files.scan_paths, or dev_artifacts.scan_paths
+// when none are set, with protect paths, all expanded against home.
+func junkScanPaths(cfg *config.Config, home string) ([]string, []string) {
+	scanPaths := cfg.JunkFiles.ScanPaths
+	if len(scanPaths) == 0 {
+		scanPaths = cfg.DevArtifacts.ScanPaths
+	}
+	roots := make([]string, 0, len(scanPaths))
+	for _, path := range scanPaths {
+		roots = append(roots, expandHome(path, home))
+	}
+	protect := make([]string, 0, len(cfg.DevArtifacts.ProtectPaths))
+	for _, path := range cfg.DevArtifacts.ProtectPaths {
+		protect = append(protect, expandHome(path, home))
+	}
+	return roots, protect
+}
+
+// sweepJunkFiles finds junk under root on root's filesystem, outside protect
+// paths and .git directories, removing it when remove is set. Only regular
+// files match file patterns; symlinks are never followed or removed.
+func sweepJunkFiles(ctx context.Context, root string, patterns []junkPattern, protect []string, remove bool) (junkSweep, error) {
+	var sweep junkSweep
+	rootDev, err := deviceID(root)
+	if err != nil {
+		return sweep, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if path != root && isProtectedPath(path, protect) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				walkErrors.note(err)
+				return filepath.SkipDir
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); !ok || uint64(stat.Dev) != rootDev || entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !junkPatternMatch(patterns, entry.Name(), true) {
+				return nil
+			}
+			size := getDirSizeSameDevice(path)
+			if remove {
+				if err := os.RemoveAll(path); err != nil {
+					walkErrors.note(err)
+					return filepath.SkipDir
+				}
+			}
+			sweep.Dirs++
+			sweep.Bytes += size
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() || !junkPatternMatch(patterns, entry.Name(), false) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if remove {
+			if err := os.Remove(path); err != nil {
+				walkErrors.note(err)
+				return nil
+			}
+		}
+		sweep.Files++
+		sweep.Bytes += accountedFileBytes(info)
+		return nil
+	})
+	return sweep, err
+}
+
+// PlanCleanup reports the junk under each scan path.
+func (p *JunkFilesPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Junk file cleanup plan",
+		WouldRun: level >= LevelModerate,
+		Steps: []string{
+			"Find files matching junk_files.patterns under the scan paths, staying on each path's filesystem",
+			"Skip dev_artifacts.protect_paths and .git directories",
+		},
+		Metadata: map[string]string{
+			"patterns": strings.Join(cfg.JunkFiles.Patterns, ","),
+		},
+	}
+	if level >= LevelModerate {
+		plan.Steps = append(plan.Steps, "Remove the matches and report their count and bytes")
+	} else {
+		plan.SkipReason = "below_moderate_level"
+	}
+
+	patterns, err := parseJunkPatterns(cfg.JunkFiles.Patterns)
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "invalid_patterns"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	roots, protect := junkScanPaths(cfg, home)
+	var files int
+	for _, root := range roots {
+		if !pathExistsAndIsDir(root) {
+			continue
+		}
+		sweep, err := sweepJunkFiles(ctx, root, patterns, protect, false)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", root, err))
+			continue
+		}
+		if sweep.Files+sweep.Dirs == 0 {
+			continue
+		}
+		target := CleanupTarget{
+			Type:      "junk-files",
+			Name:      filepath.Base(root),
+			Path:      root,
+			Bytes:     sweep.Bytes,
+			Action:    "delete_junk_files",
+			Protected: level < LevelModerate,
+			Reason:    fmt.Sprintf("%d files and %d directories match junk_files.patterns", sweep.Files, sweep.Dirs),
+		}
+		if target.Protected {
+			target.Action = "report"
+		}
+		annotateCleanupTargetPolicy(&target, CleanupTierSafe, hostReclaimForAction(target.Action))
+		plan.Targets = append(plan.Targets, target)
+		files += sweep.Files + sweep.Dirs
+		if level >= LevelModerate {
+			plan.EstimatedBytesFreed += sweep.Bytes
+		}
+	}
+	plan.Metadata["match_count"] = strconv.Itoa(files)
+	return plan
+}
+
+// Cleanup removes junk files under the scan paths at Moderate and above.
+func (p *JunkFilesPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	if level < LevelModerate {
+		return result
+	}
+	patterns, err := parseJunkPatterns(cfg.JunkFiles.Patterns)
+	if err != nil {
+		logger.Warn("skipping junk file cleanup", "error", err)
+		return result
+	}
+
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping junk file cleanup: home directory unavailable", "error", err)
+		return result
+	}
+
+	skipped := walkErrors.snapshot()
+	roots, protect := junkScanPaths(cfg, home)
+	for _, root := range roots {
+		if ctx.Err() != nil {
+			break
+		}
+		if !pathExistsAndIsDir(root) {
+			continue
+		}
+		sweep, err := sweepJunkFiles(ctx, root, patterns, protect, true)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("junk file cleanup failed", "path", root, "error", err)
+		}
+		result.BytesFreed += sweep.Bytes
+		result.ItemsCleaned += sweep.Files + sweep.Dirs
+		if sweep.Files+sweep.Dirs > 0 {
+			logger.Info("removed junk files", "path", root, "files", sweep.Files, "dirs", sweep.Dirs, "freed_kb", sweep.Bytes/1024)
+		}
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+func TestJunkFilesCleanupRemovesOnlyMatches(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string, size int) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	junk := write("app/.DS_Store", 100)
+	nested := write("app/src/.DS_Store", 200)
+	thumbs := write("media/Thumbs.db", 300)
+	double := write("media/.AppleDouble/photo.jpg", 400)
+	protected := write("keep/.DS_Store", 500)
+	inGit := write("app/.git/.DS_Store", 600)
+	source := write("app/src/main.go", 700)
+	if err := os.Symlink(source, filepath.Join(root, "app", "linked.DS_Store")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Enable.JunkFiles = true
+	cfg.JunkFiles.ScanPaths = []string{root}
+	cfg.JunkFiles.Patterns = []string{".DS_Store", "*.DS_Store", "Thumbs.db", ".AppleDouble/"}
+	cfg.DevArtifacts.ProtectPaths = []string{filepath.Join(root, "keep")}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewJunkFilesPlugin()
+
+	if result := plugin.Cleanup(context.Background(), LevelWarning, cfg, logger); result.ItemsCleaned != 0 || !pathExists(junk) {
+		t.Fatalf("warning level should not remove anything, got %+v", result)
+	}
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if len(plan.Targets) != 1 || plan.Targets[0].Bytes != 1000 || plan.Metadata["match_count"] != "4" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.ItemsCleaned != 4 || result.BytesFreed != 1000 {
+		t.Fatalf("expected four matches and 1000 bytes, got %+v", result)
+	}
+	for _, removed := range []string{junk, nested, thumbs, filepath.Dir(double)} {
+		if pathExists(removed) {
+			t.Errorf("expected %s to be removed", removed)
+		}
+	}
+	for _, kept := range []string{protected, inGit, source, filepath.Join(root, "app", "linked.DS_Store")} {
+		if _, err := os.Lstat(kept); err != nil {
+			t.Errorf("expected %s to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestJunkFilesSkipsWithoutHome(t *testing.T) {
+	userHome := t.TempDir()
+	junk := filepath.Join(userHome, ".DS_Store")
+	if err := os.WriteFile(junk, make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env.Configure("", "")
+	t.Setenv("HOME", "")
+
+	cfg := config.DefaultConfig()
+	cfg.Enable.JunkFiles = true
+	cfg.JunkFiles.ScanPaths = []string{"~", userHome}
+	cfg.JunkFiles.Patterns = []string{".DS_Store"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewJunkFilesPlugin()
+	if plan := plugin.PlanCleanup(context.Background(), LevelCritical, cfg, logger); plan.SkipReason != "home_unavailable" || plan.WouldRun || len(plan.Targets) != 0 {
+		t.Fatalf("expected the plan to skip with home_unavailable, got %+v", plan)
+	}
+	if result := plugin.Cleanup(context.Background(), LevelCritical, cfg, logger); result.ItemsCleaned != 0 || !pathExists(junk) {
+		t.Fatalf("expected nothing removed without a home, got %+v", result)
+	}
+}
+
+func TestValidateJunkFilePatterns(t *testing.T) {
+	if err := ValidateJunkFilePatterns([]string{".DS_Store", "._*", "Thumbs.db", ".AppleDouble/"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, pattern := range []string{"", "*", "*/", "??*", "src/.DS_Store", "[", "/"} {
+		if err := ValidateJunkFilePatterns([]string{pattern}); err == nil {
+			t.Errorf("expected pattern %q to be rejected", pattern)
+		}
+	}
+}
@@ -12,8 +12,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 // LimaPlugin handles Lima VM cleanup and disk resize operations.
@@ -40,6 +42,28 @@ func (p *LimaPlugin) Description() string {
 	return "Cleans Lima VMs and manages disk resize operations"
 }
 
+// Priority runs Lima VM cleanup with the VM and snapshot work at the end;
+// VM restarts and compaction are disruptive.
+func (p *LimaPlugin) Priority() int {
+	return 100
+}
+
+// HeavyAt reports Lima cleanup as heavy when offline compaction is enabled,
+// since it can run at any level for VMs without fstrim support.
+func (p *LimaPlugin) HeavyAt(level CleanupLevel, cfg *config.Config) bool {
+	return cfg.Lima.CompactOffline
+}
+
+// DataPaths returns the Lima instance directory holding VM disk images, or
+// nil when the home directory is unavailable.
+func (p *LimaPlugin) DataPaths(cfg *config.Config) []string {
+	home, err := env.HomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".lima")}
+}
+
 // SupportedPlatforms returns supported platforms (Darwin only).
 func (p *LimaPlugin) SupportedPlatforms() []string {
 	return []string{PlatformDarwin}
@@ -50,6 +74,22 @@ func (p *LimaPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Lima
 }
 
+// LevelDescription summarizes Lima VM cleanup at each level.
+func (p *LimaPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "prunes dangling images and buildx cache older than 24h inside running VMs, then runs fstrim"
+	case LevelModerate:
+		return "prunes images older than 24h, stopped containers older than 1h, and buildx cache inside running VMs, then runs fstrim"
+	case LevelAggressive:
+		return "prunes old images, all stopped containers, unused volumes, and all builder cache inside running VMs, then runs fstrim"
+	case LevelCritical:
+		return "runs a full system prune with volumes inside running VMs, fstrim, and offline qcow2 compaction only when compact_offline is true"
+	default:
+		return "no cleanup"
+	}
+}
+
 // Cleanup performs Lima VM cleanup at the specified level.
 func (p *LimaPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
@@ -63,10 +103,16 @@ func (p *LimaPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 		return result
 	}
 
+	// A VM whose compaction restart failed on an earlier run is started
+	// again before the running VMs are listed.
+	if err := recoverVMRestarts(ctx, cfg, p.Name(), limaStart, limaHealthProbe, logger); err != nil {
+		result.Error = err
+	}
+
 	// Get running VMs
 	runningVMs, err := p.getRunningVMs(ctx)
 	if err != nil {
-		result.Error = err
+		result.Error = newPluginError(p.Name(), "vm_list", err)
 		return result
 	}
 
@@ -75,6 +121,14 @@ func (p *LimaPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 		return result
 	}
 
+	trimStatePath := limaTrimStatePath(cfg)
+	trimState := loadLimaTrimState(trimStatePath)
+	defer func() {
+		if err := trimState.save(trimStatePath); err != nil {
+			logger.Warn("failed to save Lima trim state", "path", trimStatePath, "error", err)
+		}
+	}()
+
 	// Process configured VMs
 	for _, vmName := range cfg.Lima.VMNames {
 		if !contains(runningVMs, vmName) {
@@ -88,14 +142,36 @@ func (p *LimaPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 		diskUsageBefore := p.getVMDiskUsage(ctx, vmName, logger)
 
 		// Perform cleanup based on level
-		vmResult := p.cleanupVM(ctx, vmName, level, cfg, logger)
-		result.BytesFreed += vmResult.BytesFreed
-		result.ItemsCleaned += vmResult.ItemsCleaned
+		if cfg.Lima.Allows(vmName, config.LimaOpPrune) {
+			vmResult := p.cleanupVM(ctx, vmName, level, cfg, logger)
+			result.BytesFreed += vmResult.BytesFreed
+			result.ItemsCleaned += vmResult.ItemsCleaned
+		} else {
+			logger.Info("skipping in-VM prune; not allowed by lima.vm_policies", "vm", vmName)
+		}
 
-		// Run fstrim to reclaim space
-		logger.Debug("running fstrim in Lima VM", "vm", vmName)
-		fstrimResult := p.runFSTrim(ctx, vmName, logger)
-		result.BytesFreed += fstrimResult.BytesFreed
+		// Run fstrim to reclaim space, unless this VM is known to reject it
+		trimUnsupported := false
+		now := time.Now()
+		if !cfg.Lima.Allows(vmName, config.LimaOpFSTrim) {
+			logger.Info("skipping fstrim; not allowed by lima.vm_policies", "vm", vmName)
+		} else if trimState.shouldTrim(vmName, now) {
+			logger.Debug("running fstrim in Lima VM", "vm", vmName)
+			fstrimResult, supported := p.runFSTrim(ctx, vmName, logger)
+			result.BytesFreed += fstrimResult.BytesFreed
+			if !supported {
+				logger.Info("fstrim not supported in Lima VM; skipping trim until recheck",
+					"vm", vmName,
+					"recheck_after", limaTrimRecheckInterval.String())
+			}
+			trimState.record(vmName, supported, now)
+			trimUnsupported = !supported
+		} else {
+			logger.Debug("skipping fstrim: Lima VM recorded as not supporting trim",
+				"vm", vmName,
+				"checked_at", trimState.VMs[vmName].CheckedAt.Format(time.RFC3339))
+			trimUnsupported = true
+		}
 
 		// Check disk usage after cleanup
 		diskUsageAfter := p.getVMDiskUsage(ctx, vmName, logger)
@@ -113,17 +189,39 @@ func (p *LimaPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *confi
 			}
 		}
 
-		// At Critical level with compact_offline enabled, do offline compaction
-		if level >= LevelCritical && cfg.Lima.CompactOffline {
+		if trimUnsupported && !cfg.Lima.CompactOffline {
+			logger.Debug("fstrim unsupported and compact_offline disabled; Lima disk image will not shrink", "vm", vmName)
+		}
+
+		// With compact_offline enabled, do offline compaction at Critical level,
+		// or at the current level when fstrim cannot reclaim space.
+		skipReason := limaCompactionSkipReason(cfg, vmName, level, trimUnsupported)
+		if skipReason == "vm_policy" {
+			logger.Info("skipping offline compaction; not allowed by lima.vm_policies", "vm", vmName, "level", level.String())
+		}
+		if skipReason == "" {
 			diskInfo, err := p.GetVMDiskInfo(ctx, vmName)
 			if err == nil && diskInfo.DiskPath != "" {
-				compactFreed, err := p.compactDisk(ctx, diskInfo, logger)
-				if err != nil {
-					logger.Warn("Lima disk compaction failed", "vm", vmName, "error", err)
-				} else if compactFreed > 0 {
+				if level < LevelCritical {
+					if !limaDiskBloated(p.getActualDiskSize(diskInfo.DiskPath), diskInfo.HostDiskSize) {
+						logger.Debug("fstrim unsupported but Lima disk is not bloated; skipping compaction", "vm", vmName)
+						continue
+					}
+					logger.Info("fstrim unsupported; falling back to offline compaction",
+						"vm", vmName,
+						"level", level.String())
+				}
+				compactFreed, err := p.compactDisk(ctx, diskInfo, cfg, logger)
+				if compactFreed > 0 {
 					result.BytesFreed += compactFreed
 					result.ItemsCleaned++
 				}
+				if err != nil {
+					logger.Warn("Lima disk compaction failed", "vm", vmName, "error", err)
+					if result.Error == nil {
+						result.Error = pluginErrorForVM(p.Name(), "disk_compact", vmName, err)
+					}
+				}
 			}
 		}
 	}
@@ -137,77 +235,53 @@ func (p *LimaPlugin) isLimaAvailable() bool {
 }
 
 func (p *LimaPlugin) getRunningVMs(ctx context.Context) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "limactl", "list", "--format", "{{.Name}}\t{{.Status}}")
+	version, _ := p.limaVersion(ctx)
+	cmd := exec.CommandContext(ctx, "limactl", limaListArgs(version)...)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list VMs: %w", err)
+		// Older releases may reject the listing flags; the default table
+		// output has carried NAME and STATUS columns in every release.
+		cmd = exec.CommandContext(ctx, "limactl", "list")
+		output, err = cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list VMs: %w", err)
+		}
 	}
 
 	var running []string
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Split(line, "\t")
-		if len(parts) >= 2 && parts[1] == "Running" {
-			running = append(running, parts[0])
+	for _, vm := range parseLimaList(string(output)) {
+		if isLimaRunning(vm.Status) {
+			running = append(running, vm.Name)
 		}
 	}
 
 	return running, nil
 }
 
+func (p *LimaPlugin) limaVersion(ctx context.Context) (limaVersion, error) {
+	output, err := exec.CommandContext(ctx, "limactl", "--version").Output()
+	if err != nil {
+		return limaVersion{}, err
+	}
+	return parseLimaVersion(string(output)), nil
+}
+
 func (p *LimaPlugin) cleanupVM(ctx context.Context, vmName string, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name() + "-" + vmName}
 
 	// Commands to run inside the VM based on cleanup level
-	var commands [][]string
-
-	switch level {
-	case LevelWarning:
-		// Light cleanup: just dangling resources
-		commands = [][]string{
-			{"docker", "image", "prune", "-f"},
-			{"docker", "buildx", "prune", "-f", "--filter", "until=24h"},
-		}
-
-	case LevelModerate:
-		// Moderate: add old containers and volumes
-		commands = [][]string{
-			{"docker", "image", "prune", "-af", "--filter", "until=24h"},
-			{"docker", "container", "prune", "-f", "--filter", "until=1h"},
-			{"docker", "buildx", "prune", "-f", "--filter", "until=24h"},
-		}
-
-	case LevelAggressive:
-		// Aggressive: add volumes and build cache
-		commands = [][]string{
-			{"docker", "image", "prune", "-af", "--filter", "until=24h"},
-			{"docker", "container", "prune", "-f"},
-			{"docker", "volume", "prune", "-f"},
-			{"docker", "builder", "prune", "-af"},
-		}
-
-	case LevelCritical:
-		// Critical: full system prune
-		commands = [][]string{
-			{"docker", "system", "prune", "-af", "--volumes"},
-		}
-	}
+	commands := limaVMCommands(level)
 
 	// Execute commands inside VM
 	for _, args := range commands {
-		cmdArgs := append([]string{"shell", vmName, "--"}, args...)
-		cmd := exec.CommandContext(ctx, "limactl", cmdArgs...)
-		output, err := cmd.CombinedOutput()
+		output, err := runLimaShell(ctx, vmName, args...)
 		if err != nil {
 			logger.Debug("VM command failed", "vm", vmName, "cmd", strings.Join(args, " "), "error", err)
 			continue
 		}
 
 		// Parse reclaimed space from Docker output
-		if bytesFreed := parseDockerReclaimedSpace(string(output)); bytesFreed > 0 {
+		if bytesFreed := parseDockerReclaimedSpace(output); bytesFreed > 0 {
 			result.BytesFreed += bytesFreed
 			result.ItemsCleaned++
 		}
@@ -216,21 +290,38 @@ func (p *LimaPlugin) cleanupVM(ctx context.Context, vmName string, level Cleanup
 	return result
 }
 
-func (p *LimaPlugin) runFSTrim(ctx context.Context, vmName string, logger *slog.Logger) CleanupResult {
+// CleanupCommands returns the limactl commands Cleanup would run at level for
+// each configured VM, leaving out operations lima.vm_policies forbids. VMs
+// that are not running are skipped at cleanup time, and offline compaction is
+// omitted because it depends on disk inspection.
+func (p *LimaPlugin) CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	var commands [][]string
+	for _, vmName := range cfg.Lima.VMNames {
+		commands = append(commands, limaPolicyCommands(cfg, vmName, level)...)
+	}
+	return commands
+}
+
+// runFSTrim trims all mounted filesystems in the VM. The second return value
+// is false when fstrim reports that discard is not supported, as on krunkit.
+func (p *LimaPlugin) runFSTrim(ctx context.Context, vmName string, logger *slog.Logger) (CleanupResult, bool) {
 	result := CleanupResult{Plugin: p.Name() + "-fstrim"}
 
 	// Run fstrim -av to reclaim all space
-	cmd := exec.CommandContext(ctx, "limactl", "shell", vmName, "--", "sudo", "fstrim", "-av")
-	output, err := cmd.CombinedOutput()
+	output, err := runLimaShell(ctx, vmName, "sudo", "fstrim", "-av")
+	if fstrimUnsupported(output) {
+		logger.Debug("fstrim not supported", "vm", vmName, "output", strings.TrimSpace(output))
+		return result, false
+	}
 	if err != nil {
 		logger.Debug("fstrim failed", "vm", vmName, "error", err)
-		return result
+		return result, true
 	}
 
 	// Parse fstrim output for bytes trimmed
 	// Example: "/var: 1.5 GiB (1610612736 bytes) trimmed on /dev/vda1"
 	re := regexp.MustCompile(`(\d+)\s+bytes?\s+trimmed`)
-	matches := re.FindAllStringSubmatch(string(output), -1)
+	matches := re.FindAllStringSubmatch(output, -1)
 	var totalTrimmed int64
 	for _, match := range matches {
 		if len(match) >= 2 {
@@ -245,7 +336,7 @@ func (p *LimaPlugin) runFSTrim(ctx context.Context, vmName string, logger *slog.
 		logger.Debug("fstrim completed", "vm", vmName, "trimmed_mb", totalTrimmed/(1024*1024))
 	}
 
-	return result
+	return result, true
 }
 
 func (p *LimaPlugin) getVMDiskUsage(ctx context.Context, vmName string, logger *slog.Logger) int64 {
@@ -288,7 +379,7 @@ func (p *LimaPlugin) GetVMDiskInfo(ctx context.Context, vmName string) (*VMDiskI
 	}
 
 	status := strings.TrimSpace(string(statusOutput))
-	if status != "Running" {
+	if !isLimaRunning(status) {
 		return &VMDiskInfo{Name: vmName, Status: status}, nil
 	}
 
@@ -318,11 +409,13 @@ func (p *LimaPlugin) GetVMDiskInfo(ctx context.Context, vmName string) (*VMDiskI
 	usedPercent := strings.TrimSuffix(fields[3], "%")
 
 	// Get disk image file size on host
-	home, _ := os.UserHomeDir()
-	diskPath := filepath.Join(home, ".lima", vmName, "diffdisk")
+	var diskPath string
 	hostSize := int64(0)
-	if stat, err := os.Stat(diskPath); err == nil {
-		hostSize = stat.Size()
+	if home, err := env.HomeDir(); err == nil {
+		diskPath = filepath.Join(home, ".lima", vmName, "diffdisk")
+		if stat, err := os.Stat(diskPath); err == nil {
+			hostSize = stat.Size()
+		}
 	}
 
 	return &VMDiskInfo{
@@ -337,6 +430,41 @@ func (p *LimaPlugin) GetVMDiskInfo(ctx context.Context, vmName string) (*VMDiskI
 	}, nil
 }
 
+// VMDisks reports each configured Lima VM's guest usage and the host
+// allocation of its diffdisk.
+func (p *LimaPlugin) VMDisks(ctx context.Context, cfg *config.Config) []VMDiskStatus {
+	if !p.isLimaAvailable() {
+		return nil
+	}
+	var disks []VMDiskStatus
+	for _, vmName := range cfg.Lima.VMNames {
+		info, err := p.GetVMDiskInfo(ctx, vmName)
+		if err != nil {
+			disks = append(disks, VMDiskStatus{Name: vmName, Status: "unknown", Error: err.Error()})
+			continue
+		}
+		disk := VMDiskStatus{
+			Name:            info.Name,
+			Status:          info.Status,
+			GuestTotalBytes: info.TotalBytes,
+			GuestUsedBytes:  info.UsedBytes,
+			DiskPath:        info.DiskPath,
+		}
+		if disk.DiskPath == "" {
+			if home, err := env.HomeDir(); err == nil {
+				disk.DiskPath = filepath.Join(home, ".lima", vmName, "diffdisk")
+			}
+		}
+		if allocated, err := getFileAllocatedBytes(disk.DiskPath); err == nil {
+			disk.HostAllocatedBytes = allocated
+		} else {
+			disk.DiskPath = ""
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
 // VMDiskInfo contains disk information for a Lima VM.
 type VMDiskInfo struct {
 	Name           string
@@ -386,9 +514,11 @@ func parseDockerReclaimedSpace(output string) int64 {
 
 // compactDisk performs offline qcow2 compaction for a Lima VM disk image.
 // This stops the VM, converts the disk image to reclaim sparse space, verifies
-// the compacted image, and replaces the original before restarting.
-// ONLY runs at Critical level with explicit opt-in via config.
-func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, logger *slog.Logger) (int64, error) {
+// the compacted image, and replaces the original before restarting. After the
+// restart the guest root mount is compared with the one recorded before stop.
+// Runs only with explicit opt-in via config, at Critical level or when fstrim
+// is not supported in the VM, and only once risks have been acknowledged.
+func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, cfg *config.Config, logger *slog.Logger) (int64, error) {
 	if vm.DiskPath == "" {
 		return 0, fmt.Errorf("no disk path for VM %s", vm.Name)
 	}
@@ -414,7 +544,7 @@ func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, logger *sl
 	actualSize := p.getActualDiskSize(vm.DiskPath)
 	if actualSize > 0 && apparentSize > 0 {
 		sparseRatio := float64(actualSize) / float64(apparentSize) * 100
-		if sparseRatio > 70 {
+		if !limaDiskBloated(actualSize, apparentSize) {
 			logger.Debug("Lima disk already well-compacted",
 				"vm", vm.Name,
 				"sparse_ratio", fmt.Sprintf("%.0f%%", sparseRatio))
@@ -436,24 +566,52 @@ func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, logger *sl
 		return 0, nil
 	}
 
+	if !requireRiskAcknowledgment(cfg, p.Name(), "disk_compact", vm.Name, logger) {
+		return 0, nil
+	}
+	if !cooldownAllows(cfg, p.Name(), "disk_compact", logger) {
+		return 0, nil
+	}
+
 	compactPath := vm.DiskPath + ".compact"
 
+	// Record the guest root so the restarted VM can be checked against it.
+	rootBefore, _, err := readLimaGuestRoot(ctx, vm.Name)
+	if err != nil {
+		logger.Debug("could not read Lima guest root before compaction; will only check that / mounts", "vm", vm.Name, "error", err)
+	}
+
+	volume := measureCompactionVolume(vm.DiskPath, getFreeDiskSpace)
+
 	logger.Warn("CRITICAL: stopping Lima VM for disk compaction", "vm", vm.Name)
 
 	// 1. Stop VM
 	stopCmd := exec.CommandContext(ctx, "limactl", "stop", vm.Name)
 	if output, err := stopCmd.CombinedOutput(); err != nil {
-		return 0, fmt.Errorf("failed to stop VM: %w (output: %s)", err, string(output))
+		return 0, newCommandError(p.Name(), "vm_stop", err, string(output)).withVM(vm.Name)
 	}
+	// A stopped VM is the disruption the cooldown paces, whether or not the
+	// compaction itself succeeds.
+	defer recordCooldown(cfg, p.Name(), "disk_compact", logger)
+	// Once stopped, the VM must be restarted even if the cycle deadline
+	// cancels ctx mid-compaction.
+	restartCtx := context.WithoutCancel(ctx)
 
 	// 2. Compact: qemu-img convert
 	logger.Info("compacting Lima disk image", "vm", vm.Name, "disk", vm.DiskPath)
 	convertCmd := exec.CommandContext(ctx, "qemu-img", "convert", "-O", "qcow2", vm.DiskPath, compactPath)
-	if output, err := convertCmd.CombinedOutput(); err != nil {
+	stopProgress := watchCompactionProgress(ctx, compactPath, compactionProgressInterval,
+		logCompactionProgress(logger, actualSize, "vm", vm.Name))
+	output, err := convertCmd.CombinedOutput()
+	stopProgress()
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Warn("Lima disk compaction cancelled; the original disk image is unchanged", "vm", vm.Name, "error", ctx.Err())
+		}
 		// Restart VM before returning error
-		exec.CommandContext(ctx, "limactl", "start", vm.Name).Run()
+		p.restartAfterCompaction(restartCtx, cfg, vm.Name, logger)
 		os.Remove(compactPath)
-		return 0, fmt.Errorf("qemu-img convert failed: %w (output: %s)", err, string(output))
+		return 0, newCommandError(p.Name(), "disk_convert", err, string(output)).withVM(vm.Name).withPath(vm.DiskPath)
 	}
 
 	// 3. Verify compacted image
@@ -461,33 +619,44 @@ func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, logger *sl
 	if output, err := checkCmd.CombinedOutput(); err != nil {
 		// Verification failed - remove compact file and restart
 		os.Remove(compactPath)
-		exec.CommandContext(ctx, "limactl", "start", vm.Name).Run()
-		return 0, fmt.Errorf("qemu-img check failed: %w (output: %s)", err, string(output))
+		p.restartAfterCompaction(restartCtx, cfg, vm.Name, logger)
+		return 0, newCommandError(p.Name(), "disk_verify", err, string(output)).withVM(vm.Name).withPath(compactPath)
 	}
 
 	// 4. Get compacted size
 	compactStat, err := os.Stat(compactPath)
 	if err != nil {
 		os.Remove(compactPath)
-		exec.CommandContext(ctx, "limactl", "start", vm.Name).Run()
+		p.restartAfterCompaction(restartCtx, cfg, vm.Name, logger)
 		return 0, fmt.Errorf("cannot stat compacted disk: %w", err)
 	}
 
 	// 5. Atomic replace
 	if err := os.Rename(compactPath, vm.DiskPath); err != nil {
 		os.Remove(compactPath)
-		exec.CommandContext(ctx, "limactl", "start", vm.Name).Run()
+		p.restartAfterCompaction(restartCtx, cfg, vm.Name, logger)
 		return 0, fmt.Errorf("failed to replace disk image: %w", err)
 	}
 
+	volume.measureAfter()
+
 	// 6. Restart VM
 	logger.Info("restarting Lima VM after compaction", "vm", vm.Name)
-	startCmd := exec.CommandContext(ctx, "limactl", "start", vm.Name)
-	if output, err := startCmd.CombinedOutput(); err != nil {
-		logger.Error("failed to restart VM after compaction", "vm", vm.Name, "error", err, "output", string(output))
+	// A VM that does not come back is logged and recorded for recovery.
+	restartErr := p.restartAfterCompaction(restartCtx, cfg, vm.Name, logger)
+	if restartErr == nil {
+		if err := verifyLimaGuestRoot(restartCtx, vm.Name, rootBefore); err != nil {
+			logger.Error("LIMA GUEST ROOT CHECK FAILED after disk compaction; inspect the VM before using it",
+				"vm", vm.Name,
+				"disk", vm.DiskPath,
+				"expected_source", rootBefore.Source,
+				"expected_fstype", rootBefore.FSType,
+				"error", err)
+			restartErr = err
+		}
 	}
 
-	freed := hostSizeBefore - compactStat.Size()
+	freed := volume.verifiedCompactionFreed(hostSizeBefore-compactStat.Size(), logger, "vm", vm.Name)
 	if freed > 0 {
 		logger.Info("Lima disk compaction complete",
 			"vm", vm.Name,
@@ -495,23 +664,25 @@ func (p *LimaPlugin) compactDisk(ctx context.Context, vm *VMDiskInfo, logger *sl
 			"before_gb", fmt.Sprintf("%.1f", float64(hostSizeBefore)/(1024*1024*1024)),
 			"after_gb", fmt.Sprintf("%.1f", float64(compactStat.Size())/(1024*1024*1024)),
 		)
-		return freed, nil
+		return freed, restartErr
 	}
 
-	return 0, nil
+	return 0, restartErr
+}
+
+// restartAfterCompaction starts vmName and waits until limactl lists it as
+// running and it answers a shell command.
+func (p *LimaPlugin) restartAfterCompaction(ctx context.Context, cfg *config.Config, vmName string, logger *slog.Logger) error {
+	return restartVMAfterCompaction(ctx, cfg, p.Name(), vmName, limaStart, limaHealthProbe(vmName), logger)
 }
 
 // getActualDiskSize returns the actual disk blocks used (not apparent size).
 func (p *LimaPlugin) getActualDiskSize(path string) int64 {
-	// Use stat to get actual block usage
-	var stat os.FileInfo
-	stat, err := os.Stat(path)
+	allocated, err := getFileAllocatedBytes(path)
 	if err != nil {
 		return 0
 	}
-	// On Unix, Sys() returns *syscall.Stat_t which has Blocks
-	// Use apparent size as fallback
-	return stat.Size()
+	return allocated
 }
 
 func contains(slice []string, item string) bool {
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package plugins
+
+import (
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+func TestLimaDataPathsWithoutHome(t *testing.T) {
+	env.Configure("", "")
+	t.Setenv("HOME", "")
+	if paths := NewLimaPlugin().DataPaths(config.DefaultConfig()); paths != nil {
+		t.Fatalf("expected no Lima data paths without a home, got %v", paths)
+	}
+}
@@ -0,0 +1,58 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// limaGuestRoot is the device and filesystem type mounted at / in a Lima VM.
+type limaGuestRoot struct {
+	Source string
+	FSType string
+}
+
+// limaGuestRootArgs lists the guest root mount as "SOURCE FSTYPE".
+var limaGuestRootArgs = []string{"findmnt", "-n", "-o", "SOURCE,FSTYPE", "/"}
+
+// readLimaGuestRoot reads the VM's root mount with findmnt, waiting out a
+// guest that is not reachable over ssh yet.
+func readLimaGuestRoot(ctx context.Context, vmName string) (limaGuestRoot, string, error) {
+	output, err := runLimaShell(ctx, vmName, limaGuestRootArgs...)
+	if err != nil {
+		return limaGuestRoot{}, output, err
+	}
+	root, err := parseFindmntRoot(output)
+	return root, output, err
+}
+
+// parseFindmntRoot parses `findmnt -n -o SOURCE,FSTYPE /` output, e.g.
+// "/dev/vda1 ext4" or "/dev/vda2[/@] btrfs".
+func parseFindmntRoot(output string) (limaGuestRoot, error) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			return limaGuestRoot{Source: fields[0], FSType: fields[1]}, nil
+		}
+	}
+	return limaGuestRoot{}, fmt.Errorf("no root mount in findmnt output %q", strings.TrimSpace(output))
+}
+
+// verifyLimaGuestRoot checks that / is mounted in the restarted VM and, when the
+// pre-compaction root is known, that it is still the same device and
+// filesystem. A mismatch means the guest fell back to another root or the
+// image no longer matches its partition layout.
+func verifyLimaGuestRoot(ctx context.Context, vmName string, before limaGuestRoot) error {
+	after, output, err := readLimaGuestRoot(ctx, vmName)
+	if err != nil {
+		return newCommandError("lima", "guest_verify", err, output).withVM(vmName)
+	}
+	if before.Source == "" {
+		return nil
+	}
+	if after != before {
+		err := fmt.Errorf("guest root changed from %s (%s) to %s (%s)", before.Source, before.FSType, after.Source, after.FSType)
+		return newCommandError("lima", "guest_verify", err, output).withVM(vmName)
+	}
+	return nil
+}
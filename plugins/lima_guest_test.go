@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseFindmntRoot(t *testing.T) {
+	for _, tt := range []struct {
+		output string
+		want   limaGuestRoot
+	}{
+		{"/dev/vda1 ext4\n", limaGuestRoot{Source: "/dev/vda1", FSType: "ext4"}},
+		{"/dev/vda2[/@] btrfs\n", limaGuestRoot{Source: "/dev/vda2[/@]", FSType: "btrfs"}},
+		{"/dev/mapper/vg-root xfs", limaGuestRoot{Source: "/dev/mapper/vg-root", FSType: "xfs"}},
+	} {
+		got, err := parseFindmntRoot(tt.output)
+		if err != nil || got != tt.want {
+			t.Errorf("parseFindmntRoot(%q) = %+v, %v; want %+v", tt.output, got, err, tt.want)
+		}
+	}
+	if _, err := parseFindmntRoot("\n"); err == nil {
+		t.Error("expected empty findmnt output to fail")
+	}
+}
+
+func TestVerifyLimaGuestRoot(t *testing.T) {
+	const command = "limactl shell default -- findmnt -n -o SOURCE,FSTYPE /"
+	before := limaGuestRoot{Source: "/dev/vda1", FSType: "ext4"}
+
+	useFakeRunner(t, map[string]fakeResponse{command: {Output: "/dev/vda1 ext4\n"}})
+	if err := verifyLimaGuestRoot(context.Background(), "default", before); err != nil {
+		t.Fatalf("unchanged root should verify, got %v", err)
+	}
+
+	useFakeRunner(t, map[string]fakeResponse{command: {Output: "/dev/vdb1 ext4\n"}})
+	err := verifyLimaGuestRoot(context.Background(), "default", before)
+	if pluginErr, ok := AsPluginError(err); !ok || pluginErr.Operation != "guest_verify" || pluginErr.VM != "default" {
+		t.Fatalf("expected guest_verify PluginError for a changed root, got %v", err)
+	}
+	if err := verifyLimaGuestRoot(context.Background(), "default", limaGuestRoot{}); err != nil {
+		t.Fatalf("without a recorded root only the mount should be checked, got %v", err)
+	}
+
+	useFakeRunner(t, map[string]fakeResponse{command: {Err: errors.New("exit status 1"), Output: "findmnt: can't read /proc/mounts"}})
+	err = verifyLimaGuestRoot(context.Background(), "default", limaGuestRoot{})
+	if pluginErr, ok := AsPluginError(err); !ok || pluginErr.CommandOutput != "findmnt: can't read /proc/mounts" {
+		t.Fatalf("expected an unreadable root to fail verification, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package plugins
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// limaVersion is a parsed limactl release version.
+type limaVersion struct {
+	Major int
+	Minor int
+	Known bool
+}
+
+// limaVM is one VM row from limactl list output.
+type limaVM struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+var limaVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.\d+)?`)
+
+// parseLimaVersion parses "limactl version 1.0.3" style output. Development
+// builds without a semantic version are reported as unknown.
+func parseLimaVersion(output string) limaVersion {
+	match := limaVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return limaVersion{}
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return limaVersion{Major: major, Minor: minor, Known: true}
+}
+
+// limaListArgs returns the limactl list arguments for a version. Lima 1.x
+// emits one JSON object per VM, which is immune to column and template
+// changes; 0.x and unknown versions use the tab-separated template.
+func limaListArgs(version limaVersion) []string {
+	if version.Known && version.Major >= 1 {
+		return []string{"list", "--json"}
+	}
+	return []string{"list", "--format", "{{.Name}}\t{{.Status}}"}
+}
+
+// parseLimaList parses limactl list output in JSON-lines, tab-separated
+// template, or default table form.
+func parseLimaList(output string) []limaVM {
+	var vms []limaVM
+	statusColumn := -1
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var vm limaVM
+			if err := json.Unmarshal([]byte(line), &vm); err == nil && vm.Name != "" {
+				vms = append(vms, vm)
+			}
+			continue
+		}
+		if parts := strings.Split(line, "\t"); len(parts) >= 2 {
+			vms = append(vms, limaVM{Name: strings.TrimSpace(parts[0]), Status: strings.TrimSpace(parts[1])})
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "NAME" {
+			for i, field := range fields {
+				if field == "STATUS" {
+					statusColumn = i
+				}
+			}
+			continue
+		}
+		if statusColumn > 0 && len(fields) > statusColumn {
+			vms = append(vms, limaVM{Name: fields[0], Status: fields[statusColumn]})
+		}
+	}
+	return vms
+}
+
+// isLimaRunning reports whether a limactl status means the VM is running.
+// Lima has used both "Running" and "running", so the match ignores case;
+// any other state such as Stopped, Broken, or Starting is not running.
+func isLimaRunning(status string) bool {
+	return strings.EqualFold(strings.TrimSpace(status), "running")
+}
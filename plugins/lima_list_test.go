@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLimaVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   limaVersion
+	}{
+		{"limactl version 0.23.2\n", limaVersion{Major: 0, Minor: 23, Known: true}},
+		{"limactl version 1.0.3\n", limaVersion{Major: 1, Minor: 0, Known: true}},
+		{"limactl version HEAD-3f1c2a9\n", limaVersion{}},
+	}
+	for _, tt := range tests {
+		if got := parseLimaVersion(tt.output); got != tt.want {
+			t.Fatalf("parseLimaVersion(%q) = %+v, want %+v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestLimaListArgsByVersion(t *testing.T) {
+	if got := strings.Join(limaListArgs(limaVersion{Major: 1, Known: true}), " "); got != "list --json" {
+		t.Fatalf("Lima 1.x list args = %q", got)
+	}
+	for _, version := range []limaVersion{{Major: 0, Minor: 23, Known: true}, {}} {
+		if got := strings.Join(limaListArgs(version), " "); got != "list --format {{.Name}}\t{{.Status}}" {
+			t.Fatalf("list args for %+v = %q", version, got)
+		}
+	}
+}
+
+func TestParseLimaListRunningVMsAcrossVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name:   "lima 0.x template",
+			output: "colima\tRunning\ndefault\tStopped\nbroken\tBroken\n",
+		},
+		{
+			name: "lima 1.x json lines",
+			output: `{"name":"colima","status":"Running","dir":"/Users/dev/.lima/colima","vmType":"vz","arch":"aarch64"}
+{"name":"default","status":"Stopped","dir":"/Users/dev/.lima/default","vmType":"qemu","arch":"aarch64"}
+{"name":"broken","status":"Broken","errors":["disk missing"]}
+`,
+		},
+		{
+			name:   "lowercase status",
+			output: "colima\trunning\ndefault\tstopped\nbroken\tstarting\n",
+		},
+		{
+			name: "default table fallback",
+			output: `NAME       STATUS     SSH                VMTYPE    ARCH       CPUS    MEMORY    DISK      DIR
+colima     Running    127.0.0.1:50022    vz        aarch64    4       8GiB      100GiB    ~/.lima/colima
+default    Stopped    127.0.0.1:0        qemu      aarch64    4       4GiB      100GiB    ~/.lima/default
+broken     Broken     127.0.0.1:0        qemu      aarch64    4       4GiB      100GiB    ~/.lima/broken
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vms := parseLimaList(tt.output)
+			if len(vms) != 3 {
+				t.Fatalf("expected 3 VMs, got %+v", vms)
+			}
+			var running []string
+			for _, vm := range vms {
+				if isLimaRunning(vm.Status) {
+					running = append(running, vm.Name)
+				}
+			}
+			if strings.Join(running, ",") != "colima" {
+				t.Fatalf("running VMs = %v, want [colima]", running)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import "github.com/Jesssullivan/tinyland-cleanup/config"
+
+// limaCompactionSkipReason returns why the compaction phase does not run for
+// vm, or "" when it does. compact_offline compacts at critical level, or at
+// any level when fstrim cannot reclaim space, unless lima.vm_policies leaves
+// compact out for vm.
+func limaCompactionSkipReason(cfg *config.Config, vm string, level CleanupLevel, trimUnsupported bool) string {
+	switch {
+	case !cfg.Lima.CompactOffline:
+		return "compact_offline_disabled"
+	case level < LevelCritical && !trimUnsupported:
+		return "below_critical"
+	case !cfg.Lima.Allows(vm, config.LimaOpCompact):
+		return "vm_policy"
+	default:
+		return ""
+	}
+}
+
+// limaVMCommands returns the commands run inside a Lima VM at each cleanup
+// level, in execution order.
+func limaVMCommands(level CleanupLevel) [][]string {
+	switch level {
+	case LevelWarning:
+		// Light cleanup: just dangling resources
+		return [][]string{
+			{"docker", "image", "prune", "-f"},
+			{"docker", "buildx", "prune", "-f", "--filter", "until=24h"},
+		}
+
+	case LevelModerate:
+		// Moderate: add old containers and volumes
+		return [][]string{
+			{"docker", "image", "prune", "-af", "--filter", "until=24h"},
+			{"docker", "container", "prune", "-f", "--filter", "until=1h"},
+			{"docker", "buildx", "prune", "-f", "--filter", "until=24h"},
+		}
+
+	case LevelAggressive:
+		// Aggressive: add volumes and build cache
+		return [][]string{
+			{"docker", "image", "prune", "-af", "--filter", "until=24h"},
+			{"docker", "container", "prune", "-f"},
+			{"docker", "volume", "prune", "-f"},
+			{"docker", "builder", "prune", "-af"},
+		}
+
+	case LevelCritical:
+		// Critical: full system prune
+		return [][]string{
+			{"docker", "system", "prune", "-af", "--volumes"},
+		}
+
+	default:
+		return nil
+	}
+}
+
+// limaPolicyCommands returns the limactl commands lima.vm_policies allows
+// for vm at level: the in-VM prunes and the trim.
+func limaPolicyCommands(cfg *config.Config, vm string, level CleanupLevel) [][]string {
+	vmCommands := limaVMCommands(level)
+	if len(vmCommands) == 0 {
+		return nil
+	}
+	var commands [][]string
+	if cfg.Lima.Allows(vm, config.LimaOpPrune) {
+		for _, args := range vmCommands {
+			commands = append(commands, append([]string{"limactl", "shell", vm, "--"}, args...))
+		}
+	}
+	if cfg.Lima.Allows(vm, config.LimaOpFSTrim) {
+		commands = append(commands, []string{"limactl", "shell", vm, "--", "sudo", "fstrim", "-av"})
+	}
+	return commands
+}
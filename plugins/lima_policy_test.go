@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestLimaCompactionSkippedByVMPolicy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Lima.CompactOffline = true
+	cfg.Lima.VMPolicies = map[string][]string{"unified": {config.LimaOpPrune}}
+
+	if reason := limaCompactionSkipReason(cfg, "unified", LevelCritical, false); reason != "vm_policy" {
+		t.Fatalf("expected unified compaction skipped by policy, got %q", reason)
+	}
+	if reason := limaCompactionSkipReason(cfg, "unified", LevelModerate, true); reason != "vm_policy" {
+		t.Fatalf("expected the fstrim fallback skipped by policy too, got %q", reason)
+	}
+	if reason := limaCompactionSkipReason(cfg, "colima", LevelCritical, false); reason != "" {
+		t.Fatalf("expected colima to compact, got %q", reason)
+	}
+	if reason := limaCompactionSkipReason(cfg, "colima", LevelModerate, false); reason != "below_critical" {
+		t.Fatalf("expected no compaction below critical when fstrim works, got %q", reason)
+	}
+	cfg.Lima.CompactOffline = false
+	if reason := limaCompactionSkipReason(cfg, "colima", LevelCritical, false); reason != "compact_offline_disabled" {
+		t.Fatalf("expected compaction off without compact_offline, got %q", reason)
+	}
+}
+
+func TestLimaPolicyCommands(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Lima.VMPolicies = map[string][]string{
+		"unified": {config.LimaOpPrune},
+		"sealed":  {},
+	}
+
+	unified := limaPolicyCommands(cfg, "unified", LevelWarning)
+	if len(unified) != len(limaVMCommands(LevelWarning)) || unified[len(unified)-1][len(unified[len(unified)-1])-1] == "-av" {
+		t.Fatalf("expected prunes without fstrim for unified, got %v", unified)
+	}
+	if commands := limaPolicyCommands(cfg, "sealed", LevelWarning); len(commands) != 0 {
+		t.Fatalf("expected nothing for sealed, got %v", commands)
+	}
+	if commands := limaPolicyCommands(cfg, "colima", LevelWarning); len(commands) != len(limaVMCommands(LevelWarning))+1 {
+		t.Fatalf("expected prunes and fstrim for colima, got %v", commands)
+	}
+}
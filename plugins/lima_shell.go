@@ -0,0 +1,64 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Right after limactl start, and especially after the compaction restart,
+// the guest's sshd may not accept connections yet. limactl shell then fails
+// before the command runs. Those failures are retried; a command that ran and
+// failed is not.
+var (
+	limaShellAttempts   = 4
+	limaShellRetryDelay = 5 * time.Second
+)
+
+// limaSSHUnreachableMarkers are ssh client errors meaning the guest could
+// not be reached, as opposed to the remote command failing.
+var limaSSHUnreachableMarkers = []string{
+	"connection refused",
+	"connection timed out",
+	"operation timed out",
+	"connection reset by peer",
+	"connection closed by",
+	"kex_exchange_identification",
+	"no route to host",
+	"ssh: connect to host",
+	"ssh: handshake failed",
+}
+
+// limaVMUnreachable reports whether a failed limactl shell never reached the
+// guest, so retrying after a delay may succeed.
+func limaVMUnreachable(output string, err error) bool {
+	if err == nil {
+		return false
+	}
+	text := strings.ToLower(output + "\n" + err.Error())
+	for _, marker := range limaSSHUnreachableMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// runLimaShell runs args inside vmName with limactl shell, retrying while
+// the VM is not reachable over ssh yet.
+func runLimaShell(ctx context.Context, vmName string, args ...string) (string, error) {
+	command := append([]string{"shell", vmName, "--"}, args...)
+	var output []byte
+	var err error
+	for attempt := 1; ; attempt++ {
+		output, err = runner.CombinedOutput(ctx, nil, "limactl", command...)
+		if attempt >= limaShellAttempts || !limaVMUnreachable(string(output), err) {
+			return string(output), err
+		}
+		select {
+		case <-ctx.Done():
+			return string(output), err
+		case <-time.After(limaShellRetryDelay):
+		}
+	}
+}
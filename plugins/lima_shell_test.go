@@ -0,0 +1,90 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// flakyRunner answers CombinedOutput from a queue before falling back to
+// the wrapped fakeRunner, to script a guest that comes up mid-retry.
+type flakyRunner struct {
+	*fakeRunner
+	queue []fakeResponse
+}
+
+func (f *flakyRunner) CombinedOutput(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	output, err := f.fakeRunner.CombinedOutput(ctx, env, name, args...)
+	if len(f.queue) == 0 {
+		return output, err
+	}
+	next := f.queue[0]
+	f.queue = f.queue[1:]
+	return []byte(next.Output), next.Err
+}
+
+func useFlakyRunner(t *testing.T, queue ...fakeResponse) *flakyRunner {
+	t.Helper()
+	flaky := &flakyRunner{fakeRunner: useFakeRunner(t, nil), queue: queue}
+	runner = flaky
+	delay := limaShellRetryDelay
+	limaShellRetryDelay = 0
+	t.Cleanup(func() { limaShellRetryDelay = delay })
+	return flaky
+}
+
+func TestLimaVMUnreachable(t *testing.T) {
+	exit255 := errors.New("exit status 255")
+	for _, tt := range []struct {
+		output string
+		err    error
+		want   bool
+	}{
+		{"ssh: connect to host 127.0.0.1 port 60022: Connection refused\n", exit255, true},
+		{"kex_exchange_identification: read: Connection reset by peer\n", exit255, true},
+		{"ssh: connect to host 127.0.0.1 port 60022: Operation timed out\n", exit255, true},
+		{"Error response from daemon: conflict: unable to delete image\n", errors.New("exit status 1"), false},
+		{"fstrim: /: the discard operation is not supported\n", errors.New("exit status 1"), false},
+		{"Connection refused in the log of a successful command", nil, false},
+	} {
+		if got := limaVMUnreachable(tt.output, tt.err); got != tt.want {
+			t.Errorf("limaVMUnreachable(%q, %v) = %v, want %v", tt.output, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRunLimaShellRetriesUntilReachable(t *testing.T) {
+	refused := fakeResponse{Output: "ssh: connect to host 127.0.0.1 port 60022: Connection refused\n", Err: errors.New("exit status 255")}
+	flaky := useFlakyRunner(t, refused, refused, fakeResponse{Output: "/dev/vda1 ext4\n"})
+
+	root, _, err := readLimaGuestRoot(context.Background(), "default")
+	if err != nil || root.Source != "/dev/vda1" {
+		t.Fatalf("expected the guest root after the VM came up, got %+v, %v", root, err)
+	}
+	if calls := len(flaky.calls); calls != 3 {
+		t.Fatalf("expected two retries, got %d calls", calls)
+	}
+}
+
+func TestRunLimaShellDoesNotRetryCommandFailures(t *testing.T) {
+	flaky := useFlakyRunner(t, fakeResponse{Output: "findmnt: can't read /proc/mounts", Err: errors.New("exit status 1")})
+	if _, err := runLimaShell(context.Background(), "default", limaGuestRootArgs...); err == nil {
+		t.Fatal("expected the command failure to be returned")
+	}
+	if calls := len(flaky.calls); calls != 1 {
+		t.Fatalf("a command that ran and failed should not be retried, got %d calls", calls)
+	}
+
+	refused := fakeResponse{Output: "ssh: connect to host 127.0.0.1 port 60022: Connection refused\n", Err: errors.New("exit status 255")}
+	queue := make([]fakeResponse, limaShellAttempts+1)
+	for i := range queue {
+		queue[i] = refused
+	}
+	flaky = useFlakyRunner(t, queue...)
+	if _, err := runLimaShell(context.Background(), "default", "true"); err == nil {
+		t.Fatal("expected an unreachable VM to fail after the last attempt")
+	}
+	if calls := len(flaky.calls); calls != limaShellAttempts {
+		t.Fatalf("expected %d attempts, got %d", limaShellAttempts, calls)
+	}
+}
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+const (
+	limaTrimStateVersion  = 1
+	limaTrimStateFileName = "lima-trim-state.json"
+
+	// limaTrimRecheckInterval is how long a VM recorded as not supporting
+	// fstrim is left alone before trim is probed again, e.g. after the VM is
+	// recreated on a different vmType.
+	limaTrimRecheckInterval = 7 * 24 * time.Hour
+
+	// limaCompactSparseRatio is the allocated-to-apparent size percentage at
+	// or below which a Lima disk image is considered bloated enough to compact.
+	limaCompactSparseRatio = 70
+)
+
+// limaTrimState records per VM whether fstrim reached the host disk image.
+// Some VM backends, such as krunkit, reject discard, so trimming them every
+// cycle only produces the same failure.
+type limaTrimState struct {
+	Version int                       `json:"version"`
+	VMs     map[string]limaTrimRecord `json:"vms"`
+}
+
+// limaTrimRecord is the last fstrim probe result for one VM.
+type limaTrimRecord struct {
+	Supported bool      `json:"supported"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func newLimaTrimState() *limaTrimState {
+	return &limaTrimState{Version: limaTrimStateVersion, VMs: map[string]limaTrimRecord{}}
+}
+
+// limaTrimStatePath places the trim state next to the daemon state file.
+func limaTrimStatePath(cfg *config.Config) string {
+	if cfg.Policy.StateFile == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.Policy.StateFile), limaTrimStateFileName)
+}
+
+// loadLimaTrimState reads the trim state at path. A missing, unreadable, or
+// outdated file yields an empty state, so every VM is probed again.
+func loadLimaTrimState(path string) *limaTrimState {
+	state := newLimaTrimState()
+	if path == "" {
+		return state
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	var loaded limaTrimState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return state
+	}
+	if loaded.Version != limaTrimStateVersion || loaded.VMs == nil {
+		return state
+	}
+	return &loaded
+}
+
+// save writes the trim state atomically.
+func (s *limaTrimState) save(path string) error {
+	if s == nil || path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// shouldTrim reports whether fstrim should run for vm. VMs recorded as not
+// supporting trim are skipped until limaTrimRecheckInterval has passed.
+func (s *limaTrimState) shouldTrim(vm string, now time.Time) bool {
+	record, ok := s.VMs[vm]
+	if !ok || record.Supported {
+		return true
+	}
+	return now.Sub(record.CheckedAt) >= limaTrimRecheckInterval
+}
+
+// knownUnsupported reports whether vm was last recorded as not supporting trim.
+func (s *limaTrimState) knownUnsupported(vm string) bool {
+	record, ok := s.VMs[vm]
+	return ok && !record.Supported
+}
+
+// record stores the outcome of an fstrim probe for vm.
+func (s *limaTrimState) record(vm string, supported bool, now time.Time) {
+	s.VMs[vm] = limaTrimRecord{Supported: supported, CheckedAt: now}
+}
+
+// fstrimUnsupported reports whether fstrim output says discard is not
+// supported, e.g. "fstrim: /: the discard operation is not supported".
+func fstrimUnsupported(output string) bool {
+	return strings.Contains(strings.ToLower(output), "not supported")
+}
+
+// limaDiskBloated reports whether a disk image's allocated size is a small
+// enough share of its apparent size that offline compaction is worthwhile.
+// Unknown sizes are treated as bloated and left to compaction to measure.
+func limaDiskBloated(allocated int64, apparent int64) bool {
+	if allocated <= 0 || apparent <= 0 {
+		return true
+	}
+	return float64(allocated)/float64(apparent)*100 <= limaCompactSparseRatio
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestFstrimUnsupported(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"fstrim: /: the discard operation is not supported\n", true},
+		{"fstrim: /var: FITRIM ioctl failed: Operation not supported\n", true},
+		{"/: 1.5 GiB (1610612736 bytes) trimmed on /dev/vda1\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := fstrimUnsupported(tt.output); got != tt.want {
+			t.Errorf("fstrimUnsupported(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestLimaTrimStateSkipsUnsupportedUntilRecheck(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	path := limaTrimStatePath(cfg)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	state := loadLimaTrimState(path)
+	if !state.shouldTrim("krunkit", now) {
+		t.Fatal("expected an unknown VM to be trimmed")
+	}
+	state.record("krunkit", false, now)
+	state.record("colima", true, now)
+	if err := state.save(path); err != nil {
+		t.Fatalf("save trim state: %v", err)
+	}
+
+	loaded := loadLimaTrimState(path)
+	if !loaded.knownUnsupported("krunkit") || loaded.knownUnsupported("colima") {
+		t.Fatalf("unexpected loaded state %+v", loaded.VMs)
+	}
+	if loaded.shouldTrim("krunkit", now.Add(time.Hour)) {
+		t.Error("expected unsupported VM to skip trim before recheck")
+	}
+	if !loaded.shouldTrim("krunkit", now.Add(limaTrimRecheckInterval)) {
+		t.Error("expected unsupported VM to be probed again after recheck interval")
+	}
+	if !loaded.shouldTrim("colima", now.Add(time.Hour)) {
+		t.Error("expected supported VM to be trimmed every cycle")
+	}
+}
+
+func TestLoadLimaTrimStateWithoutStateFile(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = ""
+	state := loadLimaTrimState(limaTrimStatePath(cfg))
+	if len(state.VMs) != 0 {
+		t.Fatalf("expected empty state, got %+v", state.VMs)
+	}
+	if err := state.save(""); err != nil {
+		t.Fatalf("save without path: %v", err)
+	}
+}
+
+func TestLimaDiskBloated(t *testing.T) {
+	const gb = int64(1 << 30)
+	if !limaDiskBloated(20*gb, 100*gb) {
+		t.Error("expected 20% allocation to be bloated")
+	}
+	if limaDiskBloated(90*gb, 100*gb) {
+		t.Error("expected 90% allocation to be compact")
+	}
+	if !limaDiskBloated(0, 100*gb) {
+		t.Error("expected unknown allocation to defer to compaction")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 const nixDefaultCommandTimeout = 20 * time.Minute
@@ -53,6 +54,16 @@ func (p *NixPlugin) Description() string {
 	return "Runs Nix garbage collection with generation and daemon-contention safeguards"
 }
 
+// Priority runs Nix GC after cheap caches; deleting generations costs rollbacks.
+func (p *NixPlugin) Priority() int {
+	return 40
+}
+
+// DataPaths returns the Nix store volume.
+func (p *NixPlugin) DataPaths(cfg *config.Config) []string {
+	return []string{nixHostMeasurePath(cfg.Nix)}
+}
+
 // SupportedPlatforms returns supported platforms (all).
 func (p *NixPlugin) SupportedPlatforms() []string {
 	return nil // All platforms (Nix can be installed anywhere)
@@ -63,6 +74,20 @@ func (p *NixPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.NixGC
 }
 
+// LevelDescription summarizes Nix cleanup at each level.
+func (p *NixPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "runs Nix garbage collection without deleting profile generations"
+	case LevelModerate, LevelAggressive:
+		return "deletes user generations older than delete_generations_older_than, keeping min_user_generations, then runs Nix garbage collection"
+	case LevelCritical:
+		return "deletes user generations older than critical_delete_generations_older_than, runs Nix garbage collection, and optimizes the store only when allow_store_optimize is true"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup returns a non-mutating Nix cleanup preflight plan.
 func (p *NixPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	plan := CleanupPlan{
@@ -82,6 +107,7 @@ func (p *NixPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *co
 			"daemon_busy_backoff":                       cfg.Nix.DaemonBusyBackoff,
 			"max_gc_duration":                           cfg.Nix.MaxGCDuration,
 			"host_measure_path":                         nixHostMeasurePath(cfg.Nix),
+			"store_volume":                              cfg.Nix.StoreVolume,
 			"root_attribution_limit":                    strconv.Itoa(nixRootAttributionLimit(cfg.Nix)),
 			"generation_policy_delete_older_than_level": nixGenerationPolicyAge(level, cfg.Nix),
 		},
@@ -94,6 +120,21 @@ func (p *NixPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *co
 		return plan
 	}
 
+	layout := p.daemonLayout()
+	plan.Metadata["nix_daemon_layout"] = layout.String()
+	if layout.daemonRequired() && !layout.SocketPresent {
+		plan.WouldRun = false
+		plan.SkipReason = "nix_daemon_not_running"
+		plan.Summary = "Nix cleanup is skipped because the Nix daemon socket is missing"
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("start the Nix daemon so %s exists before running Nix cleanup", nixDaemonSocketPath))
+		return plan
+	}
+	useSudo := p.gcUsesSudo(ctx, layout)
+	plan.Metadata["nix_gc_privilege"] = nixGCPrivilege(useSudo)
+	if layout.daemonRequired() && !useSudo && os.Geteuid() != 0 {
+		plan.Warnings = append(plan.Warnings, "Nix garbage collection will run without root because passwordless sudo is unavailable; store paths owned by other users' roots stay live")
+	}
+
 	if busy, err := p.activeNixProcesses(ctx); err != nil {
 		if cfg.Nix.SkipWhenDaemonBusy {
 			nixDeferPlan(&plan,
@@ -191,6 +232,19 @@ func (p *NixPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config
 		return result
 	}
 
+	layout := p.daemonLayout()
+	if layout.daemonRequired() && !layout.SocketPresent {
+		logger.Warn("skipping Nix cleanup because the Nix daemon socket is missing",
+			"layout", layout.String(),
+			"socket", nixDaemonSocketPath)
+		return result
+	}
+	useSudo := p.gcUsesSudo(ctx, layout)
+	if layout.daemonRequired() && !useSudo && os.Geteuid() != 0 {
+		logger.Warn("running Nix garbage collection without root; passwordless sudo is unavailable",
+			"layout", layout.String())
+	}
+
 	if cfg.Nix.SkipWhenDaemonBusy {
 		busy, err := p.activeNixProcesses(ctx)
 		if err != nil {
@@ -224,7 +278,7 @@ func (p *NixPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config
 		logger.Warn("skipping Nix garbage collection because dry-run preflight failed",
 			"error", dryRunErr,
 			"output", strings.TrimSpace(dryRunOutput))
-		result.Error = fmt.Errorf("nix garbage collection preflight failed: %w", dryRunErr)
+		result.Error = newCommandError(p.Name(), "gc_preflight", dryRunErr, dryRunOutput)
 		return result
 	} else if p.nixDryRunHasNoGCWork(dryRunOutput) && generationsDeleted == 0 && !(level == LevelCritical && cfg.Nix.AllowStoreOptimize) {
 		logger.Info("skipping Nix garbage collection because dry-run reported no reclaimable store paths")
@@ -233,14 +287,14 @@ func (p *NixPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config
 
 	switch level {
 	case LevelWarning, LevelModerate, LevelAggressive:
-		gcResult := p.collectGarbage(ctx, level, nil, cfg.Nix, logger)
+		gcResult := p.collectGarbage(ctx, level, useSudo, nil, cfg.Nix, logger)
 		result.BytesFreed += gcResult.BytesFreed
 		result.CommandBytesFreed += gcResult.CommandBytesFreed
 		result.HostBytesFreed += gcResult.HostBytesFreed
 		result.ItemsCleaned += gcResult.ItemsCleaned
 		result.Error = gcResult.Error
 	case LevelCritical:
-		gcResult := p.collectGarbageCritical(ctx, cfg.Nix, logger)
+		gcResult := p.collectGarbageCritical(ctx, useSudo, cfg.Nix, logger)
 		result.BytesFreed += gcResult.BytesFreed
 		result.CommandBytesFreed += gcResult.CommandBytesFreed
 		result.HostBytesFreed += gcResult.HostBytesFreed
@@ -310,24 +364,25 @@ func (p *NixPlugin) planGCRootAttribution(ctx context.Context, cfg config.NixCon
 	return targets, metadata, nil
 }
 
-func (p *NixPlugin) collectGarbage(ctx context.Context, level CleanupLevel, args []string, cfg config.NixConfig, logger *slog.Logger) CleanupResult {
+func (p *NixPlugin) collectGarbage(ctx context.Context, level CleanupLevel, useSudo bool, args []string, cfg config.NixConfig, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: level}
 
-	logger.Debug("running nix-collect-garbage", "args", strings.Join(args, " "))
+	logger.Debug("running nix-collect-garbage", "args", strings.Join(args, " "), "privilege", nixGCPrivilege(useSudo))
 	measurePath := nixHostMeasurePath(cfg)
 	before, beforeOK := p.measureFreeDiskSpace(measurePath, logger)
 
 	ctx, cancel := context.WithTimeout(ctx, nixCommandTimeout(cfg))
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "nix-collect-garbage", args...)
+	name, cmdArgs := nixCommandArgs(useSudo, "nix-collect-garbage", args...)
+	cmd := exec.CommandContext(ctx, name, cmdArgs...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		if reason, ok := nixContentionReason(string(output)); ok && cfg.SkipWhenDaemonBusy {
 			logger.Warn("skipping Nix garbage collection because store contention was reported", "reason", reason)
 			return result
 		}
-		result.Error = err
+		result.Error = newCommandError(p.Name(), "gc", err, string(output))
 		return result
 	}
 
@@ -341,23 +396,37 @@ func (p *NixPlugin) collectGarbage(ctx context.Context, level CleanupLevel, args
 	return result
 }
 
+// nixHostMeasurePath returns the filesystem used for host free-space deltas.
+// store_volume wins over host_measure_path because on macOS the store is a
+// separate APFS volume whose free space differs from the home volume. A
+// configured path under an unavailable home directory is passed over rather
+// than measured relative to the working directory.
 func nixHostMeasurePath(cfg config.NixConfig) string {
+	home, err := env.HomeDir()
+	if err != nil {
+		home = ""
+	}
+	if volume := strings.TrimSpace(cfg.StoreVolume); volume != "" {
+		volume = filepath.Clean(expandHome(volume, home))
+		if filepath.IsAbs(volume) && pathExists(volume) {
+			return volume
+		}
+	}
 	path := strings.TrimSpace(cfg.HostMeasurePath)
 	if path == "" {
 		path = "/nix/store"
 	}
-	home, _ := os.UserHomeDir()
 	path = filepath.Clean(expandHome(path, home))
-	if pathExists(path) {
+	if filepath.IsAbs(path) && pathExists(path) {
 		return path
 	}
-	if pathExists("/nix") {
+	if pathExists(nixDarwinStoreVolume) {
 		return "/nix"
 	}
 	if home != "" && pathExists(home) {
 		return home
 	}
-	return "."
+	return "/"
 }
 
 func (p *NixPlugin) measureFreeDiskSpace(path string, logger *slog.Logger) (int64, bool) {
@@ -381,11 +450,11 @@ func (p *NixPlugin) measuredHostDelta(before int64, path string, logger *slog.Lo
 	return after - before
 }
 
-func (p *NixPlugin) collectGarbageCritical(ctx context.Context, cfg config.NixConfig, logger *slog.Logger) CleanupResult {
+func (p *NixPlugin) collectGarbageCritical(ctx context.Context, useSudo bool, cfg config.NixConfig, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: LevelCritical}
 
 	logger.Warn("CRITICAL: running Nix garbage collection")
-	gcResult := p.collectGarbage(ctx, LevelCritical, nil, cfg, logger)
+	gcResult := p.collectGarbage(ctx, LevelCritical, useSudo, nil, cfg, logger)
 	result.BytesFreed = gcResult.BytesFreed
 	result.CommandBytesFreed = gcResult.CommandBytesFreed
 	result.HostBytesFreed = gcResult.HostBytesFreed
@@ -406,7 +475,8 @@ func (p *NixPlugin) collectGarbageCritical(ctx context.Context, cfg config.NixCo
 	optimizeCtx, cancel := context.WithTimeout(ctx, nixCommandTimeout(cfg))
 	defer cancel()
 
-	cmd := exec.CommandContext(optimizeCtx, "nix-store", "--optimize")
+	name, args := nixCommandArgs(useSudo, "nix-store", "--optimize")
+	cmd := exec.CommandContext(optimizeCtx, name, args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		logger.Error("nix-store --optimize failed", "error", err, "output", string(output))
@@ -464,7 +534,7 @@ func (p *NixPlugin) deleteUserGenerationsByPolicy(ctx context.Context, level Cle
 			logger.Warn("skipping Nix generation deletion because store contention was reported", "reason", reason)
 			return result
 		}
-		result.Error = fmt.Errorf("nix-env %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		result.Error = newCommandError(p.Name(), "generation_delete", err, string(output))
 		return result
 	}
 
@@ -498,7 +568,7 @@ func (p *NixPlugin) planGenerationTargets(ctx context.Context, level CleanupLeve
 		}
 	}
 
-	if home, err := os.UserHomeDir(); err != nil {
+	if home, err := env.HomeDir(); err != nil {
 		warnings = append(warnings, fmt.Sprintf("could not locate user home for lock-free Nix profile inspection: %v", err))
 	} else {
 		stateProfilesDir := filepath.Join(home, ".local", "state", "nix", "profiles")
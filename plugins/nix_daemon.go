@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"runtime"
+)
+
+// Well-known paths for multi-user Nix installs. On macOS the store lives on a
+// dedicated APFS volume mounted at /nix and GC goes through the daemon socket.
+const (
+	nixDaemonSocketPath       = "/nix/var/nix/daemon-socket/socket"
+	nixDaemonPlistPath        = "/Library/LaunchDaemons/org.nixos.nix-daemon.plist"
+	determinateNixdPath       = "/usr/local/bin/determinate-nixd"
+	determinateNixDaemonPlist = "/Library/LaunchDaemons/systems.determinate.nix-daemon.plist"
+	nixDarwinStoreVolume      = "/nix"
+)
+
+// nixDaemonLayout describes how Nix is installed on this host.
+type nixDaemonLayout struct {
+	// Darwin is true on macOS.
+	Darwin bool
+	// MultiUser is true when a nix-daemon launchd job or socket is present.
+	MultiUser bool
+	// Determinate is true when the Determinate Systems nixd daemon is installed.
+	Determinate bool
+	// SocketPresent is true when the daemon socket exists.
+	SocketPresent bool
+}
+
+// detectNixDaemonLayout inspects the install markers for a Nix daemon.
+func detectNixDaemonLayout(goos string, exists func(string) bool) nixDaemonLayout {
+	layout := nixDaemonLayout{
+		Darwin:        goos == "darwin",
+		SocketPresent: exists(nixDaemonSocketPath),
+	}
+	layout.Determinate = exists(determinateNixdPath) || exists(determinateNixDaemonPlist)
+	layout.MultiUser = layout.SocketPresent || layout.Determinate || exists(nixDaemonPlistPath)
+	return layout
+}
+
+// String names the layout for plan metadata.
+func (l nixDaemonLayout) String() string {
+	switch {
+	case l.Darwin && l.Determinate:
+		return "darwin-determinate"
+	case l.Darwin && l.MultiUser:
+		return "darwin-multi-user"
+	case l.MultiUser:
+		return "multi-user"
+	default:
+		return "single-user"
+	}
+}
+
+// daemonRequired reports whether GC cannot proceed without the daemon socket.
+func (l nixDaemonLayout) daemonRequired() bool {
+	return l.Darwin && l.MultiUser
+}
+
+// nixGCUsesSudo reports whether store-wide Nix commands should run through
+// sudo -H. Only Darwin daemon installs need it, and only when the daemon is
+// not already running as the caller and sudo will not prompt.
+func nixGCUsesSudo(layout nixDaemonLayout, euid int, sudoPasswordless bool) bool {
+	return layout.daemonRequired() && euid != 0 && sudoPasswordless
+}
+
+// nixCommandArgs returns the program and arguments for a store-wide Nix
+// command, wrapped in non-interactive sudo -H when useSudo is set.
+func nixCommandArgs(useSudo bool, name string, args ...string) (string, []string) {
+	if !useSudo {
+		return name, args
+	}
+	return "sudo", append([]string{"-n", "-H", name}, args...)
+}
+
+func (p *NixPlugin) daemonLayout() nixDaemonLayout {
+	return detectNixDaemonLayout(runtime.GOOS, pathExists)
+}
+
+func (p *NixPlugin) gcUsesSudo(ctx context.Context, layout nixDaemonLayout) bool {
+	euid := os.Geteuid()
+	if !layout.daemonRequired() || euid == 0 {
+		return false
+	}
+	return nixGCUsesSudo(layout, euid, DetectSudo(ctx).Passwordless)
+}
+
+func nixGCPrivilege(useSudo bool) string {
+	if useSudo {
+		return "sudo"
+	}
+	return "user"
+}
@@ -153,7 +153,7 @@ printf '%s\n' "16.0 MiB freed"
 	}
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	result := p.collectGarbage(context.Background(), LevelWarning, nil, cfg, logger)
+	result := p.collectGarbage(context.Background(), LevelWarning, false, nil, cfg, logger)
 	if result.Error != nil {
 		t.Fatalf("collectGarbage returned error: %v", result.Error)
 	}
@@ -181,7 +181,7 @@ func TestNixCollectGarbageSkipsHostDeltaWhenMeasurementFails(t *testing.T) {
 	}
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	result := p.collectGarbage(context.Background(), LevelWarning, nil, cfg, logger)
+	result := p.collectGarbage(context.Background(), LevelWarning, false, nil, cfg, logger)
 	if result.Error != nil {
 		t.Fatalf("collectGarbage returned error: %v", result.Error)
 	}
@@ -206,6 +206,82 @@ func TestNixHostMeasurePathDefaultsAndFallbacks(t *testing.T) {
 	}
 }
 
+func TestNixHostMeasurePathPrefersStoreVolume(t *testing.T) {
+	storeVolume := t.TempDir()
+	cfg := config.NixConfig{HostMeasurePath: t.TempDir(), StoreVolume: storeVolume}
+	if got := nixHostMeasurePath(cfg); got != storeVolume {
+		t.Fatalf("expected store volume %q, got %q", storeVolume, got)
+	}
+
+	cfg.StoreVolume = filepath.Join(storeVolume, "missing")
+	if got := nixHostMeasurePath(cfg); got != cfg.HostMeasurePath {
+		t.Fatalf("missing store volume should fall back to %q, got %q", cfg.HostMeasurePath, got)
+	}
+}
+
+func TestDetectNixDaemonLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		present []string
+		want    string
+		socket  bool
+	}{
+		{name: "linux single-user", goos: "linux", want: "single-user"},
+		{name: "linux daemon", goos: "linux", present: []string{nixDaemonSocketPath}, want: "multi-user", socket: true},
+		{name: "darwin upstream daemon", goos: "darwin", present: []string{nixDaemonPlistPath, nixDaemonSocketPath}, want: "darwin-multi-user", socket: true},
+		{name: "darwin determinate", goos: "darwin", present: []string{determinateNixdPath, nixDaemonSocketPath}, want: "darwin-determinate", socket: true},
+		{name: "darwin daemon stopped", goos: "darwin", present: []string{determinateNixDaemonPlist}, want: "darwin-determinate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists := func(path string) bool {
+				for _, present := range tt.present {
+					if path == present {
+						return true
+					}
+				}
+				return false
+			}
+			layout := detectNixDaemonLayout(tt.goos, exists)
+			if layout.String() != tt.want {
+				t.Fatalf("layout = %q, want %q", layout.String(), tt.want)
+			}
+			if layout.SocketPresent != tt.socket {
+				t.Fatalf("SocketPresent = %v, want %v", layout.SocketPresent, tt.socket)
+			}
+		})
+	}
+}
+
+func TestNixGCUsesSudoOnlyForDarwinDaemonInstalls(t *testing.T) {
+	darwinDaemon := nixDaemonLayout{Darwin: true, MultiUser: true, SocketPresent: true}
+	if !nixGCUsesSudo(darwinDaemon, 501, true) {
+		t.Fatal("expected sudo for non-root Darwin daemon install with passwordless sudo")
+	}
+	if nixGCUsesSudo(darwinDaemon, 0, true) {
+		t.Fatal("root should not wrap Nix GC in sudo")
+	}
+	if nixGCUsesSudo(darwinDaemon, 501, false) {
+		t.Fatal("sudo that would prompt must not be used")
+	}
+	if nixGCUsesSudo(nixDaemonLayout{MultiUser: true, SocketPresent: true}, 1000, true) {
+		t.Fatal("Linux daemon installs should keep running Nix GC as the caller")
+	}
+}
+
+func TestNixCommandArgs(t *testing.T) {
+	name, args := nixCommandArgs(false, "nix-collect-garbage")
+	if name != "nix-collect-garbage" || len(args) != 0 {
+		t.Fatalf("unexpected unprivileged command: %s %v", name, args)
+	}
+
+	name, args = nixCommandArgs(true, "nix-store", "--optimize")
+	if got := name + " " + strings.Join(args, " "); got != "sudo -n -H nix-store --optimize" {
+		t.Fatalf("unexpected sudo command: %q", got)
+	}
+}
+
 func TestParseNixPolicyDuration(t *testing.T) {
 	tests := []struct {
 		raw      string
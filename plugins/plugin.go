@@ -5,6 +5,7 @@ import (
 	"context"
 	"log/slog"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
@@ -80,12 +81,41 @@ type CleanupResult struct {
 	CommandBytesFreed int64
 	// HostBytesFreed is measured from host free-space deltas when isolated.
 	HostBytesFreed int64
+	// BytesGrown is how much measured targets grew during cleanup, e.g. when
+	// a cache tool rewrites its index. Growth never reduces BytesFreed.
+	BytesGrown int64
 	// ItemsCleaned is the number of items cleaned (files, images, etc.)
 	ItemsCleaned int
+	// ProtectedSkippedItems counts cleanup candidates left in place because
+	// they matched a protect list.
+	ProtectedSkippedItems int
+	// ProtectedSkippedBytes is the size of those candidates, where they were
+	// already sized when the protect check ran.
+	ProtectedSkippedBytes int64
+	// WalkPermissionSkips counts paths a scan skipped because they could not
+	// be read for lack of permission. Their size is missing from the totals.
+	WalkPermissionSkips int
+	// WalkErrorSkips counts paths a scan skipped for any other read error.
+	WalkErrorSkips int
+	// DeepGCBytesFreed is the BuildKit state shrink measured after a deep
+	// build cache GC, beyond what the normal builder prune reclaimed.
+	DeepGCBytesFreed int64
+	// CompressedBytesSaved is the space saved by compressing files in place
+	// instead of deleting them. It is not part of BytesFreed.
+	CompressedBytesSaved int64
+	// CleanedPaths lists configured paths the plugin emptied, such as
+	// cache.extra_paths entries, with the bytes freed from each.
+	CleanedPaths []CleanedPath
 	// Error if cleanup failed
 	Error error
 }
 
+// CleanedPath is one configured path a plugin emptied.
+type CleanedPath struct {
+	Path       string `json:"path"`
+	BytesFreed int64  `json:"bytes_freed"`
+}
+
 // CleanupPlan describes what a dry-run cleanup cycle would do.
 type CleanupPlan struct {
 	// Plugin is the plugin that produced the plan.
@@ -159,6 +189,7 @@ func hostReclaimExpectation(reclaim string) *bool {
 func hostReclaimForAction(action string) string {
 	switch {
 	case strings.HasPrefix(action, "delete"),
+		strings.HasPrefix(action, "compress"),
 		action == "stop_idle_server_then_delete_output_base",
 		action == "clean-cache",
 		action == "clean-stale-files",
@@ -202,9 +233,183 @@ type Planner interface {
 	PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan
 }
 
+// LevelDescriber is implemented by plugins that can summarize what each
+// cleanup level does, so operators can review the blast radius before enabling.
+type LevelDescriber interface {
+	LevelDescription(level CleanupLevel) string
+}
+
+// CommandExplainer is implemented by command-oriented plugins that can list
+// the external commands they would run at a level without executing them.
+type CommandExplainer interface {
+	CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string
+}
+
+// Prioritizer is implemented by plugins that declare their reclaim order.
+// Lower priorities run first, so cheap reversible cleanup can satisfy the
+// target before expensive or destructive plugins are reached.
+type Prioritizer interface {
+	Priority() int
+}
+
+// HeavyWorker is implemented by plugins whose cleanup at some levels is CPU- or
+// I/O-heavy, such as VM disk compaction, large filesystem scans, or repacks.
+// The daemon can defer heavy plugins when the host should stay quiet.
+type HeavyWorker interface {
+	HeavyAt(level CleanupLevel, cfg *config.Config) bool
+}
+
+// IsHeavy reports whether p declares heavy work at level.
+func IsHeavy(p Plugin, level CleanupLevel, cfg *config.Config) bool {
+	if worker, ok := p.(HeavyWorker); ok {
+		return worker.HeavyAt(level, cfg)
+	}
+	return false
+}
+
+// VolumeDeleter is implemented by plugins that delete files directly on a
+// host volume, so their reported BytesFreed should match that volume's
+// free-space delta. FreedBytesVolume returns a path on the volume; empty
+// means the daemon's primary monitored path.
+type VolumeDeleter interface {
+	FreedBytesVolume(cfg *config.Config) string
+}
+
+// MountScoped is implemented by plugins whose reclaimable data lives on a
+// particular filesystem, such as container storage or VM disk images.
+// DataPaths returns paths on those filesystems. When several monitored mounts
+// reach the cycle's level, the daemon runs a mount-scoped plugin only if one
+// of its paths is on the prioritized mount. Other plugins run regardless.
+type MountScoped interface {
+	DataPaths(cfg *config.Config) []string
+}
+
+// VMDiskReporter is implemented by plugins that clean inside VMs. It reports
+// each VM's guest disk usage next to its disk image's host allocation,
+// without changing anything.
+type VMDiskReporter interface {
+	VMDisks(ctx context.Context, cfg *config.Config) []VMDiskStatus
+}
+
+// VMDiskStatus is one VM's disk as seen from the guest and the host.
+type VMDiskStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// GuestTotalBytes and GuestUsedBytes are the guest root filesystem's
+	// size and usage; zero when the VM is not running.
+	GuestTotalBytes int64 `json:"guest_total_bytes,omitempty"`
+	GuestUsedBytes  int64 `json:"guest_used_bytes,omitempty"`
+	// DiskPath is the VM's disk image on the host.
+	DiskPath string `json:"disk_path,omitempty"`
+	// HostAllocatedBytes is the disk image's allocated size on the host,
+	// which exceeds GuestUsedBytes by the space fstrim or compaction could
+	// return.
+	HostAllocatedBytes int64  `json:"host_allocated_bytes,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// ProactiveCleaner is implemented by plugins that watch their own usage signal
+// and can reclaim space every cycle, independent of host disk usage.
+type ProactiveCleaner interface {
+	ProactiveCleanup(ctx context.Context, cfg *config.Config, dryRun bool, logger *slog.Logger) ProactiveResult
+}
+
+// ProactiveResult reports one proactive check and any cleanup it triggered.
+type ProactiveResult struct {
+	// Plugin is the plugin that ran the check.
+	Plugin string
+	// Checked is false when proactive cleanup is disabled for the plugin.
+	Checked bool
+	// Triggered reports that reclaimable space exceeded the threshold.
+	Triggered bool
+	// SkipReason explains why no cleanup ran.
+	SkipReason string
+	// ReclaimableBytes is the plugin's own reclaimable-space measurement.
+	ReclaimableBytes int64
+	// ThresholdBytes is the reclaimable size that triggers cleanup.
+	ThresholdBytes int64
+	// BytesFreed is reported by the cleanup commands.
+	BytesFreed int64
+	// ItemsCleaned is the number of cleanup commands that succeeded.
+	ItemsCleaned int
+	// Error is the first cleanup failure, if any.
+	Error error
+	// Hosts are the per-host checks of remote daemons, such as docker.hosts.
+	// Their bytes are freed on the remote host, not counted in BytesFreed.
+	Hosts []RemoteHostResult
+}
+
+// RemoteHostResult is one remote daemon's proactive check.
+type RemoteHostResult struct {
+	// Host is the daemon address, such as a DOCKER_HOST value.
+	Host string
+	// Triggered reports that reclaimable space exceeded the threshold.
+	Triggered bool
+	// SkipReason explains why no cleanup ran.
+	SkipReason string
+	// ReclaimableBytes is the remote daemon's reclaimable-space measurement.
+	ReclaimableBytes int64
+	// ThresholdBytes is the reclaimable size that triggers cleanup.
+	ThresholdBytes int64
+	// BytesFreed is reported by the remote cleanup commands.
+	BytesFreed int64
+	// ItemsCleaned is the number of cleanup commands that succeeded.
+	ItemsCleaned int
+	// Error is the first failure on this host, if any.
+	Error error
+}
+
+// DefaultPriority is used for plugins that do not implement Prioritizer.
+const DefaultPriority = 50
+
+// PluginPriority returns the plugin's declared priority or DefaultPriority.
+func PluginPriority(p Plugin) int {
+	if prioritizer, ok := p.(Prioritizer); ok {
+		return prioritizer.Priority()
+	}
+	return DefaultPriority
+}
+
+// SortByPriority returns a copy of plugins ordered by ascending priority.
+// Plugins with equal priority keep their registration order.
+func SortByPriority(plugins []Plugin) []Plugin {
+	sorted := append([]Plugin(nil), plugins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return PluginPriority(sorted[i]) < PluginPriority(sorted[j])
+	})
+	return sorted
+}
+
+// SortByOrder returns a copy of plugins with those named in order first, in
+// that order, followed by the remaining plugins in their original order.
+func SortByOrder(plugins []Plugin, order []string) []Plugin {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+	sorted := append([]Plugin(nil), plugins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, iListed := rank[sorted[i].Name()]
+		rj, jListed := rank[sorted[j].Name()]
+		if iListed && jListed {
+			return ri < rj
+		}
+		return iListed && !jListed
+	})
+	return sorted
+}
+
+// ActionLevels returns the cleanup levels that perform work, in escalation order.
+func ActionLevels() []CleanupLevel {
+	return []CleanupLevel{LevelWarning, LevelModerate, LevelAggressive, LevelCritical}
+}
+
 // Registry holds registered cleanup plugins.
 type Registry struct {
 	plugins []Plugin
+	skipped map[string]bool
 }
 
 // NewRegistry creates a new plugin registry.
@@ -219,12 +424,28 @@ func (r *Registry) Register(p Plugin) {
 	r.plugins = append(r.plugins, p)
 }
 
-// GetEnabled returns all enabled plugins for the current platform and configuration.
+// Skip makes GetEnabled leave out the named plugins whatever the config
+// enables, for the -skip flag.
+func (r *Registry) Skip(names ...string) {
+	if r.skipped == nil {
+		r.skipped = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		r.skipped[name] = true
+	}
+}
+
+// GetEnabled returns all enabled plugins for the current platform and
+// configuration, leaving out plugins passed to Skip.
 func (r *Registry) GetEnabled(cfg *config.Config) []Plugin {
 	platform := currentPlatform()
 	enabled := make([]Plugin, 0)
 
 	for _, p := range r.plugins {
+		if r.skipped[p.Name()] {
+			continue
+		}
+
 		// Check if plugin is enabled in config
 		if !p.Enabled(cfg) {
 			continue
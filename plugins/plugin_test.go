@@ -4,9 +4,12 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 func TestCleanupLevelString(t *testing.T) {
@@ -126,6 +129,91 @@ func TestRegistryEnabledFiltering(t *testing.T) {
 	}
 }
 
+func TestRegistrySkip(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockPlugin{name: "docker", enabledVal: true})
+	registry.Register(&mockPlugin{name: "lima", enabledVal: true})
+	registry.Register(&mockPlugin{name: "cache", enabledVal: true})
+	registry.Skip("docker", "lima")
+
+	enabled := registry.GetEnabled(config.DefaultConfig())
+	if len(enabled) != 1 || enabled[0].Name() != "cache" {
+		t.Fatalf("expected only cache after skipping docker and lima, got %d plugins", len(enabled))
+	}
+	if len(registry.GetAll()) != 3 {
+		t.Fatalf("expected skipped plugins to stay registered, got %d", len(registry.GetAll()))
+	}
+}
+
+func TestSortByPriorityIsStableAndDefaultsUnprioritized(t *testing.T) {
+	registered := []Plugin{
+		&mockPlugin{name: "default-a"},
+		&prioritizedMockPlugin{mockPlugin: mockPlugin{name: "destructive"}, priority: 90},
+		&prioritizedMockPlugin{mockPlugin: mockPlugin{name: "cheap"}, priority: 10},
+		&mockPlugin{name: "default-b"},
+	}
+
+	sorted := SortByPriority(registered)
+	var names []string
+	for _, plugin := range sorted {
+		names = append(names, plugin.Name())
+	}
+	if got := strings.Join(names, ","); got != "cheap,default-a,default-b,destructive" {
+		t.Fatalf("unexpected priority order: %s", got)
+	}
+	if registered[0].Name() != "default-a" {
+		t.Fatal("SortByPriority must not reorder its input")
+	}
+}
+
+func TestSortByOrderPutsListedPluginsFirst(t *testing.T) {
+	registered := []Plugin{
+		&mockPlugin{name: "cache"},
+		&mockPlugin{name: "lima"},
+		&mockPlugin{name: "nix"},
+		&mockPlugin{name: "docker"},
+	}
+
+	var names []string
+	for _, plugin := range SortByOrder(registered, []string{"docker", "lima", "absent"}) {
+		names = append(names, plugin.Name())
+	}
+	if got := strings.Join(names, ","); got != "docker,lima,cache,nix" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+	if registered[0].Name() != "cache" {
+		t.Fatal("SortByOrder must not reorder its input")
+	}
+}
+
+func TestBuiltinPluginPriorityOrdersPrunesBeforeVMWork(t *testing.T) {
+	for _, tc := range []struct {
+		plugin Plugin
+		want   int
+	}{
+		{NewCachePlugin(), 10},
+		{NewDockerPlugin(), 50},
+		{NewPodmanPlugin(), 50},
+		{NewContainerdPlugin(), 50},
+	} {
+		if got := PluginPriority(tc.plugin); got != tc.want {
+			t.Errorf("%s priority = %d, want %d", tc.plugin.Name(), got, tc.want)
+		}
+	}
+	if PluginPriority(NewSparseFilesPlugin()) <= 100 {
+		t.Error("expected the sparse file report after VM and snapshot work")
+	}
+}
+
+type prioritizedMockPlugin struct {
+	mockPlugin
+	priority int
+}
+
+func (m *prioritizedMockPlugin) Priority() int {
+	return m.priority
+}
+
 // mockPlugin implements Plugin for testing
 type mockPlugin struct {
 	name        string
@@ -167,6 +255,18 @@ func TestDockerPluginName(t *testing.T) {
 	}
 }
 
+func TestDockerLevelDescriptionNamesPruneAges(t *testing.T) {
+	p := NewDockerPlugin()
+	for level, want := range map[CleanupLevel]string{
+		LevelModerate:   "prune_ages.moderate",
+		LevelAggressive: "prune_ages.aggressive",
+	} {
+		if got := p.LevelDescription(level); !strings.Contains(got, want) {
+			t.Errorf("%s description %q does not mention %s", level, got, want)
+		}
+	}
+}
+
 func TestDockerPluginEnabled(t *testing.T) {
 	p := NewDockerPlugin()
 	cfg := config.DefaultConfig()
@@ -260,6 +360,32 @@ Build Cache     20        0         2GiB      512MiB
 	}
 }
 
+func TestDockerProactiveReclaimableExcludesVolumes(t *testing.T) {
+	rows := parseDockerDFSummaryRows(`TYPE            TOTAL     ACTIVE    SIZE      RECLAIMABLE
+Images          12        3         8GB       4GB (50%)
+Containers      2         0         1GB       1GB (100%)
+Local Volumes   4         1         10GB      6GB (60%)
+Build Cache     20        0         2GiB      1GiB
+`)
+	if got, want := dockerProactiveReclaimableBytes(rows), int64(6<<30); got != want {
+		t.Fatalf("proactive reclaimable = %d, want %d", got, want)
+	}
+	if got := dockerProactiveThresholdBytes(config.DockerConfig{}); got != 10<<30 {
+		t.Fatalf("default threshold = %d, want 10GiB", got)
+	}
+	if got := dockerProactiveThresholdBytes(config.DockerConfig{ProactiveReclaimGB: 4}); got != 4<<30 {
+		t.Fatalf("configured threshold = %d, want 4GiB", got)
+	}
+}
+
+func TestDockerProactiveCleanupDisabledByDefault(t *testing.T) {
+	p := NewDockerPlugin()
+	result := p.ProactiveCleanup(context.Background(), config.DefaultConfig(), false, slog.Default())
+	if result.Checked || result.Triggered {
+		t.Fatalf("expected disabled proactive cleanup to skip the check, got %+v", result)
+	}
+}
+
 func TestDockerPlanTargetsCriticalProtectsActiveWork(t *testing.T) {
 	rows := []dockerDFSummaryRow{
 		{Type: "Images", ReclaimableBytes: 10},
@@ -302,6 +428,72 @@ func TestDockerPlanTargetsCriticalEstimatesEligibleBytes(t *testing.T) {
 	}
 }
 
+func TestDockerCleanupCommands(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Docker.PruneImagesAge = "48h"
+	cfg.Docker.Socket = ""
+	p := NewDockerPlugin()
+
+	moderate := p.CleanupCommands(LevelModerate, cfg)
+	if len(moderate) != 4 {
+		t.Fatalf("expected 4 moderate commands, got %d: %v", len(moderate), moderate)
+	}
+	if got := strings.Join(moderate[1], " "); got != "docker image prune -af --filter until=48h" {
+		t.Fatalf("unexpected old image prune command: %q", got)
+	}
+
+	aggressive := p.CleanupCommands(LevelAggressive, cfg)
+	if len(aggressive) != 7 || strings.Join(aggressive[6], " ") != "docker builder prune -af" {
+		t.Fatalf("unexpected aggressive commands: %v", aggressive)
+	}
+
+	cfg.Docker.PruneAges = map[string]string{"aggressive": "6h"}
+	if got := strings.Join(p.CleanupCommands(LevelModerate, cfg)[1], " "); got != "docker image prune -af --filter until=48h" {
+		t.Fatalf("moderate should keep prune_images_age, got %q", got)
+	}
+	if got := strings.Join(p.CleanupCommands(LevelAggressive, cfg)[1], " "); got != "docker image prune -af --filter until=6h" {
+		t.Fatalf("aggressive should use its prune_ages entry, got %q", got)
+	}
+
+	cfg.Docker.Socket = "/run/user/1000/docker.sock"
+	critical := p.CleanupCommands(LevelCritical, cfg)
+	if len(critical) != 1 || strings.Join(critical[0], " ") != "env DOCKER_HOST=unix:///run/user/1000/docker.sock docker system prune -af --volumes" {
+		t.Fatalf("unexpected critical commands: %v", critical)
+	}
+
+	cfg.Docker.DeepBuildCacheGC = true
+	critical = p.CleanupCommands(LevelCritical, cfg)
+	if len(critical) != 2 || strings.Join(critical[1], " ") != "env DOCKER_HOST=unix:///run/user/1000/docker.sock docker exec <buildkit-container> buildctl prune --all" {
+		t.Fatalf("expected the deep BuildKit GC after the system prune, got %v", critical)
+	}
+}
+
+func TestPodmanLevelCommandsGateCriticalSystemPrune(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Podman.CriticalSystemPrune = false
+	if got := podmanLevelCommands(LevelCritical, cfg); len(got) != 0 {
+		t.Fatalf("expected no critical system prune commands when disabled, got %v", got)
+	}
+
+	cfg.Podman.CriticalSystemPrune = true
+	got := podmanLevelCommands(LevelCritical, cfg)
+	if len(got) != 2 || strings.Join(got[0], " ") != "system prune -af --volumes" {
+		t.Fatalf("unexpected critical commands: %v", got)
+	}
+
+	if got := podmanLevelCommands(LevelAggressive, cfg); len(got) != 6 {
+		t.Fatalf("expected aggressive commands to include moderate cleanup, got %v", got)
+	}
+
+	cfg.Podman.PruneAges = map[string]string{"moderate": "72h", "aggressive": "12h"}
+	if got := strings.Join(podmanLevelCommands(LevelModerate, cfg)[1], " "); got != "image prune -af --filter until=72h" {
+		t.Fatalf("moderate should use its prune_ages entry, got %q", got)
+	}
+	if got := strings.Join(podmanLevelCommands(LevelAggressive, cfg)[1], " "); got != "image prune -af --filter until=12h" {
+		t.Fatalf("aggressive should use its prune_ages entry, got %q", got)
+	}
+}
+
 func TestNixPluginName(t *testing.T) {
 	p := NewNixPlugin()
 	if p.Name() != "nix" {
@@ -384,6 +576,31 @@ func TestNixPluginParseDeletedPaths(t *testing.T) {
 	}
 }
 
+func TestHomePathsStayAbsoluteWithoutHome(t *testing.T) {
+	env.Configure("", "")
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	cfg := config.DefaultConfig()
+
+	for _, plugin := range []MountScoped{NewDockerPlugin(), NewPodmanPlugin()} {
+		for _, path := range plugin.DataPaths(cfg) {
+			if !filepath.IsAbs(path) {
+				t.Errorf("%T.DataPaths returned relative path %q without a home", plugin, path)
+			}
+		}
+	}
+	if got := nixHostMeasurePath(config.NixConfig{StoreVolume: "~/nix", HostMeasurePath: "~/store"}); !filepath.IsAbs(got) {
+		t.Errorf("nixHostMeasurePath = %q, want an absolute path", got)
+	}
+	if path, ok := resolveQemuImgPath("~/bin/qemu-img"); ok {
+		t.Errorf("resolveQemuImgPath accepted %q without a home", path)
+	}
+	cfg.SparseFiles.ScanPaths = []string{"~", "/var/lib/libvirt"}
+	if roots := sparseFileRoots(cfg, ""); len(roots) != 1 || roots[0] != "/var/lib/libvirt" {
+		t.Errorf("sparseFileRoots = %v, want only the absolute root", roots)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Create a null logger for tests
 	os.Exit(m.Run())
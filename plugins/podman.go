@@ -6,36 +6,48 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
 )
 
 // PodmanPlugin handles Podman cleanup operations.
 type PodmanPlugin struct {
 	environment *PodmanEnvironment
+	geteuid     func() int
 }
 
 // PodmanEnvironment contains information about the Podman runtime environment.
 type PodmanEnvironment struct {
 	// Runtime is "podman" if available, "" otherwise
 	Runtime string
-	// NeedsVM is true on Darwin where Podman requires a VM
+	// NeedsVM is true when cleanup targets Podman machine VMs: always on
+	// Darwin, and on Linux when `podman machine list` shows a running machine
 	NeedsVM bool
-	// VMProvider is "applehv", "libkrun", "qemu", or "" (Linux)
+	// VMProvider is "applehv", "libkrun", "qemu", or "" without a machine
 	VMProvider string
 	// VMRunning is true if a Podman machine is running
 	VMRunning bool
-	// MachineName is the name of the running machine
+	// MachineName is the name of the machine currently being cleaned
 	MachineName string
+	// RunningMachines lists every running machine in podman machine list order
+	RunningMachines []string
 	// StoragePath is the path to container storage
 	StoragePath string
+	// RootfulStoragePath is the rootful engine's storage, once
+	// podman.clean_rootful has reached it
+	RootfulStoragePath string
+	// Connections lists podman system connection list on Linux
+	Connections []podmanConnection
+	// Rootful is true while cleanup targets the rootful engine through sudo
+	Rootful bool
 	// SocketPath is the path to the Podman socket
 	SocketPath string
 }
@@ -68,11 +80,8 @@ type podmanBuildKitCachePlanInput struct {
 	ReclaimableBytes int64
 	TotalBytes       int64
 	InspectionError  string
-}
-
-type podmanBuildKitContainer struct {
-	ID   string
-	Name string
+	// MachineVM is true when the BuildKit container runs in a Podman machine.
+	MachineVM bool
 }
 
 type podmanVMDiskTrimResult struct {
@@ -134,7 +143,7 @@ type podmanCompactionPlanInput struct {
 
 // NewPodmanPlugin creates a new Podman cleanup plugin.
 func NewPodmanPlugin() *PodmanPlugin {
-	return &PodmanPlugin{}
+	return &PodmanPlugin{geteuid: os.Geteuid}
 }
 
 // Name returns the plugin identifier.
@@ -147,6 +156,36 @@ func (p *PodmanPlugin) Description() string {
 	return "Cleans Podman images, containers, volumes, build cache, and VM disk space"
 }
 
+// Priority runs Podman prune with the other container prunes, ahead of VM
+// work.
+func (p *PodmanPlugin) Priority() int {
+	return 50
+}
+
+// HeavyAt reports critical Podman cleanup as heavy when offline disk
+// compaction is enabled.
+func (p *PodmanPlugin) HeavyAt(level CleanupLevel, cfg *config.Config) bool {
+	return level >= LevelCritical && cfg.Podman.CompactDiskOffline
+}
+
+// DataPaths returns the Podman machine disk directories and, on Linux, the
+// rootless and rootful container storage roots. Only the rootful root is
+// returned when the home directory is unavailable.
+func (p *PodmanPlugin) DataPaths(cfg *config.Config) []string {
+	home, err := env.HomeDir()
+	if err != nil {
+		if runtime.GOOS == "linux" {
+			return []string{podmanRootfulStorage}
+		}
+		return nil
+	}
+	paths := podmanMachineDataDirs(home, os.Getenv("XDG_DATA_HOME"))
+	if runtime.GOOS == "linux" {
+		paths = append(paths, podmanRootlessStorage(home, os.Getenv("XDG_DATA_HOME")), podmanRootfulStorage)
+	}
+	return paths
+}
+
 // SupportedPlatforms returns supported platforms (all).
 func (p *PodmanPlugin) SupportedPlatforms() []string {
 	return nil // All platforms
@@ -157,6 +196,22 @@ func (p *PodmanPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Podman
 }
 
+// LevelDescription summarizes Podman cleanup at each level.
+func (p *PodmanPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "prunes dangling images"
+	case LevelModerate:
+		return "prunes dangling images, images older than prune_images_age, stopped containers older than 1h, and build cache"
+	case LevelAggressive:
+		return "runs moderate cleanup, then prunes unused volumes and build containers and trims the Podman machine disk when one is running; with deep_build_cache_gc, also drops every BuildKit cache record"
+	case LevelCritical:
+		return "prunes BuildKit cache; system prune with volumes only when critical_system_prune is true; every BuildKit cache record only when deep_build_cache_gc is true; offline VM disk compaction only when compact_disk_offline is true"
+	default:
+		return "no cleanup"
+	}
+}
+
 // PlanCleanup returns a dry-run plan without mutating Podman state.
 func (p *PodmanPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
 	plan := CleanupPlan{
@@ -192,12 +247,33 @@ func (p *PodmanPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg
 	plan.Metadata["vm_provider"] = p.environment.VMProvider
 	plan.Metadata["vm_running"] = strconv.FormatBool(p.environment.VMRunning)
 	plan.Metadata["machine_name"] = p.environment.MachineName
-
-	if p.environment.NeedsVM && !p.environment.VMRunning {
-		plan.WouldRun = false
-		plan.SkipReason = "podman_machine_not_running"
-		plan.Summary = "Podman machine is not running"
-		return plan
+	if runtime.GOOS == "linux" {
+		plan.Metadata["storage_path"] = p.environment.StoragePath
+		plan.Metadata["connections"] = podmanConnectionSummary(p.environment.Connections)
+		plan.Metadata["clean_rootful"] = strconv.FormatBool(p.cleansRootful(cfg))
+		if p.cleansRootful(cfg) {
+			plan.Steps = append(plan.Steps, fmt.Sprintf("Repeat Podman cleanup against the rootful engine (%s) through sudo -n", podmanRootfulStorage))
+		} else if !cfg.Podman.CleanRootful && pathExistsAndIsDir(podmanRootfulStorage) && os.Geteuid() != 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("rootful Podman storage at %s is not cleaned; set podman.clean_rootful to clean it through sudo -n", podmanRootfulStorage))
+		}
+	}
+
+	var machines []string
+	if p.environment.NeedsVM {
+		machines = selectPodmanMachines(p.environment.RunningMachines, cfg.Podman.MachineNames)
+		plan.Metadata["running_machines"] = strings.Join(p.environment.RunningMachines, ",")
+		plan.Metadata["machine_names"] = strings.Join(machines, ",")
+		if len(machines) == 0 {
+			plan.WouldRun = false
+			plan.SkipReason = "podman_machine_not_running"
+			plan.Summary = "Podman machine is not running"
+			return plan
+		}
+		p.useMachine(machines[0])
+		plan.Metadata["machine_name"] = p.environment.MachineName
+		if len(machines) > 1 {
+			plan.Steps = append(plan.Steps, fmt.Sprintf("Repeat Podman cleanup for machines: %s", strings.Join(machines, ", ")))
+		}
 	}
 
 	switch level {
@@ -206,17 +282,17 @@ func (p *PodmanPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg
 	case LevelModerate:
 		plan.Steps = append(plan.Steps,
 			"Prune dangling Podman images",
-			fmt.Sprintf("Prune Podman images older than %s", cfg.Podman.PruneImagesAge),
+			fmt.Sprintf("Prune Podman images older than %s", cfg.Podman.PruneAge(level.String())),
 			"Prune old stopped Podman containers",
 			"Prune Podman build cache",
 		)
 	case LevelAggressive:
 		plan.Steps = append(plan.Steps,
-			"Run moderate Podman cleanup",
+			fmt.Sprintf("Run moderate Podman cleanup with images older than %s", cfg.Podman.PruneAge(level.String())),
 			"Prune unused Podman volumes",
 			"Prune Podman build containers",
 		)
-		if runtime.GOOS == "darwin" && p.environment.VMRunning && cfg.Podman.TrimVMDisk && !p.fstrimReclaimsHostSpace() {
+		if p.machineVMRunning() && cfg.Podman.TrimVMDisk && !p.fstrimReclaimsHostSpace() {
 			plan.Warnings = append(plan.Warnings, "guest fstrim output is not counted as host bytes for this provider; measured host free-space delta remains authoritative")
 		}
 	case LevelCritical:
@@ -245,51 +321,78 @@ func (p *PodmanPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg
 		plan.Metadata["buildkit_cache_min_reclaim_bytes"] = strconv.FormatInt(buildKit.MinReclaimBytes, 10)
 		plan.Metadata["buildkit_cache_reclaimable_bytes"] = strconv.FormatInt(buildKit.ReclaimableBytes, 10)
 		plan.Metadata["buildkit_cache_total_bytes"] = strconv.FormatInt(buildKit.TotalBytes, 10)
-		if runtime.GOOS == "darwin" && p.environment.VMRunning {
-			if cfg.Podman.CleanInsideVM && cfg.Podman.CriticalSystemPrune {
-				plan.Steps = append(plan.Steps, "Run critical cleanup inside the Podman VM")
-			} else if cfg.Podman.CleanInsideVM {
-				plan.Steps = append(plan.Steps, "Skip critical Podman VM system prune because podman.critical_system_prune=false")
-			}
+		if p.machineVMRunning() {
 			if cfg.Podman.TrimVMDisk && !p.fstrimReclaimsHostSpace() {
 				plan.Warnings = append(plan.Warnings, "guest fstrim output is not counted as host bytes for this provider; measured host free-space delta remains authoritative")
 			}
-
-			compaction := p.planOfflineCompaction(ctx, cfg, logger)
-			plan.RequiredFreeBytes = compaction.RequiredFreeBytes
-			if compaction.CanCompact {
-				plan.EstimatedBytesFreed = compaction.EstimatedReclaimBytes
+			for _, machine := range machines {
+				p.useMachine(machine)
+				p.planMachineCritical(ctx, cfg, logger, &plan, podmanMachineMetadataPrefix(machines, machine))
 			}
-			plan.Warnings = append(plan.Warnings, compaction.Warnings...)
-			plan.Steps = append(plan.Steps, compaction.Steps...)
-			plan.Targets = append(plan.Targets, podmanCompactionTargets(compaction)...)
-			plan.Metadata["offline_compaction_enabled"] = strconv.FormatBool(compaction.ConfigEnabled)
-			plan.Metadata["offline_compaction_can_run"] = strconv.FormatBool(compaction.CanCompact)
-			plan.Metadata["offline_compaction_skip_reason"] = compaction.SkipReason
-			plan.Metadata["offline_compaction_provider"] = compaction.Provider
-			plan.Metadata["offline_compaction_format"] = compaction.DiskFormat
-			plan.Metadata["offline_compaction_disk_path"] = compaction.DiskPath
-			plan.Metadata["offline_compaction_scratch_dir"] = compaction.ScratchDir
-			plan.Metadata["offline_compaction_temp_path"] = compaction.TempPath
-			plan.Metadata["offline_compaction_backup_path"] = compaction.BackupPath
-			plan.Metadata["offline_compaction_qemu_img_path"] = compaction.QemuImgPath
-			plan.Metadata["offline_compaction_logical_bytes"] = strconv.FormatInt(compaction.LogicalBytes, 10)
-			plan.Metadata["offline_compaction_physical_bytes"] = strconv.FormatInt(compaction.PhysicalBytes, 10)
-			plan.Metadata["offline_compaction_free_bytes"] = strconv.FormatInt(compaction.FreeBytes, 10)
-			plan.Metadata["offline_compaction_required_free_bytes"] = strconv.FormatInt(compaction.RequiredFreeBytes, 10)
-			plan.Metadata["offline_compaction_estimated_reclaim_bytes"] = strconv.FormatInt(compaction.EstimatedReclaimBytes, 10)
-			plan.Metadata["offline_compaction_active_containers"] = strconv.FormatBool(compaction.ActiveContainers)
-			plan.Metadata["offline_compaction_scratch_dir_configured"] = strconv.FormatBool(compaction.ScratchDirConfigured)
-			plan.Metadata["offline_compaction_scratch_dir_available"] = strconv.FormatBool(compaction.ScratchDirAvailable)
-			plan.Metadata["offline_compaction_scratch_dir_cross_device"] = strconv.FormatBool(compaction.ScratchDirCrossDevice)
-			plan.Metadata["offline_compaction_cross_device_replacement"] = strconv.FormatBool(compaction.CrossDeviceReplacement)
 		}
 		plan.Metadata["target_count"] = strconv.Itoa(len(plan.Targets))
 	}
+	if level >= LevelAggressive && cfg.Podman.DeepBuildCacheGC {
+		plan.Steps = append(plan.Steps, "Drop every BuildKit cache record in buildx builder containers when no build is active")
+		plan.Metadata["deep_build_cache_gc"] = "true"
+	}
 
 	return plan
 }
 
+// planMachineCritical adds critical VM steps, warnings, and offline compaction
+// preflight for the active Podman machine. Metadata keys are prefixed when
+// several machines are planned in the same cycle.
+func (p *PodmanPlugin) planMachineCritical(ctx context.Context, cfg *config.Config, logger *slog.Logger, plan *CleanupPlan, prefix string) {
+	if cfg.Podman.CleanInsideVM && cfg.Podman.CriticalSystemPrune {
+		plan.Steps = append(plan.Steps, fmt.Sprintf("Run critical cleanup inside Podman machine %q", p.environment.MachineName))
+	} else if cfg.Podman.CleanInsideVM {
+		plan.Steps = append(plan.Steps, fmt.Sprintf("Skip critical Podman VM system prune on %q because podman.critical_system_prune=false", p.environment.MachineName))
+	}
+
+	compaction := p.planOfflineCompaction(ctx, cfg, logger)
+	// Compactions run one machine at a time, so the scratch requirement is
+	// the largest single machine rather than the sum.
+	if compaction.RequiredFreeBytes > plan.RequiredFreeBytes {
+		plan.RequiredFreeBytes = compaction.RequiredFreeBytes
+	}
+	if compaction.CanCompact {
+		plan.EstimatedBytesFreed += compaction.EstimatedReclaimBytes
+	}
+	plan.Warnings = append(plan.Warnings, compaction.Warnings...)
+	plan.Steps = append(plan.Steps, compaction.Steps...)
+	plan.Targets = append(plan.Targets, podmanCompactionTargets(compaction)...)
+	plan.Metadata[prefix+"offline_compaction_enabled"] = strconv.FormatBool(compaction.ConfigEnabled)
+	plan.Metadata[prefix+"offline_compaction_can_run"] = strconv.FormatBool(compaction.CanCompact)
+	plan.Metadata[prefix+"offline_compaction_skip_reason"] = compaction.SkipReason
+	plan.Metadata[prefix+"offline_compaction_provider"] = compaction.Provider
+	plan.Metadata[prefix+"offline_compaction_format"] = compaction.DiskFormat
+	plan.Metadata[prefix+"offline_compaction_disk_path"] = compaction.DiskPath
+	plan.Metadata[prefix+"offline_compaction_scratch_dir"] = compaction.ScratchDir
+	plan.Metadata[prefix+"offline_compaction_temp_path"] = compaction.TempPath
+	plan.Metadata[prefix+"offline_compaction_backup_path"] = compaction.BackupPath
+	plan.Metadata[prefix+"offline_compaction_qemu_img_path"] = compaction.QemuImgPath
+	plan.Metadata[prefix+"offline_compaction_logical_bytes"] = strconv.FormatInt(compaction.LogicalBytes, 10)
+	plan.Metadata[prefix+"offline_compaction_physical_bytes"] = strconv.FormatInt(compaction.PhysicalBytes, 10)
+	plan.Metadata[prefix+"offline_compaction_free_bytes"] = strconv.FormatInt(compaction.FreeBytes, 10)
+	plan.Metadata[prefix+"offline_compaction_required_free_bytes"] = strconv.FormatInt(compaction.RequiredFreeBytes, 10)
+	plan.Metadata[prefix+"offline_compaction_estimated_reclaim_bytes"] = strconv.FormatInt(compaction.EstimatedReclaimBytes, 10)
+	plan.Metadata[prefix+"offline_compaction_active_containers"] = strconv.FormatBool(compaction.ActiveContainers)
+	plan.Metadata[prefix+"offline_compaction_scratch_dir_configured"] = strconv.FormatBool(compaction.ScratchDirConfigured)
+	plan.Metadata[prefix+"offline_compaction_scratch_dir_available"] = strconv.FormatBool(compaction.ScratchDirAvailable)
+	plan.Metadata[prefix+"offline_compaction_scratch_dir_cross_device"] = strconv.FormatBool(compaction.ScratchDirCrossDevice)
+	plan.Metadata[prefix+"offline_compaction_cross_device_replacement"] = strconv.FormatBool(compaction.CrossDeviceReplacement)
+}
+
+// podmanMachineMetadataPrefix keeps single-machine plan metadata keys stable
+// and namespaces them by machine name when several machines are planned.
+func podmanMachineMetadataPrefix(machines []string, machine string) string {
+	if len(machines) <= 1 {
+		return ""
+	}
+	return machine + "."
+}
+
 // Cleanup performs Podman cleanup at the specified level.
 func (p *PodmanPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
@@ -313,14 +416,94 @@ func (p *PodmanPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *con
 			"needs_vm", env.NeedsVM,
 			"vm_provider", env.VMProvider,
 			"vm_running", env.VMRunning,
-			"machine_name", env.MachineName)
+			"running_machines", env.RunningMachines)
+	}
+
+	// A machine whose compaction restart failed on an earlier run is started
+	// again before cleanup.
+	var recoverErr error
+	if p.environment.NeedsVM {
+		recoverErr = recoverVMRestarts(ctx, cfg, p.Name(), podmanMachineStart, podmanHealthProbe, logger)
 	}
 
-	// On Darwin, check if VM is running before attempting cleanup
-	if p.environment.NeedsVM && !p.environment.VMRunning {
-		logger.Debug("podman machine not running, skipping")
+	if !p.environment.NeedsVM && !p.cleansRootful(cfg) {
+		return p.cleanLevel(ctx, level, cfg, logger)
+	}
+
+	// On Linux the podman CLI keeps its native engine alongside any machine,
+	// so clean host storage, rootless then rootful, before the machines.
+	if runtime.GOOS == "linux" {
+		addPodmanResult(&result, p.cleanNative(ctx, level, cfg, logger), "")
+		if p.cleansRootful(cfg) {
+			addPodmanResult(&result, p.cleanRootful(ctx, level, cfg, logger), "")
+		}
+	}
+	if !p.environment.NeedsVM {
 		return result
 	}
+	if result.Error == nil {
+		result.Error = recoverErr
+	}
+
+	// With Podman machines, clean each selected running machine in turn.
+	machines := selectPodmanMachines(p.environment.RunningMachines, cfg.Podman.MachineNames)
+	if len(machines) == 0 {
+		logger.Debug("no selected podman machine running, skipping",
+			"running_machines", p.environment.RunningMachines,
+			"machine_names", cfg.Podman.MachineNames)
+		return result
+	}
+
+	for _, machine := range machines {
+		p.useMachine(machine)
+		machineResult := p.cleanLevel(ctx, level, cfg, logger.With("machine", machine))
+		logger.Info("Podman machine cleanup completed",
+			"machine", machine,
+			"level", level.String(),
+			"freed_mb", machineResult.BytesFreed/(1024*1024),
+			"host_freed_mb", machineResult.HostBytesFreed/(1024*1024),
+			"items", machineResult.ItemsCleaned)
+		addPodmanResult(&result, machineResult, machine)
+	}
+
+	return result
+}
+
+// cleanNative runs a level against the host's own Podman engine while
+// machines are also running, with no machine as the active target.
+func (p *PodmanPlugin) cleanNative(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	machine, running := p.environment.MachineName, p.environment.VMRunning
+	p.environment.MachineName, p.environment.VMRunning = "", false
+	defer func() { p.environment.MachineName, p.environment.VMRunning = machine, running }()
+	return p.cleanLevel(ctx, level, cfg, logger)
+}
+
+// addPodmanResult adds one connection's cleanup to the plugin result. The
+// first failure is kept, attributed to machine when there is one.
+func addPodmanResult(result *CleanupResult, partial CleanupResult, machine string) {
+	result.BytesFreed += partial.BytesFreed
+	result.EstimatedBytesFreed += partial.EstimatedBytesFreed
+	result.CommandBytesFreed += partial.CommandBytesFreed
+	result.HostBytesFreed += partial.HostBytesFreed
+	result.DeepGCBytesFreed += partial.DeepGCBytesFreed
+	result.ItemsCleaned += partial.ItemsCleaned
+	if partial.Error == nil || result.Error != nil {
+		return
+	}
+	if machine == "" {
+		result.Error = partial.Error
+		return
+	}
+	result.Error = pluginErrorForVM("podman", "machine_cleanup", machine, partial.Error)
+}
+
+// cleanLevel runs the cleanup for one level against the active Podman
+// connection or machine.
+func (p *PodmanPlugin) cleanLevel(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{
+		Plugin: p.Name(),
+		Level:  level,
+	}
 
 	switch level {
 	case LevelWarning:
@@ -348,7 +531,7 @@ func (p *PodmanPlugin) cleanDangling(ctx context.Context, logger *slog.Logger) C
 	output, err := p.runPodmanCommand(ctx, "image", "prune", "-f")
 	if err != nil {
 		logger.Warn("failed to prune dangling images", "error", err)
-		result.Error = err
+		result.Error = newCommandError(p.Name(), "image_prune", err, output)
 		return result
 	}
 
@@ -364,63 +547,23 @@ func (p *PodmanPlugin) cleanDangling(ctx context.Context, logger *slog.Logger) C
 // cleanModerate performs moderate cleanup: dangling images, old images, old containers, build cache.
 func (p *PodmanPlugin) cleanModerate(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{Plugin: p.Name(), Level: LevelModerate}
-
-	// Clean dangling images
-	logger.Debug("cleaning dangling podman images")
-	if output, err := p.runPodmanCommand(ctx, "image", "prune", "-f"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
-	}
-
-	// Clean old images (with age filter)
-	logger.Debug("cleaning old podman images", "age", cfg.Podman.PruneImagesAge)
-	args := []string{"image", "prune", "-af", "--filter", fmt.Sprintf("until=%s", cfg.Podman.PruneImagesAge)}
-	if output, err := p.runPodmanCommand(ctx, args...); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
-	}
-
-	// Clean old stopped containers
-	logger.Debug("cleaning old podman containers")
-	if output, err := p.runPodmanCommand(ctx, "container", "prune", "-f", "--filter", "until=1h"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
-	}
-
-	// Clean build cache (important for Podman - survives normal prune)
-	logger.Debug("cleaning podman build cache")
-	if output, err := p.runPodmanCommand(ctx, "image", "prune", "--build-cache", "-f"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
-	}
-
+	p.runPruneCommands(ctx, podmanLevelCommands(LevelModerate, cfg), &result, logger)
 	return result
 }
 
 // cleanAggressive performs aggressive cleanup: moderate + volumes + VM fstrim.
 func (p *PodmanPlugin) cleanAggressive(ctx context.Context, cfg *config.Config, logger *slog.Logger) CleanupResult {
-	result := p.cleanModerate(ctx, cfg, logger)
-	result.Level = LevelAggressive
-
-	// Clean unused volumes
-	logger.Debug("cleaning unused podman volumes")
-	if output, err := p.runPodmanCommand(ctx, "volume", "prune", "-f"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
+	result := CleanupResult{Plugin: p.Name(), Level: LevelAggressive}
+	p.runPruneCommands(ctx, podmanLevelCommands(LevelAggressive, cfg), &result, logger)
+	if cfg.Podman.DeepBuildCacheGC {
+		p.deepBuildCacheGC(ctx, &result, logger)
 	}
 
-	// Clean build containers (may interfere with active builds)
-	logger.Debug("cleaning podman build containers")
-	if output, err := p.runPodmanCommand(ctx, "system", "prune", "-f", "--build"); err == nil {
-		result.BytesFreed += p.parseReclaimedSpace(output)
-		result.ItemsCleaned++
-	}
-
-	// On Darwin, run fstrim inside VM to reclaim sparse disk space when the
+	// With a Podman machine, run fstrim inside it to reclaim sparse disk space when the
 	// provider reflects guest discard operations back to the host disk image.
 	// Providers such as applehv still benefit from fstrim after guest cleanup,
 	// but only the measured host free-space delta is counted.
-	if runtime.GOOS == "darwin" && p.environment.VMRunning && cfg.Podman.TrimVMDisk {
+	if p.machineVMRunning() && cfg.Podman.TrimVMDisk {
 		logger.Debug("running fstrim in Podman VM", "machine", p.environment.MachineName)
 		if trim, err := p.trimVMDiskWithHostDelta(ctx, logger); err == nil {
 			p.addTrimResult(&result, trim, logger)
@@ -450,10 +593,10 @@ func (p *PodmanPlugin) cleanCritical(ctx context.Context, cfg *config.Config, lo
 	if cfg.Podman.CriticalSystemPrune {
 		// Full system prune with volumes.
 		logger.Warn("CRITICAL: running full Podman system prune with volumes")
-		output, err := p.runPodmanCommand(ctx, "system", "prune", "-af", "--volumes")
+		output, err := p.runPodmanCommand(ctx, podmanSystemPruneArgs...)
 		if err != nil {
 			logger.Error("full system prune failed", "error", err)
-			result.Error = err
+			result.Error = newCommandError(p.Name(), "system_prune", err, output)
 			return result
 		}
 		result.BytesFreed += p.parseReclaimedSpace(output)
@@ -461,7 +604,7 @@ func (p *PodmanPlugin) cleanCritical(ctx context.Context, cfg *config.Config, lo
 
 		// Clean external/orphaned storage (transient mode).
 		logger.Warn("CRITICAL: cleaning external podman storage")
-		if output, err := p.runPodmanCommand(ctx, "system", "prune", "--external", "-f"); err == nil {
+		if output, err := p.runPodmanCommand(ctx, podmanExternalPruneArgs...); err == nil {
 			result.BytesFreed += p.parseReclaimedSpace(output)
 			result.ItemsCleaned++
 		} else {
@@ -472,8 +615,14 @@ func (p *PodmanPlugin) cleanCritical(ctx context.Context, cfg *config.Config, lo
 		logger.Warn("skipping broad Podman system prune with volumes", "reason", "critical_system_prune_disabled")
 	}
 
-	// On Darwin, aggressive VM cleanup
-	if runtime.GOOS == "darwin" && p.environment.VMRunning {
+	// Blobs released by the deep GC are only returned to the host after
+	// another trim, even when the BuildKit prune already trimmed the VM.
+	if cfg.Podman.DeepBuildCacheGC && p.deepBuildCacheGC(ctx, &result, logger) > 0 {
+		buildKitTrimRan = false
+	}
+
+	// With a Podman machine, aggressive VM cleanup
+	if p.machineVMRunning() {
 		// First, clean inside the VM
 		if cfg.Podman.CleanInsideVM && cfg.Podman.CriticalSystemPrune {
 			logger.Warn("CRITICAL: cleaning inside Podman VM")
@@ -500,6 +649,9 @@ func (p *PodmanPlugin) cleanCritical(ctx context.Context, cfg *config.Config, lo
 			compactFreed, err := p.compactRawDisk(ctx, cfg, logger)
 			if err != nil {
 				logger.Warn("Podman disk compaction failed", "error", err)
+				if result.Error == nil {
+					result.Error = pluginErrorForVM(p.Name(), "disk_compact", p.environment.MachineName, err)
+				}
 			} else if compactFreed > 0 {
 				result.BytesFreed += compactFreed
 				result.HostBytesFreed += compactFreed
@@ -540,7 +692,7 @@ func (p *PodmanPlugin) cleanBuildKitCache(ctx context.Context, cfg *config.Confi
 	}
 
 	trimRan := false
-	if runtime.GOOS == "darwin" && p.environment.VMRunning && cfg.Podman.TrimVMDisk {
+	if p.machineVMRunning() && cfg.Podman.TrimVMDisk {
 		logger.Debug("running fstrim after BuildKit cache prune", "machine", p.environment.MachineName)
 		trimRan = true
 		if trim, err := p.trimVMDiskWithHostDelta(ctx, logger); err == nil {
@@ -553,6 +705,34 @@ func (p *PodmanPlugin) cleanBuildKitCache(ctx context.Context, cfg *config.Confi
 	return result, trimRan
 }
 
+// deepBuildCacheGC collects orphaned blobs in every BuildKit container on the
+// active connection after the normal prune, and returns the bytes it
+// reclaimed. Like the targeted BuildKit prune, the bytes are reported by the
+// container rather than measured on the host. It forces a cold build cache,
+// so it never runs while a build is active.
+func (p *PodmanPlugin) deepBuildCacheGC(ctx context.Context, result *CleanupResult, logger *slog.Logger) int64 {
+	activeReasons, err := p.activeBuilds(ctx)
+	if err != nil {
+		logger.Warn("skipping deep BuildKit cache GC because active process inspection failed", "error", err)
+		return 0
+	}
+	if len(activeReasons) > 0 {
+		logger.Info("skipping deep BuildKit cache GC because an active build was detected", "active", strings.Join(activeReasons, ", "))
+		return 0
+	}
+
+	containers, err := listBuildKitContainers(ctx, p.runPodmanCommand)
+	if err != nil {
+		logger.Warn("could not list BuildKit containers", "error", err)
+		return 0
+	}
+	gc := deepBuildKitGC(ctx, p.runPodmanCommand, containers, logger)
+	result.CommandBytesFreed += gc.Bytes
+	result.DeepGCBytesFreed += gc.Bytes
+	result.ItemsCleaned += gc.Containers
+	return gc.Bytes
+}
+
 func podmanBuildKitPruneArgs(plan podmanBuildKitCachePlan) []string {
 	return []string{
 		"exec", plan.ContainerID,
@@ -607,20 +787,202 @@ func (p *PodmanPlugin) addTrimResult(result *CleanupResult, trim podmanVMDiskTri
 	}
 }
 
+// runPruneCommands runs each podman prune command in order, counting every
+// successful command and continuing past failures.
+func (p *PodmanPlugin) runPruneCommands(ctx context.Context, commands [][]string, result *CleanupResult, logger *slog.Logger) {
+	for _, args := range commands {
+		command := strings.Join(args, " ")
+		logger.Debug("running podman prune", "command", command)
+		output, err := p.runPodmanCommand(ctx, args...)
+		if err != nil {
+			logger.Debug("podman prune failed", "command", command, "error", err)
+			continue
+		}
+		result.BytesFreed += p.parseReclaimedSpace(output)
+		result.ItemsCleaned++
+	}
+}
+
+var (
+	podmanSystemPruneArgs   = []string{"system", "prune", "-af", "--volumes"}
+	podmanExternalPruneArgs = []string{"system", "prune", "--external", "-f"}
+)
+
+// podmanLevelCommands returns the host podman arguments run at each cleanup
+// level, in execution order. Critical BuildKit and VM operations depend on
+// runtime discovery and are listed by CleanupCommands instead.
+func podmanLevelCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	moderate := [][]string{
+		{"image", "prune", "-f"},
+		{"image", "prune", "-af", "--filter", fmt.Sprintf("until=%s", cfg.Podman.PruneAge(level.String()))},
+		{"container", "prune", "-f", "--filter", "until=1h"},
+		{"image", "prune", "--build-cache", "-f"},
+	}
+
+	switch level {
+	case LevelWarning:
+		return [][]string{{"image", "prune", "-f"}}
+	case LevelModerate:
+		return moderate
+	case LevelAggressive:
+		return append(moderate,
+			[]string{"volume", "prune", "-f"},
+			[]string{"system", "prune", "-f", "--build"},
+		)
+	case LevelCritical:
+		if !cfg.Podman.CriticalSystemPrune {
+			return nil
+		}
+		return [][]string{podmanSystemPruneArgs, podmanExternalPruneArgs}
+	default:
+		return nil
+	}
+}
+
+// podmanVMCommands returns the commands run inside a Podman machine at each
+// cleanup level.
+func podmanVMCommands(level CleanupLevel) [][]string {
+	switch level {
+	case LevelWarning:
+		return [][]string{
+			{"podman", "image", "prune", "-f"},
+		}
+	case LevelModerate:
+		return [][]string{
+			{"podman", "image", "prune", "-f"},
+			{"podman", "container", "prune", "-f"},
+			{"podman", "image", "prune", "--build-cache", "-f"},
+		}
+	case LevelAggressive:
+		return [][]string{
+			{"podman", "image", "prune", "-af"},
+			{"podman", "container", "prune", "-f"},
+			{"podman", "volume", "prune", "-f"},
+			{"podman", "image", "prune", "--build-cache", "-f"},
+		}
+	case LevelCritical:
+		return [][]string{
+			{"podman", "system", "prune", "-af", "--volumes"},
+		}
+	default:
+		return nil
+	}
+}
+
+// CleanupCommands returns the podman commands Cleanup would run at level.
+// Machines come from podman.machine_names; when that list is empty a
+// placeholder stands in for each running machine on Darwin, and Linux hosts
+// are assumed to run Podman natively. The BuildKit prune only
+// runs when its reclaim threshold is met, the deep BuildKit GC only when no
+// build is active, and offline disk compaction is omitted because it depends
+// on disk preflight checks. With podman.clean_rootful on Linux, the level's
+// prunes repeat against the rootful engine through sudo -n.
+func (p *PodmanPlugin) CleanupCommands(level CleanupLevel, cfg *config.Config) [][]string {
+	machines := []string{""}
+	if runtime.GOOS == "darwin" || len(cfg.Podman.MachineNames) > 0 {
+		machines = cfg.Podman.MachineNames
+		if len(machines) == 0 {
+			machines = []string{"<running-machine>"}
+		}
+	}
+
+	var commands [][]string
+	for _, machine := range machines {
+		host := []string{"podman"}
+		if len(machines) > 1 {
+			host = append(host, "--connection", machine)
+		}
+		trim := []string{"podman", "machine", "ssh", machine, "--", "sudo", "fstrim", "-av"}
+		trimmed := false
+
+		if level == LevelCritical && cfg.Podman.BuildKitPrune {
+			commands = append(commands, append(append([]string{}, host...), podmanBuildKitPruneArgs(podmanBuildKitCachePlan{
+				ContainerID:   "<buildkit-container>",
+				KeepDuration:  cfg.Podman.BuildKitPruneKeepDuration,
+				KeepStorageMB: cfg.Podman.BuildKitPruneKeepStorageMB,
+			})...))
+			if machine != "" && cfg.Podman.TrimVMDisk {
+				commands = append(commands, trim)
+				trimmed = true
+			}
+		}
+		for _, args := range podmanLevelCommands(level, cfg) {
+			commands = append(commands, append(append([]string{}, host...), args...))
+		}
+		if level >= LevelAggressive && cfg.Podman.DeepBuildCacheGC {
+			commands = append(commands, append(append([]string{}, host...), buildKitDeepGCArgs("<buildkit-container>")...))
+			trimmed = false
+		}
+		if machine == "" {
+			continue
+		}
+
+		switch level {
+		case LevelAggressive:
+			if cfg.Podman.TrimVMDisk {
+				commands = append(commands, trim)
+			}
+		case LevelCritical:
+			if cfg.Podman.CleanInsideVM && cfg.Podman.CriticalSystemPrune {
+				for _, args := range podmanVMCommands(LevelCritical) {
+					commands = append(commands, append([]string{"podman", "machine", "ssh", machine, "--"}, args...))
+				}
+			}
+			if cfg.Podman.TrimVMDisk && !trimmed {
+				commands = append(commands, trim)
+			}
+		}
+	}
+	if p.cleansRootful(cfg) {
+		for _, args := range podmanLevelCommands(level, cfg) {
+			commands = append(commands, append([]string{"sudo", "-n", "podman"}, args...))
+		}
+	}
+	return commands
+}
+
 // runPodmanCommand executes a podman command with timeout.
 func (p *PodmanPlugin) runPodmanCommand(ctx context.Context, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "podman", args...)
-	output, err := cmd.CombinedOutput()
+	if p.environment != nil && p.environment.Rootful {
+		output, err := runner.CombinedOutput(ctx, nil, "sudo", append([]string{"-n", "podman"}, args...)...)
+		return string(output), err
+	}
+	output, err := runner.CombinedOutput(ctx, nil, "podman", p.podmanCommandArgs(args...)...)
 	return string(output), err
 }
 
+// podmanCommandArgs targets the active machine's connection when more than
+// one machine is running, since the default connection only reaches one of
+// them, and always on Linux, where the default is the native engine. Podman
+// names each rootless machine connection after the machine.
+func (p *PodmanPlugin) podmanCommandArgs(args ...string) []string {
+	if p.environment == nil || !p.environment.NeedsVM || p.environment.MachineName == "" {
+		return args
+	}
+	if runtime.GOOS == "darwin" && len(p.environment.RunningMachines) < 2 {
+		return args
+	}
+	return append([]string{"--connection", p.environment.MachineName}, args...)
+}
+
+// machineVMRunning reports whether cleanup targets a running Podman machine.
+func (p *PodmanPlugin) machineVMRunning() bool {
+	return p.environment != nil && p.environment.NeedsVM && p.environment.VMRunning
+}
+
+// useMachine makes machine the target of subsequent VM operations.
+func (p *PodmanPlugin) useMachine(machine string) {
+	p.environment.MachineName = machine
+	p.environment.VMRunning = true
+}
+
 // fstrimReclaimsHostSpace reports whether guest fstrim output can be counted
 // as host bytes freed for the detected Podman machine provider.
 func (p *PodmanPlugin) fstrimReclaimsHostSpace() bool {
-	if runtime.GOOS != "darwin" || p.environment == nil {
+	if p.environment == nil || !p.environment.NeedsVM {
 		return true
 	}
 
@@ -675,12 +1037,13 @@ func (p *PodmanPlugin) planBuildKitCache(ctx context.Context, cfg *config.Config
 		KeepDuration:    cfg.Podman.BuildKitPruneKeepDuration,
 		KeepStorageMB:   cfg.Podman.BuildKitPruneKeepStorageMB,
 		MinReclaimBytes: int64(cfg.Podman.BuildKitPruneMinReclaimGB) * podmanCompactionGiB,
+		MachineVM:       p.environment != nil && p.environment.NeedsVM,
 	}
 	if !cfg.Podman.BuildKitPrune {
 		return buildPodmanBuildKitCachePlan(input)
 	}
 
-	containers, err := p.listBuildKitContainers(ctx)
+	containers, err := listBuildKitContainers(ctx, p.runPodmanCommand)
 	if err != nil {
 		input.InspectionError = err.Error()
 		return buildPodmanBuildKitCachePlan(input)
@@ -743,7 +1106,7 @@ func buildPodmanBuildKitCachePlan(input podmanBuildKitCachePlanInput) podmanBuil
 			fmt.Sprintf("Inspect BuildKit cache in Podman container %q", podmanBuildKitContainerLabel(plan)),
 			fmt.Sprintf("Run buildctl prune with keep-duration %s and keep-storage %dMB", plan.KeepDuration, plan.KeepStorageMB),
 		)
-		if runtime.GOOS == "darwin" {
+		if input.MachineVM {
 			plan.Steps = append(plan.Steps, "Run advisory Podman VM fstrim and measure host free-space delta")
 			plan.Warnings = append(plan.Warnings, "BuildKit cache prune is guest-side reclaim; host bytes are counted only from measured host free-space delta")
 		}
@@ -816,34 +1179,6 @@ func podmanBuildKitContainerLabel(plan podmanBuildKitCachePlan) string {
 	return "buildkit"
 }
 
-func (p *PodmanPlugin) listBuildKitContainers(ctx context.Context) ([]podmanBuildKitContainer, error) {
-	output, err := p.runPodmanCommand(ctx, "ps", "--filter", "name=buildx_buildkit", "--format", "{{.ID}}\t{{.Names}}")
-	if err != nil {
-		return nil, err
-	}
-
-	var containers []podmanBuildKitContainer
-	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		container := podmanBuildKitContainer{ID: strings.TrimSpace(parts[0])}
-		if len(parts) > 1 {
-			container.Name = strings.TrimSpace(parts[1])
-		}
-		if container.ID == "" {
-			continue
-		}
-		if container.Name == "" {
-			container.Name = container.ID
-		}
-		containers = append(containers, container)
-	}
-	return containers, nil
-}
-
 func parseBuildKitDUSummary(output string) (int64, int64) {
 	var reclaimable int64
 	var total int64
@@ -898,37 +1233,45 @@ func parsePodmanByteQuantity(value float64, unit string) int64 {
 
 // detectPodmanEnvironment detects the Podman runtime environment.
 func detectPodmanEnvironment() (*PodmanEnvironment, error) {
-	env := &PodmanEnvironment{}
+	environment := &PodmanEnvironment{}
 
 	// Check if podman CLI is available
-	if _, err := exec.LookPath("podman"); err != nil {
-		return env, nil
+	if _, err := runner.LookPath("podman"); err != nil {
+		return environment, nil
 	}
 
 	// Verify podman is functional
-	cmd := exec.Command("podman", "info", "--format", "{{.Version.Version}}")
-	if err := cmd.Run(); err != nil {
-		return env, nil
+	if err := runner.Run(context.Background(), nil, "podman", "info", "--format", "{{.Version.Version}}"); err != nil {
+		return environment, nil
 	}
-	env.Runtime = "podman"
+	environment.Runtime = "podman"
 
-	// Platform-specific detection
-	switch runtime.GOOS {
-	case "darwin":
-		env.NeedsVM = true
-		env.VMProvider = detectMachineProvider()
-		env.VMRunning, env.MachineName = detectRunningMachine()
-		if env.VMRunning {
-			env.SocketPath = getPodmanSocket()
+	// Darwin always runs Podman in a machine. Linux runs it natively unless
+	// the user has started a podman machine, whose disk bloats the same way.
+	running := detectRunningMachines()
+	switch {
+	case runtime.GOOS == "darwin" || len(running) > 0:
+		environment.NeedsVM = true
+		environment.VMProvider = detectMachineProvider()
+		environment.RunningMachines = running
+		environment.VMRunning = len(running) > 0
+		if environment.VMRunning {
+			environment.MachineName = running[0]
+			environment.SocketPath = getPodmanSocket()
 		}
-	case "linux":
-		env.NeedsVM = false
-		home, _ := os.UserHomeDir()
-		env.StoragePath = filepath.Join(home, ".local/share/containers/storage")
-		env.SocketPath = getPodmanSocket()
+	case runtime.GOOS == "linux":
+		if os.Geteuid() == 0 {
+			environment.StoragePath = podmanRootfulStorage
+		} else if home, err := env.HomeDir(); err == nil {
+			environment.StoragePath = podmanRootlessStorage(home, os.Getenv("XDG_DATA_HOME"))
+		}
+		environment.SocketPath = getPodmanSocket()
+	}
+	if runtime.GOOS == "linux" {
+		environment.Connections = detectPodmanConnections()
 	}
 
-	return env, nil
+	return environment, nil
 }
 
 // detectMachineProvider detects the Podman machine virtualization provider.
@@ -939,37 +1282,69 @@ func detectMachineProvider() string {
 	}
 
 	// Check containers.conf
-	home, _ := os.UserHomeDir()
-	configPath := filepath.Join(home, ".config/containers/containers.conf")
-	if data, err := os.ReadFile(configPath); err == nil {
-		re := regexp.MustCompile(`provider\s*=\s*"([^"]+)"`)
-		if matches := re.FindStringSubmatch(string(data)); len(matches) > 1 {
-			return matches[1]
+	if home, err := env.HomeDir(); err == nil {
+		configPath := filepath.Join(home, ".config/containers/containers.conf")
+		if data, err := os.ReadFile(configPath); err == nil {
+			re := regexp.MustCompile(`provider\s*=\s*"([^"]+)"`)
+			if matches := re.FindStringSubmatch(string(data)); len(matches) > 1 {
+				return matches[1]
+			}
 		}
 	}
 
-	// Default on modern macOS
-	return "applehv"
+	// Podman defaults to applehv on modern macOS and qemu on Linux.
+	if runtime.GOOS == "darwin" {
+		return "applehv"
+	}
+	return "qemu"
 }
 
-// detectRunningMachine detects if a Podman machine is running and returns its name.
-func detectRunningMachine() (bool, string) {
-	cmd := exec.Command("podman", "machine", "list", "--format", "{{.Name}}\t{{.Running}}")
-	output, err := cmd.Output()
+// detectRunningMachines returns the names of all running Podman machines.
+func detectRunningMachines() []string {
+	output, err := runner.Output(context.Background(), nil, "podman", "machine", "list", "--format", "{{.Name}}\t{{.Running}}")
 	if err != nil {
-		return false, ""
+		return nil
 	}
+	return parseRunningMachines(string(output))
+}
 
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+// parseRunningMachines extracts running machine names from podman machine
+// list output formatted as name<TAB>running.
+func parseRunningMachines(output string) []string {
+	var running []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
 		parts := strings.Split(line, "\t")
-		if len(parts) >= 2 && strings.ToLower(parts[1]) == "true" {
+		if len(parts) >= 2 && strings.ToLower(strings.TrimSpace(parts[1])) == "true" {
 			// Strip trailing "*" which marks the default machine
-			name := strings.TrimRight(parts[0], "*")
-			return true, name
+			name := strings.TrimRight(strings.TrimSpace(parts[0]), "*")
+			if name != "" {
+				running = append(running, name)
+			}
 		}
 	}
+	return running
+}
 
-	return false, ""
+// selectPodmanMachines returns the running machines to clean. An empty
+// configured list selects every running machine; otherwise the configured
+// order is kept and machines that are not running are skipped.
+func selectPodmanMachines(running, configured []string) []string {
+	if len(configured) == 0 {
+		return running
+	}
+
+	isRunning := make(map[string]bool, len(running))
+	for _, name := range running {
+		isRunning[name] = true
+	}
+	selected := make([]string, 0, len(configured))
+	for _, name := range configured {
+		if isRunning[name] {
+			selected = append(selected, name)
+			isRunning[name] = false
+		}
+	}
+	return selected
 }
 
 // getPodmanSocket returns the Podman socket path.
@@ -988,12 +1363,11 @@ func getPodmanSocket() string {
 	}
 
 	// Default locations
-	home, _ := os.UserHomeDir()
-	locations := []string{
-		filepath.Join(home, ".local/share/containers/podman/machine/podman.sock"),
-		"/run/podman/podman.sock",
-		"/var/run/podman/podman.sock",
+	var locations []string
+	if home, err := env.HomeDir(); err == nil {
+		locations = append(locations, filepath.Join(home, ".local/share/containers/podman/machine/podman.sock"))
 	}
+	locations = append(locations, "/run/podman/podman.sock", "/var/run/podman/podman.sock")
 
 	for _, loc := range locations {
 		if _, err := os.Stat(loc); err == nil {
@@ -1005,15 +1379,14 @@ func getPodmanSocket() string {
 }
 
 // trimVMDisk runs fstrim inside the Podman VM to reclaim sparse disk space.
-// This is only applicable on Darwin where Podman uses a VM.
+// This is only applicable when a Podman machine is running.
 func (p *PodmanPlugin) trimVMDisk(ctx context.Context, logger *slog.Logger) (int64, error) {
 	if !p.environment.VMRunning || p.environment.MachineName == "" {
 		return 0, nil
 	}
 
-	cmd := exec.CommandContext(ctx, "podman", "machine", "ssh",
+	output, err := runner.CombinedOutput(ctx, nil, "podman", "machine", "ssh",
 		p.environment.MachineName, "--", "sudo", "fstrim", "-av")
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return 0, fmt.Errorf("fstrim failed: %w", err)
 	}
@@ -1058,16 +1431,15 @@ func (p *PodmanPlugin) trimVMDiskWithHostDelta(ctx context.Context, logger *slog
 }
 
 func (p *PodmanPlugin) podmanHostMeasurePath(ctx context.Context, logger *slog.Logger) string {
-	if runtime.GOOS == "darwin" {
-		if diskPath, err := p.getMachineDiskPath(ctx); err == nil && diskPath != "" {
+	if p.environment != nil && p.environment.NeedsVM {
+		if diskPath, err := p.getMachineDiskPath(ctx, p.environment.MachineName); err == nil && diskPath != "" {
 			return filepath.Dir(diskPath)
 		} else if err != nil {
 			logger.Debug("Podman disk path unavailable for host free-space measurement", "error", err)
 		}
 	}
 
-	home, err := os.UserHomeDir()
-	if err == nil && home != "" {
+	if home, err := env.HomeDir(); err == nil {
 		return home
 	}
 	return "."
@@ -1099,7 +1471,7 @@ func (p *PodmanPlugin) planOfflineCompaction(ctx context.Context, cfg *config.Co
 		Config:           cfg.Podman,
 	}
 
-	diskPath, err := p.getMachineDiskPath(ctx)
+	diskPath, err := p.getMachineDiskPath(ctx, p.environment.MachineName)
 	if err != nil {
 		logger.Debug("Podman disk path preflight failed", "error", err)
 		input.DiskPathExpected = false
@@ -1131,9 +1503,16 @@ func (p *PodmanPlugin) planOfflineCompaction(ctx context.Context, cfg *config.Co
 	}
 
 	if configuredScratchDir := strings.TrimSpace(cfg.Podman.CompactScratchDir); configuredScratchDir != "" {
-		home, _ := os.UserHomeDir()
+		home, homeErr := env.HomeDir()
 		input.ScratchDirConfigured = true
 		input.ScratchDir = filepath.Clean(expandHome(configuredScratchDir, home))
+		if homeErr != nil && !filepath.IsAbs(input.ScratchDir) {
+			input.ScratchDirAvailable = false
+			plan := buildPodmanCompactionPlan(input)
+			plan.SkipReason = "scratch_dir_unavailable"
+			plan.Warnings = append(plan.Warnings, homeErr.Error())
+			return plan
+		}
 		stat, err := os.Stat(input.ScratchDir)
 		if err != nil {
 			logger.Debug("Podman compaction scratch directory preflight failed", "scratch_dir", input.ScratchDir, "error", err)
@@ -1497,8 +1876,8 @@ func podmanCompactionRequiredFreeBytes(physicalBytes int64, crossDevice bool) in
 }
 
 func expectedPodmanMachineDiskPath(path string) bool {
-	home, err := os.UserHomeDir()
-	if err != nil || home == "" {
+	home, err := env.HomeDir()
+	if err != nil {
 		return false
 	}
 
@@ -1540,15 +1919,57 @@ func (p *PodmanPlugin) hasActiveContainers(ctx context.Context) (bool, error) {
 	return strings.TrimSpace(output) != "", nil
 }
 
+// activeBuilds lists image builds running on the host, whether driven by
+// podman, buildah, or docker buildx against the Podman socket.
+func (p *PodmanPlugin) activeBuilds(ctx context.Context) ([]string, error) {
+	psCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := runner.Output(psCtx, nil, "ps", "-axo", "comm=,args=")
+	if err != nil {
+		return nil, err
+	}
+	return podmanBuildProcessReasons(string(output)), nil
+}
+
+func podmanBuildProcessReasons(output string) []string {
+	reasons := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		lower := strings.ToLower(line)
+		switch {
+		case strings.Contains(lower, "podman build "), strings.Contains(lower, "podman buildx build"):
+			reasons["podman build"] = true
+		case strings.Contains(lower, "buildah bud"), strings.Contains(lower, "buildah build"):
+			reasons["buildah build"] = true
+		case strings.Contains(lower, "docker buildx build"),
+			strings.Contains(lower, "docker-buildx") && strings.Contains(lower, " build"):
+			reasons["docker buildx"] = true
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		out = append(out, reason)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func resolveQemuImgPath(configuredPath string) (string, bool) {
 	if strings.TrimSpace(configuredPath) != "" {
-		home, _ := os.UserHomeDir()
+		home, err := env.HomeDir()
 		path := filepath.Clean(expandHome(strings.TrimSpace(configuredPath), home))
+		if err != nil && !filepath.IsAbs(path) {
+			return path, false
+		}
 		info, err := os.Stat(path)
 		return path, err == nil && !info.IsDir()
 	}
 
-	path, err := exec.LookPath("qemu-img")
+	path, err := runner.LookPath("qemu-img")
 	if err != nil {
 		return "qemu-img", false
 	}
@@ -1584,9 +2005,8 @@ func restorePodmanDiskBackupCopy(ctx context.Context, qemuImgPath, diskFormat, d
 
 func convertPodmanDiskImage(ctx context.Context, qemuImgPath, diskFormat, sourcePath, destPath string) error {
 	destExisted := pathExists(destPath)
-	convertCmd := exec.CommandContext(ctx, qemuImgPath, "convert",
-		"-f", diskFormat, "-O", diskFormat, sourcePath, destPath)
-	if output, err := convertCmd.CombinedOutput(); err != nil {
+	if output, err := runner.CombinedOutput(ctx, nil, qemuImgPath, "convert",
+		"-f", diskFormat, "-O", diskFormat, sourcePath, destPath); err != nil {
 		if !destExisted {
 			_ = os.Remove(destPath)
 		}
@@ -1600,17 +2020,23 @@ func verifyPodmanDiskImage(ctx context.Context, qemuImgPath, diskFormat, diskPat
 		return nil
 	}
 
-	checkCmd := exec.CommandContext(ctx, qemuImgPath, "check", diskPath)
-	if output, err := checkCmd.CombinedOutput(); err != nil {
+	if output, err := runner.CombinedOutput(ctx, nil, qemuImgPath, "check", diskPath); err != nil {
 		return fmt.Errorf("qemu-img check failed: %w (output: %s)", err, string(output))
 	}
 	return nil
 }
 
+// restartAfterCompaction starts the current machine and waits until podman
+// lists it as running and it answers over ssh.
+func (p *PodmanPlugin) restartAfterCompaction(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	return restartVMAfterCompaction(ctx, cfg, p.Name(), p.environment.MachineName, podmanMachineStart, podmanHealthProbe(p.environment.MachineName), logger)
+}
+
 // compactRawDisk performs offline disk compaction for the Podman machine VM.
 // For raw disk images (applehv, libkrun): creates a sparse copy via qemu-img.
 // For qcow2 (qemu): converts to reclaim space.
-// ONLY runs at Critical level with explicit opt-in via config.
+// ONLY runs at Critical level with explicit opt-in via config, once risks
+// have been acknowledged.
 func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, logger *slog.Logger) (int64, error) {
 	if !p.environment.VMRunning || p.environment.MachineName == "" {
 		return 0, nil
@@ -1624,6 +2050,12 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 			"reason", plan.SkipReason)
 		return 0, nil
 	}
+	if !requireRiskAcknowledgment(cfg, p.Name(), "disk_compact", plan.MachineName, logger) {
+		return 0, nil
+	}
+	if !cooldownAllows(cfg, p.Name(), "disk_compact", logger) {
+		return 0, nil
+	}
 
 	logger.Warn("CRITICAL: stopping Podman machine for disk compaction",
 		"machine", p.environment.MachineName,
@@ -1632,12 +2064,19 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 		"physical_gb", fmt.Sprintf("%.1f", float64(plan.PhysicalBytes)/float64(podmanCompactionGiB)),
 		"required_free_gb", fmt.Sprintf("%.1f", float64(plan.RequiredFreeBytes)/float64(podmanCompactionGiB)))
 
+	volume := measureCompactionVolume(plan.DiskPath, getFreeDiskSpace)
+
 	// 1. Stop machine
-	stopCmd := exec.CommandContext(ctx, "podman", "machine", "stop", p.environment.MachineName)
-	if output, err := stopCmd.CombinedOutput(); err != nil {
-		return 0, fmt.Errorf("failed to stop machine: %w (output: %s)", err, string(output))
+	if output, err := runner.CombinedOutput(ctx, nil, "podman", "machine", "stop", p.environment.MachineName); err != nil {
+		return 0, newCommandError(p.Name(), "vm_stop", err, string(output)).withVM(p.environment.MachineName)
 	}
 	p.environment.VMRunning = false
+	// A stopped machine is the disruption the cooldown paces, whether or not
+	// the compaction itself succeeds.
+	defer recordCooldown(cfg, p.Name(), "disk_compact", logger)
+	// Once stopped, the machine must be restarted even if the cycle deadline
+	// cancels ctx mid-compaction.
+	restartCtx := context.WithoutCancel(ctx)
 
 	// 2. Convert to sparse copy
 	logger.Info("compacting Podman machine disk", "machine", p.environment.MachineName)
@@ -1645,10 +2084,18 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 	if qemuImgPath == "" {
 		qemuImgPath = "qemu-img"
 	}
-	if err := convertPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath, plan.TempPath); err != nil {
+	stopProgress := watchCompactionProgress(ctx, plan.TempPath, compactionProgressInterval,
+		logCompactionProgress(logger, plan.PhysicalBytes, "machine", p.environment.MachineName))
+	err := convertPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath, plan.TempPath)
+	stopProgress()
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Warn("Podman disk compaction cancelled; the original disk image is unchanged",
+				"machine", p.environment.MachineName, "error", ctx.Err())
+		}
 		os.Remove(plan.TempPath)
 		// Restart machine before returning
-		exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+		p.restartAfterCompaction(restartCtx, cfg, logger)
 		p.environment.VMRunning = true
 		return 0, err
 	}
@@ -1656,14 +2103,14 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 	// 3. Verify if qcow2 format
 	if err := verifyPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.TempPath); err != nil {
 		os.Remove(plan.TempPath)
-		exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+		p.restartAfterCompaction(restartCtx, cfg, logger)
 		p.environment.VMRunning = true
 		return 0, err
 	}
 
 	if _, err := os.Stat(plan.TempPath); err != nil {
 		os.Remove(plan.TempPath)
-		exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+		p.restartAfterCompaction(restartCtx, cfg, logger)
 		p.environment.VMRunning = true
 		return 0, fmt.Errorf("cannot stat compacted disk: %w", err)
 	}
@@ -1672,26 +2119,26 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 	if plan.CrossDeviceReplacement {
 		if !cfg.Podman.CompactKeepBackupUntilRestart {
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			return 0, fmt.Errorf("cross-device disk replacement requires compact_keep_backup_until_restart")
 		}
 		if err := convertPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath, plan.BackupPath); err != nil {
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			return 0, fmt.Errorf("failed to preserve original disk backup: %w", err)
 		}
 		if err := os.Remove(plan.DiskPath); err != nil {
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			return 0, fmt.Errorf("failed to remove original disk after preserving backup: %w", err)
 		}
 		if err := convertPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.TempPath, plan.DiskPath); err != nil {
 			restoreErr := restorePodmanDiskBackupCopy(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath, plan.BackupPath)
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			if restoreErr != nil {
 				return 0, fmt.Errorf("failed to write compacted disk and restore backup: replace=%w restore=%v", err, restoreErr)
@@ -1701,7 +2148,7 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 		if err := verifyPodmanDiskImage(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath); err != nil {
 			restoreErr := restorePodmanDiskBackupCopy(ctx, qemuImgPath, plan.DiskFormat, plan.DiskPath, plan.BackupPath)
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			if restoreErr != nil {
 				return 0, fmt.Errorf("failed to verify compacted disk and restore backup: verify=%w restore=%v", err, restoreErr)
@@ -1712,14 +2159,14 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 	} else if cfg.Podman.CompactKeepBackupUntilRestart {
 		if err := os.Rename(plan.DiskPath, plan.BackupPath); err != nil {
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			return 0, fmt.Errorf("failed to preserve original disk backup: %w", err)
 		}
 		if err := os.Rename(plan.TempPath, plan.DiskPath); err != nil {
 			restoreErr := os.Rename(plan.BackupPath, plan.DiskPath)
 			os.Remove(plan.TempPath)
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
 			p.environment.VMRunning = true
 			if restoreErr != nil {
 				return 0, fmt.Errorf("failed to replace disk and restore backup: replace=%w restore=%v", err, restoreErr)
@@ -1728,15 +2175,20 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 		}
 	} else if err := os.Rename(plan.TempPath, plan.DiskPath); err != nil {
 		os.Remove(plan.TempPath)
-		exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+		p.restartAfterCompaction(restartCtx, cfg, logger)
 		p.environment.VMRunning = true
 		return 0, fmt.Errorf("failed to replace disk: %w", err)
 	}
 
+	// A kept backup holds the original blocks until it is removed after the
+	// restart, so the filesystem is measured then instead.
+	if !cfg.Podman.CompactKeepBackupUntilRestart {
+		volume.measureAfter()
+	}
+
 	// 5. Restart machine
 	logger.Info("restarting Podman machine after compaction", "machine", p.environment.MachineName)
-	startCmd := exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName)
-	if output, err := startCmd.CombinedOutput(); err != nil {
+	if output, err := runner.CombinedOutput(restartCtx, nil, "podman", "machine", "start", p.environment.MachineName); err != nil {
 		logger.Error("failed to restart machine after compaction",
 			"machine", p.environment.MachineName, "error", err, "output", string(output))
 		if cfg.Podman.CompactKeepBackupUntilRestart {
@@ -1747,19 +2199,34 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 				restoreErr = restorePodmanDiskBackup(plan.DiskPath, plan.BackupPath)
 			}
 			if restoreErr != nil {
+				recordVMRestartFailure(cfg, p.Name(), p.environment.MachineName, restoreErr, false, logger)
 				return 0, fmt.Errorf("failed to restart machine after compaction and restore backup: restart=%w restore=%v", err, restoreErr)
 			}
-			exec.CommandContext(ctx, "podman", "machine", "start", p.environment.MachineName).Run()
+			p.restartAfterCompaction(restartCtx, cfg, logger)
+		} else {
+			recordVMRestartFailure(cfg, p.Name(), p.environment.MachineName, err, false, logger)
 		}
 		p.environment.VMRunning = true
-		return 0, fmt.Errorf("failed to restart machine after compaction: %w", err)
+		return 0, newCommandError(p.Name(), "vm_restart", err, string(output)).withVM(p.environment.MachineName)
 	}
 	p.environment.VMRunning = true
+	if err := waitVMHealthy(restartCtx, podmanHealthProbe(p.environment.MachineName)); err != nil {
+		healthErr := newPluginError(p.Name(), "vm_health", err).withVM(p.environment.MachineName)
+		logger.Error("Podman machine restarted after compaction but is not healthy; inspect it before use",
+			"machine", p.environment.MachineName, "error", err)
+		if cfg.Podman.CompactKeepBackupUntilRestart {
+			logger.Warn("keeping original Podman disk backup until the machine is healthy", "backup", plan.BackupPath)
+		}
+		recordVMRestartFailure(cfg, p.Name(), p.environment.MachineName, healthErr, false, logger)
+		return 0, healthErr
+	}
+	clearVMRestartFailure(cfg, p.Name(), p.environment.MachineName, logger)
 
 	if cfg.Podman.CompactKeepBackupUntilRestart {
 		if err := os.Remove(plan.BackupPath); err != nil {
 			return 0, fmt.Errorf("compacted disk verified but backup remains at %s: %w", plan.BackupPath, err)
 		}
+		volume.measureAfter()
 	}
 
 	finalStat, err := os.Stat(plan.DiskPath)
@@ -1771,7 +2238,7 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 		return 0, fmt.Errorf("cannot stat compacted disk allocation: %w", err)
 	}
 
-	freed := safeBytesDiff(plan.PhysicalBytes, physicalAfter)
+	freed := volume.verifiedCompactionFreed(safeBytesDiff(plan.PhysicalBytes, physicalAfter), logger, "machine", p.environment.MachineName)
 	if freed > 0 {
 		logger.Info("Podman disk compaction complete",
 			"machine", p.environment.MachineName,
@@ -1788,10 +2255,9 @@ func (p *PodmanPlugin) compactRawDisk(ctx context.Context, cfg *config.Config, l
 }
 
 // getMachineDiskPath extracts the disk image path from podman machine config.
-func (p *PodmanPlugin) getMachineDiskPath(ctx context.Context) (string, error) {
+func (p *PodmanPlugin) getMachineDiskPath(ctx context.Context, machineName string) (string, error) {
 	// Strategy 1: Try podman machine inspect for ImagePath/DiskPath (older Podman)
-	cmd := exec.CommandContext(ctx, "podman", "machine", "inspect", p.environment.MachineName)
-	if output, err := cmd.Output(); err == nil {
+	if output, err := runner.Output(ctx, nil, "podman", "machine", "inspect", machineName); err == nil {
 		outputStr := string(output)
 		// Check for simple string value: "ImagePath": "/path/to/disk"
 		for _, key := range []string{"ImagePath", "DiskPath"} {
@@ -1805,26 +2271,84 @@ func (p *PodmanPlugin) getMachineDiskPath(ctx context.Context) (string, error) {
 		configDirRe := regexp.MustCompile(`"ConfigDir"\s*:\s*\{\s*"Path"\s*:\s*"([^"]+)"`)
 		if matches := configDirRe.FindStringSubmatch(outputStr); len(matches) > 1 {
 			configDir := matches[1]
-			return p.readDiskPathFromConfig(configDir)
+			return p.readDiskPathFromConfig(configDir, machineName)
 		}
 	}
 
 	// Strategy 2: Read internal config JSON from known provider paths
-	home, _ := os.UserHomeDir()
-	providers := []string{"libkrun", "applehv", "qemu"}
-	for _, provider := range providers {
-		configDir := filepath.Join(home, ".config/containers/podman/machine", provider)
-		if path, err := p.readDiskPathFromConfig(configDir); err == nil {
+	home, err := env.HomeDir()
+	if err != nil {
+		return "", fmt.Errorf("disk path not found in machine inspect output: %w", err)
+	}
+	for _, configDir := range podmanMachineConfigDirs(home, os.Getenv("XDG_CONFIG_HOME")) {
+		if path, err := p.readDiskPathFromConfig(configDir, machineName); err == nil {
 			return path, nil
 		}
 	}
 
+	// Strategy 3: Look for the image in the machine data directories, where
+	// Linux keeps ~/.local/share/containers/podman/machine/qemu/<name>-<arch>.qcow2
+	if path, err := findPodmanMachineDisk(podmanMachineDataDirs(home, os.Getenv("XDG_DATA_HOME")), machineName); err == nil {
+		return path, nil
+	}
+
 	return "", fmt.Errorf("disk path not found in machine config")
 }
 
+// podmanMachineProviders lists the machine providers whose config and data
+// directories are searched, in lookup order.
+var podmanMachineProviders = []string{"libkrun", "applehv", "qemu", "wsl", "hyperv"}
+
+// podmanMachineConfigDirs returns the per-provider machine config directories
+// under $XDG_CONFIG_HOME, or ~/.config when it is unset.
+func podmanMachineConfigDirs(home, xdgConfigHome string) []string {
+	root := filepath.Join(home, ".config")
+	if xdgConfigHome != "" {
+		root = xdgConfigHome
+	}
+	return podmanMachineProviderDirs(filepath.Join(root, "containers/podman/machine"))
+}
+
+// podmanMachineDataDirs returns the per-provider machine data directories
+// under $XDG_DATA_HOME, or ~/.local/share when it is unset.
+func podmanMachineDataDirs(home, xdgDataHome string) []string {
+	root := filepath.Join(home, ".local/share")
+	if xdgDataHome != "" {
+		root = xdgDataHome
+	}
+	return podmanMachineProviderDirs(filepath.Join(root, "containers/podman/machine"))
+}
+
+func podmanMachineProviderDirs(base string) []string {
+	dirs := make([]string, 0, len(podmanMachineProviders))
+	for _, provider := range podmanMachineProviders {
+		dirs = append(dirs, filepath.Join(base, provider))
+	}
+	return dirs
+}
+
+// findPodmanMachineDisk returns the first disk image for machine in dirs.
+// Podman 5 names images <name>-<arch>.<format>; Podman 4 on Linux used
+// <name>_fedora-coreos-<version>.qcow2.
+func findPodmanMachineDisk(dirs []string, machine string) (string, error) {
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(machine) + `(-(amd64|arm64|x86_64|aarch64)|_fedora-coreos-[^/]+)?\.(qcow2|raw)$`)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && pattern.MatchString(entry.Name()) {
+				return filepath.Join(dir, entry.Name()), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no disk image for machine %s", machine)
+}
+
 // readDiskPathFromConfig reads the disk image path from a machine config JSON file.
-func (p *PodmanPlugin) readDiskPathFromConfig(configDir string) (string, error) {
-	configFile := filepath.Join(configDir, p.environment.MachineName+".json")
+func (p *PodmanPlugin) readDiskPathFromConfig(configDir, machineName string) (string, error) {
+	configFile := filepath.Join(configDir, machineName+".json")
 	data, err := os.ReadFile(configFile)
 	if err != nil {
 		return "", err
@@ -1847,36 +2371,11 @@ func (p *PodmanPlugin) cleanInsideVM(ctx context.Context, level CleanupLevel, lo
 		return result
 	}
 
-	var commands [][]string
-
-	switch level {
-	case LevelWarning:
-		commands = [][]string{
-			{"podman", "image", "prune", "-f"},
-		}
-	case LevelModerate:
-		commands = [][]string{
-			{"podman", "image", "prune", "-f"},
-			{"podman", "container", "prune", "-f"},
-			{"podman", "image", "prune", "--build-cache", "-f"},
-		}
-	case LevelAggressive:
-		commands = [][]string{
-			{"podman", "image", "prune", "-af"},
-			{"podman", "container", "prune", "-f"},
-			{"podman", "volume", "prune", "-f"},
-			{"podman", "image", "prune", "--build-cache", "-f"},
-		}
-	case LevelCritical:
-		commands = [][]string{
-			{"podman", "system", "prune", "-af", "--volumes"},
-		}
-	}
+	commands := podmanVMCommands(level)
 
 	for _, args := range commands {
-		cmd := exec.CommandContext(ctx, "podman",
+		output, err := runner.CombinedOutput(ctx, nil, "podman",
 			append([]string{"machine", "ssh", p.environment.MachineName, "--"}, args...)...)
-		output, err := cmd.CombinedOutput()
 		if err != nil {
 			logger.Debug("VM cleanup command failed", "args", args, "error", err)
 			continue
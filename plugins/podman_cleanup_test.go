@@ -0,0 +1,258 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestPodmanCleanupModerateRunsPrunesInOrder(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("host podman cleanup without a machine is Linux-only")
+	}
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"podman image prune -f":                       {Output: "Total reclaimed space: 10MB\n"},
+		"podman image prune -af --filter until=24h":   {Err: errors.New("exit status 125")},
+		"podman container prune -f --filter until=1h": {Output: "Total reclaimed space: 0B\n"},
+		"podman image prune --build-cache -f":         {Output: "Total reclaimed space: 1.5GB\n"},
+	})
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewPodmanPlugin().Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("moderate prune failures should not fail the plugin, got %v", result.Error)
+	}
+	if want := int64(10*1024*1024 + 1.5*1024*1024*1024); result.BytesFreed != want {
+		t.Fatalf("BytesFreed = %d, want %d", result.BytesFreed, want)
+	}
+	if result.ItemsCleaned != 3 {
+		t.Fatalf("ItemsCleaned = %d, want 3 successful prunes", result.ItemsCleaned)
+	}
+
+	want := []string{
+		"podman info --format {{.Version.Version}}",
+		"podman machine list --format {{.Name}}\t{{.Running}}",
+		"podman system connection list --format {{.Name}}\t{{.URI}}\t{{.Default}}",
+		"podman image prune -f",
+		"podman image prune -af --filter until=24h",
+		"podman container prune -f --filter until=1h",
+		"podman image prune --build-cache -f",
+	}
+	if got := fake.commandLines("podman"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("podman commands = %v, want %v", got, want)
+	}
+}
+
+func TestPodmanCleanupSkipsWithoutPodman(t *testing.T) {
+	fake := useFakeRunner(t, nil)
+	fake.missing["podman"] = true
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewPodmanPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.Error != nil || result.BytesFreed != 0 {
+		t.Fatalf("expected a quiet skip, got %+v", result)
+	}
+	if len(fake.calls) != 0 {
+		t.Fatalf("expected no commands without podman, got %v", fake.commandLines(""))
+	}
+}
+
+func TestPodmanCleanupCriticalSystemPrune(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newPlugin := func() *PodmanPlugin {
+		return &PodmanPlugin{environment: &PodmanEnvironment{Runtime: "podman"}}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Podman.BuildKitPrune = false
+	fake := useFakeRunner(t, nil)
+	result := newPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.Error != nil || len(fake.calls) != 0 {
+		t.Fatalf("expected no broad prune while critical_system_prune is off, got %+v with %v", result, fake.commandLines(""))
+	}
+
+	cfg.Podman.CriticalSystemPrune = true
+	fake = useFakeRunner(t, map[string]fakeResponse{
+		"podman system prune -af --volumes": {Output: "Total reclaimed space: 2GB\n"},
+		"podman system prune --external -f": {Err: errors.New("unknown flag: --external")},
+	})
+	result = newPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.Error != nil {
+		t.Fatalf("unsupported --external should not fail the plugin, got %v", result.Error)
+	}
+	if result.BytesFreed != 2*1024*1024*1024 || result.ItemsCleaned != 1 {
+		t.Fatalf("expected the system prune to be counted once, got %+v", result)
+	}
+	want := []string{"podman system prune -af --volumes", "podman system prune --external -f"}
+	if got := fake.commandLines(""); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %v, want %v", got, want)
+	}
+
+	fake = useFakeRunner(t, map[string]fakeResponse{
+		"podman system prune -af --volumes": {Err: errors.New("exit status 125"), Output: "storage locked"},
+	})
+	result = newPlugin().Cleanup(context.Background(), LevelCritical, cfg, logger)
+	pluginErr, ok := AsPluginError(result.Error)
+	if !ok || pluginErr.Operation != "system_prune" || pluginErr.CommandOutput != "storage locked" {
+		t.Fatalf("expected system_prune PluginError, got %v", result.Error)
+	}
+	if got := fake.commandLines(""); !reflect.DeepEqual(got, []string{"podman system prune -af --volumes"}) {
+		t.Fatalf("expected external prune to be skipped after a failed system prune, got %v", got)
+	}
+}
+
+func TestPodmanCleanupTargetsEachMachineConnection(t *testing.T) {
+	fake := useFakeRunner(t, nil)
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	p := &PodmanPlugin{environment: &PodmanEnvironment{
+		Runtime:         "podman",
+		NeedsVM:         true,
+		RunningMachines: []string{"podman-machine-default", "x86-emulation"},
+	}}
+
+	p.Cleanup(context.Background(), LevelWarning, cfg, logger)
+
+	want := []string{
+		"podman --connection podman-machine-default image prune -f",
+		"podman --connection x86-emulation image prune -f",
+	}
+	if runtime.GOOS == "linux" {
+		// Linux hosts also clean the native engine first.
+		want = append([]string{"podman image prune -f"}, want...)
+	}
+	if got := fake.commandLines(""); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %v, want %v", got, want)
+	}
+}
+
+func TestDetectPodmanEnvironmentFindsLinuxMachine(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Linux machine detection")
+	}
+	t.Setenv("CONTAINERS_MACHINE_PROVIDER", "")
+	t.Setenv("HOME", t.TempDir())
+	useFakeRunner(t, map[string]fakeResponse{
+		"podman machine list --format {{.Name}}\t{{.Running}}": {Output: "dev*\ttrue\nold\tfalse\n"},
+	})
+
+	environment, err := detectPodmanEnvironment()
+	if err != nil {
+		t.Fatalf("detectPodmanEnvironment failed: %v", err)
+	}
+	if !environment.NeedsVM || !environment.VMRunning || environment.MachineName != "dev" || environment.VMProvider != "qemu" {
+		t.Fatalf("expected a running qemu machine on Linux, got %+v", environment)
+	}
+
+	useFakeRunner(t, nil)
+	environment, err = detectPodmanEnvironment()
+	if err != nil {
+		t.Fatalf("detectPodmanEnvironment failed: %v", err)
+	}
+	if environment.NeedsVM || environment.StoragePath == "" {
+		t.Fatalf("expected native Podman without a machine, got %+v", environment)
+	}
+}
+
+func TestLinuxPodmanMachineDiskPaths(t *testing.T) {
+	home := t.TempDir()
+	configDirs := podmanMachineConfigDirs(home, "")
+	if want := filepath.Join(home, ".config/containers/podman/machine/qemu"); !slices.Contains(configDirs, want) {
+		t.Fatalf("config dirs %v missing %s", configDirs, want)
+	}
+	if dirs := podmanMachineDataDirs(home, "/xdg/data"); !slices.Contains(dirs, "/xdg/data/containers/podman/machine/qemu") {
+		t.Fatalf("data dirs %v should honor XDG_DATA_HOME", dirs)
+	}
+
+	qemuConfig := filepath.Join(home, ".config/containers/podman/machine/qemu")
+	if err := os.MkdirAll(qemuConfig, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	diskPath := filepath.Join(home, ".local/share/containers/podman/machine/qemu/dev-amd64.qcow2")
+	config := `{"ConfigPath":{"Path":"` + qemuConfig + `/dev.json"},"ImagePath":{"Path":"` + diskPath + `"},"Name":"dev"}`
+	if err := os.WriteFile(filepath.Join(qemuConfig, "dev.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&PodmanPlugin{}).readDiskPathFromConfig(qemuConfig, "dev")
+	if err != nil || got != diskPath {
+		t.Fatalf("readDiskPathFromConfig = %q, %v; want %q", got, err, diskPath)
+	}
+}
+
+func TestFindPodmanMachineDisk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"dev-2-amd64.qcow2",
+		"podman-machine-default_fedora-coreos-39.20240128.2.2-qemu.x86_64.qcow2",
+		"dev-amd64.qcow2",
+		"dev-amd64.qcow2.compact",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for machine, want := range map[string]string{
+		"dev":                    "dev-amd64.qcow2",
+		"dev-2":                  "dev-2-amd64.qcow2",
+		"podman-machine-default": "podman-machine-default_fedora-coreos-39.20240128.2.2-qemu.x86_64.qcow2",
+	} {
+		got, err := findPodmanMachineDisk([]string{filepath.Join(dir, "missing"), dir}, machine)
+		if err != nil || got != filepath.Join(dir, want) {
+			t.Errorf("findPodmanMachineDisk(%q) = %q, %v; want %s", machine, got, err, want)
+		}
+	}
+	if _, err := findPodmanMachineDisk([]string{dir}, "other"); err == nil {
+		t.Error("expected no disk for an unknown machine")
+	}
+}
+
+func TestPodmanCleanupDeepBuildCacheGC(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("host podman cleanup without a machine is Linux-only")
+	}
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"podman ps --filter name=buildx_buildkit --format {{.ID}}\t{{.Names}}": {Output: "abc\tbuildx_buildkit_ci0\n"},
+		"podman exec abc buildctl prune --all":                                 {Output: "Total:\t512MB\n"},
+	})
+	cfg := config.DefaultConfig()
+	cfg.Podman.DeepBuildCacheGC = true
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	result := NewPodmanPlugin().Cleanup(context.Background(), LevelAggressive, cfg, logger)
+	if result.DeepGCBytesFreed != 512<<20 || result.CommandBytesFreed != 512<<20 {
+		t.Fatalf("expected the deep GC to report 512MB, got %+v", result)
+	}
+	if got := fake.commandLines("podman exec abc buildctl"); !reflect.DeepEqual(got, []string{"podman exec abc buildctl prune --all"}) {
+		t.Fatalf("expected one deep prune, got %v", got)
+	}
+
+	fake = useFakeRunner(t, map[string]fakeResponse{
+		"ps -axo comm=,args=": {Output: "buildah buildah bud -t app .\n"},
+	})
+	result = NewPodmanPlugin().Cleanup(context.Background(), LevelAggressive, cfg, logger)
+	if result.DeepGCBytesFreed != 0 || len(fake.commandLines("podman exec")) != 0 {
+		t.Fatalf("expected no deep GC during a build, got %+v", result)
+	}
+}
+
+func TestPodmanBuildProcessReasons(t *testing.T) {
+	got := podmanBuildProcessReasons("podman podman build -t app .\nbuildah buildah bud .\nzsh -zsh\npodman podman ps\n")
+	if !reflect.DeepEqual(got, []string{"buildah build", "podman build"}) {
+		t.Fatalf("unexpected reasons %v", got)
+	}
+	if got := podmanBuildProcessReasons("podman podman images\n"); got != nil {
+		t.Fatalf("expected no build reasons, got %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// podmanRootfulStorage is the rootful engine's default graph root.
+const podmanRootfulStorage = "/var/lib/containers/storage"
+
+// podmanConnection is one entry from podman system connection list.
+type podmanConnection struct {
+	Name    string
+	URI     string
+	Default bool
+	// Rootful is true when the connection reaches a root-owned engine.
+	Rootful bool
+}
+
+// podmanRootlessStorage returns the rootless engine's default graph root,
+// honoring XDG_DATA_HOME.
+func podmanRootlessStorage(home, xdgDataHome string) string {
+	if xdgDataHome != "" {
+		return filepath.Join(xdgDataHome, "containers/storage")
+	}
+	return filepath.Join(home, ".local/share/containers/storage")
+}
+
+// detectPodmanConnections lists the configured Podman connections.
+func detectPodmanConnections() []podmanConnection {
+	output, err := runner.Output(context.Background(), nil, "podman", "system", "connection", "list", "--format", "{{.Name}}\t{{.URI}}\t{{.Default}}")
+	if err != nil {
+		return nil
+	}
+	return parsePodmanConnections(string(output))
+}
+
+// parsePodmanConnections parses podman system connection list output
+// formatted as name<TAB>uri<TAB>default. Older Podman marks the default
+// connection with a trailing "*" on the name instead.
+func parsePodmanConnections(output string) []podmanConnection {
+	var connections []podmanConnection
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		connection := podmanConnection{
+			Name:    strings.TrimRight(name, "*"),
+			URI:     strings.TrimSpace(parts[1]),
+			Default: strings.HasSuffix(name, "*"),
+		}
+		if len(parts) >= 3 && strings.EqualFold(strings.TrimSpace(parts[2]), "true") {
+			connection.Default = true
+		}
+		if connection.Name == "" {
+			continue
+		}
+		connection.Rootful = podmanURIRootful(connection.URI)
+		connections = append(connections, connection)
+	}
+	return connections
+}
+
+// podmanURIRootful reports whether a connection URI reaches a rootful
+// engine: an ssh connection as root, or the system socket under /run/podman.
+func podmanURIRootful(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	if parsed.User != nil && parsed.User.Username() == "root" {
+		return true
+	}
+	return parsed.Scheme == "unix" &&
+		(strings.HasPrefix(parsed.Path, "/run/podman/") || strings.HasPrefix(parsed.Path, "/var/run/podman/"))
+}
+
+// podmanConnectionSummary renders connections as name=rootful|rootless for
+// plan metadata, marking the default with "*".
+func podmanConnectionSummary(connections []podmanConnection) string {
+	entries := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		mode := "rootless"
+		if connection.Rootful {
+			mode = "rootful"
+		}
+		name := connection.Name
+		if connection.Default {
+			name += "*"
+		}
+		entries = append(entries, name+"="+mode)
+	}
+	return strings.Join(entries, ",")
+}
+
+// cleansRootful reports whether cleanup also targets the rootful system
+// engine: podman.clean_rootful on Linux when not already running as root,
+// where the native engine is the rootful one.
+func (p *PodmanPlugin) cleansRootful(cfg *config.Config) bool {
+	geteuid := os.Geteuid
+	if p.geteuid != nil {
+		geteuid = p.geteuid
+	}
+	return cfg.Podman.CleanRootful && runtime.GOOS == "linux" && geteuid() != 0
+}
+
+// detectRootfulStorage checks that sudo -n can reach the rootful engine and
+// returns its graph root.
+func detectRootfulStorage(ctx context.Context) (string, error) {
+	output, err := runner.Output(ctx, nil, "sudo", "-n", "podman", "info", "--format", "{{.Store.GraphRoot}}")
+	if err != nil {
+		return "", newCommandError("podman", "rootful_info", err, string(output))
+	}
+	if root := strings.TrimSpace(string(output)); root != "" {
+		return root, nil
+	}
+	return podmanRootfulStorage, nil
+}
+
+// cleanRootful runs a level against the rootful system engine through
+// sudo -n, with no machine as the active target. A sudo that would prompt
+// skips the rootful engine with a warning.
+func (p *PodmanPlugin) cleanRootful(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	logger = logger.With("connection", "rootful")
+	storage, err := detectRootfulStorage(ctx)
+	if err != nil {
+		logger.Warn("skipping rootful Podman cleanup; podman.clean_rootful needs passwordless sudo", "error", err)
+		return CleanupResult{Plugin: p.Name(), Level: level}
+	}
+	p.environment.RootfulStoragePath = storage
+
+	machine, running := p.environment.MachineName, p.environment.VMRunning
+	p.environment.MachineName, p.environment.VMRunning, p.environment.Rootful = "", false, true
+	defer func() {
+		p.environment.MachineName, p.environment.VMRunning, p.environment.Rootful = machine, running, false
+	}()
+	result := p.cleanLevel(ctx, level, cfg, logger)
+	logger.Info("Podman connection cleanup completed",
+		"storage", storage,
+		"level", level.String(),
+		"freed_mb", result.BytesFreed/(1024*1024),
+		"items", result.ItemsCleaned)
+	return result
+}
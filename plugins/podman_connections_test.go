@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestParsePodmanConnections(t *testing.T) {
+	output := "podman-machine-default\tssh://core@127.0.0.1:52341/run/user/501/podman/podman.sock\tfalse\n" +
+		"podman-machine-default-root\tssh://root@127.0.0.1:52341/run/podman/podman.sock\tfalse\n" +
+		"local\tunix:///run/user/1000/podman/podman.sock\ttrue\n" +
+		"system*\tunix:///run/podman/podman.sock\n" +
+		"\n"
+
+	got := parsePodmanConnections(output)
+	want := []podmanConnection{
+		{Name: "podman-machine-default", URI: "ssh://core@127.0.0.1:52341/run/user/501/podman/podman.sock"},
+		{Name: "podman-machine-default-root", URI: "ssh://root@127.0.0.1:52341/run/podman/podman.sock", Rootful: true},
+		{Name: "local", URI: "unix:///run/user/1000/podman/podman.sock", Default: true},
+		{Name: "system", URI: "unix:///run/podman/podman.sock", Default: true, Rootful: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parsePodmanConnections = %+v, want %+v", got, want)
+	}
+	if summary := podmanConnectionSummary(got[2:]); summary != "local*=rootless,system*=rootful" {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+	if got := parsePodmanConnections(""); len(got) != 0 {
+		t.Fatalf("expected no connections, got %+v", got)
+	}
+}
+
+func TestPodmanRootlessStorage(t *testing.T) {
+	if got := podmanRootlessStorage("/home/dev", ""); got != "/home/dev/.local/share/containers/storage" {
+		t.Fatalf("unexpected rootless storage %q", got)
+	}
+	if got := podmanRootlessStorage("/home/dev", "/xdg/data"); got != "/xdg/data/containers/storage" {
+		t.Fatalf("rootless storage should honor XDG_DATA_HOME, got %q", got)
+	}
+}
+
+func TestPodmanCleanupRootfulThroughSudo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rootful Podman cleanup is Linux-only")
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.DefaultConfig()
+	cfg.Podman.CleanRootful = true
+	newPlugin := func(euid int) *PodmanPlugin {
+		return &PodmanPlugin{
+			environment: &PodmanEnvironment{Runtime: "podman"},
+			geteuid:     func() int { return euid },
+		}
+	}
+
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"podman image prune -f":                             {Output: "Total reclaimed space: 10MB\n"},
+		"sudo -n podman info --format {{.Store.GraphRoot}}": {Output: "/srv/containers/storage\n"},
+		"sudo -n podman image prune -f":                     {Output: "Total reclaimed space: 20MB\n"},
+	})
+	plugin := newPlugin(1000)
+	result := plugin.Cleanup(context.Background(), LevelWarning, cfg, logger)
+	if result.Error != nil || result.BytesFreed != 30*1024*1024 || result.ItemsCleaned != 2 {
+		t.Fatalf("expected rootless and rootful prunes to add up, got %+v", result)
+	}
+	want := []string{
+		"podman image prune -f",
+		"sudo -n podman info --format {{.Store.GraphRoot}}",
+		"sudo -n podman image prune -f",
+	}
+	if got := fake.commandLines(""); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %v, want %v", got, want)
+	}
+	if plugin.environment.Rootful || plugin.environment.RootfulStoragePath != "/srv/containers/storage" {
+		t.Fatalf("expected the rootless target restored and storage recorded, got %+v", plugin.environment)
+	}
+
+	fake = useFakeRunner(t, map[string]fakeResponse{
+		"sudo -n podman info --format {{.Store.GraphRoot}}": {Output: "sudo: a password is required\n", Err: errors.New("exit status 1")},
+	})
+	if result := newPlugin(1000).Cleanup(context.Background(), LevelWarning, cfg, logger); result.Error != nil {
+		t.Fatalf("a sudo that would prompt should skip the rootful engine quietly, got %v", result.Error)
+	}
+	if got := fake.commandLines("sudo"); len(got) != 1 {
+		t.Fatalf("expected only the sudo preflight, got %v", got)
+	}
+
+	fake = useFakeRunner(t, nil)
+	newPlugin(0).Cleanup(context.Background(), LevelWarning, cfg, logger)
+	if got := fake.commandLines("sudo"); len(got) != 0 {
+		t.Fatalf("root already cleans the rootful engine natively, got %v", got)
+	}
+
+	commands := newPlugin(1000).CleanupCommands(LevelWarning, cfg)
+	if last := commands[len(commands)-1]; !reflect.DeepEqual(last, []string{"sudo", "-n", "podman", "image", "prune", "-f"}) {
+		t.Fatalf("expected the explained commands to end with the rootful prune, got %v", commands)
+	}
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseRunningMachinesReturnsAllRunning(t *testing.T) {
+	output := "podman-machine-default*\ttrue\nx86-emulation\ttrue\nstopped\tfalse\n"
+
+	got := parseRunningMachines(output)
+	want := []string{"podman-machine-default", "x86-emulation"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseRunningMachinesEmptyOutput(t *testing.T) {
+	if got := parseRunningMachines(""); len(got) != 0 {
+		t.Fatalf("expected no running machines, got %v", got)
+	}
+}
+
+func TestSelectPodmanMachines(t *testing.T) {
+	running := []string{"podman-machine-default", "x86-emulation"}
+
+	tests := []struct {
+		name       string
+		configured []string
+		want       []string
+	}{
+		{name: "empty selects all running", configured: nil, want: running},
+		{name: "configured order wins", configured: []string{"x86-emulation", "podman-machine-default"}, want: []string{"x86-emulation", "podman-machine-default"}},
+		{name: "stopped machines skipped", configured: []string{"missing", "x86-emulation"}, want: []string{"x86-emulation"}},
+		{name: "duplicates collapsed", configured: []string{"x86-emulation", "x86-emulation"}, want: []string{"x86-emulation"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := selectPodmanMachines(running, tc.configured)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPodmanCommandArgsTargetsMachineConnection(t *testing.T) {
+	p := &PodmanPlugin{environment: &PodmanEnvironment{
+		Runtime:         "podman",
+		NeedsVM:         true,
+		RunningMachines: []string{"podman-machine-default"},
+	}}
+	p.useMachine("podman-machine-default")
+
+	got := p.podmanCommandArgs("image", "prune", "-f")
+	want := []string{"image", "prune", "-f"}
+	if runtime.GOOS != "darwin" {
+		// Outside Darwin the default connection is the native engine.
+		want = []string{"--connection", "podman-machine-default", "image", "prune", "-f"}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("single machine args = %v, want %v", got, want)
+	}
+
+	p.environment.RunningMachines = append(p.environment.RunningMachines, "x86-emulation")
+	p.useMachine("x86-emulation")
+	got = p.podmanCommandArgs("image", "prune", "-f")
+	if want := []string{"--connection", "x86-emulation", "image", "prune", "-f"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected connection-scoped args %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,90 @@
+package plugins
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// riskAcknowledgmentFileName marks that the operator has read RiskWarning.
+// It lives beside the daemon state file.
+const riskAcknowledgmentFileName = "risks-acknowledged"
+
+// RiskWarning explains what offline VM disk compaction does. It is printed by
+// -acknowledge-risks and logged whenever compaction is refused.
+const RiskWarning = `WARNING: offline VM disk compaction is disruptive.
+
+When lima.compact_offline or podman.compact_disk_offline is enabled, a
+cleanup cycle may:
+  - stop the Lima VM or Podman machine, interrupting every container in it;
+  - rewrite the VM disk image with qemu-img and replace the original file;
+  - restart the VM, which can fail and leave it stopped until you intervene.
+
+A crash or full disk during the rewrite can corrupt the image. Back up any
+VM data you cannot recreate before enabling compaction.`
+
+// risksAcknowledgedForRun is set by -yes-i-understand for this process only.
+var risksAcknowledgedForRun atomic.Bool
+
+// AcknowledgeRisksForRun allows disruptive operations for the rest of this
+// process without writing the acknowledgment file.
+func AcknowledgeRisksForRun() {
+	risksAcknowledgedForRun.Store(true)
+}
+
+// RiskAcknowledgmentPath returns the acknowledgment file path, or "" when no
+// state file is configured.
+func RiskAcknowledgmentPath(cfg *config.Config) string {
+	if cfg.Policy.StateFile == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.Policy.StateFile), riskAcknowledgmentFileName)
+}
+
+// WriteRiskAcknowledgment records a one-time acknowledgment of RiskWarning.
+func WriteRiskAcknowledgment(cfg *config.Config, now time.Time) (string, error) {
+	path := RiskAcknowledgmentPath(cfg)
+	if path == "" {
+		return "", fmt.Errorf("policy.state_file is empty; cannot record risk acknowledgment")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("acknowledged %s\n", now.UTC().Format(time.RFC3339))
+	return path, os.WriteFile(path, []byte(content), 0o644)
+}
+
+// risksAcknowledged reports whether -yes-i-understand was passed or the
+// acknowledgment file exists.
+func risksAcknowledged(cfg *config.Config) bool {
+	if risksAcknowledgedForRun.Load() {
+		return true
+	}
+	path := RiskAcknowledgmentPath(cfg)
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// requireRiskAcknowledgment gates a disruptive operation. Without an
+// acknowledgment it logs RiskWarning and how to proceed, and returns false.
+func requireRiskAcknowledgment(cfg *config.Config, plugin, operation, vm string, logger *slog.Logger) bool {
+	if risksAcknowledged(cfg) {
+		return true
+	}
+	logger.Error("refusing disruptive operation until risks are acknowledged; run once with -acknowledge-risks or pass -yes-i-understand",
+		"plugin", plugin,
+		"operation", operation,
+		"vm", vm,
+		"acknowledgment_file", RiskAcknowledgmentPath(cfg),
+		"warning", RiskWarning,
+	)
+	return false
+}
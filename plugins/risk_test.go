@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestRequireRiskAcknowledgment(t *testing.T) {
+	t.Cleanup(func() { risksAcknowledgedForRun.Store(false) })
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = filepath.Join(t.TempDir(), "state", "state.json")
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	if requireRiskAcknowledgment(cfg, "podman", "disk_compact", "podman-machine-default", logger) {
+		t.Fatal("expected compaction to be refused before acknowledgment")
+	}
+	if !strings.Contains(logs.String(), "-acknowledge-risks") || !strings.Contains(logs.String(), "rewrite the VM disk image") {
+		t.Fatalf("expected refusal to explain the risk and how to proceed, got %s", logs.String())
+	}
+
+	path, err := WriteRiskAcknowledgment(cfg, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("WriteRiskAcknowledgment failed: %v", err)
+	}
+	if path != filepath.Join(filepath.Dir(cfg.Policy.StateFile), riskAcknowledgmentFileName) {
+		t.Fatalf("acknowledgment path = %s, want beside the state file", path)
+	}
+	if !requireRiskAcknowledgment(cfg, "podman", "disk_compact", "podman-machine-default", logger) {
+		t.Fatal("expected compaction to proceed once the acknowledgment file exists")
+	}
+}
+
+func TestAcknowledgeRisksForRun(t *testing.T) {
+	t.Cleanup(func() { risksAcknowledgedForRun.Store(false) })
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = ""
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	if requireRiskAcknowledgment(cfg, "lima", "disk_compact", "default", logger) {
+		t.Fatal("expected refusal without a state file or flag")
+	}
+	if _, err := WriteRiskAcknowledgment(cfg, time.Now()); err == nil {
+		t.Fatal("expected an error recording acknowledgment without a state file")
+	}
+	AcknowledgeRisksForRun()
+	if !requireRiskAcknowledgment(cfg, "lima", "disk_compact", "default", logger) {
+		t.Fatal("expected -yes-i-understand to allow compaction")
+	}
+}
@@ -33,6 +33,11 @@ func (p *RKE2Plugin) Description() string {
 	return "Cleans RKE2/k3s containerd images, old pod logs, and kubelet garbage"
 }
 
+// Priority runs RKE2 cleanup late because pruned images are re-pulled by the node.
+func (p *RKE2Plugin) Priority() int {
+	return 65
+}
+
 // SupportedPlatforms returns supported platforms (Linux only).
 func (p *RKE2Plugin) SupportedPlatforms() []string {
 	return []string{PlatformLinux}
@@ -143,7 +148,7 @@ func (p *RKE2Plugin) cleanOldPodLogs(ctx context.Context, logger *slog.Logger) C
 	})
 
 	if err != nil {
-		result.Error = err
+		result.Error = newPluginError(p.Name(), "pod_log_cleanup", err).withPath(podLogDir)
 	}
 
 	// Also clean container logs in /var/log/containers
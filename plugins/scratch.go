@@ -0,0 +1,406 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// ScratchPlugin deletes old files from user-nominated scratch directories,
+// like tmpreaper does for /tmp. It never crosses a mount boundary, only
+// deletes files owned by the owner of the scratch directory, and refuses
+// roots that would widen into a whole filesystem or the home directory.
+type ScratchPlugin struct{}
+
+// NewScratchPlugin creates a new scratch directory cleanup plugin.
+func NewScratchPlugin() *ScratchPlugin {
+	return &ScratchPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *ScratchPlugin) Name() string {
+	return "scratch"
+}
+
+// Description returns the plugin description.
+func (p *ScratchPlugin) Description() string {
+	return "Deletes old files from user-nominated scratch directories"
+}
+
+// Priority runs scratch cleanup with the other cheap cache clears.
+func (p *ScratchPlugin) Priority() int {
+	return 10
+}
+
+// SupportedPlatforms returns supported platforms (all).
+func (p *ScratchPlugin) SupportedPlatforms() []string {
+	return nil
+}
+
+// Enabled reports whether scratch cleanup is enabled and any directory is
+// configured.
+func (p *ScratchPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.Scratch && len(cfg.ScratchDirs) > 0
+}
+
+// DataPaths returns the configured scratch directories, or nil when the home
+// directory is unavailable.
+func (p *ScratchPlugin) DataPaths(cfg *config.Config) []string {
+	home, err := env.HomeDir()
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, 0, len(cfg.ScratchDirs))
+	for _, dir := range cfg.ScratchDirs {
+		paths = append(paths, expandHome(dir.Path, home))
+	}
+	return paths
+}
+
+// LevelDescription summarizes scratch cleanup at each level.
+func (p *ScratchPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports files in scratch_dirs older than each directory's max_age_days"
+	case LevelModerate, LevelAggressive, LevelCritical:
+		return "deletes files in scratch_dirs older than each directory's max_age_days, or gzips them in place with scratch.compress_instead_of_delete, and empty subdirectories when remove_empty_dirs is set"
+	default:
+		return "no cleanup"
+	}
+}
+
+// ValidateScratchDirs rejects scratch_dirs entries without a positive
+// max_age_days, and paths the scratch guard refuses. Any entry is rejected
+// when the home directory is unavailable, since the guard cannot then tell
+// whether a path is the home directory or one of its ancestors.
+func ValidateScratchDirs(dirs []config.ScratchDirConfig) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		return fmt.Errorf("scratch_dirs: %w", err)
+	}
+	for i, dir := range dirs {
+		if dir.Path == "" {
+			return fmt.Errorf("scratch_dirs[%d].path is required", i)
+		}
+		if dir.MaxAgeDays <= 0 {
+			return fmt.Errorf("scratch_dirs[%d].max_age_days must be positive", i)
+		}
+		if reason := scratchRootRefusal(expandHome(dir.Path, home), home); reason != "" {
+			return fmt.Errorf("scratch_dirs[%d].path %q refused: %s", i, dir.Path, reason)
+		}
+	}
+	return nil
+}
+
+// ValidateScratchConfig rejects a min_compression_ratio below 1 when
+// compress_instead_of_delete is set.
+func ValidateScratchConfig(cfg config.ScratchConfig) error {
+	if !cfg.CompressInsteadOfDelete {
+		return nil
+	}
+	return validateCompressionRatio("scratch.min_compression_ratio", cfg.MinCompressionRatio)
+}
+
+// scratchRootRefusal explains why path may not be a scratch root, or returns
+// "" when it may. Relative paths, the filesystem root, top-level directories,
+// and the home directory or any of its ancestors are refused. Every path is
+// refused when home is empty, since its ancestors are then unknown.
+func scratchRootRefusal(path, home string) string {
+	if home == "" {
+		return "home directory unavailable, so the path cannot be checked against it"
+	}
+	if !filepath.IsAbs(path) {
+		return "path must be absolute or start with ~"
+	}
+	clean := filepath.Clean(path)
+	if clean == string(filepath.Separator) {
+		return "filesystem root"
+	}
+	if filepath.Dir(clean) == string(filepath.Separator) {
+		return "top-level directory"
+	}
+	if rel, err := filepath.Rel(clean, filepath.Clean(home)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "home directory or one of its ancestors"
+	}
+	return ""
+}
+
+// scratchDirStats is what one pass over a scratch directory found or removed.
+type scratchDirStats struct {
+	Files int
+	Bytes int64
+	Dirs  int
+	// Compressed files were gzipped in place, saving SavedBytes.
+	Compressed int
+	SavedBytes int64
+}
+
+// scratchRoot resolves a configured scratch directory, following a symlinked
+// root and re-checking the guard against its target.
+func scratchRoot(dir config.ScratchDirConfig, home string) (string, error) {
+	path := expandHome(dir.Path, home)
+	if reason := scratchRootRefusal(path, home); reason != "" {
+		return "", fmt.Errorf("refused: %s", reason)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if reason := scratchRootRefusal(resolved, home); reason != "" {
+		return "", fmt.Errorf("symlink target %s refused: %s", resolved, reason)
+	}
+	if info, err := os.Stat(resolved); err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("not a directory")
+	}
+	return resolved, nil
+}
+
+// sweepScratchDir finds files under root older than cutoff that are on
+// root's filesystem and owned by root's owner, deleting them when remove is
+// set. With compress, only files worth compressing are found, and remove
+// gzips them in place instead. With remove and removeEmpty, subdirectories
+// left empty are removed too when they were already older than cutoff or
+// this pass emptied them.
+func sweepScratchDir(root string, cutoff time.Time, recursive, remove, removeEmpty bool, compress *fileCompressor) (scratchDirStats, error) {
+	var stats scratchDirStats
+	var rootStat syscall.Stat_t
+	if err := syscall.Stat(root, &rootStat); err != nil {
+		return stats, err
+	}
+
+	type scratchSubdir struct {
+		path string
+		old  bool
+	}
+	var subdirs []scratchSubdir
+	emptied := map[string]bool{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || uint64(stat.Dev) != uint64(rootStat.Dev) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if !recursive {
+				return filepath.SkipDir
+			}
+			if stat.Uid == rootStat.Uid {
+				subdirs = append(subdirs, scratchSubdir{path: path, old: info.ModTime().Before(cutoff)})
+			}
+			return nil
+		}
+		if stat.Uid != rootStat.Uid || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if compress != nil {
+			if !compressCandidate(entry.Name()) {
+				return nil
+			}
+			if remove {
+				saved, err := compress.compress(path, info)
+				if err != nil {
+					if !errors.Is(err, errCompressSkipped) {
+						walkErrors.note(err)
+					}
+					return nil
+				}
+				stats.Compressed++
+				stats.SavedBytes += saved
+				return nil
+			}
+		}
+		size := accountedFileBytes(info)
+		if remove {
+			if err := os.Remove(path); err != nil {
+				walkErrors.note(err)
+				return nil
+			}
+			emptied[filepath.Dir(path)] = true
+		}
+		stats.Files++
+		stats.Bytes += size
+		return nil
+	})
+	if err != nil || !remove || !removeEmpty {
+		return stats, err
+	}
+
+	// Walk order lists each directory before its children, so the reverse
+	// reaches children first and a parent can be emptied by their removal.
+	for i := len(subdirs) - 1; i >= 0; i-- {
+		dir := subdirs[i]
+		if !dir.old && !emptied[dir.path] {
+			continue
+		}
+		if entries, err := os.ReadDir(dir.path); err != nil || len(entries) > 0 {
+			continue
+		}
+		if os.Remove(dir.path) == nil {
+			stats.Dirs++
+			emptied[filepath.Dir(dir.path)] = true
+		}
+	}
+	return stats, nil
+}
+
+// PlanCleanup reports the expired files in each scratch directory.
+func (p *ScratchPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "Scratch directory cleanup plan",
+		WouldRun: level >= LevelModerate,
+		Steps:    []string{"Find files older than each scratch directory's max_age_days on its own filesystem and owned by its owner"},
+		Metadata: map[string]string{
+			"cleanup_level": level.String(),
+			"scratch_dirs":  strconv.Itoa(len(cfg.ScratchDirs)),
+		},
+	}
+	compressMode := cfg.Scratch.CompressInsteadOfDelete
+	if compressMode {
+		plan.Metadata["compress_instead_of_delete"] = "true"
+	}
+	if level >= LevelModerate && compressMode {
+		plan.Steps = append(plan.Steps, fmt.Sprintf("Gzip those files in place where that makes them at least %gx smaller, skipping compressed files and files open for writing, and remove empty subdirectories where remove_empty_dirs is set", cfg.Scratch.MinCompressionRatio))
+	} else if level >= LevelModerate {
+		plan.Steps = append(plan.Steps, "Delete those files, and empty subdirectories where remove_empty_dirs is set")
+	} else {
+		plan.SkipReason = "warning_level_reports_only"
+	}
+
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+	for _, dir := range cfg.ScratchDirs {
+		target := CleanupTarget{Type: "scratch-dir", Name: dir.Path, Path: expandHome(dir.Path, home)}
+		root, err := scratchRoot(dir, home)
+		if err != nil {
+			target.Protected = true
+			target.Action = "skip"
+			target.Reason = err.Error()
+			plan.Targets = append(plan.Targets, target)
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -dir.MaxAgeDays)
+		var compress *fileCompressor
+		if compressMode {
+			compress = newFileCompressor(ctx, cfg.Scratch.MinCompressionRatio)
+		}
+		stats, err := sweepScratchDir(root, cutoff, dir.Recursive, false, false, compress)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", dir.Path, err))
+			continue
+		}
+		target.Path = root
+		target.Bytes = stats.Bytes
+		target.Action = "report"
+		target.Protected = level < LevelModerate
+		tier := CleanupTierDestructive
+		switch {
+		case level >= LevelModerate && compressMode:
+			target.Action = "compress_expired_files"
+			tier = CleanupTierWarm
+		case level >= LevelModerate:
+			target.Action = "delete_expired_files"
+		}
+		target.Reason = fmt.Sprintf("%d files older than %d days", stats.Files, dir.MaxAgeDays)
+		if compressMode {
+			target.Reason = fmt.Sprintf("%d uncompressed files older than %d days; savings depend on how well they compress", stats.Files, dir.MaxAgeDays)
+		}
+		annotateCleanupTargetPolicy(&target, tier, hostReclaimForAction(target.Action))
+		plan.Targets = append(plan.Targets, target)
+		if level >= LevelModerate && !compressMode {
+			plan.EstimatedBytesFreed += stats.Bytes
+		}
+	}
+	return plan
+}
+
+// Cleanup deletes expired files from each scratch directory at moderate level
+// and above, and reports them at warning level.
+func (p *ScratchPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	skipped := walkErrors.snapshot()
+
+	remove := level >= LevelModerate
+	var compress *fileCompressor
+	if cfg.Scratch.CompressInsteadOfDelete {
+		if err := ValidateScratchConfig(cfg.Scratch); err != nil {
+			logger.Warn("skipping scratch cleanup", "error", err)
+			return result
+		}
+		compress = newFileCompressor(ctx, cfg.Scratch.MinCompressionRatio)
+	}
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping scratch cleanup: home directory unavailable", "error", err)
+		return result
+	}
+	for _, dir := range cfg.ScratchDirs {
+		if ctx.Err() != nil {
+			break
+		}
+		root, err := scratchRoot(dir, home)
+		if err != nil {
+			logger.Warn("skipping scratch dir", "path", dir.Path, "error", err)
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -dir.MaxAgeDays)
+		stats, err := sweepScratchDir(root, cutoff, dir.Recursive, remove, dir.Recursive && dir.RemoveEmptyDirs, compress)
+		if err != nil {
+			logger.Warn("scratch dir cleanup failed", "path", root, "error", err)
+			continue
+		}
+		if !remove {
+			if stats.Files > 0 {
+				logger.Info("scratch dir has expired files", "path", root, "files", stats.Files, "mb", stats.Bytes/(1024*1024), "max_age_days", dir.MaxAgeDays)
+			}
+			continue
+		}
+		result.BytesFreed += stats.Bytes
+		result.CompressedBytesSaved += stats.SavedBytes
+		result.ItemsCleaned += stats.Files + stats.Dirs + stats.Compressed
+		if compress != nil {
+			logger.Info("compressed scratch dir", "path", root, "files", stats.Compressed, "dirs", stats.Dirs, "saved_mb", stats.SavedBytes/(1024*1024))
+			continue
+		}
+		logger.Info("cleaned scratch dir", "path", root, "files", stats.Files, "dirs", stats.Dirs, "freed_mb", stats.Bytes/(1024*1024))
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
@@ -0,0 +1,275 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+func writeAgedFile(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(-age)
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScratchRootRefusal(t *testing.T) {
+	home := "/home/alice"
+	cases := map[string]bool{
+		"/":                     true,
+		"/tmp":                  true,
+		"/home":                 true,
+		"/home/alice":           true,
+		"/home/alice/":          true,
+		"relative/tmp":          true,
+		"/home/alice/scratch":   false,
+		"/home/alice-other/tmp": false,
+		"/var/tmp/builds":       false,
+	}
+	for path, refused := range cases {
+		if got := scratchRootRefusal(path, home) != ""; got != refused {
+			t.Errorf("scratchRootRefusal(%q) refused = %v, want %v", path, got, refused)
+		}
+	}
+}
+
+func TestValidateScratchDirs(t *testing.T) {
+	if err := ValidateScratchDirs([]config.ScratchDirConfig{{Path: t.TempDir(), MaxAgeDays: 7}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, dirs := range [][]config.ScratchDirConfig{
+		{{Path: "", MaxAgeDays: 7}},
+		{{Path: t.TempDir(), MaxAgeDays: 0}},
+		{{Path: "/", MaxAgeDays: 7}},
+		{{Path: "~", MaxAgeDays: 7}},
+		{{Path: "./tmp", MaxAgeDays: 7}},
+	} {
+		if err := ValidateScratchDirs(dirs); err == nil {
+			t.Errorf("expected %+v to be rejected", dirs)
+		}
+	}
+}
+
+func TestScratchCleanupDeletesExpiredFiles(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "old.log")
+	fresh := filepath.Join(root, "fresh.log")
+	nestedOld := filepath.Join(root, "build", "out", "old.o")
+	writeAgedFile(t, old, 4096, 10*24*time.Hour)
+	writeAgedFile(t, fresh, 4096, time.Hour)
+	writeAgedFile(t, nestedOld, 8192, 10*24*time.Hour)
+
+	cfg := config.DefaultConfig()
+	cfg.ScratchDirs = []config.ScratchDirConfig{{Path: root, MaxAgeDays: 7}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewScratchPlugin()
+
+	result := plugin.Cleanup(context.Background(), LevelWarning, cfg, logger)
+	if result.BytesFreed != 0 || !pathExists(old) {
+		t.Fatalf("warning level should only report, got %+v", result)
+	}
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if len(plan.Targets) != 1 || plan.Targets[0].Bytes != 4096 || plan.Targets[0].Action != "delete_expired_files" {
+		t.Fatalf("unexpected plan targets: %+v", plan.Targets)
+	}
+
+	result = plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 4096 || result.ItemsCleaned != 1 {
+		t.Fatalf("expected the top-level old file only, got %+v", result)
+	}
+	if pathExists(old) || !pathExists(fresh) || !pathExists(nestedOld) {
+		t.Fatal("non-recursive cleanup should delete only top-level expired files")
+	}
+
+	cfg.ScratchDirs[0].Recursive = true
+	cfg.ScratchDirs[0].RemoveEmptyDirs = true
+	result = plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 8192 || result.ItemsCleaned != 3 {
+		t.Fatalf("expected the nested file and its two emptied directories, got %+v", result)
+	}
+	if pathExists(filepath.Join(root, "build")) || !pathExists(fresh) {
+		t.Fatal("expected emptied subdirectories removed and fresh files kept")
+	}
+}
+
+func TestScratchRefusesEveryRootWithoutHome(t *testing.T) {
+	userHome := t.TempDir()
+	old := filepath.Join(userHome, "notes.txt")
+	writeAgedFile(t, old, 4096, 30*24*time.Hour)
+	env.Configure("", "")
+	t.Setenv("HOME", "")
+
+	dirs := []config.ScratchDirConfig{{Path: userHome, MaxAgeDays: 7, Recursive: true}}
+	if err := ValidateScratchDirs(dirs); err == nil || !strings.Contains(err.Error(), "home directory unavailable") {
+		t.Fatalf("expected scratch_dirs to be rejected without a home, got %v", err)
+	}
+	if reason := scratchRootRefusal(userHome, ""); reason == "" {
+		t.Fatal("expected the guard to refuse every root without a home")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ScratchDirs = dirs
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewScratchPlugin()
+	if plan := plugin.PlanCleanup(context.Background(), LevelCritical, cfg, logger); plan.SkipReason != "home_unavailable" || plan.WouldRun || len(plan.Targets) != 0 {
+		t.Fatalf("expected the plan to skip with home_unavailable, got %+v", plan)
+	}
+	if paths := plugin.DataPaths(cfg); paths != nil {
+		t.Fatalf("expected no data paths without a home, got %v", paths)
+	}
+	if result := plugin.Cleanup(context.Background(), LevelCritical, cfg, logger); result.BytesFreed != 0 || result.ItemsCleaned != 0 || !pathExists(old) {
+		t.Fatalf("expected the home directory to be left alone, got %+v", result)
+	}
+}
+
+func TestScratchCleanupKeepsOtherOwnersFiles(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing file ownership requires root")
+	}
+	root := t.TempDir()
+	foreign := filepath.Join(root, "foreign.log")
+	writeAgedFile(t, foreign, 4096, 10*24*time.Hour)
+	if err := os.Lchown(foreign, 65534, 65534); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ScratchDirs = []config.ScratchDirConfig{{Path: root, MaxAgeDays: 7}}
+	result := NewScratchPlugin().Cleanup(context.Background(), LevelCritical, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if result.BytesFreed != 0 || !pathExists(foreign) {
+		t.Fatalf("expected a file owned by another user to be kept, got %+v", result)
+	}
+}
+
+func TestScratchCleanupRefusesSymlinkToHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil || strings.HasPrefix(t.TempDir(), home) {
+		t.Skip("needs a temp dir outside the home directory")
+	}
+	link := filepath.Join(t.TempDir(), "scratch")
+	if err := os.Symlink(home, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scratchRoot(config.ScratchDirConfig{Path: link, MaxAgeDays: 1}, home); err == nil {
+		t.Fatal("expected a scratch root that resolves to the home directory to be refused")
+	}
+}
+
+func TestScratchCleanupCompressesInsteadOfDeleting(t *testing.T) {
+	root := t.TempDir()
+	csv := filepath.Join(root, "results.csv")
+	content := []byte(strings.Repeat("2026-01-01,build,passed,123\n", 4096))
+	if err := os.WriteFile(csv, content, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(csv, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(root, "old.tar.gz")
+	writeAgedFile(t, archive, 65536, 10*24*time.Hour)
+	held := filepath.Join(root, "held.log")
+	writeAgedFile(t, held, 65536, 10*24*time.Hour)
+	writer, err := os.OpenFile(held, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.ScratchDirs = []config.ScratchDirConfig{{Path: root, MaxAgeDays: 7}}
+	cfg.Scratch.CompressInsteadOfDelete = true
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewScratchPlugin()
+
+	plan := plugin.PlanCleanup(context.Background(), LevelModerate, cfg, logger)
+	if len(plan.Targets) != 1 || plan.Targets[0].Action != "compress_expired_files" || plan.EstimatedBytesFreed != 0 {
+		t.Fatalf("unexpected compress plan: %+v", plan)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelModerate, cfg, logger)
+	if result.BytesFreed != 0 || result.ItemsCleaned != 1 || result.CompressedBytesSaved <= 0 {
+		t.Fatalf("expected one file compressed and nothing deleted, got %+v", result)
+	}
+	if pathExists(csv) || !pathExists(archive) {
+		t.Fatal("expected the csv replaced and the archive left alone")
+	}
+	if goosValue == PlatformLinux && (!pathExists(held) || pathExists(held+compressedSuffix)) {
+		t.Fatal("expected the file open for writing to be left alone")
+	}
+
+	info, err := os.Stat(csv + compressedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(stamp) || info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected mtime and mode preserved, got %v %v", info.ModTime(), info.Mode())
+	}
+	file, err := os.Open(csv + compressedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := io.ReadAll(reader); err != nil || !bytes.Equal(restored, content) {
+		t.Fatalf("compressed copy does not round-trip: %v", err)
+	}
+}
+
+func TestScratchCompressionRatioGuardKeepsFile(t *testing.T) {
+	root := t.TempDir()
+	noisy := filepath.Join(root, "noise.bin")
+	data := make([]byte, 65536)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(noisy, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(-10 * 24 * time.Hour)
+	if err := os.Chtimes(noisy, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ScratchDirs = []config.ScratchDirConfig{{Path: root, MaxAgeDays: 7}}
+	cfg.Scratch.CompressInsteadOfDelete = true
+	result := NewScratchPlugin().Cleanup(context.Background(), LevelCritical, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if result.ItemsCleaned != 0 || result.CompressedBytesSaved != 0 || !pathExists(noisy) {
+		t.Fatalf("expected an incompressible file to be kept as is, got %+v", result)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Fatalf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestValidateScratchConfig(t *testing.T) {
+	if err := ValidateScratchConfig(config.ScratchConfig{MinCompressionRatio: 0}); err != nil {
+		t.Fatalf("ratio is unused without compress_instead_of_delete: %v", err)
+	}
+	if err := ValidateScratchConfig(config.ScratchConfig{CompressInsteadOfDelete: true, MinCompressionRatio: 0.5}); err == nil {
+		t.Fatal("expected a ratio below 1 to be rejected")
+	}
+}
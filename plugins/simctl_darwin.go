@@ -0,0 +1,128 @@
+//go:build darwin
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Simulator device data containers grow to gigabytes with installed apps and
+// their documents. `xcrun simctl erase` resets a shut-down device to factory
+// state without deleting it, so Xcode still lists the device.
+
+// simctlNow is the clock device idle time is measured against.
+var simctlNow = time.Now
+
+// simDevice is one device from `xcrun simctl list devices --json`.
+type simDevice struct {
+	UDID         string `json:"udid"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	DataPath     string `json:"dataPath"`
+	LastBootedAt string `json:"lastBootedAt"`
+	// Runtime is the runtime identifier the device is listed under.
+	Runtime string `json:"-"`
+}
+
+// parseSimctlDevices parses `xcrun simctl list devices --json`, ordered by
+// runtime and name.
+func parseSimctlDevices(output []byte) ([]simDevice, error) {
+	var listing struct {
+		Devices map[string][]simDevice `json:"devices"`
+	}
+	if err := json.Unmarshal(output, &listing); err != nil {
+		return nil, fmt.Errorf("parse simctl device list: %w", err)
+	}
+	var devices []simDevice
+	for runtime, list := range listing.Devices {
+		for _, device := range list {
+			device.Runtime = runtime
+			devices = append(devices, device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].Runtime != devices[j].Runtime {
+			return devices[i].Runtime < devices[j].Runtime
+		}
+		return devices[i].Name < devices[j].Name
+	})
+	return devices, nil
+}
+
+// lastUsed returns when the device was last booted. Older Xcode releases omit
+// lastBootedAt, so the data directory's modification time stands in.
+func (d simDevice) lastUsed() (time.Time, bool) {
+	if booted, err := time.Parse(time.RFC3339, d.LastBootedAt); err == nil {
+		return booted, true
+	}
+	if info, err := os.Stat(d.DataPath); err == nil {
+		return info.ModTime(), true
+	}
+	return time.Time{}, false
+}
+
+// unusedSimDevices returns the shut-down devices last used before cutoff.
+// Booted devices, and devices whose last use cannot be told, are kept.
+func unusedSimDevices(devices []simDevice, cutoff time.Time) []simDevice {
+	var unused []simDevice
+	for _, device := range devices {
+		if !strings.EqualFold(device.State, "Shutdown") || device.DataPath == "" {
+			continue
+		}
+		if used, ok := device.lastUsed(); !ok || !used.Before(cutoff) {
+			continue
+		}
+		unused = append(unused, device)
+	}
+	return unused
+}
+
+// listSimDevices lists simulator devices through simctl.
+func listSimDevices(ctx context.Context) ([]simDevice, error) {
+	listCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	output, err := runner.Output(listCtx, nil, "xcrun", "simctl", "list", "devices", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("xcrun simctl list devices: %w", err)
+	}
+	return parseSimctlDevices(output)
+}
+
+// eraseUnusedDevices erases shut-down devices not booted within days and
+// returns the bytes freed, measured per device from its data directory, and
+// the number of devices erased. Erase failures are logged and skipped.
+func (p *IOSSimulatorPlugin) eraseUnusedDevices(ctx context.Context, days int, logger *slog.Logger) (int64, int) {
+	if days <= 0 {
+		return 0, 0
+	}
+	devices, err := listSimDevices(ctx)
+	if err != nil {
+		logger.Warn("skipping unused iOS Simulator device erase", "error", err)
+		return 0, 0
+	}
+
+	var freed int64
+	erased := 0
+	for _, device := range unusedSimDevices(devices, simctlNow().AddDate(0, 0, -days)) {
+		sizeBefore := getDirSize(device.DataPath)
+		eraseCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		output, err := runner.CombinedOutput(eraseCtx, nil, "xcrun", "simctl", "erase", device.UDID)
+		cancel()
+		if err != nil {
+			logger.Warn("xcrun simctl erase failed", "device", device.Name, "udid", device.UDID, "error", err, "output", strings.TrimSpace(string(output)))
+			continue
+		}
+		deviceFreed := max(sizeBefore-getDirSize(device.DataPath), 0)
+		logger.Info("erased unused iOS Simulator device", "device", device.Name, "runtime", strings.TrimPrefix(device.Runtime, "com.apple.CoreSimulator.SimRuntime."), "udid", device.UDID, "freed_mb", deviceFreed/(1024*1024))
+		freed += deviceFreed
+		erased++
+	}
+	return freed, erased
+}
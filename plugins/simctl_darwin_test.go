@@ -0,0 +1,60 @@
+//go:build darwin
+
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnusedSimDevicesKeepsBootedAndRecentDevices(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	previous := simctlNow
+	simctlNow = func() time.Time { return now }
+	t.Cleanup(func() { simctlNow = previous })
+
+	root := t.TempDir()
+	dataPath := func(udid string) string { return filepath.Join(root, udid, "data") }
+	writeAgedFile(t, filepath.Join(dataPath("OLD"), "app.bin"), 4096, 0)
+	old := now.AddDate(0, 0, -60).Format(time.RFC3339)
+	recent := now.AddDate(0, 0, -2).Format(time.RFC3339)
+	listing := `{"devices": {
+		"com.apple.CoreSimulator.SimRuntime.iOS-17-0": [
+			{"udid": "OLD", "name": "iPhone 15", "state": "Shutdown", "dataPath": "` + dataPath("OLD") + `", "lastBootedAt": "` + old + `"},
+			{"udid": "RECENT", "name": "iPhone 15 Pro", "state": "Shutdown", "dataPath": "` + dataPath("RECENT") + `", "lastBootedAt": "` + recent + `"},
+			{"udid": "BOOTED", "name": "iPad Air", "state": "Booted", "dataPath": "` + dataPath("BOOTED") + `", "lastBootedAt": "` + old + `"}
+		],
+		"com.apple.CoreSimulator.SimRuntime.watchOS-10-0": [
+			{"udid": "UNKNOWN", "name": "Apple Watch", "state": "Shutdown", "dataPath": "` + dataPath("UNKNOWN") + `"}
+		]
+	}}`
+	fake := useFakeRunner(t, map[string]fakeResponse{"xcrun simctl list devices --json": {Output: listing}})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, erased := NewIOSSimulatorPlugin().eraseUnusedDevices(context.Background(), 30, logger)
+	if got, want := fake.commandLines("xcrun simctl erase"), []string{"xcrun simctl erase OLD"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("erase commands = %v, want %v", got, want)
+	}
+	if erased != 1 {
+		t.Fatalf("erased = %d, want 1", erased)
+	}
+
+	devices, err := parseSimctlDevices([]byte(listing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := iosSimulatorUnusedDeviceTargets(unusedSimDevices(devices, now.AddDate(0, 0, -30)), 30, false)
+	if len(targets) != 1 || targets[0].Name != "iPhone 15" || targets[0].Version != "iOS-17-0" || targets[0].Bytes != 4096 {
+		t.Fatalf("unexpected erase targets: %+v", targets)
+	}
+
+	fake.calls = nil
+	if _, erased := NewIOSSimulatorPlugin().eraseUnusedDevices(context.Background(), 0, logger); erased != 0 || len(fake.calls) != 0 {
+		t.Fatalf("expected erase_unused_after_days 0 to do nothing, got %d erased, calls %v", erased, fake.calls)
+	}
+}
@@ -0,0 +1,321 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+// SparseFilesPlugin reports large files whose apparent size and allocated
+// size disagree, the same comparison the VM plugins make for disk images,
+// applied to any file. A heavily sparse file makes du --apparent-size and
+// file managers overstate its cost; a dense one is really using the space.
+// The plugin never deletes or modifies anything.
+type SparseFilesPlugin struct{}
+
+// sparseFile is one large file found by the scan.
+type sparseFile struct {
+	Path           string
+	ApparentBytes  int64
+	AllocatedBytes int64
+	// Density is "sparse", "dense", or "partial".
+	Density string
+	// Format names a recognized compactable format, such as "qcow2".
+	Format string
+	// Compaction is how that format is compacted.
+	Compaction string
+}
+
+// sparseFileFormats maps disk image extensions to how they are compacted.
+// SQLite databases and qcow2 images are recognized by their headers instead.
+var sparseFileFormats = map[string]string{
+	".raw":  "raw disk image",
+	".img":  "raw disk image",
+	".vmdk": "vmdk disk image",
+	".vdi":  "vdi disk image",
+	".vhd":  "vhd disk image",
+	".vhdx": "vhdx disk image",
+}
+
+var (
+	sqliteHeader = []byte("SQLite format 3\x00")
+	qcow2Header  = []byte("QFI\xfb")
+)
+
+// NewSparseFilesPlugin creates a new large sparse file report plugin.
+func NewSparseFilesPlugin() *SparseFilesPlugin {
+	return &SparseFilesPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *SparseFilesPlugin) Name() string {
+	return "sparse-files"
+}
+
+// Description returns the plugin description.
+func (p *SparseFilesPlugin) Description() string {
+	return "Reports large sparse and dense files and flags compactable formats"
+}
+
+// Priority runs the report after every cleanup plugin, so it reflects what
+// they left behind.
+func (p *SparseFilesPlugin) Priority() int {
+	return 110
+}
+
+// SupportedPlatforms returns supported platforms (all).
+func (p *SparseFilesPlugin) SupportedPlatforms() []string {
+	return nil
+}
+
+// Enabled checks if the sparse file report is enabled.
+func (p *SparseFilesPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.SparseFiles
+}
+
+// LevelDescription summarizes the sparse file report at each level.
+func (p *SparseFilesPlugin) LevelDescription(level CleanupLevel) string {
+	if level == LevelNone {
+		return "no cleanup"
+	}
+	return "reports files above sparse_files.min_size_gb with their apparent and allocated sizes; never deletes"
+}
+
+// ValidateSparseFilesConfig rejects a non-positive min_size_gb and
+// percentages that are out of range or leave no band between sparse and
+// dense.
+func ValidateSparseFilesConfig(cfg config.SparseFilesConfig) error {
+	if cfg.MinSizeGB <= 0 {
+		return fmt.Errorf("sparse_files.min_size_gb must be positive, got %v", cfg.MinSizeGB)
+	}
+	if cfg.SparsePercent <= 0 || cfg.DensePercent > 100 || cfg.SparsePercent >= cfg.DensePercent {
+		return fmt.Errorf("sparse_files needs 0 < sparse_percent < dense_percent <= 100, got %d and %d", cfg.SparsePercent, cfg.DensePercent)
+	}
+	return nil
+}
+
+// classifySparseFile returns "sparse" when allocated is below sparsePercent
+// of apparent, "dense" at or above densePercent, and "partial" between.
+func classifySparseFile(apparent, allocated int64, sparsePercent, densePercent int) string {
+	switch {
+	case allocated*100 < apparent*int64(sparsePercent):
+		return "sparse"
+	case allocated*100 >= apparent*int64(densePercent):
+		return "dense"
+	default:
+		return "partial"
+	}
+}
+
+// sparseFileFormat recognizes formats that have an offline compaction
+// procedure, returning the format and how to compact it.
+func sparseFileFormat(path string) (string, string) {
+	header := make([]byte, len(sqliteHeader))
+	if file, err := os.Open(path); err == nil {
+		n, _ := io.ReadFull(file, header)
+		file.Close()
+		header = header[:n]
+	}
+	switch {
+	case bytes.HasPrefix(header, sqliteHeader):
+		return "SQLite database", "VACUUM with the database closed"
+	case bytes.HasPrefix(header, qcow2Header):
+		return "qcow2 disk image", "qemu-img convert with the VM stopped"
+	}
+	if format, ok := sparseFileFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return format, "fstrim in the guest, or qemu-img convert with the VM stopped"
+	}
+	return "", ""
+}
+
+// scanSparseFiles returns regular files under root, on root's filesystem,
+// whose apparent or allocated size reaches minBytes, largest allocation
+// first.
+func scanSparseFiles(ctx context.Context, root string, minBytes int64, sparsePercent, densePercent int) ([]sparseFile, error) {
+	rootDev, err := deviceID(root)
+	if err != nil {
+		return nil, err
+	}
+	var files []sparseFile
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				walkErrors.note(err)
+				return filepath.SkipDir
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); !ok || uint64(stat.Dev) != rootDev {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			walkErrors.note(err)
+			return nil
+		}
+		apparent, allocated := info.Size(), allocatedBytes(info)
+		if apparent < minBytes && allocated < minBytes {
+			return nil
+		}
+		file := sparseFile{
+			Path:           path,
+			ApparentBytes:  apparent,
+			AllocatedBytes: allocated,
+			Density:        classifySparseFile(apparent, allocated, sparsePercent, densePercent),
+		}
+		file.Format, file.Compaction = sparseFileFormat(path)
+		files = append(files, file)
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool { return files[i].AllocatedBytes > files[j].AllocatedBytes })
+	return files, err
+}
+
+// sparseFileRoots returns the scan paths expanded against home, dropping any
+// that stay relative, such as "~" when the home directory is unavailable.
+func sparseFileRoots(cfg *config.Config, home string) []string {
+	roots := make([]string, 0, len(cfg.SparseFiles.ScanPaths))
+	for _, path := range cfg.SparseFiles.ScanPaths {
+		if root := expandHome(path, home); filepath.IsAbs(root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+func (p *SparseFilesPlugin) scan(ctx context.Context, cfg *config.Config, report func(root string, err error)) []sparseFile {
+	minBytes := int64(cfg.SparseFiles.MinSizeGB * 1024 * 1024 * 1024)
+	home, err := env.HomeDir()
+	if err != nil {
+		home = ""
+	}
+	var files []sparseFile
+	for _, root := range sparseFileRoots(cfg, home) {
+		if ctx.Err() != nil {
+			break
+		}
+		if !pathExistsAndIsDir(root) {
+			continue
+		}
+		found, err := scanSparseFiles(ctx, root, minBytes, cfg.SparseFiles.SparsePercent, cfg.SparseFiles.DensePercent)
+		if err != nil && ctx.Err() == nil {
+			report(root, err)
+		}
+		files = append(files, found...)
+	}
+	return files
+}
+
+func sparseFileReason(file sparseFile) string {
+	percent := 0
+	if file.ApparentBytes > 0 {
+		percent = int(file.AllocatedBytes * 100 / file.ApparentBytes)
+	}
+	reason := fmt.Sprintf("%s: apparent %.1f GB, allocated %.1f GB (%d%%)", file.Density,
+		float64(file.ApparentBytes)/(1024*1024*1024), float64(file.AllocatedBytes)/(1024*1024*1024), percent)
+	if file.Format != "" {
+		reason += fmt.Sprintf("; %s, candidate for compaction (%s)", file.Format, file.Compaction)
+	}
+	return reason
+}
+
+// PlanCleanup lists each large file as a report-only target.
+func (p *SparseFilesPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	plan := CleanupPlan{
+		Plugin:     p.Name(),
+		Level:      level.String(),
+		Summary:    "Large sparse file report",
+		WouldRun:   false,
+		SkipReason: "report_only",
+		Steps: []string{
+			fmt.Sprintf("Find files of at least %.0f GB apparent or allocated under the scan paths, staying on each path's filesystem", cfg.SparseFiles.MinSizeGB),
+			fmt.Sprintf("Flag files allocating under %d%% of their apparent size as sparse and at least %d%% as dense", cfg.SparseFiles.SparsePercent, cfg.SparseFiles.DensePercent),
+			"Flag SQLite databases and VM disk images as candidates for compaction",
+		},
+		Metadata: map[string]string{
+			"cleanup_level": level.String(),
+			"min_size_gb":   strconv.FormatFloat(cfg.SparseFiles.MinSizeGB, 'f', -1, 64),
+		},
+	}
+	if err := ValidateSparseFilesConfig(cfg.SparseFiles); err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+
+	counts := map[string]int{}
+	candidates := 0
+	for _, file := range p.scan(ctx, cfg, func(root string, err error) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not scan %s: %v", root, err))
+	}) {
+		target := CleanupTarget{
+			Type:      "large-file",
+			Name:      filepath.Base(file.Path),
+			Path:      file.Path,
+			Bytes:     file.AllocatedBytes,
+			Action:    "report",
+			Protected: true,
+			Reason:    sparseFileReason(file),
+		}
+		annotateCleanupTargetPolicy(&target, CleanupTierSafe, hostReclaimForAction(target.Action))
+		plan.Targets = append(plan.Targets, target)
+		counts[file.Density]++
+		if file.Format != "" {
+			candidates++
+		}
+	}
+	for _, density := range []string{"sparse", "partial", "dense"} {
+		plan.Metadata[density+"_count"] = strconv.Itoa(counts[density])
+	}
+	plan.Metadata["compaction_candidates"] = strconv.Itoa(candidates)
+	return plan
+}
+
+// Cleanup logs each large file with its apparent and allocated sizes. It
+// never frees space.
+func (p *SparseFilesPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{Plugin: p.Name(), Level: level}
+	if err := ValidateSparseFilesConfig(cfg.SparseFiles); err != nil {
+		logger.Warn("skipping sparse file report", "error", err)
+		return result
+	}
+
+	skipped := walkErrors.snapshot()
+	for _, file := range p.scan(ctx, cfg, func(root string, err error) {
+		logger.Warn("sparse file scan failed", "path", root, "error", err)
+	}) {
+		logger.Info("large "+file.Density+" file",
+			"path", file.Path,
+			"apparent_gb", fmt.Sprintf("%.1f", float64(file.ApparentBytes)/(1024*1024*1024)),
+			"allocated_gb", fmt.Sprintf("%.1f", float64(file.AllocatedBytes)/(1024*1024*1024)),
+			"format", file.Format,
+			"compaction_candidate", file.Format != "")
+	}
+	recordWalkSkips(&result, skipped, logger)
+	return result
+}
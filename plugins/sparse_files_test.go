@@ -0,0 +1,112 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestClassifySparseFile(t *testing.T) {
+	cases := []struct {
+		apparent, allocated int64
+		want                string
+	}{
+		{200, 180, "dense"},
+		{200, 100, "partial"},
+		{200, 10, "sparse"},
+		{100, 120, "dense"},
+	}
+	for _, tc := range cases {
+		if got := classifySparseFile(tc.apparent, tc.allocated, 50, 90); got != tc.want {
+			t.Errorf("classifySparseFile(%d, %d) = %q, want %q", tc.apparent, tc.allocated, got, tc.want)
+		}
+	}
+}
+
+func TestSparseFilesPlanReportsLargeFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string, header []byte, written, apparent int64) string {
+		path := filepath.Join(root, name)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		data := make([]byte, written)
+		for i := range data {
+			data[i] = 1
+		}
+		copy(data, header)
+		if _, err := file.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Truncate(apparent); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	sparse := write("disk.img", nil, 1<<20, 64<<20)
+	dense := write("app.data", sqliteHeader, 8<<20, 8<<20)
+	write("small.log", nil, 1<<20, 1<<20)
+
+	info, err := os.Stat(sparse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocatedBytes(info) >= 32<<20 {
+		t.Skip("filesystem does not store sparse files")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Enable.SparseFiles = true
+	cfg.SparseFiles.ScanPaths = []string{root}
+	cfg.SparseFiles.MinSizeGB = 4.0 / 1024
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plugin := NewSparseFilesPlugin()
+
+	plan := plugin.PlanCleanup(context.Background(), LevelWarning, cfg, logger)
+	if len(plan.Targets) != 2 || plan.WouldRun || plan.EstimatedBytesFreed != 0 {
+		t.Fatalf("expected two report-only targets, got %+v", plan)
+	}
+	if plan.Targets[0].Path != dense || !strings.HasPrefix(plan.Targets[0].Reason, "dense") || !strings.Contains(plan.Targets[0].Reason, "SQLite database, candidate for compaction") {
+		t.Errorf("expected the dense SQLite file first, got %+v", plan.Targets[0])
+	}
+	if plan.Targets[1].Path != sparse || !strings.HasPrefix(plan.Targets[1].Reason, "sparse") || !strings.Contains(plan.Targets[1].Reason, "raw disk image") {
+		t.Errorf("expected the sparse disk image second, got %+v", plan.Targets[1])
+	}
+	for _, target := range plan.Targets {
+		if !target.Protected || target.Action != "report" {
+			t.Errorf("expected report-only target, got %+v", target)
+		}
+	}
+	if plan.Metadata["sparse_count"] != "1" || plan.Metadata["dense_count"] != "1" || plan.Metadata["compaction_candidates"] != "2" {
+		t.Errorf("unexpected metadata: %v", plan.Metadata)
+	}
+
+	result := plugin.Cleanup(context.Background(), LevelCritical, cfg, logger)
+	if result.BytesFreed != 0 || !pathExists(sparse) || !pathExists(dense) {
+		t.Fatalf("the report must not remove anything, got %+v", result)
+	}
+}
+
+func TestValidateSparseFilesConfig(t *testing.T) {
+	if err := ValidateSparseFilesConfig(config.DefaultConfig().SparseFiles); err != nil {
+		t.Fatalf("defaults should validate: %v", err)
+	}
+	for _, cfg := range []config.SparseFilesConfig{
+		{MinSizeGB: 0, SparsePercent: 50, DensePercent: 90},
+		{MinSizeGB: 10, SparsePercent: 0, DensePercent: 90},
+		{MinSizeGB: 10, SparsePercent: 90, DensePercent: 50},
+		{MinSizeGB: 10, SparsePercent: 50, DensePercent: 101},
+	} {
+		if err := ValidateSparseFilesConfig(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
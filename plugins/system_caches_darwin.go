@@ -0,0 +1,323 @@
+//go:build darwin
+
+// Package plugins provides cleanup plugin implementations.
+// system_caches_darwin.go resets the macOS Quick Look thumbnail and icon
+// services caches, which the system rebuilds on demand.
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/pkg/env"
+)
+
+const (
+	quickLookThumbnailCacheName = "com.apple.QuickLook.thumbnailcache"
+	iconServicesStorePath       = "/Library/Caches/com.apple.iconservices.store"
+)
+
+// SystemCachesPlugin handles macOS Quick Look and icon services cache resets.
+type SystemCachesPlugin struct{}
+
+// NewSystemCachesPlugin creates a new macOS system cache cleanup plugin.
+func NewSystemCachesPlugin() *SystemCachesPlugin {
+	return &SystemCachesPlugin{}
+}
+
+// Name returns the plugin identifier.
+func (p *SystemCachesPlugin) Name() string {
+	return "system-caches"
+}
+
+// Description returns the plugin description.
+func (p *SystemCachesPlugin) Description() string {
+	return "Resets macOS Quick Look thumbnail and icon services caches"
+}
+
+// Priority runs system cache resets early; both caches rebuild on demand.
+func (p *SystemCachesPlugin) Priority() int {
+	return 12
+}
+
+// SupportedPlatforms returns supported platforms (Darwin only).
+func (p *SystemCachesPlugin) SupportedPlatforms() []string {
+	return []string{PlatformDarwin}
+}
+
+// Enabled checks if system cache cleanup is enabled.
+func (p *SystemCachesPlugin) Enabled(cfg *config.Config) bool {
+	return cfg.Enable.SystemCaches
+}
+
+// LevelDescription summarizes system cache cleanup at each level.
+func (p *SystemCachesPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "reports Quick Look thumbnail and icon services cache sizes only"
+	case LevelModerate, LevelAggressive:
+		return "resets Quick Look thumbnails with qlmanage -r cache"
+	case LevelCritical:
+		return "resets Quick Look thumbnails; removes the icon services store and restarts the Dock with passwordless sudo when system_caches.reset_icon_services is true"
+	default:
+		return "no cleanup"
+	}
+}
+
+// PlanCleanup reports system cache candidates without resetting them.
+func (p *SystemCachesPlugin) PlanCleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupPlan {
+	_ = logger
+
+	plan := CleanupPlan{
+		Plugin:   p.Name(),
+		Level:    level.String(),
+		Summary:  "macOS system cache cleanup plan",
+		WouldRun: true,
+		Steps:    systemCachesPlanSteps(level),
+		Metadata: map[string]string{
+			"cleanup_level":       level.String(),
+			"reset_icon_services": strconv.FormatBool(cfg.SystemCaches.ResetIconServices),
+		},
+	}
+
+	home, err := env.HomeDir()
+	if err != nil {
+		plan.WouldRun = false
+		plan.SkipReason = "home_unavailable"
+		plan.Summary = "System cache cleanup skipped because the home directory is unavailable"
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return plan
+	}
+
+	_, qlErr := exec.LookPath("qlmanage")
+	sudoCap := DetectSudo(ctx)
+	plan.Metadata["qlmanage_available"] = strconv.FormatBool(qlErr == nil)
+	plan.Metadata["sudo_passwordless"] = strconv.FormatBool(sudoCap.Passwordless)
+
+	iconBytes := p.iconServicesSize(ctx, sudoCap.Passwordless)
+	plan.Targets = systemCachesPlanTargets(level, quickLookCacheSizes(quickLookCachePaths(ctx, home)), qlErr == nil, iconBytes, cfg.SystemCaches.ResetIconServices, sudoCap.Passwordless)
+	plan.EstimatedBytesFreed = cleanupTargetEstimatedBytes(plan.Targets)
+	plan.Metadata["target_count"] = strconv.Itoa(len(plan.Targets))
+
+	if level == LevelWarning {
+		plan.Summary = "System caches are report-only at warning level"
+		plan.WouldRun = false
+		plan.SkipReason = "report_only"
+	}
+	if level == LevelCritical && cfg.SystemCaches.ResetIconServices && !sudoCap.Passwordless {
+		plan.Warnings = append(plan.Warnings, "icon services reset requires passwordless sudo")
+	}
+	if level == LevelCritical && cfg.SystemCaches.ResetIconServices {
+		plan.Warnings = append(plan.Warnings, "icon services reset restarts the Dock; windows and Spaces are kept")
+	}
+	return plan
+}
+
+// Cleanup performs system cache cleanup at the specified level.
+func (p *SystemCachesPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
+	result := CleanupResult{
+		Plugin: p.Name(),
+		Level:  level,
+	}
+
+	home, err := env.HomeDir()
+	if err != nil {
+		logger.Warn("skipping system cache cleanup: home directory unavailable", "error", err)
+		return result
+	}
+	qlPaths := quickLookCachePaths(ctx, home)
+
+	if level == LevelWarning {
+		var total int64
+		for _, cache := range quickLookCacheSizes(qlPaths) {
+			total += cache.Bytes
+		}
+		logger.Info("macOS system cache status",
+			"quicklook_mb", total/(1024*1024),
+			"icon_services_mb", p.iconServicesSize(ctx, false)/(1024*1024))
+		return result
+	}
+
+	if level >= LevelModerate {
+		p.resetQuickLook(ctx, qlPaths, &result, logger)
+	}
+
+	if level >= LevelCritical {
+		if !cfg.SystemCaches.ResetIconServices {
+			logger.Debug("icon services reset not enabled (system_caches.reset_icon_services)")
+			return result
+		}
+		sudoCap := DetectSudo(ctx)
+		if !sudoCap.Passwordless {
+			logger.Warn("passwordless sudo not available, skipping icon services reset")
+			return result
+		}
+		p.resetIconServices(ctx, &result, logger)
+	}
+
+	return result
+}
+
+// resetQuickLook clears Quick Look thumbnails with qlmanage and measures the
+// thumbnail cache directories before and after.
+func (p *SystemCachesPlugin) resetQuickLook(ctx context.Context, paths []string, result *CleanupResult, logger *slog.Logger) {
+	if _, err := exec.LookPath("qlmanage"); err != nil {
+		logger.Debug("qlmanage not available, skipping Quick Look reset")
+		return
+	}
+
+	before := quickLookCacheSizes(paths)
+	output, err := exec.CommandContext(ctx, "qlmanage", "-r", "cache").CombinedOutput()
+	if err != nil {
+		result.Error = newCommandError(p.Name(), "quicklook_reset", err, string(output))
+		logger.Warn("Quick Look cache reset failed", "error", err, "output", strings.TrimSpace(string(output)))
+		return
+	}
+	result.ItemsCleaned++
+
+	for _, cache := range before {
+		freed := measuredBytesDiff(result, logger, cache.Path, cache.Bytes, getDirSize(cache.Path))
+		result.BytesFreed += freed
+	}
+	logger.Info("reset Quick Look thumbnail cache", "freed_mb", result.BytesFreed/(1024*1024))
+}
+
+// resetIconServices removes the icon services store and restarts the Dock so
+// it rebuilds. Both steps need root.
+func (p *SystemCachesPlugin) resetIconServices(ctx context.Context, result *CleanupResult, logger *slog.Logger) {
+	if !pathExists(iconServicesStorePath) {
+		logger.Debug("icon services store not found", "path", iconServicesStorePath)
+		return
+	}
+
+	size := p.iconServicesSize(ctx, true)
+	logger.Warn("CRITICAL: removing icon services store and restarting the Dock",
+		"path", iconServicesStorePath,
+		"size_mb", size/(1024*1024))
+	output, err := RunWithSudo(ctx, "rm", "-rf", iconServicesStorePath)
+	if err != nil {
+		pluginErr := newCommandError(p.Name(), "icon_services_reset", err, string(output))
+		pluginErr.Path = iconServicesStorePath
+		result.Error = pluginErr
+		logger.Error("failed to remove icon services store", "error", err, "output", string(output))
+		return
+	}
+	result.BytesFreed += size
+	result.ItemsCleaned++
+
+	if output, err := RunWithSudo(ctx, "killall", "Dock"); err != nil {
+		logger.Warn("Dock restart failed; icons rebuild at next login", "error", err, "output", strings.TrimSpace(string(output)))
+	}
+}
+
+// iconServicesSize measures the icon services store. The store is root-owned,
+// so it is sized with sudo du when passwordless sudo is available.
+func (p *SystemCachesPlugin) iconServicesSize(ctx context.Context, useSudo bool) int64 {
+	if !useSudo {
+		return getDirSize(iconServicesStorePath)
+	}
+	output, err := RunWithSudo(ctx, "du", "-sk", iconServicesStorePath)
+	if err != nil {
+		return getDirSize(iconServicesStorePath)
+	}
+	return parseDuKilobytes(string(output))
+}
+
+type quickLookCache struct {
+	Path  string
+	Bytes int64
+}
+
+// quickLookCachePaths returns the thumbnail cache locations. Current macOS
+// keeps it under DARWIN_USER_CACHE_DIR; older releases used ~/Library/Caches.
+func quickLookCachePaths(ctx context.Context, home string) []string {
+	paths := []string{filepath.Join(home, "Library", "Caches", quickLookThumbnailCacheName)}
+	if output, err := exec.CommandContext(ctx, "getconf", "DARWIN_USER_CACHE_DIR").Output(); err == nil {
+		if dir := strings.TrimSpace(string(output)); dir != "" {
+			paths = append(paths, filepath.Join(dir, quickLookThumbnailCacheName))
+		}
+	}
+	return paths
+}
+
+func quickLookCacheSizes(paths []string) []quickLookCache {
+	var caches []quickLookCache
+	for _, path := range paths {
+		if !pathExistsAndIsDir(path) {
+			continue
+		}
+		caches = append(caches, quickLookCache{Path: path, Bytes: getDirSize(path)})
+	}
+	return caches
+}
+
+func systemCachesPlanSteps(level CleanupLevel) []string {
+	switch level {
+	case LevelWarning:
+		return []string{"Report Quick Look thumbnail and icon services cache sizes"}
+	case LevelModerate, LevelAggressive:
+		return []string{"Reset Quick Look thumbnails with qlmanage -r cache"}
+	case LevelCritical:
+		return []string{
+			"Reset Quick Look thumbnails with qlmanage -r cache",
+			fmt.Sprintf("Remove %s with sudo and restart the Dock when system_caches.reset_icon_services is true", iconServicesStorePath),
+		}
+	default:
+		return []string{"Report macOS system cache state"}
+	}
+}
+
+func systemCachesPlanTargets(level CleanupLevel, quickLook []quickLookCache, qlmanageAvailable bool, iconBytes int64, resetIconServices bool, sudoPasswordless bool) []CleanupTarget {
+	var targets []CleanupTarget
+	for _, cache := range quickLook {
+		target := CleanupTarget{
+			Type:      "quicklook-thumbnails",
+			Tier:      CleanupTierSafe,
+			Name:      "Quick Look thumbnails",
+			Path:      cache.Path,
+			Bytes:     cache.Bytes,
+			Protected: level < LevelModerate || !qlmanageAvailable || cache.Bytes == 0,
+			Action:    "delete_quicklook_thumbnails",
+			Reason:    "qlmanage -r cache clears thumbnails; Quick Look regenerates them on demand",
+		}
+		if target.Protected {
+			target.Action = "protect"
+			target.Reason = "Quick Look thumbnails are reset at moderate level and above"
+			if !qlmanageAvailable {
+				target.Reason = "qlmanage is not available"
+			}
+		}
+		annotateCleanupTargetPolicy(&target, target.Tier, hostReclaimForAction(target.Action))
+		targets = append(targets, target)
+	}
+
+	iconTarget := CleanupTarget{
+		Type:      "icon-services-store",
+		Tier:      CleanupTierPrivileged,
+		Name:      "icon services store",
+		Path:      iconServicesStorePath,
+		Bytes:     iconBytes,
+		Protected: level < LevelCritical || !resetIconServices || !sudoPasswordless || iconBytes == 0,
+		Action:    "delete_icon_services_store",
+		Reason:    "critical level removes the icon services store and restarts the Dock to rebuild it",
+	}
+	if iconTarget.Protected {
+		iconTarget.Action = "protect"
+		switch {
+		case !resetIconServices:
+			iconTarget.Reason = "icon services reset requires system_caches.reset_icon_services"
+		case !sudoPasswordless:
+			iconTarget.Reason = "icon services reset requires passwordless sudo"
+		default:
+			iconTarget.Reason = "icon services store is reset only at critical level"
+		}
+	}
+	annotateCleanupTargetPolicy(&iconTarget, iconTarget.Tier, hostReclaimForAction(iconTarget.Action))
+	return append(targets, iconTarget)
+}
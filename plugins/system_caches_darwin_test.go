@@ -0,0 +1,93 @@
+//go:build darwin
+
+package plugins
+
+import (
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestSystemCachesPluginInterface(t *testing.T) {
+	p := NewSystemCachesPlugin()
+
+	if p.Name() != "system-caches" {
+		t.Errorf("expected name 'system-caches', got %q", p.Name())
+	}
+	platforms := p.SupportedPlatforms()
+	if len(platforms) != 1 || platforms[0] != PlatformDarwin {
+		t.Errorf("expected [darwin], got %v", platforms)
+	}
+
+	cfg := config.DefaultConfig()
+	if !p.Enabled(cfg) {
+		t.Error("expected SystemCaches to be enabled by default on Darwin")
+	}
+	if cfg.SystemCaches.ResetIconServices {
+		t.Error("expected icon services reset to be opt-in")
+	}
+}
+
+func TestSystemCachesPlanTargets(t *testing.T) {
+	quickLook := []quickLookCache{{Path: "/cache/com.apple.QuickLook.thumbnailcache", Bytes: 300}}
+
+	targets := systemCachesPlanTargets(LevelWarning, quickLook, true, 500, true, true)
+	if len(targets) != 2 {
+		t.Fatalf("expected Quick Look and icon services targets, got %d", len(targets))
+	}
+	for _, target := range targets {
+		if !target.Protected {
+			t.Errorf("warning level should be report-only, got %+v", target)
+		}
+	}
+
+	targets = systemCachesPlanTargets(LevelModerate, quickLook, true, 500, true, true)
+	if targets[0].Protected || targets[0].Action != "delete_quicklook_thumbnails" {
+		t.Errorf("moderate level should reset Quick Look, got %+v", targets[0])
+	}
+	if !targets[1].Protected {
+		t.Error("icon services should only be reset at critical level")
+	}
+	if got := cleanupTargetEstimatedBytes(targets); got != 300 {
+		t.Errorf("moderate estimate = %d, want 300", got)
+	}
+
+	targets = systemCachesPlanTargets(LevelCritical, quickLook, false, 500, true, true)
+	if !targets[0].Protected || targets[0].Reason != "qlmanage is not available" {
+		t.Errorf("Quick Look should be protected without qlmanage, got %+v", targets[0])
+	}
+
+	targets = systemCachesPlanTargets(LevelCritical, quickLook, true, 500, false, true)
+	if !targets[1].Protected || targets[1].Reason != "icon services reset requires system_caches.reset_icon_services" {
+		t.Errorf("icon services reset should require opt-in, got %+v", targets[1])
+	}
+
+	targets = systemCachesPlanTargets(LevelCritical, quickLook, true, 500, true, false)
+	if !targets[1].Protected || targets[1].Reason != "icon services reset requires passwordless sudo" {
+		t.Errorf("icon services reset should require passwordless sudo, got %+v", targets[1])
+	}
+
+	targets = systemCachesPlanTargets(LevelCritical, quickLook, true, 500, true, true)
+	if targets[1].Protected || targets[1].Tier != CleanupTierPrivileged || targets[1].Reclaim != CleanupReclaimHost {
+		t.Errorf("icon services should be a privileged host-reclaim target, got %+v", targets[1])
+	}
+	if got := cleanupTargetEstimatedBytes(targets); got != 800 {
+		t.Errorf("critical estimate = %d, want 800", got)
+	}
+}
+
+func TestParseDuKilobytes(t *testing.T) {
+	for _, tt := range []struct {
+		output string
+		want   int64
+	}{
+		{"2048\t/Library/Caches/com.apple.iconservices.store\n", 2048 * 1024},
+		{"0\t/tmp/empty\n", 0},
+		{"", 0},
+		{"du: cannot access\n", 0},
+	} {
+		if got := parseDuKilobytes(tt.output); got != tt.want {
+			t.Errorf("parseDuKilobytes(%q) = %d, want %d", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// A VM restarted after disk compaction is only healthy once its manager lists
+// it as running and it answers a trivial command. limactl start and podman
+// machine start can return success while the guest is still booting or stuck.
+var (
+	vmRestartTimeout      = 3 * time.Minute
+	vmRestartPollInterval = 5 * time.Second
+	vmReachTimeout        = 30 * time.Second
+)
+
+// vmReachMarker is echoed inside the VM to prove a command ran there.
+const vmReachMarker = "tinyland-cleanup-reachable"
+
+// vmRestartMaxRecoveries bounds how many later runs try to start a VM whose
+// compaction restart failed before leaving it to the operator.
+const vmRestartMaxRecoveries = 3
+
+// vmRestartMarkerFileName records VMs that did not come back after a
+// compaction restart. It lives beside the daemon state file.
+const vmRestartMarkerFileName = "vm-restarts.json"
+
+// vmRestartMu serializes read-modify-write cycles on the marker file.
+var vmRestartMu sync.Mutex
+
+// vmHealthProbe checks one VM after a restart.
+type vmHealthProbe struct {
+	// running reports whether the VM manager lists the VM as running.
+	running func(ctx context.Context) (bool, error)
+	// reach runs vmReachMarker's echo in the VM and returns its output.
+	reach func(ctx context.Context) (string, error)
+}
+
+// waitVMHealthy polls probe until the VM is running and reachable, or
+// returns the last failure once vmRestartTimeout has passed.
+func waitVMHealthy(ctx context.Context, probe vmHealthProbe) error {
+	deadline := time.Now().Add(vmRestartTimeout)
+	for {
+		err := checkVMHealth(ctx, probe)
+		if err == nil {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("not healthy within %s: %w", vmRestartTimeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last check: %v)", ctx.Err(), err)
+		case <-time.After(vmRestartPollInterval):
+		}
+	}
+}
+
+func checkVMHealth(ctx context.Context, probe vmHealthProbe) error {
+	running, err := probe.running(ctx)
+	if err != nil {
+		return fmt.Errorf("list status: %w", err)
+	}
+	if !running {
+		return errors.New("not listed as running")
+	}
+	reachCtx, cancel := context.WithTimeout(ctx, vmReachTimeout)
+	defer cancel()
+	output, err := probe.reach(reachCtx)
+	if err != nil {
+		return fmt.Errorf("not reachable: %w (output: %s)", err, strings.TrimSpace(output))
+	}
+	if !strings.Contains(output, vmReachMarker) {
+		return fmt.Errorf("not reachable: unexpected output %q", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// limaHealthProbe checks a Lima VM with limactl list and limactl shell.
+func limaHealthProbe(vmName string) vmHealthProbe {
+	return vmHealthProbe{
+		running: func(ctx context.Context) (bool, error) {
+			// The default table has carried NAME and STATUS in every release.
+			output, err := runner.Output(ctx, nil, "limactl", "list")
+			if err != nil {
+				return false, err
+			}
+			for _, vm := range parseLimaList(string(output)) {
+				if vm.Name == vmName {
+					return isLimaRunning(vm.Status), nil
+				}
+			}
+			return false, nil
+		},
+		reach: func(ctx context.Context) (string, error) {
+			output, err := runner.CombinedOutput(ctx, nil, "limactl", "shell", vmName, "--", "echo", vmReachMarker)
+			return string(output), err
+		},
+	}
+}
+
+// limaStart starts a stopped Lima VM.
+func limaStart(ctx context.Context, vmName string) ([]byte, error) {
+	return runner.CombinedOutput(ctx, nil, "limactl", "start", vmName)
+}
+
+// podmanHealthProbe checks a Podman machine with podman machine list and
+// podman machine ssh.
+func podmanHealthProbe(machine string) vmHealthProbe {
+	return vmHealthProbe{
+		running: func(ctx context.Context) (bool, error) {
+			output, err := runner.Output(ctx, nil, "podman", "machine", "list", "--format", "{{.Name}}\t{{.Running}}")
+			if err != nil {
+				return false, err
+			}
+			return slices.Contains(parseRunningMachines(string(output)), machine), nil
+		},
+		reach: func(ctx context.Context) (string, error) {
+			output, err := runner.CombinedOutput(ctx, nil, "podman", "machine", "ssh", machine, "echo", vmReachMarker)
+			return string(output), err
+		},
+	}
+}
+
+// podmanMachineStart starts a stopped Podman machine.
+func podmanMachineStart(ctx context.Context, machine string) ([]byte, error) {
+	return runner.CombinedOutput(ctx, nil, "podman", "machine", "start", machine)
+}
+
+// vmRestartFailure is a VM that did not come back after a compaction restart.
+type vmRestartFailure struct {
+	Plugin string `json:"plugin"`
+	VM     string `json:"vm"`
+	// FailedAt is when the restart or a later recovery last failed, in RFC 3339.
+	FailedAt string `json:"failed_at"`
+	Error    string `json:"error"`
+	// Recoveries counts the later runs that tried to start the VM.
+	Recoveries int `json:"recoveries"`
+}
+
+type vmRestartMarker struct {
+	Failures map[string]vmRestartFailure `json:"failures"`
+}
+
+// VMRestartMarkerPath returns the restart failure marker path, or "" when no
+// state file is configured.
+func VMRestartMarkerPath(cfg *config.Config) string {
+	if cfg.Policy.StateFile == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.Policy.StateFile), vmRestartMarkerFileName)
+}
+
+func vmRestartKey(plugin, vm string) string {
+	return plugin + "/" + vm
+}
+
+// pendingVMRestarts returns plugin's recorded restart failures by VM name.
+func pendingVMRestarts(cfg *config.Config, plugin string) ([]vmRestartFailure, error) {
+	vmRestartMu.Lock()
+	defer vmRestartMu.Unlock()
+	marker, err := loadVMRestartMarker(VMRestartMarkerPath(cfg))
+	if err != nil {
+		return nil, err
+	}
+	var pending []vmRestartFailure
+	for _, failure := range marker.Failures {
+		if failure.Plugin == plugin {
+			pending = append(pending, failure)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].VM < pending[j].VM })
+	return pending, nil
+}
+
+// recordVMRestartFailure marks vm as not recovered. A recovery attempt counts
+// toward vmRestartMaxRecoveries; the failure of the restart itself does not.
+func recordVMRestartFailure(cfg *config.Config, plugin, vm string, cause error, recovery bool, logger *slog.Logger) {
+	err := updateVMRestartMarker(cfg, func(marker *vmRestartMarker) {
+		key := vmRestartKey(plugin, vm)
+		failure := marker.Failures[key]
+		failure.Plugin, failure.VM = plugin, vm
+		failure.FailedAt = time.Now().UTC().Format(time.RFC3339)
+		failure.Error = cause.Error()
+		if recovery {
+			failure.Recoveries++
+		}
+		marker.Failures[key] = failure
+	})
+	if err != nil {
+		logger.Warn("failed to record VM restart failure", "plugin", plugin, "vm", vm, "error", err)
+	}
+}
+
+// clearVMRestartFailure removes vm's marker once it is healthy again.
+func clearVMRestartFailure(cfg *config.Config, plugin, vm string, logger *slog.Logger) {
+	err := updateVMRestartMarker(cfg, func(marker *vmRestartMarker) {
+		delete(marker.Failures, vmRestartKey(plugin, vm))
+	})
+	if err != nil {
+		logger.Warn("failed to clear VM restart failure", "plugin", plugin, "vm", vm, "error", err)
+	}
+}
+
+func updateVMRestartMarker(cfg *config.Config, update func(*vmRestartMarker)) error {
+	path := VMRestartMarkerPath(cfg)
+	if path == "" {
+		return nil
+	}
+	vmRestartMu.Lock()
+	defer vmRestartMu.Unlock()
+	marker, err := loadVMRestartMarker(path)
+	if err != nil {
+		return err
+	}
+	update(marker)
+	if len(marker.Failures) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func loadVMRestartMarker(path string) (*vmRestartMarker, error) {
+	marker := &vmRestartMarker{Failures: map[string]vmRestartFailure{}}
+	if path == "" {
+		return marker, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return marker, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, marker); err != nil {
+		return nil, err
+	}
+	if marker.Failures == nil {
+		marker.Failures = map[string]vmRestartFailure{}
+	}
+	return marker, nil
+}
+
+// vmStarter starts a stopped VM and returns the command output.
+type vmStarter func(ctx context.Context, vm string) ([]byte, error)
+
+// restartVMAfterCompaction runs start, then waits for probe. A VM that fails
+// either step is recorded for recovery on a later run and an error with
+// operation vm_restart or vm_health is returned; a healthy VM clears any
+// earlier record.
+func restartVMAfterCompaction(ctx context.Context, cfg *config.Config, plugin, vm string, start vmStarter, probe vmHealthProbe, logger *slog.Logger) error {
+	if output, err := start(ctx, vm); err != nil {
+		restartErr := newCommandError(plugin, "vm_restart", err, string(output)).withVM(vm)
+		logger.Error("VM did not restart after compaction; it will be retried next run", "plugin", plugin, "vm", vm, "error", err, "output", string(output))
+		recordVMRestartFailure(cfg, plugin, vm, restartErr, false, logger)
+		return restartErr
+	}
+	if err := waitVMHealthy(ctx, probe); err != nil {
+		healthErr := newPluginError(plugin, "vm_health", err).withVM(vm)
+		logger.Error("VM restarted after compaction but is not healthy; inspect it before use", "plugin", plugin, "vm", vm, "error", err)
+		recordVMRestartFailure(cfg, plugin, vm, healthErr, false, logger)
+		return healthErr
+	}
+	clearVMRestartFailure(cfg, plugin, vm, logger)
+	return nil
+}
+
+// recoverVMRestarts retries the VMs whose compaction restart failed on an
+// earlier run. A VM that is already healthy is cleared without being
+// started; others are started and checked, up to vmRestartMaxRecoveries
+// times. The first failure is returned.
+func recoverVMRestarts(ctx context.Context, cfg *config.Config, plugin string, start vmStarter, probe func(vm string) vmHealthProbe, logger *slog.Logger) error {
+	pending, err := pendingVMRestarts(cfg, plugin)
+	if err != nil {
+		logger.Warn("failed to read VM restart failures", "plugin", plugin, "path", VMRestartMarkerPath(cfg), "error", err)
+		return nil
+	}
+	var firstErr error
+	for _, failure := range pending {
+		vm := failure.VM
+		if checkVMHealth(ctx, probe(vm)) == nil {
+			logger.Info("VM recovered since its failed compaction restart", "plugin", plugin, "vm", vm)
+			clearVMRestartFailure(cfg, plugin, vm, logger)
+			continue
+		}
+		if failure.Recoveries >= vmRestartMaxRecoveries {
+			logger.Error("VM still not healthy after compaction restart; manual intervention needed",
+				"plugin", plugin, "vm", vm, "failed_at", failure.FailedAt, "error", failure.Error)
+			if firstErr == nil {
+				firstErr = newPluginError(plugin, "vm_health", errors.New(failure.Error)).withVM(vm)
+			}
+			continue
+		}
+		logger.Warn("retrying start of VM whose compaction restart failed", "plugin", plugin, "vm", vm, "attempt", failure.Recoveries+1)
+		var recoverErr error
+		if output, err := start(ctx, vm); err != nil {
+			recoverErr = newCommandError(plugin, "vm_restart", err, string(output)).withVM(vm)
+		} else if err := waitVMHealthy(ctx, probe(vm)); err != nil {
+			recoverErr = newPluginError(plugin, "vm_health", err).withVM(vm)
+		}
+		if recoverErr != nil {
+			logger.Error("VM recovery failed", "plugin", plugin, "vm", vm, "error", recoverErr)
+			recordVMRestartFailure(cfg, plugin, vm, recoverErr, true, logger)
+			if firstErr == nil {
+				firstErr = recoverErr
+			}
+			continue
+		}
+		logger.Info("VM recovered after failed compaction restart", "plugin", plugin, "vm", vm)
+		clearVMRestartFailure(cfg, plugin, vm, logger)
+	}
+	return firstErr
+}
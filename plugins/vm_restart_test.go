@@ -0,0 +1,123 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func useQuickVMRestartChecks(t *testing.T) *config.Config {
+	t.Helper()
+	timeout, interval := vmRestartTimeout, vmRestartPollInterval
+	vmRestartTimeout, vmRestartPollInterval = 0, time.Millisecond
+	t.Cleanup(func() { vmRestartTimeout, vmRestartPollInterval = timeout, interval })
+	cfg := config.DefaultConfig()
+	cfg.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	return cfg
+}
+
+const (
+	podmanListCommand = "podman machine list --format {{.Name}}\t{{.Running}}"
+	podmanSSHCommand  = "podman machine ssh podman-machine-default echo " + vmReachMarker
+)
+
+func TestRestartVMAfterCompactionClearsMarkerWhenHealthy(t *testing.T) {
+	cfg := useQuickVMRestartChecks(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recordVMRestartFailure(cfg, "podman", "podman-machine-default", errors.New("earlier failure"), false, logger)
+	useFakeRunner(t, map[string]fakeResponse{
+		podmanListCommand: {Output: "podman-machine-default*\ttrue\n"},
+		podmanSSHCommand:  {Output: vmReachMarker + "\n"},
+	})
+
+	err := restartVMAfterCompaction(context.Background(), cfg, "podman", "podman-machine-default", podmanMachineStart, podmanHealthProbe("podman-machine-default"), logger)
+	if err != nil {
+		t.Fatalf("healthy restart failed: %v", err)
+	}
+	if _, err := os.Stat(VMRestartMarkerPath(cfg)); !os.IsNotExist(err) {
+		t.Fatalf("expected marker removed, stat err = %v", err)
+	}
+}
+
+func TestRestartVMAfterCompactionRecordsUnhealthyVM(t *testing.T) {
+	cfg := useQuickVMRestartChecks(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	useFakeRunner(t, map[string]fakeResponse{
+		podmanListCommand: {Output: "podman-machine-default\tfalse\n"},
+	})
+
+	err := restartVMAfterCompaction(context.Background(), cfg, "podman", "podman-machine-default", podmanMachineStart, podmanHealthProbe("podman-machine-default"), logger)
+	pluginErr, ok := AsPluginError(err)
+	if !ok || pluginErr.Operation != "vm_health" || pluginErr.VM != "podman-machine-default" {
+		t.Fatalf("expected vm_health error, got %v", err)
+	}
+	pending, err := pendingVMRestarts(cfg, "podman")
+	if err != nil || len(pending) != 1 || pending[0].VM != "podman-machine-default" || pending[0].Recoveries != 0 {
+		t.Fatalf("expected one pending restart, got %+v (err %v)", pending, err)
+	}
+}
+
+func TestRestartVMAfterCompactionRecordsFailedStart(t *testing.T) {
+	cfg := useQuickVMRestartChecks(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	useFakeRunner(t, map[string]fakeResponse{
+		"limactl start default": {Output: "FATA[0600] did not receive an event", Err: errors.New("exit status 1")},
+	})
+
+	err := restartVMAfterCompaction(context.Background(), cfg, "lima", "default", limaStart, limaHealthProbe("default"), logger)
+	if pluginErr, ok := AsPluginError(err); !ok || pluginErr.Operation != "vm_restart" {
+		t.Fatalf("expected vm_restart error, got %v", err)
+	}
+	if pending, _ := pendingVMRestarts(cfg, "lima"); len(pending) != 1 {
+		t.Fatalf("expected failed start recorded, got %+v", pending)
+	}
+}
+
+func TestRecoverVMRestartsStopsAfterMaxRecoveries(t *testing.T) {
+	cfg := useQuickVMRestartChecks(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recordVMRestartFailure(cfg, "podman", "podman-machine-default", errors.New("not listed as running"), false, logger)
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		podmanListCommand: {Output: "podman-machine-default\tfalse\n"},
+	})
+
+	for run := 1; run <= vmRestartMaxRecoveries+1; run++ {
+		if err := recoverVMRestarts(context.Background(), cfg, "podman", podmanMachineStart, podmanHealthProbe, logger); err == nil {
+			t.Fatalf("run %d: expected recovery failure", run)
+		}
+	}
+	if starts := fake.commandLines("podman machine start"); len(starts) != vmRestartMaxRecoveries {
+		t.Fatalf("expected %d start attempts, got %q", vmRestartMaxRecoveries, starts)
+	}
+	pending, _ := pendingVMRestarts(cfg, "podman")
+	if len(pending) != 1 || pending[0].Recoveries != vmRestartMaxRecoveries {
+		t.Fatalf("expected marker kept with %d recoveries, got %+v", vmRestartMaxRecoveries, pending)
+	}
+}
+
+func TestRecoverVMRestartsClearsHealthyVMWithoutStarting(t *testing.T) {
+	cfg := useQuickVMRestartChecks(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recordVMRestartFailure(cfg, "lima", "default", errors.New("not reachable"), false, logger)
+	fake := useFakeRunner(t, map[string]fakeResponse{
+		"limactl list": {Output: "NAME       STATUS     SSH                VMTYPE    ARCH       CPUS    MEMORY    DISK      DIR\ndefault    Running    127.0.0.1:60022    vz        aarch64    4       4GiB      100GiB    ~/.lima/default\n"},
+		"limactl shell default -- echo " + vmReachMarker: {Output: vmReachMarker + "\n"},
+	})
+
+	if err := recoverVMRestarts(context.Background(), cfg, "lima", limaStart, limaHealthProbe, logger); err != nil {
+		t.Fatalf("recovery of healthy VM failed: %v", err)
+	}
+	if starts := fake.commandLines("limactl start"); len(starts) != 0 {
+		t.Fatalf("healthy VM should not be started, got %q", starts)
+	}
+	if pending, _ := pendingVMRestarts(cfg, "lima"); len(pending) != 0 {
+		t.Fatalf("expected marker cleared, got %+v", pending)
+	}
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// guestRootDFArgs reports the guest root filesystem's size and usage in 1K
+// blocks.
+var guestRootDFArgs = []string{"df", "--output=size,used", "/"}
+
+// parseGuestRootDF parses guestRootDFArgs output into total and used bytes.
+func parseGuestRootDF(output string) (int64, int64, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, 0, fmt.Errorf("unexpected df output %q", strings.TrimSpace(output))
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("unexpected df row %q", lines[len(lines)-1])
+	}
+	totalKB, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse df size: %w", err)
+	}
+	usedKB, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse df used: %w", err)
+	}
+	return totalKB * 1024, usedKB * 1024, nil
+}
+
+// VMDisks reports the running Podman machines selected by
+// podman.machine_names. Stopped machines are not listed.
+func (p *PodmanPlugin) VMDisks(ctx context.Context, cfg *config.Config) []VMDiskStatus {
+	if _, err := runner.LookPath("podman"); err != nil {
+		return nil
+	}
+	var disks []VMDiskStatus
+	for _, machine := range selectPodmanMachines(detectRunningMachines(), cfg.Podman.MachineNames) {
+		disk := VMDiskStatus{Name: machine, Status: "running"}
+		dfCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		output, err := runner.Output(dfCtx, nil, "podman", append([]string{"machine", "ssh", machine, "--"}, guestRootDFArgs...)...)
+		cancel()
+		if err == nil {
+			disk.GuestTotalBytes, disk.GuestUsedBytes, err = parseGuestRootDF(string(output))
+		}
+		if err != nil {
+			disk.Error = "guest df: " + err.Error()
+		}
+		if path, err := p.getMachineDiskPath(ctx, machine); err == nil && path != "" {
+			disk.DiskPath = path
+			disk.HostAllocatedBytes, _ = getFileAllocatedBytes(path)
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestParseGuestRootDF(t *testing.T) {
+	total, used, err := parseGuestRootDF(" 1K-blocks     Used\n 104857600 20971520\n")
+	if err != nil {
+		t.Fatalf("parseGuestRootDF: %v", err)
+	}
+	if total != 100<<30 || used != 20<<30 {
+		t.Fatalf("got total %d used %d, want 100 GiB and 20 GiB", total, used)
+	}
+	if _, _, err := parseGuestRootDF("df: /: No such file or directory"); err == nil {
+		t.Fatal("expected an error without a data row")
+	}
+}
+
+func TestPodmanVMDisksReportsGuestAndHostUsage(t *testing.T) {
+	diskPath := filepath.Join(t.TempDir(), "podman-machine-default.raw")
+	if err := os.WriteFile(diskPath, make([]byte, 8192), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useFakeRunner(t, map[string]fakeResponse{
+		"podman machine list --format {{.Name}}\t{{.Running}}":                 {Output: "podman-machine-default*\ttrue\nidle\tfalse\n"},
+		"podman machine ssh podman-machine-default -- df --output=size,used /": {Output: "1K-blocks Used\n104857600 20971520\n"},
+		"podman machine inspect podman-machine-default":                        {Output: `[{"ImagePath": "` + diskPath + `"}]`},
+	})
+
+	disks := NewPodmanPlugin().VMDisks(context.Background(), config.DefaultConfig())
+	if len(disks) != 1 {
+		t.Fatalf("expected only the running machine, got %+v", disks)
+	}
+	disk := disks[0]
+	if disk.Name != "podman-machine-default" || disk.GuestUsedBytes != 20<<30 || disk.GuestTotalBytes != 100<<30 {
+		t.Fatalf("unexpected guest usage %+v", disk)
+	}
+	if disk.DiskPath != diskPath || disk.HostAllocatedBytes <= 0 || disk.Error != "" {
+		t.Fatalf("unexpected host disk %+v", disk)
+	}
+}
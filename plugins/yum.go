@@ -28,6 +28,11 @@ func (p *YumPlugin) Description() string {
 	return "Cleans YUM/DNF package manager caches"
 }
 
+// Priority runs package cache cleanup with the other cheap cache clears.
+func (p *YumPlugin) Priority() int {
+	return 10
+}
+
 // SupportedPlatforms returns supported platforms (Linux only).
 func (p *YumPlugin) SupportedPlatforms() []string {
 	return []string{"linux"}
@@ -38,6 +43,18 @@ func (p *YumPlugin) Enabled(cfg *config.Config) bool {
 	return cfg.Enable.Yum
 }
 
+// LevelDescription summarizes YUM/DNF cleanup at each level.
+func (p *YumPlugin) LevelDescription(level CleanupLevel) string {
+	switch level {
+	case LevelWarning:
+		return "no cleanup"
+	case LevelModerate, LevelAggressive, LevelCritical:
+		return "runs dnf or yum clean all with passwordless sudo"
+	default:
+		return "no cleanup"
+	}
+}
+
 // Cleanup performs YUM cache cleanup at the specified level.
 func (p *YumPlugin) Cleanup(ctx context.Context, level CleanupLevel, cfg *config.Config, logger *slog.Logger) CleanupResult {
 	result := CleanupResult{
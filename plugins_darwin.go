@@ -17,4 +17,7 @@ func registerDarwinPlugins(registry *plugins.Registry) {
 	registry.Register(plugins.NewPhotosPlugin())
 	registry.Register(plugins.NewLimaPlugin())
 	registry.Register(plugins.NewAPFSPlugin())
+	registry.Register(plugins.NewSystemCachesPlugin())
+	registry.Register(plugins.NewAppLogsPlugin())
+	registry.Register(plugins.NewExternalTrashPlugin())
 }
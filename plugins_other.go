@@ -12,4 +12,6 @@ func registerDarwinPlugins(registry *plugins.Registry) {
 func registerLinuxPlugins(registry *plugins.Registry) {
 	registry.Register(plugins.NewGitHubRunnerPlugin())
 	registry.Register(plugins.NewYumPlugin())
+	registry.Register(plugins.NewZFSSnapshotsPlugin())
+	registry.Register(plugins.NewBtrfsSnapshotsPlugin())
 }
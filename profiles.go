@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+// profileListEntry is one -list-profiles entry.
+type profileListEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Settings    string `json:"settings"`
+}
+
+// writeProfileList prints each built-in profile with the settings it applies
+// under the user's config.
+func writeProfileList(w io.Writer, output string, profiles []config.Profile) error {
+	entries := make([]profileListEntry, 0, len(profiles))
+	for _, profile := range profiles {
+		entries = append(entries, profileListEntry{Name: profile.Name, Description: profile.Description, Settings: profile.Settings})
+	}
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Profiles []profileListEntry `json:"profiles"`
+		}{entries})
+	}
+
+	if _, err := fmt.Fprintln(w, "tinyland-cleanup profiles (select with -profile or profile: in config; config keys override)"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "\n%s - %s\n", entry.Name, entry.Description); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimRight(entry.Settings, "\n"), "\n") {
+			if _, err := fmt.Fprintf(w, "  %s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+)
+
+func TestWriteProfileList(t *testing.T) {
+	var text bytes.Buffer
+	if err := writeProfileList(&text, "text", config.Profiles()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"laptop - ", "ci-runner - ", "workstation - ", "  safety:", "    max_level: aggressive"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeProfileList(&out, "json", config.Profiles()); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Profiles []profileListEntry `json:"profiles"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(report.Profiles) != 3 || report.Profiles[0].Name != "ci-runner" || report.Profiles[0].Settings == "" {
+		t.Fatalf("unexpected profiles: %+v", report.Profiles)
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// uncoveredScanDepth is how many directory levels below the monitored path
+// the recommendations scan descends to find large directories no plugin
+// looks at. Three levels reach a project directory in a home, such as
+// /Users/me/big-dataset.
+const uncoveredScanDepth = 3
+
+// recommendation explains one reason a cycle did not free more space and,
+// when there is one, the config change that would let it.
+type recommendation struct {
+	// Kind is protected, skipped, failed, disabled, uncovered, or config.
+	Kind       string `json:"kind"`
+	Plugin     string `json:"plugin,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Bytes      int64  `json:"bytes,omitempty"`
+	Finding    string `json:"finding"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// recommend runs the second pass after a real cycle that left the monitored
+// disk over the warning threshold: it looks at what the first pass kept,
+// skipped, or never covered and records recommendations in report. Repeats
+// within policy.cooldown of the last analysis are skipped, since the answer
+// will not have changed.
+func (d *daemon) recommend(ctx context.Context, report *cycleReport) {
+	cfg := d.currentConfig().Recommendations
+	level := parseLevel(report.Level)
+	if !cfg.Enabled || report.DryRun || level == monitor.LevelNone || ctx.Err() != nil {
+		return
+	}
+	now := d.currentTime()
+	if !d.lastRecommendations.IsZero() && now.Sub(d.lastRecommendations) < d.cleanupCooldown() {
+		return
+	}
+	if after := d.assessMounts(); after.Level == monitor.LevelNone {
+		return
+	}
+	d.lastRecommendations = now
+
+	protection := d.planProtection(ctx, level)
+	report.Recommendations = append(report.Recommendations, protectedRecommendations(protection, cfg.MaxItems)...)
+	report.Recommendations = append(report.Recommendations, skippedRecommendations(*report)...)
+	report.Recommendations = append(report.Recommendations, d.disabledRecommendations(ctx, level)...)
+	if cfg.MaxItems > 0 && report.MonitorPath != "" {
+		scanCtx, cancel := context.WithTimeout(ctx, diskHogScanTimeout)
+		hogs := uncoveredDiskHogs(scanCtx, report.MonitorPath, d.pluginScopes(protection), cfg.MaxItems, uncoveredScanDepth)
+		cancel()
+		report.Recommendations = append(report.Recommendations, uncoveredRecommendations(hogs)...)
+	}
+	report.Recommendations = append(report.Recommendations, configRecommendations(d.currentConfig(), *report)...)
+	if len(report.Recommendations) == 0 {
+		return
+	}
+
+	d.logger.Warn("cleanup left the disk over threshold; see recommendations",
+		"path", report.MonitorPath,
+		"level", report.Level,
+		"recommendations", len(report.Recommendations),
+	)
+	if !cfg.Notify || level < notifyMinLevel(d.currentConfig().Notify) {
+		return
+	}
+	message := recommendationsMessage(*report)
+	if d.redactor != nil {
+		message = d.redactor.String(message)
+	}
+	if err := d.sendNotification(ctx, message); err != nil {
+		d.logger.Warn("failed to send recommendations", "error", err)
+	}
+}
+
+// protectedRecommendations lists the largest candidates the plan kept.
+func protectedRecommendations(protection protectionReport, limit int) []recommendation {
+	var kept []recommendation
+	for _, plugin := range protection.Plugins {
+		for _, candidate := range plugin.Candidates {
+			if candidate.Decision != "keep" || candidate.Bytes <= 0 {
+				continue
+			}
+			finding := "kept"
+			if candidate.Reason != "" {
+				finding += ": " + candidate.Reason
+			}
+			kept = append(kept, recommendation{
+				Kind:       "protected",
+				Plugin:     plugin.Name,
+				Path:       candidate.Path,
+				Bytes:      candidate.Bytes,
+				Finding:    finding,
+				Suggestion: "review it by hand, or run -explain-protection -plugins " + plugin.Name + " to see every rule that kept it",
+			})
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Bytes > kept[j].Bytes
+	})
+	if limit > 0 && len(kept) > limit {
+		kept = kept[:limit]
+	}
+	return kept
+}
+
+// skippedRecommendations covers plugins the cycle held back or that failed.
+func skippedRecommendations(report cycleReport) []recommendation {
+	var recommendations []recommendation
+	for _, plugin := range report.Plugins {
+		if plugin.Error != "" {
+			recommendations = append(recommendations, recommendation{
+				Kind:       "failed",
+				Plugin:     plugin.Name,
+				Finding:    "failed: " + plugin.Error,
+				Suggestion: "fix the cause in the plugin's log; it is retried next cycle",
+			})
+			continue
+		}
+		var suggestion string
+		switch plugin.SkipReason {
+		case "cooldown":
+			remaining := time.Duration(plugin.CooldownRemainingSeconds) * time.Second
+			suggestion = fmt.Sprintf("it runs again in %s; shorten policy.cooldown to run it sooner", remaining)
+		case "min_interval":
+			suggestion = "lower -watch-min-interval"
+		case "level_plugins":
+			suggestion = fmt.Sprintf("add %s to level_plugins.%s", plugin.Name, plugin.Level)
+		case "plugin_levels":
+			suggestion = fmt.Sprintf("raise or remove plugin_levels.%s", plugin.Name)
+		case "display_asleep":
+			suggestion = "set safety.skip_when_display_asleep to false, or wake the display"
+		case "free_now_heavy":
+			suggestion = "run a regular cycle, which includes heavy plugins"
+		default:
+			continue
+		}
+		recommendations = append(recommendations, recommendation{
+			Kind:       "skipped",
+			Plugin:     plugin.Name,
+			Finding:    "skipped: " + plugin.SkipReason,
+			Suggestion: suggestion,
+		})
+	}
+	return recommendations
+}
+
+// disabledRecommendations plans each disabled plugin supported on this
+// platform and suggests enabling the ones that would free space.
+func (d *daemon) disabledRecommendations(ctx context.Context, level monitor.CleanupLevel) []recommendation {
+	cfg := d.currentConfig()
+	var recommendations []recommendation
+	for _, p := range d.registry.GetAll() {
+		if ctx.Err() != nil {
+			break
+		}
+		if p.Enabled(cfg) || !pluginSupportedOnCurrentPlatform(p.SupportedPlatforms()) {
+			continue
+		}
+		planner, ok := p.(plugins.Planner)
+		if !ok {
+			continue
+		}
+		plan := planner.PlanCleanup(ctx, plugins.CleanupLevel(level), cfg, d.logger)
+		bytes := plan.EstimatedBytesFreed
+		if bytes <= 0 {
+			for _, target := range plan.Targets {
+				if !target.Protected {
+					bytes += target.Bytes
+				}
+			}
+		}
+		key := enableKey(p, cfg)
+		if bytes <= 0 || key == "" {
+			continue
+		}
+		recommendations = append(recommendations, recommendation{
+			Kind:       "disabled",
+			Plugin:     p.Name(),
+			Bytes:      bytes,
+			Finding:    "disabled, but would free space at " + level.String(),
+			Suggestion: "set " + key + " to true",
+		})
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Bytes > recommendations[j].Bytes
+	})
+	return recommendations
+}
+
+// enableKey returns the enable flag that turns p on, found by setting each
+// flag in turn on a copy of cfg, or "" when no single flag does.
+func enableKey(p plugins.Plugin, cfg *config.Config) string {
+	probe := *cfg
+	flags := reflect.ValueOf(&probe.Enable).Elem()
+	for i := 0; i < flags.NumField(); i++ {
+		field := flags.Field(i)
+		if field.Kind() != reflect.Bool || field.Bool() {
+			continue
+		}
+		field.SetBool(true)
+		enabled := p.Enabled(&probe)
+		field.SetBool(false)
+		if enabled {
+			return "enable." + strings.Split(flags.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		}
+	}
+	return ""
+}
+
+// pluginScopes returns the paths enabled plugins look at: every candidate
+// the plan found, mount-scoped data paths, and configured scan roots.
+func (d *daemon) pluginScopes(protection protectionReport) []string {
+	cfg := d.currentConfig()
+	var scopes []string
+	for _, plugin := range protection.Plugins {
+		for _, candidate := range plugin.Candidates {
+			scopes = append(scopes, candidate.Path)
+		}
+	}
+	enabled := d.registry.GetEnabled(cfg)
+	for _, p := range enabled {
+		if scoped, ok := p.(plugins.MountScoped); ok {
+			scopes = append(scopes, scoped.DataPaths(cfg)...)
+		}
+	}
+	if len(filterEnabledPlugins(enabled, []string{"dev-artifacts"})) > 0 {
+		for _, path := range cfg.DevArtifacts.ScanPaths {
+			scopes = append(scopes, expandPathHome(path))
+		}
+	}
+	if len(filterEnabledPlugins(enabled, []string{"scratch"})) > 0 {
+		for _, dir := range cfg.ScratchDirs {
+			scopes = append(scopes, expandPathHome(dir.Path))
+		}
+	}
+	return scopes
+}
+
+// uncoveredDiskHogs returns the largest directories under root that no
+// scope path lies in or under. A large directory holding a scope path, such
+// as a home, is searched again one level down, up to depth levels.
+func uncoveredDiskHogs(ctx context.Context, root string, scopes []string, limit, depth int) []diskHog {
+	var uncovered []diskHog
+	for _, hog := range topDiskHogs(ctx, root, limit) {
+		if ctx.Err() != nil {
+			break
+		}
+		switch {
+		case pathWithinAny(hog.Path, scopes):
+		case scopeWithin(hog.Path, scopes):
+			if depth > 1 {
+				uncovered = append(uncovered, uncoveredDiskHogs(ctx, hog.Path, scopes, limit, depth-1)...)
+			}
+		default:
+			uncovered = append(uncovered, hog)
+		}
+	}
+	sort.SliceStable(uncovered, func(i, j int) bool {
+		return uncovered[i].Bytes > uncovered[j].Bytes
+	})
+	if len(uncovered) > limit {
+		uncovered = uncovered[:limit]
+	}
+	return uncovered
+}
+
+// pathWithinAny reports whether path is one of scopes or lies under one.
+func pathWithinAny(path string, scopes []string) bool {
+	for _, scope := range scopes {
+		if scope != "" && pathWithin(path, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeWithin reports whether some scope lies under dir.
+func scopeWithin(dir string, scopes []string) bool {
+	for _, scope := range scopes {
+		if scope != "" && pathWithin(scope, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func uncoveredRecommendations(hogs []diskHog) []recommendation {
+	recommendations := make([]recommendation, 0, len(hogs))
+	for _, hog := range hogs {
+		recommendations = append(recommendations, recommendation{
+			Kind:       "uncovered",
+			Path:       hog.Path,
+			Bytes:      hog.Bytes,
+			Finding:    "not covered by any enabled plugin",
+			Suggestion: fmt.Sprintf("add %s to dev_artifacts.scan_paths if it holds build output, or to scratch_dirs if its files are disposable", hog.Path),
+		})
+	}
+	return recommendations
+}
+
+// configRecommendations suggests settings that hold back plugins which ran.
+func configRecommendations(cfg *config.Config, report cycleReport) []recommendation {
+	var recommendations []recommendation
+	if report.LevelClampedFrom != "" {
+		recommendations = append(recommendations, recommendation{
+			Kind:       "config",
+			Finding:    fmt.Sprintf("safety.max_level held the cycle at %s instead of %s", report.Level, report.LevelClampedFrom),
+			Suggestion: "raise safety.max_level to " + report.LevelClampedFrom,
+		})
+	}
+	ran := map[string]bool{}
+	for _, plugin := range report.Plugins {
+		if plugin.SkipReason == "" && plugin.Error == "" {
+			ran[plugin.Name] = true
+		}
+	}
+	for _, engine := range []struct {
+		name      string
+		age       string
+		overrides map[string]string
+	}{
+		{"docker", cfg.Docker.PruneImagesAge, cfg.Docker.PruneAges},
+		{"podman", cfg.Podman.PruneImagesAge, cfg.Podman.PruneAges},
+	} {
+		if !ran[engine.name] || engine.overrides[report.Level] != "" {
+			continue
+		}
+		if age, err := time.ParseDuration(engine.age); err != nil || age < 24*time.Hour {
+			continue
+		}
+		recommendations = append(recommendations, recommendation{
+			Kind:       "config",
+			Plugin:     engine.name,
+			Finding:    fmt.Sprintf("%s only prunes images older than %s", engine.name, engine.age),
+			Suggestion: fmt.Sprintf("reduce %s.prune_images_age, or set %s.prune_ages.%s to a shorter age", engine.name, engine.name, report.Level),
+		})
+	}
+	if ran["lima"] && !cfg.Lima.CompactOffline {
+		recommendations = append(recommendations, recommendation{
+			Kind:       "config",
+			Plugin:     "lima",
+			Finding:    "Lima VM disks are only trimmed while running",
+			Suggestion: "enable lima.compact_offline to compact stopped VM disks at critical",
+		})
+	}
+	if ran["podman"] && !cfg.Podman.CompactDiskOffline {
+		recommendations = append(recommendations, recommendation{
+			Kind:       "config",
+			Plugin:     "podman",
+			Finding:    "the Podman machine disk is not compacted",
+			Suggestion: "enable podman.compact_disk_offline to compact it at critical",
+		})
+	}
+	return recommendations
+}
+
+func recommendationsMessage(report cycleReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cleanup left %s at %s after freeing %s.", report.MonitorPath, report.Level, formatByteCount(report.TotalBytesFreed))
+	if report.HostFreeAfterBytes > 0 {
+		fmt.Fprintf(&b, " %s free.", formatByteCount(int64(report.HostFreeAfterBytes)))
+	}
+	b.WriteString("\nRecommendations:")
+	for _, rec := range report.Recommendations {
+		fmt.Fprintf(&b, "\n- %s", recommendationLine(rec))
+	}
+	return b.String()
+}
+
+// recommendationLine is one recommendation as a single line of text.
+func recommendationLine(rec recommendation) string {
+	var subject []string
+	if rec.Plugin != "" {
+		subject = append(subject, rec.Plugin)
+	}
+	if rec.Path != "" {
+		subject = append(subject, rec.Path)
+	}
+	if rec.Bytes > 0 {
+		subject = append(subject, "("+formatByteCount(rec.Bytes)+")")
+	}
+	line := "[" + rec.Kind + "] "
+	if len(subject) > 0 {
+		line += strings.Join(subject, " ") + ": "
+	}
+	line += rec.Finding
+	if rec.Suggestion != "" {
+		line += "; " + rec.Suggestion
+	}
+	return line
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// plannedPlugin is a reportingPlugin with a fixed plan, enabled by enabled or
+// by enable.junk_files.
+type plannedPlugin struct {
+	reportingPlugin
+	enabled bool
+	plan    plugins.CleanupPlan
+}
+
+func (p *plannedPlugin) Enabled(cfg *config.Config) bool {
+	return p.enabled || cfg.Enable.JunkFiles
+}
+
+func (p *plannedPlugin) PlanCleanup(context.Context, plugins.CleanupLevel, *config.Config, *slog.Logger) plugins.CleanupPlan {
+	return p.plan
+}
+
+func TestRunOnceRecommendsWhenStillOverThreshold(t *testing.T) {
+	root := t.TempDir()
+	writeSizedFile(t, filepath.Join(root, "dataset", "blob"), 4096)
+	writeSizedFile(t, filepath.Join(root, "scratch", "recent"), 2048)
+
+	scratch := &plannedPlugin{
+		reportingPlugin: reportingPlugin{name: "scratch"},
+		enabled:         true,
+		plan: plugins.CleanupPlan{WouldRun: true, Targets: []plugins.CleanupTarget{
+			{Path: filepath.Join(root, "scratch", "recent"), Bytes: 2048, Protected: true, Reason: "modified within max_age_days"},
+		}},
+	}
+	junk := &plannedPlugin{
+		reportingPlugin: reportingPlugin{name: "junk-files"},
+		plan:            plugins.CleanupPlan{WouldRun: true, EstimatedBytesFreed: 1024},
+	}
+	held := &reportingPlugin{name: "held"}
+
+	var output bytes.Buffer
+	daemon := newTestDaemonWithPlugins(t, &output, scratch, junk, held)
+	daemon.config.MonitoredMounts = []config.MountConfig{{Path: root, Label: "data"}}
+	daemon.config.LevelPlugins = map[string][]string{"critical": {"scratch"}}
+	daemon.config.Notify.AlertOnIneffectiveCritical = false
+	daemon.config.Recommendations.Enabled = true
+	daemon.config.Recommendations.Notify = true
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 40, 96))
+	var messages []string
+	daemon.notify = func(_ context.Context, message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	kinds := map[string]recommendation{}
+	for _, rec := range report.Recommendations {
+		kinds[rec.Kind] = rec
+	}
+	if rec := kinds["protected"]; rec.Plugin != "scratch" || rec.Bytes != 2048 || !strings.Contains(rec.Finding, "max_age_days") {
+		t.Fatalf("protected recommendation = %+v", rec)
+	}
+	if rec := kinds["skipped"]; rec.Plugin != "held" || rec.Suggestion != "add held to level_plugins.critical" {
+		t.Fatalf("skipped recommendation = %+v", rec)
+	}
+	if rec := kinds["disabled"]; rec.Plugin != "junk-files" || rec.Suggestion != "set enable.junk_files to true" {
+		t.Fatalf("disabled recommendation = %+v", rec)
+	}
+	if rec := kinds["uncovered"]; rec.Path != filepath.Join(root, "dataset") || rec.Bytes != 4096 {
+		t.Fatalf("uncovered recommendation = %+v, want the dataset directory outside the scratch plan", rec)
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "Recommendations:") || !strings.Contains(messages[0], filepath.Join(root, "dataset")) {
+		t.Fatalf("expected one recommendations notification, got %q", messages)
+	}
+
+	output.Reset()
+	if err := daemon.runOnce(context.Background(), monitor.LevelCritical); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if report := decodeCycleReport(t, output.Bytes()); len(report.Recommendations) != 0 || len(messages) != 1 {
+		t.Fatalf("expected the analysis to wait out policy.cooldown, got %+v", report.Recommendations)
+	}
+}
+
+func TestRunOnceSkipsRecommendationsBelowThreshold(t *testing.T) {
+	root := t.TempDir()
+	writeSizedFile(t, filepath.Join(root, "dataset", "blob"), 4096)
+
+	var output bytes.Buffer
+	daemon := newTestDaemon(t, &reportingPlugin{}, &output)
+	daemon.config.MonitoredMounts = []config.MountConfig{{Path: root, Label: "data"}}
+	daemon.config.Recommendations.Enabled = true
+	daemon.diskStats = sequenceDiskStats(t,
+		diskStats(10<<30, 1<<30, 90),
+		diskStats(10<<30, 1<<30, 90),
+		diskStats(10<<30, 5<<30, 50),
+	)
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if report := decodeCycleReport(t, output.Bytes()); len(report.Recommendations) != 0 {
+		t.Fatalf("expected no recommendations once under threshold, got %+v", report.Recommendations)
+	}
+}
+
+func TestConfigRecommendations(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Docker.PruneImagesAge = "72h"
+	cfg.Lima.CompactOffline = false
+	report := cycleReport{
+		Level:            "aggressive",
+		LevelClampedFrom: "critical",
+		Plugins: []pluginCycleReport{
+			{Name: "docker"},
+			{Name: "lima"},
+			{Name: "podman", SkipReason: "cooldown"},
+		},
+	}
+
+	var suggestions []string
+	for _, rec := range configRecommendations(cfg, report) {
+		suggestions = append(suggestions, rec.Suggestion)
+	}
+	got := strings.Join(suggestions, "\n")
+	for _, want := range []string{
+		"raise safety.max_level to critical",
+		"reduce docker.prune_images_age, or set docker.prune_ages.aggressive",
+		"enable lima.compact_offline",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("suggestions %q missing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "podman") {
+		t.Fatalf("podman did not run, so it should get no suggestion: %q", got)
+	}
+
+	cfg.Docker.PruneAges = map[string]string{"aggressive": "1h"}
+	for _, rec := range configRecommendations(cfg, report) {
+		if rec.Plugin == "docker" {
+			t.Fatalf("expected prune_ages.aggressive to silence the docker suggestion, got %+v", rec)
+		}
+	}
+}
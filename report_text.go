@@ -17,6 +17,9 @@ func writeTextReport(w io.Writer, report cycleReport) error {
 	if report.Level == monitor.LevelNone.String() {
 		mode = "monitor"
 	}
+	if report.FreeNow {
+		mode = "free-now " + mode
+	}
 
 	if _, err := fmt.Fprintf(w, "tinyland-cleanup %s report\n", mode); err != nil {
 		return err
@@ -31,6 +34,12 @@ func writeTextReport(w io.Writer, report cycleReport) error {
 	if report.ForcedLevel {
 		levelLine += " (forced)"
 	}
+	if report.LevelClampedFrom != "" {
+		levelLine += fmt.Sprintf(" (clamped from %s by safety.max_level)", report.LevelClampedFrom)
+	}
+	if report.HeavyDeferred {
+		levelLine += " (heavy plugins deferred: display asleep)"
+	}
 	if _, err := fmt.Fprintln(w, levelLine); err != nil {
 		return err
 	}
@@ -40,6 +49,16 @@ func writeTextReport(w io.Writer, report cycleReport) error {
 			return err
 		}
 	}
+	if report.WatchPath != "" {
+		if _, err := fmt.Fprintf(w, "triggered by watch: %s (%s)\n", report.WatchPath, formatByteCount(report.WatchBytes)); err != nil {
+			return err
+		}
+	}
+	if report.PriorityMount != "" {
+		if _, err := fmt.Fprintf(w, "priority mount: %s\n", report.PriorityMount); err != nil {
+			return err
+		}
+	}
 	if len(report.PluginFilter) > 0 {
 		if _, err := fmt.Fprintf(w, "plugin filter: %s\n", strings.Join(report.PluginFilter, ", ")); err != nil {
 			return err
@@ -63,7 +82,15 @@ func writeTextReport(w io.Writer, report cycleReport) error {
 		}
 	}
 
-	if report.TargetUsedPercent > 0 {
+	if report.TargetFreeGB > 0 {
+		if _, err := fmt.Fprintf(w, "target: >=%s free (<=%.1f%% used), deficit %s\n",
+			formatByteCount(int64(report.TargetFreeBytes)),
+			report.TargetMaxUsedPercent,
+			formatByteCount(report.TargetFreeDeficitBytes),
+		); err != nil {
+			return err
+		}
+	} else if report.TargetUsedPercent > 0 {
 		if _, err := fmt.Fprintf(w, "target: <=%d%% used, need %s free, deficit %s\n",
 			report.TargetUsedPercent,
 			formatByteCount(int64(report.TargetFreeBytes)),
@@ -122,6 +149,93 @@ func writeTextReport(w io.Writer, report cycleReport) error {
 			return err
 		}
 	}
+	if !report.DryRun && report.TotalCompressedBytesSaved > 0 {
+		if _, err := fmt.Fprintf(w, "compressed: %s saved by compressing files in place\n", formatByteCount(report.TotalCompressedBytesSaved)); err != nil {
+			return err
+		}
+	}
+
+	if len(report.EfficiencyRanking) > 0 {
+		if _, err := fmt.Fprintf(w, "efficiency: %s\n", strings.Join(report.EfficiencyRanking, " > ")); err != nil {
+			return err
+		}
+	}
+
+	if report.IneffectiveCritical {
+		if _, err := fmt.Fprintln(w, "alert: critical cleanup was ineffective; manual intervention needed"); err != nil {
+			return err
+		}
+	}
+	if report.NotificationCoalesced {
+		if _, err := fmt.Fprintln(w, "notification: coalesced into the ongoing critical streak"); err != nil {
+			return err
+		}
+	}
+	if report.FreedBytesDiscrepancies > 0 {
+		if _, err := fmt.Fprintf(w, "verify: %d plugins reported freed bytes that diverge from observed free space\n", report.FreedBytesDiscrepancies); err != nil {
+			return err
+		}
+	}
+	if len(report.DiskHogs) > 0 {
+		if _, err := fmt.Fprintln(w, "largest directories:"); err != nil {
+			return err
+		}
+		for _, hog := range report.DiskHogs {
+			if _, err := fmt.Fprintf(w, "- %s: %s\n", hog.Path, formatByteCount(hog.Bytes)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(report.Recommendations) > 0 {
+		if _, err := fmt.Fprintln(w, "recommendations:"); err != nil {
+			return err
+		}
+		for _, rec := range report.Recommendations {
+			if _, err := fmt.Fprintf(w, "- %s\n", recommendationLine(rec)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(report.Proactive) > 0 {
+		if _, err := fmt.Fprintln(w, "proactive:"); err != nil {
+			return err
+		}
+		for _, proactive := range report.Proactive {
+			outcome := fmt.Sprintf("freed %s across %d items", formatByteCount(proactive.BytesFreed), proactive.ItemsCleaned)
+			if proactive.SkipReason != "" {
+				outcome = "skipped (" + proactive.SkipReason + ")"
+			}
+			if _, err := fmt.Fprintf(w, "- %s: reclaimable %s, threshold %s, %s\n",
+				proactive.Plugin,
+				formatByteCount(proactive.ReclaimableBytes),
+				formatByteCount(proactive.ThresholdBytes),
+				outcome,
+			); err != nil {
+				return err
+			}
+			if proactive.Error != "" {
+				if _, err := fmt.Fprintf(w, "  error: %s\n", proactive.Error); err != nil {
+					return err
+				}
+			}
+			for _, host := range proactive.Hosts {
+				outcome := fmt.Sprintf("freed %s across %d items", formatByteCount(host.BytesFreed), host.ItemsCleaned)
+				if host.SkipReason != "" {
+					outcome = "skipped (" + host.SkipReason + ")"
+				}
+				if _, err := fmt.Fprintf(w, "  host %s: reclaimable %s, %s\n", host.Host, formatByteCount(host.ReclaimableBytes), outcome); err != nil {
+					return err
+				}
+				if host.Error != "" {
+					if _, err := fmt.Fprintf(w, "    error: %s\n", host.Error); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
 
 	if len(report.Plugins) == 0 {
 		return nil
@@ -146,6 +260,12 @@ func writeTextPluginReport(w io.Writer, plugin pluginCycleReport) error {
 	if plugin.SkipReason != "" {
 		status += " (" + plugin.SkipReason + ")"
 	}
+	if plugin.LevelClampedFrom != "" {
+		status += fmt.Sprintf(" (at %s, capped from %s by plugin_levels)", plugin.Level, plugin.LevelClampedFrom)
+	}
+	if plugin.Cancelled {
+		status += " (cancelled)"
+	}
 
 	if _, err := fmt.Fprintf(w, "- %s: %s\n", plugin.Name, status); err != nil {
 		return err
@@ -207,13 +327,65 @@ func writeTextPluginReport(w io.Writer, plugin pluginCycleReport) error {
 		}
 	}
 	if plugin.BytesFreed > 0 || plugin.ItemsCleaned > 0 {
-		if _, err := fmt.Fprintf(w, "  cleaned: %s across %d items\n",
+		if _, err := fmt.Fprintf(w, "  cleaned: %s across %d items%s\n",
 			formatByteCount(plugin.BytesFreed),
 			plugin.ItemsCleaned,
+			formatEfficiency(plugin),
+		); err != nil {
+			return err
+		}
+	}
+	if plugin.ProtectedSkippedItems > 0 {
+		if _, err := fmt.Fprintf(w, "  protected: skipped %d items (%s)\n",
+			plugin.ProtectedSkippedItems,
+			formatByteCount(plugin.ProtectedSkippedBytes),
 		); err != nil {
 			return err
 		}
 	}
+	if plugin.WalkPermissionSkips > 0 || plugin.WalkErrorSkips > 0 {
+		if _, err := fmt.Fprintf(w, "  unreadable: skipped %d paths due to permission errors (%d other errors)\n",
+			plugin.WalkPermissionSkips,
+			plugin.WalkErrorSkips,
+		); err != nil {
+			return err
+		}
+	}
+	if plugin.CompressedBytesSaved > 0 {
+		if _, err := fmt.Fprintf(w, "  compressed: %s saved by compressing files in place\n", formatByteCount(plugin.CompressedBytesSaved)); err != nil {
+			return err
+		}
+	}
+	for _, cleaned := range plugin.CleanedPaths {
+		if _, err := fmt.Fprintf(w, "  cleaned path: %s (%s)\n", cleaned.Path, formatByteCount(cleaned.BytesFreed)); err != nil {
+			return err
+		}
+	}
+	if plugin.DeepGCBytesFreed > 0 {
+		if _, err := fmt.Fprintf(w, "  deep build cache gc: %s beyond the normal prune\n", formatByteCount(plugin.DeepGCBytesFreed)); err != nil {
+			return err
+		}
+	}
+	if plugin.BytesGrown > 0 {
+		if _, err := fmt.Fprintf(w, "  grew during cleanup: %s\n", formatByteCount(plugin.BytesGrown)); err != nil {
+			return err
+		}
+	}
+	if check := plugin.FreedBytesCheck; check != nil && (check.Discrepancy != "" || check.Error != "") {
+		line := fmt.Sprintf("  verify: reported %s, observed %s on %s",
+			formatByteCount(check.ReportedBytes),
+			formatSignedByteCount(check.ObservedBytes),
+			check.Path,
+		)
+		if check.Error != "" {
+			line = fmt.Sprintf("  verify: unavailable (%s)", check.Error)
+		} else {
+			line += " (" + strings.ReplaceAll(check.Discrepancy, "_", "-") + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -314,3 +486,12 @@ func formatByteCount(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %s", value, units[unit])
 }
+
+// formatEfficiency renders a plugin's duration and bytes per second, or
+// nothing when the duration was not measured.
+func formatEfficiency(plugin pluginCycleReport) string {
+	if plugin.DurationSeconds <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" in %.1fs (%s/s)", plugin.DurationSeconds, formatByteCount(plugin.BytesPerSecond))
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// launchdLabel names the per-user LaunchAgent.
+	launchdLabel = "com.tinyland.cleanup"
+	// systemdUserUnit names the systemd --user unit.
+	systemdUserUnit = "tinyland-cleanup.service"
+	// serviceRestartSeconds throttles restarts after a crash on both platforms.
+	serviceRestartSeconds = 30
+)
+
+// launchdPath covers Homebrew, Nix, and system tool locations. LaunchAgents
+// start with only /usr/bin:/bin:/usr/sbin:/sbin, which hides docker, brew,
+// nix, and podman from the cleanup plugins.
+var launchdPath = []string{
+	"/opt/homebrew/bin",
+	"/usr/local/bin",
+	"/nix/var/nix/profiles/default/bin",
+	"/run/current-system/sw/bin",
+	"/usr/bin",
+	"/bin",
+	"/usr/sbin",
+	"/sbin",
+}
+
+// serviceFile is a generated service definition for the current user.
+type serviceFile struct {
+	Platform string `json:"platform"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	// Activate are the commands that load the service after it is written.
+	Activate []string `json:"activate"`
+	// Deactivate are the commands that stop the service before it is removed.
+	Deactivate []string `json:"deactivate"`
+}
+
+// serviceReport is the -install-service / -uninstall-service result.
+type serviceReport struct {
+	Action  string      `json:"action"`
+	Confirm bool        `json:"confirm"`
+	Changed bool        `json:"changed"`
+	Service serviceFile `json:"service"`
+}
+
+// renderServiceFile generates a launchd LaunchAgent on darwin or a systemd
+// --user unit on linux that runs binary in daemon mode with configPath.
+// Restarts happen only after a failure, throttled to serviceRestartSeconds.
+func renderServiceFile(goos, binary, configPath, home string) (serviceFile, error) {
+	if binary == "" || configPath == "" || home == "" {
+		return serviceFile{}, errors.New("service generation needs the binary, config, and home paths")
+	}
+	switch goos {
+	case "darwin":
+		return renderLaunchdAgent(binary, configPath, home), nil
+	case "linux":
+		return renderSystemdUserUnit(binary, configPath, home), nil
+	default:
+		return serviceFile{}, fmt.Errorf("service generation supports darwin and linux, not %s", goos)
+	}
+}
+
+func renderLaunchdAgent(binary, configPath, home string) serviceFile {
+	path := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+	logPath := filepath.Join(home, "Library", "Logs", "tinyland-cleanup", "launchd.log")
+	searchPath := append([]string{filepath.Join(home, ".nix-profile", "bin")}, launchdPath...)
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	writePlistString(&b, "Label", launchdLabel)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range []string{binary, "--daemon", "--config", configPath} {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	fmt.Fprintf(&b, "\t\t<key>HOME</key>\n\t\t<string>%s</string>\n", xmlEscape(home))
+	fmt.Fprintf(&b, "\t\t<key>PATH</key>\n\t\t<string>%s</string>\n", xmlEscape(strings.Join(searchPath, ":")))
+	b.WriteString("\t</dict>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	// Restart only after a crash; a clean exit stays stopped.
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	fmt.Fprintf(&b, "\t<key>ThrottleInterval</key>\n\t<integer>%d</integer>\n", serviceRestartSeconds)
+	writePlistString(&b, "ProcessType", "Background")
+	b.WriteString("\t<key>LowPriorityIO</key>\n\t<true/>\n")
+	writePlistString(&b, "StandardOutPath", logPath)
+	writePlistString(&b, "StandardErrorPath", logPath)
+	b.WriteString("</dict>\n</plist>\n")
+
+	domain := "gui/$(id -u)"
+	return serviceFile{
+		Platform: "launchd",
+		Path:     path,
+		Content:  b.String(),
+		Activate: []string{
+			"mkdir -p " + shellQuote(filepath.Dir(logPath)),
+			"launchctl bootstrap " + domain + " " + shellQuote(path),
+		},
+		Deactivate: []string{
+			"launchctl bootout " + domain + "/" + launchdLabel,
+		},
+	}
+}
+
+func renderSystemdUserUnit(binary, configPath, home string) serviceFile {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" || !filepath.IsAbs(configHome) {
+		configHome = filepath.Join(home, ".config")
+	}
+	path := filepath.Join(configHome, "systemd", "user", systemdUserUnit)
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=tinyland-cleanup disk-pressure daemon\n")
+	b.WriteString("Documentation=https://github.com/Jesssullivan/tinyland-cleanup\n")
+	fmt.Fprintf(&b, "ConditionPathExists=%s\n", systemdEscapePath(configPath))
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s --daemon --config %s\n", systemdQuote(binary), systemdQuote(configPath))
+	b.WriteString("Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=%ds\n", serviceRestartSeconds)
+	// The daemon also appends to log_file; the journal keeps startup failures.
+	b.WriteString("StandardOutput=journal\n")
+	b.WriteString("StandardError=journal\n")
+	b.WriteString("Nice=10\n")
+	b.WriteString("IOSchedulingClass=idle\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+
+	return serviceFile{
+		Platform: "systemd",
+		Path:     path,
+		Content:  b.String(),
+		Activate: []string{
+			"systemctl --user daemon-reload",
+			"systemctl --user enable --now " + systemdUserUnit,
+		},
+		Deactivate: []string{
+			"systemctl --user disable --now " + systemdUserUnit,
+		},
+	}
+}
+
+// applyService writes or removes the service file when confirm is set and
+// reports what it did or would do. Removing a missing file is not an error.
+func applyService(action string, service serviceFile, confirm bool) (serviceReport, error) {
+	report := serviceReport{Action: action, Confirm: confirm, Service: service}
+	if !confirm {
+		return report, nil
+	}
+	switch action {
+	case "install":
+		if err := os.MkdirAll(filepath.Dir(service.Path), 0755); err != nil {
+			return report, err
+		}
+		if err := os.WriteFile(service.Path, []byte(service.Content), 0644); err != nil {
+			return report, err
+		}
+		report.Changed = true
+	case "uninstall":
+		err := os.Remove(service.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, err
+		}
+		report.Changed = err == nil
+	default:
+		return report, fmt.Errorf("unknown service action %q", action)
+	}
+	return report, nil
+}
+
+func writeServiceReport(w io.Writer, output string, report serviceReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	service := report.Service
+	var b strings.Builder
+	switch {
+	case report.Action == "install" && !report.Confirm:
+		fmt.Fprintf(&b, "would write %s unit to %s:\n\n%s\n", service.Platform, service.Path, service.Content)
+		b.WriteString("re-run with -confirm to write it, then load it with:\n")
+		writeServiceCommands(&b, service.Activate)
+	case report.Action == "install":
+		fmt.Fprintf(&b, "wrote %s unit to %s\n", service.Platform, service.Path)
+		b.WriteString("load it with:\n")
+		writeServiceCommands(&b, service.Activate)
+	case !report.Confirm:
+		fmt.Fprintf(&b, "would remove %s unit %s\n", service.Platform, service.Path)
+		b.WriteString("stop it first, then re-run with -confirm:\n")
+		writeServiceCommands(&b, service.Deactivate)
+	case report.Changed:
+		fmt.Fprintf(&b, "removed %s unit %s\n", service.Platform, service.Path)
+		b.WriteString("if it is still loaded, stop it with:\n")
+		writeServiceCommands(&b, service.Deactivate)
+	default:
+		fmt.Fprintf(&b, "no %s unit at %s\n", service.Platform, service.Path)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeServiceCommands(b *strings.Builder, commands []string) {
+	for _, command := range commands {
+		fmt.Fprintf(b, "  %s\n", command)
+	}
+}
+
+func writePlistString(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// systemdQuote quotes an ExecStart argument when it contains spaces or quotes.
+func systemdQuote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return strings.ReplaceAll(arg, "%", "%%")
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + strings.ReplaceAll(arg, "%", "%%") + `"`
+}
+
+// systemdEscapePath escapes specifiers in a path used as a condition value.
+func systemdEscapePath(path string) string {
+	return strings.ReplaceAll(path, "%", "%%")
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$`!*?[]{}()<>|&;") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// runServiceCommand handles -install-service and -uninstall-service for the
+// running binary and configPath.
+func runServiceCommand(w io.Writer, output string, install, uninstall bool, configPath string, confirm bool) error {
+	if install && uninstall {
+		return errors.New("-install-service and -uninstall-service are mutually exclusive")
+	}
+	binary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate binary: %w", err)
+	}
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return fmt.Errorf("cannot resolve config path: %w", err)
+	}
+	// Services belong to the invoking user, not home_override or run_as_user.
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("cannot locate home directory: %w", err)
+	}
+	service, err := renderServiceFile(runtime.GOOS, binary, configPath, home)
+	if err != nil {
+		return err
+	}
+	action := "install"
+	if uninstall {
+		action = "uninstall"
+	}
+	report, err := applyService(action, service, confirm)
+	if err != nil {
+		return fmt.Errorf("failed to %s service: %w", action, err)
+	}
+	return writeServiceReport(w, output, report)
+}
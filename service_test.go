@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderServiceFileLaunchd(t *testing.T) {
+	service, err := renderServiceFile("darwin", "/opt/homebrew/bin/tinyland-cleanup", "/Users/jess/.config/tinyland-cleanup/config & more.yaml", "/Users/jess")
+	if err != nil {
+		t.Fatalf("render launchd agent: %v", err)
+	}
+	if service.Platform != "launchd" || service.Path != "/Users/jess/Library/LaunchAgents/com.tinyland.cleanup.plist" {
+		t.Fatalf("unexpected launchd service %s at %s", service.Platform, service.Path)
+	}
+	decoder := xml.NewDecoder(strings.NewReader(service.Content))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("plist is not well-formed XML: %v\n%s", err, service.Content)
+			}
+			break
+		}
+	}
+	for _, want := range []string{
+		"<string>/opt/homebrew/bin/tinyland-cleanup</string>\n\t\t<string>--daemon</string>\n\t\t<string>--config</string>",
+		"config &amp; more.yaml",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+		"<key>ThrottleInterval</key>\n\t<integer>30</integer>",
+		"<string>/Users/jess/Library/Logs/tinyland-cleanup/launchd.log</string>",
+		"/Users/jess/.nix-profile/bin:/opt/homebrew/bin:",
+	} {
+		if !strings.Contains(service.Content, want) {
+			t.Errorf("plist missing %q:\n%s", want, service.Content)
+		}
+	}
+	if len(service.Activate) == 0 || !strings.HasPrefix(service.Activate[len(service.Activate)-1], "launchctl bootstrap gui/$(id -u) ") {
+		t.Errorf("unexpected activate commands %q", service.Activate)
+	}
+}
+
+func TestRenderServiceFileSystemdUser(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	service, err := renderServiceFile("linux", "/home/jess/bin/tinyland-cleanup", "/home/jess/My Config/config.yaml", "/home/jess")
+	if err != nil {
+		t.Fatalf("render systemd unit: %v", err)
+	}
+	if service.Platform != "systemd" || service.Path != "/home/jess/.config/systemd/user/tinyland-cleanup.service" {
+		t.Fatalf("unexpected systemd service %s at %s", service.Platform, service.Path)
+	}
+	for _, want := range []string{
+		`ExecStart=/home/jess/bin/tinyland-cleanup --daemon --config "/home/jess/My Config/config.yaml"`,
+		"Restart=on-failure\nRestartSec=30s\n",
+		"StandardError=journal\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(service.Content, want) {
+			t.Errorf("unit missing %q:\n%s", want, service.Content)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/srv/xdg")
+	service, err = renderServiceFile("linux", "/usr/bin/tinyland-cleanup", "/etc/tinyland-cleanup/config.yaml", "/home/jess")
+	if err != nil {
+		t.Fatalf("render systemd unit: %v", err)
+	}
+	if service.Path != "/srv/xdg/systemd/user/tinyland-cleanup.service" {
+		t.Fatalf("expected XDG_CONFIG_HOME unit path, got %s", service.Path)
+	}
+}
+
+func TestRenderServiceFileRejectsUnsupportedPlatform(t *testing.T) {
+	if _, err := renderServiceFile("windows", "/bin/tinyland-cleanup", "/etc/config.yaml", "/home/jess"); err == nil {
+		t.Fatal("expected unsupported platform to be rejected")
+	}
+}
+
+func TestApplyServiceRequiresConfirm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "systemd", "user", systemdUserUnit)
+	service := serviceFile{Platform: "systemd", Path: path, Content: "[Unit]\n"}
+
+	report, err := applyService("install", service, false)
+	if err != nil || report.Changed {
+		t.Fatalf("unconfirmed install = %+v, %v", report, err)
+	}
+	if serviceFileExists(path) {
+		t.Fatal("expected unconfirmed install to leave the unit unwritten")
+	}
+	var output bytes.Buffer
+	if err := writeServiceReport(&output, "text", report); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if !strings.Contains(output.String(), "would write systemd unit to "+path) || !strings.Contains(output.String(), "-confirm") {
+		t.Fatalf("unexpected preview:\n%s", output.String())
+	}
+
+	if report, err = applyService("install", service, true); err != nil || !report.Changed {
+		t.Fatalf("confirmed install = %+v, %v", report, err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != service.Content {
+		t.Fatalf("installed unit = %q, %v", data, err)
+	}
+
+	if report, err = applyService("uninstall", service, false); err != nil || report.Changed || !serviceFileExists(path) {
+		t.Fatalf("unconfirmed uninstall = %+v, %v", report, err)
+	}
+	if report, err = applyService("uninstall", service, true); err != nil || !report.Changed || serviceFileExists(path) {
+		t.Fatalf("confirmed uninstall = %+v, %v", report, err)
+	}
+	if report, err = applyService("uninstall", service, true); err != nil || report.Changed {
+		t.Fatalf("uninstall of missing unit = %+v, %v", report, err)
+	}
+}
+
+func serviceFileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
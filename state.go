@@ -23,7 +23,9 @@ type pluginStateRecord struct {
 	LastLevelValue   int    `json:"last_level_value"`
 	LastBytesFreed   int64  `json:"last_bytes_freed"`
 	LastItemsCleaned int    `json:"last_items_cleaned"`
-	LastError        string `json:"last_error,omitempty"`
+	// LastBytesPerSecond is the last run's bytes freed per wall-clock second.
+	LastBytesPerSecond int64  `json:"last_bytes_per_second,omitempty"`
+	LastError          string `json:"last_error,omitempty"`
 }
 
 func newCleanupState() *cleanupState {
@@ -117,3 +119,17 @@ func (s *cleanupState) recordPluginRun(plugin string, level plugins.CleanupLevel
 	}
 	s.Plugins[plugin] = record
 }
+
+// recordEfficiency stores the bytes per second of the run just recorded for
+// plugin, for policy.order_by_efficiency.
+func (s *cleanupState) recordEfficiency(plugin string, bytesPerSecond int64) {
+	if s == nil {
+		return
+	}
+	record, ok := s.Plugins[plugin]
+	if !ok {
+		return
+	}
+	record.LastBytesPerSecond = bytesPerSecond
+	s.Plugins[plugin] = record
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// statusReport is a read-only snapshot of disk usage, the cleanup level it
+// would trigger, VM disks, and the enabled plugins.
+type statusReport struct {
+	Timestamp   string        `json:"timestamp"`
+	Level       string        `json:"level"`
+	MonitorPath string        `json:"monitor_path"`
+	Mounts      []mountReport `json:"mounts"`
+	VMs         []vmStatus    `json:"vms,omitempty"`
+	// Plugins are the enabled plugins in execution order.
+	Plugins []string `json:"plugins"`
+}
+
+// vmStatus is one VM disk reported by a plugin.
+type vmStatus struct {
+	Plugin string `json:"plugin"`
+	plugins.VMDiskStatus
+}
+
+// status measures the monitored mounts and asks every enabled VMDiskReporter
+// for its VMs, without cleaning.
+func (d *daemon) status(ctx context.Context) statusReport {
+	cfg := d.currentConfig()
+	assessment := d.assessMounts()
+	report := statusReport{
+		Timestamp:   d.currentTime().UTC().Format(time.RFC3339),
+		Level:       assessment.Level.String(),
+		MonitorPath: d.primaryMonitorPath(assessment),
+		Mounts:      assessment.Mounts,
+	}
+	for _, p := range executionOrder(filterEnabledPlugins(d.registry.GetEnabled(cfg), d.pluginFilter), cfg.PluginOrder) {
+		report.Plugins = append(report.Plugins, p.Name())
+		reporter, ok := p.(plugins.VMDiskReporter)
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+		for _, disk := range reporter.VMDisks(ctx, cfg) {
+			report.VMs = append(report.VMs, vmStatus{Plugin: p.Name(), VMDiskStatus: disk})
+		}
+	}
+	if d.redactor != nil {
+		report.MonitorPath = d.redactor.Path(report.MonitorPath)
+		for i := range report.Mounts {
+			report.Mounts[i].Path = d.redactor.Path(report.Mounts[i].Path)
+			report.Mounts[i].Error = d.redactor.String(report.Mounts[i].Error)
+		}
+		for i := range report.VMs {
+			report.VMs[i].DiskPath = d.redactor.Path(report.VMs[i].DiskPath)
+			report.VMs[i].Error = d.redactor.String(report.VMs[i].Error)
+		}
+	}
+	return report
+}
+
+func writeStatus(w io.Writer, output string, report statusReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if _, err := fmt.Fprintf(w, "tinyland-cleanup status: level %s\n\n", report.Level); err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MOUNT\tPATH\tUSED\tFREE\tLEVEL")
+	for _, mount := range report.Mounts {
+		if mount.Error != "" {
+			fmt.Fprintf(table, "%s\t%s\terror: %s\t\t\n", mount.Label, mount.Path, mount.Error)
+			continue
+		}
+		fmt.Fprintf(table, "%s\t%s\t%.1f%%\t%s\t%s\n", mount.Label, mount.Path, mount.UsedPercent, formatByteCount(int64(mount.FreeBytes)), mount.Level)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.VMs) > 0 {
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+		table = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "VM\tSTATUS\tGUEST USED\tHOST DISK")
+		for _, vm := range report.VMs {
+			guest := "-"
+			if vm.GuestTotalBytes > 0 {
+				guest = formatByteCount(vm.GuestUsedBytes) + " of " + formatByteCount(vm.GuestTotalBytes)
+			}
+			host := "-"
+			if vm.DiskPath != "" {
+				host = formatByteCount(vm.HostAllocatedBytes)
+			}
+			status := vm.Status
+			if vm.Error != "" {
+				status += " (" + vm.Error + ")"
+			}
+			fmt.Fprintf(table, "%s/%s\t%s\t%s\t%s\n", vm.Plugin, vm.Name, status, guest, host)
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nenabled plugins: %s\n", strings.Join(report.Plugins, ", "))
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+type vmReportingPlugin struct {
+	reportingPlugin
+	disks []plugins.VMDiskStatus
+}
+
+func (p *vmReportingPlugin) VMDisks(_ context.Context, _ *config.Config) []plugins.VMDiskStatus {
+	return p.disks
+}
+
+func TestStatusReportsDiskLevelAndVMsWithoutCleaning(t *testing.T) {
+	lima := &vmReportingPlugin{
+		reportingPlugin: reportingPlugin{name: "lima"},
+		disks: []plugins.VMDiskStatus{{
+			Name:               "colima",
+			Status:             "Running",
+			GuestTotalBytes:    100 << 30,
+			GuestUsedBytes:     20 << 30,
+			DiskPath:           "/Users/jess/.lima/colima/diffdisk",
+			HostAllocatedBytes: 60 << 30,
+		}},
+	}
+	cache := &reportingPlugin{name: "cache"}
+	daemon := newTestDaemonWithPlugins(t, &bytes.Buffer{}, lima, cache)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(1000, 120, 88))
+
+	report := daemon.status(context.Background())
+	if lima.called || cache.called {
+		t.Fatal("status must not run cleanup")
+	}
+	if report.Level != "moderate" {
+		t.Fatalf("level = %q, want moderate at 88%% used", report.Level)
+	}
+	if got := strings.Join(report.Plugins, ","); got != "lima,cache" {
+		t.Fatalf("plugins = %q, want lima,cache", got)
+	}
+	if len(report.VMs) != 1 || report.VMs[0].Plugin != "lima" || report.VMs[0].HostAllocatedBytes != 60<<30 {
+		t.Fatalf("unexpected VMs %+v", report.VMs)
+	}
+
+	var text bytes.Buffer
+	if err := writeStatus(&text, "text", report); err != nil {
+		t.Fatalf("writeStatus text: %v", err)
+	}
+	for _, want := range []string{"level moderate", "lima/colima", "20.0 GiB of 100.0 GiB", "60.0 GiB", "enabled plugins: lima, cache"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("text status missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := writeStatus(&encoded, "json", report); err != nil {
+		t.Fatalf("writeStatus json: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode status json: %v", err)
+	}
+	vms, _ := decoded["vms"].([]any)
+	if len(vms) != 1 || vms[0].(map[string]any)["guest_used_bytes"] != float64(20<<30) {
+		t.Fatalf("unexpected json vms %v", decoded["vms"])
+	}
+}
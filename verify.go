@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// freedBytesCheck compares a plugin's reported BytesFreed with the free-space
+// delta observed on the volume it deletes from.
+type freedBytesCheck struct {
+	Path            string `json:"path"`
+	ReportedBytes   int64  `json:"reported_bytes"`
+	ObservedBytes   int64  `json:"observed_bytes"`
+	DivergenceBytes int64  `json:"divergence_bytes"`
+	ToleranceBytes  int64  `json:"tolerance_bytes"`
+	// Discrepancy is "over_reported" or "under_reported" when the divergence
+	// exceeds the tolerance.
+	Discrepancy string `json:"discrepancy,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// freedBytesVerification holds the before snapshot for one plugin run.
+type freedBytesVerification struct {
+	path   string
+	before *monitor.DiskStats
+	err    error
+}
+
+// startFreedBytesCheck snapshots free space on the plugin's volume when
+// safety.verify_freed_bytes is set and the plugin deletes files directly.
+// It returns nil when the plugin is not verified.
+func (d *daemon) startFreedBytesCheck(p plugins.Plugin, monitorPath string) *freedBytesVerification {
+	cfg := d.currentConfig()
+	if !cfg.Safety.VerifyFreedBytes {
+		return nil
+	}
+	deleter, ok := p.(plugins.VolumeDeleter)
+	if !ok {
+		return nil
+	}
+	path := expandPathHome(deleter.FreedBytesVolume(cfg))
+	if path == "" {
+		path = monitorPath
+	}
+	before, err := d.getDiskStats(path)
+	return &freedBytesVerification{path: path, before: before, err: err}
+}
+
+// finishFreedBytesCheck measures the volume again and compares the observed
+// delta with the plugin's reported bytes.
+func (d *daemon) finishFreedBytesCheck(v *freedBytesVerification, pluginName string, result plugins.CleanupResult) *freedBytesCheck {
+	cfg := d.currentConfig()
+	if v == nil {
+		return nil
+	}
+	// Compression in place frees space on the volume too.
+	check := &freedBytesCheck{Path: v.path, ReportedBytes: result.BytesFreed + result.CompressedBytesSaved}
+	if v.err != nil {
+		check.Error = v.err.Error()
+		return check
+	}
+	after, err := d.getDiskStats(v.path)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.ObservedBytes = int64(after.Free) - int64(v.before.Free)
+	check.DivergenceBytes = check.ReportedBytes - check.ObservedBytes
+	check.ToleranceBytes = freedBytesTolerance(check.ReportedBytes, cfg.Safety.VerifyToleranceMB, cfg.Safety.VerifyTolerancePercent)
+	switch {
+	case check.DivergenceBytes > check.ToleranceBytes:
+		check.Discrepancy = "over_reported"
+	case -check.DivergenceBytes > check.ToleranceBytes:
+		check.Discrepancy = "under_reported"
+	}
+	if check.Discrepancy != "" {
+		d.logger.Warn("plugin freed-byte report diverges from observed free space",
+			"plugin", pluginName,
+			"path", check.Path,
+			"discrepancy", check.Discrepancy,
+			"reported_mb", check.ReportedBytes/(1024*1024),
+			"observed_mb", check.ObservedBytes/(1024*1024),
+			"tolerance_mb", check.ToleranceBytes/(1024*1024),
+		)
+	}
+	return check
+}
+
+// freedBytesTolerance is the larger of the absolute and proportional
+// tolerances, so small cleanups are not flagged for unrelated disk churn.
+func freedBytesTolerance(reported int64, toleranceMB, tolerancePercent int) int64 {
+	tolerance := int64(toleranceMB) * 1024 * 1024
+	if reported > 0 && tolerancePercent > 0 {
+		if proportional := reported * int64(tolerancePercent) / 100; proportional > tolerance {
+			tolerance = proportional
+		}
+	}
+	return tolerance
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+type volumeDeletingPlugin struct {
+	reportingPlugin
+	volume string
+}
+
+func (p *volumeDeletingPlugin) FreedBytesVolume(*config.Config) string {
+	return p.volume
+}
+
+func TestFreedBytesTolerance(t *testing.T) {
+	const mb = 1024 * 1024
+	if got := freedBytesTolerance(10*mb, 64, 20); got != 64*mb {
+		t.Fatalf("small cleanup tolerance = %d, want absolute 64MB", got)
+	}
+	if got := freedBytesTolerance(1000*mb, 64, 20); got != 200*mb {
+		t.Fatalf("large cleanup tolerance = %d, want 20%% of reported", got)
+	}
+	if got := freedBytesTolerance(-mb, 0, 20); got != 0 {
+		t.Fatalf("negative report tolerance = %d, want 0", got)
+	}
+}
+
+func TestRunOnceVerifiesFreedBytes(t *testing.T) {
+	const gb = 1024 * 1024 * 1024
+	for _, tt := range []struct {
+		name            string
+		reported        int64
+		freeAfter       uint64
+		wantDiscrepancy string
+	}{
+		{"matches observed delta", 2 * gb, 12 * gb, ""},
+		{"within tolerance of concurrent writes", 2 * gb, 11*gb + 700*1024*1024, ""},
+		{"logical size overstates reclaim", 4 * gb, 11 * gb, "over_reported"},
+		{"reclaim missing from report", 0, 11 * gb, "under_reported"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			plugin := &volumeDeletingPlugin{
+				reportingPlugin: reportingPlugin{result: plugins.CleanupResult{BytesFreed: tt.reported}},
+				volume:          "/data",
+			}
+			daemon := newTestDaemon(t, plugin, &output)
+			daemon.config.Safety.VerifyFreedBytes = true
+			daemon.diskStats = func(path string) (*monitor.DiskStats, error) {
+				if path == "/data" && plugin.called {
+					return diskStats(100*gb, tt.freeAfter, 88), nil
+				}
+				return diskStats(100*gb, 10*gb, 90), nil
+			}
+
+			if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+				t.Fatalf("runOnce returned error: %v", err)
+			}
+
+			report := decodeCycleReport(t, output.Bytes())
+			check := report.Plugins[0].FreedBytesCheck
+			if check == nil {
+				t.Fatal("expected freed_bytes_check for a volume-deleting plugin")
+			}
+			if check.Path != "/data" || check.ReportedBytes != tt.reported || check.ObservedBytes != int64(tt.freeAfter)-10*gb {
+				t.Fatalf("unexpected check %+v", check)
+			}
+			if check.Discrepancy != tt.wantDiscrepancy {
+				t.Fatalf("Discrepancy = %q, want %q", check.Discrepancy, tt.wantDiscrepancy)
+			}
+			wantCount := 0
+			if tt.wantDiscrepancy != "" {
+				wantCount = 1
+			}
+			if report.FreedBytesDiscrepancies != wantCount {
+				t.Fatalf("FreedBytesDiscrepancies = %d, want %d", report.FreedBytesDiscrepancies, wantCount)
+			}
+		})
+	}
+}
+
+func TestRunOnceSkipsFreedBytesVerification(t *testing.T) {
+	var output bytes.Buffer
+	commandPlugin := &reportingPlugin{name: "command", result: plugins.CleanupResult{BytesFreed: 1 << 30}}
+	deleter := &volumeDeletingPlugin{reportingPlugin: reportingPlugin{name: "deleter"}}
+	daemon := newTestDaemonWithPlugins(t, &output, commandPlugin, deleter)
+	daemon.diskStats = sequenceDiskStats(t, diskStats(100<<30, 10<<30, 90))
+
+	if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	if strings.Contains(output.String(), "freed_bytes_check") {
+		t.Fatalf("verification should be off by default: %s", output.String())
+	}
+
+	output.Reset()
+	daemon.config.Safety.VerifyFreedBytes = true
+	if err := daemon.runOnce(context.Background(), monitor.LevelModerate); err != nil {
+		t.Fatalf("runOnce returned error: %v", err)
+	}
+	report := decodeCycleReport(t, output.Bytes())
+	for _, plugin := range report.Plugins {
+		switch plugin.Name {
+		case "command":
+			if plugin.FreedBytesCheck != nil {
+				t.Fatalf("plugins that do not delete from a volume should not be verified: %+v", plugin.FreedBytesCheck)
+			}
+		case "deleter":
+			if plugin.FreedBytesCheck == nil || plugin.FreedBytesCheck.Path != report.MonitorPath {
+				t.Fatalf("expected deleter to be verified on the monitor path, got %+v", plugin.FreedBytesCheck)
+			}
+		}
+	}
+}
+
+func TestTextReportShowsFreedBytesDiscrepancy(t *testing.T) {
+	var output bytes.Buffer
+	report := cycleReport{
+		Level:                   monitor.LevelModerate.String(),
+		FreedBytesDiscrepancies: 1,
+		Plugins: []pluginCycleReport{{
+			Name:     "cache",
+			WouldRun: true,
+			FreedBytesCheck: &freedBytesCheck{
+				Path:          "/Users/me",
+				ReportedBytes: 4 << 30,
+				ObservedBytes: 1 << 30,
+				Discrepancy:   "over_reported",
+			},
+		}},
+	}
+	if err := writeTextReport(&output, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"verify: 1 plugins reported", "verify: reported 4.0 GiB, observed +1.0 GiB on /Users/me (over-reported)"} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("text report missing %q:\n%s", want, output.String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+// versionReport is the -version -output json document. The OS and the
+// plugin list explain most "plugin missing" reports: darwin-only plugins are
+// compiled into darwin binaries only.
+type versionReport struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	Date      string   `json:"date"`
+	GoVersion string   `json:"go_version"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	BuildTags []string `json:"build_tags"`
+	Plugins   []string `json:"plugins"`
+}
+
+func newVersionReport(registry *plugins.Registry) versionReport {
+	report := versionReport{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		BuildTags: []string{},
+		Plugins:   []string{},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		report.BuildTags = buildTags(info.Settings)
+	}
+	for _, plugin := range registry.GetAll() {
+		report.Plugins = append(report.Plugins, plugin.Name())
+	}
+	sort.Strings(report.Plugins)
+	return report
+}
+
+// buildTags returns the -tags the binary was built with. Builds that do not
+// stamp build settings, such as Bazel's, report none.
+func buildTags(settings []debug.BuildSetting) []string {
+	tags := []string{}
+	for _, setting := range settings {
+		if setting.Key != "-tags" {
+			continue
+		}
+		for _, tag := range strings.Split(setting.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func writeVersion(w io.Writer, output string, report versionReport) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	_, err := fmt.Fprintf(w, "tinyland-cleanup %s (%s) built %s\n", report.Version, report.Commit, report.Date)
+	return err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+func TestWriteVersionJSON(t *testing.T) {
+	registry := plugins.NewRegistry()
+	registerPlugins(registry)
+
+	var out bytes.Buffer
+	if err := writeVersion(&out, "json", newVersionReport(registry)); err != nil {
+		t.Fatal(err)
+	}
+	var report map[string]any
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if report["os"] != runtime.GOOS || report["arch"] != runtime.GOARCH || report["go_version"] != runtime.Version() {
+		t.Fatalf("unexpected platform fields: %v", report)
+	}
+	if _, ok := report["build_tags"].([]any); !ok {
+		t.Fatalf("build_tags should be a list, got %v", report["build_tags"])
+	}
+	names, _ := report["plugins"].([]any)
+	if !slices.Contains(names, any("docker")) || len(names) != len(registry.GetAll()) {
+		t.Fatalf("expected every registered plugin, got %v", names)
+	}
+}
+
+func TestWriteVersionText(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeVersion(&out, "text", versionReport{Version: "1.2.3", Commit: "abc", Date: "today"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "tinyland-cleanup 1.2.3 (abc) built today" {
+		t.Fatalf("unexpected text version: %q", got)
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	settings := []debug.BuildSetting{
+		{Key: "-compiler", Value: "gc"},
+		{Key: "-tags", Value: "netgo, osusergo"},
+		{Key: "GOOS", Value: "linux"},
+	}
+	if got, want := buildTags(settings), []string{"netgo", "osusergo"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildTags = %v, want %v", got, want)
+	}
+	if got := buildTags(nil); got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty, non-nil list, got %#v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+const (
+	defaultWatchDebounce = 5 * time.Second
+	defaultWatchPoll     = 30 * time.Second
+)
+
+// dirWatcher signals on Events whenever a watched directory tree changes.
+// Events is closed when the watch fails, with the cause in Err.
+type dirWatcher interface {
+	Events() <-chan struct{}
+	Err() error
+	Close() error
+}
+
+// watchSpec is a validated watch_dirs entry.
+type watchSpec struct {
+	path     string
+	maxBytes int64
+	level    monitor.CleanupLevel
+	plugins  []string
+	debounce time.Duration
+	poll     time.Duration
+}
+
+// watchDirSpecs validates watch_dirs and applies defaults.
+func watchDirSpecs(cfg *config.Config, registry *plugins.Registry) ([]watchSpec, error) {
+	specs := make([]watchSpec, 0, len(cfg.WatchDirs))
+	for i, dir := range cfg.WatchDirs {
+		if dir.Path == "" {
+			return nil, fmt.Errorf("watch_dirs[%d].path is required", i)
+		}
+		if dir.MaxGB <= 0 {
+			return nil, fmt.Errorf("watch_dirs[%d].max_gb must be positive", i)
+		}
+		level := monitor.LevelModerate
+		if dir.Level != "" {
+			if level = parseLevel(dir.Level); level == monitor.LevelNone {
+				return nil, fmt.Errorf("watch_dirs[%d].level %q must be warning, moderate, aggressive, or critical", i, dir.Level)
+			}
+		}
+		if err := validatePluginFilter(dir.Plugins, registry); err != nil {
+			return nil, fmt.Errorf("watch_dirs[%d].plugins: %w", i, err)
+		}
+		spec := watchSpec{
+			path:     expandPathHome(dir.Path),
+			maxBytes: int64(dir.MaxGB * 1024 * 1024 * 1024),
+			level:    level,
+			plugins:  dir.Plugins,
+			debounce: defaultWatchDebounce,
+			poll:     defaultWatchPoll,
+		}
+		if dir.DebounceSeconds > 0 {
+			spec.debounce = time.Duration(dir.DebounceSeconds) * time.Second
+		}
+		if dir.PollSeconds > 0 {
+			spec.poll = time.Duration(dir.PollSeconds) * time.Second
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// startDirWatchers launches one watch loop per watch_dirs entry. The loops
+// stop when ctx is cancelled.
+func (d *daemon) startDirWatchers(ctx context.Context) error {
+	specs, err := watchDirSpecs(d.currentConfig(), d.registry)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		go d.watchDir(ctx, spec)
+	}
+	return nil
+}
+
+// watchDir reacts to filesystem events under spec.path. The first event
+// starts a debounce window; when it closes the directory is sized, and if it
+// exceeds max_gb a scoped cleanup runs. Events during the window are folded
+// into the same check, so a continuous burst is still checked every window.
+// After a triggered cleanup the next check waits at least spec.poll, so a
+// directory the plugins cannot shrink does not trigger back-to-back cycles.
+// When the watch cannot be established, or fails later, the directory is
+// polled every spec.poll instead.
+func (d *daemon) watchDir(ctx context.Context, spec watchSpec) {
+	var events <-chan struct{}
+	var poll <-chan time.Time
+	startPolling := func(reason error) {
+		d.logger.Warn("cannot watch directory; polling it instead",
+			"path", spec.path,
+			"poll_interval", spec.poll,
+			"error", reason,
+		)
+		ticker := time.NewTicker(spec.poll)
+		go func() {
+			<-ctx.Done()
+			ticker.Stop()
+		}()
+		poll = ticker.C
+	}
+
+	watcher, err := newDirWatcher(spec.path)
+	if err != nil {
+		startPolling(err)
+	} else {
+		defer watcher.Close()
+		events = watcher.Events()
+		d.logger.Info("watching directory for growth", "path", spec.path, "max_bytes", spec.maxBytes, "level", spec.level.String())
+	}
+
+	var debounce *time.Timer
+	var fire <-chan time.Time
+	var quietUntil time.Time
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				startPolling(watcher.Err())
+				continue
+			}
+			if fire == nil {
+				delay := spec.debounce
+				if quiet := time.Until(quietUntil); quiet > delay {
+					delay = quiet
+				}
+				debounce = time.NewTimer(delay)
+				fire = debounce.C
+			}
+		case <-fire:
+			fire = nil
+			if d.checkWatchedDir(ctx, spec) {
+				quietUntil = time.Now().Add(spec.poll)
+			}
+		case <-poll:
+			d.checkWatchedDir(ctx, spec)
+		}
+	}
+}
+
+// checkWatchedDir sizes spec.path and runs the scoped cleanup when it is
+// over the limit. It reports whether a cleanup ran.
+func (d *daemon) checkWatchedDir(ctx context.Context, spec watchSpec) bool {
+	dev, ok := pathDevice(spec.path)
+	if !ok {
+		return false
+	}
+	size := sameDeviceSize(ctx, spec.path, dev)
+	if size <= spec.maxBytes || ctx.Err() != nil {
+		return false
+	}
+	d.logger.Info("watched directory over limit; running scoped cleanup",
+		"path", spec.path,
+		"size_bytes", size,
+		"max_bytes", spec.maxBytes,
+		"level", spec.level.String(),
+		"plugins", spec.plugins,
+	)
+	filter := spec.plugins
+	if len(filter) == 0 {
+		filter = d.pluginFilter
+	}
+	report := d.runScopedCycle(ctx, spec.level, d.dryRun, cycleScope{plugins: filter, watchPath: spec.path, watchBytes: size})
+	if err := d.writeReport(report); err != nil {
+		d.logger.Error("failed to write watch cleanup report", "path", spec.path, "error", err)
+	}
+	return true
+}
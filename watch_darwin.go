@@ -0,0 +1,135 @@
+//go:build darwin
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// kqueueWatchLimit caps the directories one watcher opens, since kqueue
+// holds a file descriptor per watched directory.
+const kqueueWatchLimit = 256
+
+const kqueueWatchFlags = syscall.NOTE_WRITE | syscall.NOTE_EXTEND | syscall.NOTE_ATTRIB | syscall.NOTE_DELETE | syscall.NOTE_RENAME
+
+// kqueueWatcher watches a directory and its immediate subdirectories with
+// kqueue vnode events. FSEvents would see the whole tree but needs cgo.
+type kqueueWatcher struct {
+	kq     int
+	fds    []int
+	events chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	err    error
+}
+
+// newDirWatcher watches root and its immediate subdirectories. It fails
+// when the process runs out of file descriptors, so the caller can fall back
+// to polling.
+func newDirWatcher(root string) (dirWatcher, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, kqueueError(err)
+	}
+	w := &kqueueWatcher{kq: kq, events: make(chan struct{}, 1), done: make(chan struct{})}
+
+	dirs := []string{root}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		w.release()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && len(dirs) < kqueueWatchLimit {
+			dirs = append(dirs, filepath.Join(root, entry.Name()))
+		}
+	}
+
+	changes := make([]syscall.Kevent_t, 0, len(dirs))
+	for _, dir := range dirs {
+		fd, err := syscall.Open(dir, syscall.O_EVTONLY|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			if dir != root && errors.Is(err, syscall.ENOENT) {
+				continue
+			}
+			w.release()
+			return nil, kqueueError(err)
+		}
+		w.fds = append(w.fds, fd)
+		var change syscall.Kevent_t
+		syscall.SetKevent(&change, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR)
+		change.Fflags = kqueueWatchFlags
+		changes = append(changes, change)
+	}
+	if _, err := syscall.Kevent(kq, changes, nil, nil); err != nil {
+		w.release()
+		return nil, kqueueError(err)
+	}
+	go w.readLoop()
+	return w, nil
+}
+
+func (w *kqueueWatcher) Events() <-chan struct{} { return w.events }
+
+func (w *kqueueWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *kqueueWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+// readLoop waits for vnode events with a short timeout so Close is noticed,
+// and releases the descriptors when it exits.
+func (w *kqueueWatcher) readLoop() {
+	defer close(w.events)
+	defer w.release()
+	buf := make([]syscall.Kevent_t, 64)
+	timeout := syscall.NsecToTimespec(int64(500 * 1e6))
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		n, err := syscall.Kevent(w.kq, nil, buf, &timeout)
+		if err != nil {
+			if errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			w.mu.Lock()
+			w.err = err
+			w.mu.Unlock()
+			return
+		}
+		if n > 0 {
+			select {
+			case w.events <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (w *kqueueWatcher) release() {
+	for _, fd := range w.fds {
+		syscall.Close(fd)
+	}
+	syscall.Close(w.kq)
+}
+
+func kqueueError(err error) error {
+	if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+		return fmt.Errorf("kqueue watch descriptors exhausted (raise the open file limit): %w", err)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyWatchMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_TO | syscall.IN_DELETE | syscall.IN_ONLYDIR
+
+// inotifyWatcher watches a directory tree with one inotify watch per
+// directory, adding watches for directories created after it starts.
+type inotifyWatcher struct {
+	file   *os.File
+	fd     int
+	events chan struct{}
+	// dirs maps watch descriptors to directories; only readLoop touches it
+	// after construction.
+	dirs map[int32]string
+	mu   sync.Mutex
+	err  error
+}
+
+// newDirWatcher watches root and every directory below it. It fails with a
+// hint when fs.inotify.max_user_watches is exhausted, so the caller can fall
+// back to polling.
+func newDirWatcher(root string) (dirWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, inotifyError(err)
+	}
+	w := &inotifyWatcher{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		fd:     fd,
+		events: make(chan struct{}, 1),
+		dirs:   make(map[int32]string),
+	}
+	if err := w.addTree(root); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.readLoop()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan struct{} { return w.events }
+
+func (w *inotifyWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *inotifyWatcher) Close() error {
+	return w.file.Close()
+}
+
+func (w *inotifyWatcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyWatchMask)
+		if err != nil {
+			if path != root && errors.Is(err, syscall.ENOENT) {
+				return nil
+			}
+			return inotifyError(err)
+		}
+		w.dirs[int32(wd)] = path
+		return nil
+	})
+}
+
+// readLoop signals every batch of events. Directories created inside the
+// tree are watched as they appear; failing to watch one ends the watcher,
+// because the tree is no longer fully covered.
+func (w *inotifyWatcher) readLoop() {
+	defer close(w.events)
+	var buf [64 * 1024]byte
+	for {
+		n, err := w.file.Read(buf[:])
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.fail(err)
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				continue
+			}
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				if dir, ok := w.dirs[event.Wd]; ok {
+					err := w.addTree(filepath.Join(dir, cString(nameBytes)))
+					if err != nil && !errors.Is(err, fs.ErrNotExist) {
+						w.fail(err)
+						return
+					}
+				}
+			}
+		}
+		select {
+		case w.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *inotifyWatcher) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func inotifyError(err error) error {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+		return fmt.Errorf("inotify watches exhausted (raise fs.inotify.max_user_watches or max_user_instances): %w", err)
+	}
+	return err
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// newDirWatcher is unavailable on this platform; watched directories are
+// polled instead.
+func newDirWatcher(string) (dirWatcher, error) {
+	return nil, errors.New("directory watching is not supported on this platform")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jesssullivan/tinyland-cleanup/config"
+	"github.com/Jesssullivan/tinyland-cleanup/monitor"
+	"github.com/Jesssullivan/tinyland-cleanup/plugins"
+)
+
+type signalingPlugin struct {
+	reportingPlugin
+	ran chan plugins.CleanupLevel
+}
+
+func (p *signalingPlugin) Cleanup(_ context.Context, level plugins.CleanupLevel, _ *config.Config, _ *slog.Logger) plugins.CleanupResult {
+	p.ran <- level
+	return plugins.CleanupResult{}
+}
+
+func TestWatchDirSpecs(t *testing.T) {
+	daemon := newTestDaemonWithPlugins(t, io.Discard, &reportingPlugin{name: "scratch"})
+	daemon.config.WatchDirs = []config.WatchDirConfig{
+		{Path: "/ci/scratch", MaxGB: 2},
+		{Path: "/ci/cache", MaxGB: 0.5, Level: "critical", Plugins: []string{"scratch"}, DebounceSeconds: 1, PollSeconds: 10},
+	}
+	specs, err := watchDirSpecs(daemon.config, daemon.registry)
+	if err != nil {
+		t.Fatalf("watchDirSpecs failed: %v", err)
+	}
+	if specs[0].level != monitor.LevelModerate || specs[0].maxBytes != 2<<30 || specs[0].debounce != defaultWatchDebounce || specs[0].poll != defaultWatchPoll {
+		t.Fatalf("unexpected defaults %+v", specs[0])
+	}
+	if specs[1].level != monitor.LevelCritical || specs[1].maxBytes != 512<<20 || specs[1].debounce != time.Second || specs[1].poll != 10*time.Second {
+		t.Fatalf("unexpected overrides %+v", specs[1])
+	}
+
+	for _, bad := range []config.WatchDirConfig{
+		{MaxGB: 1},
+		{Path: "/ci", MaxGB: 0},
+		{Path: "/ci", MaxGB: 1, Level: "extreme"},
+		{Path: "/ci", MaxGB: 1, Plugins: []string{"missing"}},
+	} {
+		daemon.config.WatchDirs = []config.WatchDirConfig{bad}
+		if _, err := watchDirSpecs(daemon.config, daemon.registry); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestWatchDirTriggersScopedCleanupOnGrowth(t *testing.T) {
+	dir := t.TempDir()
+	watched := &signalingPlugin{reportingPlugin: reportingPlugin{name: "scratch"}, ran: make(chan plugins.CleanupLevel, 4)}
+	other := &signalingPlugin{reportingPlugin: reportingPlugin{name: "other"}, ran: make(chan plugins.CleanupLevel, 4)}
+	daemon := newTestDaemonWithPlugins(t, io.Discard, watched, other)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	// The host target is met; the watch limit alone triggers cleanup.
+	daemon.diskStats = func(string) (*monitor.DiskStats, error) { return diskStats(1000, 900, 10), nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go daemon.watchDir(ctx, watchSpec{
+		path:     dir,
+		maxBytes: 1024,
+		level:    monitor.LevelAggressive,
+		plugins:  []string{"scratch"},
+		debounce: 20 * time.Millisecond,
+		poll:     time.Hour,
+	})
+
+	// Writing below the limit must not trigger; give the watcher time to
+	// settle before growing past it in a new subdirectory.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "small"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.MkdirAll(filepath.Join(dir, "job"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "job", "artifact"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case level := <-watched.ran:
+		if level != plugins.LevelAggressive {
+			t.Fatalf("expected aggressive scoped cleanup, got %s", level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected growth past max to trigger cleanup")
+	}
+	select {
+	case <-other.ran:
+		t.Fatal("expected cleanup scoped to the configured plugins")
+	default:
+	}
+}
+
+func TestWatchDirFallsBackToPolling(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not-yet")
+	watched := &signalingPlugin{reportingPlugin: reportingPlugin{name: "scratch"}, ran: make(chan plugins.CleanupLevel, 4)}
+	daemon := newTestDaemonWithPlugins(t, io.Discard, watched)
+	daemon.config.Policy.StateFile = filepath.Join(t.TempDir(), "state.json")
+	// The host target is met; the watch limit alone triggers cleanup.
+	daemon.diskStats = func(string) (*monitor.DiskStats, error) { return diskStats(1000, 900, 10), nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go daemon.watchDir(ctx, watchSpec{
+		path:     dir,
+		maxBytes: 1024,
+		level:    monitor.LevelModerate,
+		debounce: time.Hour,
+		poll:     20 * time.Millisecond,
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "artifact"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watched.ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the polling fallback to trigger cleanup")
+	}
+}